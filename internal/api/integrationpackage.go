@@ -4,6 +4,9 @@ import (
 	"encoding/json"
 	"fmt"
 	"os"
+	"strconv"
+	"strings"
+	"time"
 
 	"github.com/engswee/flashpipe/internal/httpclnt"
 	"github.com/go-errors/errors"
@@ -34,9 +37,10 @@ type PackageSingleData struct {
 type artifactData struct {
 	Root struct {
 		Results []struct {
-			Id      string `json:"Id"`
-			Name    string `json:"Name"`
-			Version string `json:"Version"`
+			Id         string `json:"Id"`
+			Name       string `json:"Name"`
+			Version    string `json:"Version"`
+			ModifiedAt string `json:"ModifiedAt"`
 		} `json:"results"`
 	} `json:"d"`
 }
@@ -55,6 +59,7 @@ type ArtifactDetails struct {
 	IsDraft      bool
 	Version      string
 	ArtifactType string
+	ModifiedAt   time.Time // zero when not provided by the tenant
 }
 
 // NewIntegrationPackage returns an initialised IntegrationPackage instance.
@@ -155,6 +160,7 @@ func (ip *IntegrationPackage) GetArtifactsData(id string, artifactType string) (
 			IsDraft:      draft,
 			Version:      result.Version,
 			ArtifactType: artifactType,
+			ModifiedAt:   ParseODataTime(result.ModifiedAt),
 		})
 	}
 	return details, nil
@@ -252,3 +258,14 @@ func GetPackageDetails(file string) (*PackageSingleData, error) {
 	}
 	return jsonData, nil
 }
+
+// ParseODataTime parses a timestamp in epoch milliseconds, either plain ("1700000000000")
+// or in OData V2 format ("/Date(1700000000000)/"). A zero time is returned if it cannot be parsed.
+func ParseODataTime(value string) time.Time {
+	value = strings.TrimSuffix(strings.TrimPrefix(value, "/Date("), ")/")
+	millis, err := strconv.ParseInt(value, 10, 64)
+	if err != nil {
+		return time.Time{}
+	}
+	return time.UnixMilli(millis)
+}
@@ -9,6 +9,7 @@ import (
 	"os"
 	"slices"
 	"testing"
+	"time"
 )
 
 type PackageSuite struct {
@@ -162,3 +163,11 @@ func tearDownPackage(t *testing.T, packageId string, exe *httpclnt.HTTPExecuter)
 		}
 	}
 }
+
+func TestParseODataTime(t *testing.T) {
+	expected := time.UnixMilli(1700000000000)
+	assert.Equal(t, expected, ParseODataTime("1700000000000"))
+	assert.Equal(t, expected, ParseODataTime("/Date(1700000000000)/"))
+	assert.True(t, ParseODataTime("").IsZero())
+	assert.True(t, ParseODataTime("not a date").IsZero())
+}
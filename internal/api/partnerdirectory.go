@@ -1,8 +1,10 @@
 package api
 
 import (
-	"bytes"
+	"crypto/sha256"
+	"encoding/hex"
 	"encoding/json"
+	"errors"
 	"fmt"
 	"io"
 	"net/http"
@@ -17,18 +19,57 @@ const (
 	DefaultBatchSize = 90
 )
 
+// ErrConflict is returned when a parameter update is rejected because the parameter was
+// changed on the tenant since it was read (HTTP 412 Precondition Failed)
+var ErrConflict = errors.New("parameter was changed on the tenant since it was read")
+
+// ErrNoETag is returned for an update with strict concurrency when the tenant returned no ETag
+// for the parameter, as the update could overwrite changes made on the tenant in the meantime
+var ErrNoETag = errors.New("no ETag was returned for the parameter, cannot check for changes on the tenant")
+
+// PartnerDirectoryExecuter executes the requests of PartnerDirectory. It is implemented by
+// *httpclnt.HTTPExecuter, and by fakes in tests.
+type PartnerDirectoryExecuter interface {
+	ExecGetRequest(path string, headers map[string]string) (*http.Response, error)
+	ExecRequestWithCookies(method string, path string, body io.Reader, headers map[string]string, cookies []*http.Cookie) (*http.Response, error)
+	ReadRespBody(resp *http.Response) ([]byte, error)
+	NewBatchRequest() *httpclnt.BatchRequest
+}
+
 // PartnerDirectory handles Partner Directory API operations
 type PartnerDirectory struct {
-	exe *httpclnt.HTTPExecuter
+	exe               PartnerDirectoryExecuter
+	retry             RetryPolicy
+	strictConcurrency bool
 }
 
 // NewPartnerDirectory creates a new Partner Directory API client
-func NewPartnerDirectory(exe *httpclnt.HTTPExecuter) *PartnerDirectory {
+func NewPartnerDirectory(exe PartnerDirectoryExecuter) *PartnerDirectory {
 	return &PartnerDirectory{
-		exe: exe,
+		exe:   exe,
+		retry: DefaultRetryPolicy,
 	}
 }
 
+// SetStrictConcurrency enables optimistic concurrency for updates: the ETag read with the
+// existence check is sent as If-Match instead of "*", so that parameters changed on the
+// tenant in the meantime are reported as conflict instead of being overwritten
+func (pd *PartnerDirectory) SetStrictConcurrency(strict bool) {
+	pd.strictConcurrency = strict
+}
+
+// ifMatch returns the If-Match header value for an update of a parameter with the given ETag.
+// With strict concurrency a missing ETag is an error instead of falling back to "*".
+func (pd *PartnerDirectory) ifMatch(etag string) (string, error) {
+	if !pd.strictConcurrency {
+		return "*", nil
+	}
+	if etag == "" {
+		return "", ErrNoETag
+	}
+	return etag, nil
+}
+
 // StringParameter represents a partner directory string parameter
 type StringParameter struct {
 	Pid              string `json:"Pid"`
@@ -38,8 +79,12 @@ type StringParameter struct {
 	LastModifiedBy   string `json:"LastModifiedBy,omitempty"`
 	CreatedTime      string `json:"CreatedTime,omitempty"`
 	LastModifiedTime string `json:"LastModifiedTime,omitempty"`
+	// ETag of the remote parameter when it was read, used for optimistic concurrency (not sent to the API)
+	ETag string `json:"-"`
 }
 
+func (p *StringParameter) setETag(etag string) { p.ETag = etag }
+
 // BinaryParameter represents a partner directory binary parameter
 type BinaryParameter struct {
 	Pid              string `json:"Pid"`
@@ -50,6 +95,41 @@ type BinaryParameter struct {
 	LastModifiedBy   string `json:"LastModifiedBy,omitempty"`
 	CreatedTime      string `json:"CreatedTime,omitempty"`
 	LastModifiedTime string `json:"LastModifiedTime,omitempty"`
+	// ETag of the remote parameter when it was read, used for optimistic concurrency (not sent to the API)
+	ETag string `json:"-"`
+}
+
+func (p *BinaryParameter) setETag(etag string) { p.ETag = etag }
+
+// parameterETag returns the ETag of a single parameter response, from the ETag header
+// or otherwise from the OData metadata of the body
+func parameterETag(header http.Header, body []byte) string {
+	if etag := header.Get("ETag"); etag != "" {
+		return etag
+	}
+	var result struct {
+		D struct {
+			Metadata struct {
+				ETag string `json:"etag"`
+			} `json:"__metadata"`
+		} `json:"d"`
+	}
+	if err := json.Unmarshal(body, &result); err != nil {
+		return ""
+	}
+	return result.D.Metadata.ETag
+}
+
+// BinaryChecksum returns the hex encoded SHA-256 of decoded binary parameter content
+func BinaryChecksum(data []byte) string {
+	sum := sha256.Sum256(data)
+	return hex.EncodeToString(sum[:])
+}
+
+// BinaryParameterChanged reports whether the local parameter differs from the existing one in its
+// value or content type
+func BinaryParameterChanged(existing *BinaryParameter, local BinaryParameter) bool {
+	return existing.Value != local.Value || existing.ContentType != local.ContentType
 }
 
 // BatchResult represents the results of a batch operation
@@ -59,6 +139,8 @@ type BatchResult struct {
 	Unchanged []string
 	Deleted   []string
 	Errors    []string
+	// Conflicts are updates rejected because the parameter changed on the tenant since it was read
+	Conflicts []string
 }
 
 // GetStringParameters retrieves all string parameters from partner directory
@@ -70,7 +152,7 @@ func (pd *PartnerDirectory) GetStringParameters(selectFields string) ([]StringPa
 
 	log.Debug().Msgf("Getting string parameters from %s", path)
 
-	resp, err := pd.exe.ExecGetRequest(path, map[string]string{
+	resp, err := pd.execWithRetry(http.MethodGet, path, nil, map[string]string{
 		"Accept": "application/json",
 	})
 	if err != nil {
@@ -109,7 +191,7 @@ func (pd *PartnerDirectory) GetBinaryParameters(selectFields string) ([]BinaryPa
 
 	log.Debug().Msgf("Getting binary parameters from %s", path)
 
-	resp, err := pd.exe.ExecGetRequest(path, map[string]string{
+	resp, err := pd.execWithRetry(http.MethodGet, path, nil, map[string]string{
 		"Accept": "application/json",
 	})
 	if err != nil {
@@ -141,13 +223,11 @@ func (pd *PartnerDirectory) GetBinaryParameters(selectFields string) ([]BinaryPa
 
 // GetStringParameter retrieves a single string parameter
 func (pd *PartnerDirectory) GetStringParameter(pid, id string) (*StringParameter, error) {
-	path := fmt.Sprintf("/api/v1/StringParameters(Pid='%s',Id='%s')",
-		url.QueryEscape(pid),
-		url.QueryEscape(id))
+	path := httpclnt.ParameterPath("StringParameters", pid, id)
 
 	log.Debug().Msgf("Getting string parameter %s/%s", pid, id)
 
-	resp, err := pd.exe.ExecGetRequest(path, map[string]string{
+	resp, err := pd.execWithRetry(http.MethodGet, path, nil, map[string]string{
 		"Accept": "application/json",
 	})
 	if err != nil {
@@ -174,19 +254,18 @@ func (pd *PartnerDirectory) GetStringParameter(pid, id string) (*StringParameter
 	if err := json.Unmarshal(body, &result); err != nil {
 		return nil, fmt.Errorf("failed to decode response: %w", err)
 	}
+	result.D.ETag = parameterETag(resp.Header, body)
 
 	return &result.D, nil
 }
 
 // GetBinaryParameter retrieves a single binary parameter
 func (pd *PartnerDirectory) GetBinaryParameter(pid, id string) (*BinaryParameter, error) {
-	path := fmt.Sprintf("/api/v1/BinaryParameters(Pid='%s',Id='%s')",
-		url.QueryEscape(pid),
-		url.QueryEscape(id))
+	path := httpclnt.ParameterPath("BinaryParameters", pid, id)
 
 	log.Debug().Msgf("Getting binary parameter %s/%s", pid, id)
 
-	resp, err := pd.exe.ExecGetRequest(path, map[string]string{
+	resp, err := pd.execWithRetry(http.MethodGet, path, nil, map[string]string{
 		"Accept": "application/json",
 	})
 	if err != nil {
@@ -213,6 +292,7 @@ func (pd *PartnerDirectory) GetBinaryParameter(pid, id string) (*BinaryParameter
 	if err := json.Unmarshal(body, &result); err != nil {
 		return nil, fmt.Errorf("failed to decode response: %w", err)
 	}
+	result.D.ETag = parameterETag(resp.Header, body)
 
 	return &result.D, nil
 }
@@ -232,11 +312,11 @@ func (pd *PartnerDirectory) CreateStringParameter(param StringParameter) error {
 
 	log.Debug().Msgf("Creating string parameter %s/%s", param.Pid, param.ID)
 
-	resp, err := pd.exe.ExecRequestWithCookies("POST", "/api/v1/StringParameters",
-		bytes.NewReader(bodyJSON), map[string]string{
+	resp, err := pd.execWithRetry(http.MethodPost, "/api/v1/StringParameters",
+		bodyJSON, map[string]string{
 			"Content-Type": "application/json",
 			"Accept":       "application/json",
-		}, nil)
+		})
 	if err != nil {
 		return err
 	}
@@ -258,21 +338,29 @@ func (pd *PartnerDirectory) UpdateStringParameter(param StringParameter) error {
 		return fmt.Errorf("failed to marshal body: %w", err)
 	}
 
-	path := fmt.Sprintf("/api/v1/StringParameters(Pid='%s',Id='%s')",
-		url.QueryEscape(param.Pid),
-		url.QueryEscape(param.ID))
+	path := httpclnt.ParameterPath("StringParameters", param.Pid, param.ID)
+
+	ifMatch, err := pd.ifMatch(param.ETag)
+	if err != nil {
+		return fmt.Errorf("update string parameter %s/%s failed: %w", param.Pid, param.ID, err)
+	}
 
 	log.Debug().Msgf("Updating string parameter %s/%s", param.Pid, param.ID)
 
-	resp, err := pd.exe.ExecRequestWithCookies("PUT", path,
-		bytes.NewReader(bodyJSON), map[string]string{
+	resp, err := pd.execWithRetry(http.MethodPut, path,
+		bodyJSON, map[string]string{
 			"Content-Type": "application/json",
 			"Accept":       "application/json",
-		}, nil)
+			"If-Match":     ifMatch,
+		})
 	if err != nil {
 		return err
 	}
 
+	if resp.StatusCode == http.StatusPreconditionFailed {
+		return fmt.Errorf("update string parameter %s/%s failed: %w", param.Pid, param.ID, ErrConflict)
+	}
+
 	if resp.StatusCode != http.StatusOK && resp.StatusCode != http.StatusNoContent {
 		bodyBytes, _ := io.ReadAll(resp.Body)
 		return fmt.Errorf("update string parameter failed with response code = %d: %s", resp.StatusCode, string(bodyBytes))
@@ -283,19 +371,24 @@ func (pd *PartnerDirectory) UpdateStringParameter(param StringParameter) error {
 
 // DeleteStringParameter deletes a string parameter
 func (pd *PartnerDirectory) DeleteStringParameter(pid, id string) error {
-	path := fmt.Sprintf("/api/v1/StringParameters(Pid='%s',Id='%s')",
-		url.QueryEscape(pid),
-		url.QueryEscape(id))
+	path := httpclnt.ParameterPath("StringParameters", pid, id)
 
 	log.Debug().Msgf("Deleting string parameter %s/%s", pid, id)
 
-	resp, err := pd.exe.ExecRequestWithCookies("DELETE", path, nil, map[string]string{
+	resp, err := pd.execWithRetry(http.MethodDelete, path, nil, map[string]string{
 		"Accept": "application/json",
-	}, nil)
+	})
 	if err != nil {
 		return err
 	}
 
+	// A parameter that is already gone is treated as successfully deleted so that
+	// repeated or concurrent runs remain idempotent
+	if resp.StatusCode == http.StatusNotFound {
+		log.Debug().Msgf("String parameter %s/%s already deleted", pid, id)
+		return nil
+	}
+
 	if resp.StatusCode != http.StatusOK && resp.StatusCode != http.StatusNoContent {
 		bodyBytes, _ := io.ReadAll(resp.Body)
 		return fmt.Errorf("delete string parameter failed with response code = %d: %s", resp.StatusCode, string(bodyBytes))
@@ -320,11 +413,11 @@ func (pd *PartnerDirectory) CreateBinaryParameter(param BinaryParameter) error {
 
 	log.Debug().Msgf("Creating binary parameter %s/%s", param.Pid, param.ID)
 
-	resp, err := pd.exe.ExecRequestWithCookies("POST", "/api/v1/BinaryParameters",
-		bytes.NewReader(bodyJSON), map[string]string{
+	resp, err := pd.execWithRetry(http.MethodPost, "/api/v1/BinaryParameters",
+		bodyJSON, map[string]string{
 			"Content-Type": "application/json",
 			"Accept":       "application/json",
-		}, nil)
+		})
 	if err != nil {
 		return err
 	}
@@ -349,21 +442,29 @@ func (pd *PartnerDirectory) UpdateBinaryParameter(param BinaryParameter) error {
 		return fmt.Errorf("failed to marshal body: %w", err)
 	}
 
-	path := fmt.Sprintf("/api/v1/BinaryParameters(Pid='%s',Id='%s')",
-		url.QueryEscape(param.Pid),
-		url.QueryEscape(param.ID))
+	path := httpclnt.ParameterPath("BinaryParameters", param.Pid, param.ID)
+
+	ifMatch, err := pd.ifMatch(param.ETag)
+	if err != nil {
+		return fmt.Errorf("update binary parameter %s/%s failed: %w", param.Pid, param.ID, err)
+	}
 
 	log.Debug().Msgf("Updating binary parameter %s/%s", param.Pid, param.ID)
 
-	resp, err := pd.exe.ExecRequestWithCookies("PUT", path,
-		bytes.NewReader(bodyJSON), map[string]string{
+	resp, err := pd.execWithRetry(http.MethodPut, path,
+		bodyJSON, map[string]string{
 			"Content-Type": "application/json",
 			"Accept":       "application/json",
-		}, nil)
+			"If-Match":     ifMatch,
+		})
 	if err != nil {
 		return err
 	}
 
+	if resp.StatusCode == http.StatusPreconditionFailed {
+		return fmt.Errorf("update binary parameter %s/%s failed: %w", param.Pid, param.ID, ErrConflict)
+	}
+
 	if resp.StatusCode != http.StatusOK && resp.StatusCode != http.StatusNoContent {
 		bodyBytes, _ := io.ReadAll(resp.Body)
 		return fmt.Errorf("update binary parameter failed with response code = %d: %s", resp.StatusCode, string(bodyBytes))
@@ -374,19 +475,24 @@ func (pd *PartnerDirectory) UpdateBinaryParameter(param BinaryParameter) error {
 
 // DeleteBinaryParameter deletes a binary parameter
 func (pd *PartnerDirectory) DeleteBinaryParameter(pid, id string) error {
-	path := fmt.Sprintf("/api/v1/BinaryParameters(Pid='%s',Id='%s')",
-		url.QueryEscape(pid),
-		url.QueryEscape(id))
+	path := httpclnt.ParameterPath("BinaryParameters", pid, id)
 
 	log.Debug().Msgf("Deleting binary parameter %s/%s", pid, id)
 
-	resp, err := pd.exe.ExecRequestWithCookies("DELETE", path, nil, map[string]string{
+	resp, err := pd.execWithRetry(http.MethodDelete, path, nil, map[string]string{
 		"Accept": "application/json",
-	}, nil)
+	})
 	if err != nil {
 		return err
 	}
 
+	// A parameter that is already gone is treated as successfully deleted so that
+	// repeated or concurrent runs remain idempotent
+	if resp.StatusCode == http.StatusNotFound {
+		log.Debug().Msgf("Binary parameter %s/%s already deleted", pid, id)
+		return nil
+	}
+
 	if resp.StatusCode != http.StatusOK && resp.StatusCode != http.StatusNoContent {
 		bodyBytes, _ := io.ReadAll(resp.Body)
 		return fmt.Errorf("delete binary parameter failed with response code = %d: %s", resp.StatusCode, string(bodyBytes))
@@ -395,8 +501,9 @@ func (pd *PartnerDirectory) DeleteBinaryParameter(pid, id string) error {
 	return nil
 }
 
-// BatchSyncStringParameters syncs string parameters using batch operations
-func (pd *PartnerDirectory) BatchSyncStringParameters(params []StringParameter, batchSize int) (*BatchResult, error) {
+// BatchSyncStringParameters syncs string parameters using batch operations.
+// Existing parameters with different values are only updated when replace is true.
+func (pd *PartnerDirectory) BatchSyncStringParameters(params []StringParameter, batchSize int, replace bool) (*BatchResult, error) {
 	if batchSize <= 0 {
 		batchSize = DefaultBatchSize
 	}
@@ -406,6 +513,7 @@ func (pd *PartnerDirectory) BatchSyncStringParameters(params []StringParameter,
 		Updated:   []string{},
 		Unchanged: []string{},
 		Errors:    []string{},
+		Conflicts: []string{},
 	}
 
 	// Process in batches
@@ -418,27 +526,44 @@ func (pd *PartnerDirectory) BatchSyncStringParameters(params []StringParameter,
 		batchParams := params[i:end]
 		log.Debug().Msgf("Processing string parameter batch %d-%d of %d", i+1, end, len(params))
 
+		// Check which parameters exist with a single batch of GET operations
+		keys := make([]struct{ Pid, ID string }, len(batchParams))
+		for idx, param := range batchParams {
+			keys[idx] = struct{ Pid, ID string }{param.Pid, param.ID}
+		}
+		existingParams, getErrs, err := batchGetStringParameters(pd, keys)
+		if err != nil {
+			return nil, err
+		}
+
 		// Create batch request
 		batch := pd.exe.NewBatchRequest()
+		var queued []queuedOp
 
-		// Check each parameter and add appropriate operation
+		// Add the appropriate operation for each parameter
 		for idx, param := range batchParams {
-			contentID := fmt.Sprintf("%d", idx+1)
+			contentID := fmt.Sprintf("%d", len(queued)+1)
 			key := fmt.Sprintf("%s/%s", param.Pid, param.ID)
 
-			// Check if parameter exists
-			existing, err := pd.GetStringParameter(param.Pid, param.ID)
-			if err != nil {
-				results.Errors = append(results.Errors, fmt.Sprintf("%s: %v", key, err))
+			if getErrs[idx] != nil {
+				results.Errors = append(results.Errors, fmt.Sprintf("%s: %v", key, getErrs[idx]))
 				continue
 			}
+			existing := existingParams[idx]
 
 			if existing == nil {
 				// Create new parameter
 				httpclnt.AddCreateStringParameterOp(batch, param.Pid, param.ID, param.Value, contentID)
-			} else if existing.Value != param.Value {
+				queued = append(queued, queuedOp{contentID: contentID, key: key, create: true})
+			} else if replace && existing.Value != param.Value {
 				// Update existing parameter
-				httpclnt.AddUpdateStringParameterOp(batch, param.Pid, param.ID, param.Value, contentID)
+				ifMatch, err := pd.ifMatch(existing.ETag)
+				if err != nil {
+					results.Errors = append(results.Errors, fmt.Sprintf("%s: %v", key, err))
+					continue
+				}
+				httpclnt.AddUpdateStringParameterOp(batch, param.Pid, param.ID, param.Value, ifMatch, contentID)
+				queued = append(queued, queuedOp{contentID: contentID, key: key})
 			} else {
 				// Unchanged
 				results.Unchanged = append(results.Unchanged, key)
@@ -448,38 +573,22 @@ func (pd *PartnerDirectory) BatchSyncStringParameters(params []StringParameter,
 
 		// Execute batch
 		resp, err := batch.Execute()
-		if err == nil && len(resp.Operations) > 0 {
-			// Process responses
-			for idx, opResp := range resp.Operations {
-				if idx >= len(batchParams) {
-					break
-				}
-				param := batchParams[idx]
-				key := fmt.Sprintf("%s/%s", param.Pid, param.ID)
-
-				if opResp.Error != nil {
-					results.Errors = append(results.Errors, fmt.Sprintf("%s: %v", key, opResp.Error))
-				} else if opResp.StatusCode >= 200 && opResp.StatusCode < 300 {
-					// Check if it was a create or update based on status code
-					if opResp.StatusCode == http.StatusCreated || opResp.StatusCode == 201 {
-						results.Created = append(results.Created, key)
-					} else {
-						results.Updated = append(results.Updated, key)
-					}
-				} else {
-					results.Errors = append(results.Errors, fmt.Sprintf("%s: HTTP %d", key, opResp.StatusCode))
-				}
-			}
-		} else if err != nil {
+		if err != nil {
 			return nil, fmt.Errorf("batch execution failed: %w", err)
 		}
+		contentIDs := make([]string, len(queued))
+		for idx, op := range queued {
+			contentIDs[idx] = op.contentID
+		}
+		collectSyncResults(results, queued, responsesByContentID(contentIDs, resp))
 	}
 
 	return results, nil
 }
 
-// BatchSyncBinaryParameters syncs binary parameters using batch operations
-func (pd *PartnerDirectory) BatchSyncBinaryParameters(params []BinaryParameter, batchSize int) (*BatchResult, error) {
+// BatchSyncBinaryParameters syncs binary parameters using batch operations.
+// Existing parameters with different values are only updated when replace is true.
+func (pd *PartnerDirectory) BatchSyncBinaryParameters(params []BinaryParameter, batchSize int, replace bool) (*BatchResult, error) {
 	if batchSize <= 0 {
 		batchSize = DefaultBatchSize
 	}
@@ -489,6 +598,7 @@ func (pd *PartnerDirectory) BatchSyncBinaryParameters(params []BinaryParameter,
 		Updated:   []string{},
 		Unchanged: []string{},
 		Errors:    []string{},
+		Conflicts: []string{},
 	}
 
 	// Process in batches
@@ -501,27 +611,44 @@ func (pd *PartnerDirectory) BatchSyncBinaryParameters(params []BinaryParameter,
 		batchParams := params[i:end]
 		log.Debug().Msgf("Processing binary parameter batch %d-%d of %d", i+1, end, len(params))
 
+		// Check which parameters exist with a single batch of GET operations
+		keys := make([]struct{ Pid, ID string }, len(batchParams))
+		for idx, param := range batchParams {
+			keys[idx] = struct{ Pid, ID string }{param.Pid, param.ID}
+		}
+		existingParams, getErrs, err := batchGetBinaryParameters(pd, keys)
+		if err != nil {
+			return nil, err
+		}
+
 		// Create batch request
 		batch := pd.exe.NewBatchRequest()
+		var queued []queuedOp
 
-		// Check each parameter and add appropriate operation
+		// Add the appropriate operation for each parameter
 		for idx, param := range batchParams {
-			contentID := fmt.Sprintf("%d", idx+1)
+			contentID := fmt.Sprintf("%d", len(queued)+1)
 			key := fmt.Sprintf("%s/%s", param.Pid, param.ID)
 
-			// Check if parameter exists
-			existing, err := pd.GetBinaryParameter(param.Pid, param.ID)
-			if err != nil {
-				results.Errors = append(results.Errors, fmt.Sprintf("%s: %v", key, err))
+			if getErrs[idx] != nil {
+				results.Errors = append(results.Errors, fmt.Sprintf("%s: %v", key, getErrs[idx]))
 				continue
 			}
+			existing := existingParams[idx]
 
 			if existing == nil {
 				// Create new parameter
 				httpclnt.AddCreateBinaryParameterOp(batch, param.Pid, param.ID, param.Value, param.ContentType, contentID)
-			} else if existing.Value != param.Value || existing.ContentType != param.ContentType {
+				queued = append(queued, queuedOp{contentID: contentID, key: key, create: true})
+			} else if replace && BinaryParameterChanged(existing, param) {
 				// Update existing parameter
-				httpclnt.AddUpdateBinaryParameterOp(batch, param.Pid, param.ID, param.Value, param.ContentType, contentID)
+				ifMatch, err := pd.ifMatch(existing.ETag)
+				if err != nil {
+					results.Errors = append(results.Errors, fmt.Sprintf("%s: %v", key, err))
+					continue
+				}
+				httpclnt.AddUpdateBinaryParameterOp(batch, param.Pid, param.ID, param.Value, param.ContentType, ifMatch, contentID)
+				queued = append(queued, queuedOp{contentID: contentID, key: key})
 			} else {
 				// Unchanged
 				results.Unchanged = append(results.Unchanged, key)
@@ -531,36 +658,129 @@ func (pd *PartnerDirectory) BatchSyncBinaryParameters(params []BinaryParameter,
 
 		// Execute batch
 		resp, err := batch.Execute()
-		if err == nil && len(resp.Operations) > 0 {
-			// Process responses
-			for idx, opResp := range resp.Operations {
-				if idx >= len(batchParams) {
-					break
-				}
-				param := batchParams[idx]
-				key := fmt.Sprintf("%s/%s", param.Pid, param.ID)
-
-				if opResp.Error != nil {
-					results.Errors = append(results.Errors, fmt.Sprintf("%s: %v", key, opResp.Error))
-				} else if opResp.StatusCode >= 200 && opResp.StatusCode < 300 {
-					// Check if it was a create or update based on status code
-					if opResp.StatusCode == http.StatusCreated || opResp.StatusCode == 201 {
-						results.Created = append(results.Created, key)
-					} else {
-						results.Updated = append(results.Updated, key)
-					}
-				} else {
-					results.Errors = append(results.Errors, fmt.Sprintf("%s: HTTP %d", key, opResp.StatusCode))
-				}
-			}
-		} else if err != nil {
+		if err != nil {
 			return nil, fmt.Errorf("batch execution failed: %w", err)
 		}
+		contentIDs := make([]string, len(queued))
+		for idx, op := range queued {
+			contentIDs[idx] = op.contentID
+		}
+		collectSyncResults(results, queued, responsesByContentID(contentIDs, resp))
 	}
 
 	return results, nil
 }
 
+func batchGetStringParameters(pd *PartnerDirectory, keys []struct{ Pid, ID string }) ([]*StringParameter, []error, error) {
+	return batchGetParameters[StringParameter](pd, keys, httpclnt.AddGetStringParameterOp)
+}
+
+func batchGetBinaryParameters(pd *PartnerDirectory, keys []struct{ Pid, ID string }) ([]*BinaryParameter, []error, error) {
+	return batchGetParameters[BinaryParameter](pd, keys, httpclnt.AddGetBinaryParameterOp)
+}
+
+// batchGetParameters reads the parameters of the given keys with a single $batch request of
+// GET operations. The results are returned in the order of the keys: nil for parameters that
+// do not exist, and an error per key for reads that failed.
+func batchGetParameters[T any, PT interface {
+	*T
+	setETag(string)
+}](pd *PartnerDirectory, keys []struct{ Pid, ID string },
+	addGetOp func(batch *httpclnt.BatchRequest, pid, id, contentID string)) ([]*T, []error, error) {
+
+	params := make([]*T, len(keys))
+	errs := make([]error, len(keys))
+	if len(keys) == 0 {
+		return params, errs, nil
+	}
+
+	batch := pd.exe.NewBatchRequest()
+	contentIDs := make([]string, len(keys))
+	for idx, key := range keys {
+		contentIDs[idx] = fmt.Sprintf("%d", idx+1)
+		addGetOp(batch, key.Pid, key.ID, contentIDs[idx])
+	}
+
+	resp, err := batch.Execute()
+	if err != nil {
+		return nil, nil, fmt.Errorf("batch read failed: %w", err)
+	}
+
+	responses := responsesByContentID(contentIDs, resp)
+	for idx := range keys {
+		opResp, ok := responses[contentIDs[idx]]
+		switch {
+		case !ok:
+			errs[idx] = fmt.Errorf("no response received in batch")
+		case opResp.StatusCode == http.StatusNotFound:
+			// Parameter does not exist
+		case opResp.Error != nil:
+			errs[idx] = opResp.Error
+		case opResp.StatusCode != http.StatusOK:
+			errs[idx] = fmt.Errorf("get parameter failed with response code = %d", opResp.StatusCode)
+		default:
+			var result struct {
+				D T `json:"d"`
+			}
+			if err := json.Unmarshal(opResp.Body, &result); err != nil {
+				errs[idx] = fmt.Errorf("failed to decode response: %w", err)
+				continue
+			}
+			PT(&result.D).setETag(parameterETag(opResp.Headers, opResp.Body))
+			params[idx] = &result.D
+		}
+	}
+	return params, errs, nil
+}
+
+// queuedOp records a create or update operation added to a batch so that its
+// response can be attributed to the right parameter
+type queuedOp struct {
+	contentID string
+	key       string
+	create    bool
+}
+
+// responsesByContentID returns the batch operation responses keyed by the Content-IDs of the
+// operations, so that omitted or reordered responses are not attributed to the wrong parameter.
+// If the server does not return Content-IDs at all, the responses are matched by position.
+func responsesByContentID(contentIDs []string, resp *httpclnt.BatchResponse) map[string]httpclnt.BatchOperationResponse {
+	byID := resp.ByContentID()
+	if len(byID) == 0 {
+		for idx, contentID := range contentIDs {
+			if idx < len(resp.Operations) {
+				byID[contentID] = resp.Operations[idx]
+			}
+		}
+	}
+	return byID
+}
+
+// collectSyncResults maps batch operation responses back to the queued operations
+func collectSyncResults(results *BatchResult, queued []queuedOp, responses map[string]httpclnt.BatchOperationResponse) {
+	for _, op := range queued {
+		opResp, ok := responses[op.contentID]
+		if !ok {
+			results.Errors = append(results.Errors, fmt.Sprintf("%s: no response received in batch", op.key))
+			continue
+		}
+
+		if opResp.StatusCode == http.StatusPreconditionFailed {
+			results.Conflicts = append(results.Conflicts, op.key)
+		} else if opResp.Error != nil {
+			results.Errors = append(results.Errors, fmt.Sprintf("%s: %v", op.key, opResp.Error))
+		} else if opResp.StatusCode >= 200 && opResp.StatusCode < 300 {
+			if op.create {
+				results.Created = append(results.Created, op.key)
+			} else {
+				results.Updated = append(results.Updated, op.key)
+			}
+		} else {
+			results.Errors = append(results.Errors, fmt.Sprintf("%s: HTTP %d", op.key, opResp.StatusCode))
+		}
+	}
+}
+
 // BatchDeleteStringParameters deletes string parameters using batch operations
 func (pd *PartnerDirectory) BatchDeleteStringParameters(pidsToDelete []struct{ Pid, ID string }, batchSize int) (*BatchResult, error) {
 	if batchSize <= 0 {
@@ -585,9 +805,10 @@ func (pd *PartnerDirectory) BatchDeleteStringParameters(pidsToDelete []struct{ P
 		// Create batch request
 		batch := pd.exe.NewBatchRequest()
 
+		contentIDs := make([]string, len(batchItems))
 		for idx, item := range batchItems {
-			contentID := fmt.Sprintf("%d", idx+1)
-			httpclnt.AddDeleteStringParameterOp(batch, item.Pid, item.ID, contentID)
+			contentIDs[idx] = fmt.Sprintf("%d", idx+1)
+			httpclnt.AddDeleteStringParameterOp(batch, item.Pid, item.ID, contentIDs[idx])
 		}
 
 		// Execute batch
@@ -597,14 +818,18 @@ func (pd *PartnerDirectory) BatchDeleteStringParameters(pidsToDelete []struct{ P
 		}
 
 		// Process responses
-		for idx, opResp := range resp.Operations {
-			if idx >= len(batchItems) {
-				break
-			}
-			item := batchItems[idx]
+		responses := responsesByContentID(contentIDs, resp)
+		for idx, item := range batchItems {
 			key := fmt.Sprintf("%s/%s", item.Pid, item.ID)
+			opResp, ok := responses[contentIDs[idx]]
 
-			if opResp.Error != nil {
+			if !ok {
+				results.Errors = append(results.Errors, fmt.Sprintf("%s: no response received in batch", key))
+			} else if opResp.StatusCode == http.StatusNotFound {
+				// Already deleted - treat as success
+				log.Debug().Msgf("Parameter %s already deleted", key)
+				results.Deleted = append(results.Deleted, key)
+			} else if opResp.Error != nil {
 				results.Errors = append(results.Errors, fmt.Sprintf("%s: %v", key, opResp.Error))
 			} else if opResp.StatusCode >= 200 && opResp.StatusCode < 300 {
 				results.Deleted = append(results.Deleted, key)
@@ -641,9 +866,10 @@ func (pd *PartnerDirectory) BatchDeleteBinaryParameters(pidsToDelete []struct{ P
 		// Create batch request
 		batch := pd.exe.NewBatchRequest()
 
+		contentIDs := make([]string, len(batchItems))
 		for idx, item := range batchItems {
-			contentID := fmt.Sprintf("%d", idx+1)
-			httpclnt.AddDeleteBinaryParameterOp(batch, item.Pid, item.ID, contentID)
+			contentIDs[idx] = fmt.Sprintf("%d", idx+1)
+			httpclnt.AddDeleteBinaryParameterOp(batch, item.Pid, item.ID, contentIDs[idx])
 		}
 
 		// Execute batch
@@ -653,14 +879,18 @@ func (pd *PartnerDirectory) BatchDeleteBinaryParameters(pidsToDelete []struct{ P
 		}
 
 		// Process responses
-		for idx, opResp := range resp.Operations {
-			if idx >= len(batchItems) {
-				break
-			}
-			item := batchItems[idx]
+		responses := responsesByContentID(contentIDs, resp)
+		for idx, item := range batchItems {
 			key := fmt.Sprintf("%s/%s", item.Pid, item.ID)
+			opResp, ok := responses[contentIDs[idx]]
 
-			if opResp.Error != nil {
+			if !ok {
+				results.Errors = append(results.Errors, fmt.Sprintf("%s: no response received in batch", key))
+			} else if opResp.StatusCode == http.StatusNotFound {
+				// Already deleted - treat as success
+				log.Debug().Msgf("Parameter %s already deleted", key)
+				results.Deleted = append(results.Deleted, key)
+			} else if opResp.Error != nil {
 				results.Errors = append(results.Errors, fmt.Sprintf("%s: %v", key, opResp.Error))
 			} else if opResp.StatusCode >= 200 && opResp.StatusCode < 300 {
 				results.Deleted = append(results.Deleted, key)
@@ -0,0 +1,114 @@
+package api
+
+import (
+	"bytes"
+	"io"
+	"net/http"
+	"slices"
+	"strconv"
+	"time"
+
+	"github.com/rs/zerolog/log"
+)
+
+// RetryPolicy controls how Partner Directory requests are retried on transient failures
+type RetryPolicy struct {
+	// MaxRetries is the number of retries after the first attempt (0 disables retries)
+	MaxRetries int
+	// BaseDelay is doubled after every retry unless the server sends Retry-After
+	BaseDelay time.Duration
+	// Sleep waits between attempts, defaults to time.Sleep
+	Sleep func(time.Duration)
+	// StatusCodes are the HTTP status codes that trigger a retry, defaults to DefaultRetryStatusCodes
+	StatusCodes []int
+}
+
+// DefaultRetryStatusCodes are the status codes retried when RetryPolicy.StatusCodes is not set
+var DefaultRetryStatusCodes = []int{
+	http.StatusTooManyRequests,
+	http.StatusInternalServerError,
+	http.StatusBadGateway,
+	http.StatusServiceUnavailable,
+	http.StatusGatewayTimeout,
+}
+
+// DefaultRetryPolicy is used by NewPartnerDirectory
+var DefaultRetryPolicy = RetryPolicy{
+	MaxRetries:  3,
+	BaseDelay:   time.Second,
+	StatusCodes: DefaultRetryStatusCodes,
+}
+
+// SetRetryPolicy replaces the retry policy used for Partner Directory requests
+func (pd *PartnerDirectory) SetRetryPolicy(policy RetryPolicy) {
+	pd.retry = policy
+}
+
+// execWithRetry executes a request and retries transient failures.
+// Connection errors are retried for every method, responses only when their status code
+// is one of the policy's retryable status codes.
+func (pd *PartnerDirectory) execWithRetry(method, path string, body []byte, headers map[string]string) (*http.Response, error) {
+	sleep := pd.retry.Sleep
+	if sleep == nil {
+		sleep = time.Sleep
+	}
+
+	for attempt := 0; ; attempt++ {
+		var reqBody io.Reader = http.NoBody
+		if body != nil {
+			reqBody = bytes.NewReader(body)
+		}
+
+		resp, err := pd.exe.ExecRequestWithCookies(method, path, reqBody, headers, nil)
+		if attempt >= pd.retry.MaxRetries || !pd.retry.shouldRetry(method, resp, err) {
+			return resp, err
+		}
+
+		delay := pd.retry.BaseDelay << attempt
+		if err != nil {
+			log.Warn().Msgf("%s %s failed: %v - retrying in %v (%d/%d)", method, path, err, delay, attempt+1, pd.retry.MaxRetries)
+		} else {
+			if retryAfter, ok := parseRetryAfter(resp.Header.Get("Retry-After")); ok {
+				delay = retryAfter
+			}
+			log.Warn().Msgf("%s %s returned %d - retrying in %v (%d/%d)", method, path, resp.StatusCode, delay, attempt+1, pd.retry.MaxRetries)
+			io.Copy(io.Discard, resp.Body)
+			resp.Body.Close()
+		}
+		sleep(delay)
+	}
+}
+
+// shouldRetry is the retry predicate of the policy. Retryable 5xx responses are only
+// retried for methods other than POST, as the request may already have been applied.
+func (p RetryPolicy) shouldRetry(method string, resp *http.Response, err error) bool {
+	if err != nil {
+		return true
+	}
+	statusCodes := p.StatusCodes
+	if statusCodes == nil {
+		statusCodes = DefaultRetryStatusCodes
+	}
+	if !slices.Contains(statusCodes, resp.StatusCode) {
+		return false
+	}
+	return resp.StatusCode < 500 || method != http.MethodPost
+}
+
+// parseRetryAfter parses a Retry-After header given in seconds or as an HTTP date
+func parseRetryAfter(value string) (time.Duration, bool) {
+	if value == "" {
+		return 0, false
+	}
+	if seconds, err := strconv.Atoi(value); err == nil && seconds >= 0 {
+		return time.Duration(seconds) * time.Second, true
+	}
+	if date, err := http.ParseTime(value); err == nil {
+		delay := time.Until(date)
+		if delay < 0 {
+			delay = 0
+		}
+		return delay, true
+	}
+	return 0, false
+}
@@ -0,0 +1,465 @@
+package api
+
+import (
+	"fmt"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/engswee/flashpipe/internal/httpclnt"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func newTestPartnerDirectory(t *testing.T, handler http.Handler) *PartnerDirectory {
+	svr := httptest.NewServer(handler)
+	t.Cleanup(svr.Close)
+
+	host, port := httpclnt.GetHostPort(svr.URL)
+	exe := httpclnt.New("", "", "", "", "dummyuser", "dummypassword", host, "http", port, false)
+	pd := NewPartnerDirectory(exe)
+	pd.SetRetryPolicy(RetryPolicy{MaxRetries: 2, Sleep: func(time.Duration) {}})
+	return pd
+}
+
+// fakeExecuter serves the requests of PartnerDirectory with a handler, without a tenant
+type fakeExecuter struct {
+	handler  http.Handler
+	requests []string
+}
+
+func (f *fakeExecuter) ExecGetRequest(path string, headers map[string]string) (*http.Response, error) {
+	return f.ExecRequestWithCookies(http.MethodGet, path, http.NoBody, headers, nil)
+}
+
+func (f *fakeExecuter) ExecRequestWithCookies(method string, path string, body io.Reader, headers map[string]string, cookies []*http.Cookie) (*http.Response, error) {
+	f.requests = append(f.requests, method+" "+path)
+	req := httptest.NewRequest(method, path, body)
+	for k, v := range headers {
+		req.Header.Set(k, v)
+	}
+	rec := httptest.NewRecorder()
+	f.handler.ServeHTTP(rec, req)
+	return rec.Result(), nil
+}
+
+func (f *fakeExecuter) ReadRespBody(resp *http.Response) ([]byte, error) {
+	defer resp.Body.Close()
+	return io.ReadAll(resp.Body)
+}
+
+func (f *fakeExecuter) NewBatchRequest() *httpclnt.BatchRequest {
+	return httpclnt.NewBatchRequest(f)
+}
+
+func TestPartnerDirectory_FakeExecuter(t *testing.T) {
+	exe := &fakeExecuter{handler: http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		switch {
+		case r.Method == http.MethodPost && r.URL.Path == "/api/v1/StringParameters":
+			http.Error(w, `{"error":{"code":"Conflict"}}`, http.StatusConflict)
+		case r.Method == http.MethodPut:
+			w.WriteHeader(http.StatusPreconditionFailed)
+		case r.Method == http.MethodPost && r.URL.Path == "/api/v1/$batch":
+			w.Header().Set("Content-Type", "multipart/mixed; boundary=batchresp")
+			w.WriteHeader(http.StatusAccepted)
+			fmt.Fprint(w, "--batchresp\r\n"+
+				"Content-Type: multipart/mixed; boundary=csresp\r\n\r\n"+
+				"--csresp\r\nContent-Type: application/http\r\nContent-ID: 2\r\n\r\nHTTP/1.1 204 No Content\r\n\r\n\r\n"+
+				"--csresp\r\nContent-Type: application/http\r\nContent-ID: 1\r\n\r\nHTTP/1.1 403 Forbidden\r\n\r\n\r\n"+
+				"--csresp--\r\n\r\n"+
+				"--batchresp--\r\n")
+		default:
+			t.Errorf("unexpected request %s %s", r.Method, r.URL.Path)
+		}
+	})}
+	pd := NewPartnerDirectory(exe)
+	pd.SetRetryPolicy(RetryPolicy{Sleep: func(time.Duration) {}})
+
+	err := pd.CreateStringParameter(StringParameter{Pid: "PID1", ID: "Param1", Value: "a"})
+	assert.ErrorContains(t, err, "response code = 409")
+
+	err = pd.UpdateStringParameter(StringParameter{Pid: "PID1", ID: "Param1", Value: "b"})
+	assert.ErrorIs(t, err, ErrConflict)
+
+	results, err := pd.BatchDeleteStringParameters([]struct{ Pid, ID string }{{"PID1", "Param1"}, {"PID1", "Param2"}}, 0)
+	require.NoError(t, err)
+	assert.Equal(t, []string{"PID1/Param2"}, results.Deleted)
+	assert.Equal(t, []string{"PID1/Param1: HTTP 403"}, results.Errors)
+
+	assert.Equal(t, []string{
+		"POST /api/v1/StringParameters",
+		"PUT /api/v1/StringParameters(Pid='PID1',Id='Param1')",
+		"POST /api/v1/$batch",
+	}, exe.requests)
+}
+
+func TestDeleteParameter_NotFoundIsSuccess(t *testing.T) {
+	pd := newTestPartnerDirectory(t, http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		assert.Equal(t, http.MethodDelete, r.Method)
+		http.Error(w, `{"error":{"code":"Not Found"}}`, http.StatusNotFound)
+	}))
+
+	require.NoError(t, pd.DeleteStringParameter("PID1", "Param1"))
+	require.NoError(t, pd.DeleteBinaryParameter("PID1", "Param1"))
+}
+
+func TestDeleteParameter_ServerError(t *testing.T) {
+	pd := newTestPartnerDirectory(t, http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		http.Error(w, "boom", http.StatusInternalServerError)
+	}))
+
+	assert.Error(t, pd.DeleteStringParameter("PID1", "Param1"))
+	assert.Error(t, pd.DeleteBinaryParameter("PID1", "Param1"))
+}
+
+// isReadBatch reports whether a $batch request contains GET operations, and must be
+// answered with query responses instead of a changeset
+func isReadBatch(t *testing.T, r *http.Request) bool {
+	body, err := io.ReadAll(r.Body)
+	require.NoError(t, err)
+	return strings.Contains(string(body), "\r\nGET ")
+}
+
+// writeQueryBatchResponse answers a read $batch request with the given operation responses,
+// each consisting of the part headers, a blank line and the HTTP response
+func writeQueryBatchResponse(w http.ResponseWriter, parts ...string) {
+	w.Header().Set("Content-Type", "multipart/mixed; boundary=batchresp")
+	w.WriteHeader(http.StatusAccepted)
+	for _, part := range parts {
+		fmt.Fprint(w, "--batchresp\r\nContent-Type: application/http\r\n"+part+"\r\n")
+	}
+	fmt.Fprint(w, "--batchresp--\r\n")
+}
+
+func TestBatchSyncStringParameters_ResultsMatchQueuedOperations(t *testing.T) {
+	pd := newTestPartnerDirectory(t, http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		switch {
+		case r.Method == http.MethodPost && r.URL.Path == "/api/v1/$batch" && isReadBatch(t, r):
+			writeQueryBatchResponse(w,
+				"Content-ID: 1\r\n\r\nHTTP/1.1 404 Not Found\r\n\r\n",
+				"Content-ID: 2\r\n\r\nHTTP/1.1 200 OK\r\n\r\n"+`{"d":{"Pid":"PID1","Id":"Same","Value":"a"}}`,
+				"Content-ID: 3\r\n\r\nHTTP/1.1 200 OK\r\n\r\n"+`{"d":{"Pid":"PID1","Id":"Changed","Value":"old"}}`)
+		case r.Method == http.MethodPost && r.URL.Path == "/api/v1/$batch":
+			w.Header().Set("Content-Type", "multipart/mixed; boundary=batchresp")
+			w.WriteHeader(http.StatusAccepted)
+			fmt.Fprint(w, "--batchresp\r\n"+
+				"Content-Type: multipart/mixed; boundary=csresp\r\n\r\n"+
+				"--csresp\r\nContent-Type: application/http\r\n\r\nHTTP/1.1 201 Created\r\n\r\n\r\n"+
+				"--csresp\r\nContent-Type: application/http\r\n\r\nHTTP/1.1 204 No Content\r\n\r\n\r\n"+
+				"--csresp--\r\n\r\n"+
+				"--batchresp--\r\n")
+		default:
+			t.Errorf("unexpected request %s %s", r.Method, r.URL.Path)
+		}
+	}))
+
+	params := []StringParameter{
+		{Pid: "PID1", ID: "New", Value: "n"},
+		{Pid: "PID1", ID: "Same", Value: "a"},
+		{Pid: "PID1", ID: "Changed", Value: "new"},
+	}
+
+	results, err := pd.BatchSyncStringParameters(params, 0, true)
+	require.NoError(t, err)
+	assert.Equal(t, []string{"PID1/New"}, results.Created)
+	assert.Equal(t, []string{"PID1/Changed"}, results.Updated)
+	assert.Equal(t, []string{"PID1/Same"}, results.Unchanged)
+	assert.Empty(t, results.Errors)
+}
+
+func TestBatchSyncStringParameters_ResponsesMatchedByContentID(t *testing.T) {
+	pd := newTestPartnerDirectory(t, http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		switch {
+		case r.Method == http.MethodPost && r.URL.Path == "/api/v1/$batch" && isReadBatch(t, r):
+			writeQueryBatchResponse(w,
+				"Content-ID: 1\r\n\r\nHTTP/1.1 404 Not Found\r\n\r\n",
+				"Content-ID: 2\r\n\r\nHTTP/1.1 404 Not Found\r\n\r\n",
+				"Content-ID: 3\r\n\r\nHTTP/1.1 404 Not Found\r\n\r\n")
+		case r.Method == http.MethodPost && r.URL.Path == "/api/v1/$batch":
+			// Responses in reverse order, the response of the second operation is missing
+			w.Header().Set("Content-Type", "multipart/mixed; boundary=batchresp")
+			w.WriteHeader(http.StatusAccepted)
+			fmt.Fprint(w, "--batchresp\r\n"+
+				"Content-Type: multipart/mixed; boundary=csresp\r\n\r\n"+
+				"--csresp\r\nContent-Type: application/http\r\nContent-ID: 3\r\n\r\nHTTP/1.1 400 Bad Request\r\n\r\n"+
+				`{"error":{"code":"Bad Request","message":{"lang":"en","value":"Value is too long"}}}`+"\r\n"+
+				"--csresp\r\nContent-Type: application/http\r\nContent-ID: 1\r\n\r\nHTTP/1.1 201 Created\r\n\r\n\r\n"+
+				"--csresp--\r\n\r\n"+
+				"--batchresp--\r\n")
+		default:
+			t.Errorf("unexpected request %s %s", r.Method, r.URL.Path)
+		}
+	}))
+
+	params := []StringParameter{
+		{Pid: "PID1", ID: "First", Value: "1"},
+		{Pid: "PID1", ID: "Second", Value: "2"},
+		{Pid: "PID1", ID: "Third", Value: "3"},
+	}
+
+	results, err := pd.BatchSyncStringParameters(params, 0, true)
+	require.NoError(t, err)
+	assert.Equal(t, []string{"PID1/First"}, results.Created)
+	assert.Equal(t, []string{
+		"PID1/Second: no response received in batch",
+		"PID1/Third: HTTP 400 Bad Request: Value is too long",
+	}, results.Errors)
+}
+
+func TestBatchSyncStringParameters_StrictConcurrency(t *testing.T) {
+	pd := newTestPartnerDirectory(t, http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		require.Equal(t, "/api/v1/$batch", r.URL.Path)
+		body, err := io.ReadAll(r.Body)
+		require.NoError(t, err)
+
+		if strings.Contains(string(body), "\r\nGET ") {
+			writeQueryBatchResponse(w,
+				"Content-ID: 1\r\n\r\nHTTP/1.1 200 OK\r\n\r\n"+`{"d":{"__metadata":{"etag":"W/\"1\""},"Pid":"PID1","Id":"Kept","Value":"old"}}`,
+				"Content-ID: 2\r\n\r\nHTTP/1.1 200 OK\r\nETag: W/\"7\"\r\n\r\n"+`{"d":{"Pid":"PID1","Id":"Changed","Value":"old"}}`)
+			return
+		}
+
+		assert.Contains(t, string(body), "If-Match: W/\"1\"\r\n")
+		assert.Contains(t, string(body), "If-Match: W/\"7\"\r\n")
+		assert.NotContains(t, string(body), "If-Match: *")
+
+		w.Header().Set("Content-Type", "multipart/mixed; boundary=batchresp")
+		w.WriteHeader(http.StatusAccepted)
+		fmt.Fprint(w, "--batchresp\r\n"+
+			"Content-Type: multipart/mixed; boundary=csresp\r\n\r\n"+
+			"--csresp\r\nContent-Type: application/http\r\nContent-ID: 1\r\n\r\nHTTP/1.1 204 No Content\r\n\r\n\r\n"+
+			"--csresp\r\nContent-Type: application/http\r\nContent-ID: 2\r\n\r\nHTTP/1.1 412 Precondition Failed\r\n\r\n"+
+			`{"error":{"code":"Precondition Failed","message":{"lang":"en","value":"ETag does not match"}}}`+"\r\n"+
+			"--csresp--\r\n\r\n"+
+			"--batchresp--\r\n")
+	}))
+	pd.SetStrictConcurrency(true)
+
+	params := []StringParameter{
+		{Pid: "PID1", ID: "Kept", Value: "new"},
+		{Pid: "PID1", ID: "Changed", Value: "new"},
+	}
+
+	results, err := pd.BatchSyncStringParameters(params, 0, true)
+	require.NoError(t, err)
+	assert.Equal(t, []string{"PID1/Kept"}, results.Updated)
+	assert.Equal(t, []string{"PID1/Changed"}, results.Conflicts)
+	assert.Empty(t, results.Errors)
+}
+
+func TestUpdateStringParameter_StrictConcurrency(t *testing.T) {
+	var ifMatch string
+	pd := newTestPartnerDirectory(t, http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		switch r.Method {
+		case http.MethodGet:
+			w.Header().Set("ETag", `W/"3"`)
+			fmt.Fprint(w, `{"d":{"Pid":"PID1","Id":"Param1","Value":"old"}}`)
+		case http.MethodPut:
+			ifMatch = r.Header.Get("If-Match")
+			if ifMatch != "*" && ifMatch != `W/"3"` {
+				w.WriteHeader(http.StatusPreconditionFailed)
+				return
+			}
+			w.WriteHeader(http.StatusNoContent)
+		}
+	}))
+
+	existing, err := pd.GetStringParameter("PID1", "Param1")
+	require.NoError(t, err)
+	assert.Equal(t, `W/"3"`, existing.ETag)
+
+	// Without strict concurrency, any version is overwritten
+	require.NoError(t, pd.UpdateStringParameter(StringParameter{Pid: "PID1", ID: "Param1", Value: "new", ETag: existing.ETag}))
+	assert.Equal(t, "*", ifMatch)
+
+	pd.SetStrictConcurrency(true)
+	require.NoError(t, pd.UpdateStringParameter(StringParameter{Pid: "PID1", ID: "Param1", Value: "new", ETag: existing.ETag}))
+	assert.Equal(t, `W/"3"`, ifMatch)
+
+	err = pd.UpdateStringParameter(StringParameter{Pid: "PID1", ID: "Param1", Value: "new", ETag: `W/"2"`})
+	assert.ErrorIs(t, err, ErrConflict)
+
+	// Without an ETag the parameter is not overwritten with "*"
+	ifMatch = ""
+	err = pd.UpdateStringParameter(StringParameter{Pid: "PID1", ID: "Param1", Value: "new"})
+	assert.ErrorIs(t, err, ErrNoETag)
+	assert.Empty(t, ifMatch, "no update is sent")
+}
+
+func TestBatchSyncBinaryParameters_StrictConcurrencyWithoutETag(t *testing.T) {
+	pd := newTestPartnerDirectory(t, http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		body, err := io.ReadAll(r.Body)
+		require.NoError(t, err)
+		if strings.Contains(string(body), "\r\nGET ") {
+			writeQueryBatchResponse(w,
+				"Content-ID: 1\r\n\r\nHTTP/1.1 200 OK\r\n\r\n"+`{"d":{"Pid":"PID1","Id":"Map","Value":"b2xk","ContentType":"xml"}}`)
+			return
+		}
+		assert.NotContains(t, string(body), "PUT ")
+		writeQueryBatchResponse(w)
+	}))
+	pd.SetStrictConcurrency(true)
+
+	results, err := pd.BatchSyncBinaryParameters([]BinaryParameter{{Pid: "PID1", ID: "Map", Value: "bmV3", ContentType: "xml"}}, 0, true)
+	require.NoError(t, err)
+	assert.Empty(t, results.Updated)
+	require.Len(t, results.Errors, 1)
+	assert.Contains(t, results.Errors[0], "PID1/Map: "+ErrNoETag.Error())
+}
+
+func TestBatchDeleteBinaryParameters_ResponsesMatchedByContentID(t *testing.T) {
+	pd := newTestPartnerDirectory(t, http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Content-Type", "multipart/mixed; boundary=batchresp")
+		w.WriteHeader(http.StatusAccepted)
+		fmt.Fprint(w, "--batchresp\r\n"+
+			"Content-Type: multipart/mixed; boundary=csresp\r\n\r\n"+
+			"--csresp\r\nContent-Type: application/http\r\nContent-ID: 2\r\n\r\nHTTP/1.1 500 Internal Server Error\r\n\r\n\r\n"+
+			"--csresp\r\nContent-Type: application/http\r\nContent-ID: 1\r\n\r\nHTTP/1.1 204 No Content\r\n\r\n\r\n"+
+			"--csresp\r\nContent-Type: application/http\r\nContent-ID: 3\r\n\r\nHTTP/1.1 404 Not Found\r\n\r\n"+
+			`{"error":{"code":"Not Found","message":{"lang":"en","value":"Parameter not found"}}}`+"\r\n"+
+			"--csresp--\r\n\r\n"+
+			"--batchresp--\r\n")
+	}))
+
+	results, err := pd.BatchDeleteBinaryParameters([]struct{ Pid, ID string }{{"PID1", "Cert"}, {"PID1", "Key"}, {"PID1", "Gone"}}, 0)
+	require.NoError(t, err)
+	assert.Equal(t, []string{"PID1/Cert", "PID1/Gone"}, results.Deleted)
+	assert.Equal(t, []string{"PID1/Key: HTTP 500"}, results.Errors)
+}
+
+func TestRetry_PutRetriedOnServerError(t *testing.T) {
+	attempts := 0
+	pd := newTestPartnerDirectory(t, http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		attempts++
+		body, _ := io.ReadAll(r.Body)
+		assert.JSONEq(t, `{"Value":"v"}`, string(body))
+		if attempts < 3 {
+			http.Error(w, "unavailable", http.StatusServiceUnavailable)
+			return
+		}
+		w.WriteHeader(http.StatusNoContent)
+	}))
+
+	require.NoError(t, pd.UpdateStringParameter(StringParameter{Pid: "PID1", ID: "Param1", Value: "v"}))
+	assert.Equal(t, 3, attempts)
+}
+
+func TestRetry_PostNotRetriedOnServerError(t *testing.T) {
+	attempts := 0
+	pd := newTestPartnerDirectory(t, http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		attempts++
+		http.Error(w, "boom", http.StatusInternalServerError)
+	}))
+
+	assert.Error(t, pd.CreateStringParameter(StringParameter{Pid: "PID1", ID: "Param1", Value: "v"}))
+	assert.Equal(t, 1, attempts)
+}
+
+func TestRetry_TooManyRequestsHonorsRetryAfter(t *testing.T) {
+	attempts := 0
+	pd := newTestPartnerDirectory(t, http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		attempts++
+		if attempts == 1 {
+			w.Header().Set("Retry-After", "7")
+			w.WriteHeader(http.StatusTooManyRequests)
+			return
+		}
+		w.WriteHeader(http.StatusCreated)
+	}))
+	var delays []time.Duration
+	pd.SetRetryPolicy(RetryPolicy{MaxRetries: 1, BaseDelay: time.Second, Sleep: func(d time.Duration) {
+		delays = append(delays, d)
+	}})
+
+	require.NoError(t, pd.CreateBinaryParameter(BinaryParameter{Pid: "PID1", ID: "Param1", Value: "dg==", ContentType: "txt"}))
+	assert.Equal(t, 2, attempts)
+	assert.Equal(t, []time.Duration{7 * time.Second}, delays)
+}
+
+func TestRetry_ExhaustedReturnsLastResponse(t *testing.T) {
+	attempts := 0
+	pd := newTestPartnerDirectory(t, http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		attempts++
+		http.Error(w, "unavailable", http.StatusBadGateway)
+	}))
+
+	err := pd.DeleteBinaryParameter("PID1", "Param1")
+	require.Error(t, err)
+	assert.Contains(t, err.Error(), "502")
+	assert.Equal(t, 3, attempts)
+}
+
+func TestRetry_CustomStatusCodes(t *testing.T) {
+	attempts := 0
+	pd := newTestPartnerDirectory(t, http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		attempts++
+		switch attempts {
+		case 1:
+			http.Error(w, "timeout", http.StatusRequestTimeout)
+		case 2:
+			http.Error(w, "unavailable", http.StatusServiceUnavailable)
+		default:
+			w.WriteHeader(http.StatusCreated)
+		}
+	}))
+	pd.SetRetryPolicy(RetryPolicy{MaxRetries: 3, Sleep: func(time.Duration) {}, StatusCodes: []int{http.StatusRequestTimeout}})
+
+	// 408 is retried even for POST, 503 is not in the list
+	err := pd.CreateStringParameter(StringParameter{Pid: "PID1", ID: "Param1", Value: "v"})
+	require.Error(t, err)
+	assert.Contains(t, err.Error(), "503")
+	assert.Equal(t, 2, attempts)
+}
+
+func TestRetry_PostNotRetriedOnConfiguredServerError(t *testing.T) {
+	attempts := 0
+	pd := newTestPartnerDirectory(t, http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		attempts++
+		http.Error(w, "unavailable", http.StatusBadGateway)
+	}))
+	pd.SetRetryPolicy(RetryPolicy{MaxRetries: 2, Sleep: func(time.Duration) {}, StatusCodes: []int{http.StatusBadGateway}})
+
+	assert.Error(t, pd.CreateBinaryParameter(BinaryParameter{Pid: "PID1", ID: "Param1", Value: "dg==", ContentType: "txt"}))
+	assert.Equal(t, 1, attempts)
+
+	attempts = 0
+	assert.Error(t, pd.DeleteBinaryParameter("PID1", "Param1"))
+	assert.Equal(t, 3, attempts)
+}
+
+func TestParameterKeysWithSpecialCharacters(t *testing.T) {
+	var paths []string
+	pd := newTestPartnerDirectory(t, http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		paths = append(paths, r.URL.Path)
+		assert.NotContains(t, r.RequestURI, " ")
+		switch r.Method {
+		case http.MethodGet:
+			fmt.Fprint(w, `{"d":{"Pid":"PID 1","Id":"O'Brien Key","Value":"a"}}`)
+		default:
+			w.WriteHeader(http.StatusNoContent)
+		}
+	}))
+
+	param, err := pd.GetStringParameter("PID 1", "O'Brien Key")
+	require.NoError(t, err)
+	assert.Equal(t, "O'Brien Key", param.ID)
+	require.NoError(t, pd.UpdateBinaryParameter(BinaryParameter{Pid: "PID 1", ID: "a+b", Value: "dGVzdA==", ContentType: "txt"}))
+	require.NoError(t, pd.DeleteStringParameter("PID 1", "50% off"))
+
+	assert.Equal(t, []string{
+		"/api/v1/StringParameters(Pid='PID 1',Id='O''Brien Key')",
+		"/api/v1/BinaryParameters(Pid='PID 1',Id='a+b')",
+		"/api/v1/StringParameters(Pid='PID 1',Id='50% off')",
+	}, paths)
+}
+
+func TestBinaryParameterChanged(t *testing.T) {
+	existing := &BinaryParameter{Pid: "PID1", ID: "Map", Value: "PGEvPg==", ContentType: "xml"}
+
+	assert.False(t, BinaryParameterChanged(existing, BinaryParameter{Value: "PGEvPg==", ContentType: "xml"}))
+	assert.True(t, BinaryParameterChanged(existing, BinaryParameter{Value: "PGIvPg==", ContentType: "xml"}))
+	assert.True(t, BinaryParameterChanged(existing, BinaryParameter{Value: "PGEvPg==", ContentType: "xsl"}))
+}
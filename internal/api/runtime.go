@@ -5,23 +5,37 @@ import (
 	"fmt"
 	"io"
 	"strings"
+	"time"
 
 	"github.com/engswee/flashpipe/internal/httpclnt"
 	"github.com/go-errors/errors"
 	"github.com/rs/zerolog/log"
 )
 
+// NotDeployed is returned as version by Get, and as status by GetStatus, for artifacts that are not deployed
+const NotDeployed = "NOT_DEPLOYED"
+
 type Runtime struct {
 	exe *httpclnt.HTTPExecuter
 }
 
 type runtimeData struct {
 	Root struct {
-		Version string `json:"Version"`
-		Status  string `json:"Status"`
+		Version    string `json:"Version"`
+		Status     string `json:"Status"`
+		DeployedBy string `json:"DeployedBy"`
+		DeployedOn string `json:"DeployedOn"`
 	} `json:"d"`
 }
 
+// RuntimeDeployment is the deployment of an artifact on the runtime
+type RuntimeDeployment struct {
+	Version    string
+	Status     string // NotDeployed when the artifact is not deployed
+	DeployedBy string
+	DeployedOn time.Time
+}
+
 type runtimeError struct {
 	Parameter []string `json:"parameter"`
 }
@@ -41,6 +55,34 @@ func (r *Runtime) UnDeploy(id string) error {
 }
 
 func (r *Runtime) Get(id string) (version string, status string, err error) {
+	version, status, err = r.GetStatus(id)
+	if err != nil {
+		return "", "", err
+	}
+	switch status {
+	case NotDeployed:
+		return NotDeployed, "", nil
+	case "STARTED":
+		return version, "STARTED", nil
+	default: // artifact runtime deployment failed or not complete
+		return "", status, nil
+	}
+}
+
+// GetStatus returns the deployed version and status of a runtime artifact. Unlike Get, the
+// version is also returned when the status is not STARTED. The status is NotDeployed when
+// the artifact is not deployed to the runtime.
+func (r *Runtime) GetStatus(id string) (version string, status string, err error) {
+	deployment, err := r.GetDeployment(id)
+	if err != nil {
+		return "", "", err
+	}
+	return deployment.Version, deployment.Status, nil
+}
+
+// GetDeployment returns the version, status and deployment time of a runtime artifact. The
+// status is NotDeployed when the artifact is not deployed to the runtime.
+func (r *Runtime) GetDeployment(id string) (*RuntimeDeployment, error) {
 	log.Info().Msgf("Getting details of runtime artifact %v", id)
 	urlPath := fmt.Sprintf("/api/v1/IntegrationRuntimeArtifacts('%v')", id)
 
@@ -48,35 +90,36 @@ func (r *Runtime) Get(id string) (version string, status string, err error) {
 	resp, err := readOnlyCall(urlPath, callType, r.exe)
 	if err != nil {
 		if err.Error() == fmt.Sprintf("%v call failed with response code = 404", callType) { // artifact not deployed to runtime
-			return "NOT_DEPLOYED", "", nil
+			return &RuntimeDeployment{Status: NotDeployed}, nil
 		} else {
 			bytes, err := io.ReadAll(resp.Body)
 			if err != nil {
-				return "", "", err
+				return nil, err
 			}
 			respBody := string(bytes[:])
 			if strings.Contains(respBody, "Requested entity could not be found") { // artifact not deployed to runtime
-				return "NOT_DEPLOYED", "", nil
+				return &RuntimeDeployment{Status: NotDeployed}, nil
 			}
-			return "", "", err
+			return nil, err
 		}
 	}
 	// Process response to extract version and status
 	var jsonData *runtimeData
 	respBody, err := r.exe.ReadRespBody(resp)
 	if err != nil {
-		return "", "", err
+		return nil, err
 	}
 	err = json.Unmarshal(respBody, &jsonData)
 	if err != nil {
 		log.Error().Msgf("Error unmarshalling response as JSON. Response body = %s", respBody)
-		return "", "", errors.Wrap(err, 0)
-	}
-	if jsonData.Root.Status == "STARTED" {
-		return jsonData.Root.Version, "STARTED", nil
-	} else { // artifact runtime deployment failed or not complete
-		return "", jsonData.Root.Status, nil
+		return nil, errors.Wrap(err, 0)
 	}
+	return &RuntimeDeployment{
+		Version:    jsonData.Root.Version,
+		Status:     jsonData.Root.Status,
+		DeployedBy: jsonData.Root.DeployedBy,
+		DeployedOn: ParseODataTime(jsonData.Root.DeployedOn),
+	}, nil
 }
 
 func (r *Runtime) GetErrorInfo(id string) (string, error) {
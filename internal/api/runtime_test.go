@@ -6,6 +6,8 @@ import (
 	"github.com/spf13/viper"
 	"github.com/stretchr/testify/assert"
 	"github.com/stretchr/testify/suite"
+	"net/http"
+	"net/http/httptest"
 	"os"
 	"testing"
 	"time"
@@ -146,3 +148,30 @@ func tearDownRuntime(t *testing.T, artifactId string, exe *httpclnt.HTTPExecuter
 		}
 	}
 }
+
+func TestRuntime_GetDeploymentMock(t *testing.T) {
+	mux := http.NewServeMux()
+	mux.HandleFunc("/api/v1/IntegrationRuntimeArtifacts('DummyIFlow')", func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Content-Type", "application/json")
+		w.Write([]byte(`{"d": {"Id": "DummyIFlow", "Version": "1.0.3", "Status": "STARTED", "DeployedBy": "deployer", "DeployedOn": "/Date(1700000000000)/"}}`))
+	})
+	mux.HandleFunc("/api/v1/IntegrationRuntimeArtifacts('Missing')", func(w http.ResponseWriter, r *http.Request) {
+		http.Error(w, "not found", http.StatusNotFound)
+	})
+	svr := httptest.NewServer(mux)
+	defer svr.Close()
+
+	host, port := httpclnt.GetHostPort(svr.URL)
+	rt := NewRuntime(httpclnt.New("", "", "", "", "dummy", "dummy", host, "http", port, true))
+
+	deployment, err := rt.GetDeployment("DummyIFlow")
+	assert.NoError(t, err)
+	assert.Equal(t, "1.0.3", deployment.Version)
+	assert.Equal(t, "STARTED", deployment.Status)
+	assert.Equal(t, "deployer", deployment.DeployedBy)
+	assert.Equal(t, time.UnixMilli(1700000000000), deployment.DeployedOn)
+
+	deployment, err = rt.GetDeployment("Missing")
+	assert.NoError(t, err)
+	assert.Equal(t, NotDeployed, deployment.Status)
+}
@@ -8,8 +8,12 @@ import (
 	"github.com/spf13/cobra"
 	"io"
 	"net/http"
+	"strings"
 )
 
+// DefaultOauthPath is the path of the OAuth token endpoint when oauth-path is not set
+const DefaultOauthPath = "/oauth/token"
+
 type ServiceDetails struct {
 	Host              string
 	Userid            string
@@ -34,11 +38,20 @@ func GetServiceDetails(cmd *cobra.Command) *ServiceDetails {
 			OauthHost:         oauthHost,
 			OauthClientId:     config.GetString(cmd, "oauth-clientid"),
 			OauthClientSecret: config.GetString(cmd, "oauth-clientsecret"),
-			OauthPath:         config.GetString(cmd, "oauth-path"),
+			OauthPath:         OauthPathOrDefault(config.GetString(cmd, "oauth-path")),
 		}
 	}
 }
 
+// OauthPathOrDefault returns the OAuth token path, or DefaultOauthPath when it is empty, e.g. when
+// FLASHPIPE_OAUTH_PATH or oauth-path in the config file is set to an empty value
+func OauthPathOrDefault(oauthPath string) string {
+	if strings.TrimSpace(oauthPath) == "" {
+		return DefaultOauthPath
+	}
+	return oauthPath
+}
+
 func InitHTTPExecuter(serviceDetails *ServiceDetails) *httpclnt.HTTPExecuter {
 	return httpclnt.New(serviceDetails.OauthHost, serviceDetails.OauthPath, serviceDetails.OauthClientId, serviceDetails.OauthClientSecret, serviceDetails.Userid, serviceDetails.Password, serviceDetails.Host, "https", 443, true)
 }
@@ -0,0 +1,23 @@
+package api
+
+import (
+	"testing"
+
+	"github.com/spf13/cobra"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestGetServiceDetails_OauthPath(t *testing.T) {
+	newCmd := func(oauthPath string) *cobra.Command {
+		cmd := &cobra.Command{}
+		cmd.Flags().String("tmn-host", "tenant.example.com", "")
+		cmd.Flags().String("oauth-host", "tenant.authentication.example.com", "")
+		cmd.Flags().String("oauth-path", oauthPath, "")
+		return cmd
+	}
+
+	assert.Equal(t, "/oauth2/api/v1/token", GetServiceDetails(newCmd("/oauth2/api/v1/token")).OauthPath)
+	// An empty value, e.g. from FLASHPIPE_OAUTH_PATH or the config file, falls back to the default
+	assert.Equal(t, DefaultOauthPath, GetServiceDetails(newCmd("")).OauthPath)
+	assert.Equal(t, DefaultOauthPath, OauthPathOrDefault(" "))
+}
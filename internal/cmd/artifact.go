@@ -7,6 +7,7 @@ import (
 	"github.com/engswee/flashpipe/internal/analytics"
 	"github.com/engswee/flashpipe/internal/api"
 	"github.com/engswee/flashpipe/internal/config"
+	"github.com/engswee/flashpipe/internal/deploy"
 	"github.com/engswee/flashpipe/internal/file"
 	"github.com/engswee/flashpipe/internal/httpclnt"
 	"github.com/engswee/flashpipe/internal/str"
@@ -57,7 +58,7 @@ Configuration:
 	artifactCmd.Flags().String("dir-work", "/tmp", "Working directory for in-transit files (config: update.artifact.dirWork)")
 	artifactCmd.Flags().StringSlice("script-collection-map", nil, "Comma-separated source-target ID pairs for converting script collection references during create/update (config: update.artifact.scriptCollectionMap)")
 	artifactCmd.Flags().String("artifact-type", "Integration", "Artifact type. Allowed values: Integration, MessageMapping, ScriptCollection, ValueMapping (config: update.artifact.artifactType)")
-	// TODO - another flag for replacing value mapping in QAS?
+	artifactCmd.Flags().String("file-value-mapping", "", "YAML file with replacements of target values in value_mapping.xml, for ValueMapping artifacts (config: update.artifact.fileValueMapping)")
 
 	_ = artifactCmd.MarkFlagRequired("artifact-id")
 	_ = artifactCmd.MarkFlagRequired("package-id")
@@ -91,6 +92,7 @@ func runUpdateArtifact(cmd *cobra.Command) error {
 		return fmt.Errorf("security alert for --dir-work: %w", err)
 	}
 	scriptMap := str.TrimSlice(config.GetStringSliceWithFallback(cmd, "script-collection-map", "update.artifact.scriptCollectionMap"))
+	valueMappingFile := config.GetStringWithFallback(cmd, "file-value-mapping", "update.artifact.fileValueMapping")
 
 	defaultParamFile := fmt.Sprintf("%v/src/main/resources/parameters.prop", artifactDir)
 	if parametersFile == "" {
@@ -114,6 +116,18 @@ func runUpdateArtifact(cmd *cobra.Command) error {
 		}
 	}
 
+	if valueMappingFile != "" {
+		if artifactType != "ValueMapping" {
+			return fmt.Errorf("--file-value-mapping is only supported for ValueMapping artifacts, artifact type is %v", artifactType)
+		}
+		log.Info().Msgf("Applying value mapping replacements from %v", valueMappingFile)
+		count, err := deploy.ApplyValueMappingFile(valueMappingFile, artifactDir)
+		if err != nil {
+			return err
+		}
+		log.Info().Msgf("Applied %d value mapping replacements", count)
+	}
+
 	// Default artifact name from Manifest file or artifact ID
 	if artifactName == "" {
 		headers, err := sync.GetManifestHeaders(manifestFile)
@@ -0,0 +1,265 @@
+package cmd
+
+import (
+	"bytes"
+	"crypto/sha256"
+	"encoding/hex"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+	"time"
+	"unicode/utf8"
+
+	"github.com/engswee/flashpipe/internal/analytics"
+	"github.com/engswee/flashpipe/internal/api"
+	"github.com/engswee/flashpipe/internal/config"
+	"github.com/engswee/flashpipe/internal/file"
+	"github.com/pmezard/go-difflib/difflib"
+	"github.com/rs/zerolog/log"
+	"github.com/spf13/cobra"
+)
+
+// artifactDiffResult counts the compared files of an artifact
+type artifactDiffResult struct {
+	Changed   int
+	Unchanged int
+}
+
+func NewArtifactDiffCommand() *cobra.Command {
+
+	artifactDiffCmd := &cobra.Command{
+		Use:   "artifact-diff",
+		Short: "Show the differences of an artifact between the local copy and the tenant",
+		Long: `Download the designtime artifact from the tenant and print a unified diff
+of its content against the local copy, e.g. for code reviews. Nothing is
+changed on the tenant.
+
+The META-INF directory, src/main/resources and metainfo.prop are compared.
+Line endings are normalised, and the Origin headers of MANIFEST.MF and the
+comment lines of .prop files are ignored. Binary files are compared by
+their SHA-256 hash.
+
+Configuration:
+  Settings can be loaded from the global config file (--config) under the
+  'artifactDiff' section. CLI flags override config file settings.`,
+		Example: `  # Changes of the local copy of an integration flow
+  flashpipe artifact-diff --artifact-id Order_Create --dir-artifact ./Orders/Order_Create
+
+  # Changes of a script collection
+  flashpipe artifact-diff --artifact-id Order_Scripts --artifact-type ScriptCollection \
+    --dir-artifact ./Orders/Order_Scripts`,
+		PreRunE: func(cmd *cobra.Command, args []string) error {
+			// Validate the artifact type
+			artifactType := config.GetStringWithFallback(cmd, "artifact-type", "artifactDiff.artifactType")
+			switch artifactType {
+			case "MessageMapping", "ScriptCollection", "Integration", "ValueMapping":
+			default:
+				return fmt.Errorf("invalid value for --artifact-type = %v", artifactType)
+			}
+			return nil
+		},
+		RunE: func(cmd *cobra.Command, args []string) (err error) {
+			startTime := time.Now()
+			if err = runArtifactDiff(cmd); err != nil {
+				cmd.SilenceUsage = true
+			}
+			analytics.Log(cmd, err, startTime)
+			return
+		},
+	}
+
+	// Define cobra flags, the default value has the lowest (least significant) precedence
+	// Note: These can be set in config file under 'artifactDiff' key
+	artifactDiffCmd.Flags().String("artifact-id", "", "ID of artifact (config: artifactDiff.artifactId)")
+	artifactDiffCmd.Flags().String("dir-artifact", "", "Directory containing the local contents of the designtime artifact (config: artifactDiff.dirArtifact)")
+	artifactDiffCmd.Flags().String("artifact-type", "Integration", "Artifact type. Allowed values: Integration, MessageMapping, ScriptCollection, ValueMapping (config: artifactDiff.artifactType)")
+	artifactDiffCmd.Flags().String("dir-work", "", "Working directory for in-transit files, a temporary directory if not set (config: artifactDiff.dirWork)")
+
+	return artifactDiffCmd
+}
+
+func runArtifactDiff(cmd *cobra.Command) error {
+	log.Info().Msg("Executing artifact-diff command")
+
+	artifactId := config.GetStringWithFallback(cmd, "artifact-id", "artifactDiff.artifactId")
+	artifactType := config.GetStringWithFallback(cmd, "artifact-type", "artifactDiff.artifactType")
+	artifactDir, err := config.GetStringWithEnvExpandAndFallback(cmd, "dir-artifact", "artifactDiff.dirArtifact")
+	if err != nil {
+		return fmt.Errorf("security alert for --dir-artifact: %w", err)
+	}
+	workDir, err := config.GetStringWithEnvExpandAndFallback(cmd, "dir-work", "artifactDiff.dirWork")
+	if err != nil {
+		return fmt.Errorf("security alert for --dir-work: %w", err)
+	}
+
+	if artifactId == "" {
+		return fmt.Errorf("--artifact-id is required (set via CLI flag or in config file under 'artifactDiff.artifactId')")
+	}
+	if artifactDir == "" {
+		return fmt.Errorf("--dir-artifact is required (set via CLI flag or in config file under 'artifactDiff.dirArtifact')")
+	}
+	if !file.Exists(artifactDir) {
+		return fmt.Errorf("artifact directory %s does not exist", artifactDir)
+	}
+
+	if workDir == "" {
+		workDir, err = os.MkdirTemp("", "flashpipe-artifact-diff-*")
+		if err != nil {
+			return fmt.Errorf("failed to create working directory: %w", err)
+		}
+		defer os.RemoveAll(workDir)
+	}
+
+	serviceDetails := api.GetServiceDetails(cmd)
+	exe := api.InitHTTPExecuter(serviceDetails).WithContext(cmd.Context())
+	dt := api.NewDesigntimeArtifact(artifactType, exe)
+
+	// An artifact that does not exist on the tenant yet is compared with an empty directory
+	tenantDir := filepath.Join(workDir, "tenant", artifactId)
+	if err := os.MkdirAll(tenantDir, os.ModePerm); err != nil {
+		return fmt.Errorf("failed to create directory %s: %w", tenantDir, err)
+	}
+	_, _, exists, err := dt.Get(artifactId, "active")
+	if err != nil {
+		return err
+	}
+	if exists {
+		zipFile := filepath.Join(workDir, artifactId+".zip")
+		if err := dt.Download(zipFile, artifactId); err != nil {
+			return err
+		}
+		if err := file.UnzipSource(zipFile, tenantDir); err != nil {
+			return fmt.Errorf("failed to unzip artifact %s: %w", artifactId, err)
+		}
+	} else {
+		log.Warn().Msgf("Artifact %s does not exist on the tenant, all local files are shown as added", artifactId)
+	}
+
+	result, err := diffArtifactDirs(cmd.OutOrStdout(), tenantDir, artifactDir)
+	if err != nil {
+		return err
+	}
+	if result.Changed == 0 {
+		fmt.Fprintf(cmd.OutOrStdout(), "No differences between the tenant and %s (%d file(s) compared)\n", artifactDir, result.Unchanged)
+	} else {
+		fmt.Fprintf(cmd.OutOrStdout(), "%d file(s) changed, %d unchanged\n", result.Changed, result.Unchanged)
+	}
+	return nil
+}
+
+// diffArtifactDirs writes a unified diff from the tenant content to the local content of an
+// artifact. Binary files are reported as changed by their hash.
+func diffArtifactDirs(w io.Writer, tenantDir, localDir string) (*artifactDiffResult, error) {
+	tenantFiles, err := artifactFiles(tenantDir)
+	if err != nil {
+		return nil, err
+	}
+	localFiles, err := artifactFiles(localDir)
+	if err != nil {
+		return nil, err
+	}
+
+	paths := make(map[string]bool)
+	for relPath := range tenantFiles {
+		paths[relPath] = true
+	}
+	for relPath := range localFiles {
+		paths[relPath] = true
+	}
+	sorted := make([]string, 0, len(paths))
+	for relPath := range paths {
+		if isArtifactContent(relPath) {
+			sorted = append(sorted, relPath)
+		}
+	}
+	sort.Strings(sorted)
+
+	result := &artifactDiffResult{}
+	for _, relPath := range sorted {
+		tenantContent, err := diffContentOf(tenantDir, relPath, tenantFiles[relPath])
+		if err != nil {
+			return nil, err
+		}
+		localContent, err := diffContentOf(localDir, relPath, localFiles[relPath])
+		if err != nil {
+			return nil, err
+		}
+		if tenantFiles[relPath] && localFiles[relPath] && bytes.Equal(tenantContent, localContent) {
+			result.Unchanged++
+			continue
+		}
+		result.Changed++
+
+		fromFile, toFile := "tenant/"+relPath, "local/"+relPath
+		if !tenantFiles[relPath] {
+			fromFile = "/dev/null"
+		}
+		if !localFiles[relPath] {
+			toFile = "/dev/null"
+		}
+		if isBinary(tenantContent) || isBinary(localContent) {
+			fmt.Fprintf(w, "Binary files %s (sha256 %s) and %s (sha256 %s) differ\n",
+				fromFile, contentHash(tenantContent, tenantFiles[relPath]), toFile, contentHash(localContent, localFiles[relPath]))
+			continue
+		}
+		diff, err := difflib.GetUnifiedDiffString(difflib.UnifiedDiff{
+			A:        diffLines(tenantContent),
+			B:        diffLines(localContent),
+			FromFile: fromFile,
+			ToFile:   toFile,
+			Context:  3,
+		})
+		if err != nil {
+			return nil, fmt.Errorf("failed to diff %s: %w", relPath, err)
+		}
+		fmt.Fprint(w, diff)
+	}
+	return result, nil
+}
+
+// isArtifactContent reports whether a file is part of the designtime content, like in the
+// comparison of the update command. Other directories, e.g. with parameters of other
+// environments, are ignored.
+func isArtifactContent(relPath string) bool {
+	return strings.HasPrefix(relPath, "META-INF/") || strings.HasPrefix(relPath, "src/main/resources/") || relPath == "metainfo.prop"
+}
+
+// diffContentOf returns the normalised content of a text file or the content of a binary file,
+// or nil when the file does not exist
+func diffContentOf(dir, relPath string, exists bool) ([]byte, error) {
+	if !exists {
+		return nil, nil
+	}
+	path := filepath.Join(dir, filepath.FromSlash(relPath))
+	content, err := os.ReadFile(path)
+	if err == nil && !isBinary(content) {
+		content, err = normalisedContent(path)
+	}
+	if err != nil {
+		return nil, fmt.Errorf("failed to read %s: %w", relPath, err)
+	}
+	return content, nil
+}
+
+// diffLines splits the content into lines for difflib, which expects the line endings
+func diffLines(content []byte) []string {
+	if len(content) == 0 {
+		return nil
+	}
+	return difflib.SplitLines(string(content))
+}
+
+func isBinary(content []byte) bool {
+	return bytes.IndexByte(content, 0) >= 0 || !utf8.Valid(content)
+}
+
+func contentHash(content []byte, exists bool) string {
+	if !exists {
+		return "-"
+	}
+	sum := sha256.Sum256(content)
+	return hex.EncodeToString(sum[:])[:12]
+}
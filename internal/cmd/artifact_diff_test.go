@@ -0,0 +1,53 @@
+package cmd
+
+import (
+	"bytes"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestDiffArtifactDirs(t *testing.T) {
+	writeFiles := func(dir string, files map[string]string) {
+		for relPath, content := range files {
+			path := filepath.Join(dir, filepath.FromSlash(relPath))
+			require.NoError(t, os.MkdirAll(filepath.Dir(path), 0755))
+			require.NoError(t, os.WriteFile(path, []byte(content), 0644))
+		}
+	}
+
+	tenantDir, localDir := t.TempDir(), t.TempDir()
+	writeFiles(tenantDir, map[string]string{
+		"META-INF/MANIFEST.MF":                     "Bundle-SymbolicName: Order_Create\r\nOrigin-Bundle-Version: 1.0.1\r\n",
+		"src/main/resources/parameters.prop":       "#Tue Oct 13 10:00:00 UTC 2026\nTimeout=60000\n",
+		"src/main/resources/script/order.groovy":   "def a = 1\ndef b = 2\n",
+		"src/main/resources/script/removed.groovy": "println 'removed'\n",
+		"src/main/resources/lib/helper.jar":        "PK\x00\x01",
+	})
+	writeFiles(localDir, map[string]string{
+		"META-INF/MANIFEST.MF":                   "Bundle-SymbolicName: Order_Create\n",
+		"src/main/resources/parameters.prop":     "#Wed Oct 14 10:00:00 UTC 2026\nTimeout=60000\n",
+		"src/main/resources/script/order.groovy": "def a = 1\ndef b = 3\n",
+		"src/main/resources/script/added.groovy": "println 'added'\n",
+		"src/main/resources/lib/helper.jar":      "PK\x00\x02",
+		"QA/parameters.prop":                     "Timeout=30000\n",
+	})
+
+	var buf bytes.Buffer
+	result, err := diffArtifactDirs(&buf, tenantDir, localDir)
+	require.NoError(t, err)
+	assert.Equal(t, &artifactDiffResult{Changed: 4, Unchanged: 2}, result)
+
+	output := buf.String()
+	assert.Contains(t, output, "--- tenant/src/main/resources/script/order.groovy\n+++ local/src/main/resources/script/order.groovy\n")
+	assert.Contains(t, output, "-def b = 2\n+def b = 3\n")
+	assert.Contains(t, output, "--- /dev/null\n+++ local/src/main/resources/script/added.groovy\n")
+	assert.Contains(t, output, "--- tenant/src/main/resources/script/removed.groovy\n+++ /dev/null\n")
+	assert.Contains(t, output, "Binary files tenant/src/main/resources/lib/helper.jar (sha256 ")
+	// Line endings, volatile lines and files outside the designtime content are ignored
+	assert.NotContains(t, output, "MANIFEST.MF")
+	assert.NotContains(t, output, "parameters.prop")
+}
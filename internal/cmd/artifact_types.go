@@ -0,0 +1,106 @@
+package cmd
+
+import (
+	"fmt"
+	"strings"
+
+	"github.com/engswee/flashpipe/internal/deploy"
+	"github.com/rs/zerolog/log"
+)
+
+// artifactTypeNames holds the names of an artifact type in the different APIs
+type artifactTypeNames struct {
+	Sync       string // type of api.NewDesigntimeArtifact
+	Designtime string // OData entity of the designtime artifact
+}
+
+var (
+	integrationFlowType  = artifactTypeNames{Sync: "Integration", Designtime: "IntegrationDesigntimeArtifact"}
+	valueMappingType     = artifactTypeNames{Sync: "ValueMapping", Designtime: "ValueMappingDesigntimeArtifact"}
+	messageMappingType   = artifactTypeNames{Sync: "MessageMapping", Designtime: "MessageMappingDesigntimeArtifact"}
+	scriptCollectionType = artifactTypeNames{Sync: "ScriptCollection", Designtime: "ScriptCollection"}
+)
+
+// artifactTypes maps the lower case type names accepted in deployment configs to their artifact type.
+// It includes the SAP-BundleType values of the MANIFEST.MF and the types returned by the tenant.
+var artifactTypes = map[string]artifactTypeNames{
+	"integrationflow":                  integrationFlowType,
+	"integration flow":                 integrationFlowType,
+	"iflow":                            integrationFlowType,
+	"integration":                      integrationFlowType,
+	"integrationdesigntimeartifact":    integrationFlowType,
+	"valuemapping":                     valueMappingType,
+	"value mapping":                    valueMappingType,
+	"valuemappingdesigntimeartifact":   valueMappingType,
+	"messagemapping":                   messageMappingType,
+	"message mapping":                  messageMappingType,
+	"messagemappingdesigntimeartifact": messageMappingType,
+	"scriptcollection":                 scriptCollectionType,
+	"script collection":                scriptCollectionType,
+}
+
+// knownArtifactTypes lists the canonical type names for error messages
+const knownArtifactTypes = "IntegrationFlow, MessageMapping, ScriptCollection, ValueMapping"
+
+// lookupArtifactType returns the artifact type of a type name, ignoring case. Artifacts without
+// a type are integration flows.
+func lookupArtifactType(artifactType string) (artifactTypeNames, bool) {
+	artifactType = strings.ToLower(strings.TrimSpace(artifactType))
+	if artifactType == "" {
+		return integrationFlowType, true
+	}
+	names, ok := artifactTypes[artifactType]
+	return names, ok
+}
+
+// mapArtifactType maps artifact types for deployment API calls
+func mapArtifactType(artifactType string) string {
+	names, ok := lookupArtifactType(artifactType)
+	if !ok {
+		log.Warn().Msgf("Unknown artifact type %s", artifactType)
+		return artifactType
+	}
+	return names.Designtime
+}
+
+// mapArtifactTypeForSync maps artifact types for synchroniser (NewDesigntimeArtifact)
+func mapArtifactTypeForSync(artifactType string) string {
+	names, ok := lookupArtifactType(artifactType)
+	if !ok {
+		log.Warn().Msgf("Unknown artifact type %s", artifactType)
+		return artifactType
+	}
+	return names.Sync
+}
+
+// resolveArtifactTypes replaces custom type names of the artifacts in the configs with the type
+// they are an alias of, and returns an error for artifact types that are not known
+func resolveArtifactTypes(configFiles []*deploy.DeployConfigFile, aliases map[string]string) error {
+	lowerAliases := make(map[string]string, len(aliases))
+	for alias, target := range aliases {
+		if _, ok := lookupArtifactType(target); !ok {
+			return fmt.Errorf("artifact type alias %s refers to unknown artifact type %s", alias, target)
+		}
+		lowerAliases[strings.ToLower(strings.TrimSpace(alias))] = target
+	}
+
+	for _, configFile := range configFiles {
+		for i := range configFile.Config.Packages {
+			pkg := &configFile.Config.Packages[i]
+			for j := range pkg.Artifacts {
+				artifact := &pkg.Artifacts[j]
+				if _, ok := lookupArtifactType(artifact.Type); ok {
+					continue
+				}
+				target, ok := lowerAliases[strings.ToLower(strings.TrimSpace(artifact.Type))]
+				if !ok {
+					return fmt.Errorf("unknown artifact type %s of artifact %s in package %s (known types: %s)",
+						artifact.Type, artifact.Id, pkg.ID, knownArtifactTypes)
+				}
+				log.Debug().Msgf("Artifact %s: type %s is an alias of %s", artifact.Id, artifact.Type, target)
+				artifact.Type = target
+			}
+		}
+	}
+	return nil
+}
@@ -0,0 +1,77 @@
+package cmd
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/engswee/flashpipe/internal/deploy"
+	"github.com/engswee/flashpipe/internal/models"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestMapArtifactType(t *testing.T) {
+	tests := []struct {
+		artifactType string
+		sync         string
+		designtime   string
+	}{
+		{"IntegrationFlow", "Integration", "IntegrationDesigntimeArtifact"},
+		{"Integration Flow", "Integration", "IntegrationDesigntimeArtifact"},
+		{"iflow", "Integration", "IntegrationDesigntimeArtifact"},
+		{"Integration", "Integration", "IntegrationDesigntimeArtifact"},
+		{"IntegrationDesigntimeArtifact", "Integration", "IntegrationDesigntimeArtifact"},
+		{"", "Integration", "IntegrationDesigntimeArtifact"},
+		{"ValueMapping", "ValueMapping", "ValueMappingDesigntimeArtifact"},
+		{"value mapping", "ValueMapping", "ValueMappingDesigntimeArtifact"},
+		{"ValueMappingDesigntimeArtifact", "ValueMapping", "ValueMappingDesigntimeArtifact"},
+		{"MessageMapping", "MessageMapping", "MessageMappingDesigntimeArtifact"},
+		{"messageMapping", "MessageMapping", "MessageMappingDesigntimeArtifact"},
+		{"Message Mapping", "MessageMapping", "MessageMappingDesigntimeArtifact"},
+		{"MessageMappingDesigntimeArtifact", "MessageMapping", "MessageMappingDesigntimeArtifact"},
+		{"ScriptCollection", "ScriptCollection", "ScriptCollection"},
+		{"script collection", "ScriptCollection", "ScriptCollection"},
+		{"RestApi", "RestApi", "RestApi"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.artifactType, func(t *testing.T) {
+			assert.Equal(t, tt.sync, mapArtifactTypeForSync(tt.artifactType))
+			assert.Equal(t, tt.designtime, mapArtifactType(tt.artifactType))
+		})
+	}
+
+	// Every accepted name is covered above
+	covered := make(map[string]bool, len(tests))
+	for _, tt := range tests {
+		covered[strings.ToLower(tt.artifactType)] = true
+	}
+	for name := range artifactTypes {
+		assert.True(t, covered[name], "artifact type %q is not covered", name)
+	}
+}
+
+func TestResolveArtifactTypes(t *testing.T) {
+	newConfigFiles := func(types ...string) []*deploy.DeployConfigFile {
+		pkg := models.Package{ID: "Orders"}
+		for _, artifactType := range types {
+			pkg.Artifacts = append(pkg.Artifacts, models.Artifact{Id: "Artifact" + artifactType, Type: artifactType})
+		}
+		return []*deploy.DeployConfigFile{{Config: &models.DeployConfig{Packages: []models.Package{pkg}}}}
+	}
+
+	configFiles := newConfigFiles("IntegrationFlow", "", "Mapping", "scripts")
+	err := resolveArtifactTypes(configFiles, map[string]string{"mapping": "MessageMapping", "Scripts": "ScriptCollection"})
+	require.NoError(t, err)
+	artifacts := configFiles[0].Config.Packages[0].Artifacts
+	assert.Equal(t, "IntegrationFlow", artifacts[0].Type)
+	assert.Equal(t, "", artifacts[1].Type)
+	assert.Equal(t, "MessageMapping", artifacts[2].Type)
+	assert.Equal(t, "ScriptCollection", artifacts[3].Type)
+
+	// Unknown types are no longer treated as integration flows
+	err = resolveArtifactTypes(newConfigFiles("IntegrationFlows"), nil)
+	assert.ErrorContains(t, err, "unknown artifact type IntegrationFlows of artifact ArtifactIntegrationFlows in package Orders")
+
+	err = resolveArtifactTypes(newConfigFiles("IntegrationFlow"), map[string]string{"mapping": "Mappings"})
+	assert.ErrorContains(t, err, "artifact type alias mapping refers to unknown artifact type Mappings")
+}
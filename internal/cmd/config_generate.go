@@ -1,8 +1,10 @@
 package cmd
 
 import (
+	"encoding/json"
 	"fmt"
 	"os"
+	"path"
 	"path/filepath"
 	"sort"
 	"strings"
@@ -11,6 +13,7 @@ import (
 	"github.com/engswee/flashpipe/internal/analytics"
 	"github.com/engswee/flashpipe/internal/config"
 	"github.com/engswee/flashpipe/internal/file"
+	flashpipeSync "github.com/engswee/flashpipe/internal/sync"
 	"github.com/rs/zerolog/log"
 	"github.com/spf13/cobra"
 	"gopkg.in/yaml.v3"
@@ -46,7 +49,20 @@ Features:
   flashpipe config-generate --artifact-filter "MDMEquipmentMutationOutbound,GenericBroadcaster"
 
   # Combine package and artifact filters
-  flashpipe config-generate --package-filter "DeviceManagement" --artifact-filter "MDMEquipmentMutationOutbound"`,
+  flashpipe config-generate --package-filter "DeviceManagement" --artifact-filter "MDMEquipmentMutationOutbound"
+
+  # Artifact directories named <id>__<name> (snapshot/sync --dir-naming-type COMBINED)
+  flashpipe config-generate --dir-naming-type COMBINED
+
+  # Skip non-artifact directories such as docs and generated output
+  flashpipe config-generate --artifact-dir-exclude "docs,tests,*-generated"
+
+  # List filtered directories and config entries whose directories are missing,
+  # failing when any configured directory is missing
+  flashpipe config-generate --prune-report --strict
+
+  # Generate JSON instead of YAML (also detected from a .json output extension)
+  flashpipe config-generate --output ./001-deploy-config.json`,
 		RunE: func(cmd *cobra.Command, args []string) (err error) {
 			startTime := time.Now()
 			if err = runConfigGenerate(cmd); err != nil {
@@ -65,6 +81,16 @@ Features:
 		"Comma separated list of packages to include (e.g., 'Package1,Package2')")
 	configCmd.Flags().StringSlice("artifact-filter", nil,
 		"Comma separated list of artifacts to include (e.g., 'Artifact1,Artifact2')")
+	configCmd.Flags().String("dir-naming-type", "ID",
+		"How artifact directories are named. Allowed values: ID, COMBINED (<id>__<name>)")
+	configCmd.Flags().StringSlice("artifact-dir-exclude", nil,
+		"Comma separated gitignore-style patterns of directories inside packages that are not artifacts (e.g., 'docs,tests,Orders/generated')")
+	configCmd.Flags().Bool("prune-report", false,
+		"Log directories left out by filters and config entries whose directories no longer exist")
+	configCmd.Flags().Bool("strict", false,
+		"Fail when config entries reference directories that no longer exist (implies --prune-report)")
+	configCmd.Flags().String("format", "",
+		"Output format: yaml or json (default: detected from the output file extension)")
 
 	return configCmd
 }
@@ -74,24 +100,68 @@ func runConfigGenerate(cmd *cobra.Command) error {
 	outputFile := config.GetString(cmd, "output")
 	packageFilter := config.GetStringSlice(cmd, "package-filter")
 	artifactFilter := config.GetStringSlice(cmd, "artifact-filter")
+	dirNamingType := config.GetString(cmd, "dir-naming-type")
+	format := config.GetString(cmd, "format")
+	artifactDirExclude := config.GetStringSlice(cmd, "artifact-dir-exclude")
+	pruneReport := config.GetBool(cmd, "prune-report")
+	strict := config.GetBool(cmd, "strict")
+
+	switch dirNamingType {
+	case "ID", "COMBINED":
+	default:
+		return fmt.Errorf("invalid value for --dir-naming-type = %v", dirNamingType)
+	}
+
+	switch format {
+	case "", configFormatYAML, configFormatJSON:
+	default:
+		return fmt.Errorf("invalid value for --format = %v", format)
+	}
 
 	generator := NewConfigGenerator(packagesDir, outputFile, packageFilter, artifactFilter)
+	generator.DirNamingType = dirNamingType
+	generator.Format = format
+	generator.ArtifactDirExclude = artifactDirExclude
+	generator.Strict = strict
 
 	if err := generator.Generate(); err != nil {
+		if strict && len(generator.Prune.MissingDirs) > 0 {
+			generator.printPruneReport()
+		}
 		return err
 	}
 
+	if pruneReport || strict {
+		generator.printPruneReport()
+	}
+
 	return nil
 }
 
+const (
+	configFormatYAML = "yaml"
+	configFormatJSON = "json"
+)
+
 // ConfigGenerator handles configuration generation
 type ConfigGenerator struct {
-	PackagesDir    string
-	OutputFile     string
-	PackageFilter  []string
-	ArtifactFilter []string
-	ExistingConfig *DeployConfig
-	Stats          GenerationStats
+	PackagesDir        string
+	OutputFile         string
+	PackageFilter      []string
+	ArtifactFilter     []string
+	DirNamingType      string   // ID (default) or COMBINED
+	Format             string   // yaml or json, detected from OutputFile when empty
+	ArtifactDirExclude []string // gitignore-style patterns of package subdirectories that are not artifacts
+	Strict             bool     // fail without writing the config when config entries reference missing directories
+	ExistingConfig     *DeployConfig
+	Stats              GenerationStats
+	Prune              PruneReport
+}
+
+// PruneReport lists what was left out of the generated configuration
+type PruneReport struct {
+	FilteredDirs []string // directories on disk skipped by filters or exclude patterns
+	MissingDirs  []string // config entries dropped because their directory does not exist
 }
 
 // GenerationStats tracks generation statistics
@@ -106,6 +176,7 @@ type GenerationStats struct {
 	ArtifactsAdded             int
 	ArtifactsRemoved           int
 	ArtifactsFiltered          int
+	ArtifactsExcluded          int
 	ArtifactsNameExtracted     int
 	ArtifactsNamePreserved     int
 	ArtifactsTypeExtracted     int
@@ -114,31 +185,91 @@ type GenerationStats struct {
 
 // DeployConfig represents the complete deployment configuration
 type DeployConfig struct {
-	DeploymentPrefix string    `yaml:"deploymentPrefix,omitempty"`
-	Packages         []Package `yaml:"packages"`
+	DeploymentPrefix string    `yaml:"deploymentPrefix,omitempty" json:"deploymentPrefix,omitempty"`
+	Packages         []Package `yaml:"packages" json:"packages"`
 }
 
 // Package represents a SAP CPI package
 type Package struct {
-	ID          string     `yaml:"integrationSuiteId"`
-	PackageDir  string     `yaml:"packageDir,omitempty"`
-	DisplayName string     `yaml:"displayName,omitempty"`
-	Description string     `yaml:"description,omitempty"`
-	ShortText   string     `yaml:"short_text,omitempty"`
-	Sync        bool       `yaml:"sync"`
-	Deploy      bool       `yaml:"deploy"`
-	Artifacts   []Artifact `yaml:"artifacts"`
+	ID          string     `yaml:"integrationSuiteId" json:"integrationSuiteId"`
+	PackageDir  string     `yaml:"packageDir,omitempty" json:"packageDir,omitempty"`
+	DisplayName string     `yaml:"displayName,omitempty" json:"displayName,omitempty"`
+	Description string     `yaml:"description,omitempty" json:"description,omitempty"`
+	ShortText   string     `yaml:"short_text,omitempty" json:"short_text,omitempty"`
+	Version     string     `yaml:"version,omitempty" json:"version,omitempty"`
+	Vendor      string     `yaml:"vendor,omitempty" json:"vendor,omitempty"`
+	Sync        bool       `yaml:"sync" json:"sync"`
+	Deploy      bool       `yaml:"deploy" json:"deploy"`
+	Artifacts   []Artifact `yaml:"artifacts" json:"artifacts"`
 }
 
 // Artifact represents a SAP CPI artifact
 type Artifact struct {
-	Id              string                 `yaml:"artifactId"`
-	ArtifactDir     string                 `yaml:"artifactDir"`
-	DisplayName     string                 `yaml:"displayName,omitempty"`
-	Type            string                 `yaml:"type"`
-	Sync            bool                   `yaml:"sync"`
-	Deploy          bool                   `yaml:"deploy"`
-	ConfigOverrides map[string]interface{} `yaml:"configOverrides,omitempty"`
+	Id                  string                   `yaml:"artifactId" json:"artifactId"`
+	ArtifactDir         string                   `yaml:"artifactDir" json:"artifactDir"`
+	DisplayName         string                   `yaml:"displayName,omitempty" json:"displayName,omitempty"`
+	Type                string                   `yaml:"type" json:"type"`
+	Sync                bool                     `yaml:"sync" json:"sync"`
+	Deploy              bool                     `yaml:"deploy" json:"deploy"`
+	ConfigOverrides     map[string]OverrideValue `yaml:"configOverrides,omitempty" json:"configOverrides,omitempty"`
+	ConfigOverridesFile string                   `yaml:"configOverridesFile,omitempty" json:"configOverridesFile,omitempty"`
+	ValueMappingFile    string                   `yaml:"valueMappingFile,omitempty" json:"valueMappingFile,omitempty"`
+	Order               int                      `yaml:"order,omitempty" json:"order,omitempty"`
+}
+
+// OverrideValue keeps a configOverrides value exactly as it was written (type, quoting and
+// comments) so that regenerating the config does not re-infer and reformat hand-edited values.
+// Keys are emitted in sorted order by both the YAML and JSON encoders.
+type OverrideValue struct {
+	node *yaml.Node
+	raw  json.RawMessage
+}
+
+func (v *OverrideValue) UnmarshalYAML(node *yaml.Node) error {
+	v.node = node
+	v.raw = nil
+	return nil
+}
+
+func (v OverrideValue) MarshalYAML() (interface{}, error) {
+	if v.node != nil {
+		return v.node, nil
+	}
+	return v.value()
+}
+
+func (v *OverrideValue) UnmarshalJSON(data []byte) error {
+	v.raw = append(json.RawMessage(nil), data...)
+	v.node = nil
+	return nil
+}
+
+func (v OverrideValue) MarshalJSON() ([]byte, error) {
+	if v.raw != nil {
+		return v.raw, nil
+	}
+	value, err := v.value()
+	if err != nil {
+		return nil, err
+	}
+	return json.Marshal(value)
+}
+
+// value decodes the override for conversion between YAML and JSON
+func (v OverrideValue) value() (interface{}, error) {
+	var value interface{}
+	if v.node != nil {
+		if err := v.node.Decode(&value); err != nil {
+			return nil, err
+		}
+		return value, nil
+	}
+	if v.raw != nil {
+		if err := json.Unmarshal(v.raw, &value); err != nil {
+			return nil, err
+		}
+	}
+	return value, nil
 }
 
 // PackageMetadata represents metadata from package JSON
@@ -147,6 +278,8 @@ type PackageMetadata struct {
 	Name        string `json:"Name"`
 	Description string `json:"Description"`
 	ShortText   string `json:"ShortText"`
+	Version     string `json:"Version"`
+	Vendor      string `json:"Vendor"`
 }
 
 // NewConfigGenerator creates a new configuration generator
@@ -185,6 +318,50 @@ func (g *ConfigGenerator) shouldIncludeArtifact(artifactName string) bool {
 	return false
 }
 
+// isArtifactDirExcluded checks a package subdirectory against the gitignore-style exclude patterns.
+// Patterns without a slash match the directory name, other patterns match <package>/<directory>.
+// A leading '!' re-includes a directory excluded by an earlier pattern.
+func (g *ConfigGenerator) isArtifactDirExcluded(packageName, dirName string) bool {
+	excluded := false
+	for _, pattern := range g.ArtifactDirExclude {
+		pattern = strings.TrimSpace(pattern)
+		if pattern == "" || strings.HasPrefix(pattern, "#") {
+			continue
+		}
+		negate := strings.HasPrefix(pattern, "!")
+		pattern = strings.TrimPrefix(pattern, "!")
+		if matchDirPattern(pattern, packageName, dirName) {
+			excluded = !negate
+		}
+	}
+	return excluded
+}
+
+func matchDirPattern(pattern, packageName, dirName string) bool {
+	pattern = strings.TrimSuffix(pattern, "/")
+	anchored := strings.HasPrefix(pattern, "/")
+	pattern = strings.TrimPrefix(pattern, "/")
+	if !anchored {
+		pattern = strings.TrimPrefix(pattern, "**/")
+	}
+
+	if !anchored && !strings.Contains(pattern, "/") {
+		matched, _ := path.Match(pattern, dirName)
+		return matched
+	}
+	matched, _ := path.Match(strings.ReplaceAll(pattern, "**", "*"), packageName+"/"+dirName)
+	return matched
+}
+
+// artifactIDFromDirName returns the artifact ID for an artifact directory,
+// taking the directory naming type into account
+func (g *ConfigGenerator) artifactIDFromDirName(dirName string) string {
+	if g.DirNamingType == "COMBINED" {
+		return flashpipeSync.ArtifactIDFromCombinedDirName(dirName)
+	}
+	return dirName
+}
+
 // Generate generates or updates the deployment configuration
 func (g *ConfigGenerator) Generate() error {
 	log.Info().Msg("Generating/Updating Configuration")
@@ -211,7 +388,12 @@ func (g *ConfigGenerator) Generate() error {
 			return fmt.Errorf("failed to read existing config: %w", err)
 		}
 		var existingConfig DeployConfig
-		if err := yaml.Unmarshal(data, &existingConfig); err != nil {
+		if g.outputFormat() == configFormatJSON {
+			err = json.Unmarshal(data, &existingConfig)
+		} else {
+			err = yaml.Unmarshal(data, &existingConfig)
+		}
+		if err != nil {
 			return fmt.Errorf("failed to parse existing config: %w", err)
 		}
 		g.ExistingConfig = &existingConfig
@@ -260,6 +442,7 @@ func (g *ConfigGenerator) Generate() error {
 		// Apply package filter
 		if !g.shouldIncludePackage(packageName) {
 			g.Stats.PackagesFiltered++
+			g.Prune.FilteredDirs = append(g.Prune.FilteredDirs, filepath.Join(g.PackagesDir, packageName))
 			continue
 		}
 
@@ -297,6 +480,13 @@ func (g *ConfigGenerator) Generate() error {
 				if pkg.ShortText == "" {
 					pkg.ShortText = metadata.ShortText
 				}
+				if pkg.Vendor == "" {
+					pkg.Vendor = metadata.Vendor
+				}
+				// Version tracks the source package, so it is refreshed on every run
+				if metadata.Version != "" {
+					pkg.Version = metadata.Version
+				}
 			}
 		} else {
 			pkg = Package{
@@ -310,6 +500,8 @@ func (g *ConfigGenerator) Generate() error {
 				pkg.DisplayName = metadata.Name
 				pkg.Description = metadata.Description
 				pkg.ShortText = metadata.ShortText
+				pkg.Version = metadata.Version
+				pkg.Vendor = metadata.Vendor
 				g.Stats.PackagePropertiesExtracted++
 			}
 
@@ -334,16 +526,24 @@ func (g *ConfigGenerator) Generate() error {
 			}
 
 			artifactName := artEntry.Name()
+			if g.isArtifactDirExcluded(packageName, artifactName) {
+				log.Debug().Msgf("Skipping excluded directory: %s/%s", packageName, artifactName)
+				g.Stats.ArtifactsExcluded++
+				g.Prune.FilteredDirs = append(g.Prune.FilteredDirs, filepath.Join(packageDir, artifactName))
+				continue
+			}
+			artifactID := g.artifactIDFromDirName(artifactName)
 
 			// Apply artifact filter
-			if !g.shouldIncludeArtifact(artifactName) {
+			if !g.shouldIncludeArtifact(artifactID) {
 				g.Stats.ArtifactsFiltered++
+				g.Prune.FilteredDirs = append(g.Prune.FilteredDirs, filepath.Join(packageDir, artifactName))
 				continue
 			}
 
 			artifactDir := filepath.Join(packageDir, artifactName)
 
-			processedArtifacts[artifactName] = true
+			processedArtifacts[artifactID] = true
 
 			// Extract artifact metadata from MANIFEST.MF
 			bundleName, artifactType := g.extractManifestMetadata(artifactDir)
@@ -351,7 +551,7 @@ func (g *ConfigGenerator) Generate() error {
 			// Check if artifact exists in old config
 			var artifact Artifact
 			if existingArtMap, pkgExists := existingArtifacts[packageName]; pkgExists {
-				if existingArt, artExists := existingArtMap[artifactName]; artExists {
+				if existingArt, artExists := existingArtMap[artifactID]; artExists {
 					artifact = existingArt
 					g.Stats.ArtifactsPreserved++
 
@@ -373,18 +573,19 @@ func (g *ConfigGenerator) Generate() error {
 						}
 					}
 
-					if artifact.ArtifactDir == "" {
+					if artifact.ArtifactDir == "" || g.DirNamingType == "COMBINED" {
+						// Combined directory names follow the display name, so always track the current one
 						artifact.ArtifactDir = artifactName
 					}
 				} else {
 					artifact = Artifact{
-						Id:              artifactName,
+						Id:              artifactID,
 						ArtifactDir:     artifactName,
 						DisplayName:     bundleName,
 						Type:            artifactType,
 						Sync:            true,
 						Deploy:          true,
-						ConfigOverrides: make(map[string]interface{}),
+						ConfigOverrides: make(map[string]OverrideValue),
 					}
 
 					if bundleName != "" {
@@ -398,13 +599,13 @@ func (g *ConfigGenerator) Generate() error {
 				}
 			} else {
 				artifact = Artifact{
-					Id:              artifactName,
+					Id:              artifactID,
 					ArtifactDir:     artifactName,
 					DisplayName:     bundleName,
 					Type:            artifactType,
 					Sync:            true,
 					Deploy:          true,
-					ConfigOverrides: make(map[string]interface{}),
+					ConfigOverrides: make(map[string]OverrideValue),
 				}
 
 				if bundleName != "" {
@@ -422,9 +623,10 @@ func (g *ConfigGenerator) Generate() error {
 
 		// Count removed artifacts
 		if existingArtMap, pkgExists := existingArtifacts[packageName]; pkgExists {
-			for artName := range existingArtMap {
+			for artName, art := range existingArtMap {
 				if !processedArtifacts[artName] {
 					g.Stats.ArtifactsRemoved++
+					g.recordMissingDir(filepath.Join(packageDir, artifactDirOrID(art)))
 				}
 			}
 		}
@@ -442,6 +644,7 @@ func (g *ConfigGenerator) Generate() error {
 		for _, pkg := range g.ExistingConfig.Packages {
 			if !processedPackages[pkg.ID] {
 				g.Stats.PackagesRemoved++
+				g.recordMissingDir(filepath.Join(g.PackagesDir, pkg.ID))
 			}
 		}
 	}
@@ -451,6 +654,11 @@ func (g *ConfigGenerator) Generate() error {
 		return newConfig.Packages[i].ID < newConfig.Packages[j].ID
 	})
 
+	// The config is left unchanged, so that the entries of renamed directories are not lost
+	if g.Strict && len(g.Prune.MissingDirs) > 0 {
+		return fmt.Errorf("%d config entries reference missing directories - update packageDir/artifactDir or restore the directories", len(g.Prune.MissingDirs))
+	}
+
 	// Write config file
 	if err := g.writeConfigFile(g.OutputFile, &newConfig); err != nil {
 		return fmt.Errorf("failed to write config file: %w", err)
@@ -461,6 +669,35 @@ func (g *ConfigGenerator) Generate() error {
 	return nil
 }
 
+// recordMissingDir adds a dropped config entry to the prune report when its directory does not exist
+func (g *ConfigGenerator) recordMissingDir(dir string) {
+	if _, err := os.Stat(dir); os.IsNotExist(err) {
+		g.Prune.MissingDirs = append(g.Prune.MissingDirs, dir)
+	}
+}
+
+func artifactDirOrID(art Artifact) string {
+	if art.ArtifactDir != "" {
+		return art.ArtifactDir
+	}
+	return art.Id
+}
+
+func (g *ConfigGenerator) printPruneReport() {
+	sort.Strings(g.Prune.FilteredDirs)
+	sort.Strings(g.Prune.MissingDirs)
+
+	log.Info().Msg("Prune Report:")
+	log.Info().Msgf("  Directories not added to config (filtered/excluded): %d", len(g.Prune.FilteredDirs))
+	for _, dir := range g.Prune.FilteredDirs {
+		log.Info().Msgf("    - %s", dir)
+	}
+	log.Info().Msgf("  Config entries removed because their directory is missing: %d", len(g.Prune.MissingDirs))
+	for _, dir := range g.Prune.MissingDirs {
+		log.Warn().Msgf("    - %s (renamed or deleted? update packageDir/artifactDir)", dir)
+	}
+}
+
 func (g *ConfigGenerator) extractPackageMetadata(packageDir, packageName string) *PackageMetadata {
 	jsonFile := filepath.Join(packageDir, packageName+".json")
 	if _, err := os.Stat(jsonFile); os.IsNotExist(err) {
@@ -477,7 +714,7 @@ func (g *ConfigGenerator) extractPackageMetadata(packageDir, packageName string)
 		D PackageMetadata `json:"d"`
 	}
 
-	if err := yaml.Unmarshal(data, &wrapper); err != nil {
+	if err := json.Unmarshal(data, &wrapper); err != nil {
 		log.Warn().Msgf("Failed to parse package JSON: %v", err)
 		return nil
 	}
@@ -503,7 +740,26 @@ func (g *ConfigGenerator) extractManifestMetadata(artifactDir string) (bundleNam
 	return bundleName, artifactType
 }
 
+// outputFormat returns the explicit format, or the format matching the output file extension
+func (g *ConfigGenerator) outputFormat() string {
+	if g.Format != "" {
+		return g.Format
+	}
+	if strings.EqualFold(filepath.Ext(g.OutputFile), ".json") {
+		return configFormatJSON
+	}
+	return configFormatYAML
+}
+
 func (g *ConfigGenerator) writeConfigFile(outputPath string, cfg *DeployConfig) error {
+	if g.outputFormat() == configFormatJSON {
+		data, err := json.MarshalIndent(cfg, "", "  ")
+		if err != nil {
+			return err
+		}
+		return os.WriteFile(outputPath, append(data, '\n'), 0644)
+	}
+
 	data, err := yaml.Marshal(cfg)
 	if err != nil {
 		return err
@@ -562,6 +818,9 @@ func (g *ConfigGenerator) printSummary() {
 	if g.Stats.ArtifactsFiltered > 0 {
 		log.Info().Msgf("    - Filtered:  %d", g.Stats.ArtifactsFiltered)
 	}
+	if g.Stats.ArtifactsExcluded > 0 {
+		log.Info().Msgf("    - Excluded:  %d (--artifact-dir-exclude)", g.Stats.ArtifactsExcluded)
+	}
 	log.Info().Msg("  Artifact Display Names (Bundle-Name from MANIFEST.MF):")
 	log.Info().Msgf("    - Extracted: %d", g.Stats.ArtifactsNameExtracted)
 	log.Info().Msgf("    - Preserved: %d", g.Stats.ArtifactsNamePreserved)
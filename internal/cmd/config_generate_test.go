@@ -0,0 +1,259 @@
+package cmd
+
+import (
+	"encoding/json"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"gopkg.in/yaml.v3"
+)
+
+func TestConfigGenerate_CombinedDirNaming(t *testing.T) {
+	tempDir, err := os.MkdirTemp("", "config-generate-*")
+	require.NoError(t, err)
+	defer os.RemoveAll(tempDir)
+
+	packagesDir := filepath.Join(tempDir, "packages")
+	require.NoError(t, os.MkdirAll(filepath.Join(packagesDir, "Orders", "Order_Create__Order_Create_S_4", "META-INF"), 0755))
+	outputFile := filepath.Join(tempDir, "deploy-config.yml")
+
+	generator := NewConfigGenerator(packagesDir, outputFile, nil, []string{"Order_Create"})
+	generator.DirNamingType = "COMBINED"
+	require.NoError(t, generator.Generate())
+
+	data, err := os.ReadFile(outputFile)
+	require.NoError(t, err)
+	var cfg DeployConfig
+	require.NoError(t, yaml.Unmarshal(data, &cfg))
+
+	require.Len(t, cfg.Packages, 1)
+	require.Len(t, cfg.Packages[0].Artifacts, 1)
+	assert.Equal(t, "Order_Create", cfg.Packages[0].Artifacts[0].Id)
+	assert.Equal(t, "Order_Create__Order_Create_S_4", cfg.Packages[0].Artifacts[0].ArtifactDir)
+}
+
+func TestConfigGenerate_JSONOutput(t *testing.T) {
+	tempDir, err := os.MkdirTemp("", "config-generate-*")
+	require.NoError(t, err)
+	defer os.RemoveAll(tempDir)
+
+	packagesDir := filepath.Join(tempDir, "packages")
+	require.NoError(t, os.MkdirAll(filepath.Join(packagesDir, "Orders", "Order_Create", "META-INF"), 0755))
+	outputFile := filepath.Join(tempDir, "deploy-config.json")
+
+	require.NoError(t, NewConfigGenerator(packagesDir, outputFile, nil, nil).Generate())
+
+	data, err := os.ReadFile(outputFile)
+	require.NoError(t, err)
+	assert.NotContains(t, string(data), "#")
+	var cfg DeployConfig
+	require.NoError(t, json.Unmarshal(data, &cfg))
+	require.Len(t, cfg.Packages, 1)
+	require.Len(t, cfg.Packages[0].Artifacts, 1)
+	assert.Equal(t, "Order_Create", cfg.Packages[0].Artifacts[0].Id)
+
+	// Settings in an existing JSON config are preserved on regeneration
+	cfg.Packages[0].Artifacts[0].Deploy = false
+	require.NoError(t, json.Unmarshal([]byte(`{"Timeout":"60","Retries":3}`), &cfg.Packages[0].Artifacts[0].ConfigOverrides))
+	data, err = json.Marshal(cfg)
+	require.NoError(t, err)
+	require.NoError(t, os.WriteFile(outputFile, data, 0644))
+
+	require.NoError(t, NewConfigGenerator(packagesDir, outputFile, nil, nil).Generate())
+
+	data, err = os.ReadFile(outputFile)
+	require.NoError(t, err)
+	var regenerated DeployConfig
+	require.NoError(t, json.Unmarshal(data, &regenerated))
+	assert.Equal(t, cfg, regenerated)
+}
+
+func TestConfigGenerate_ArtifactDirExclude(t *testing.T) {
+	tempDir, err := os.MkdirTemp("", "config-generate-*")
+	require.NoError(t, err)
+	defer os.RemoveAll(tempDir)
+
+	packagesDir := filepath.Join(tempDir, "packages")
+	for _, dir := range []string{"Orders/Order_Create", "Orders/docs", "Orders/generated", "Orders/tests", "Invoices/generated", "Invoices/Invoice_Post"} {
+		require.NoError(t, os.MkdirAll(filepath.Join(packagesDir, dir, "META-INF"), 0755))
+	}
+	outputFile := filepath.Join(tempDir, "deploy-config.yml")
+
+	generator := NewConfigGenerator(packagesDir, outputFile, nil, nil)
+	generator.ArtifactDirExclude = []string{"docs/", "test*", "/Orders/generated", "# comment"}
+	require.NoError(t, generator.Generate())
+	assert.Equal(t, 3, generator.Stats.ArtifactsExcluded)
+
+	data, err := os.ReadFile(outputFile)
+	require.NoError(t, err)
+	var cfg DeployConfig
+	require.NoError(t, yaml.Unmarshal(data, &cfg))
+
+	artifacts := make(map[string][]string)
+	for _, pkg := range cfg.Packages {
+		for _, art := range pkg.Artifacts {
+			artifacts[pkg.ID] = append(artifacts[pkg.ID], art.Id)
+		}
+	}
+	assert.Equal(t, []string{"Order_Create"}, artifacts["Orders"])
+	assert.ElementsMatch(t, []string{"Invoice_Post", "generated"}, artifacts["Invoices"])
+}
+
+func TestIsArtifactDirExcluded_Negation(t *testing.T) {
+	generator := &ConfigGenerator{ArtifactDirExclude: []string{"*", "!Order_*"}}
+	assert.True(t, generator.isArtifactDirExcluded("Orders", "docs"))
+	assert.False(t, generator.isArtifactDirExcluded("Orders", "Order_Create"))
+
+	generator.ArtifactDirExclude = []string{"**/generated", "Inv*/tmp"}
+	assert.True(t, generator.isArtifactDirExcluded("Orders", "generated"))
+	assert.True(t, generator.isArtifactDirExcluded("Invoices", "tmp"))
+	assert.False(t, generator.isArtifactDirExcluded("Orders", "tmp"))
+}
+
+func TestConfigGenerate_RegenerationIsByteIdentical(t *testing.T) {
+	tempDir, err := os.MkdirTemp("", "config-generate-*")
+	require.NoError(t, err)
+	defer os.RemoveAll(tempDir)
+
+	packagesDir := filepath.Join(tempDir, "packages")
+	require.NoError(t, os.MkdirAll(filepath.Join(packagesDir, "Orders", "Order_Create", "META-INF"), 0755))
+	outputFile := filepath.Join(tempDir, "deploy-config.yml")
+
+	require.NoError(t, NewConfigGenerator(packagesDir, outputFile, nil, nil).Generate())
+
+	// Hand-edit overrides with values whose type or formatting would change if re-inferred
+	data, err := os.ReadFile(outputFile)
+	require.NoError(t, err)
+	edited := strings.Replace(string(data), "          deploy: true\n", `          deploy: true
+          configOverrides:
+            Enabled: "true"
+            Port: 0x1F90
+            Ratio: 1.0
+            Receiver: 'ERP_100'
+            Timeout: 60 # seconds
+`, 1)
+	require.NotEqual(t, string(data), edited)
+	require.NoError(t, os.WriteFile(outputFile, []byte(edited), 0644))
+
+	require.NoError(t, NewConfigGenerator(packagesDir, outputFile, nil, nil).Generate())
+
+	regenerated, err := os.ReadFile(outputFile)
+	require.NoError(t, err)
+	assert.Equal(t, edited, string(regenerated))
+}
+
+func TestOverrideValue_ConvertsBetweenFormats(t *testing.T) {
+	var fromYAML map[string]OverrideValue
+	require.NoError(t, yaml.Unmarshal([]byte("Port: 0x1F90\nReceiver: 'ERP_100'\nRatio: 1.5\n"), &fromYAML))
+	data, err := json.Marshal(fromYAML)
+	require.NoError(t, err)
+	assert.JSONEq(t, `{"Port":8080,"Ratio":1.5,"Receiver":"ERP_100"}`, string(data))
+
+	var fromJSON map[string]OverrideValue
+	require.NoError(t, json.Unmarshal([]byte(`{"Timeout":"60","Retries":3}`), &fromJSON))
+	data, err = yaml.Marshal(fromJSON)
+	require.NoError(t, err)
+	assert.Equal(t, "Retries: 3\nTimeout: \"60\"\n", string(data))
+}
+
+func TestConfigGenerate_PruneReport(t *testing.T) {
+	tempDir, err := os.MkdirTemp("", "config-generate-*")
+	require.NoError(t, err)
+	defer os.RemoveAll(tempDir)
+
+	packagesDir := filepath.Join(tempDir, "packages")
+	for _, dir := range []string{"Orders/Order_Create_v2", "Orders/docs", "Invoices/Invoice_Post"} {
+		require.NoError(t, os.MkdirAll(filepath.Join(packagesDir, dir), 0755))
+	}
+	outputFile := filepath.Join(tempDir, "deploy-config.yml")
+
+	// Existing config still references the artifact folder before it was renamed, and a deleted package
+	existing := DeployConfig{Packages: []Package{
+		{ID: "Orders", Artifacts: []Artifact{{Id: "Order_Create", ArtifactDir: "Order_Create"}}},
+		{ID: "Shipments", Artifacts: []Artifact{{Id: "Ship", ArtifactDir: "Ship"}}},
+	}}
+	data, err := yaml.Marshal(existing)
+	require.NoError(t, err)
+	require.NoError(t, os.WriteFile(outputFile, data, 0644))
+
+	generator := NewConfigGenerator(packagesDir, outputFile, []string{"Orders", "Shipments"}, nil)
+	generator.ArtifactDirExclude = []string{"docs"}
+	require.NoError(t, generator.Generate())
+
+	assert.Equal(t, []string{
+		filepath.Join(packagesDir, "Invoices"),
+		filepath.Join(packagesDir, "Orders", "docs"),
+	}, generator.Prune.FilteredDirs)
+	assert.ElementsMatch(t, []string{
+		filepath.Join(packagesDir, "Orders", "Order_Create"),
+		filepath.Join(packagesDir, "Shipments"),
+	}, generator.Prune.MissingDirs)
+}
+
+func TestConfigGenerate_StrictKeepsConfig(t *testing.T) {
+	tempDir, err := os.MkdirTemp("", "config-generate-*")
+	require.NoError(t, err)
+	defer os.RemoveAll(tempDir)
+
+	packagesDir := filepath.Join(tempDir, "packages")
+	require.NoError(t, os.MkdirAll(filepath.Join(packagesDir, "Orders", "Order_Create_v2"), 0755))
+	outputFile := filepath.Join(tempDir, "deploy-config.yml")
+
+	existing := DeployConfig{Packages: []Package{
+		{ID: "Orders", Artifacts: []Artifact{{Id: "Order_Create", ArtifactDir: "Order_Create"}}},
+	}}
+	data, err := yaml.Marshal(existing)
+	require.NoError(t, err)
+	require.NoError(t, os.WriteFile(outputFile, data, 0644))
+
+	generator := NewConfigGenerator(packagesDir, outputFile, nil, nil)
+	generator.Strict = true
+	assert.EqualError(t, generator.Generate(), "1 config entries reference missing directories - update packageDir/artifactDir or restore the directories")
+
+	content, err := os.ReadFile(outputFile)
+	require.NoError(t, err)
+	assert.Equal(t, string(data), string(content))
+}
+
+func TestConfigGenerate_PackageMetadata(t *testing.T) {
+	tempDir, err := os.MkdirTemp("", "config-generate-*")
+	require.NoError(t, err)
+	defer os.RemoveAll(tempDir)
+
+	packagesDir := filepath.Join(tempDir, "packages")
+	require.NoError(t, os.MkdirAll(filepath.Join(packagesDir, "Orders", "Order_Create"), 0755))
+	require.NoError(t, os.MkdirAll(filepath.Join(packagesDir, "Invoices", "Invoice_Post"), 0755))
+	require.NoError(t, os.WriteFile(filepath.Join(packagesDir, "Orders", "Orders.json"),
+		[]byte(`{"d":{"Id":"Orders","Name":"Order Processing","ShortText":"Orders","Version":"1.0.3","Vendor":"ACME","Mode":"EDIT_ALLOWED"}}`), 0644))
+	outputFile := filepath.Join(tempDir, "deploy-config.yml")
+
+	// Existing config records an older version and a custom vendor
+	existing := DeployConfig{Packages: []Package{{ID: "Orders", Version: "1.0.2", Vendor: "Custom"}}}
+	data, err := yaml.Marshal(existing)
+	require.NoError(t, err)
+	require.NoError(t, os.WriteFile(outputFile, data, 0644))
+
+	require.NoError(t, NewConfigGenerator(packagesDir, outputFile, nil, nil).Generate())
+
+	data, err = os.ReadFile(outputFile)
+	require.NoError(t, err)
+	var cfg DeployConfig
+	require.NoError(t, yaml.Unmarshal(data, &cfg))
+	require.Len(t, cfg.Packages, 2)
+
+	orders := cfg.Packages[1]
+	require.Equal(t, "Orders", orders.ID)
+	assert.Equal(t, "Order Processing", orders.DisplayName)
+	assert.Equal(t, "1.0.3", orders.Version)
+	assert.Equal(t, "Custom", orders.Vendor)
+
+	// Packages without a package JSON have no version
+	invoices := cfg.Packages[0]
+	require.Equal(t, "Invoices", invoices.ID)
+	assert.Empty(t, invoices.Version)
+	assert.NotContains(t, string(data), "version: \"\"")
+}
@@ -1,6 +1,7 @@
 package cmd
 
 import (
+	"context"
 	"fmt"
 	"time"
 
@@ -68,17 +69,20 @@ func runDeploy(cmd *cobra.Command) error {
 	maxCheckLimit := config.GetIntWithFallback(cmd, "max-check-limit", "deploy.maxCheckLimit")
 	compareVersions := config.GetBoolWithFallback(cmd, "compare-versions", "deploy.compareVersions")
 
-	err := deployArtifacts(artifactIds, artifactType, delayLength, maxCheckLimit, compareVersions, serviceDetails)
+	err := deployArtifacts(cmd.Context(), artifactIds, artifactType, delayLength, maxCheckLimit, compareVersions, serviceDetails)
 	if err != nil {
 		return err
 	}
 	return nil
 }
 
-func deployArtifacts(artifactIds []string, artifactType string, delayLength int, maxCheckLimit int, compareVersions bool, serviceDetails *api.ServiceDetails) error {
+func deployArtifacts(ctx context.Context, artifactIds []string, artifactType string, delayLength int, maxCheckLimit int, compareVersions bool, serviceDetails *api.ServiceDetails) error {
+	if ctx == nil {
+		ctx = context.Background()
+	}
 
-	// Initialise HTTP executer
-	exe := api.InitHTTPExecuter(serviceDetails)
+	// Initialise HTTP executer, requests are aborted when ctx is cancelled
+	exe := api.InitHTTPExecuter(serviceDetails).WithContext(ctx)
 
 	// Initialise designtime artifact
 	dt := api.NewDesigntimeArtifact(artifactType, exe)
@@ -90,6 +94,9 @@ func deployArtifacts(artifactIds []string, artifactType string, delayLength int,
 
 	// Loop and deploy each artifact
 	for i, id := range artifactIds {
+		if err := ctx.Err(); err != nil {
+			return err
+		}
 		log.Info().Msgf("Processing artifact %d - %v", i+1, id)
 		err := deploySingle(dt, rt, id, compareVersions)
 		// TODO - PRIO1 write error wrapper - https://go.dev/blog/errors-are-values
@@ -100,7 +107,7 @@ func deployArtifacts(artifactIds []string, artifactType string, delayLength int,
 
 	// Check deployment status of artifacts
 	for i, id := range artifactIds {
-		err := checkDeploymentStatus(rt, delayLength, maxCheckLimit, id)
+		err := checkDeploymentStatus(ctx, rt, delayLength, maxCheckLimit, id)
 		if err != nil {
 			return err
 		}
@@ -152,24 +159,29 @@ func deploySingle(artifact api.DesigntimeArtifact, runtime *api.Runtime, id stri
 	return nil
 }
 
-func checkDeploymentStatus(runtime *api.Runtime, delayLength int, maxCheckLimit int, id string) error {
+func checkDeploymentStatus(ctx context.Context, runtime *api.Runtime, delayLength int, maxCheckLimit int, id string) error {
 	log.Info().Msgf("Checking runtime status for artifact %v every %d seconds up to %d times", id, delayLength, maxCheckLimit)
 
 	for i := 0; i < maxCheckLimit; i++ {
-		version, status, err := runtime.Get(id)
+		deployStatus, err := queryDeployStatus(runtime, id)
 		if err != nil {
 			return err
 		}
+		status := deployStatus.Status
 		log.Info().Msgf("Check %d - Current artifact runtime status = %s", i+1, status)
-		if version == "NOT_DEPLOYED" {
-			time.Sleep(time.Duration(delayLength) * time.Second)
+		if status == api.NotDeployed {
+			if err := sleepWithContext(ctx, time.Duration(delayLength)*time.Second); err != nil {
+				return err
+			}
 			continue
 		}
 		if status == "STARTED" {
 			return nil
 		} else if status != "STARTING" {
 			// If there is an error, delay before getting the error details as it sometimes return 204 when the error details are not available yet
-			time.Sleep(time.Duration(delayLength) * time.Second)
+			if err := sleepWithContext(ctx, time.Duration(delayLength)*time.Second); err != nil {
+				return err
+			}
 			errorMessage, err := runtime.GetErrorInfo(id)
 			if err != nil {
 				return err
@@ -179,7 +191,21 @@ func checkDeploymentStatus(runtime *api.Runtime, delayLength int, maxCheckLimit
 		if i == (maxCheckLimit - 1) {
 			return fmt.Errorf("Artifact status remained in %s after %d checks", status, maxCheckLimit)
 		}
-		time.Sleep(time.Duration(delayLength) * time.Second)
+		if err := sleepWithContext(ctx, time.Duration(delayLength)*time.Second); err != nil {
+			return err
+		}
 	}
 	return nil
 }
+
+// sleepWithContext pauses for the given duration, returning early with the context error when ctx is cancelled
+func sleepWithContext(ctx context.Context, d time.Duration) error {
+	timer := time.NewTimer(d)
+	defer timer.Stop()
+	select {
+	case <-ctx.Done():
+		return ctx.Err()
+	case <-timer.C:
+		return nil
+	}
+}
@@ -0,0 +1,207 @@
+package cmd
+
+import (
+	"encoding/json"
+	"fmt"
+	"io"
+	"strings"
+	"text/tabwriter"
+	"time"
+
+	"github.com/engswee/flashpipe/internal/analytics"
+	"github.com/engswee/flashpipe/internal/api"
+	"github.com/engswee/flashpipe/internal/config"
+	"github.com/engswee/flashpipe/internal/str"
+	"github.com/rs/zerolog/log"
+	"github.com/spf13/cobra"
+)
+
+// ArtifactDeployStatus is the runtime deployment status of an artifact
+type ArtifactDeployStatus struct {
+	PackageID    string `json:"packageId,omitempty"`
+	ArtifactID   string `json:"artifactId"`
+	ArtifactType string `json:"artifactType,omitempty"`
+	Status       string `json:"status"`
+	Version      string `json:"version,omitempty"`
+	Error        string `json:"error,omitempty"`
+}
+
+func NewDeployStatusCommand() *cobra.Command {
+
+	deployStatusCmd := &cobra.Command{
+		Use:   "deploy-status",
+		Short: "Show runtime deployment status of artifacts",
+		Long: `Show the runtime deployment status (STARTED, STARTING, ERROR, NOT_DEPLOYED, ...)
+and the deployed version of artifacts on the SAP Integration Suite tenant,
+without deploying anything.
+
+The artifacts are given with --artifact-ids, or all artifacts of the package
+given with --package-id are shown. When both are set, the artifact IDs must
+belong to the package.
+
+Configuration:
+  Settings can be loaded from the global config file (--config) under the
+  'deployStatus' section. CLI flags override config file settings.`,
+		Example: `  # Status of two artifacts
+  flashpipe deploy-status --artifact-ids OrderFlow,InvoiceFlow
+
+  # Status of all integration flows of a package as JSON
+  flashpipe deploy-status --package-id Orders --artifact-type Integration --output json`,
+		PreRunE: func(cmd *cobra.Command, args []string) error {
+			artifactType := config.GetStringWithFallback(cmd, "artifact-type", "deployStatus.artifactType")
+			switch artifactType {
+			case "", "MessageMapping", "ScriptCollection", "Integration", "ValueMapping":
+			default:
+				return fmt.Errorf("invalid value for --artifact-type = %v", artifactType)
+			}
+			output := config.GetStringWithFallback(cmd, "output", "deployStatus.output")
+			if output != "table" && output != "json" {
+				return fmt.Errorf("invalid output format %q: must be 'table' or 'json'", output)
+			}
+			return nil
+		},
+		RunE: func(cmd *cobra.Command, args []string) (err error) {
+			startTime := time.Now()
+			if err = runDeployStatus(cmd); err != nil {
+				cmd.SilenceUsage = true
+			}
+			analytics.Log(cmd, err, startTime)
+			return
+		},
+	}
+
+	// Define cobra flags, the default value has the lowest (least significant) precedence
+	// Note: These can be set in config file under 'deployStatus' key
+	deployStatusCmd.Flags().StringSlice("artifact-ids", nil, "Comma separated list of artifact IDs (config: deployStatus.artifactIds)")
+	deployStatusCmd.Flags().String("package-id", "", "Show the artifacts of this package, or only those of --artifact-ids in it (config: deployStatus.packageId)")
+	deployStatusCmd.Flags().String("artifact-type", "", "Only show artifacts of this type within --package-id. Allowed values: Integration, MessageMapping, ScriptCollection, ValueMapping (config: deployStatus.artifactType)")
+	deployStatusCmd.Flags().String("output", "table", "Output format: 'table' or 'json' (config: deployStatus.output)")
+
+	return deployStatusCmd
+}
+
+func runDeployStatus(cmd *cobra.Command) error {
+	artifactIds := str.TrimSlice(config.GetStringSliceWithFallback(cmd, "artifact-ids", "deployStatus.artifactIds"))
+	packageId := config.GetStringWithFallback(cmd, "package-id", "deployStatus.packageId")
+	artifactType := config.GetStringWithFallback(cmd, "artifact-type", "deployStatus.artifactType")
+	output := config.GetStringWithFallback(cmd, "output", "deployStatus.output")
+
+	if len(artifactIds) == 0 && packageId == "" {
+		return fmt.Errorf("--artifact-ids or --package-id is required")
+	}
+
+	log.Info().Msg("Executing deploy-status command")
+
+	serviceDetails := api.GetServiceDetails(cmd)
+	exe := api.InitHTTPExecuter(serviceDetails).WithContext(cmd.Context())
+
+	targets := make([]ArtifactDeployStatus, 0, len(artifactIds))
+	if packageId != "" {
+		artifacts, err := api.NewIntegrationPackage(exe).GetAllArtifacts(packageId)
+		if err != nil {
+			return fmt.Errorf("failed to get artifacts of package %s: %w", packageId, err)
+		}
+		targets, err = packageStatusTargets(packageId, artifacts, artifactIds, artifactType)
+		if err != nil {
+			return err
+		}
+	} else {
+		for _, id := range artifactIds {
+			targets = append(targets, ArtifactDeployStatus{ArtifactID: id, ArtifactType: artifactType})
+		}
+	}
+
+	statuses, err := getDeployStatuses(api.NewRuntime(exe), targets)
+	if err != nil {
+		return err
+	}
+
+	if output == "json" {
+		return writeDeployStatusJSON(cmd.OutOrStdout(), statuses)
+	}
+	writeDeployStatusTable(cmd.OutOrStdout(), statuses)
+	return nil
+}
+
+// packageStatusTargets returns the artifacts of a package to query, limited to artifactIds and
+// artifactType when set. Artifact IDs that are not in the package are reported as error.
+func packageStatusTargets(packageId string, artifacts []*api.ArtifactDetails, artifactIds []string, artifactType string) ([]ArtifactDeployStatus, error) {
+	inPackage := make(map[string]*api.ArtifactDetails, len(artifacts))
+	for _, artifact := range artifacts {
+		inPackage[artifact.Id] = artifact
+	}
+
+	var targets []ArtifactDeployStatus
+	if len(artifactIds) > 0 {
+		for _, id := range artifactIds {
+			artifact, exists := inPackage[id]
+			if !exists {
+				return nil, fmt.Errorf("artifact %s not found in package %s", id, packageId)
+			}
+			if artifactType == "" || artifact.ArtifactType == artifactType {
+				targets = append(targets, ArtifactDeployStatus{PackageID: packageId, ArtifactID: id, ArtifactType: artifact.ArtifactType})
+			}
+		}
+		return targets, nil
+	}
+
+	for _, artifact := range artifacts {
+		if artifactType == "" || artifact.ArtifactType == artifactType {
+			targets = append(targets, ArtifactDeployStatus{PackageID: packageId, ArtifactID: artifact.Id, ArtifactType: artifact.ArtifactType})
+		}
+	}
+	return targets, nil
+}
+
+// getDeployStatuses queries the runtime status of the targets. For failed deployments the
+// error information of the runtime artifact is added when it is available.
+func getDeployStatuses(rt *api.Runtime, targets []ArtifactDeployStatus) ([]ArtifactDeployStatus, error) {
+	statuses := make([]ArtifactDeployStatus, 0, len(targets))
+	for _, target := range targets {
+		deployStatus, err := queryDeployStatus(rt, target.ArtifactID)
+		if err != nil {
+			return nil, err
+		}
+		target.Status = deployStatus.Status
+		target.Version = deployStatus.Version
+		switch target.Status {
+		case "STARTED", "STARTING", api.NotDeployed:
+		default:
+			errorMessage, err := rt.GetErrorInfo(target.ArtifactID)
+			if err != nil {
+				log.Warn().Msgf("Failed to get error information of artifact %s: %v", target.ArtifactID, err)
+			} else {
+				target.Error = errorMessage
+			}
+		}
+		statuses = append(statuses, target)
+	}
+	return statuses, nil
+}
+
+// queryDeployStatus returns the runtime status of an artifact, with status NOT_DEPLOYED when it is not deployed
+func queryDeployStatus(rt *api.Runtime, id string) (*ArtifactDeployStatus, error) {
+	version, status, err := rt.GetStatus(id)
+	if err != nil {
+		return nil, err
+	}
+	return &ArtifactDeployStatus{ArtifactID: id, Status: status, Version: version}, nil
+}
+
+func writeDeployStatusJSON(w io.Writer, statuses []ArtifactDeployStatus) error {
+	encoder := json.NewEncoder(w)
+	encoder.SetIndent("", "  ")
+	if err := encoder.Encode(statuses); err != nil {
+		return fmt.Errorf("failed to encode deploy status: %w", err)
+	}
+	return nil
+}
+
+func writeDeployStatusTable(w io.Writer, statuses []ArtifactDeployStatus) {
+	tw := tabwriter.NewWriter(w, 0, 0, 2, ' ', 0)
+	fmt.Fprintln(tw, "ARTIFACT\tTYPE\tSTATUS\tVERSION\tERROR")
+	for _, s := range statuses {
+		fmt.Fprintf(tw, "%s\t%s\t%s\t%s\t%s\n", s.ArtifactID, s.ArtifactType, s.Status, s.Version, strings.ReplaceAll(s.Error, "\n", " "))
+	}
+	tw.Flush()
+}
@@ -0,0 +1,87 @@
+package cmd
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/engswee/flashpipe/internal/api"
+	"github.com/engswee/flashpipe/internal/httpclnt"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestGetDeployStatuses(t *testing.T) {
+	svr := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		switch r.URL.Path {
+		case "/api/v1/IntegrationRuntimeArtifacts('OrderFlow')":
+			fmt.Fprint(w, `{"d":{"Version":"1.0.2","Status":"STARTED"}}`)
+		case "/api/v1/IntegrationRuntimeArtifacts('InvoiceFlow')":
+			fmt.Fprint(w, `{"d":{"Version":"1.1.0","Status":"ERROR"}}`)
+		case "/api/v1/IntegrationRuntimeArtifacts('InvoiceFlow')/ErrorInformation/$value":
+			fmt.Fprint(w, `{"parameter":["Unresolved receiver endpoint"]}`)
+		default:
+			w.WriteHeader(http.StatusNotFound)
+		}
+	}))
+	defer svr.Close()
+
+	host, port := httpclnt.GetHostPort(svr.URL)
+	exe := httpclnt.New("", "", "", "", "user", "password", host, "http", port, false)
+
+	statuses, err := getDeployStatuses(api.NewRuntime(exe), []ArtifactDeployStatus{
+		{ArtifactID: "OrderFlow", ArtifactType: "Integration"},
+		{ArtifactID: "InvoiceFlow", ArtifactType: "Integration"},
+		{ArtifactID: "NewFlow", ArtifactType: "Integration"},
+	})
+	require.NoError(t, err)
+	require.Len(t, statuses, 3)
+
+	assert.Equal(t, ArtifactDeployStatus{ArtifactID: "OrderFlow", ArtifactType: "Integration", Status: "STARTED", Version: "1.0.2"}, statuses[0])
+	assert.Equal(t, ArtifactDeployStatus{ArtifactID: "InvoiceFlow", ArtifactType: "Integration", Status: "ERROR", Version: "1.1.0",
+		Error: "Unresolved receiver endpoint"}, statuses[1])
+	assert.Equal(t, ArtifactDeployStatus{ArtifactID: "NewFlow", ArtifactType: "Integration", Status: api.NotDeployed}, statuses[2])
+
+	var buf bytes.Buffer
+	require.NoError(t, writeDeployStatusJSON(&buf, statuses))
+	var decoded []map[string]interface{}
+	require.NoError(t, json.Unmarshal(buf.Bytes(), &decoded))
+	assert.Equal(t, "STARTED", decoded[0]["status"])
+	assert.Equal(t, "1.0.2", decoded[0]["version"])
+	assert.NotContains(t, decoded[2], "version")
+
+	buf.Reset()
+	writeDeployStatusTable(&buf, statuses)
+	assert.Contains(t, buf.String(), "ARTIFACT")
+	assert.Regexp(t, `InvoiceFlow\s+Integration\s+ERROR\s+1\.1\.0\s+Unresolved receiver endpoint`, buf.String())
+}
+
+func TestPackageStatusTargets(t *testing.T) {
+	artifacts := []*api.ArtifactDetails{
+		{Id: "OrderFlow", ArtifactType: "Integration"},
+		{Id: "OrderScripts", ArtifactType: "ScriptCollection"},
+	}
+
+	targets, err := packageStatusTargets("Orders", artifacts, nil, "")
+	require.NoError(t, err)
+	assert.Equal(t, []ArtifactDeployStatus{
+		{PackageID: "Orders", ArtifactID: "OrderFlow", ArtifactType: "Integration"},
+		{PackageID: "Orders", ArtifactID: "OrderScripts", ArtifactType: "ScriptCollection"},
+	}, targets)
+
+	targets, err = packageStatusTargets("Orders", artifacts, nil, "ScriptCollection")
+	require.NoError(t, err)
+	require.Len(t, targets, 1)
+	assert.Equal(t, "OrderScripts", targets[0].ArtifactID)
+
+	targets, err = packageStatusTargets("Orders", artifacts, []string{"OrderFlow"}, "")
+	require.NoError(t, err)
+	require.Len(t, targets, 1)
+	assert.Equal(t, "OrderFlow", targets[0].ArtifactID)
+
+	_, err = packageStatusTargets("Orders", artifacts, []string{"InvoiceFlow"}, "")
+	assert.EqualError(t, err, "artifact InvoiceFlow not found in package Orders")
+}
@@ -1,18 +1,29 @@
 package cmd
 
 import (
+	"context"
 	"encoding/json"
+	"errors"
 	"fmt"
 	"os"
+	"os/signal"
 	"path/filepath"
+	"regexp"
+	"sort"
 	"strings"
 	"sync"
+	"syscall"
+	"time"
 
 	"github.com/engswee/flashpipe/internal/api"
 	"github.com/engswee/flashpipe/internal/config"
 	"github.com/engswee/flashpipe/internal/deploy"
+	"github.com/engswee/flashpipe/internal/file"
+	"github.com/engswee/flashpipe/internal/httpclnt"
 	"github.com/engswee/flashpipe/internal/models"
+	"github.com/engswee/flashpipe/internal/str"
 	flashpipeSync "github.com/engswee/flashpipe/internal/sync"
+	"github.com/rs/zerolog"
 	"github.com/rs/zerolog/log"
 	"github.com/spf13/cobra"
 	"github.com/spf13/viper"
@@ -27,16 +38,25 @@ const (
 	ModeDeployOnly      OperationMode = "deploy-only"
 )
 
-// ProcessingStats tracks processing statistics
+// ProcessingStats tracks processing statistics. During the deploy phase the results of several
+// packages are recorded concurrently, so they are only recorded via methods holding mu.
 type ProcessingStats struct {
+	mu                  sync.Mutex
+	packageDeployFailed map[string]bool // deploy outcome per package, see recordPackageDeploy
+	completed           *completedWork  // work of previous runs recorded in the --state-file
+
+	TenantName                string
 	PackagesUpdated           int
 	PackagesDeployed          int
 	PackagesFailed            int
 	PackagesFiltered          int
+	PackagesSkipped           int // existing packages whose metadata was not updated by --skip-package-update
 	ArtifactsTotal            int
 	ArtifactsDeployedSuccess  int
 	ArtifactsDeployedFailed   int
 	ArtifactsFiltered         int
+	ArtifactsUnchanged        int // not updated by --only-changed as the tenant content is identical
+	ArtifactsResumed          int // updates and deployments skipped as completed in a previous run
 	UpdateFailures            int
 	DeployFailures            int
 	SuccessfulPackageUpdates  map[string]bool
@@ -45,14 +65,216 @@ type ProcessingStats struct {
 	FailedPackageUpdates      map[string]bool
 	FailedArtifactUpdates     map[string]bool
 	FailedArtifactDeploys     map[string]bool
+	SkippedArtifactUpdates    map[string]bool // skipped by --update-existing-only or --create-only, not deployed
+	DeployAborted             bool            // remaining deployments were not started as --max-failures was reached
+	ArtifactsNotDeployed      int             // deployments not started after --max-failures or a failed lower deployOrder
+	PackageResults            []*PackageResult
+	StartTime                 time.Time
+	EndTime                   time.Time
+	UpdatePhaseDuration       time.Duration
+	DeployPhaseDuration       time.Duration
+	TotalDuration             time.Duration
+}
+
+// ResultStatus is the outcome of an update or deploy step
+type ResultStatus string
+
+const (
+	ResultNotRun  ResultStatus = ""
+	ResultSuccess ResultStatus = "success"
+	ResultFailed  ResultStatus = "failed"
+	ResultSkipped ResultStatus = "skipped"
+)
+
+// PackageResult captures the outcome of processing a single package
+type PackageResult struct {
+	PackageID    string
+	UpdateStatus ResultStatus
+	Error        string
+	Artifacts    []*ArtifactResult
+}
+
+// ArtifactResult captures the update and deploy outcome of a single artifact
+type ArtifactResult struct {
+	ArtifactID   string
+	UpdateStatus ResultStatus
+	DeployStatus ResultStatus
+	Error        string
+	// DeployDuration is the time from starting the deployment until its final status
+	DeployDuration time.Duration
+	// Version and deployment time on the runtime after a successful deployment
+	DeployedVersion string
+	DeployedOn      time.Time
+}
+
+// packageResult returns the result entry for the package, creating it if needed
+func (s *ProcessingStats) packageResult(packageID string) *PackageResult {
+	for _, r := range s.PackageResults {
+		if r.PackageID == packageID {
+			return r
+		}
+	}
+	r := &PackageResult{PackageID: packageID}
+	s.PackageResults = append(s.PackageResults, r)
+	return r
+}
+
+// recordDeployResult records the outcome of deploying an artifact. It is safe for concurrent use.
+func (s *ProcessingStats) recordDeployResult(packageID string, result deployResult) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	artifactResult := s.packageResult(packageID).artifact(result.Task.ArtifactID)
+	artifactResult.DeployDuration = result.Duration
+	if result.Skipped != "" {
+		if result.Skipped == skippedMaxFailures {
+			s.DeployAborted = true
+		}
+		s.ArtifactsNotDeployed++
+		artifactResult.DeployStatus = ResultSkipped
+		artifactResult.Error = result.Skipped
+		return
+	}
+	if result.Error != nil {
+		s.ArtifactsDeployedFailed++
+		s.DeployFailures++
+		s.FailedArtifactDeploys[result.Task.ArtifactID] = true
+		artifactResult.DeployStatus = ResultFailed
+		artifactResult.Error = result.Error.Error()
+	} else {
+		s.ArtifactsDeployedSuccess++
+		s.SuccessfulArtifactDeploys[result.Task.ArtifactID] = true
+		artifactResult.DeployStatus = ResultSuccess
+		artifactResult.DeployedVersion = result.Version
+		artifactResult.DeployedOn = result.DeployedOn
+	}
+}
+
+// artifactTiming is the deploy duration of an artifact
+type artifactTiming struct {
+	PackageID  string
+	ArtifactID string
+	Duration   time.Duration
+}
+
+// slowestDeploys returns up to n deployed artifacts with the longest deploy durations, slowest first
+func (s *ProcessingStats) slowestDeploys(n int) []artifactTiming {
+	var timings []artifactTiming
+	for _, pkg := range s.PackageResults {
+		for _, artifact := range pkg.Artifacts {
+			if artifact.DeployDuration > 0 {
+				timings = append(timings, artifactTiming{PackageID: pkg.PackageID, ArtifactID: artifact.ArtifactID, Duration: artifact.DeployDuration})
+			}
+		}
+	}
+	sort.SliceStable(timings, func(i, j int) bool {
+		return timings[i].Duration > timings[j].Duration
+	})
+	if len(timings) > n {
+		timings = timings[:n]
+	}
+	return timings
+}
+
+// deployedVersion is the runtime version of a successfully deployed artifact
+type deployedVersion struct {
+	PackageID  string
+	ArtifactID string
+	Version    string
+	DeployedOn time.Time
+}
+
+// deployedVersions returns the runtime versions of the deployed artifacts, in the order of the results
+func (s *ProcessingStats) deployedVersions() []deployedVersion {
+	var versions []deployedVersion
+	for _, pkg := range s.PackageResults {
+		for _, artifact := range pkg.Artifacts {
+			if artifact.DeployStatus == ResultSuccess && artifact.DeployedVersion != "" {
+				versions = append(versions, deployedVersion{
+					PackageID:  pkg.PackageID,
+					ArtifactID: artifact.ArtifactID,
+					Version:    artifact.DeployedVersion,
+					DeployedOn: artifact.DeployedOn,
+				})
+			}
+		}
+	}
+	return versions
+}
+
+// formatDeployedVersion renders a deployed version with its deployment time, if known
+func formatDeployedVersion(version string, deployedOn time.Time) string {
+	if deployedOn.IsZero() {
+		return version
+	}
+	return fmt.Sprintf("%s deployed %s", version, deployedOn.UTC().Format(time.RFC3339))
+}
+
+// recordPackageDeploy counts a package whose deployments completed. A package whose artifacts are
+// deployed in several steps, e.g. with --deploy-scripts-first, is counted once and as failed when
+// any step failed. It is safe for concurrent use.
+func (s *ProcessingStats) recordPackageDeploy(packageID string, failed bool) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	if s.packageDeployFailed == nil {
+		s.packageDeployFailed = make(map[string]bool)
+	}
+	previouslyFailed, recorded := s.packageDeployFailed[packageID]
+	switch {
+	case !recorded && failed:
+		s.PackagesFailed++
+	case !recorded:
+		s.PackagesDeployed++
+	case failed && !previouslyFailed:
+		s.PackagesDeployed--
+		s.PackagesFailed++
+	}
+	s.packageDeployFailed[packageID] = previouslyFailed || failed
 }
 
+// artifact returns the result entry for the artifact, creating it if needed
+func (r *PackageResult) artifact(artifactID string) *ArtifactResult {
+	for _, a := range r.Artifacts {
+		if a.ArtifactID == artifactID {
+			return a
+		}
+	}
+	a := &ArtifactResult{ArtifactID: artifactID}
+	r.Artifacts = append(r.Artifacts, a)
+	return a
+}
+
+// Result messages of artifacts whose deployment was not started
+const (
+	skippedMaxFailures      = "not deployed, --max-failures reached"
+	skippedFailedDependency = "not deployed, a deployment with a lower deployOrder failed"
+)
+
+// skippedByFilter is the result message of artifacts excluded by --artifact-filter
+const skippedByFilter = "excluded by artifact filter"
+
+// skippedReadOnly is the result message of Configure-only packages, which cannot be updated
+const skippedReadOnly = "Configure-only (read-only) package, update skipped"
+
+// skippedPackageUpdate is the result message of existing packages with --skip-package-update
+const skippedPackageUpdate = "package metadata not updated by --skip-package-update"
+
+// Result messages of artifacts skipped by --update-existing-only and --create-only
+const (
+	skippedNotOnTenant     = "not on the tenant, skipped by --update-existing-only"
+	skippedAlreadyOnTenant = "already on the tenant, skipped by --create-only"
+)
+
 // DeploymentTask represents an artifact ready for deployment
 type DeploymentTask struct {
 	ArtifactID   string
 	ArtifactType string
 	PackageID    string
 	DisplayName  string
+	// Deploy order of the package and of the artifact within its package, lower orders deploy first
+	PackageDeployOrder int
+	DeployOrder        int
 }
 
 func NewFlashpipeOrchestratorCommand() *cobra.Command {
@@ -61,6 +283,7 @@ func NewFlashpipeOrchestratorCommand() *cobra.Command {
 		deployConfig        string
 		deploymentPrefix    string
 		packageFilter       string
+		setOverrides        []string
 		artifactFilter      string
 		keepTemp            bool
 		debugMode           bool
@@ -72,6 +295,33 @@ func NewFlashpipeOrchestratorCommand() *cobra.Command {
 		deployRetries       int
 		deployDelaySeconds  int
 		parallelDeployments int
+		parallelPackages    int
+		maxFailures         int
+		scriptsFirst        bool
+		summaryMarkdown     string
+		junitFile           string
+		backupDir           string
+		updateExistingOnly  bool
+		createOnly          bool
+		onlyChanged         bool
+		strictDirs          bool
+		createMissing       bool
+		skipPackageUpdate   bool
+		expandEnv           bool
+		prefixFromFilename  string
+		metricsEndpoint     string
+		metricsFormat       string
+		notifyURL           string
+		notifyOn            string
+		strictConfig        bool
+		showProgress        bool
+		stateFile           string
+		secretsFile         string
+		tenantName          string
+		configCacheDir      string
+		noConfigCache       bool
+		showPlan            bool
+		planFormat          string
 	)
 
 	orchestratorCmd := &cobra.Command{
@@ -94,6 +344,7 @@ Configuration Sources:
   - Single file:      ./001-deploy-config.yml
   - Folder:           ./configs (processes all matching files alphabetically)
   - Remote URL:       https://raw.githubusercontent.com/org/repo/main/config.yml
+  - Multiple sources: comma-separated list of the above, processed in the given order
 
   Use --orchestrator-config to load all settings from a YAML file:
   - Sets all flags from YAML
@@ -142,6 +393,9 @@ Configuration:
 			if !cmd.Flags().Changed("deployment-prefix") && viper.IsSet("orchestrator.deploymentPrefix") {
 				deploymentPrefix = viper.GetString("orchestrator.deploymentPrefix")
 			}
+			if !cmd.Flags().Changed("set") && viper.IsSet("orchestrator.set") {
+				setOverrides = viper.GetStringSlice("orchestrator.set")
+			}
 			if !cmd.Flags().Changed("package-filter") && viper.IsSet("orchestrator.packageFilter") {
 				packageFilter = viper.GetString("orchestrator.packageFilter")
 			}
@@ -157,6 +411,19 @@ Configuration:
 			if !cmd.Flags().Changed("keep-temp") && viper.IsSet("orchestrator.keepTemp") {
 				keepTemp = viper.GetBool("orchestrator.keepTemp")
 			}
+			if !cmd.Flags().Changed("debug") {
+				// Falls back to the global debug setting, e.g. FLASHPIPE_DEBUG
+				if viper.IsSet("orchestrator.debug") {
+					debugMode = viper.GetBool("orchestrator.debug")
+				} else {
+					debugMode = viper.GetBool("debug")
+				}
+			}
+			// The root --debug flag has already set the level, orchestrator.debug in the config
+			// file has not
+			if debugMode {
+				zerolog.SetGlobalLevel(zerolog.DebugLevel)
+			}
 			if !updateMode && !updateOnlyMode && !deployOnlyMode && viper.IsSet("orchestrator.mode") {
 				switch viper.GetString("orchestrator.mode") {
 				case "update-and-deploy":
@@ -176,49 +443,228 @@ Configuration:
 			if !cmd.Flags().Changed("parallel-deployments") && viper.IsSet("orchestrator.parallelDeployments") {
 				parallelDeployments = viper.GetInt("orchestrator.parallelDeployments")
 			}
+			if !cmd.Flags().Changed("parallel-packages") && viper.IsSet("orchestrator.parallelPackages") {
+				parallelPackages = viper.GetInt("orchestrator.parallelPackages")
+			}
+			if !cmd.Flags().Changed("max-failures") && viper.IsSet("orchestrator.maxFailures") {
+				maxFailures = viper.GetInt("orchestrator.maxFailures")
+			}
+			if !cmd.Flags().Changed("deploy-scripts-first") && viper.IsSet("orchestrator.deployScriptsFirst") {
+				scriptsFirst = viper.GetBool("orchestrator.deployScriptsFirst")
+			}
+			if !cmd.Flags().Changed("summary-markdown") && viper.IsSet("orchestrator.summaryMarkdown") {
+				summaryMarkdown = viper.GetString("orchestrator.summaryMarkdown")
+			}
+			if !cmd.Flags().Changed("junit-file") && viper.IsSet("orchestrator.junitFile") {
+				junitFile = viper.GetString("orchestrator.junitFile")
+			}
+			if !cmd.Flags().Changed("backup-dir") && viper.IsSet("orchestrator.backupDir") {
+				backupDir = viper.GetString("orchestrator.backupDir")
+			}
+			if !cmd.Flags().Changed("update-existing-only") && viper.IsSet("orchestrator.updateExistingOnly") {
+				updateExistingOnly = viper.GetBool("orchestrator.updateExistingOnly")
+			}
+			if !cmd.Flags().Changed("create-only") && viper.IsSet("orchestrator.createOnly") {
+				createOnly = viper.GetBool("orchestrator.createOnly")
+			}
+			if !cmd.Flags().Changed("only-changed") && viper.IsSet("orchestrator.onlyChanged") {
+				onlyChanged = viper.GetBool("orchestrator.onlyChanged")
+			}
+			if !cmd.Flags().Changed("strict-dirs") && viper.IsSet("orchestrator.strictDirs") {
+				strictDirs = viper.GetBool("orchestrator.strictDirs")
+			}
+			if !cmd.Flags().Changed("create-missing-packages") && viper.IsSet("orchestrator.createMissingPackages") {
+				createMissing = viper.GetBool("orchestrator.createMissingPackages")
+			}
+			if !cmd.Flags().Changed("skip-package-update") && viper.IsSet("orchestrator.skipPackageUpdate") {
+				skipPackageUpdate = viper.GetBool("orchestrator.skipPackageUpdate")
+			}
+			if !cmd.Flags().Changed("expand-env") && viper.IsSet("orchestrator.expandEnv") {
+				expandEnv = viper.GetBool("orchestrator.expandEnv")
+			}
+			if !cmd.Flags().Changed("prefix-from-filename") && viper.IsSet("orchestrator.prefixFromFilename") {
+				prefixFromFilename = viper.GetString("orchestrator.prefixFromFilename")
+			}
+			if !cmd.Flags().Changed("metrics-endpoint") && viper.IsSet("orchestrator.metricsEndpoint") {
+				metricsEndpoint = viper.GetString("orchestrator.metricsEndpoint")
+			}
+			if !cmd.Flags().Changed("metrics-format") && viper.IsSet("orchestrator.metricsFormat") {
+				metricsFormat = viper.GetString("orchestrator.metricsFormat")
+			}
+			if !cmd.Flags().Changed("notify-url") && viper.IsSet("orchestrator.notifyUrl") {
+				notifyURL = viper.GetString("orchestrator.notifyUrl")
+			}
+			if !cmd.Flags().Changed("notify-on") && viper.IsSet("orchestrator.notifyOn") {
+				notifyOn = viper.GetString("orchestrator.notifyOn")
+			}
+			if !cmd.Flags().Changed("strict-config") && viper.IsSet("orchestrator.strictConfig") {
+				strictConfig = viper.GetBool("orchestrator.strictConfig")
+			}
+			if !cmd.Flags().Changed("progress") && viper.IsSet("orchestrator.progress") {
+				showProgress = viper.GetBool("orchestrator.progress")
+			}
+			if !cmd.Flags().Changed("state-file") && viper.IsSet("orchestrator.stateFile") {
+				stateFile = viper.GetString("orchestrator.stateFile")
+			}
+			if !cmd.Flags().Changed("secrets-file") && viper.IsSet("orchestrator.secretsFile") {
+				secretsFile = viper.GetString("orchestrator.secretsFile")
+			}
+			if !cmd.Flags().Changed("tenant-name") && viper.IsSet("orchestrator.tenantName") {
+				tenantName = viper.GetString("orchestrator.tenantName")
+			}
+			if !cmd.Flags().Changed("config-cache-dir") && viper.IsSet("orchestrator.configCacheDir") {
+				configCacheDir = viper.GetString("orchestrator.configCacheDir")
+			}
+			if !cmd.Flags().Changed("no-config-cache") && viper.IsSet("orchestrator.noConfigCache") {
+				noConfigCache = viper.GetBool("orchestrator.noConfigCache")
+			}
+			if !cmd.Flags().Changed("plan-format") && viper.IsSet("orchestrator.planFormat") {
+				planFormat = viper.GetString("orchestrator.planFormat")
+			}
 
 			// Validate required parameters
 			if deployConfig == "" {
 				return fmt.Errorf("--deploy-config is required (set via CLI flag or in config file under 'orchestrator.deployConfig')")
 			}
 
-			// Set defaults for deployment settings
-			if deployRetries == 0 {
-				deployRetries = 5
-			}
-			if deployDelaySeconds == 0 {
-				deployDelaySeconds = 15
+			opts := Options{
+				Mode:                  mode,
+				PackagesDir:           packagesDir,
+				DeployConfig:          deployConfig,
+				DeploymentPrefix:      deploymentPrefix,
+				PackageFilter:         parseFilter(packageFilter),
+				ArtifactFilter:        parseFilter(artifactFilter),
+				SetOverrides:          setOverrides,
+				KeepTemp:              keepTemp,
+				Debug:                 debugMode,
+				ConfigPattern:         configPattern,
+				MergeConfigs:          mergeConfigs,
+				StrictConfig:          strictConfig,
+				PrefixFromFilename:    prefixFromFilename,
+				DeployRetries:         deployRetries,
+				DeployDelaySeconds:    deployDelaySeconds,
+				ParallelDeployments:   parallelDeployments,
+				ParallelPackages:      parallelPackages,
+				MaxFailures:           maxFailures,
+				DeployScriptsFirst:    scriptsFirst,
+				StrictDirs:            strictDirs,
+				CreateMissingPackages: createMissing,
+				SkipPackageUpdate:     skipPackageUpdate,
+				ExpandEnv:             expandEnv,
+				BackupDir:             backupDir,
+				UpdateExistingOnly:    updateExistingOnly,
+				CreateOnly:            createOnly,
+				OnlyChanged:           onlyChanged,
+				Progress:              showProgress,
+				StateFile:             stateFile,
+				SecretsFile:           secretsFile,
+				TenantName:            tenantName,
+				ConfigCacheDir:        configCacheDir,
+				NoConfigCache:         noConfigCache,
+				Plan:                  showPlan,
+				PlanFormat:            planFormat,
+				SummaryMarkdown:       summaryMarkdown,
+				JUnitFile:             junitFile,
+				MetricsEndpoint:       metricsEndpoint,
+				MetricsFormat:         metricsFormat,
+				NotifyURL:             notifyURL,
+				NotifyOn:              notifyOn,
+				// OAuth client credentials for config repositories behind an OAuth-protected gateway
+				ConfigOAuthClientID:     viper.GetString("orchestrator.configOauth.clientId"),
+				ConfigOAuthClientSecret: viper.GetString("orchestrator.configOauth.clientSecret"),
+				ConfigOAuthTokenURL:     viper.GetString("orchestrator.configOauth.tokenUrl"),
+				// Custom artifact type names are shared with the reconcile command
+				ArtifactTypeAliases: viper.GetStringMapString("artifactTypeAliases"),
+				// Read credentials from viper if not provided via CLI flags
+				ServiceDetails: getServiceDetailsFromViperOrCmd(cmd),
 			}
-			if parallelDeployments == 0 {
-				parallelDeployments = 3
+			// Get auth settings from viper/config for remote URLs
+			if viper.IsSet("host") {
+				// Use CPI credentials from global config if deploying from URL
+				opts.ConfigUsername = config.GetString(cmd, "username")
+				opts.ConfigPassword = config.GetString(cmd, "password")
 			}
 
-			return runOrchestrator(cmd, mode, packagesDir, deployConfig,
-				deploymentPrefix, packageFilter, artifactFilter, keepTemp, debugMode,
-				configPattern, mergeConfigs, deployRetries, deployDelaySeconds, parallelDeployments)
+			// Cancel in-flight deployments on SIGINT/SIGTERM instead of leaving the tenant mid-deploy
+			ctx, stop := signal.NotifyContext(cmd.Context(), os.Interrupt, syscall.SIGTERM)
+			defer stop()
+
+			_, err := NewOrchestrator(opts).Run(ctx)
+			return err
 		},
 	}
 
 	// Flags
-	orchestratorCmd.Flags().StringVarP(&packagesDir, "packages-dir", "d", "", "Directory containing packages (config: orchestrator.packagesDir)")
-	orchestratorCmd.Flags().StringVarP(&deployConfig, "deploy-config", "c", "", "Path to deployment config file/folder/URL (config: orchestrator.deployConfig)")
+	orchestratorCmd.Flags().StringVarP(&packagesDir, "packages-dir", "d", "", "Directory containing packages, or a zip archive of it (config: orchestrator.packagesDir)")
+	orchestratorCmd.Flags().StringVarP(&deployConfig, "deploy-config", "c", "", "Path to deployment config file/folder/URL, or a comma-separated list of them (config: orchestrator.deployConfig)")
 	orchestratorCmd.Flags().StringVarP(&deploymentPrefix, "deployment-prefix", "p", "", "Deployment prefix for package/artifact IDs (config: orchestrator.deploymentPrefix)")
+	orchestratorCmd.Flags().StringArrayVar(&setOverrides, "set", nil, "Override a deployment config value, e.g. package.<id>.deploy=false or artifact.<id>.sync=true; repeatable (config: orchestrator.set)")
 	orchestratorCmd.Flags().StringVar(&packageFilter, "package-filter", "", "Comma-separated list of packages to include (config: orchestrator.packageFilter)")
 	orchestratorCmd.Flags().StringVar(&artifactFilter, "artifact-filter", "", "Comma-separated list of artifacts to include (config: orchestrator.artifactFilter)")
 	orchestratorCmd.Flags().BoolVar(&keepTemp, "keep-temp", false, "Keep temporary directory after execution (config: orchestrator.keepTemp)")
 	orchestratorCmd.Flags().BoolVar(&debugMode, "debug", false, "Enable debug logging")
+	orchestratorCmd.Flags().StringVar(&tenantName, "tenant-name", "", "Tenant label added to all log lines and the summary, defaults to the tenant host (config: orchestrator.tenantName)")
 	orchestratorCmd.Flags().StringVar(&configPattern, "config-pattern", "*.y*ml", "File pattern for config files in folders (config: orchestrator.configPattern)")
 	orchestratorCmd.Flags().BoolVar(&mergeConfigs, "merge-configs", false, "Merge multiple configs into single deployment (config: orchestrator.mergeConfigs)")
+	orchestratorCmd.Flags().StringVar(&configCacheDir, "config-cache-dir", "", "Directory caching remote deploy configs, revalidated with their ETag and used when the URL is unreachable (config: orchestrator.configCacheDir, default: user cache directory)")
+	orchestratorCmd.Flags().BoolVar(&noConfigCache, "no-config-cache", false, "Always download remote deploy configs without caching them (config: orchestrator.noConfigCache)")
 	orchestratorCmd.Flags().BoolVar(&updateMode, "update", false, "Update and deploy artifacts")
 	orchestratorCmd.Flags().BoolVar(&updateOnlyMode, "update-only", false, "Only update artifacts, don't deploy")
 	orchestratorCmd.Flags().BoolVar(&deployOnlyMode, "deploy-only", false, "Only deploy artifacts, don't update")
+	orchestratorCmd.Flags().BoolVar(&showPlan, "plan", false, "Print the packages and artifacts that would be updated and deployed, in order, without connecting to the tenant")
+	orchestratorCmd.Flags().StringVar(&planFormat, "plan-format", PlanFormatTable, "Output format of --plan: 'table' or 'json' (config: orchestrator.planFormat)")
 	orchestratorCmd.Flags().IntVar(&deployRetries, "deploy-retries", 0, "Number of retries for deployment status checks (config: orchestrator.deployRetries, default: 5)")
 	orchestratorCmd.Flags().IntVar(&deployDelaySeconds, "deploy-delay", 0, "Delay in seconds between deployment status checks (config: orchestrator.deployDelaySeconds, default: 15)")
 	orchestratorCmd.Flags().IntVar(&parallelDeployments, "parallel-deployments", 0, "Number of parallel deployments per package (config: orchestrator.parallelDeployments, default: 3)")
+	orchestratorCmd.Flags().IntVar(&parallelPackages, "parallel-packages", 0, "Number of packages deployed in parallel (config: orchestrator.parallelPackages, default: 1)")
+	orchestratorCmd.Flags().BoolVar(&scriptsFirst, "deploy-scripts-first", false, "Deploy all script collections before the other artifacts (config: orchestrator.deployScriptsFirst)")
+	orchestratorCmd.Flags().IntVar(&maxFailures, "max-failures", 0, "Stop starting further deployments once this many have failed, 0 for no limit (config: orchestrator.maxFailures)")
+	orchestratorCmd.Flags().StringVar(&summaryMarkdown, "summary-markdown", "", "Write a Markdown summary suitable for PR comments to this file (config: orchestrator.summaryMarkdown)")
+	orchestratorCmd.Flags().StringVar(&junitFile, "junit-file", "", "Write the results as JUnit XML for CI test reporting to this file (config: orchestrator.junitFile)")
+	orchestratorCmd.Flags().BoolVar(&strictDirs, "strict-dirs", false, "Fail when a configured package or artifact directory is missing instead of skipping it (config: orchestrator.strictDirs)")
+	orchestratorCmd.Flags().BoolVar(&expandEnv, "expand-env", false, "Expand environment variables such as ${TARGET_ENDPOINT} in config override values (config: orchestrator.expandEnv)")
+	orchestratorCmd.Flags().StringVar(&secretsFile, "secrets-file", "", "YAML file with NAME: value pairs for {{secret:NAME}} references in config overrides, the environment takes precedence (config: orchestrator.secretsFile)")
+	orchestratorCmd.Flags().StringVar(&backupDir, "backup-dir", "", "Download the current tenant version of each artifact into this directory before updating it (config: orchestrator.backupDir)")
+	orchestratorCmd.Flags().BoolVar(&updateExistingOnly, "update-existing-only", false, "Only update artifacts that already exist on the tenant, skip new ones instead of creating them (config: orchestrator.updateExistingOnly)")
+	orchestratorCmd.Flags().BoolVar(&createOnly, "create-only", false, "Only create artifacts that do not exist on the tenant yet, skip existing ones (config: orchestrator.createOnly)")
+	orchestratorCmd.Flags().BoolVar(&onlyChanged, "only-changed", false, "Compare each artifact with its tenant version and skip the update when identical (config: orchestrator.onlyChanged)")
+	orchestratorCmd.Flags().BoolVar(&createMissing, "create-missing-packages", true, "Create configured packages that do not exist on the tenant; set to false to fail instead (config: orchestrator.createMissingPackages)")
+	orchestratorCmd.Flags().BoolVar(&skipPackageUpdate, "skip-package-update", false, "Leave the metadata of existing packages unchanged, only create missing packages (config: orchestrator.skipPackageUpdate)")
+	orchestratorCmd.Flags().StringVar(&prefixFromFilename, "prefix-from-filename", "", "Regex whose first capture group, matched against the config file name, is used as deployment prefix when the config does not set one (config: orchestrator.prefixFromFilename)")
+	orchestratorCmd.Flags().StringVar(&metricsEndpoint, "metrics-endpoint", "", "Push deployment metrics to this Prometheus Pushgateway or OTLP/HTTP collector URL (config: orchestrator.metricsEndpoint)")
+	orchestratorCmd.Flags().StringVar(&metricsFormat, "metrics-format", MetricsFormatPrometheus, "Format of pushed metrics: 'prometheus' or 'otlp' (config: orchestrator.metricsFormat)")
+	orchestratorCmd.Flags().StringVar(&notifyURL, "notify-url", "", "POST the run summary as JSON to this webhook URL, e.g. a Slack or Teams incoming webhook (config: orchestrator.notifyUrl)")
+	orchestratorCmd.Flags().StringVar(&notifyOn, "notify-on", NotifyOnAlways, "When to send the notification: 'always' or 'failure' (config: orchestrator.notifyOn)")
+	orchestratorCmd.Flags().BoolVar(&strictConfig, "strict-config", false, "Fail on unknown keys in deployment config files instead of ignoring them (config: orchestrator.strictConfig)")
+	orchestratorCmd.Flags().BoolVar(&showProgress, "progress", false, "Log overall progress and estimated time remaining during the deploy phase (config: orchestrator.progress)")
+	orchestratorCmd.Flags().StringVar(&stateFile, "state-file", "", "Record completed updates and deployments in this file and skip them when re-running after a failure (config: orchestrator.stateFile)")
+
+	orchestratorCmd.MarkFlagsMutuallyExclusive("update-existing-only", "create-only")
 
 	return orchestratorCmd
 }
 
+// defaultConfigCacheDir returns the cache directory for remote deploy configs in the user
+// cache, or an empty string to disable caching when there is no user cache directory
+func defaultConfigCacheDir(ctx context.Context) string {
+	logger := runLogger(ctx)
+	dir, err := os.UserCacheDir()
+	if err != nil {
+		logger.Debug().Msgf("Remote configs are not cached: %v", err)
+		return ""
+	}
+	return filepath.Join(dir, "flashpipe", "configs")
+}
+
+// runLogger returns the logger of the orchestrator run in ctx. Outside of a run, e.g. when
+// packages are updated by the promote command, the global logger is used.
+func runLogger(ctx context.Context) *zerolog.Logger {
+	if logger := zerolog.Ctx(ctx); logger.GetLevel() != zerolog.Disabled {
+		return logger
+	}
+	return &log.Logger
+}
+
 // getServiceDetailsFromViperOrCmd reads service credentials from viper config or CLI flags
 // This allows the orchestrator to use credentials from the global config file
 func getServiceDetailsFromViperOrCmd(cmd *cobra.Command) *api.ServiceDetails {
@@ -243,17 +689,12 @@ func getServiceDetailsFromViperOrCmd(cmd *cobra.Command) *api.ServiceDetails {
 		if oauthHost != "" {
 			log.Debug().Msgf("  oauth-host: %s", oauthHost)
 
-			oauthPath := viper.GetString("oauth-path")
-			if oauthPath == "" {
-				oauthPath = "/oauth/token" // Default value
-			}
-
 			return &api.ServiceDetails{
 				Host:              tmnHost,
 				OauthHost:         oauthHost,
 				OauthClientId:     viper.GetString("oauth-clientid"),
 				OauthClientSecret: viper.GetString("oauth-clientsecret"),
-				OauthPath:         oauthPath,
+				OauthPath:         api.OauthPathOrDefault(viper.GetString("oauth-path")),
 			}
 		} else {
 			log.Debug().Msg("  Using Basic Auth")
@@ -269,23 +710,124 @@ func getServiceDetailsFromViperOrCmd(cmd *cobra.Command) *api.ServiceDetails {
 	return serviceDetails
 }
 
-func runOrchestrator(cmd *cobra.Command, mode OperationMode, packagesDir, deployConfigPath,
-	deploymentPrefix, packageFilterStr, artifactFilterStr string, keepTemp, debugMode bool,
-	configPattern string, mergeConfigs bool, deployRetries, deployDelaySeconds, parallelDeployments int) error {
+// Options configures an Orchestrator run. Zero values of DeployRetries, DeployDelaySeconds
+// and ParallelDeployments are replaced by their defaults (5, 15 and 3).
+type Options struct {
+	Mode             OperationMode
+	PackagesDir      string
+	DeployConfig     string // file, folder, URL or a comma-separated list of them
+	DeploymentPrefix string
+	PackageFilter    []string
+	ArtifactFilter   []string
+	SetOverrides     []string // --set expressions applied to the loaded deployment configs
+	KeepTemp         bool
+	Debug            bool
+
+	// Config loading
+	ConfigPattern      string
+	MergeConfigs       bool
+	StrictConfig       bool
+	PrefixFromFilename string
+	// ArtifactTypeAliases maps custom artifact type names to the artifact types they stand for
+	ArtifactTypeAliases map[string]string
+	// ConfigCacheDir caches remote deploy configs, defaults to a directory in the user cache
+	ConfigCacheDir string
+	NoConfigCache  bool
+
+	// Credentials for remote deploy configs
+	ConfigUsername          string
+	ConfigPassword          string
+	ConfigOAuthClientID     string
+	ConfigOAuthClientSecret string
+	ConfigOAuthTokenURL     string
+
+	// Tenant credentials
+	ServiceDetails *api.ServiceDetails
+
+	// Deployment settings
+	DeployRetries         int
+	DeployDelaySeconds    int
+	ParallelDeployments   int
+	ParallelPackages      int
+	MaxFailures           int  // stop starting deployments after this many failures, 0 for no limit
+	DeployScriptsFirst    bool // deploy all script collections before the other artifacts
+	StrictDirs            bool
+	CreateMissingPackages bool
+	SkipPackageUpdate     bool // only create missing packages, leave the metadata of existing ones unchanged
+	ExpandEnv             bool
+	SecretsFile           string // secrets for {{secret:NAME}} references in config overrides
+	BackupDir             string // backup of the tenant artifacts before they are updated
+	UpdateExistingOnly    bool   // skip artifacts that do not exist on the tenant
+	CreateOnly            bool   // skip artifacts that already exist on the tenant
+	OnlyChanged           bool   // skip artifacts whose content is identical to the tenant version
+	Progress              bool
+	TenantName            string // label of the tenant in logs and summary, defaults to the tenant host
+	StateFile             string // completed work of failed runs, skipped when resuming
+
+	// Logger is the base logger of the run, defaults to the global logger. The run labels its
+	// lines with the tenant on a copy, so that concurrent runs do not share the label.
+	Logger *zerolog.Logger
+
+	// Plan prints the packages and artifacts that would be updated and deployed, without
+	// connecting to the tenant
+	Plan       bool
+	PlanFormat string // PlanFormatTable or PlanFormatJSON
+
+	// Reporting
+	SummaryMarkdown string
+	JUnitFile       string
+	MetricsEndpoint string
+	MetricsFormat   string
+	NotifyURL       string // webhook receiving the run summary
+	NotifyOn        string // NotifyOnAlways or NotifyOnFailure
+}
 
-	log.Info().Msg("Starting flashpipe orchestrator")
-	log.Info().Msgf("Deployment Strategy: Two-phase with parallel deployment")
-	log.Info().Msgf("  Phase 1: Update all artifacts")
-	log.Info().Msgf("  Phase 2: Deploy all artifacts in parallel (max %d concurrent)", parallelDeployments)
+// Orchestrator updates and deploys the packages and artifacts of deployment configs
+type Orchestrator struct {
+	opts Options
+}
 
-	// Validate deployment prefix
-	if err := deploy.ValidateDeploymentPrefix(deploymentPrefix); err != nil {
-		return err
+// NewOrchestrator returns an Orchestrator for the given options
+func NewOrchestrator(opts Options) *Orchestrator {
+	if opts.Mode == "" {
+		opts.Mode = ModeUpdateAndDeploy
+	}
+	if opts.DeployRetries == 0 {
+		opts.DeployRetries = 5
+	}
+	if opts.DeployDelaySeconds == 0 {
+		opts.DeployDelaySeconds = 15
+	}
+	if opts.ParallelDeployments == 0 {
+		opts.ParallelDeployments = 3
 	}
+	if opts.ParallelPackages == 0 {
+		opts.ParallelPackages = 1
+	}
+	if opts.MetricsFormat == "" {
+		opts.MetricsFormat = MetricsFormatPrometheus
+	}
+	if opts.NotifyOn == "" {
+		opts.NotifyOn = NotifyOnAlways
+	}
+	if opts.PlanFormat == "" {
+		opts.PlanFormat = PlanFormatTable
+	}
+	return &Orchestrator{opts: opts}
+}
 
-	// Parse filters
-	packageFilter := parseFilter(packageFilterStr)
-	artifactFilter := parseFilter(artifactFilterStr)
+// Run executes the update and deploy phases. The returned stats are never nil, so
+// callers can inspect partial results also when an error is returned. Cancelling ctx
+// stops processing further packages and starting further deployments.
+func (o *Orchestrator) Run(ctx context.Context) (_ *ProcessingStats, runErr error) {
+	opts := o.opts
+	mode := opts.Mode
+	packagesDir := opts.PackagesDir
+	deployConfigPath := opts.DeployConfig
+	deploymentPrefix := opts.DeploymentPrefix
+	packageFilter := opts.PackageFilter
+	artifactFilter := opts.ArtifactFilter
+	parallelDeployments := opts.ParallelDeployments
 
 	// Initialize stats
 	stats := ProcessingStats{
@@ -295,230 +837,495 @@ func runOrchestrator(cmd *cobra.Command, mode OperationMode, packagesDir, deploy
 		FailedArtifactUpdates:     make(map[string]bool),
 		FailedPackageUpdates:      make(map[string]bool),
 		FailedArtifactDeploys:     make(map[string]bool),
+		SkippedArtifactUpdates:    make(map[string]bool),
+	}
+
+	// All log lines of the run are labelled with the tenant, so that the logs of runs against
+	// several tenants can be told apart
+	stats.TenantName = opts.TenantName
+	if stats.TenantName == "" && opts.ServiceDetails != nil {
+		stats.TenantName = opts.ServiceDetails.Host
+	}
+	logger := log.Logger
+	if opts.Logger != nil {
+		logger = *opts.Logger
+	}
+	if stats.TenantName != "" {
+		logger = logger.With().Str("tenant", stats.TenantName).Logger()
+	}
+	// Diagnostic output is logged at debug level and only shown with --debug. The level is set
+	// on the logger of the run, so that the log level of the process is left as it is.
+	if opts.Debug {
+		logger = logger.Level(zerolog.DebugLevel)
+	} else {
+		logger = logger.Level(zerolog.InfoLevel)
+	}
+	ctx = logger.WithContext(ctx)
+
+	runStart := time.Now()
+	stats.StartTime = runStart
+	logger.Info().Msg("Starting flashpipe orchestrator")
+	logger.Info().Msgf("Deployment Strategy: Two-phase with parallel deployment")
+	logger.Info().Msgf("  Phase 1: Update all artifacts")
+	logger.Info().Msgf("  Phase 2: Deploy all artifacts in parallel (max %d concurrent)", parallelDeployments)
+	logger.Debug().Msgf("Deploy settings: %d status check retries, %ds delay, %d packages in parallel",
+		opts.DeployRetries, opts.DeployDelaySeconds, opts.ParallelPackages)
+
+	if deployConfigPath == "" {
+		return &stats, fmt.Errorf("deploy config is required")
+	}
+
+	// Validate deployment prefix
+	if err := deploy.ValidateDeploymentPrefix(deploymentPrefix); err != nil {
+		return &stats, err
+	}
+
+	var filenamePrefixRegex *regexp.Regexp
+	if opts.PrefixFromFilename != "" {
+		re, err := compilePrefixFromFilename(opts.PrefixFromFilename)
+		if err != nil {
+			return &stats, err
+		}
+		filenamePrefixRegex = re
+	}
+
+	if opts.UpdateExistingOnly && opts.CreateOnly {
+		return &stats, fmt.Errorf("--update-existing-only and --create-only cannot be used together")
+	}
+
+	if opts.StateFile != "" {
+		completed, err := loadRunState(opts.StateFile)
+		if err != nil {
+			return &stats, err
+		}
+		if len(completed.updated) > 0 || len(completed.deployed) > 0 {
+			logger.Info().Msgf("Resuming from state file %s: %d artifact(s) updated and %d deployed by a previous run",
+				opts.StateFile, len(completed.updated), len(completed.deployed))
+		}
+		stats.completed = completed
+	}
+
+	secrets, err := deploy.NewSecretResolver(opts.SecretsFile)
+	if err != nil {
+		return &stats, err
+	}
+
+	if opts.Plan && opts.PlanFormat != PlanFormatTable && opts.PlanFormat != PlanFormatJSON {
+		return &stats, fmt.Errorf("invalid plan format %q: must be '%s' or '%s'", opts.PlanFormat, PlanFormatTable, PlanFormatJSON)
+	}
+
+	if opts.MaxFailures < 0 {
+		return &stats, fmt.Errorf("invalid --max-failures %d: must not be negative", opts.MaxFailures)
+	}
+
+	if opts.MetricsEndpoint != "" && opts.MetricsFormat != MetricsFormatPrometheus && opts.MetricsFormat != MetricsFormatOTLP {
+		return &stats, fmt.Errorf("invalid metrics format %q: must be '%s' or '%s'", opts.MetricsFormat, MetricsFormatPrometheus, MetricsFormatOTLP)
+	}
+
+	if opts.NotifyURL != "" && opts.NotifyOn != NotifyOnAlways && opts.NotifyOn != NotifyOnFailure {
+		return &stats, fmt.Errorf("invalid notify-on value %q: must be '%s' or '%s'", opts.NotifyOn, NotifyOnAlways, NotifyOnFailure)
+	}
+
+	if opts.NotifyURL != "" && !opts.Plan {
+		// Deferred, so that runs ending early, e.g. with a config that cannot be loaded, are notified too
+		defer func() {
+			if stats.EndTime.IsZero() {
+				stats.EndTime = time.Now()
+				stats.TotalDuration = stats.EndTime.Sub(stats.StartTime)
+			}
+			// The webhook URL usually contains a secret token, so it is not logged
+			if sent, err := sendNotification(opts.NotifyURL, opts.NotifyOn, &stats, runErr); err != nil {
+				logger.Warn().Msgf("Failed to send notification: %v", err)
+			} else if sent {
+				logger.Info().Msg("Notification sent")
+			}
+		}()
+	}
+
+	setOverrides := make([]deploy.SetOverride, 0, len(opts.SetOverrides))
+	for _, expression := range opts.SetOverrides {
+		override, err := deploy.ParseSetOverride(expression)
+		if err != nil {
+			return &stats, err
+		}
+		setOverrides = append(setOverrides, override)
 	}
 
 	// Setup config loader
 	configLoader := deploy.NewConfigLoader()
-	configLoader.Debug = debugMode
-	configLoader.FilePattern = configPattern
-
-	// Get auth settings from viper/config for remote URLs
-	if viper.IsSet("host") {
-		// Use CPI credentials from global config if deploying from URL
-		configLoader.Username = config.GetString(cmd, "username")
-		configLoader.Password = config.GetString(cmd, "password")
+	configLoader.Debug = opts.Debug
+	if opts.ConfigPattern != "" {
+		configLoader.FilePattern = opts.ConfigPattern
+	}
+	configLoader.StrictFields = opts.StrictConfig
+	configLoader.Username = opts.ConfigUsername
+	configLoader.Password = opts.ConfigPassword
+	configLoader.OAuthClientID = opts.ConfigOAuthClientID
+	configLoader.OAuthClientSecret = opts.ConfigOAuthClientSecret
+	configLoader.OAuthTokenURL = opts.ConfigOAuthTokenURL
+	if !opts.NoConfigCache {
+		configLoader.CacheDir = opts.ConfigCacheDir
+		if configLoader.CacheDir == "" {
+			configLoader.CacheDir = defaultConfigCacheDir(ctx)
+		}
 	}
 
 	if err := configLoader.DetectSource(deployConfigPath); err != nil {
-		return fmt.Errorf("failed to detect config source: %w", err)
+		return &stats, &ConfigLoadError{Source: deployConfigPath, Err: fmt.Errorf("failed to detect config source: %w", err)}
 	}
 
-	log.Info().Msgf("Loading config from: %s (type: %s)", deployConfigPath, configLoader.Source)
-	configFiles, err := configLoader.LoadConfigs()
+	logger.Info().Msgf("Loading config from: %s (type: %s)", deployConfigPath, configLoader.Source)
+	configFiles, err := configLoader.LoadConfigsContext(ctx)
 	if err != nil {
-		return fmt.Errorf("failed to load deployment config: %w", err)
+		return &stats, &ConfigLoadError{Source: deployConfigPath, Err: fmt.Errorf("failed to load deployment config: %w", err)}
+	}
+
+	logger.Info().Msgf("Loaded %d config file(s)", len(configFiles))
+	for _, configFile := range configFiles {
+		if configFile.CacheFallback != "" {
+			logger.Warn().Msgf("Using cached copy of %s as it could not be fetched: %s", configFile.Source, configFile.CacheFallback)
+		}
+	}
+
+	if len(setOverrides) > 0 {
+		if err := deploy.ApplySetOverrides(configFiles, setOverrides); err != nil {
+			return &stats, &ConfigLoadError{Source: deployConfigPath, Err: err}
+		}
+		for _, override := range setOverrides {
+			logger.Info().Msgf("Config override: %s", override.Expression)
+		}
 	}
 
-	log.Info().Msgf("Loaded %d config file(s)", len(configFiles))
+	if err := resolveArtifactTypes(configFiles, opts.ArtifactTypeAliases); err != nil {
+		return &stats, &ConfigLoadError{Source: deployConfigPath, Err: err}
+	}
 
 	// Create temporary work directory if needed
 	var workDir string
-	if mode != ModeDeployOnly {
+	if mode != ModeDeployOnly && !opts.Plan {
 		tempDir, err := os.MkdirTemp("", "flashpipe-orchestrator-*")
 		if err != nil {
-			return fmt.Errorf("failed to create temp directory: %w", err)
+			return &stats, fmt.Errorf("failed to create temp directory: %w", err)
 		}
 		workDir = tempDir
 
-		if !keepTemp {
+		if !opts.KeepTemp {
 			defer os.RemoveAll(tempDir)
 		} else {
-			log.Info().Msgf("Temporary directory: %s", tempDir)
+			logger.Info().Msgf("Temporary directory: %s", tempDir)
 		}
 	}
 
-	log.Info().Msgf("Mode: %s", mode)
-	log.Info().Msgf("Packages Directory: %s", packagesDir)
+	// A zip archive of the packages is extracted and processed like a directory
+	if packagesDir != "" {
+		extractedDir, err := extractPackagesArchive(packagesDir)
+		if err != nil {
+			return &stats, err
+		}
+		if extractedDir != "" {
+			logger.Info().Msgf("Extracted packages archive %s to %s", packagesDir, extractedDir)
+			if !opts.KeepTemp {
+				defer os.RemoveAll(extractedDir)
+			}
+			packagesDir = extractedDir
+		}
+	}
+
+	logger.Info().Msgf("Mode: %s", mode)
+	logger.Info().Msgf("Packages Directory: %s", packagesDir)
 
 	if len(packageFilter) > 0 {
-		log.Info().Msgf("Package filter: %s", strings.Join(packageFilter, ", "))
+		logger.Info().Msgf("Package filter: %s", strings.Join(packageFilter, ", "))
 	}
 	if len(artifactFilter) > 0 {
-		log.Info().Msgf("Artifact filter: %s", strings.Join(artifactFilter, ", "))
+		logger.Info().Msgf("Artifact filter: %s", strings.Join(artifactFilter, ", "))
 	}
 
-	// Get service details once (shared across all operations)
-	// Read credentials from viper if not provided via CLI flags
-	serviceDetails := getServiceDetailsFromViperOrCmd(cmd)
-	if serviceDetails == nil {
-		return fmt.Errorf("missing CPI credentials: provide via --config file or CLI flags (--tmn-host, --oauth-host, etc.)")
-	}
+	// Service details are shared across all operations, a plan does not connect to the tenant
+	serviceDetails := opts.ServiceDetails
+	if !opts.Plan {
+		if serviceDetails == nil {
+			return &stats, fmt.Errorf("missing CPI credentials: provide via --config file or CLI flags (--tmn-host, --oauth-host, etc.)")
+		}
 
-	// Validate serviceDetails has required fields
-	if serviceDetails.Host == "" {
-		return fmt.Errorf("CPI host (tmn-host) is required but not provided")
-	}
+		// Validate serviceDetails has required fields
+		if serviceDetails.Host == "" {
+			return &stats, fmt.Errorf("CPI host (tmn-host) is required but not provided")
+		}
 
-	log.Debug().Msg("CPI credentials successfully loaded:")
-	log.Debug().Msgf("  Host: %s", serviceDetails.Host)
-	if serviceDetails.OauthHost != "" {
-		log.Debug().Msgf("  OAuth Host: %s", serviceDetails.OauthHost)
-		log.Debug().Msg("  Auth Method: OAuth")
-	} else {
-		log.Debug().Msg("  Auth Method: Basic Auth")
+		logServiceDetails(ctx, serviceDetails)
 	}
+	plan := &RunPlan{Mode: mode}
 
 	// Collect all deployment tasks (will be executed in phase 2)
+	updateStart := time.Now()
 	var deploymentTasks []DeploymentTask
 
 	// Process configs
-	if mergeConfigs && len(configFiles) > 1 {
-		log.Info().Msg("Merging multiple configs into single deployment")
+	if opts.MergeConfigs && len(configFiles) > 1 {
+		logger.Info().Msg("Merging multiple configs into single deployment")
 
 		if deploymentPrefix != "" {
-			log.Warn().Msg("Note: --deployment-prefix is ignored when merging configs with their own prefixes")
+			logger.Warn().Msg("Note: --deployment-prefix is ignored when merging configs with their own prefixes")
 		}
 
 		mergedConfig, err := deploy.MergeConfigs(configFiles)
 		if err != nil {
-			return fmt.Errorf("failed to merge configs: %w", err)
+			return &stats, &ConfigLoadError{Source: deployConfigPath, Err: fmt.Errorf("failed to merge configs: %w", err)}
 		}
 
-		tasks, err := processPackages(mergedConfig, false, mode, packagesDir, workDir,
-			packageFilter, artifactFilter, &stats, serviceDetails)
-		if err != nil {
-			return err
+		if opts.Plan {
+			deploymentTasks = planPackages(ctx, plan, mergedConfig, false, mode, packagesDir, packageFilter, artifactFilter, opts.StrictDirs)
+		} else {
+			tasks, err := processPackages(ctx, mergedConfig, false, mode, packagesDir, workDir, opts.BackupDir,
+				packageFilter, artifactFilter, opts.StrictDirs, opts.CreateMissingPackages, opts.SkipPackageUpdate, opts.ExpandEnv,
+				opts.UpdateExistingOnly, opts.CreateOnly, opts.OnlyChanged, secrets, &stats, serviceDetails)
+			// A cancelled run continues below, so that the completed updates are recorded in the state file
+			if err != nil && ctx.Err() == nil {
+				return &stats, err
+			}
+			deploymentTasks = append(deploymentTasks, tasks...)
 		}
-		deploymentTasks = append(deploymentTasks, tasks...)
 	} else {
 		for _, configFile := range configFiles {
+			if ctx.Err() != nil {
+				break
+			}
 			if len(configFiles) > 1 {
-				log.Info().Msgf("Processing Config: %s", configFile.FileName)
+				logger.Info().Msgf("Processing Config: %s", configFile.FileName)
 			}
 
 			// Override deployment prefix if specified via CLI
 			if deploymentPrefix != "" {
 				configFile.Config.DeploymentPrefix = deploymentPrefix
+			} else if filenamePrefixRegex != nil && configFile.Config.DeploymentPrefix == "" {
+				prefix, err := prefixFromConfigFilename(filenamePrefixRegex, configFile.FileName)
+				if err != nil {
+					return &stats, err
+				}
+				logger.Info().Msgf("Using deployment prefix %q from config file name %s", prefix, configFile.FileName)
+				configFile.Config.DeploymentPrefix = prefix
 			}
 
-			log.Info().Msgf("Deployment Prefix: %s", configFile.Config.DeploymentPrefix)
+			logger.Info().Msgf("Deployment Prefix: %s", configFile.Config.DeploymentPrefix)
+
+			if opts.Plan {
+				deploymentTasks = append(deploymentTasks, planPackages(ctx, plan, configFile.Config, true, mode, packagesDir,
+					packageFilter, artifactFilter, opts.StrictDirs)...)
+				continue
+			}
 
-			tasks, err := processPackages(configFile.Config, true, mode, packagesDir, workDir,
-				packageFilter, artifactFilter, &stats, serviceDetails)
+			tasks, err := processPackages(ctx, configFile.Config, true, mode, packagesDir, workDir, opts.BackupDir,
+				packageFilter, artifactFilter, opts.StrictDirs, opts.CreateMissingPackages, opts.SkipPackageUpdate, opts.ExpandEnv,
+				opts.UpdateExistingOnly, opts.CreateOnly, opts.OnlyChanged, secrets, &stats, serviceDetails)
 			if err != nil {
-				log.Error().Msgf("Failed to process config %s: %v", configFile.FileName, err)
+				logger.Error().Msgf("Failed to process config %s: %v", configFile.FileName, err)
 				continue
 			}
 			deploymentTasks = append(deploymentTasks, tasks...)
 		}
 	}
 
+	if opts.Plan {
+		plan.setDeploySteps(deploymentTasks, opts.DeployScriptsFirst)
+		return &stats, writePlan(os.Stdout, plan, opts.PlanFormat)
+	}
+
+	stats.UpdatePhaseDuration = time.Since(updateStart)
+
+	if opts.StateFile != "" {
+		// Record the updates before the deploy phase, which may take long
+		if err := saveRunState(opts.StateFile, &stats); err != nil {
+			logger.Error().Msgf("%v", err)
+		}
+		deploymentTasks = skipCompletedDeploys(ctx, deploymentTasks, &stats)
+	}
+
 	// Phase 2: Deploy all artifacts in parallel (if not update-only mode)
-	if mode != ModeUpdateOnly && len(deploymentTasks) > 0 {
-		log.Info().Msg("")
-		log.Info().Msg("═══════════════════════════════════════════════════════════════════════")
-		log.Info().Msg("PHASE 2: DEPLOYING ALL ARTIFACTS IN PARALLEL")
-		log.Info().Msg("═══════════════════════════════════════════════════════════════════════")
-		log.Info().Msgf("Total artifacts to deploy: %d", len(deploymentTasks))
-		log.Info().Msgf("Max concurrent deployments: %d", parallelDeployments)
-		log.Info().Msg("")
-
-		err := deployAllArtifactsParallel(deploymentTasks, parallelDeployments, deployRetries,
-			deployDelaySeconds, &stats, serviceDetails)
+	if mode != ModeUpdateOnly && len(deploymentTasks) > 0 && ctx.Err() == nil {
+		deployStart := time.Now()
+		logger.Info().Msg("")
+		logger.Info().Msg("═══════════════════════════════════════════════════════════════════════")
+		logger.Info().Msg("PHASE 2: DEPLOYING ALL ARTIFACTS IN PARALLEL")
+		logger.Info().Msg("═══════════════════════════════════════════════════════════════════════")
+		logger.Info().Msgf("Total artifacts to deploy: %d", len(deploymentTasks))
+		logger.Info().Msgf("Max concurrent deployments: %d per package, %d packages at a time", parallelDeployments, opts.ParallelPackages)
+		if opts.MaxFailures > 0 {
+			logger.Info().Msgf("Max failures: %d", opts.MaxFailures)
+		}
+		logger.Info().Msg("")
+
+		err := deployAllArtifactsParallel(ctx, deploymentTasks, parallelDeployments, opts.ParallelPackages, opts.MaxFailures,
+			opts.DeployScriptsFirst, opts.DeployRetries, opts.DeployDelaySeconds, opts.Progress, &stats, serviceDetails)
 		if err != nil {
-			log.Error().Msgf("Deployment phase failed: %v", err)
+			logger.Error().Msgf("Deployment phase failed: %v", err)
+		}
+		stats.DeployPhaseDuration = time.Since(deployStart)
+	}
+	stats.EndTime = time.Now()
+	stats.TotalDuration = stats.EndTime.Sub(runStart)
+
+	if opts.StateFile != "" {
+		if runSucceeded(&stats) && ctx.Err() == nil {
+			if err := clearRunState(opts.StateFile); err != nil {
+				logger.Error().Msgf("%v", err)
+			}
+		} else if err := saveRunState(opts.StateFile, &stats); err != nil {
+			logger.Error().Msgf("%v", err)
+		} else {
+			logger.Info().Msgf("Completed work recorded in state file %s, re-run with the same --state-file to resume", opts.StateFile)
 		}
 	}
 
 	// Print summary
-	printSummary(&stats)
+	printSummary(ctx, &stats)
 
-	// Return error if there were failures
-	if stats.PackagesFailed > 0 || stats.UpdateFailures > 0 || stats.DeployFailures > 0 {
-		return fmt.Errorf("deployment completed with failures")
+	if opts.SummaryMarkdown != "" {
+		if err := writeMarkdownSummary(&stats, opts.SummaryMarkdown); err != nil {
+			logger.Error().Msgf("Failed to write Markdown summary: %v", err)
+		} else {
+			logger.Info().Msgf("Markdown summary written to %s", opts.SummaryMarkdown)
+		}
 	}
 
-	return nil
+	if opts.JUnitFile != "" {
+		if err := writeJUnitReport(&stats, opts.JUnitFile); err != nil {
+			logger.Error().Msgf("Failed to write JUnit report: %v", err)
+		} else {
+			logger.Info().Msgf("JUnit report written to %s", opts.JUnitFile)
+		}
+	}
+
+	if opts.MetricsEndpoint != "" {
+		now := time.Now()
+		if err := pushMetrics(opts.MetricsEndpoint, opts.MetricsFormat, buildOrchestratorMetrics(&stats, now), now); err != nil {
+			logger.Error().Msgf("Failed to push metrics: %v", err)
+		} else {
+			logger.Info().Msgf("Metrics pushed to %s", opts.MetricsEndpoint)
+		}
+	}
+
+	if err := ctx.Err(); err != nil {
+		return &stats, fmt.Errorf("orchestrator cancelled: %w", err)
+	}
+
+	// Return a phase error if there were failures
+	return &stats, resultError(&stats)
 }
 
-func processPackages(config *models.DeployConfig, applyPrefix bool, mode OperationMode,
-	packagesDir, workDir string, packageFilter, artifactFilter []string,
-	stats *ProcessingStats, serviceDetails *api.ServiceDetails) ([]DeploymentTask, error) {
+func processPackages(ctx context.Context, config *models.DeployConfig, applyPrefix bool, mode OperationMode,
+	packagesDir, workDir, backupDir string, packageFilter, artifactFilter []string, strictDirs, createMissingPackages, skipPackageUpdate, expandEnv bool,
+	updateExistingOnly, createOnly, onlyChanged bool, secrets *deploy.SecretResolver, stats *ProcessingStats, serviceDetails *api.ServiceDetails) ([]DeploymentTask, error) {
+	logger := runLogger(ctx)
 
 	var deploymentTasks []DeploymentTask
 
 	// Phase 1: Update all packages and artifacts
 	if mode != ModeDeployOnly {
-		log.Info().Msg("")
-		log.Info().Msg("═══════════════════════════════════════════════════════════════════════")
-		log.Info().Msg("PHASE 1: UPDATING ALL PACKAGES AND ARTIFACTS")
-		log.Info().Msg("═══════════════════════════════════════════════════════════════════════")
-		log.Info().Msg("")
+		logger.Info().Msg("")
+		logger.Info().Msg("═══════════════════════════════════════════════════════════════════════")
+		logger.Info().Msg("PHASE 1: UPDATING ALL PACKAGES AND ARTIFACTS")
+		logger.Info().Msg("═══════════════════════════════════════════════════════════════════════")
+		logger.Info().Msg("")
 	}
 
 	for _, pkg := range config.Packages {
+		if err := ctx.Err(); err != nil {
+			return deploymentTasks, err
+		}
+
 		// Apply package filter
 		if !shouldInclude(pkg.ID, packageFilter) {
-			log.Debug().Msgf("Skipping package %s (filtered)", pkg.ID)
+			logger.Debug().Msgf("Skipping package %s (filtered)", pkg.ID)
 			stats.PackagesFiltered++
 			continue
 		}
 
 		if !pkg.Sync && !pkg.Deploy {
-			log.Info().Msgf("Skipping package %s (sync=false, deploy=false)", pkg.ID)
+			logger.Info().Msgf("Skipping package %s (sync=false, deploy=false)", pkg.ID)
 			continue
 		}
 
-		log.Info().Msgf("━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━")
-		log.Info().Msgf("📦 Package: %s", pkg.ID)
+		logger.Info().Msgf("━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━")
+		logger.Info().Msgf("📦 Package: %s", pkg.ID)
+
+		finalPackageID, finalPackageName := packageIdentity(&pkg, config.DeploymentPrefix, applyPrefix)
 
 		packageDir := filepath.Join(packagesDir, pkg.PackageDir)
 		if !deploy.DirExists(packageDir) {
-			log.Warn().Msgf("Package directory not found: %s", packageDir)
+			if strictDirs {
+				logger.Error().Msgf("Package directory not found: %s", packageDir)
+				stats.FailedPackageUpdates[pkg.ID] = true
+				stats.PackagesFailed++
+				pkgResult := stats.packageResult(finalPackageID)
+				pkgResult.UpdateStatus = ResultFailed
+				pkgResult.Error = fmt.Sprintf("package directory not found: %s", packageDir)
+			} else {
+				logger.Warn().Msgf("Package directory not found: %s", packageDir)
+			}
 			continue
 		}
 
-		// Calculate final package ID and name
-		finalPackageID := pkg.ID
-		finalPackageName := pkg.DisplayName
-		if finalPackageName == "" {
-			finalPackageName = pkg.ID
-		}
+		logger.Info().Msgf("Package ID: %s", finalPackageID)
+		logger.Info().Msgf("Package Name: %s", finalPackageName)
 
-		// Apply prefix if needed
-		if applyPrefix && config.DeploymentPrefix != "" {
-			finalPackageID = config.DeploymentPrefix + "" + pkg.ID
-			finalPackageName = config.DeploymentPrefix + " - " + finalPackageName
-		}
-
-		log.Info().Msgf("Package ID: %s", finalPackageID)
-		log.Info().Msgf("Package Name: %s", finalPackageName)
+		pkgResult := stats.packageResult(finalPackageID)
+		packageReadOnly := false
+		packageCreated := false
 
 		// Update package metadata
 		if mode != ModeDeployOnly {
-			err := updatePackage(&pkg, finalPackageID, finalPackageName, workDir, serviceDetails)
-			if err != nil {
-				log.Error().Msgf("Failed to update package %s: %v", pkg.ID, err)
-				stats.FailedPackageUpdates[pkg.ID] = true
-				stats.PackagesFailed++
-				continue
+			var ip *api.IntegrationPackage
+			if serviceDetails != nil {
+				ip = api.NewIntegrationPackage(api.InitHTTPExecuter(serviceDetails))
+			}
+			exists, readOnly, err := tenantPackageState(ip, finalPackageID)
+			if err == nil && !exists && !createMissingPackages {
+				err = fmt.Errorf("package %s does not exist on the tenant and --create-missing-packages is false - check the tenant and deployment prefix", finalPackageID)
+			}
+			if err == nil && readOnly {
+				// The artifacts of the package are still deployed, e.g. after configuring them on the tenant
+				logger.Warn().Msgf("Skipping update of package %s as it is Configure-only (read-only) on the tenant and cannot be updated", finalPackageID)
+				stats.PackagesFiltered++
+				pkgResult.UpdateStatus = ResultSkipped
+				pkgResult.Error = skippedReadOnly
+				packageReadOnly = true
+			} else if err == nil && exists && skipPackageUpdate {
+				logger.Info().Msgf("Package %s exists, metadata not updated (--skip-package-update)", finalPackageID)
+				stats.PackagesSkipped++
+				pkgResult.UpdateStatus = ResultSkipped
+				pkgResult.Error = skippedPackageUpdate
+			} else {
+				if err == nil {
+					err = updatePackage(ctx, ip, &pkg, finalPackageID, finalPackageName, exists)
+				}
+				if err != nil {
+					logger.Error().Msgf("Failed to update package %s: %v", pkg.ID, err)
+					stats.FailedPackageUpdates[pkg.ID] = true
+					stats.PackagesFailed++
+					pkgResult.UpdateStatus = ResultFailed
+					pkgResult.Error = err.Error()
+					continue
+				}
+				stats.SuccessfulPackageUpdates[pkg.ID] = true
+				stats.PackagesUpdated++
+				pkgResult.UpdateStatus = ResultSuccess
+				packageCreated = !exists
 			}
-			stats.SuccessfulPackageUpdates[pkg.ID] = true
-			stats.PackagesUpdated++
 		}
 
 		// Process artifacts for update
-		if pkg.Sync && mode != ModeDeployOnly {
-			if err := updateArtifacts(&pkg, packageDir, finalPackageID, finalPackageName,
-				config.DeploymentPrefix, workDir, artifactFilter, stats, serviceDetails); err != nil {
-				log.Error().Msgf("Failed to update artifacts for package %s: %v", pkg.ID, err)
+		if pkg.Sync && mode != ModeDeployOnly && !packageReadOnly {
+			if err := updateArtifacts(ctx, &pkg, packageDir, finalPackageID, finalPackageName,
+				config.DeploymentPrefix, workDir, backupDir, artifactFilter, packageCreated, strictDirs, expandEnv, updateExistingOnly, createOnly, onlyChanged, secrets, stats, serviceDetails); err != nil {
+				logger.Error().Msgf("Failed to update artifacts for package %s: %v", pkg.ID, err)
 				stats.UpdateFailures++
 			}
 		}
 
 		// Collect deployment tasks (will be executed in phase 2)
 		if pkg.Deploy && mode != ModeUpdateOnly {
-			tasks := collectDeploymentTasks(&pkg, finalPackageID, config.DeploymentPrefix,
+			tasks := collectDeploymentTasks(ctx, &pkg, finalPackageID, config.DeploymentPrefix,
 				artifactFilter, stats)
 			deploymentTasks = append(deploymentTasks, tasks...)
 		}
@@ -527,15 +1334,67 @@ func processPackages(config *models.DeployConfig, applyPrefix bool, mode Operati
 	return deploymentTasks, nil
 }
 
-func updatePackage(pkg *models.Package, finalPackageID, finalPackageName, workDir string,
-	serviceDetails *api.ServiceDetails) error {
+// packageIdentity returns the package ID and name as created on the tenant
+func packageIdentity(pkg *models.Package, prefix string, applyPrefix bool) (string, string) {
+	finalPackageID := pkg.ID
+	finalPackageName := pkg.DisplayName
+	if finalPackageName == "" {
+		finalPackageName = pkg.ID
+	}
+	if applyPrefix && prefix != "" {
+		finalPackageID = prefix + "" + pkg.ID
+		finalPackageName = prefix + " - " + finalPackageName
+	}
+	return finalPackageID, finalPackageName
+}
 
-	if serviceDetails == nil {
+// tenantPackageState reports whether the package exists on the tenant and whether it is
+// Configure-only (read-only), e.g. SAP-managed content that cannot be updated
+func tenantPackageState(ip *api.IntegrationPackage, packageID string) (exists bool, readOnly bool, err error) {
+	if ip == nil {
+		return false, false, fmt.Errorf("serviceDetails is nil - cannot check package")
+	}
+	_, readOnly, exists, err = ip.Get(packageID)
+	if err != nil {
+		return false, false, fmt.Errorf("failed to check package %s on the tenant: %w", packageID, err)
+	}
+	return exists, readOnly, nil
+}
+
+// updatePackage creates the package when it does not exist on the tenant, otherwise it updates
+// the package metadata
+func updatePackage(ctx context.Context, ip *api.IntegrationPackage, pkg *models.Package, finalPackageID, finalPackageName string, exists bool) error {
+	logger := runLogger(ctx)
+	if ip == nil {
 		return fmt.Errorf("serviceDetails is nil - cannot update package")
 	}
 
-	log.Info().Msg("Updating package in tenant...")
+	jsonData, err := buildPackageJSON(pkg, finalPackageID, finalPackageName)
+	if err != nil {
+		return err
+	}
+	packageData := new(api.PackageSingleData)
+	if err := json.Unmarshal(jsonData, packageData); err != nil {
+		return fmt.Errorf("failed to parse package JSON: %w", err)
+	}
+
+	if !exists {
+		if err := ip.Create(packageData); err != nil {
+			return fmt.Errorf("failed to create package %s: %w", finalPackageID, err)
+		}
+		logger.Info().Msg("  ✓ Package created")
+		return nil
+	}
+	if err := ip.Update(packageData); err != nil {
+		return fmt.Errorf("failed to update package %s: %w", finalPackageID, err)
+	}
+	logger.Info().Msg("  ✓ Package metadata updated")
+	return nil
+}
 
+// buildPackageJSON creates the package JSON used to create or update the package in the tenant.
+// Version and Vendor are only included when set in the deploy config.
+func buildPackageJSON(pkg *models.Package, finalPackageID, finalPackageName string) ([]byte, error) {
 	description := pkg.Description
 	if description == "" {
 		description = finalPackageName
@@ -546,51 +1405,33 @@ func updatePackage(pkg *models.Package, finalPackageID, finalPackageName, workDi
 		shortText = finalPackageName
 	}
 
-	// Create package JSON
-	packageJSON := map[string]interface{}{
-		"d": map[string]interface{}{
-			"Id":          finalPackageID,
-			"Name":        finalPackageName,
-			"Description": description,
-			"ShortText":   shortText,
-		},
-	}
-
-	jsonData, err := json.MarshalIndent(packageJSON, "", "  ")
-	if err != nil {
-		return fmt.Errorf("failed to marshal package JSON: %w", err)
+	packageData := map[string]interface{}{
+		"Id":          finalPackageID,
+		"Name":        finalPackageName,
+		"Description": description,
+		"ShortText":   shortText,
 	}
-
-	// Write to temporary file
-	packageJSONPath := filepath.Join(workDir, "modified", fmt.Sprintf("package_%s.json", pkg.ID))
-	if err := os.MkdirAll(filepath.Dir(packageJSONPath), 0755); err != nil {
-		return fmt.Errorf("failed to create package JSON directory: %w", err)
+	if pkg.Version != "" {
+		packageData["Version"] = pkg.Version
 	}
-
-	if err := os.WriteFile(packageJSONPath, jsonData, 0644); err != nil {
-		return fmt.Errorf("failed to write package JSON: %w", err)
+	if pkg.Vendor != "" {
+		packageData["Vendor"] = pkg.Vendor
 	}
 
-	// Use internal sync package update function
-	exe := api.InitHTTPExecuter(serviceDetails)
-	packageSynchroniser := flashpipeSync.NewSyncer("tenant", "CPIPackage", exe)
-
-	err = packageSynchroniser.Exec(flashpipeSync.Request{PackageFile: packageJSONPath})
+	jsonData, err := json.MarshalIndent(map[string]interface{}{"d": packageData}, "", "  ")
 	if err != nil {
-		log.Warn().Msgf("Package update warning (may not exist yet): %v", err)
-		// Don't return error - package might not exist yet
-		return nil
+		return nil, fmt.Errorf("failed to marshal package JSON: %w", err)
 	}
-
-	log.Info().Msg("  ✓ Package metadata updated")
-	return nil
+	return jsonData, nil
 }
 
-func updateArtifacts(pkg *models.Package, packageDir, finalPackageID, finalPackageName, prefix, workDir string,
-	artifactFilter []string, stats *ProcessingStats, serviceDetails *api.ServiceDetails) error {
+func updateArtifacts(ctx context.Context, pkg *models.Package, packageDir, finalPackageID, finalPackageName, prefix, workDir, backupDir string,
+	artifactFilter []string, packageCreated, strictDirs, expandEnv, updateExistingOnly, createOnly, onlyChanged bool, secrets *deploy.SecretResolver,
+	stats *ProcessingStats, serviceDetails *api.ServiceDetails) error {
+	logger := runLogger(ctx)
 
 	updatedCount := 0
-	log.Info().Msg("Updating artifacts...")
+	logger.Info().Msg("Updating artifacts...")
 
 	if serviceDetails == nil {
 		return fmt.Errorf("serviceDetails is nil - cannot initialize HTTP executer")
@@ -599,70 +1440,140 @@ func updateArtifacts(pkg *models.Package, packageDir, finalPackageID, finalPacka
 		return fmt.Errorf("serviceDetails.Host is empty - check CPI credentials in config file")
 	}
 
-	log.Info().Msgf("DEBUG: ServiceDetails before InitHTTPExecuter:")
-	log.Info().Msgf("  Host: %s", serviceDetails.Host)
-	log.Info().Msgf("  OauthHost: %s", serviceDetails.OauthHost)
-	log.Info().Msgf("  OauthClientId: %s", serviceDetails.OauthClientId)
-	log.Info().Msgf("  OauthPath: %s", serviceDetails.OauthPath)
-	log.Info().Msgf("  Userid: %s", serviceDetails.Userid)
-
-	log.Debug().Msgf("Initializing HTTP executer with host: %s", serviceDetails.Host)
+	logger.Debug().Msgf("Initializing HTTP executer with host: %s", serviceDetails.Host)
 	exe := api.InitHTTPExecuter(serviceDetails)
 	if exe == nil {
 		return fmt.Errorf("failed to initialize HTTP executer")
 	}
 
-	log.Info().Msgf("DEBUG: exe after InitHTTPExecuter is NOT nil")
-
 	synchroniser := flashpipeSync.New(exe)
 	if synchroniser == nil {
 		return fmt.Errorf("failed to initialize synchroniser")
 	}
 
-	log.Info().Msgf("DEBUG: synchroniser created successfully")
+	pkgResult := stats.packageResult(finalPackageID)
 
-	for _, artifact := range pkg.Artifacts {
+	// Preflight: look up the types of the artifacts already in the package, so that a
+	// wrong type in the config is reported instead of surfacing as an opaque API error.
+	// A package created in this run has no artifacts yet.
+	tenantTypes := map[string]string{}
+	if !packageCreated {
+		var err error
+		tenantTypes, err = tenantArtifactTypes(api.NewIntegrationPackage(exe), finalPackageID)
+		if err != nil {
+			logger.Warn().Msgf("Skipping artifact type check, failed to get artifacts of package %s: %v", finalPackageID, err)
+		}
+	}
+
+	for _, artifact := range deploy.SortArtifactsByOrder(pkg.Artifacts) {
 		// Apply artifact filter
 		if !shouldInclude(artifact.Id, artifactFilter) {
-			log.Debug().Msgf("Skipping artifact %s (filtered)", artifact.Id)
+			logger.Debug().Msgf("Skipping artifact %s (filtered)", artifact.Id)
 			stats.ArtifactsFiltered++
+			filteredResult := pkgResult.artifact(prefixedArtifactID(prefix, artifact))
+			filteredResult.UpdateStatus = ResultSkipped
+			filteredResult.Error = skippedByFilter
 			continue
 		}
 
 		if !artifact.Sync {
-			log.Debug().Msgf("Skipping artifact %s (sync=false)", artifact.DisplayName)
+			logger.Debug().Msgf("Skipping artifact %s (sync=false)", artifact.DisplayName)
 			continue
 		}
 
 		stats.ArtifactsTotal++
 
-		artifactDir := filepath.Join(packageDir, artifact.ArtifactDir)
-		if !deploy.DirExists(artifactDir) {
-			log.Warn().Msgf("Artifact directory not found: %s", artifactDir)
-			continue
-		}
-
 		// Calculate final artifact ID and name
-		finalArtifactID := artifact.Id
+		finalArtifactID := prefixedArtifactID(prefix, artifact)
 		finalArtifactName := artifact.DisplayName
 		if finalArtifactName == "" {
 			finalArtifactName = artifact.Id
 		}
 
-		if prefix != "" {
-			finalArtifactID = prefix + "_" + artifact.Id
+		artifactResult := pkgResult.artifact(finalArtifactID)
+
+		if stats.completed != nil && stats.completed.updated[finalArtifactID] {
+			logger.Info().Msgf("  Skipping %s: %s", finalArtifactID, skippedResumed)
+			stats.ArtifactsResumed++
+			artifactResult.UpdateStatus = ResultSkipped
+			artifactResult.Error = skippedResumed
+			continue
+		}
+
+		artifactDir := filepath.Join(packageDir, artifact.ArtifactDir)
+		if !deploy.DirExists(artifactDir) {
+			artifactResult.Error = fmt.Sprintf("artifact directory not found: %s", artifactDir)
+			if strictDirs {
+				logger.Error().Msgf("Artifact directory not found: %s", artifactDir)
+				stats.UpdateFailures++
+				stats.FailedArtifactUpdates[artifact.Id] = true
+				artifactResult.UpdateStatus = ResultFailed
+			} else {
+				logger.Warn().Msgf("Artifact directory not found: %s", artifactDir)
+				artifactResult.UpdateStatus = ResultSkipped
+			}
+			continue
 		}
 
-		log.Info().Msgf("  Updating: %s", finalArtifactID)
+		logger.Info().Msgf("  Updating: %s", finalArtifactID)
 
 		// Map artifact type for synchroniser (uses simple type names)
 		artifactType := mapArtifactTypeForSync(artifact.Type)
 
+		if err := checkArtifactType(finalArtifactID, artifactType, tenantTypes); err != nil {
+			logger.Error().Msgf("%v", err)
+			stats.UpdateFailures++
+			stats.FailedArtifactUpdates[artifact.Id] = true
+			artifactResult.UpdateStatus = ResultFailed
+			artifactResult.Error = err.Error()
+			continue
+		}
+
+		if updateExistingOnly || createOnly {
+			exists, err := artifactExistsOnTenant(exe, finalArtifactID, artifactType, tenantTypes)
+			if err != nil {
+				logger.Error().Msgf("%v", err)
+				stats.UpdateFailures++
+				stats.FailedArtifactUpdates[artifact.Id] = true
+				artifactResult.UpdateStatus = ResultFailed
+				artifactResult.Error = err.Error()
+				continue
+			}
+			if skipReason := existenceSkipReason(exists, updateExistingOnly, createOnly); skipReason != "" {
+				logger.Warn().Msgf("Skipping artifact %s: %s", finalArtifactID, skipReason)
+				stats.SkippedArtifactUpdates[artifact.Id] = true
+				artifactResult.UpdateStatus = ResultSkipped
+				artifactResult.Error = skipReason
+				continue
+			}
+		}
+
+		// Keep a copy of the tenant version, so that the artifact can be restored after a bad update
+		if backupDir != "" {
+			backedUp, err := backupArtifact(exe, finalArtifactID, artifactType, finalPackageID, backupDir, workDir, tenantTypes)
+			if err != nil {
+				logger.Error().Msgf("Backup failed for %s: %v", finalArtifactID, err)
+				stats.UpdateFailures++
+				stats.FailedArtifactUpdates[artifact.Id] = true
+				artifactResult.UpdateStatus = ResultFailed
+				artifactResult.Error = err.Error()
+				continue
+			}
+			if backedUp {
+				logger.Info().Msgf("    Backed up tenant version to %s", filepath.Join(backupDir, finalPackageID, finalArtifactID))
+			} else {
+				logger.Debug().Msgf("Artifact %s does not exist on the tenant yet, nothing to back up", finalArtifactID)
+			}
+		}
+
 		// Create temp directory for this artifact
 		tempArtifactDir := filepath.Join(workDir, artifact.Id)
 		if err := deploy.CopyDir(artifactDir, tempArtifactDir); err != nil {
-			log.Error().Msgf("Failed to copy artifact to temp: %v", err)
+			logger.Error().Msgf("Failed to copy artifact to temp: %v", err)
+			stats.UpdateFailures++
 			stats.FailedArtifactUpdates[artifact.Id] = true
+			artifactResult.UpdateStatus = ResultFailed
+			artifactResult.Error = err.Error()
 			continue
 		}
 
@@ -672,10 +1583,28 @@ func updateArtifacts(pkg *models.Package, packageDir, finalPackageID, finalPacka
 
 		if deploy.FileExists(manifestPath) {
 			if err := deploy.UpdateManifestBundleName(manifestPath, finalArtifactID, finalArtifactName, modifiedManifestPath); err != nil {
-				log.Warn().Msgf("Failed to update MANIFEST.MF: %v", err)
+				logger.Warn().Msgf("Failed to update MANIFEST.MF: %v", err)
 			}
 		}
 
+		// Combine overrides from the referenced file with the inline overrides
+		configOverrides, err := deploy.LoadArtifactOverrides(artifact)
+		if err == nil && expandEnv {
+			configOverrides, err = expandOverrideValues(configOverrides)
+		}
+		// Secrets are resolved last, so that their values are written as they are
+		if err == nil {
+			configOverrides, err = resolveOverrideSecrets(secrets, configOverrides)
+		}
+		if err != nil {
+			logger.Error().Msgf("Failed to load config overrides: %v", err)
+			stats.UpdateFailures++
+			stats.FailedArtifactUpdates[artifact.Id] = true
+			artifactResult.UpdateStatus = ResultFailed
+			artifactResult.Error = err.Error()
+			continue
+		}
+
 		// Handle parameters.prop
 		var modifiedParamsPath string
 		paramsPath := deploy.FindParametersFile(tempArtifactDir)
@@ -683,11 +1612,11 @@ func updateArtifacts(pkg *models.Package, packageDir, finalPackageID, finalPacka
 		if paramsPath != "" && deploy.FileExists(paramsPath) {
 			modifiedParamsPath = filepath.Join(workDir, "modified", artifact.Id, "parameters.prop")
 
-			if len(artifact.ConfigOverrides) > 0 {
-				if err := deploy.MergeParametersFile(paramsPath, artifact.ConfigOverrides, modifiedParamsPath); err != nil {
-					log.Warn().Msgf("Failed to merge parameters: %v", err)
+			if len(configOverrides) > 0 {
+				if err := deploy.MergeParametersFile(paramsPath, configOverrides, modifiedParamsPath); err != nil {
+					logger.Warn().Msgf("Failed to merge parameters: %v", err)
 				} else {
-					log.Debug().Msgf("Applied %d config overrides", len(artifact.ConfigOverrides))
+					logger.Debug().Msgf("Applied %d config overrides", len(configOverrides))
 				}
 			} else {
 				// No overrides, copy to modified location
@@ -718,61 +1647,95 @@ func updateArtifacts(pkg *models.Package, packageDir, finalPackageID, finalPacka
 			}
 		}
 
+		// Replace value mapping entries with the values of the target environment
+		if artifact.ValueMappingFile != "" {
+			if err := applyValueMappingFile(ctx, artifact, artifactType, tempArtifactDir); err != nil {
+				logger.Error().Msgf("Failed to apply value mapping file: %v", err)
+				stats.UpdateFailures++
+				stats.FailedArtifactUpdates[artifact.Id] = true
+				artifactResult.UpdateStatus = ResultFailed
+				artifactResult.Error = err.Error()
+				continue
+			}
+		}
+
+		// Skip the update when the prepared content is identical to the tenant version
+		if onlyChanged {
+			unchanged, err := artifactUnchanged(exe, finalArtifactID, artifactType, tempArtifactDir, workDir, tenantTypes)
+			if err != nil {
+				logger.Warn().Msgf("Failed to compare %s with the tenant version, updating it: %v", finalArtifactID, err)
+			} else if unchanged {
+				logger.Info().Msg("    = Unchanged, skipping update")
+				stats.ArtifactsUnchanged++
+				artifactResult.UpdateStatus = ResultSkipped
+				artifactResult.Error = skippedUnchanged
+				continue
+			}
+		}
+
 		// Call internal sync function
-		log.Debug().Msgf("DEBUG: About to call SingleArtifactToTenant for %s", finalArtifactID)
-		log.Debug().Msgf("  synchroniser: %v", synchroniser)
-		log.Debug().Msgf("  finalPackageID: %s", finalPackageID)
-		log.Debug().Msgf("  artifactType: %s", artifactType)
+		logger.Debug().Msgf("Updating %s artifact %s in package %s", artifactType, finalArtifactID, finalPackageID)
 
-		err := synchroniser.SingleArtifactToTenant(finalArtifactID, finalArtifactName, artifactType,
+		err = synchroniser.SingleArtifactToTenant(finalArtifactID, finalArtifactName, artifactType,
 			finalPackageID, tempArtifactDir, workDir, "", nil)
 
 		if err != nil {
-			log.Error().Msgf("Update failed for %s: %v", finalArtifactName, err)
+			logger.Error().Msgf("Update failed for %s: %v", finalArtifactName, err)
 			stats.UpdateFailures++
 			stats.FailedArtifactUpdates[artifact.Id] = true
+			artifactResult.UpdateStatus = ResultFailed
+			artifactResult.Error = err.Error()
 			continue
 		}
 
-		log.Info().Msg("    ✓ Updated successfully")
+		logger.Info().Msg("    ✓ Updated successfully")
 		updatedCount++
 		stats.SuccessfulArtifactUpdates[finalArtifactID] = true
+		artifactResult.UpdateStatus = ResultSuccess
 	}
 
 	if updatedCount > 0 {
-		log.Info().Msgf("✓ Updated %d artifact(s) in package", updatedCount)
+		logger.Info().Msgf("✓ Updated %d artifact(s) in package", updatedCount)
 	}
 
 	return nil
 }
 
-func collectDeploymentTasks(pkg *models.Package, finalPackageID, prefix string,
+func collectDeploymentTasks(ctx context.Context, pkg *models.Package, finalPackageID, prefix string,
 	artifactFilter []string, stats *ProcessingStats) []DeploymentTask {
+	logger := runLogger(ctx)
 
 	var tasks []DeploymentTask
 
 	for _, artifact := range pkg.Artifacts {
 		// Skip if update failed
 		if stats.FailedArtifactUpdates[artifact.Id] {
-			log.Debug().Msgf("Skipping artifact %s (due to failed update)", artifact.Id)
+			logger.Debug().Msgf("Skipping artifact %s (due to failed update)", artifact.Id)
+			continue
+		}
+
+		// Skip if update was skipped because of the artifact's existence on the tenant
+		if stats.SkippedArtifactUpdates[artifact.Id] {
+			logger.Debug().Msgf("Skipping artifact %s (update skipped)", artifact.Id)
+			stats.packageResult(finalPackageID).artifact(prefixedArtifactID(prefix, artifact)).DeployStatus = ResultSkipped
 			continue
 		}
 
 		// Apply artifact filter
 		if !shouldInclude(artifact.Id, artifactFilter) {
-			log.Debug().Msgf("Skipping artifact %s (filtered)", artifact.Id)
+			logger.Debug().Msgf("Skipping artifact %s (filtered)", artifact.Id)
+			filteredResult := stats.packageResult(finalPackageID).artifact(prefixedArtifactID(prefix, artifact))
+			filteredResult.DeployStatus = ResultSkipped
+			filteredResult.Error = skippedByFilter
 			continue
 		}
 
 		if !artifact.Deploy {
-			log.Debug().Msgf("Skipping artifact %s (deploy=false)", artifact.DisplayName)
+			logger.Debug().Msgf("Skipping artifact %s (deploy=false)", artifact.DisplayName)
 			continue
 		}
 
-		finalArtifactID := artifact.Id
-		if prefix != "" {
-			finalArtifactID = prefix + "_" + artifact.Id
-		}
+		finalArtifactID := prefixedArtifactID(prefix, artifact)
 
 		artifactType := artifact.Type
 		if artifactType == "" {
@@ -784,126 +1747,408 @@ func collectDeploymentTasks(pkg *models.Package, finalPackageID, prefix string,
 			ArtifactType: artifactType,
 			PackageID:    finalPackageID,
 			DisplayName:  artifact.DisplayName,
+
+			PackageDeployOrder: pkg.DeployOrder,
+			DeployOrder:        artifact.DeployOrder,
 		})
 	}
 
 	return tasks
 }
 
-func deployAllArtifactsParallel(tasks []DeploymentTask, maxConcurrent int,
-	retries int, delaySeconds int, stats *ProcessingStats, serviceDetails *api.ServiceDetails) error {
+// deployAllArtifactsParallel deploys the tasks of up to maxPackages packages at a time, with up to
+// maxConcurrent deployments per package. Stats and progress are shared by the packages.
+// With scriptsFirst, all script collections are deployed before the other artifacts.
+// Once maxFailures deployments have failed, no further deployments are started.
+func deployAllArtifactsParallel(ctx context.Context, tasks []DeploymentTask, maxConcurrent, maxPackages, maxFailures int,
+	scriptsFirst bool, retries int, delaySeconds int, showProgress bool, stats *ProcessingStats, serviceDetails *api.ServiceDetails) error {
+	logger := runLogger(ctx)
 
-	// Group tasks by package for better control
-	tasksByPackage := make(map[string][]DeploymentTask)
+	if maxPackages < 1 {
+		maxPackages = 1
+	}
+	progress := newDeployProgress(len(tasks), maxConcurrent*maxPackages)
+	limit := newFailureLimit(maxFailures)
+
+	if !scriptsFirst {
+		deployInPackageOrder(ctx, tasks, maxConcurrent, maxPackages, retries, delaySeconds, showProgress, progress, limit,
+			stats, serviceDetails)
+		return nil
+	}
+
+	scripts, others := splitScriptCollections(tasks)
+	if len(scripts) > 0 {
+		logger.Info().Msgf("Deploying %d script collection(s) before the other artifacts", len(scripts))
+		deployInPackageOrder(ctx, scripts, maxConcurrent, maxPackages, retries, delaySeconds, showProgress, progress, limit,
+			stats, serviceDetails)
+	}
+	if len(others) > 0 {
+		if len(scripts) > 0 {
+			logger.Info().Msgf("Deploying the remaining %d artifact(s)", len(others))
+		}
+		deployInPackageOrder(ctx, others, maxConcurrent, maxPackages, retries, delaySeconds, showProgress, progress, limit,
+			stats, serviceDetails)
+	}
+	return nil
+}
+
+// splitScriptCollections separates the script collection tasks from the other tasks, keeping their order
+func splitScriptCollections(tasks []DeploymentTask) (scripts, others []DeploymentTask) {
 	for _, task := range tasks {
-		tasksByPackage[task.PackageID] = append(tasksByPackage[task.PackageID], task)
+		if mapArtifactTypeForSync(task.ArtifactType) == "ScriptCollection" {
+			scripts = append(scripts, task)
+		} else {
+			others = append(others, task)
+		}
 	}
+	return
+}
 
-	// Process each package's deployments
-	for packageID, packageTasks := range tasksByPackage {
-		log.Info().Msgf("━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━")
-		log.Info().Msgf("📦 Deploying %d artifacts for package: %s", len(packageTasks), packageID)
+// deployInPackageOrder deploys the packages of the tasks in groups of equal deployOrder, lowest first.
+// When a deployment fails, the packages with a higher deployOrder are not deployed.
+func deployInPackageOrder(ctx context.Context, tasks []DeploymentTask, maxConcurrent, maxPackages int,
+	retries int, delaySeconds int, showProgress bool, progress *deployProgress, limit *failureLimit,
+	stats *ProcessingStats, serviceDetails *api.ServiceDetails) {
+	logger := runLogger(ctx)
+
+	waves := groupByDeployOrder(tasks, func(t DeploymentTask) int { return t.PackageDeployOrder })
+	dependencyFailed := false
+	for _, wave := range waves {
+		packageIDs, tasksByPackage := groupTasksByPackage(wave)
+		if len(waves) > 1 {
+			logger.Info().Msgf("Deploying %d package(s) with deployOrder %d", len(packageIDs), wave[0].PackageDeployOrder)
+		}
+
+		if dependencyFailed {
+			for _, packageID := range packageIDs {
+				logger.Warn().Msgf("⚠ Package %s not deployed as a package with a lower deployOrder failed to deploy", packageID)
+				for _, task := range tasksByPackage[packageID] {
+					stats.recordDeployResult(packageID, deployResult{Task: task, Skipped: skippedFailedDependency})
+				}
+				stats.recordPackageDeploy(packageID, true)
+			}
+			continue
+		}
 
-		// Deploy artifacts in parallel with semaphore
+		failuresBefore := stats.DeployFailures
 		var wg sync.WaitGroup
-		semaphore := make(chan struct{}, maxConcurrent)
-		resultChan := make(chan deployResult, len(packageTasks))
+		packageSemaphore := make(chan struct{}, maxPackages)
 
-		for _, task := range packageTasks {
+		for _, packageID := range packageIDs {
 			wg.Add(1)
-			go func(t DeploymentTask) {
+			go func(packageID string, packageTasks []DeploymentTask) {
 				defer wg.Done()
+				packageSemaphore <- struct{}{}
+				defer func() { <-packageSemaphore }()
 
-				// Acquire semaphore
-				semaphore <- struct{}{}
-				defer func() { <-semaphore }()
+				deployPackageArtifacts(ctx, packageID, packageTasks, maxConcurrent, retries, delaySeconds,
+					showProgress, progress, limit, stats, serviceDetails)
+			}(packageID, tasksByPackage[packageID])
+		}
+		wg.Wait()
 
-				// Deploy artifact
-				// Use mapArtifactTypeForSync because deployArtifacts calls api.NewDesigntimeArtifact
-				flashpipeType := mapArtifactTypeForSync(t.ArtifactType)
-				log.Info().Msgf("  → Deploying: %s (type: %s)", t.ArtifactID, t.ArtifactType)
+		dependencyFailed = stats.DeployFailures > failuresBefore
+	}
+}
 
-				err := deployArtifacts([]string{t.ArtifactID}, flashpipeType, retries, delaySeconds, true, serviceDetails)
+// groupTasksByPackage groups the tasks by package, keeping the order in which the packages appear
+func groupTasksByPackage(tasks []DeploymentTask) ([]string, map[string][]DeploymentTask) {
+	var packageIDs []string
+	tasksByPackage := make(map[string][]DeploymentTask)
+	for _, task := range tasks {
+		if _, exists := tasksByPackage[task.PackageID]; !exists {
+			packageIDs = append(packageIDs, task.PackageID)
+		}
+		tasksByPackage[task.PackageID] = append(tasksByPackage[task.PackageID], task)
+	}
+	return packageIDs, tasksByPackage
+}
 
-				resultChan <- deployResult{
-					Task:  t,
-					Error: err,
-				}
-			}(task)
+// groupByDeployOrder splits the tasks into groups of equal deploy order, lowest order first.
+// Tasks keep their relative order within a group.
+func groupByDeployOrder(tasks []DeploymentTask, order func(DeploymentTask) int) [][]DeploymentTask {
+	sorted := make([]DeploymentTask, len(tasks))
+	copy(sorted, tasks)
+	sort.SliceStable(sorted, func(i, j int) bool {
+		return order(sorted[i]) < order(sorted[j])
+	})
+
+	var groups [][]DeploymentTask
+	for i, task := range sorted {
+		if i == 0 || order(task) != order(sorted[i-1]) {
+			groups = append(groups, nil)
+		}
+		groups[len(groups)-1] = append(groups[len(groups)-1], task)
+	}
+	return groups
+}
+
+// deployPackageArtifacts deploys the tasks of one package in the order of their deployOrder, with up
+// to maxConcurrent deployments at a time. When a deployment fails, the artifacts with a higher
+// deployOrder are not deployed.
+func deployPackageArtifacts(ctx context.Context, packageID string, packageTasks []DeploymentTask, maxConcurrent int,
+	retries int, delaySeconds int, showProgress bool, progress *deployProgress, limit *failureLimit,
+	stats *ProcessingStats, serviceDetails *api.ServiceDetails) {
+	logger := runLogger(ctx)
+
+	logger.Info().Msgf("━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━")
+	logger.Info().Msgf("📦 Deploying %d artifacts for package: %s", len(packageTasks), packageID)
+
+	successCount := 0
+	failureCount := 0
+	skippedCount := 0
+
+	for _, wave := range groupByDeployOrder(packageTasks, func(t DeploymentTask) int { return t.DeployOrder }) {
+		if failureCount > 0 {
+			for _, task := range wave {
+				logger.Warn().Msgf("  ⏭ Not deployed: %s (an artifact with a lower deployOrder failed)", task.ArtifactID)
+				stats.recordDeployResult(packageID, deployResult{Task: task, Skipped: skippedFailedDependency})
+				skippedCount++
+			}
+			continue
 		}
+		success, failure, skipped := deployArtifactWave(ctx, packageID, wave, maxConcurrent, retries, delaySeconds,
+			showProgress, progress, limit, stats, serviceDetails)
+		successCount += success
+		failureCount += failure
+		skippedCount += skipped
+	}
+
+	if failureCount == 0 && skippedCount == 0 {
+		logger.Info().Msgf("✓ All %d artifacts deployed successfully for package %s", successCount, packageID)
+	} else if skippedCount > 0 {
+		logger.Warn().Msgf("⚠ Package %s: %d succeeded, %d failed, %d not deployed", packageID, successCount, failureCount, skippedCount)
+	} else {
+		logger.Warn().Msgf("⚠ Package %s: %d succeeded, %d failed", packageID, successCount, failureCount)
+	}
+	stats.recordPackageDeploy(packageID, failureCount > 0 || skippedCount > 0)
+}
+
+// deployArtifactWave deploys tasks of a package in parallel, with up to maxConcurrent deployments
+// at a time, and returns the number of successful, failed and not started deployments
+func deployArtifactWave(ctx context.Context, packageID string, tasks []DeploymentTask, maxConcurrent int,
+	retries int, delaySeconds int, showProgress bool, progress *deployProgress, limit *failureLimit,
+	stats *ProcessingStats, serviceDetails *api.ServiceDetails) (successCount, failureCount, skippedCount int) {
+	logger := runLogger(ctx)
+
+	// Deploy artifacts in parallel with semaphore
+	var wg sync.WaitGroup
+	semaphore := make(chan struct{}, maxConcurrent)
+	resultChan := make(chan deployResult, len(tasks))
+
+	for _, task := range tasks {
+		wg.Add(1)
+		go func(t DeploymentTask) {
+			defer wg.Done()
+
+			// Acquire semaphore, don't start further deployments once cancelled or too many failed
+			select {
+			case semaphore <- struct{}{}:
+			case <-ctx.Done():
+				resultChan <- deployResult{Task: t, Error: ctx.Err()}
+				return
+			case <-limit.reached:
+				resultChan <- deployResult{Task: t, Skipped: skippedMaxFailures}
+				return
+			}
+			defer func() { <-semaphore }()
 
-		// Wait for all deployments to complete
+			if err := ctx.Err(); err != nil {
+				resultChan <- deployResult{Task: t, Error: err}
+				return
+			}
+			if limit.isReached() {
+				resultChan <- deployResult{Task: t, Skipped: skippedMaxFailures}
+				return
+			}
+
+			// Deploy artifact
+			// Use mapArtifactTypeForSync because deployArtifacts calls api.NewDesigntimeArtifact
+			flashpipeType := mapArtifactTypeForSync(t.ArtifactType)
+			logger.Info().Msgf("  → Deploying: %s (type: %s)", t.ArtifactID, t.ArtifactType)
+
+			start := time.Now()
+			err := deployArtifacts(ctx, []string{t.ArtifactID}, flashpipeType, retries, delaySeconds, true, serviceDetails)
+			result := deployResult{
+				Task:     t,
+				Error:    err,
+				Duration: time.Since(start),
+			}
+			if err == nil {
+				result.Version, result.DeployedOn = queryDeployedVersion(ctx, t.ArtifactID, serviceDetails)
+			}
+			resultChan <- result
+		}(task)
+	}
+
+	// Close the channel once all deployments are complete
+	go func() {
 		wg.Wait()
 		close(resultChan)
+	}()
 
-		// Process results
-		successCount := 0
-		failureCount := 0
-
-		for result := range resultChan {
-			if result.Error != nil {
-				log.Error().Msgf("  ✗ Deploy failed: %s - %v", result.Task.ArtifactID, result.Error)
-				stats.ArtifactsDeployedFailed++
-				stats.DeployFailures++
-				stats.FailedArtifactDeploys[result.Task.ArtifactID] = true
-				failureCount++
-			} else {
-				log.Info().Msgf("  ✓ Deployed: %s", result.Task.ArtifactID)
-				stats.ArtifactsDeployedSuccess++
-				stats.SuccessfulArtifactDeploys[result.Task.ArtifactID] = true
-				successCount++
+	// Process results as they arrive, stats and progress are shared with the packages deployed in parallel
+	for result := range resultChan {
+		stats.recordDeployResult(packageID, result)
+		if result.Skipped != "" {
+			skippedCount++
+			continue
+		}
+		if result.Error != nil {
+			logger.Error().Msgf("  ✗ Deploy failed: %s - %v", result.Task.ArtifactID, result.Error)
+			failureCount++
+			if limit.recordFailure() {
+				logger.Error().Msgf("Reached %d failed deployments (--max-failures), no further deployments are started", limit.max)
 			}
+		} else {
+			logger.Info().Msgf("  ✓ Deployed: %s", result.Task.ArtifactID)
+			successCount++
 		}
 
-		if failureCount == 0 {
-			log.Info().Msgf("✓ All %d artifacts deployed successfully for package %s", successCount, packageID)
-			stats.PackagesDeployed++
-		} else {
-			log.Warn().Msgf("⚠ Package %s: %d succeeded, %d failed", packageID, successCount, failureCount)
-			stats.PackagesFailed++
+		progress.record(result.Duration, result.Error != nil)
+		if showProgress {
+			logger.Info().Msgf("  Progress: %s", progress)
 		}
 	}
-
-	return nil
+	return
 }
 
 type deployResult struct {
-	Task  DeploymentTask
-	Error error
-}
-
-// mapArtifactType maps artifact types for deployment API calls
-func mapArtifactType(artifactType string) string {
-	switch strings.ToLower(artifactType) {
-	case "integrationflow", "integration flow", "iflow":
-		return "IntegrationDesigntimeArtifact"
-	case "valuemapping", "value mapping":
-		return "ValueMappingDesigntimeArtifact"
-	case "messageMapping", "message mapping":
-		return "MessageMappingDesigntimeArtifact"
-	case "scriptcollection", "script collection":
-		return "ScriptCollection"
-	default:
-		// Default to integration flow
-		return "IntegrationDesigntimeArtifact"
-	}
-}
-
-// mapArtifactTypeForSync maps artifact types for synchroniser (NewDesigntimeArtifact)
-func mapArtifactTypeForSync(artifactType string) string {
-	switch strings.ToLower(artifactType) {
-	case "integrationflow", "integration flow", "iflow":
-		return "Integration"
-	case "valuemapping", "value mapping":
-		return "ValueMapping"
-	case "messagemapping", "message mapping":
-		return "MessageMapping"
-	case "scriptcollection", "script collection":
-		return "ScriptCollection"
+	Task     DeploymentTask
+	Error    error
+	Duration time.Duration
+	Skipped  string // reason the deployment was not started
+	// Version and deployment time reported by the runtime after a successful deployment
+	Version    string
+	DeployedOn time.Time
+}
+
+// queryDeployedVersion returns the version and deployment time of a deployed artifact from the
+// runtime. A failed query only loses these details, the deployment itself succeeded.
+func queryDeployedVersion(ctx context.Context, artifactID string, serviceDetails *api.ServiceDetails) (string, time.Time) {
+	logger := runLogger(ctx)
+	rt := api.NewRuntime(api.InitHTTPExecuter(serviceDetails).WithContext(ctx))
+	deployment, err := rt.GetDeployment(artifactID)
+	if err != nil {
+		logger.Warn().Msgf("Failed to get deployed version of %s: %v", artifactID, err)
+		return "", time.Time{}
+	}
+	return deployment.Version, deployment.DeployedOn
+}
+
+// failureLimit counts failed deployments across all packages and closes reached once max
+// failures have been counted. A max of 0 disables the limit. It is safe for concurrent use.
+type failureLimit struct {
+	mu       sync.Mutex
+	max      int
+	failures int
+	reached  chan struct{}
+}
+
+func newFailureLimit(max int) *failureLimit {
+	return &failureLimit{max: max, reached: make(chan struct{})}
+}
+
+// recordFailure counts a failed deployment and reports whether it reached the limit
+func (l *failureLimit) recordFailure() bool {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	l.failures++
+	if l.max > 0 && l.failures == l.max {
+		close(l.reached)
+		return true
+	}
+	return false
+}
+
+func (l *failureLimit) isReached() bool {
+	select {
+	case <-l.reached:
+		return true
 	default:
-		// Default to integration flow
-		return "Integration"
+		return false
+	}
+}
+
+// tenantArtifactTypes returns the type of each designtime artifact in the package, keyed by artifact ID
+func tenantArtifactTypes(ip *api.IntegrationPackage, packageID string) (map[string]string, error) {
+	artifacts, err := ip.GetAllArtifacts(packageID)
+	if err != nil {
+		return nil, err
+	}
+	types := make(map[string]string, len(artifacts))
+	for _, artifact := range artifacts {
+		types[artifact.Id] = artifact.ArtifactType
+	}
+	return types, nil
+}
+
+// checkArtifactType returns an error when the artifact already exists on the tenant with a different type
+func checkArtifactType(artifactID, artifactType string, tenantTypes map[string]string) error {
+	tenantType, exists := tenantTypes[artifactID]
+	if !exists || tenantType == artifactType {
+		return nil
+	}
+	return fmt.Errorf("artifact %s is configured as type %s but exists on the tenant as %s - check the type in the deploy config", artifactID, artifactType, tenantType)
+}
+
+// artifactExistsOnTenant reports whether the designtime artifact exists on the tenant. tenantTypes, the
+// artifacts of the package, is used when available. Artifact IDs are unique across the tenant, so an
+// artifact not in the package is still looked up, and one found in another package is returned as an
+// error instead of being reported as new, as creating it would fail.
+func artifactExistsOnTenant(exe *httpclnt.HTTPExecuter, artifactID, artifactType string, tenantTypes map[string]string) (bool, error) {
+	if _, exists := tenantTypes[artifactID]; exists {
+		return true, nil
+	}
+	dt := api.NewDesigntimeArtifact(artifactType, exe)
+	if dt == nil {
+		return false, fmt.Errorf("unsupported artifact type %s for %s", artifactType, artifactID)
+	}
+	_, _, exists, err := dt.Get(artifactID, "active")
+	if err != nil {
+		return false, fmt.Errorf("failed to check if artifact %s exists: %w", artifactID, err)
+	}
+	if exists && tenantTypes != nil {
+		return false, fmt.Errorf("artifact %s already exists on the tenant in another package - artifact IDs must be unique across packages", artifactID)
 	}
+	return exists, nil
+}
+
+// existenceSkipReason returns why an artifact is skipped by --update-existing-only or --create-only,
+// or an empty string when it is updated
+func existenceSkipReason(exists, updateExistingOnly, createOnly bool) string {
+	if updateExistingOnly && !exists {
+		return skippedNotOnTenant
+	}
+	if createOnly && exists {
+		return skippedAlreadyOnTenant
+	}
+	return ""
+}
+
+// compilePrefixFromFilename compiles the --prefix-from-filename regex, which must contain a capture group
+func compilePrefixFromFilename(pattern string) (*regexp.Regexp, error) {
+	re, err := regexp.Compile(pattern)
+	if err != nil {
+		return nil, fmt.Errorf("invalid --prefix-from-filename regex %q: %w", pattern, err)
+	}
+	if re.NumSubexp() == 0 {
+		return nil, fmt.Errorf("--prefix-from-filename regex %q must contain a capture group for the prefix", pattern)
+	}
+	return re, nil
+}
+
+// prefixFromConfigFilename returns the first capture group of re matched against the base name of the config file
+func prefixFromConfigFilename(re *regexp.Regexp, fileName string) (string, error) {
+	baseName := filepath.Base(fileName)
+	match := re.FindStringSubmatch(baseName)
+	if match == nil || match[1] == "" {
+		return "", fmt.Errorf("config file name %s does not match --prefix-from-filename regex %q", baseName, re.String())
+	}
+	if err := deploy.ValidateDeploymentPrefix(match[1]); err != nil {
+		return "", fmt.Errorf("prefix %q derived from config file name %s is invalid: %w", match[1], baseName, err)
+	}
+	return match[1], nil
 }
 
 func parseFilter(filterStr string) []string {
@@ -921,6 +2166,79 @@ func parseFilter(filterStr string) []string {
 	return result
 }
 
+// expandOverrideValues expands environment variables such as ${TARGET_ENDPOINT} in the override
+// values. Values that would expand to credentials of the tenant are rejected.
+func expandOverrideValues(overrides map[string]interface{}) (map[string]interface{}, error) {
+	expanded := make(map[string]interface{}, len(overrides))
+	for key, value := range overrides {
+		val, err := config.ExpandEnv(deploy.FormatOverrideValue(value))
+		if err != nil {
+			return nil, fmt.Errorf("Sensitive content found in config override %v: %w", key, err)
+		}
+		expanded[key] = val
+	}
+	return expanded, nil
+}
+
+// resolveOverrideSecrets resolves the secret references in the override values. Resolved values
+// that contain credentials of the tenant are rejected like with expandOverrideValues.
+func resolveOverrideSecrets(secrets *deploy.SecretResolver, overrides map[string]interface{}) (map[string]interface{}, error) {
+	resolved, err := secrets.ResolveOverrides(overrides)
+	if err != nil {
+		return nil, err
+	}
+	for key, value := range overrides {
+		if !deploy.HasSecretReference(value) {
+			continue
+		}
+		if err := config.VerifyNoSensitiveContent(deploy.FormatOverrideValue(resolved[key])); err != nil {
+			return nil, fmt.Errorf("Sensitive content found in secret of config override %v: %w", key, err)
+		}
+	}
+	return resolved, nil
+}
+
+// applyValueMappingFile patches value_mapping.xml of a ValueMapping artifact with the
+// replacements of its valueMappingFile
+func applyValueMappingFile(ctx context.Context, artifact models.Artifact, artifactType, artifactDir string) error {
+	logger := runLogger(ctx)
+	if artifactType != "ValueMapping" {
+		return fmt.Errorf("valueMappingFile is only supported for ValueMapping artifacts, %s is of type %s", artifact.Id, artifactType)
+	}
+	count, err := deploy.ApplyValueMappingFile(artifact.ValueMappingFile, artifactDir)
+	if err != nil {
+		return err
+	}
+	logger.Debug().Msgf("Applied %d value mapping replacements", count)
+	return nil
+}
+
+// prefixedArtifactID returns the artifact ID as deployed on the tenant. Artifacts with skipPrefix
+// keep their ID in all environments.
+func prefixedArtifactID(prefix string, artifact models.Artifact) string {
+	if prefix == "" || artifact.SkipPrefix {
+		return artifact.Id
+	}
+	return prefix + "_" + artifact.Id
+}
+
+// logServiceDetails logs the tenant connection at debug level. Client and user IDs are masked,
+// secrets and passwords are never logged.
+func logServiceDetails(ctx context.Context, serviceDetails *api.ServiceDetails) {
+	logger := runLogger(ctx)
+	logger.Debug().Msg("CPI credentials successfully loaded:")
+	logger.Debug().Msgf("  Host: %s", serviceDetails.Host)
+	if serviceDetails.OauthHost != "" {
+		logger.Debug().Msgf("  OAuth Host: %s", serviceDetails.OauthHost)
+		logger.Debug().Msgf("  OAuth Path: %s", serviceDetails.OauthPath)
+		logger.Debug().Msgf("  OAuth Client ID: %s", str.Mask(serviceDetails.OauthClientId))
+		logger.Debug().Msg("  Auth Method: OAuth")
+	} else {
+		logger.Debug().Msgf("  User ID: %s", str.Mask(serviceDetails.Userid))
+		logger.Debug().Msg("  Auth Method: Basic Auth")
+	}
+}
+
 func shouldInclude(id string, filter []string) bool {
 	if len(filter) == 0 {
 		return true
@@ -933,42 +2251,108 @@ func shouldInclude(id string, filter []string) bool {
 	return false
 }
 
-func printSummary(stats *ProcessingStats) {
-	log.Info().Msg("")
-	log.Info().Msg("═══════════════════════════════════════════════════════════════════════")
-	log.Info().Msg("📊 DEPLOYMENT SUMMARY")
-	log.Info().Msg("═══════════════════════════════════════════════════════════════════════")
-	log.Info().Msgf("Packages Updated:   %d", stats.PackagesUpdated)
-	log.Info().Msgf("Packages Deployed:  %d", stats.PackagesDeployed)
-	log.Info().Msgf("Packages Failed:    %d", stats.PackagesFailed)
-	log.Info().Msgf("Packages Filtered:  %d", stats.PackagesFiltered)
-	log.Info().Msg("───────────────────────────────────────────────────────────────────────")
-	log.Info().Msgf("Artifacts Total:         %d", stats.ArtifactsTotal)
-	log.Info().Msgf("Artifacts Updated:       %d", len(stats.SuccessfulArtifactUpdates))
-	log.Info().Msgf("Artifacts Deployed OK:   %d", stats.ArtifactsDeployedSuccess)
-	log.Info().Msgf("Artifacts Deployed Fail: %d", stats.ArtifactsDeployedFailed)
-	log.Info().Msgf("Artifacts Filtered:      %d", stats.ArtifactsFiltered)
-	log.Info().Msg("───────────────────────────────────────────────────────────────────────")
+// extractPackagesArchive extracts packagesDir to a new temporary directory when it is a zip archive
+// and returns that directory. For a directory, it returns an empty string.
+func extractPackagesArchive(packagesDir string) (string, error) {
+	isZip, err := file.IsZipArchive(packagesDir)
+	if err != nil {
+		if errors.Is(err, os.ErrNotExist) {
+			// Missing package directories are reported per package
+			return "", nil
+		}
+		return "", fmt.Errorf("failed to read packages directory %s: %w", packagesDir, err)
+	}
+	if !isZip {
+		if !deploy.DirExists(packagesDir) {
+			return "", fmt.Errorf("packages directory %s is neither a directory nor a zip archive", packagesDir)
+		}
+		return "", nil
+	}
+
+	tempDir, err := os.MkdirTemp("", "flashpipe-packages-*")
+	if err != nil {
+		return "", fmt.Errorf("failed to create temp directory: %w", err)
+	}
+	if err := file.UnzipSource(packagesDir, tempDir); err != nil {
+		os.RemoveAll(tempDir)
+		return "", fmt.Errorf("failed to extract packages archive %s: %w", packagesDir, err)
+	}
+	return tempDir, nil
+}
+
+// slowestDeploysShown is the number of slowest artifact deployments listed in the summary
+const slowestDeploysShown = 5
+
+func printSummary(ctx context.Context, stats *ProcessingStats) {
+	logger := runLogger(ctx)
+	logger.Info().Msg("")
+	logger.Info().Msg("═══════════════════════════════════════════════════════════════════════")
+	logger.Info().Msg("📊 DEPLOYMENT SUMMARY")
+	logger.Info().Msg("═══════════════════════════════════════════════════════════════════════")
+	if stats.TenantName != "" {
+		logger.Info().Msgf("Tenant:             %s", stats.TenantName)
+	}
+	logger.Info().Msgf("Packages Updated:   %d", stats.PackagesUpdated)
+	logger.Info().Msgf("Packages Deployed:  %d", stats.PackagesDeployed)
+	logger.Info().Msgf("Packages Failed:    %d", stats.PackagesFailed)
+	logger.Info().Msgf("Packages Filtered:  %d", stats.PackagesFiltered)
+	logger.Info().Msgf("Packages Skipped:   %d", stats.PackagesSkipped)
+	logger.Info().Msg("───────────────────────────────────────────────────────────────────────")
+	logger.Info().Msgf("Artifacts Total:         %d", stats.ArtifactsTotal)
+	logger.Info().Msgf("Artifacts Updated:       %d", len(stats.SuccessfulArtifactUpdates))
+	logger.Info().Msgf("Artifacts Resumed:       %d", stats.ArtifactsResumed)
+	logger.Info().Msgf("Artifacts Deployed OK:   %d", stats.ArtifactsDeployedSuccess)
+	logger.Info().Msgf("Artifacts Deployed Fail: %d", stats.ArtifactsDeployedFailed)
+	logger.Info().Msgf("Artifacts Filtered:      %d", stats.ArtifactsFiltered)
+	if stats.ArtifactsUnchanged > 0 {
+		logger.Info().Msgf("Artifacts Unchanged:     %d", stats.ArtifactsUnchanged)
+	}
+	logger.Info().Msg("───────────────────────────────────────────────────────────────────────")
+	if !stats.StartTime.IsZero() {
+		logger.Info().Msgf("Started:   %s", stats.StartTime.Format(time.RFC3339))
+		logger.Info().Msgf("Finished:  %s", stats.EndTime.Format(time.RFC3339))
+	}
+	logger.Info().Msgf("Duration:  %s (update %s, deploy %s)", stats.TotalDuration.Round(time.Second),
+		stats.UpdatePhaseDuration.Round(time.Second), stats.DeployPhaseDuration.Round(time.Second))
+	if slowest := stats.slowestDeploys(slowestDeploysShown); len(slowest) > 0 {
+		logger.Info().Msg("Slowest Deployments:")
+		for _, timing := range slowest {
+			logger.Info().Msgf("  - %s (%s): %s", timing.ArtifactID, timing.PackageID, timing.Duration.Round(time.Second))
+		}
+	}
+	if deployed := stats.deployedVersions(); len(deployed) > 0 {
+		logger.Info().Msg("Deployed Versions:")
+		for _, d := range deployed {
+			logger.Info().Msgf("  - %s (%s): %s", d.ArtifactID, d.PackageID, formatDeployedVersion(d.Version, d.DeployedOn))
+		}
+	}
+	logger.Info().Msg("───────────────────────────────────────────────────────────────────────")
 
 	if stats.UpdateFailures > 0 {
-		log.Warn().Msgf("⚠ Update Failures: %d", stats.UpdateFailures)
-		log.Info().Msg("Failed Artifact Updates:")
+		logger.Warn().Msgf("⚠ Update Failures: %d", stats.UpdateFailures)
+		logger.Info().Msg("Failed Artifact Updates:")
 		for artifactID := range stats.FailedArtifactUpdates {
-			log.Info().Msgf("  - %s", artifactID)
+			logger.Info().Msgf("  - %s", artifactID)
 		}
 	}
 
+	if stats.DeployAborted {
+		logger.Warn().Msgf("⚠ Deployment aborted as --max-failures was reached: %d artifacts not deployed", stats.ArtifactsNotDeployed)
+	} else if stats.ArtifactsNotDeployed > 0 {
+		logger.Warn().Msgf("⚠ %d artifacts not deployed as a deployment with a lower deployOrder failed", stats.ArtifactsNotDeployed)
+	}
+
 	if stats.DeployFailures > 0 {
-		log.Warn().Msgf("⚠ Deploy Failures: %d", stats.DeployFailures)
-		log.Info().Msg("Failed Artifact Deployments:")
+		logger.Warn().Msgf("⚠ Deploy Failures: %d", stats.DeployFailures)
+		logger.Info().Msg("Failed Artifact Deployments:")
 		for artifactID := range stats.FailedArtifactDeploys {
-			log.Info().Msgf("  - %s", artifactID)
+			logger.Info().Msgf("  - %s", artifactID)
 		}
 	}
 
 	if stats.UpdateFailures == 0 && stats.DeployFailures == 0 {
-		log.Info().Msg("✓ All operations completed successfully!")
+		logger.Info().Msg("✓ All operations completed successfully!")
 	}
 
-	log.Info().Msg("═══════════════════════════════════════════════════════════════════════")
+	logger.Info().Msg("═══════════════════════════════════════════════════════════════════════")
 }
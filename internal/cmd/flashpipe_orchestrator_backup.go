@@ -0,0 +1,45 @@
+package cmd
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+
+	"github.com/engswee/flashpipe/internal/api"
+	"github.com/engswee/flashpipe/internal/file"
+	"github.com/engswee/flashpipe/internal/httpclnt"
+)
+
+// backupArtifact downloads the current designtime content of an artifact from the tenant and
+// unzips it into backupDir/<packageID>/<artifactID>, replacing an earlier backup of the artifact.
+// Artifacts that do not exist on the tenant yet are skipped. tenantTypes is used to check for
+// existence when available, otherwise the designtime artifact is looked up. It reports whether
+// a backup was written.
+func backupArtifact(exe *httpclnt.HTTPExecuter, artifactID, artifactType, packageID, backupDir, workDir string,
+	tenantTypes map[string]string) (bool, error) {
+
+	dt := api.NewDesigntimeArtifact(artifactType, exe)
+	if dt == nil {
+		return false, fmt.Errorf("unsupported artifact type %s for backup of %s", artifactType, artifactID)
+	}
+
+	exists, err := artifactExistsOnTenant(exe, artifactID, artifactType, tenantTypes)
+	if err != nil || !exists {
+		return false, err
+	}
+
+	zipFile := filepath.Join(workDir, "backup", artifactID+".zip")
+	if err := dt.Download(zipFile, artifactID); err != nil {
+		return false, fmt.Errorf("failed to download artifact %s for backup: %w", artifactID, err)
+	}
+	defer os.Remove(zipFile)
+
+	targetDir := filepath.Join(backupDir, packageID, artifactID)
+	if err := os.RemoveAll(targetDir); err != nil {
+		return false, fmt.Errorf("failed to clear backup directory %s: %w", targetDir, err)
+	}
+	if err := file.UnzipSource(zipFile, targetDir); err != nil {
+		return false, fmt.Errorf("failed to unzip backup of artifact %s: %w", artifactID, err)
+	}
+	return true, nil
+}
@@ -0,0 +1,94 @@
+package cmd
+
+import (
+	"archive/zip"
+	"bytes"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/engswee/flashpipe/internal/httpclnt"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func testArtifactZip(t *testing.T) []byte {
+	var buf bytes.Buffer
+	zw := zip.NewWriter(&buf)
+	w, err := zw.Create("META-INF/MANIFEST.MF")
+	require.NoError(t, err)
+	_, err = w.Write([]byte("Manifest-Version: 1.0\nBundle-Version: 1.0.3\n"))
+	require.NoError(t, err)
+	require.NoError(t, zw.Close())
+	return buf.Bytes()
+}
+
+func TestBackupArtifact(t *testing.T) {
+	content := testArtifactZip(t)
+	svr := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		switch r.URL.Path {
+		case "/api/v1/IntegrationDesigntimeArtifacts(Id='DEV_OrderFlow',Version='active')/$value":
+			w.Write(content)
+		case "/api/v1/IntegrationDesigntimeArtifacts(Id='DEV_OrderFlow',Version='active')":
+			w.Write([]byte(`{"d":{"Version":"1.0.3","Description":""}}`))
+		default:
+			w.WriteHeader(http.StatusNotFound)
+		}
+	}))
+	defer svr.Close()
+
+	host, port := httpclnt.GetHostPort(svr.URL)
+	exe := httpclnt.New("", "", "", "", "user", "password", host, "http", port, false)
+
+	backupDir, err := os.MkdirTemp("", "orchestrator-backup-*")
+	require.NoError(t, err)
+	defer os.RemoveAll(backupDir)
+	workDir, err := os.MkdirTemp("", "orchestrator-work-*")
+	require.NoError(t, err)
+	defer os.RemoveAll(workDir)
+
+	// Stale files of an earlier backup are removed
+	staleFile := filepath.Join(backupDir, "DEVOrders", "DEV_OrderFlow", "stale.txt")
+	require.NoError(t, os.MkdirAll(filepath.Dir(staleFile), 0755))
+	require.NoError(t, os.WriteFile(staleFile, []byte("old"), 0644))
+
+	tenantTypes := map[string]string{"DEV_OrderFlow": "Integration"}
+	backedUp, err := backupArtifact(exe, "DEV_OrderFlow", "Integration", "DEVOrders", backupDir, workDir, tenantTypes)
+	require.NoError(t, err)
+	assert.True(t, backedUp)
+
+	manifest, err := os.ReadFile(filepath.Join(backupDir, "DEVOrders", "DEV_OrderFlow", "META-INF", "MANIFEST.MF"))
+	require.NoError(t, err)
+	assert.Contains(t, string(manifest), "Bundle-Version: 1.0.3")
+	assert.NoFileExists(t, staleFile)
+
+	// Without the tenant types, existence is checked via the designtime artifact
+	backedUp, err = backupArtifact(exe, "DEV_OrderFlow", "Integration", "DEVOrders", backupDir, workDir, nil)
+	require.NoError(t, err)
+	assert.True(t, backedUp)
+}
+
+func TestBackupArtifact_NotOnTenant(t *testing.T) {
+	svr := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(http.StatusNotFound)
+	}))
+	defer svr.Close()
+
+	host, port := httpclnt.GetHostPort(svr.URL)
+	exe := httpclnt.New("", "", "", "", "user", "password", host, "http", port, false)
+
+	backupDir, err := os.MkdirTemp("", "orchestrator-backup-*")
+	require.NoError(t, err)
+	defer os.RemoveAll(backupDir)
+
+	backedUp, err := backupArtifact(exe, "DEV_NewFlow", "Integration", "DEVOrders", backupDir, backupDir, map[string]string{})
+	require.NoError(t, err)
+	assert.False(t, backedUp)
+
+	backedUp, err = backupArtifact(exe, "DEV_NewFlow", "Integration", "DEVOrders", backupDir, backupDir, nil)
+	require.NoError(t, err)
+	assert.False(t, backedUp)
+	assert.NoDirExists(t, filepath.Join(backupDir, "DEVOrders"))
+}
@@ -0,0 +1,136 @@
+package cmd
+
+import (
+	"bytes"
+	"fmt"
+	"io/fs"
+	"os"
+	"path/filepath"
+	"strings"
+
+	"github.com/engswee/flashpipe/internal/api"
+	"github.com/engswee/flashpipe/internal/file"
+	"github.com/engswee/flashpipe/internal/httpclnt"
+)
+
+// skippedUnchanged is the result message of artifacts not updated by --only-changed
+const skippedUnchanged = "unchanged on the tenant, skipped by --only-changed"
+
+// artifactUnchanged downloads the designtime content of an artifact from the tenant and reports
+// whether it is identical to the prepared content in localDir. Artifacts that do not exist on the
+// tenant yet are reported as changed.
+func artifactUnchanged(exe *httpclnt.HTTPExecuter, artifactID, artifactType, localDir, workDir string,
+	tenantTypes map[string]string) (bool, error) {
+
+	exists, err := artifactExistsOnTenant(exe, artifactID, artifactType, tenantTypes)
+	if err != nil || !exists {
+		return false, err
+	}
+
+	dt := api.NewDesigntimeArtifact(artifactType, exe)
+	compareDir := filepath.Join(workDir, "compare")
+	zipFile := filepath.Join(compareDir, artifactID+".zip")
+	if err := dt.Download(zipFile, artifactID); err != nil {
+		return false, fmt.Errorf("failed to download artifact %s for comparison: %w", artifactID, err)
+	}
+	tenantDir := filepath.Join(compareDir, artifactID)
+	defer os.RemoveAll(tenantDir)
+	defer os.Remove(zipFile)
+
+	if err := os.RemoveAll(tenantDir); err != nil {
+		return false, fmt.Errorf("failed to clear comparison directory %s: %w", tenantDir, err)
+	}
+	if err := file.UnzipSource(zipFile, tenantDir); err != nil {
+		return false, fmt.Errorf("failed to unzip artifact %s for comparison: %w", artifactID, err)
+	}
+	return artifactContentEqual(localDir, tenantDir)
+}
+
+// artifactContentEqual compares the files of two artifact directories. Line endings are normalised
+// and volatile metadata is ignored: the Origin headers of MANIFEST.MF, the comment lines of .prop
+// files (which hold the time they were written) and .DS_Store files.
+func artifactContentEqual(firstDir, secondDir string) (bool, error) {
+	firstFiles, err := artifactFiles(firstDir)
+	if err != nil {
+		return false, err
+	}
+	secondFiles, err := artifactFiles(secondDir)
+	if err != nil {
+		return false, err
+	}
+	if len(firstFiles) != len(secondFiles) {
+		return false, nil
+	}
+	for relPath := range firstFiles {
+		if !secondFiles[relPath] {
+			return false, nil
+		}
+		first, err := normalisedContent(filepath.Join(firstDir, relPath))
+		if err != nil {
+			return false, err
+		}
+		second, err := normalisedContent(filepath.Join(secondDir, relPath))
+		if err != nil {
+			return false, err
+		}
+		if !bytes.Equal(first, second) {
+			return false, nil
+		}
+	}
+	return true, nil
+}
+
+// artifactFiles returns the relative paths of the files in dir, using forward slashes
+func artifactFiles(dir string) (map[string]bool, error) {
+	files := make(map[string]bool)
+	err := filepath.WalkDir(dir, func(path string, d fs.DirEntry, err error) error {
+		if err != nil {
+			return err
+		}
+		if d.IsDir() || d.Name() == ".DS_Store" {
+			return nil
+		}
+		relPath, err := filepath.Rel(dir, path)
+		if err != nil {
+			return err
+		}
+		files[filepath.ToSlash(relPath)] = true
+		return nil
+	})
+	if err != nil {
+		return nil, fmt.Errorf("failed to list files of %s: %w", dir, err)
+	}
+	return files, nil
+}
+
+// normalisedContent returns the content of a file with LF line endings and without volatile lines
+func normalisedContent(path string) ([]byte, error) {
+	content, err := os.ReadFile(path)
+	if err != nil {
+		return nil, err
+	}
+	content = bytes.ReplaceAll(content, []byte("\r\n"), []byte("\n"))
+
+	var volatile func(line string, previousVolatile bool) bool
+	switch {
+	case filepath.Base(path) == "MANIFEST.MF":
+		// Long manifest headers continue on lines starting with a space
+		volatile = func(line string, previousVolatile bool) bool {
+			return strings.HasPrefix(line, "Origin") || (previousVolatile && strings.HasPrefix(line, " "))
+		}
+	case strings.HasSuffix(path, ".prop"):
+		volatile = func(line string, _ bool) bool { return strings.HasPrefix(line, "#") }
+	default:
+		return bytes.TrimRight(content, "\n"), nil
+	}
+
+	var kept []string
+	previousVolatile := false
+	for _, line := range strings.Split(string(content), "\n") {
+		previousVolatile = volatile(line, previousVolatile)
+		if !previousVolatile {
+			kept = append(kept, line)
+		}
+	}
+	return bytes.TrimRight([]byte(strings.Join(kept, "\n")), "\n"), nil
+}
@@ -0,0 +1,86 @@
+package cmd
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/engswee/flashpipe/internal/httpclnt"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func writeArtifactFiles(t *testing.T, dir string, files map[string]string) {
+	for relPath, content := range files {
+		path := filepath.Join(dir, relPath)
+		require.NoError(t, os.MkdirAll(filepath.Dir(path), 0755))
+		require.NoError(t, os.WriteFile(path, []byte(content), 0644))
+	}
+}
+
+func TestArtifactContentEqual(t *testing.T) {
+	local := t.TempDir()
+	tenant := t.TempDir()
+	writeArtifactFiles(t, local, map[string]string{
+		"META-INF/MANIFEST.MF":                       "Manifest-Version: 1.0\nBundle-Version: 1.0.3\n",
+		"src/main/resources/parameters.prop":         "#Mon Oct 12 10:00:00 UTC 2026\nReceiver=https\\://qa.example.com\n",
+		"src/main/resources/script/Transform.groovy": "def run() {\n  return 1\n}\n",
+		".DS_Store": "finder",
+	})
+	writeArtifactFiles(t, tenant, map[string]string{
+		"META-INF/MANIFEST.MF":                       "Manifest-Version: 1.0\r\nBundle-Version: 1.0.3\r\nOrigin-Bundle-Name: Order Flow with a long name\r\n  continued\r\n",
+		"src/main/resources/parameters.prop":         "#Tue Oct 13 08:30:00 UTC 2026\r\nReceiver=https\\://qa.example.com\r\n",
+		"src/main/resources/script/Transform.groovy": "def run() {\r\n  return 1\r\n}",
+	})
+
+	equal, err := artifactContentEqual(local, tenant)
+	require.NoError(t, err)
+	assert.True(t, equal)
+
+	// A changed value is a difference
+	writeArtifactFiles(t, tenant, map[string]string{"src/main/resources/parameters.prop": "Receiver=https\\://dev.example.com\n"})
+	equal, err = artifactContentEqual(local, tenant)
+	require.NoError(t, err)
+	assert.False(t, equal)
+
+	// A file only on one side is a difference
+	writeArtifactFiles(t, tenant, map[string]string{"src/main/resources/parameters.prop": "Receiver=https\\://qa.example.com\n"})
+	writeArtifactFiles(t, local, map[string]string{"src/main/resources/mapping/New.mmap": "<mapping/>"})
+	equal, err = artifactContentEqual(local, tenant)
+	require.NoError(t, err)
+	assert.False(t, equal)
+}
+
+func TestArtifactUnchanged(t *testing.T) {
+	content := testArtifactZip(t)
+	svr := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Path == "/api/v1/IntegrationDesigntimeArtifacts(Id='DEV_OrderFlow',Version='active')/$value" {
+			w.Write(content)
+			return
+		}
+		w.WriteHeader(http.StatusNotFound)
+	}))
+	defer svr.Close()
+
+	host, port := httpclnt.GetHostPort(svr.URL)
+	exe := httpclnt.New("", "", "", "", "user", "password", host, "http", port, false)
+	tenantTypes := map[string]string{"DEV_OrderFlow": "Integration"}
+
+	local := t.TempDir()
+	writeArtifactFiles(t, local, map[string]string{"META-INF/MANIFEST.MF": "Manifest-Version: 1.0\r\nBundle-Version: 1.0.3\r\n"})
+	unchanged, err := artifactUnchanged(exe, "DEV_OrderFlow", "Integration", local, t.TempDir(), tenantTypes)
+	require.NoError(t, err)
+	assert.True(t, unchanged)
+
+	writeArtifactFiles(t, local, map[string]string{"META-INF/MANIFEST.MF": "Manifest-Version: 1.0\nBundle-Version: 1.0.4\n"})
+	unchanged, err = artifactUnchanged(exe, "DEV_OrderFlow", "Integration", local, t.TempDir(), tenantTypes)
+	require.NoError(t, err)
+	assert.False(t, unchanged)
+
+	// Artifacts that are not on the tenant yet are always updated
+	unchanged, err = artifactUnchanged(exe, "DEV_NewFlow", "Integration", local, t.TempDir(), tenantTypes)
+	require.NoError(t, err)
+	assert.False(t, unchanged)
+}
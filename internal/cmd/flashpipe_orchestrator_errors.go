@@ -0,0 +1,72 @@
+package cmd
+
+// errDeploymentFailures is the message of the phase errors, kept identical for CLI output
+const errDeploymentFailures = "deployment completed with failures"
+
+// ConfigLoadError is returned when the deployment config cannot be detected, loaded or merged
+type ConfigLoadError struct {
+	Source string
+	Err    error
+}
+
+func (e *ConfigLoadError) Error() string {
+	return e.Err.Error()
+}
+
+func (e *ConfigLoadError) Unwrap() error {
+	return e.Err
+}
+
+// UpdatePhaseError is returned when packages or artifacts failed to update.
+// If deployments failed as well, the DeployPhaseError is available via errors.As.
+type UpdatePhaseError struct {
+	Stats             *ProcessingStats
+	FailedPackageIDs  []string
+	FailedArtifactIDs []string
+	Deploy            *DeployPhaseError
+}
+
+func (e *UpdatePhaseError) Error() string {
+	return errDeploymentFailures
+}
+
+func (e *UpdatePhaseError) Unwrap() error {
+	if e.Deploy == nil {
+		return nil
+	}
+	return e.Deploy
+}
+
+// DeployPhaseError is returned when artifacts failed to deploy
+type DeployPhaseError struct {
+	Stats             *ProcessingStats
+	FailedArtifactIDs []string
+}
+
+func (e *DeployPhaseError) Error() string {
+	return errDeploymentFailures
+}
+
+// resultError returns the phase error matching the failures recorded in stats, or nil
+func resultError(stats *ProcessingStats) error {
+	var deployErr *DeployPhaseError
+	if stats.DeployFailures > 0 {
+		deployErr = &DeployPhaseError{
+			Stats:             stats,
+			FailedArtifactIDs: sortedKeys(stats.FailedArtifactDeploys),
+		}
+	}
+
+	if len(stats.FailedPackageUpdates) > 0 || stats.UpdateFailures > 0 {
+		return &UpdatePhaseError{
+			Stats:             stats,
+			FailedPackageIDs:  sortedKeys(stats.FailedPackageUpdates),
+			FailedArtifactIDs: sortedKeys(stats.FailedArtifactUpdates),
+			Deploy:            deployErr,
+		}
+	}
+	if deployErr != nil {
+		return deployErr
+	}
+	return nil
+}
@@ -0,0 +1,69 @@
+package cmd
+
+import (
+	"errors"
+	"fmt"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestResultError_NoFailures(t *testing.T) {
+	stats := &ProcessingStats{PackagesUpdated: 1, ArtifactsDeployedSuccess: 2}
+	assert.NoError(t, resultError(stats))
+}
+
+func TestResultError_DeployPhase(t *testing.T) {
+	stats := &ProcessingStats{
+		PackagesFailed:        1,
+		DeployFailures:        2,
+		FailedArtifactDeploys: map[string]bool{"FlowB": true, "FlowA": true},
+	}
+
+	err := resultError(stats)
+	require.Error(t, err)
+	assert.Equal(t, "deployment completed with failures", err.Error())
+
+	var deployErr *DeployPhaseError
+	require.True(t, errors.As(err, &deployErr))
+	assert.Equal(t, []string{"FlowA", "FlowB"}, deployErr.FailedArtifactIDs)
+	assert.Same(t, stats, deployErr.Stats)
+
+	var updateErr *UpdatePhaseError
+	assert.False(t, errors.As(err, &updateErr))
+}
+
+func TestResultError_UpdateAndDeployPhase(t *testing.T) {
+	stats := &ProcessingStats{
+		UpdateFailures:        1,
+		DeployFailures:        1,
+		FailedPackageUpdates:  map[string]bool{"Package1": true},
+		FailedArtifactUpdates: map[string]bool{"FlowA": true},
+		FailedArtifactDeploys: map[string]bool{"FlowB": true},
+	}
+
+	// Wrapped errors are still matched, as when embedding the orchestrator
+	err := fmt.Errorf("orchestrator: %w", resultError(stats))
+
+	var updateErr *UpdatePhaseError
+	require.True(t, errors.As(err, &updateErr))
+	assert.Equal(t, []string{"Package1"}, updateErr.FailedPackageIDs)
+	assert.Equal(t, []string{"FlowA"}, updateErr.FailedArtifactIDs)
+
+	var deployErr *DeployPhaseError
+	require.True(t, errors.As(err, &deployErr))
+	assert.Equal(t, []string{"FlowB"}, deployErr.FailedArtifactIDs)
+}
+
+func TestConfigLoadError(t *testing.T) {
+	cause := errors.New("no config files found")
+	var err error = &ConfigLoadError{Source: "./configs", Err: fmt.Errorf("failed to load deployment config: %w", cause)}
+
+	assert.Equal(t, "failed to load deployment config: no config files found", err.Error())
+	assert.ErrorIs(t, err, cause)
+
+	var configErr *ConfigLoadError
+	require.True(t, errors.As(err, &configErr))
+	assert.Equal(t, "./configs", configErr.Source)
+}
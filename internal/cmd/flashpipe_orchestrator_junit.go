@@ -0,0 +1,114 @@
+package cmd
+
+import (
+	"encoding/xml"
+	"fmt"
+	"os"
+	"path/filepath"
+)
+
+type junitTestSuites struct {
+	XMLName  xml.Name         `xml:"testsuites"`
+	Name     string           `xml:"name,attr"`
+	Tests    int              `xml:"tests,attr"`
+	Failures int              `xml:"failures,attr"`
+	Skipped  int              `xml:"skipped,attr"`
+	Time     string           `xml:"time,attr"`
+	Suites   []junitTestSuite `xml:"testsuite"`
+}
+
+type junitTestSuite struct {
+	Name      string          `xml:"name,attr"`
+	Tests     int             `xml:"tests,attr"`
+	Failures  int             `xml:"failures,attr"`
+	Skipped   int             `xml:"skipped,attr"`
+	TestCases []junitTestCase `xml:"testcase"`
+}
+
+type junitTestCase struct {
+	ClassName string        `xml:"classname,attr"`
+	Name      string        `xml:"name,attr"`
+	Failure   *junitFailure `xml:"failure,omitempty"`
+	Skipped   *junitSkipped `xml:"skipped,omitempty"`
+}
+
+type junitFailure struct {
+	Message string `xml:"message,attr"`
+	Type    string `xml:"type,attr"`
+	Text    string `xml:",chardata"`
+}
+
+type junitSkipped struct {
+	Message string `xml:"message,attr,omitempty"`
+}
+
+// writeJUnitReport writes the orchestrator results as JUnit XML for CI test reporting
+func writeJUnitReport(stats *ProcessingStats, path string) error {
+	content, err := buildJUnitReport(stats)
+	if err != nil {
+		return fmt.Errorf("failed to encode JUnit report: %w", err)
+	}
+	if dir := filepath.Dir(path); dir != "" {
+		if err := os.MkdirAll(dir, 0755); err != nil {
+			return fmt.Errorf("failed to create directory for %s: %w", path, err)
+		}
+	}
+	if err := os.WriteFile(path, content, 0644); err != nil {
+		return fmt.Errorf("failed to write %s: %w", path, err)
+	}
+	return nil
+}
+
+// buildJUnitReport renders each artifact as a testcase of its package's testsuite.
+// A failed package update without artifacts is reported as a testcase named after the package.
+func buildJUnitReport(stats *ProcessingStats) ([]byte, error) {
+	report := junitTestSuites{
+		Name: "flashpipe-orchestrator",
+		Time: fmt.Sprintf("%.3f", stats.TotalDuration.Seconds()),
+	}
+
+	for _, pkg := range stats.PackageResults {
+		suite := junitTestSuite{Name: pkg.PackageID}
+
+		if pkg.UpdateStatus == ResultFailed && len(pkg.Artifacts) == 0 {
+			suite.TestCases = append(suite.TestCases, junitTestCase{
+				ClassName: pkg.PackageID,
+				Name:      pkg.PackageID,
+				Failure:   &junitFailure{Message: "package update failed", Type: "update", Text: pkg.Error},
+			})
+		}
+
+		for _, a := range pkg.Artifacts {
+			testCase := junitTestCase{ClassName: pkg.PackageID, Name: a.ArtifactID}
+			switch {
+			case a.UpdateStatus == ResultFailed:
+				testCase.Failure = &junitFailure{Message: "artifact update failed", Type: "update", Text: a.Error}
+			case a.DeployStatus == ResultFailed:
+				testCase.Failure = &junitFailure{Message: "artifact deployment failed", Type: "deploy", Text: a.Error}
+			case (a.UpdateStatus == ResultSkipped || a.DeployStatus == ResultSkipped) &&
+				a.UpdateStatus != ResultSuccess && a.DeployStatus != ResultSuccess:
+				testCase.Skipped = &junitSkipped{Message: a.Error}
+			}
+			suite.TestCases = append(suite.TestCases, testCase)
+		}
+
+		for _, testCase := range suite.TestCases {
+			suite.Tests++
+			if testCase.Failure != nil {
+				suite.Failures++
+			} else if testCase.Skipped != nil {
+				suite.Skipped++
+			}
+		}
+		report.Tests += suite.Tests
+		report.Failures += suite.Failures
+		report.Skipped += suite.Skipped
+		report.Suites = append(report.Suites, suite)
+	}
+
+	content, err := xml.MarshalIndent(report, "", "  ")
+	if err != nil {
+		return nil, err
+	}
+	return append([]byte(xml.Header), append(content, '\n')...), nil
+}
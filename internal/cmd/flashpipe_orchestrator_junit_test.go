@@ -0,0 +1,76 @@
+package cmd
+
+import (
+	"encoding/xml"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestBuildJUnitReport(t *testing.T) {
+	stats := &ProcessingStats{TotalDuration: 1500 * time.Millisecond}
+	pkg := stats.packageResult("DEVPackage")
+	pkg.UpdateStatus = ResultSuccess
+	flowA := pkg.artifact("DEV_FlowA")
+	flowA.UpdateStatus = ResultSuccess
+	flowA.DeployStatus = ResultSuccess
+	flowB := pkg.artifact("DEV_FlowB")
+	flowB.UpdateStatus = ResultSuccess
+	flowB.DeployStatus = ResultFailed
+	flowB.Error = `status <ERROR> & "details"`
+	flowC := pkg.artifact("DEV_FlowC")
+	flowC.UpdateStatus = ResultSkipped
+	flowC.Error = skippedByFilter
+	failedPkg := stats.packageResult("DEVBroken")
+	failedPkg.UpdateStatus = ResultFailed
+	failedPkg.Error = "package not found"
+
+	content, err := buildJUnitReport(stats)
+	require.NoError(t, err)
+	assert.Contains(t, string(content), `<testsuites name="flashpipe-orchestrator" tests="4" failures="2" skipped="1" time="1.500">`)
+	assert.Contains(t, string(content), "status &lt;ERROR&gt; &amp; &#34;details&#34;")
+
+	var report junitTestSuites
+	require.NoError(t, xml.Unmarshal(content, &report))
+	require.Len(t, report.Suites, 2)
+
+	suite := report.Suites[0]
+	assert.Equal(t, "DEVPackage", suite.Name)
+	assert.Equal(t, 3, suite.Tests)
+	assert.Equal(t, 1, suite.Failures)
+	assert.Equal(t, 1, suite.Skipped)
+	assert.Equal(t, "DEVPackage", suite.TestCases[0].ClassName)
+	assert.Equal(t, "DEV_FlowA", suite.TestCases[0].Name)
+	assert.Nil(t, suite.TestCases[0].Failure)
+	require.NotNil(t, suite.TestCases[1].Failure)
+	assert.Equal(t, "deploy", suite.TestCases[1].Failure.Type)
+	assert.Equal(t, `status <ERROR> & "details"`, suite.TestCases[1].Failure.Text)
+	require.NotNil(t, suite.TestCases[2].Skipped)
+	assert.Equal(t, skippedByFilter, suite.TestCases[2].Skipped.Message)
+
+	broken := report.Suites[1]
+	require.Len(t, broken.TestCases, 1)
+	require.NotNil(t, broken.TestCases[0].Failure)
+	assert.Equal(t, "update", broken.TestCases[0].Failure.Type)
+}
+
+func TestWriteJUnitReport(t *testing.T) {
+	tempDir, err := os.MkdirTemp("", "orchestrator-junit-*")
+	require.NoError(t, err)
+	defer os.RemoveAll(tempDir)
+
+	stats := &ProcessingStats{}
+	stats.packageResult("EmptyPackage").UpdateStatus = ResultSuccess
+
+	path := filepath.Join(tempDir, "reports", "junit.xml")
+	require.NoError(t, writeJUnitReport(stats, path))
+
+	content, err := os.ReadFile(path)
+	require.NoError(t, err)
+	assert.Contains(t, string(content), `<?xml version="1.0" encoding="UTF-8"?>`)
+	assert.Contains(t, string(content), `<testsuite name="EmptyPackage" tests="0" failures="0" skipped="0"></testsuite>`)
+}
@@ -0,0 +1,162 @@
+package cmd
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"strconv"
+	"strings"
+	"time"
+)
+
+const (
+	MetricsFormatPrometheus = "prometheus"
+	MetricsFormatOTLP       = "otlp"
+
+	metricsJobName     = "flashpipe_orchestrator"
+	metricsServiceName = "flashpipe"
+)
+
+// orchestratorMetric is a single gauge value derived from the processing results
+type orchestratorMetric struct {
+	Name  string
+	Help  string
+	Unit  string
+	Value float64
+}
+
+// buildOrchestratorMetrics converts the final processing stats and phase timings into gauges
+func buildOrchestratorMetrics(stats *ProcessingStats, now time.Time) []orchestratorMetric {
+	success := 1.0
+	if !runSucceeded(stats) {
+		success = 0
+	}
+	return []orchestratorMetric{
+		{Name: "flashpipe_orchestrator_packages_updated", Help: "Number of packages updated", Unit: "1", Value: float64(stats.PackagesUpdated)},
+		{Name: "flashpipe_orchestrator_packages_skipped", Help: "Number of existing packages whose metadata was not updated", Unit: "1", Value: float64(stats.PackagesSkipped)},
+		{Name: "flashpipe_orchestrator_packages_failed", Help: "Number of packages that failed to process", Unit: "1", Value: float64(stats.PackagesFailed)},
+		{Name: "flashpipe_orchestrator_artifacts_updated", Help: "Number of artifacts updated", Unit: "1", Value: float64(len(stats.SuccessfulArtifactUpdates))},
+		{Name: "flashpipe_orchestrator_artifacts_update_failed", Help: "Number of artifacts that failed to update", Unit: "1", Value: float64(stats.UpdateFailures)},
+		{Name: "flashpipe_orchestrator_artifacts_deployed", Help: "Number of artifacts deployed", Unit: "1", Value: float64(stats.ArtifactsDeployedSuccess)},
+		{Name: "flashpipe_orchestrator_artifacts_deploy_failed", Help: "Number of artifacts that failed to deploy", Unit: "1", Value: float64(stats.ArtifactsDeployedFailed)},
+		{Name: "flashpipe_orchestrator_update_phase_duration_seconds", Help: "Duration of the update phase", Unit: "s", Value: stats.UpdatePhaseDuration.Seconds()},
+		{Name: "flashpipe_orchestrator_deploy_phase_duration_seconds", Help: "Duration of the deploy phase", Unit: "s", Value: stats.DeployPhaseDuration.Seconds()},
+		{Name: "flashpipe_orchestrator_duration_seconds", Help: "Total duration of the orchestrator run", Unit: "s", Value: stats.TotalDuration.Seconds()},
+		{Name: "flashpipe_orchestrator_success", Help: "1 if the run completed without failures, otherwise 0", Unit: "1", Value: success},
+		{Name: "flashpipe_orchestrator_last_run_timestamp_seconds", Help: "Unix time the run completed", Unit: "s", Value: float64(now.Unix())},
+	}
+}
+
+// formatPrometheusMetrics renders the metrics in the Prometheus text exposition format
+func formatPrometheusMetrics(metrics []orchestratorMetric) string {
+	var sb strings.Builder
+	for _, metric := range metrics {
+		fmt.Fprintf(&sb, "# HELP %s %s\n", metric.Name, metric.Help)
+		fmt.Fprintf(&sb, "# TYPE %s gauge\n", metric.Name)
+		fmt.Fprintf(&sb, "%s %s\n", metric.Name, strconv.FormatFloat(metric.Value, 'g', -1, 64))
+	}
+	return sb.String()
+}
+
+// buildOTLPMetrics renders the metrics as an OTLP/HTTP JSON export request
+func buildOTLPMetrics(metrics []orchestratorMetric, now time.Time) ([]byte, error) {
+	type dataPoint struct {
+		TimeUnixNano string  `json:"timeUnixNano"`
+		AsDouble     float64 `json:"asDouble"`
+	}
+	type gauge struct {
+		DataPoints []dataPoint `json:"dataPoints"`
+	}
+	type metric struct {
+		Name        string `json:"name"`
+		Description string `json:"description"`
+		Unit        string `json:"unit"`
+		Gauge       gauge  `json:"gauge"`
+	}
+
+	timestamp := strconv.FormatInt(now.UnixNano(), 10)
+	otlpMetrics := make([]metric, 0, len(metrics))
+	for _, m := range metrics {
+		otlpMetrics = append(otlpMetrics, metric{
+			Name:        m.Name,
+			Description: m.Help,
+			Unit:        m.Unit,
+			Gauge:       gauge{DataPoints: []dataPoint{{TimeUnixNano: timestamp, AsDouble: m.Value}}},
+		})
+	}
+
+	request := map[string]interface{}{
+		"resourceMetrics": []interface{}{
+			map[string]interface{}{
+				"resource": map[string]interface{}{
+					"attributes": []interface{}{
+						map[string]interface{}{
+							"key":   "service.name",
+							"value": map[string]string{"stringValue": metricsServiceName},
+						},
+					},
+				},
+				"scopeMetrics": []interface{}{
+					map[string]interface{}{
+						"scope":   map[string]string{"name": metricsJobName},
+						"metrics": otlpMetrics,
+					},
+				},
+			},
+		},
+	}
+	return json.Marshal(request)
+}
+
+// pushMetrics sends the metrics to a Prometheus Pushgateway or an OTLP/HTTP collector
+func pushMetrics(endpoint, format string, metrics []orchestratorMetric, now time.Time) error {
+	var method, url, contentType string
+	var body []byte
+	endpoint = strings.TrimSuffix(endpoint, "/")
+
+	switch format {
+	case MetricsFormatPrometheus:
+		method = http.MethodPut
+		url = endpoint
+		if !strings.Contains(url, "/metrics/job/") {
+			url += "/metrics/job/" + metricsJobName
+		}
+		contentType = "text/plain; version=0.0.4"
+		body = []byte(formatPrometheusMetrics(metrics))
+	case MetricsFormatOTLP:
+		method = http.MethodPost
+		url = endpoint
+		if !strings.HasSuffix(url, "/v1/metrics") {
+			url += "/v1/metrics"
+		}
+		contentType = "application/json"
+		otlpBody, err := buildOTLPMetrics(metrics, now)
+		if err != nil {
+			return fmt.Errorf("failed to encode OTLP metrics: %w", err)
+		}
+		body = otlpBody
+	default:
+		return fmt.Errorf("invalid metrics format %q: must be '%s' or '%s'", format, MetricsFormatPrometheus, MetricsFormatOTLP)
+	}
+
+	req, err := http.NewRequest(method, url, bytes.NewReader(body))
+	if err != nil {
+		return fmt.Errorf("failed to create metrics request: %w", err)
+	}
+	req.Header.Set("Content-Type", contentType)
+
+	client := &http.Client{Timeout: 30 * time.Second}
+	resp, err := client.Do(req)
+	if err != nil {
+		return fmt.Errorf("failed to push metrics to %s: %w", url, err)
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode < 200 || resp.StatusCode >= 300 {
+		respBody, _ := io.ReadAll(resp.Body)
+		return fmt.Errorf("failed to push metrics to %s: status %d: %s", url, resp.StatusCode, strings.TrimSpace(string(respBody)))
+	}
+	return nil
+}
@@ -0,0 +1,103 @@
+package cmd
+
+import (
+	"encoding/json"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func metricsTestStats() *ProcessingStats {
+	return &ProcessingStats{
+		PackagesUpdated:           2,
+		ArtifactsDeployedSuccess:  3,
+		ArtifactsDeployedFailed:   1,
+		DeployFailures:            1,
+		SuccessfulArtifactUpdates: map[string]bool{"FlowA": true, "FlowB": true, "FlowC": true, "FlowD": true},
+		UpdatePhaseDuration:       1500 * time.Millisecond,
+		DeployPhaseDuration:       30 * time.Second,
+		TotalDuration:             32 * time.Second,
+	}
+}
+
+func TestFormatPrometheusMetrics(t *testing.T) {
+	now := time.Unix(1700000000, 0)
+	text := formatPrometheusMetrics(buildOrchestratorMetrics(metricsTestStats(), now))
+
+	assert.Contains(t, text, "# TYPE flashpipe_orchestrator_artifacts_deployed gauge\nflashpipe_orchestrator_artifacts_deployed 3\n")
+	assert.Contains(t, text, "flashpipe_orchestrator_artifacts_updated 4\n")
+	assert.Contains(t, text, "flashpipe_orchestrator_artifacts_deploy_failed 1\n")
+	assert.Contains(t, text, "flashpipe_orchestrator_update_phase_duration_seconds 1.5\n")
+	assert.Contains(t, text, "flashpipe_orchestrator_success 0\n")
+	assert.Contains(t, text, "flashpipe_orchestrator_last_run_timestamp_seconds 1.7e+09\n")
+}
+
+func TestPushMetrics_Prometheus(t *testing.T) {
+	var method, path, body string
+	svr := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		method = r.Method
+		path = r.URL.Path
+		data, _ := io.ReadAll(r.Body)
+		body = string(data)
+		w.WriteHeader(http.StatusOK)
+	}))
+	defer svr.Close()
+
+	now := time.Now()
+	err := pushMetrics(svr.URL+"/", MetricsFormatPrometheus, buildOrchestratorMetrics(metricsTestStats(), now), now)
+	require.NoError(t, err)
+
+	assert.Equal(t, http.MethodPut, method)
+	assert.Equal(t, "/metrics/job/flashpipe_orchestrator", path)
+	assert.Contains(t, body, "flashpipe_orchestrator_deploy_phase_duration_seconds 30\n")
+}
+
+func TestPushMetrics_OTLP(t *testing.T) {
+	var path string
+	var request map[string]interface{}
+	svr := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		path = r.URL.Path
+		assert.Equal(t, "application/json", r.Header.Get("Content-Type"))
+		assert.NoError(t, json.NewDecoder(r.Body).Decode(&request))
+		w.WriteHeader(http.StatusOK)
+	}))
+	defer svr.Close()
+
+	now := time.Now()
+	err := pushMetrics(svr.URL, MetricsFormatOTLP, buildOrchestratorMetrics(metricsTestStats(), now), now)
+	require.NoError(t, err)
+
+	assert.Equal(t, "/v1/metrics", path)
+	resourceMetrics := request["resourceMetrics"].([]interface{})
+	require.Len(t, resourceMetrics, 1)
+	scopeMetrics := resourceMetrics[0].(map[string]interface{})["scopeMetrics"].([]interface{})
+	metrics := scopeMetrics[0].(map[string]interface{})["metrics"].([]interface{})
+	first := metrics[0].(map[string]interface{})
+	assert.Equal(t, "flashpipe_orchestrator_packages_updated", first["name"])
+	dataPoints := first["gauge"].(map[string]interface{})["dataPoints"].([]interface{})
+	assert.Equal(t, 2.0, dataPoints[0].(map[string]interface{})["asDouble"])
+}
+
+func TestPushMetrics_Errors(t *testing.T) {
+	svr := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(http.StatusBadRequest)
+		w.Write([]byte("bad metrics"))
+	}))
+	defer svr.Close()
+
+	now := time.Now()
+	metrics := buildOrchestratorMetrics(metricsTestStats(), now)
+
+	err := pushMetrics(svr.URL, MetricsFormatPrometheus, metrics, now)
+	require.Error(t, err)
+	assert.Contains(t, err.Error(), "status 400: bad metrics")
+
+	err = pushMetrics(svr.URL, "statsd", metrics, now)
+	require.Error(t, err)
+	assert.Contains(t, err.Error(), "invalid metrics format")
+}
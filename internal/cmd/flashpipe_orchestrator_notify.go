@@ -0,0 +1,176 @@
+package cmd
+
+import (
+	"bytes"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"strings"
+	"time"
+)
+
+const (
+	NotifyOnAlways  = "always"
+	NotifyOnFailure = "failure"
+)
+
+// RunSummary is the structured summary of an orchestrator run
+type RunSummary struct {
+	Success               bool           `json:"success"`
+	Error                 string         `json:"error,omitempty"` // error that ended the run, e.g. a config that cannot be loaded
+	Tenant                string         `json:"tenant,omitempty"`
+	PackagesUpdated       int            `json:"packagesUpdated"`
+	PackagesDeployed      int            `json:"packagesDeployed"`
+	PackagesFailed        int            `json:"packagesFailed"`
+	PackagesFiltered      int            `json:"packagesFiltered"`
+	PackagesSkipped       int            `json:"packagesSkipped"`
+	ArtifactsTotal        int            `json:"artifactsTotal"`
+	ArtifactsUpdated      int            `json:"artifactsUpdated"`
+	ArtifactsUpdateFailed int            `json:"artifactsUpdateFailed"`
+	ArtifactsDeployed     int            `json:"artifactsDeployed"`
+	ArtifactsDeployFailed int            `json:"artifactsDeployFailed"`
+	ArtifactsFiltered     int            `json:"artifactsFiltered"`
+	ArtifactsUnchanged    int            `json:"artifactsUnchanged"`
+	ArtifactsResumed      int            `json:"artifactsResumed"`
+	ArtifactsNotDeployed  int            `json:"artifactsNotDeployed"`
+	DeployAborted         bool           `json:"deployAborted"`
+	FailedArtifactUpdates []string       `json:"failedArtifactUpdates,omitempty"`
+	FailedArtifactDeploys []string       `json:"failedArtifactDeploys,omitempty"`
+	StartTime             string         `json:"startTime,omitempty"`
+	EndTime               string         `json:"endTime,omitempty"`
+	DurationSeconds       float64        `json:"durationSeconds"`
+	UpdatePhaseSeconds    float64        `json:"updatePhaseSeconds"`
+	DeployPhaseSeconds    float64        `json:"deployPhaseSeconds"`
+	SlowestDeploys        []DeployTiming `json:"slowestDeploys,omitempty"`
+	DeployedVersions      []DeployedItem `json:"deployedVersions,omitempty"`
+}
+
+// DeployTiming is the deploy duration of an artifact in a RunSummary
+type DeployTiming struct {
+	PackageID       string  `json:"packageId"`
+	ArtifactID      string  `json:"artifactId"`
+	DurationSeconds float64 `json:"durationSeconds"`
+}
+
+// DeployedItem is the runtime version of a deployed artifact in the RunSummary
+type DeployedItem struct {
+	PackageID  string `json:"packageId"`
+	ArtifactID string `json:"artifactId"`
+	Version    string `json:"version"`
+	DeployedOn string `json:"deployedOn,omitempty"`
+}
+
+// runSucceeded reports whether the run completed without package, update or deploy failures
+func runSucceeded(stats *ProcessingStats) bool {
+	return stats.PackagesFailed == 0 && stats.UpdateFailures == 0 && stats.DeployFailures == 0
+}
+
+// buildRunSummary converts the final processing stats into a RunSummary
+func buildRunSummary(stats *ProcessingStats) RunSummary {
+	summary := RunSummary{
+		Success:               runSucceeded(stats),
+		Tenant:                stats.TenantName,
+		PackagesUpdated:       stats.PackagesUpdated,
+		PackagesDeployed:      stats.PackagesDeployed,
+		PackagesFailed:        stats.PackagesFailed,
+		PackagesFiltered:      stats.PackagesFiltered,
+		PackagesSkipped:       stats.PackagesSkipped,
+		ArtifactsTotal:        stats.ArtifactsTotal,
+		ArtifactsUpdated:      len(stats.SuccessfulArtifactUpdates),
+		ArtifactsUpdateFailed: stats.UpdateFailures,
+		ArtifactsDeployed:     stats.ArtifactsDeployedSuccess,
+		ArtifactsDeployFailed: stats.ArtifactsDeployedFailed,
+		ArtifactsFiltered:     stats.ArtifactsFiltered,
+		ArtifactsUnchanged:    stats.ArtifactsUnchanged,
+		ArtifactsResumed:      stats.ArtifactsResumed,
+		ArtifactsNotDeployed:  stats.ArtifactsNotDeployed,
+		DeployAborted:         stats.DeployAborted,
+		FailedArtifactUpdates: sortedKeys(stats.FailedArtifactUpdates),
+		FailedArtifactDeploys: sortedKeys(stats.FailedArtifactDeploys),
+		DurationSeconds:       stats.TotalDuration.Seconds(),
+		UpdatePhaseSeconds:    stats.UpdatePhaseDuration.Seconds(),
+		DeployPhaseSeconds:    stats.DeployPhaseDuration.Seconds(),
+	}
+	if !stats.StartTime.IsZero() {
+		summary.StartTime = stats.StartTime.Format(time.RFC3339)
+		summary.EndTime = stats.EndTime.Format(time.RFC3339)
+	}
+	for _, timing := range stats.slowestDeploys(slowestDeploysShown) {
+		summary.SlowestDeploys = append(summary.SlowestDeploys, DeployTiming{
+			PackageID:       timing.PackageID,
+			ArtifactID:      timing.ArtifactID,
+			DurationSeconds: timing.Duration.Seconds(),
+		})
+	}
+	for _, deployed := range stats.deployedVersions() {
+		item := DeployedItem{PackageID: deployed.PackageID, ArtifactID: deployed.ArtifactID, Version: deployed.Version}
+		if !deployed.DeployedOn.IsZero() {
+			item.DeployedOn = deployed.DeployedOn.UTC().Format(time.RFC3339)
+		}
+		summary.DeployedVersions = append(summary.DeployedVersions, item)
+	}
+	return summary
+}
+
+// notificationText is the message shown by chat webhooks such as Slack and Teams
+func notificationText(summary RunSummary) string {
+	status := "✅ Flashpipe deployment succeeded"
+	if !summary.Success {
+		status = "❌ Flashpipe deployment failed"
+	}
+	text := fmt.Sprintf("%s: %d artifacts updated (%d failed), %d deployed (%d failed) in %s", status,
+		summary.ArtifactsUpdated, summary.ArtifactsUpdateFailed, summary.ArtifactsDeployed, summary.ArtifactsDeployFailed,
+		time.Duration(summary.DurationSeconds*float64(time.Second)).Round(time.Second))
+	failed := append(append([]string{}, summary.FailedArtifactUpdates...), summary.FailedArtifactDeploys...)
+	if len(failed) > 0 {
+		text += "\nFailed: " + strings.Join(failed, ", ")
+	}
+	if summary.Error != "" {
+		text += "\nError: " + summary.Error
+	}
+	return text
+}
+
+// sendNotification posts the run summary as JSON to a webhook URL. The payload has a 'text' field
+// for Slack and Teams incoming webhooks and the structured summary under 'summary'.
+// A run that ended with runErr is reported as failed. With notifyOn 'failure' nothing is sent
+// for successful runs.
+func sendNotification(webhookURL, notifyOn string, stats *ProcessingStats, runErr error) (bool, error) {
+	summary := buildRunSummary(stats)
+	if runErr != nil {
+		summary.Success = false
+		summary.Error = runErr.Error()
+	}
+	if notifyOn == NotifyOnFailure && summary.Success {
+		return false, nil
+	}
+
+	body, err := json.Marshal(map[string]interface{}{
+		"text":    notificationText(summary),
+		"summary": summary,
+	})
+	if err != nil {
+		return false, fmt.Errorf("failed to encode notification: %w", err)
+	}
+
+	client := &http.Client{Timeout: 30 * time.Second}
+	resp, err := client.Post(webhookURL, "application/json", bytes.NewReader(body))
+	if err != nil {
+		// Webhook URLs contain a secret token, drop it from the error
+		var urlErr *url.Error
+		if errors.As(err, &urlErr) {
+			err = urlErr.Err
+		}
+		return false, fmt.Errorf("failed to send notification: %w", err)
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode < 200 || resp.StatusCode >= 300 {
+		respBody, _ := io.ReadAll(resp.Body)
+		return false, fmt.Errorf("failed to send notification: status %d: %s", resp.StatusCode, strings.TrimSpace(string(respBody)))
+	}
+	return true, nil
+}
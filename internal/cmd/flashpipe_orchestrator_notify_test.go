@@ -0,0 +1,114 @@
+package cmd
+
+import (
+	"encoding/json"
+	"errors"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestSendNotification(t *testing.T) {
+	var requests int
+	var contentType string
+	var payload map[string]interface{}
+	svr := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		requests++
+		contentType = r.Header.Get("Content-Type")
+		data, _ := io.ReadAll(r.Body)
+		require.NoError(t, json.Unmarshal(data, &payload))
+		w.WriteHeader(http.StatusOK)
+	}))
+	defer svr.Close()
+
+	stats := metricsTestStats()
+	stats.FailedArtifactDeploys = map[string]bool{"FlowD": true}
+
+	sent, err := sendNotification(svr.URL, NotifyOnAlways, stats, nil)
+	require.NoError(t, err)
+	assert.True(t, sent)
+	assert.Equal(t, "application/json", contentType)
+	assert.Equal(t, "❌ Flashpipe deployment failed: 4 artifacts updated (0 failed), 3 deployed (1 failed) in 32s\nFailed: FlowD", payload["text"])
+
+	summary := payload["summary"].(map[string]interface{})
+	assert.Equal(t, false, summary["success"])
+	assert.Equal(t, 4.0, summary["artifactsUpdated"])
+	assert.Equal(t, 1.0, summary["artifactsDeployFailed"])
+	assert.Equal(t, []interface{}{"FlowD"}, summary["failedArtifactDeploys"])
+	assert.NotContains(t, summary, "startTime")
+	assert.Equal(t, 30.0, summary["deployPhaseSeconds"])
+
+	// Successful runs are not reported with notify-on failure
+	sent, err = sendNotification(svr.URL, NotifyOnFailure, &ProcessingStats{PackagesUpdated: 1}, nil)
+	require.NoError(t, err)
+	assert.False(t, sent)
+	assert.Equal(t, 1, requests)
+}
+
+func TestSendNotification_RunError(t *testing.T) {
+	var payload map[string]interface{}
+	svr := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		data, _ := io.ReadAll(r.Body)
+		require.NoError(t, json.Unmarshal(data, &payload))
+		w.WriteHeader(http.StatusOK)
+	}))
+	defer svr.Close()
+
+	// A run ending with an error is a failure, even without failed artifacts
+	sent, err := sendNotification(svr.URL, NotifyOnFailure, &ProcessingStats{}, errors.New("failed to load deployment config"))
+	require.NoError(t, err)
+	assert.True(t, sent)
+	assert.Contains(t, payload["text"], "❌ Flashpipe deployment failed")
+	assert.Contains(t, payload["text"], "\nError: failed to load deployment config")
+	summary := payload["summary"].(map[string]interface{})
+	assert.Equal(t, false, summary["success"])
+	assert.Equal(t, "failed to load deployment config", summary["error"])
+}
+
+func TestSendNotification_Error(t *testing.T) {
+	svr := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(http.StatusForbidden)
+		w.Write([]byte("invalid_token\n"))
+	}))
+	defer svr.Close()
+
+	_, err := sendNotification(svr.URL+"/services/T000/B000/secret", NotifyOnAlways, &ProcessingStats{}, nil)
+	assert.EqualError(t, err, "failed to send notification: status 403: invalid_token")
+
+	svr.Close()
+	_, err = sendNotification(svr.URL+"/services/T000/B000/secret", NotifyOnAlways, &ProcessingStats{}, nil)
+	require.Error(t, err)
+	assert.NotContains(t, err.Error(), "secret")
+}
+
+func TestBuildRunSummary_Timing(t *testing.T) {
+	stats := newTestProcessingStats()
+	stats.StartTime = time.Date(2024, 5, 1, 8, 0, 0, 0, time.UTC)
+	stats.EndTime = stats.StartTime.Add(10 * time.Minute)
+	stats.recordDeployResult("Orders", deployResult{Task: DeploymentTask{ArtifactID: "OrderFlow"}, Duration: 95 * time.Second})
+
+	summary := buildRunSummary(stats)
+	assert.Equal(t, "2024-05-01T08:00:00Z", summary.StartTime)
+	assert.Equal(t, "2024-05-01T08:10:00Z", summary.EndTime)
+	assert.Equal(t, []DeployTiming{{PackageID: "Orders", ArtifactID: "OrderFlow", DurationSeconds: 95}}, summary.SlowestDeploys)
+}
+
+func TestBuildRunSummary_DeployedVersions(t *testing.T) {
+	stats := newTestProcessingStats()
+	deployedOn := time.Date(2024, 5, 1, 8, 5, 0, 0, time.UTC)
+	stats.recordDeployResult("Orders", deployResult{Task: DeploymentTask{ArtifactID: "OrderFlow"}, Version: "1.0.3", DeployedOn: deployedOn})
+	stats.recordDeployResult("Orders", deployResult{Task: DeploymentTask{ArtifactID: "Scripts"}, Version: "2.1.0"})
+	stats.recordDeployResult("Orders", deployResult{Task: DeploymentTask{ArtifactID: "FailedFlow"}, Version: "1.0.0", Error: errors.New("failed")})
+
+	summary := buildRunSummary(stats)
+	assert.Equal(t, []DeployedItem{
+		{PackageID: "Orders", ArtifactID: "OrderFlow", Version: "1.0.3", DeployedOn: "2024-05-01T08:05:00Z"},
+		{PackageID: "Orders", ArtifactID: "Scripts", Version: "2.1.0"},
+	}, summary.DeployedVersions)
+	assert.Equal(t, "1.0.3 deployed 2024-05-01T08:05:00Z", formatDeployedVersion("1.0.3", deployedOn))
+}
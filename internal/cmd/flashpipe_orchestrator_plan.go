@@ -0,0 +1,182 @@
+package cmd
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"path/filepath"
+	"text/tabwriter"
+
+	"github.com/engswee/flashpipe/internal/deploy"
+	"github.com/engswee/flashpipe/internal/models"
+)
+
+// Output formats of --plan
+const (
+	PlanFormatTable = "table"
+	PlanFormatJSON  = "json"
+)
+
+// RunPlan is the work a run would do, printed by --plan without connecting to the tenant
+type RunPlan struct {
+	Mode     OperationMode  `json:"mode"`
+	Packages []*PackagePlan `json:"packages"`
+}
+
+// PackagePlan lists the artifacts of a package in the order they are updated
+type PackagePlan struct {
+	PackageID   string            `json:"packageId"`
+	PackageName string            `json:"packageName"`
+	DeployOrder int               `json:"deployOrder"`
+	Update      bool              `json:"update"` // package metadata is created or updated
+	Artifacts   []PlannedArtifact `json:"artifacts"`
+}
+
+// PlannedArtifact is an artifact of the plan. DeployStep is its position in the deploy phase,
+// 0 when it is not deployed. Artifacts in the same package wave and deployOrder are deployed
+// in parallel, so the steps only give the order between these groups.
+type PlannedArtifact struct {
+	ArtifactID   string `json:"artifactId"`
+	ArtifactType string `json:"type"`
+	Update       bool   `json:"update"`
+	DeployStep   int    `json:"deployStep,omitempty"`
+	DeployOrder  int    `json:"deployOrder,omitempty"`
+}
+
+// planPackages adds the packages of a config to the plan, applying the same filters, prefixes
+// and directory checks as processPackages
+func planPackages(ctx context.Context, plan *RunPlan, config *models.DeployConfig, applyPrefix bool, mode OperationMode, packagesDir string,
+	packageFilter, artifactFilter []string, strictDirs bool) []DeploymentTask {
+	logger := runLogger(ctx)
+
+	var deploymentTasks []DeploymentTask
+	for _, pkg := range config.Packages {
+		if !shouldInclude(pkg.ID, packageFilter) || (!pkg.Sync && !pkg.Deploy) {
+			continue
+		}
+
+		finalPackageID, finalPackageName := packageIdentity(&pkg, config.DeploymentPrefix, applyPrefix)
+		packageDir := filepath.Join(packagesDir, pkg.PackageDir)
+		if !deploy.DirExists(packageDir) {
+			logger.Warn().Msgf("Package directory not found: %s", packageDir)
+			continue
+		}
+
+		pkgPlan := &PackagePlan{
+			PackageID:   finalPackageID,
+			PackageName: finalPackageName,
+			DeployOrder: pkg.DeployOrder,
+			Update:      mode != ModeDeployOnly,
+		}
+		plan.Packages = append(plan.Packages, pkgPlan)
+
+		// Artifacts failing the update in strict mode are not deployed
+		stats := &ProcessingStats{FailedArtifactUpdates: make(map[string]bool)}
+		for _, artifact := range deploy.SortArtifactsByOrder(pkg.Artifacts) {
+			if !shouldInclude(artifact.Id, artifactFilter) {
+				continue
+			}
+			update := pkg.Sync && mode != ModeDeployOnly && artifact.Sync
+			if update && !deploy.DirExists(filepath.Join(packageDir, artifact.ArtifactDir)) {
+				logger.Warn().Msgf("Artifact directory not found: %s", filepath.Join(packageDir, artifact.ArtifactDir))
+				update = false
+				if strictDirs {
+					stats.FailedArtifactUpdates[artifact.Id] = true
+				}
+			}
+			pkgPlan.Artifacts = append(pkgPlan.Artifacts, PlannedArtifact{
+				ArtifactID:   prefixedArtifactID(config.DeploymentPrefix, artifact),
+				ArtifactType: mapArtifactType(artifact.Type),
+				Update:       update,
+				DeployOrder:  artifact.DeployOrder,
+			})
+		}
+
+		if pkg.Deploy && mode != ModeUpdateOnly {
+			deploymentTasks = append(deploymentTasks, collectDeploymentTasks(ctx, &pkg, finalPackageID, config.DeploymentPrefix,
+				artifactFilter, stats)...)
+		}
+	}
+	return deploymentTasks
+}
+
+// setDeploySteps numbers the deployment tasks in the order of the deploy phase
+func (p *RunPlan) setDeploySteps(tasks []DeploymentTask, scriptsFirst bool) {
+	var ordered []DeploymentTask
+	if scriptsFirst {
+		scripts, others := splitScriptCollections(tasks)
+		ordered = append(orderDeploymentTasks(scripts), orderDeploymentTasks(others)...)
+	} else {
+		ordered = orderDeploymentTasks(tasks)
+	}
+
+	for step, task := range ordered {
+		for _, pkgPlan := range p.Packages {
+			if pkgPlan.PackageID != task.PackageID {
+				continue
+			}
+			for i := range pkgPlan.Artifacts {
+				if pkgPlan.Artifacts[i].ArtifactID == task.ArtifactID {
+					pkgPlan.Artifacts[i].DeployStep = step + 1
+				}
+			}
+		}
+	}
+}
+
+// orderDeploymentTasks orders the tasks like deployInPackageOrder and deployPackageArtifacts:
+// by the deployOrder of their package, then by package and by the deployOrder of the artifact
+func orderDeploymentTasks(tasks []DeploymentTask) []DeploymentTask {
+	var ordered []DeploymentTask
+	for _, wave := range groupByDeployOrder(tasks, func(t DeploymentTask) int { return t.PackageDeployOrder }) {
+		packageIDs, tasksByPackage := groupTasksByPackage(wave)
+		for _, packageID := range packageIDs {
+			for _, group := range groupByDeployOrder(tasksByPackage[packageID], func(t DeploymentTask) int { return t.DeployOrder }) {
+				ordered = append(ordered, group...)
+			}
+		}
+	}
+	return ordered
+}
+
+// writePlan writes the plan as table or JSON
+func writePlan(w io.Writer, plan *RunPlan, format string) error {
+	if format == PlanFormatJSON {
+		data, err := json.MarshalIndent(plan, "", "  ")
+		if err != nil {
+			return fmt.Errorf("failed to encode plan: %w", err)
+		}
+		_, err = fmt.Fprintln(w, string(data))
+		return err
+	}
+
+	tw := tabwriter.NewWriter(w, 0, 0, 2, ' ', 0)
+	fmt.Fprintf(tw, "PACKAGE\tARTIFACT\tTYPE\tUPDATE\tDEPLOY\n")
+	updates, deploys := 0, 0
+	for _, pkgPlan := range plan.Packages {
+		packageUpdate := "-"
+		if pkgPlan.Update {
+			packageUpdate = "yes"
+		}
+		fmt.Fprintf(tw, "%s\t\t\t%s\t\n", pkgPlan.PackageID, packageUpdate)
+		for _, artifact := range pkgPlan.Artifacts {
+			update, deployStep := "-", "-"
+			if artifact.Update {
+				update = "yes"
+				updates++
+			}
+			if artifact.DeployStep > 0 {
+				deployStep = fmt.Sprintf("#%d", artifact.DeployStep)
+				deploys++
+			}
+			fmt.Fprintf(tw, "\t%s\t%s\t%s\t%s\n", artifact.ArtifactID, artifact.ArtifactType, update, deployStep)
+		}
+	}
+	if err := tw.Flush(); err != nil {
+		return err
+	}
+	_, err := fmt.Fprintf(w, "\nPlan (%s): %d package(s), %d artifact update(s), %d deployment(s)\n",
+		plan.Mode, len(plan.Packages), updates, deploys)
+	return err
+}
@@ -0,0 +1,85 @@
+package cmd
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/engswee/flashpipe/internal/models"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestPlanPackages(t *testing.T) {
+	packagesDir := t.TempDir()
+	for _, dir := range []string{"Orders/OrderFlow", "Orders/Scripts", "Orders/Mapping", "Invoices/InvoiceFlow"} {
+		require.NoError(t, os.MkdirAll(filepath.Join(packagesDir, dir), 0755))
+	}
+
+	config := &models.DeployConfig{
+		DeploymentPrefix: "DEV",
+		Packages: []models.Package{
+			{ID: "Invoices", PackageDir: "Invoices", Sync: true, Deploy: true, DeployOrder: 1, Artifacts: []models.Artifact{
+				{Id: "InvoiceFlow", ArtifactDir: "InvoiceFlow", Sync: true, Deploy: true},
+			}},
+			{ID: "Orders", PackageDir: "Orders", Sync: true, Deploy: true, Artifacts: []models.Artifact{
+				{Id: "OrderFlow", ArtifactDir: "OrderFlow", Sync: true, Deploy: true, DeployOrder: 1},
+				{Id: "Scripts", ArtifactDir: "Scripts", Type: "ScriptCollection", Sync: true, Deploy: true, DeployOrder: 1},
+				{Id: "Mapping", ArtifactDir: "Mapping", Type: "MessageMapping", Sync: true, Deploy: false},
+				{Id: "Missing", ArtifactDir: "Missing", Sync: true, Deploy: true},
+				{Id: "Filtered", ArtifactDir: "Filtered", Sync: true, Deploy: true},
+			}},
+			{ID: "Other", PackageDir: "Other", Sync: true, Deploy: true},
+		},
+	}
+	artifactFilter := []string{"InvoiceFlow", "OrderFlow", "Scripts", "Mapping", "Missing"}
+
+	plan := &RunPlan{Mode: ModeUpdateAndDeploy}
+	tasks := planPackages(context.Background(), plan, config, true, ModeUpdateAndDeploy, packagesDir, []string{"Orders", "Invoices"}, artifactFilter, true)
+	plan.setDeploySteps(tasks, false)
+
+	require.Len(t, plan.Packages, 2)
+	invoices, orders := plan.Packages[0], plan.Packages[1]
+	assert.Equal(t, "DEVInvoices", invoices.PackageID)
+	assert.Equal(t, "DEV - Orders", orders.PackageName)
+	assert.Equal(t, []PlannedArtifact{
+		{ArtifactID: "DEV_OrderFlow", ArtifactType: "IntegrationDesigntimeArtifact", Update: true, DeployStep: 1, DeployOrder: 1},
+		{ArtifactID: "DEV_Scripts", ArtifactType: "ScriptCollection", Update: true, DeployStep: 2, DeployOrder: 1},
+		{ArtifactID: "DEV_Mapping", ArtifactType: "MessageMappingDesigntimeArtifact", Update: true},
+		// Not deployed as its update fails with strict directory checks
+		{ArtifactID: "DEV_Missing", ArtifactType: "IntegrationDesigntimeArtifact"},
+	}, orders.Artifacts)
+	// The package with the higher deployOrder is deployed last
+	assert.Equal(t, 3, invoices.Artifacts[0].DeployStep)
+
+	// Script collections are deployed first
+	plan.setDeploySteps(tasks, true)
+	assert.Equal(t, 1, orders.Artifacts[1].DeployStep)
+	assert.Equal(t, 2, orders.Artifacts[0].DeployStep)
+
+	var buf bytes.Buffer
+	require.NoError(t, writePlan(&buf, plan, PlanFormatTable))
+	assert.Contains(t, buf.String(), "DEV_Scripts")
+	assert.Contains(t, buf.String(), "Plan (update-and-deploy): 2 package(s), 4 artifact update(s), 3 deployment(s)")
+
+	buf.Reset()
+	require.NoError(t, writePlan(&buf, plan, PlanFormatJSON))
+	var decoded RunPlan
+	require.NoError(t, json.Unmarshal(buf.Bytes(), &decoded))
+	assert.Equal(t, plan.Packages[1].Artifacts, decoded.Packages[1].Artifacts)
+}
+
+func TestOrchestratorRun_PlanWithoutCredentials(t *testing.T) {
+	tempDir := t.TempDir()
+	configPath := filepath.Join(tempDir, "deploy.yml")
+	require.NoError(t, os.WriteFile(configPath, []byte("packages:\n  - integrationSuiteId: Package1\n    packageDir: Package1\n"), 0644))
+
+	_, err := NewOrchestrator(Options{DeployConfig: configPath, PackagesDir: tempDir, Plan: true}).Run(context.Background())
+	require.NoError(t, err)
+
+	_, err = NewOrchestrator(Options{DeployConfig: configPath, Plan: true, PlanFormat: "yaml"}).Run(context.Background())
+	assert.ErrorContains(t, err, `invalid plan format "yaml"`)
+}
@@ -0,0 +1,78 @@
+package cmd
+
+import (
+	"fmt"
+	"sync"
+	"time"
+)
+
+// progressWindow is the number of most recent deployments used for the ETA moving average
+const progressWindow = 10
+
+// deployProgress tracks completed deployments across all packages of the deploy phase.
+// It is safe for concurrent use, as packages are deployed in parallel.
+type deployProgress struct {
+	mu          sync.Mutex
+	total       int
+	completed   int
+	failed      int
+	concurrency int
+	durations   []time.Duration
+}
+
+func newDeployProgress(total, concurrency int) *deployProgress {
+	if concurrency < 1 {
+		concurrency = 1
+	}
+	return &deployProgress{total: total, concurrency: concurrency}
+}
+
+// record adds a completed deployment and its duration
+func (p *deployProgress) record(duration time.Duration, failed bool) {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	p.completed++
+	if failed {
+		p.failed++
+	}
+	p.durations = append(p.durations, duration)
+	if len(p.durations) > progressWindow {
+		p.durations = p.durations[len(p.durations)-progressWindow:]
+	}
+}
+
+// eta estimates the remaining time from the moving average of recent deployment
+// durations, assuming the remaining deployments run with the configured concurrency
+func (p *deployProgress) eta() time.Duration {
+	remaining := p.total - p.completed
+	if remaining <= 0 || len(p.durations) == 0 {
+		return 0
+	}
+	var sum time.Duration
+	for _, d := range p.durations {
+		sum += d
+	}
+	average := sum / time.Duration(len(p.durations))
+	parallel := min(p.concurrency, remaining)
+	batches := (remaining + parallel - 1) / parallel
+	return average * time.Duration(batches)
+}
+
+// String renders the progress, e.g. "deployed 12/87, 3 failed, ~2m remaining"
+func (p *deployProgress) String() string {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	s := fmt.Sprintf("deployed %d/%d, %d failed", p.completed, p.total, p.failed)
+	if p.completed < p.total && len(p.durations) > 0 {
+		s += fmt.Sprintf(", ~%s remaining", formatETA(p.eta()))
+	}
+	return s
+}
+
+// formatETA renders a duration as whole seconds below one minute and as rounded minutes above
+func formatETA(d time.Duration) string {
+	if d < time.Minute {
+		return fmt.Sprintf("%ds", int(d.Round(time.Second).Seconds()))
+	}
+	return fmt.Sprintf("%dm", int(d.Round(time.Minute).Minutes()))
+}
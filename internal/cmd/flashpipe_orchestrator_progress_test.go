@@ -0,0 +1,44 @@
+package cmd
+
+import (
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestDeployProgress(t *testing.T) {
+	progress := newDeployProgress(10, 2)
+	assert.Equal(t, "deployed 0/10, 0 failed", progress.String())
+
+	progress.record(30*time.Second, false)
+	progress.record(90*time.Second, true)
+
+	// 8 remaining in batches of 2 at an average of 60s
+	assert.Equal(t, 4*time.Minute, progress.eta())
+	assert.Equal(t, "deployed 2/10, 1 failed, ~4m remaining", progress.String())
+
+	for i := 0; i < 8; i++ {
+		progress.record(time.Second, false)
+	}
+	assert.Equal(t, time.Duration(0), progress.eta())
+	assert.Equal(t, "deployed 10/10, 1 failed", progress.String())
+}
+
+func TestDeployProgress_MovingAverage(t *testing.T) {
+	progress := newDeployProgress(100, 1)
+	progress.record(time.Hour, false)
+	for i := 0; i < progressWindow; i++ {
+		progress.record(2*time.Second, false)
+	}
+
+	// The slow first deployment has dropped out of the window
+	assert.Equal(t, 89*2*time.Second, progress.eta())
+	assert.Equal(t, "deployed 11/100, 0 failed, ~3m remaining", progress.String())
+}
+
+func TestFormatETA(t *testing.T) {
+	assert.Equal(t, "45s", formatETA(45*time.Second))
+	assert.Equal(t, "1m", formatETA(89*time.Second))
+	assert.Equal(t, "2m", formatETA(90*time.Second))
+}
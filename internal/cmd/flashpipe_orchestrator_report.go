@@ -0,0 +1,102 @@
+package cmd
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+)
+
+// writeMarkdownSummary writes the orchestrator results as Markdown suitable for PR comments
+func writeMarkdownSummary(stats *ProcessingStats, path string) error {
+	if dir := filepath.Dir(path); dir != "" {
+		if err := os.MkdirAll(dir, 0755); err != nil {
+			return fmt.Errorf("failed to create directory for %s: %w", path, err)
+		}
+	}
+	if err := os.WriteFile(path, []byte(buildMarkdownSummary(stats)), 0644); err != nil {
+		return fmt.Errorf("failed to write %s: %w", path, err)
+	}
+	return nil
+}
+
+// buildMarkdownSummary renders the package and artifact results as a Markdown document
+func buildMarkdownSummary(stats *ProcessingStats) string {
+	var sb strings.Builder
+
+	sb.WriteString("## 📊 Flashpipe Deployment Summary\n\n")
+	if stats.PackagesFailed > 0 || stats.UpdateFailures > 0 || stats.DeployFailures > 0 {
+		sb.WriteString("❌ **Deployment completed with failures**\n\n")
+	} else {
+		sb.WriteString("✅ **All operations completed successfully**\n\n")
+	}
+	if stats.TenantName != "" {
+		fmt.Fprintf(&sb, "Tenant: `%s`\n\n", stats.TenantName)
+	}
+
+	sb.WriteString("| Packages Updated | Packages Deployed | Packages Failed | Artifacts Updated | Artifacts Deployed | Artifacts Failed |\n")
+	sb.WriteString("|---:|---:|---:|---:|---:|---:|\n")
+	fmt.Fprintf(&sb, "| %d | %d | %d | %d | %d | %d |\n\n",
+		stats.PackagesUpdated, stats.PackagesDeployed, stats.PackagesFailed,
+		len(stats.SuccessfulArtifactUpdates), stats.ArtifactsDeployedSuccess,
+		len(stats.FailedArtifactUpdates)+stats.ArtifactsDeployedFailed)
+
+	var failed []string
+	for _, pkg := range stats.PackageResults {
+		fmt.Fprintf(&sb, "### 📦 %s %s\n\n", pkg.PackageID, statusEmoji(pkg.UpdateStatus))
+		if pkg.UpdateStatus == ResultFailed {
+			failed = append(failed, fmt.Sprintf("- `%s` (package update): %s", pkg.PackageID, markdownText(pkg.Error)))
+		}
+
+		if len(pkg.Artifacts) == 0 {
+			sb.WriteString("_No artifacts processed_\n\n")
+			continue
+		}
+
+		sb.WriteString("| Artifact | Update | Deploy |\n")
+		sb.WriteString("|---|:---:|:---:|\n")
+		for _, a := range pkg.Artifacts {
+			deployStatus := statusEmoji(a.DeployStatus)
+			if a.DeployStatus == ResultSuccess && a.DeployedVersion != "" {
+				deployStatus += fmt.Sprintf(" `%s`", a.DeployedVersion)
+			}
+			fmt.Fprintf(&sb, "| `%s` | %s | %s |\n", a.ArtifactID, statusEmoji(a.UpdateStatus), deployStatus)
+			if a.UpdateStatus == ResultFailed {
+				failed = append(failed, fmt.Sprintf("- `%s` (update): %s", a.ArtifactID, markdownText(a.Error)))
+			} else if a.DeployStatus == ResultFailed {
+				failed = append(failed, fmt.Sprintf("- `%s` (deploy): %s", a.ArtifactID, markdownText(a.Error)))
+			}
+		}
+		sb.WriteString("\n")
+	}
+
+	if len(failed) > 0 {
+		sb.WriteString("### ❌ Failures\n\n")
+		sb.WriteString(strings.Join(failed, "\n"))
+		sb.WriteString("\n\n")
+	}
+
+	sb.WriteString("<sub>✅ success · ❌ failed · ⏭️ skipped · ➖ not run</sub>\n")
+
+	return sb.String()
+}
+
+func statusEmoji(status ResultStatus) string {
+	switch status {
+	case ResultSuccess:
+		return "✅"
+	case ResultFailed:
+		return "❌"
+	case ResultSkipped:
+		return "⏭️"
+	default:
+		return "➖"
+	}
+}
+
+// markdownText flattens text onto a single line so it does not break list or table layout
+func markdownText(text string) string {
+	text = strings.ReplaceAll(text, "\r\n", " ")
+	text = strings.ReplaceAll(text, "\n", " ")
+	return strings.ReplaceAll(text, "|", "\\|")
+}
@@ -0,0 +1,61 @@
+package cmd
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestBuildMarkdownSummary(t *testing.T) {
+	stats := &ProcessingStats{
+		PackagesUpdated:           1,
+		PackagesFailed:            1,
+		DeployFailures:            1,
+		ArtifactsDeployedSuccess:  1,
+		ArtifactsDeployedFailed:   1,
+		SuccessfulArtifactUpdates: map[string]bool{"DEV_FlowA": true, "DEV_FlowB": true},
+		FailedArtifactUpdates:     map[string]bool{},
+	}
+	pkg := stats.packageResult("DEVPackage")
+	pkg.UpdateStatus = ResultSuccess
+	flowA := pkg.artifact("DEV_FlowA")
+	flowA.UpdateStatus = ResultSuccess
+	flowA.DeployStatus = ResultSuccess
+	flowA.DeployedVersion = "1.0.3"
+	flowB := pkg.artifact("DEV_FlowB")
+	flowB.UpdateStatus = ResultSuccess
+	flowB.DeployStatus = ResultFailed
+	flowB.Error = "deployment failed\nstatus | ERROR"
+
+	// Looking up the same artifact again returns the existing entry
+	assert.Same(t, flowA, stats.packageResult("DEVPackage").artifact("DEV_FlowA"))
+
+	md := buildMarkdownSummary(stats)
+
+	assert.Contains(t, md, "❌ **Deployment completed with failures**")
+	assert.Contains(t, md, "| 1 | 0 | 1 | 2 | 1 | 1 |")
+	assert.Contains(t, md, "### 📦 DEVPackage ✅")
+	assert.Contains(t, md, "| `DEV_FlowA` | ✅ | ✅ `1.0.3` |")
+	assert.Contains(t, md, "| `DEV_FlowB` | ✅ | ❌ |")
+	assert.Contains(t, md, "- `DEV_FlowB` (deploy): deployment failed status \\| ERROR")
+}
+
+func TestWriteMarkdownSummary(t *testing.T) {
+	tempDir, err := os.MkdirTemp("", "orchestrator-summary-*")
+	require.NoError(t, err)
+	defer os.RemoveAll(tempDir)
+
+	stats := &ProcessingStats{}
+	stats.packageResult("EmptyPackage").UpdateStatus = ResultSuccess
+
+	path := filepath.Join(tempDir, "reports", "summary.md")
+	require.NoError(t, writeMarkdownSummary(stats, path))
+
+	content, err := os.ReadFile(path)
+	require.NoError(t, err)
+	assert.Contains(t, string(content), "✅ **All operations completed successfully**")
+	assert.Contains(t, string(content), "_No artifacts processed_")
+}
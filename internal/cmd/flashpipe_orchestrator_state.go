@@ -0,0 +1,112 @@
+package cmd
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"os"
+)
+
+// skippedResumed is the result message of artifacts completed in a previous run recorded in the state file
+const skippedResumed = "completed in a previous run, skipped by --state-file"
+
+// runState is the content of the --state-file: the artifacts that were updated and deployed
+// successfully by previous runs, by their final (prefixed) artifact ID
+type runState struct {
+	Updated  []string `json:"updated"`
+	Deployed []string `json:"deployed"`
+}
+
+// completedWork holds the artifacts of a runState for lookups
+type completedWork struct {
+	updated  map[string]bool
+	deployed map[string]bool
+}
+
+// loadRunState reads the state file of a previous run. A missing file is an empty state.
+func loadRunState(path string) (*completedWork, error) {
+	completed := &completedWork{updated: make(map[string]bool), deployed: make(map[string]bool)}
+	data, err := os.ReadFile(path)
+	if os.IsNotExist(err) {
+		return completed, nil
+	}
+	if err != nil {
+		return nil, fmt.Errorf("failed to read state file %s: %w", path, err)
+	}
+	var state runState
+	if err := json.Unmarshal(data, &state); err != nil {
+		return nil, fmt.Errorf("failed to parse state file %s: %w", path, err)
+	}
+	for _, id := range state.Updated {
+		completed.updated[id] = true
+	}
+	for _, id := range state.Deployed {
+		completed.deployed[id] = true
+	}
+	return completed, nil
+}
+
+// saveRunState writes the artifacts completed by previous runs and this run to the state file.
+// An artifact updated in this run is no longer recorded as deployed, as its new version still
+// has to be deployed.
+func saveRunState(path string, stats *ProcessingStats) error {
+	stats.mu.Lock()
+	defer stats.mu.Unlock()
+
+	updated := make(map[string]bool)
+	deployed := make(map[string]bool)
+	if stats.completed != nil {
+		for id := range stats.completed.updated {
+			updated[id] = true
+		}
+		for id := range stats.completed.deployed {
+			deployed[id] = true
+		}
+	}
+	for id := range stats.SuccessfulArtifactUpdates {
+		updated[id] = true
+		delete(deployed, id)
+	}
+	for id := range stats.SuccessfulArtifactDeploys {
+		deployed[id] = true
+	}
+
+	data, err := json.MarshalIndent(runState{Updated: sortedKeys(updated), Deployed: sortedKeys(deployed)}, "", "  ")
+	if err != nil {
+		return fmt.Errorf("failed to encode state file: %w", err)
+	}
+	if err := os.WriteFile(path, data, 0644); err != nil {
+		return fmt.Errorf("failed to write state file %s: %w", path, err)
+	}
+	return nil
+}
+
+// clearRunState removes the state file after a successful run
+func clearRunState(path string) error {
+	if err := os.Remove(path); err != nil && !os.IsNotExist(err) {
+		return fmt.Errorf("failed to remove state file %s: %w", path, err)
+	}
+	return nil
+}
+
+// skipCompletedDeploys removes the deployment tasks of artifacts deployed in a previous run and
+// records them as skipped
+func skipCompletedDeploys(ctx context.Context, tasks []DeploymentTask, stats *ProcessingStats) []DeploymentTask {
+	logger := runLogger(ctx)
+	if stats.completed == nil || len(stats.completed.deployed) == 0 {
+		return tasks
+	}
+	var remaining []DeploymentTask
+	for _, task := range tasks {
+		if !stats.completed.deployed[task.ArtifactID] {
+			remaining = append(remaining, task)
+			continue
+		}
+		logger.Info().Msgf("Skipping deployment of %s: %s", task.ArtifactID, skippedResumed)
+		stats.ArtifactsResumed++
+		result := stats.packageResult(task.PackageID).artifact(task.ArtifactID)
+		result.DeployStatus = ResultSkipped
+		result.Error = skippedResumed
+	}
+	return remaining
+}
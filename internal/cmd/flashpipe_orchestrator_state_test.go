@@ -0,0 +1,75 @@
+package cmd
+
+import (
+	"context"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestRunState_RoundTrip(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "state.json")
+
+	// A missing state file is an empty state
+	completed, err := loadRunState(path)
+	require.NoError(t, err)
+	assert.Empty(t, completed.updated)
+	assert.Empty(t, completed.deployed)
+
+	stats := newTestProcessingStats()
+	stats.completed = completed
+	stats.SuccessfulArtifactUpdates["DEVOrderFlow"] = true
+	stats.SuccessfulArtifactUpdates["DEVInvoiceFlow"] = true
+	stats.SuccessfulArtifactDeploys["DEVOrderFlow"] = true
+	require.NoError(t, saveRunState(path, stats))
+
+	completed, err = loadRunState(path)
+	require.NoError(t, err)
+	assert.Equal(t, map[string]bool{"DEVOrderFlow": true, "DEVInvoiceFlow": true}, completed.updated)
+	assert.Equal(t, map[string]bool{"DEVOrderFlow": true}, completed.deployed)
+
+	// The work of previous runs is kept, an artifact updated again has to be deployed again
+	stats = newTestProcessingStats()
+	stats.completed = completed
+	stats.SuccessfulArtifactUpdates["DEVOrderFlow"] = true
+	stats.SuccessfulArtifactDeploys["DEVInvoiceFlow"] = true
+	require.NoError(t, saveRunState(path, stats))
+
+	completed, err = loadRunState(path)
+	require.NoError(t, err)
+	assert.Equal(t, map[string]bool{"DEVOrderFlow": true, "DEVInvoiceFlow": true}, completed.updated)
+	assert.Equal(t, map[string]bool{"DEVInvoiceFlow": true}, completed.deployed)
+
+	require.NoError(t, clearRunState(path))
+	assert.NoFileExists(t, path)
+	require.NoError(t, clearRunState(path))
+}
+
+func TestLoadRunState_Invalid(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "state.json")
+	require.NoError(t, os.WriteFile(path, []byte("not json"), 0644))
+
+	_, err := loadRunState(path)
+	assert.ErrorContains(t, err, "failed to parse state file")
+}
+
+func TestSkipCompletedDeploys(t *testing.T) {
+	tasks := []DeploymentTask{
+		{ArtifactID: "DEVOrderFlow", ArtifactType: "IntegrationFlow", PackageID: "DEVOrders"},
+		{ArtifactID: "DEVInvoiceFlow", ArtifactType: "IntegrationFlow", PackageID: "DEVOrders"},
+	}
+
+	stats := newTestProcessingStats()
+	assert.Equal(t, tasks, skipCompletedDeploys(context.Background(), tasks, stats))
+
+	stats.completed = &completedWork{deployed: map[string]bool{"DEVOrderFlow": true}}
+	remaining := skipCompletedDeploys(context.Background(), tasks, stats)
+	assert.Equal(t, []DeploymentTask{tasks[1]}, remaining)
+	assert.Equal(t, 1, stats.ArtifactsResumed)
+	result := stats.packageResult("DEVOrders").artifact("DEVOrderFlow")
+	assert.Equal(t, ResultSkipped, result.DeployStatus)
+	assert.Equal(t, skippedResumed, result.Error)
+}
@@ -0,0 +1,1028 @@
+package cmd
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/engswee/flashpipe/internal/api"
+	"github.com/engswee/flashpipe/internal/deploy"
+	"github.com/engswee/flashpipe/internal/httpclnt"
+	"github.com/engswee/flashpipe/internal/models"
+	"github.com/rs/zerolog"
+	"github.com/rs/zerolog/log"
+	"github.com/spf13/cobra"
+	"github.com/spf13/viper"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func newTestProcessingStats() *ProcessingStats {
+	return &ProcessingStats{
+		SuccessfulArtifactUpdates: make(map[string]bool),
+		FailedArtifactUpdates:     make(map[string]bool),
+		SuccessfulPackageUpdates:  make(map[string]bool),
+		FailedPackageUpdates:      make(map[string]bool),
+		SuccessfulArtifactDeploys: make(map[string]bool),
+		FailedArtifactDeploys:     make(map[string]bool),
+		SkippedArtifactUpdates:    make(map[string]bool),
+	}
+}
+
+func TestProcessPackages_MissingPackageDir(t *testing.T) {
+	packagesDir, err := os.MkdirTemp("", "orchestrator-packages-*")
+	require.NoError(t, err)
+	defer os.RemoveAll(packagesDir)
+
+	config := &models.DeployConfig{
+		DeploymentPrefix: "DEV",
+		Packages: []models.Package{
+			{ID: "Missing", PackageDir: "Typo", Sync: true, Deploy: true},
+		},
+	}
+
+	// Default mode skips the package without recording a failure
+	stats := newTestProcessingStats()
+	tasks, err := processPackages(context.Background(), config, true, ModeUpdateAndDeploy, packagesDir, packagesDir, "", nil, nil, false, true, false, false, false, false, false, nil, stats, nil)
+	require.NoError(t, err)
+	assert.Empty(t, tasks)
+	assert.Equal(t, 0, stats.PackagesFailed)
+	assert.Empty(t, stats.PackageResults)
+
+	// Strict mode reports the package as failed
+	stats = newTestProcessingStats()
+	tasks, err = processPackages(context.Background(), config, true, ModeUpdateAndDeploy, packagesDir, packagesDir, "", nil, nil, true, true, false, false, false, false, false, nil, stats, nil)
+	require.NoError(t, err)
+	assert.Empty(t, tasks)
+	assert.Equal(t, 1, stats.PackagesFailed)
+	assert.True(t, stats.FailedPackageUpdates["Missing"])
+	require.Len(t, stats.PackageResults, 1)
+	assert.Equal(t, "DEVMissing", stats.PackageResults[0].PackageID)
+	assert.Equal(t, ResultFailed, stats.PackageResults[0].UpdateStatus)
+}
+
+func TestExistenceSkipReason(t *testing.T) {
+	assert.Equal(t, "", existenceSkipReason(true, false, false))
+	assert.Equal(t, "", existenceSkipReason(false, false, false))
+	assert.Equal(t, "", existenceSkipReason(true, true, false))
+	assert.Equal(t, skippedNotOnTenant, existenceSkipReason(false, true, false))
+	assert.Equal(t, "", existenceSkipReason(false, false, true))
+	assert.Equal(t, skippedAlreadyOnTenant, existenceSkipReason(true, false, true))
+}
+
+func TestArtifactExistsOnTenant(t *testing.T) {
+	svr := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Path == "/api/v1/IntegrationDesigntimeArtifacts(Id='DEV_OrderFlow',Version='active')" {
+			w.Write([]byte(`{"d":{"Version":"1.0.3","Description":""}}`))
+			return
+		}
+		w.WriteHeader(http.StatusNotFound)
+	}))
+	defer svr.Close()
+
+	host, port := httpclnt.GetHostPort(svr.URL)
+	exe := httpclnt.New("", "", "", "", "user", "password", host, "http", port, false)
+
+	exists, err := artifactExistsOnTenant(exe, "DEV_OrderFlow", "Integration", map[string]string{"DEV_OrderFlow": "Integration"})
+	require.NoError(t, err)
+	assert.True(t, exists)
+
+	// An artifact outside of the package is not reported as new
+	_, err = artifactExistsOnTenant(exe, "DEV_OrderFlow", "Integration", map[string]string{})
+	assert.ErrorContains(t, err, "artifact DEV_OrderFlow already exists on the tenant in another package")
+
+	exists, err = artifactExistsOnTenant(exe, "DEV_Typo", "Integration", map[string]string{})
+	require.NoError(t, err)
+	assert.False(t, exists)
+
+	exists, err = artifactExistsOnTenant(exe, "DEV_OrderFlow", "Integration", nil)
+	require.NoError(t, err)
+	assert.True(t, exists)
+
+	exists, err = artifactExistsOnTenant(exe, "DEV_Typo", "Integration", nil)
+	require.NoError(t, err)
+	assert.False(t, exists)
+}
+
+func TestCollectDeploymentTasks_SkippedUpdateNotDeployed(t *testing.T) {
+	pkg := &models.Package{
+		ID: "Orders",
+		Artifacts: []models.Artifact{
+			{Id: "OrderFlow", Type: "IntegrationFlow", Deploy: true},
+			{Id: "NewFlow", Type: "IntegrationFlow", Deploy: true},
+		},
+	}
+	stats := newTestProcessingStats()
+	stats.SkippedArtifactUpdates["NewFlow"] = true
+	stats.packageResult("DEVOrders").artifact("DEV_NewFlow").UpdateStatus = ResultSkipped
+
+	tasks := collectDeploymentTasks(context.Background(), pkg, "DEVOrders", "DEV", nil, stats)
+	require.Len(t, tasks, 1)
+	assert.Equal(t, "DEV_OrderFlow", tasks[0].ArtifactID)
+	assert.Equal(t, ResultSkipped, stats.packageResult("DEVOrders").artifact("DEV_NewFlow").DeployStatus)
+}
+
+func TestCollectDeploymentTasks_SkipPrefix(t *testing.T) {
+	pkg := &models.Package{
+		ID: "Orders",
+		Artifacts: []models.Artifact{
+			{Id: "OrderFlow", Type: "IntegrationFlow", Deploy: true},
+			{Id: "ErrorHandler", Type: "IntegrationFlow", Deploy: true, SkipPrefix: true},
+		},
+	}
+
+	tasks := collectDeploymentTasks(context.Background(), pkg, "DEVOrders", "DEV", nil, newTestProcessingStats())
+	require.Len(t, tasks, 2)
+	assert.Equal(t, "DEV_OrderFlow", tasks[0].ArtifactID)
+	assert.Equal(t, "ErrorHandler", tasks[1].ArtifactID)
+	assert.Equal(t, "ErrorHandler", prefixedArtifactID("DEV", pkg.Artifacts[1]))
+}
+
+func TestRun_UpdateExistingOnlyAndCreateOnlyExclusive(t *testing.T) {
+	_, err := NewOrchestrator(Options{DeployConfig: "deploy.yml", UpdateExistingOnly: true, CreateOnly: true}).Run(context.Background())
+	assert.EqualError(t, err, "--update-existing-only and --create-only cannot be used together")
+}
+
+func TestPrefixFromConfigFilename(t *testing.T) {
+	re, err := compilePrefixFromFilename(`^([A-Za-z0-9]+)-config\.ya?ml$`)
+	require.NoError(t, err)
+
+	prefix, err := prefixFromConfigFilename(re, "envs/dev-config.yml")
+	require.NoError(t, err)
+	assert.Equal(t, "dev", prefix)
+
+	_, err = prefixFromConfigFilename(re, "shared.yml")
+	assert.ErrorContains(t, err, "does not match")
+
+	re, err = compilePrefixFromFilename(`^([a-z-]+)\.yml$`)
+	require.NoError(t, err)
+	_, err = prefixFromConfigFilename(re, "dev-eu.yml")
+	assert.ErrorContains(t, err, "is invalid")
+
+	_, err = compilePrefixFromFilename(`-config\.yml$`)
+	assert.ErrorContains(t, err, "capture group")
+}
+
+func TestBuildPackageJSON(t *testing.T) {
+	data, err := buildPackageJSON(&models.Package{ID: "Orders", Version: "1.0.3", Vendor: "ACME"}, "DEVOrders", "DEV - Orders")
+	require.NoError(t, err)
+	assert.JSONEq(t, `{"d":{"Id":"DEVOrders","Name":"DEV - Orders","Description":"DEV - Orders","ShortText":"DEV - Orders","Version":"1.0.3","Vendor":"ACME"}}`, string(data))
+
+	data, err = buildPackageJSON(&models.Package{ID: "Orders", Description: "Order flows"}, "Orders", "Orders")
+	require.NoError(t, err)
+	assert.JSONEq(t, `{"d":{"Id":"Orders","Name":"Orders","Description":"Order flows","ShortText":"Orders"}}`, string(data))
+}
+
+func TestNewOrchestrator_Defaults(t *testing.T) {
+	o := NewOrchestrator(Options{DeployConfig: "./configs"})
+	assert.Equal(t, ModeUpdateAndDeploy, o.opts.Mode)
+	assert.Equal(t, 5, o.opts.DeployRetries)
+	assert.Equal(t, 15, o.opts.DeployDelaySeconds)
+	assert.Equal(t, 3, o.opts.ParallelDeployments)
+	assert.Equal(t, 1, o.opts.ParallelPackages)
+	assert.Equal(t, MetricsFormatPrometheus, o.opts.MetricsFormat)
+
+	o = NewOrchestrator(Options{Mode: ModeDeployOnly, ParallelDeployments: 7})
+	assert.Equal(t, ModeDeployOnly, o.opts.Mode)
+	assert.Equal(t, 7, o.opts.ParallelDeployments)
+}
+
+func TestOrchestratorRun_ConfigLoadError(t *testing.T) {
+	stats, err := NewOrchestrator(Options{DeployConfig: "/does/not/exist.yml"}).Run(context.Background())
+	require.Error(t, err)
+	require.NotNil(t, stats)
+
+	var configErr *ConfigLoadError
+	require.ErrorAs(t, err, &configErr)
+	assert.Equal(t, "/does/not/exist.yml", configErr.Source)
+}
+
+func TestOrchestratorRun_DebugSetsLogLevel(t *testing.T) {
+	var buf bytes.Buffer
+	previousLogger, previousLevel := log.Logger, zerolog.GlobalLevel()
+	log.Logger = zerolog.New(&buf)
+	zerolog.SetGlobalLevel(zerolog.DebugLevel)
+	defer func() {
+		log.Logger = previousLogger
+		zerolog.SetGlobalLevel(previousLevel)
+	}()
+
+	_, err := NewOrchestrator(Options{DeployConfig: "/does/not/exist.yml", Debug: true}).Run(context.Background())
+	require.Error(t, err)
+	assert.Contains(t, buf.String(), "Deploy settings")
+
+	// Without Debug the run logs at info level, and the level of the process is left as it is
+	buf.Reset()
+	_, err = NewOrchestrator(Options{DeployConfig: "/does/not/exist.yml"}).Run(context.Background())
+	require.Error(t, err)
+	assert.Equal(t, zerolog.DebugLevel, zerolog.GlobalLevel())
+	assert.NotContains(t, buf.String(), "Deploy settings")
+	assert.Contains(t, buf.String(), "Starting flashpipe orchestrator")
+}
+
+func TestOrchestratorRun_NotifiesConfigLoadError(t *testing.T) {
+	var payload map[string]interface{}
+	svr := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		data, _ := io.ReadAll(r.Body)
+		require.NoError(t, json.Unmarshal(data, &payload))
+	}))
+	defer svr.Close()
+
+	_, err := NewOrchestrator(Options{
+		DeployConfig: "/does/not/exist.yml",
+		NotifyURL:    svr.URL,
+		NotifyOn:     NotifyOnFailure,
+	}).Run(context.Background())
+	var configErr *ConfigLoadError
+	require.ErrorAs(t, err, &configErr)
+
+	require.NotNil(t, payload, "the early failure is notified")
+	summary := payload["summary"].(map[string]interface{})
+	assert.Equal(t, false, summary["success"])
+	assert.Contains(t, summary["error"], "/does/not/exist.yml")
+	assert.NotEmpty(t, summary["endTime"])
+}
+
+func TestOrchestratorRun_TenantName(t *testing.T) {
+	var globalBuf bytes.Buffer
+	previousLogger := log.Logger
+	log.Logger = zerolog.New(&globalBuf)
+	defer func() { log.Logger = previousLogger }()
+
+	serviceDetails := &api.ServiceDetails{Host: "dev.it-cpi.example.com"}
+	stats, err := NewOrchestrator(Options{DeployConfig: "/does/not/exist.yml", ServiceDetails: serviceDetails}).Run(context.Background())
+	require.Error(t, err)
+	assert.Equal(t, "dev.it-cpi.example.com", stats.TenantName)
+	assert.Contains(t, globalBuf.String(), `"tenant":"dev.it-cpi.example.com"`)
+
+	// The label is set on the logger of the run, the global logger is left unchanged
+	globalBuf.Reset()
+	var buf bytes.Buffer
+	logger := zerolog.New(&buf)
+	stats, err = NewOrchestrator(Options{DeployConfig: "/does/not/exist.yml", ServiceDetails: serviceDetails, TenantName: "DEV", Logger: &logger}).Run(context.Background())
+	require.Error(t, err)
+	assert.Equal(t, "DEV", stats.TenantName)
+	assert.Contains(t, buf.String(), `"tenant":"DEV"`)
+	assert.Empty(t, globalBuf.String())
+
+	// Functions called outside of a run log with the global logger
+	runLogger(context.Background()).Info().Msg("outside run")
+	assert.Contains(t, globalBuf.String(), "outside run")
+	assert.NotContains(t, globalBuf.String(), "tenant")
+}
+
+func TestOrchestratorRun_Cancelled(t *testing.T) {
+	tempDir, err := os.MkdirTemp("", "orchestrator-run-*")
+	require.NoError(t, err)
+	defer os.RemoveAll(tempDir)
+
+	configPath := filepath.Join(tempDir, "deploy.yml")
+	configContent := "packages:\n  - integrationSuiteId: Package1\n    packageDir: Package1\n"
+	require.NoError(t, os.WriteFile(configPath, []byte(configContent), 0644))
+
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel()
+
+	stats, err := NewOrchestrator(Options{
+		PackagesDir:    tempDir,
+		DeployConfig:   configPath,
+		ServiceDetails: &api.ServiceDetails{Host: "tenant.example.com"},
+	}).Run(ctx)
+	require.ErrorIs(t, err, context.Canceled)
+	assert.Empty(t, stats.PackageResults)
+	assert.Equal(t, 0, stats.PackagesUpdated)
+}
+
+// startTestTenant serves handler as the tenant of the executers created by api.InitHTTPExecuter.
+// These always connect to port 443, so the requests are tunnelled through a proxy to a TLS server.
+func startTestTenant(t *testing.T, handler http.HandlerFunc) {
+	tenant := httptest.NewTLSServer(handler)
+	t.Cleanup(tenant.Close)
+
+	proxy := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.Method != http.MethodConnect {
+			w.WriteHeader(http.StatusMethodNotAllowed)
+			return
+		}
+		upstream, err := net.Dial("tcp", tenant.Listener.Addr().String())
+		if err != nil {
+			w.WriteHeader(http.StatusBadGateway)
+			return
+		}
+		client, _, err := w.(http.Hijacker).Hijack()
+		if err != nil {
+			upstream.Close()
+			return
+		}
+		fmt.Fprint(client, "HTTP/1.1 200 Connection established\r\n\r\n")
+		go func() {
+			io.Copy(upstream, client)
+			upstream.Close()
+		}()
+		go func() {
+			io.Copy(client, upstream)
+			client.Close()
+		}()
+	}))
+	t.Cleanup(proxy.Close)
+
+	require.NoError(t, httpclnt.ConfigureTransport(httpclnt.TransportOptions{Proxy: proxy.URL, Insecure: true}))
+	t.Cleanup(func() { httpclnt.ConfigureTransport(httpclnt.TransportOptions{}) })
+}
+
+// runWithArtifactConfig runs the update phase of a package with a single artifact, whose
+// config is completed by artifactConfig, against a tenant where the package already exists
+func runWithArtifactConfig(t *testing.T, artifactConfig string) (*ProcessingStats, error) {
+	startTestTenant(t, func(w http.ResponseWriter, r *http.Request) {
+		switch r.URL.Path {
+		case "/api/v1/IntegrationPackages('Package1')":
+			fmt.Fprint(w, `{"d":{"Id":"Package1","Mode":"EDIT_ALLOWED"}}`)
+		default:
+			fmt.Fprint(w, `{"d":{"results":[]}}`)
+		}
+	})
+
+	tempDir := t.TempDir()
+	require.NoError(t, os.MkdirAll(filepath.Join(tempDir, "Package1", "Flow1", "META-INF"), 0755))
+	configPath := filepath.Join(tempDir, "deploy.yml")
+	configContent := "packages:\n  - integrationSuiteId: Package1\n    packageDir: Package1\n    artifacts:\n" +
+		"      - artifactId: Flow1\n        artifactDir: Flow1\n        type: Integration\n" + artifactConfig
+	require.NoError(t, os.WriteFile(configPath, []byte(configContent), 0644))
+
+	return NewOrchestrator(Options{
+		Mode:              ModeUpdateOnly,
+		PackagesDir:       tempDir,
+		DeployConfig:      configPath,
+		NoConfigCache:     true,
+		SkipPackageUpdate: true,
+		ServiceDetails:    &api.ServiceDetails{Host: "tenant.example.com", Userid: "user", Password: "password"},
+	}).Run(context.Background())
+}
+
+func TestOrchestratorRun_ConfigOverridesError(t *testing.T) {
+	stats, err := runWithArtifactConfig(t, "        configOverridesFile: missing.yml\n")
+	var updateErr *UpdatePhaseError
+	require.ErrorAs(t, err, &updateErr)
+	assert.Equal(t, []string{"Flow1"}, updateErr.FailedArtifactIDs)
+	assert.Equal(t, 1, stats.UpdateFailures)
+	require.Len(t, stats.PackageResults, 1)
+	require.Len(t, stats.PackageResults[0].Artifacts, 1)
+	assert.Equal(t, ResultFailed, stats.PackageResults[0].Artifacts[0].UpdateStatus)
+	assert.Contains(t, stats.PackageResults[0].Artifacts[0].Error, "failed to load configOverridesFile")
+}
+
+func TestOrchestratorRun_UnresolvedSecret(t *testing.T) {
+	stats, err := runWithArtifactConfig(t, "        configOverrides:\n          Password: \"{{secret:FLASHPIPE_TEST_UNSET_SECRET}}\"\n")
+	var updateErr *UpdatePhaseError
+	require.ErrorAs(t, err, &updateErr)
+	assert.Equal(t, []string{"Flow1"}, updateErr.FailedArtifactIDs)
+	assert.Equal(t, 1, stats.UpdateFailures)
+	require.Len(t, stats.PackageResults, 1)
+	require.Len(t, stats.PackageResults[0].Artifacts, 1)
+	assert.Equal(t, ResultFailed, stats.PackageResults[0].Artifacts[0].UpdateStatus)
+	assert.Contains(t, stats.PackageResults[0].Artifacts[0].Error, "FLASHPIPE_TEST_UNSET_SECRET")
+}
+
+func TestOrchestratorRun_ValueMappingFileError(t *testing.T) {
+	stats, err := runWithArtifactConfig(t, "        valueMappingFile: values.yml\n")
+	var updateErr *UpdatePhaseError
+	require.ErrorAs(t, err, &updateErr)
+	assert.Equal(t, []string{"Flow1"}, updateErr.FailedArtifactIDs)
+	assert.Equal(t, 1, stats.UpdateFailures)
+	require.Len(t, stats.PackageResults, 1)
+	require.Len(t, stats.PackageResults[0].Artifacts, 1)
+	assert.Contains(t, stats.PackageResults[0].Artifacts[0].Error, "valueMappingFile is only supported for ValueMapping artifacts")
+}
+
+func TestOrchestratorRun_MergedConfigsCancelledSavesState(t *testing.T) {
+	tempDir := t.TempDir()
+	configDir := filepath.Join(tempDir, "configs")
+	require.NoError(t, os.MkdirAll(configDir, 0755))
+	for _, name := range []string{"a.yml", "b.yml"} {
+		configContent := "deploymentPrefix: " + strings.ToUpper(name[:1]) + "\npackages:\n  - integrationSuiteId: Package1\n    artifacts: []\n"
+		require.NoError(t, os.WriteFile(filepath.Join(configDir, name), []byte(configContent), 0644))
+	}
+	stateFile := filepath.Join(tempDir, "state.json")
+
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel()
+
+	_, err := NewOrchestrator(Options{
+		Mode:           ModeUpdateOnly,
+		PackagesDir:    tempDir,
+		DeployConfig:   configDir,
+		MergeConfigs:   true,
+		NoConfigCache:  true,
+		StateFile:      stateFile,
+		ServiceDetails: &api.ServiceDetails{Host: "tenant.example.com", Userid: "user", Password: "password"},
+	}).Run(ctx)
+	require.ErrorIs(t, err, context.Canceled)
+	assert.FileExists(t, stateFile)
+}
+
+func TestOrchestratorRun_CreatedPackageSkipsPreflight(t *testing.T) {
+	var mu sync.Mutex
+	var requests []string
+	startTestTenant(t, func(w http.ResponseWriter, r *http.Request) {
+		mu.Lock()
+		requests = append(requests, r.Method+" "+r.URL.Path)
+		mu.Unlock()
+		switch {
+		case r.Method == http.MethodGet && r.URL.Path == "/api/v1/IntegrationPackages('Package1')":
+			http.NotFound(w, r)
+		case r.Method == http.MethodPost:
+			w.WriteHeader(http.StatusCreated)
+			fmt.Fprint(w, `{}`)
+		default:
+			fmt.Fprint(w, `{"d":{"results":[]}}`)
+		}
+	})
+
+	tempDir := t.TempDir()
+	require.NoError(t, os.MkdirAll(filepath.Join(tempDir, "Package1", "Flow1", "META-INF"), 0755))
+	configPath := filepath.Join(tempDir, "deploy.yml")
+	configContent := "packages:\n  - integrationSuiteId: Package1\n    packageDir: Package1\n    artifacts:\n" +
+		"      - artifactId: Flow1\n        artifactDir: Flow1\n        type: Integration\n"
+	require.NoError(t, os.WriteFile(configPath, []byte(configContent), 0644))
+
+	stats, _ := NewOrchestrator(Options{
+		Mode:                  ModeUpdateOnly,
+		PackagesDir:           tempDir,
+		DeployConfig:          configPath,
+		NoConfigCache:         true,
+		CreateMissingPackages: true,
+		ServiceDetails:        &api.ServiceDetails{Host: "tenant.example.com", Userid: "user", Password: "password"},
+	}).Run(context.Background())
+	assert.Equal(t, 1, stats.PackagesUpdated)
+
+	// The preflight does not list the artifacts of all types in the created package
+	assert.Contains(t, requests, "POST /api/v1/IntegrationPackages")
+	assert.NotContains(t, requests, "GET /api/v1/IntegrationPackages('Package1')/ValueMappingDesigntimeArtifacts")
+	assert.NotContains(t, requests, "GET /api/v1/IntegrationPackages('Package1')/ScriptCollectionDesigntimeArtifacts")
+}
+
+func TestArtifactTypePreflight(t *testing.T) {
+	svr := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		switch {
+		case strings.HasSuffix(r.URL.Path, "/IntegrationDesigntimeArtifacts"):
+			fmt.Fprint(w, `{"d":{"results":[{"Id":"DEV_OrderFlow","Name":"Order Flow","Version":"1.0.0"}]}}`)
+		case strings.HasSuffix(r.URL.Path, "/ScriptCollectionDesigntimeArtifacts"):
+			fmt.Fprint(w, `{"d":{"results":[{"Id":"DEV_Scripts","Name":"Scripts","Version":"1.0.0"}]}}`)
+		default:
+			fmt.Fprint(w, `{"d":{"results":[]}}`)
+		}
+	}))
+	defer svr.Close()
+
+	host, port := httpclnt.GetHostPort(svr.URL)
+	exe := httpclnt.New("", "", "", "", "user", "password", host, "http", port, false)
+
+	tenantTypes, err := tenantArtifactTypes(api.NewIntegrationPackage(exe), "DEVOrders")
+	require.NoError(t, err)
+	assert.Equal(t, map[string]string{"DEV_OrderFlow": "Integration", "DEV_Scripts": "ScriptCollection"}, tenantTypes)
+
+	assert.NoError(t, checkArtifactType("DEV_OrderFlow", "Integration", tenantTypes))
+	assert.NoError(t, checkArtifactType("DEV_NewFlow", "Integration", tenantTypes))
+	err = checkArtifactType("DEV_Scripts", "Integration", tenantTypes)
+	assert.ErrorContains(t, err, "artifact DEV_Scripts is configured as type Integration but exists on the tenant as ScriptCollection")
+
+	// Without tenant information the check is skipped
+	assert.NoError(t, checkArtifactType("DEV_Scripts", "Integration", nil))
+}
+
+func TestLogServiceDetails_NoCredentials(t *testing.T) {
+	var buf bytes.Buffer
+	previousLogger, previousLevel := log.Logger, zerolog.GlobalLevel()
+	log.Logger = zerolog.New(&buf)
+	zerolog.SetGlobalLevel(zerolog.DebugLevel)
+	defer func() {
+		log.Logger = previousLogger
+		zerolog.SetGlobalLevel(previousLevel)
+	}()
+
+	logServiceDetails(context.Background(), &api.ServiceDetails{
+		Host:              "tenant.example.com",
+		OauthHost:         "tenant.authentication.example.com",
+		OauthPath:         "/oauth/token",
+		OauthClientId:     "sb-clientid-1234567890",
+		OauthClientSecret: "oauth-client-secret",
+	})
+	logServiceDetails(context.Background(), &api.ServiceDetails{
+		Host:     "tenant.example.com",
+		Userid:   "integration.user@example.com",
+		Password: "basic-auth-password",
+	})
+
+	output := buf.String()
+	assert.Contains(t, output, "tenant.example.com")
+	assert.Contains(t, output, "sb-****890")
+	for _, credential := range []string{"sb-clientid-1234567890", "oauth-client-secret", "integration.user@example.com", "basic-auth-password"} {
+		assert.NotContains(t, output, credential)
+	}
+}
+
+func TestGetServiceDetailsFromViperOrCmd_OauthPath(t *testing.T) {
+	defer viper.Reset()
+	viper.SetEnvPrefix("FLASHPIPE")
+	viper.SetEnvKeyReplacer(strings.NewReplacer("-", "_"))
+	viper.AutomaticEnv()
+	viper.Set("tmn-host", "tenant.example.com")
+	viper.Set("oauth-host", "tenant.authentication.example.com")
+
+	// Without oauth-path the default token path is used
+	serviceDetails := getServiceDetailsFromViperOrCmd(&cobra.Command{})
+	require.NotNil(t, serviceDetails)
+	assert.Equal(t, api.DefaultOauthPath, serviceDetails.OauthPath)
+
+	t.Setenv("FLASHPIPE_OAUTH_PATH", "/oauth2/api/v1/token")
+	assert.Equal(t, "/oauth2/api/v1/token", getServiceDetailsFromViperOrCmd(&cobra.Command{}).OauthPath)
+}
+
+func TestTenantPackageState(t *testing.T) {
+	svr := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		switch r.URL.Path {
+		case "/api/v1/IntegrationPackages('DEVOrders')":
+			fmt.Fprint(w, `{"d":{"Id":"DEVOrders","Mode":"EDIT_ALLOWED"}}`)
+		case "/api/v1/IntegrationPackages('SAPContent')":
+			fmt.Fprint(w, `{"d":{"Id":"SAPContent","Mode":"READ_ONLY"}}`)
+		default:
+			w.WriteHeader(http.StatusNotFound)
+		}
+	}))
+	defer svr.Close()
+
+	host, port := httpclnt.GetHostPort(svr.URL)
+	ip := api.NewIntegrationPackage(httpclnt.New("", "", "", "", "user", "password", host, "http", port, false))
+
+	exists, readOnly, err := tenantPackageState(ip, "DEVOrders")
+	require.NoError(t, err)
+	assert.True(t, exists)
+	assert.False(t, readOnly)
+
+	exists, readOnly, err = tenantPackageState(ip, "SAPContent")
+	require.NoError(t, err)
+	assert.True(t, exists)
+	assert.True(t, readOnly)
+
+	exists, _, err = tenantPackageState(ip, "DEVMissing")
+	require.NoError(t, err)
+	assert.False(t, exists)
+
+	_, _, err = tenantPackageState(nil, "DEVOrders")
+	assert.Error(t, err)
+}
+
+func TestProcessPackages_PackageState(t *testing.T) {
+	var requests []string
+	var mu sync.Mutex
+	startTestTenant(t, func(w http.ResponseWriter, r *http.Request) {
+		mu.Lock()
+		requests = append(requests, r.Method+" "+r.URL.Path)
+		mu.Unlock()
+		switch r.URL.Path {
+		case "/api/v1/IntegrationPackages('SAPContent')":
+			fmt.Fprint(w, `{"d":{"Id":"SAPContent","Mode":"READ_ONLY"}}`)
+		case "/api/v1/IntegrationPackages('Missing')":
+			w.WriteHeader(http.StatusNotFound)
+		default:
+			w.WriteHeader(http.StatusInternalServerError)
+		}
+	})
+	serviceDetails := &api.ServiceDetails{Host: "tenant.example.com", Userid: "user", Password: "password"}
+
+	packagesDir := t.TempDir()
+	for _, dir := range []string{"SAPContent/Flow1", "Missing/Flow2"} {
+		require.NoError(t, os.MkdirAll(filepath.Join(packagesDir, dir), 0755))
+	}
+
+	// The artifacts of a Configure-only package are not updated, but still deployed
+	config := &models.DeployConfig{Packages: []models.Package{{ID: "SAPContent", PackageDir: "SAPContent", Sync: true, Deploy: true,
+		Artifacts: []models.Artifact{{Id: "Flow1", ArtifactDir: "Flow1", Type: "Integration", Sync: true, Deploy: true}}}}}
+	stats := newTestProcessingStats()
+	tasks, err := processPackages(context.Background(), config, true, ModeUpdateAndDeploy, packagesDir, t.TempDir(), "", nil, nil, false, true, false, false, false, false, false, nil, stats, serviceDetails)
+	require.NoError(t, err)
+	require.Len(t, tasks, 1)
+	assert.Equal(t, "Flow1", tasks[0].ArtifactID)
+	assert.Equal(t, 1, stats.PackagesFiltered)
+	assert.Equal(t, 0, stats.ArtifactsTotal)
+	assert.Equal(t, ResultSkipped, stats.PackageResults[0].UpdateStatus)
+	assert.Equal(t, []string{"GET /api/v1/IntegrationPackages('SAPContent')"}, requests)
+
+	// A missing package fails when it is not created
+	config = &models.DeployConfig{Packages: []models.Package{{ID: "Missing", PackageDir: "Missing", Sync: true, Deploy: true,
+		Artifacts: []models.Artifact{{Id: "Flow2", ArtifactDir: "Flow2", Type: "Integration", Sync: true, Deploy: true}}}}}
+	stats = newTestProcessingStats()
+	tasks, err = processPackages(context.Background(), config, true, ModeUpdateAndDeploy, packagesDir, t.TempDir(), "", nil, nil, false, false, false, false, false, false, false, nil, stats, serviceDetails)
+	require.NoError(t, err)
+	assert.Empty(t, tasks)
+	assert.Equal(t, 1, stats.PackagesFailed)
+	assert.True(t, stats.FailedPackageUpdates["Missing"])
+	assert.Contains(t, stats.PackageResults[0].Error, "does not exist on the tenant and --create-missing-packages is false")
+}
+
+func TestProcessPackages_SkipPackageUpdate(t *testing.T) {
+	var requests []string
+	var mu sync.Mutex
+	startTestTenant(t, func(w http.ResponseWriter, r *http.Request) {
+		mu.Lock()
+		requests = append(requests, r.Method+" "+r.URL.Path)
+		mu.Unlock()
+		switch {
+		case r.Method == http.MethodGet && r.URL.Path == "/api/v1/IntegrationPackages('Existing')":
+			fmt.Fprint(w, `{"d":{"Id":"Existing","Mode":"EDIT_ALLOWED"}}`)
+		case r.Method == http.MethodGet && r.URL.Path == "/api/v1/IntegrationPackages('New')":
+			w.WriteHeader(http.StatusNotFound)
+		case r.Method == http.MethodGet:
+			w.Header().Set("x-csrf-token", "token")
+		case r.Method == http.MethodPost && r.URL.Path == "/api/v1/IntegrationPackages":
+			w.WriteHeader(http.StatusCreated)
+		default:
+			w.WriteHeader(http.StatusInternalServerError)
+		}
+	})
+	serviceDetails := &api.ServiceDetails{Host: "tenant.example.com", Userid: "user", Password: "password"}
+
+	packagesDir := t.TempDir()
+	for _, dir := range []string{"Existing", "New"} {
+		require.NoError(t, os.MkdirAll(filepath.Join(packagesDir, dir), 0755))
+	}
+	config := &models.DeployConfig{Packages: []models.Package{
+		{ID: "Existing", PackageDir: "Existing", Deploy: true},
+		{ID: "New", PackageDir: "New", Deploy: true},
+	}}
+
+	stats := newTestProcessingStats()
+	_, err := processPackages(context.Background(), config, true, ModeUpdateOnly, packagesDir, t.TempDir(), "", nil, nil, false, true, true, false, false, false, false, nil, stats, serviceDetails)
+	require.NoError(t, err)
+
+	// The existing package is not updated, the missing package is still created
+	assert.NotContains(t, requests, "PUT /api/v1/IntegrationPackages('Existing')")
+	assert.Contains(t, requests, "POST /api/v1/IntegrationPackages")
+	assert.Equal(t, 1, stats.PackagesSkipped)
+	assert.Equal(t, 1, stats.PackagesUpdated)
+	assert.True(t, stats.SuccessfulPackageUpdates["New"])
+	require.Len(t, stats.PackageResults, 2)
+	assert.Equal(t, ResultSkipped, stats.PackageResults[0].UpdateStatus)
+	assert.Equal(t, skippedPackageUpdate, stats.PackageResults[0].Error)
+	assert.Equal(t, ResultSuccess, stats.PackageResults[1].UpdateStatus)
+}
+
+func TestUpdatePackage(t *testing.T) {
+	var requests []string
+	svr := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		switch {
+		case r.Method == http.MethodGet:
+			w.Header().Set("x-csrf-token", "token")
+		case r.Method == http.MethodPost && r.URL.Path == "/api/v1/IntegrationPackages":
+			requests = append(requests, "create")
+			w.WriteHeader(http.StatusCreated)
+		case r.Method == http.MethodPut && r.URL.Path == "/api/v1/IntegrationPackages('DEVOrders')":
+			requests = append(requests, "update")
+			w.WriteHeader(http.StatusAccepted)
+		default:
+			w.WriteHeader(http.StatusInternalServerError)
+		}
+	}))
+	defer svr.Close()
+
+	host, port := httpclnt.GetHostPort(svr.URL)
+	ip := api.NewIntegrationPackage(httpclnt.New("", "", "", "", "user", "password", host, "http", port, false))
+	pkg := &models.Package{ID: "Orders"}
+
+	require.NoError(t, updatePackage(context.Background(), ip, pkg, "DEVOrders", "DEV - Orders", false))
+	require.NoError(t, updatePackage(context.Background(), ip, pkg, "DEVOrders", "DEV - Orders", true))
+	assert.Equal(t, []string{"create", "update"}, requests)
+
+	// A failed update is reported instead of being ignored
+	err := updatePackage(context.Background(), ip, &models.Package{ID: "Invoices"}, "DEVInvoices", "DEV - Invoices", true)
+	assert.ErrorContains(t, err, "failed to update package DEVInvoices")
+
+	assert.Error(t, updatePackage(context.Background(), nil, pkg, "DEVOrders", "DEV - Orders", true))
+}
+
+func TestDeployAllArtifactsParallel_Cancelled(t *testing.T) {
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel()
+
+	stats := newTestProcessingStats()
+	tasks := []DeploymentTask{
+		{ArtifactID: "FlowA", ArtifactType: "Integration", PackageID: "Package1"},
+		{ArtifactID: "FlowB", ArtifactType: "Integration", PackageID: "Package1"},
+	}
+
+	err := deployAllArtifactsParallel(ctx, tasks, 1, 1, 0, false, 1, 1, false, stats, &api.ServiceDetails{Host: "tenant.example.com"})
+	require.NoError(t, err)
+	assert.Equal(t, 0, stats.ArtifactsDeployedSuccess)
+	assert.Equal(t, 2, stats.ArtifactsDeployedFailed)
+	assert.Equal(t, map[string]bool{"FlowA": true, "FlowB": true}, stats.FailedArtifactDeploys)
+}
+
+func TestDeployAllArtifactsParallel_ParallelPackages(t *testing.T) {
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel()
+
+	stats := newTestProcessingStats()
+	var tasks []DeploymentTask
+	for p := 1; p <= 5; p++ {
+		for a := 1; a <= 4; a++ {
+			tasks = append(tasks, DeploymentTask{
+				ArtifactID:   fmt.Sprintf("Package%d_Flow%d", p, a),
+				ArtifactType: "Integration",
+				PackageID:    fmt.Sprintf("Package%d", p),
+			})
+		}
+	}
+
+	// Run with -race to verify that the shared stats are synchronised across packages
+	err := deployAllArtifactsParallel(ctx, tasks, 2, 3, 0, false, 1, 1, true, stats, &api.ServiceDetails{Host: "tenant.example.com"})
+	require.NoError(t, err)
+	assert.Equal(t, 20, stats.ArtifactsDeployedFailed)
+	assert.Equal(t, 20, stats.DeployFailures)
+	assert.Len(t, stats.FailedArtifactDeploys, 20)
+	assert.Equal(t, 5, stats.PackagesFailed)
+	require.Len(t, stats.PackageResults, 5)
+	for _, pkgResult := range stats.PackageResults {
+		assert.Len(t, pkgResult.Artifacts, 4)
+	}
+}
+
+func TestProcessingStats_RecordPackageDeploy(t *testing.T) {
+	stats := newTestProcessingStats()
+	stats.recordPackageDeploy("Orders", false)
+	stats.recordPackageDeploy("Orders", false)
+	stats.recordPackageDeploy("Invoices", false)
+	stats.recordPackageDeploy("Invoices", true)
+	stats.recordPackageDeploy("Invoices", false)
+	assert.Equal(t, 1, stats.PackagesDeployed)
+	assert.Equal(t, 1, stats.PackagesFailed)
+}
+
+func TestGroupByDeployOrder(t *testing.T) {
+	tasks := []DeploymentTask{
+		{ArtifactID: "FlowA", DeployOrder: 2},
+		{ArtifactID: "Scripts", DeployOrder: 1},
+		{ArtifactID: "FlowB", DeployOrder: 2},
+		{ArtifactID: "Mapping", DeployOrder: 1},
+	}
+	groups := groupByDeployOrder(tasks, func(t DeploymentTask) int { return t.DeployOrder })
+	require.Len(t, groups, 2)
+	assert.Equal(t, []string{"Scripts", "Mapping"}, []string{groups[0][0].ArtifactID, groups[0][1].ArtifactID})
+	assert.Equal(t, []string{"FlowA", "FlowB"}, []string{groups[1][0].ArtifactID, groups[1][1].ArtifactID})
+
+	assert.Len(t, groupByDeployOrder(tasks[:1], func(t DeploymentTask) int { return t.DeployOrder }), 1)
+	assert.Empty(t, groupByDeployOrder(nil, func(t DeploymentTask) int { return t.DeployOrder }))
+}
+
+func TestDeployAllArtifactsParallel_DeployOrder(t *testing.T) {
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel()
+
+	stats := newTestProcessingStats()
+	tasks := []DeploymentTask{
+		{ArtifactID: "OrderFlow", ArtifactType: "Integration", PackageID: "Orders", PackageDeployOrder: 2},
+		{ArtifactID: "SharedScripts", ArtifactType: "ScriptCollection", PackageID: "Shared", PackageDeployOrder: 1, DeployOrder: 1},
+		{ArtifactID: "SharedFlow", ArtifactType: "Integration", PackageID: "Shared", PackageDeployOrder: 1, DeployOrder: 2},
+	}
+
+	// The failed script collection stops its dependents in the package and the packages deployed later
+	err := deployAllArtifactsParallel(ctx, tasks, 2, 2, 0, false, 1, 1, false, stats, &api.ServiceDetails{Host: "tenant.example.com"})
+	require.NoError(t, err)
+	assert.Equal(t, map[string]bool{"SharedScripts": true}, stats.FailedArtifactDeploys)
+	assert.Equal(t, 2, stats.ArtifactsNotDeployed)
+	assert.False(t, stats.DeployAborted)
+	assert.Equal(t, 2, stats.PackagesFailed)
+
+	require.Len(t, stats.PackageResults, 2)
+	assert.Equal(t, "Shared", stats.PackageResults[0].PackageID)
+	sharedFlow := stats.packageResult("Shared").artifact("SharedFlow")
+	assert.Equal(t, ResultSkipped, sharedFlow.DeployStatus)
+	assert.Equal(t, skippedFailedDependency, sharedFlow.Error)
+	assert.Equal(t, ResultSkipped, stats.packageResult("Orders").artifact("OrderFlow").DeployStatus)
+}
+
+func TestDeployAllArtifactsParallel_ScriptsFirst(t *testing.T) {
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel()
+
+	stats := newTestProcessingStats()
+	tasks := []DeploymentTask{
+		{ArtifactID: "OrderFlow", ArtifactType: "IntegrationFlow", PackageID: "Orders"},
+		{ArtifactID: "OrderScripts", ArtifactType: "ScriptCollection", PackageID: "Orders"},
+		{ArtifactID: "InvoiceFlow", ArtifactType: "Integration", PackageID: "Invoices"},
+		{ArtifactID: "InvoiceScripts", ArtifactType: "script collection", PackageID: "Invoices"},
+	}
+
+	scripts, others := splitScriptCollections(tasks)
+	assert.Equal(t, []DeploymentTask{tasks[1], tasks[3]}, scripts)
+	assert.Equal(t, []DeploymentTask{tasks[0], tasks[2]}, others)
+
+	// Script collections are recorded first, the other artifacts are still deployed after failures
+	err := deployAllArtifactsParallel(ctx, tasks, 1, 1, 0, true, 1, 1, false, stats, &api.ServiceDetails{Host: "tenant.example.com"})
+	require.NoError(t, err)
+	assert.Equal(t, 4, stats.ArtifactsDeployedFailed)
+	assert.Equal(t, 0, stats.ArtifactsNotDeployed)
+	assert.Equal(t, 2, stats.PackagesFailed)
+	require.Len(t, stats.PackageResults, 2)
+	assert.Equal(t, "OrderScripts", stats.packageResult("Orders").Artifacts[0].ArtifactID)
+	assert.Equal(t, "InvoiceScripts", stats.packageResult("Invoices").Artifacts[0].ArtifactID)
+}
+
+func TestDeployPackageArtifacts_MaxFailuresReached(t *testing.T) {
+	stats := newTestProcessingStats()
+	tasks := []DeploymentTask{
+		{ArtifactID: "FlowA", ArtifactType: "Integration", PackageID: "Package1"},
+		{ArtifactID: "FlowB", ArtifactType: "Integration", PackageID: "Package1"},
+	}
+
+	// With the limit already reached by another package, no deployment is started
+	limit := newFailureLimit(1)
+	assert.True(t, limit.recordFailure())
+	deployPackageArtifacts(context.Background(), "Package1", tasks, 2, 1, 1, false, newDeployProgress(2, 2), limit,
+		stats, &api.ServiceDetails{Host: "tenant.example.com"})
+
+	assert.True(t, stats.DeployAborted)
+	assert.Equal(t, 2, stats.ArtifactsNotDeployed)
+	assert.Equal(t, 0, stats.DeployFailures)
+	assert.Equal(t, 1, stats.PackagesFailed)
+	require.Len(t, stats.PackageResults, 1)
+	for _, artifact := range stats.PackageResults[0].Artifacts {
+		assert.Equal(t, ResultSkipped, artifact.DeployStatus)
+		assert.Equal(t, skippedMaxFailures, artifact.Error)
+	}
+}
+
+func TestFailureLimit(t *testing.T) {
+	limit := newFailureLimit(2)
+	assert.False(t, limit.recordFailure())
+	assert.False(t, limit.isReached())
+	assert.True(t, limit.recordFailure())
+	assert.True(t, limit.isReached())
+	// Further failures don't close the channel again
+	assert.False(t, limit.recordFailure())
+
+	unlimited := newFailureLimit(0)
+	for i := 0; i < 10; i++ {
+		assert.False(t, unlimited.recordFailure())
+	}
+	assert.False(t, unlimited.isReached())
+}
+
+func TestProcessingStats_ConcurrentDeployResults(t *testing.T) {
+	stats := newTestProcessingStats()
+
+	// Run with -race to verify that recording is synchronised
+	var wg sync.WaitGroup
+	for p := 1; p <= 10; p++ {
+		wg.Add(1)
+		go func(packageID string) {
+			defer wg.Done()
+			var inner sync.WaitGroup
+			for a := 1; a <= 20; a++ {
+				inner.Add(1)
+				go func(artifactID string, failed bool) {
+					defer inner.Done()
+					result := deployResult{Task: DeploymentTask{ArtifactID: artifactID, PackageID: packageID}}
+					if failed {
+						result.Error = fmt.Errorf("deployment of %s failed", artifactID)
+					}
+					stats.recordDeployResult(packageID, result)
+				}(fmt.Sprintf("%s_Flow%d", packageID, a), a%4 == 0)
+			}
+			inner.Wait()
+			stats.recordPackageDeploy(packageID, true)
+		}(fmt.Sprintf("Package%d", p))
+	}
+	wg.Wait()
+
+	assert.Equal(t, 150, stats.ArtifactsDeployedSuccess)
+	assert.Equal(t, 50, stats.ArtifactsDeployedFailed)
+	assert.Len(t, stats.SuccessfulArtifactDeploys, 150)
+	assert.Len(t, stats.FailedArtifactDeploys, 50)
+	assert.Equal(t, 10, stats.PackagesFailed)
+	require.Len(t, stats.PackageResults, 10)
+	for _, pkgResult := range stats.PackageResults {
+		assert.Len(t, pkgResult.Artifacts, 20)
+	}
+}
+
+func TestProcessingStats_SlowestDeploys(t *testing.T) {
+	stats := newTestProcessingStats()
+	for i, duration := range []time.Duration{20 * time.Second, 90 * time.Second, 45 * time.Second} {
+		packageID := "Orders"
+		if i == 2 {
+			packageID = "Invoices"
+		}
+		stats.recordDeployResult(packageID, deployResult{
+			Task:     DeploymentTask{ArtifactID: fmt.Sprintf("Flow%d", i+1), PackageID: packageID},
+			Duration: duration,
+		})
+	}
+	// Artifacts that were not deployed have no duration
+	stats.packageResult("Orders").artifact("Flow4").UpdateStatus = ResultSuccess
+
+	assert.Equal(t, []artifactTiming{
+		{PackageID: "Orders", ArtifactID: "Flow2", Duration: 90 * time.Second},
+		{PackageID: "Invoices", ArtifactID: "Flow3", Duration: 45 * time.Second},
+	}, stats.slowestDeploys(2))
+	assert.Len(t, stats.slowestDeploys(5), 3)
+}
+
+func TestSleepWithContext(t *testing.T) {
+	assert.NoError(t, sleepWithContext(context.Background(), time.Millisecond))
+
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel()
+	start := time.Now()
+	assert.ErrorIs(t, sleepWithContext(ctx, time.Minute), context.Canceled)
+	assert.Less(t, time.Since(start), time.Second)
+}
+
+func TestExpandOverrideValues(t *testing.T) {
+	t.Setenv("TARGET_ENDPOINT", "https://qa.example.com")
+
+	expanded, err := expandOverrideValues(map[string]interface{}{
+		"SenderURL": "${TARGET_ENDPOINT}/api",
+		"Timeout":   60000,
+	})
+	require.NoError(t, err)
+	assert.Equal(t, map[string]interface{}{"SenderURL": "https://qa.example.com/api", "Timeout": "60000"}, expanded)
+
+	// Credentials of the tenant must not end up in parameter files
+	viper.Set("tmn-password", "tenant-secret")
+	defer viper.Reset()
+	t.Setenv("LEAKED", "tenant-secret")
+
+	_, err = expandOverrideValues(map[string]interface{}{"Password": "$LEAKED"})
+	require.Error(t, err)
+	assert.Contains(t, err.Error(), "Sensitive content found in config override Password")
+	assert.NotContains(t, err.Error(), "tenant-secret")
+}
+
+func TestResolveOverrideSecrets(t *testing.T) {
+	secretsFile := filepath.Join(t.TempDir(), "secrets.yml")
+	require.NoError(t, os.WriteFile(secretsFile, []byte("API_KEY: key\nCOPIED_PASSWORD: tenant-secret\n"), 0644))
+	secrets, err := deploy.NewSecretResolver(secretsFile)
+	require.NoError(t, err)
+
+	viper.Set("tmn-password", "tenant-secret")
+	defer viper.Reset()
+
+	resolved, err := resolveOverrideSecrets(secrets, map[string]interface{}{"ApiKey": "{{secret:API_KEY}}"})
+	require.NoError(t, err)
+	assert.Equal(t, "key", resolved["ApiKey"])
+
+	// Credentials of the tenant must not end up in parameter files, whatever the secret is called
+	_, err = resolveOverrideSecrets(secrets, map[string]interface{}{"Password": "{{secret:COPIED_PASSWORD}}"})
+	require.Error(t, err)
+	assert.Contains(t, err.Error(), "Sensitive content found in secret of config override Password")
+	assert.NotContains(t, err.Error(), "tenant-secret")
+
+	t.Setenv("FLASHPIPE_TMN_PASSWORD", "tenant-secret")
+	_, err = resolveOverrideSecrets(secrets, map[string]interface{}{"Password": "{{secret:FLASHPIPE_TMN_PASSWORD}}"})
+	assert.ErrorContains(t, err, "credentials of the tenant cannot be used as secrets")
+}
+
+func TestExtractPackagesArchive(t *testing.T) {
+	tempDir, err := os.MkdirTemp("", "orchestrator-archive-*")
+	require.NoError(t, err)
+	defer os.RemoveAll(tempDir)
+
+	// Directories are used as they are
+	extractedDir, err := extractPackagesArchive(tempDir)
+	require.NoError(t, err)
+	assert.Empty(t, extractedDir)
+
+	// Zip archives are detected by their signature, also without .zip extension
+	for _, name := range []string{"packages.zip", "packages.bin"} {
+		archive := filepath.Join(tempDir, name)
+		require.NoError(t, os.WriteFile(archive, testArtifactZip(t), 0644))
+
+		extractedDir, err = extractPackagesArchive(archive)
+		require.NoError(t, err)
+		require.NotEmpty(t, extractedDir)
+		assert.FileExists(t, filepath.Join(extractedDir, "META-INF", "MANIFEST.MF"))
+		require.NoError(t, os.RemoveAll(extractedDir))
+	}
+
+	// Other files are neither a directory nor an archive
+	textFile := filepath.Join(tempDir, "packages.txt")
+	require.NoError(t, os.WriteFile(textFile, []byte("not a zip"), 0644))
+	_, err = extractPackagesArchive(textFile)
+	assert.EqualError(t, err, "packages directory "+textFile+" is neither a directory nor a zip archive")
+
+	brokenZip := filepath.Join(tempDir, "broken.zip")
+	require.NoError(t, os.WriteFile(brokenZip, []byte("not a zip"), 0644))
+	_, err = extractPackagesArchive(brokenZip)
+	assert.ErrorContains(t, err, "failed to extract packages archive")
+}
@@ -0,0 +1,168 @@
+package cmd
+
+import (
+	"encoding/json"
+	"fmt"
+	"io"
+	"text/tabwriter"
+	"time"
+
+	"github.com/engswee/flashpipe/internal/analytics"
+	"github.com/engswee/flashpipe/internal/api"
+	"github.com/engswee/flashpipe/internal/config"
+	"github.com/engswee/flashpipe/internal/str"
+	"github.com/rs/zerolog/log"
+	"github.com/spf13/cobra"
+)
+
+// ListedPackage is an integration package of the tenant, with its artifacts when requested
+type ListedPackage struct {
+	ID        string           `json:"id"`
+	Artifacts []ListedArtifact `json:"artifacts,omitempty"`
+}
+
+// ListedArtifact is a designtime artifact of an integration package
+type ListedArtifact struct {
+	ID      string `json:"id"`
+	Name    string `json:"name,omitempty"`
+	Type    string `json:"type"`
+	Version string `json:"version"`
+	IsDraft bool   `json:"isDraft,omitempty"`
+}
+
+func NewListCommand() *cobra.Command {
+
+	listCmd := &cobra.Command{
+		Use:   "list",
+		Short: "List integration packages and artifacts of the tenant",
+		Long: `List the integration packages on the SAP Integration Suite tenant and,
+with --with-artifacts, the designtime artifacts of each package with their
+type and version. Nothing is downloaded or changed.
+
+Configuration:
+  Settings can be loaded from the global config file (--config) under the
+  'list' section. CLI flags override config file settings.`,
+		Example: `  # IDs of all packages
+  flashpipe list
+
+  # Artifacts of the Orders packages as JSON
+  flashpipe list --ids-include Orders,OrdersLegacy --with-artifacts --output json`,
+		PreRunE: func(cmd *cobra.Command, args []string) error {
+			output := config.GetStringWithFallback(cmd, "output", "list.output")
+			if output != "table" && output != "json" {
+				return fmt.Errorf("invalid output format %q: must be 'table' or 'json'", output)
+			}
+			return nil
+		},
+		RunE: func(cmd *cobra.Command, args []string) (err error) {
+			startTime := time.Now()
+			if err = runList(cmd); err != nil {
+				cmd.SilenceUsage = true
+			}
+			analytics.Log(cmd, err, startTime)
+			return
+		},
+	}
+
+	// Define cobra flags, the default value has the lowest (least significant) precedence
+	// Note: These can be set in config file under 'list' key
+	listCmd.Flags().StringSlice("ids-include", nil, "List of included package IDs (config: list.idsInclude)")
+	listCmd.Flags().StringSlice("ids-exclude", nil, "List of excluded package IDs (config: list.idsExclude)")
+	listCmd.Flags().Bool("with-artifacts", false, "List the artifacts of each package (config: list.withArtifacts)")
+	listCmd.Flags().String("output", "table", "Output format: 'table' or 'json' (config: list.output)")
+
+	listCmd.MarkFlagsMutuallyExclusive("ids-include", "ids-exclude")
+
+	return listCmd
+}
+
+func runList(cmd *cobra.Command) error {
+	includedIds := str.TrimSlice(config.GetStringSliceWithFallback(cmd, "ids-include", "list.idsInclude"))
+	excludedIds := str.TrimSlice(config.GetStringSliceWithFallback(cmd, "ids-exclude", "list.idsExclude"))
+	withArtifacts := config.GetBoolWithFallback(cmd, "with-artifacts", "list.withArtifacts")
+	output := config.GetStringWithFallback(cmd, "output", "list.output")
+
+	log.Info().Msg("Executing list command")
+
+	serviceDetails := api.GetServiceDetails(cmd)
+	exe := api.InitHTTPExecuter(serviceDetails).WithContext(cmd.Context())
+
+	packages, err := listPackages(api.NewIntegrationPackage(exe), includedIds, excludedIds, withArtifacts)
+	if err != nil {
+		return err
+	}
+
+	if output == "json" {
+		return writeListJSON(cmd.OutOrStdout(), packages)
+	}
+	writeListTable(cmd.OutOrStdout(), packages, withArtifacts)
+	return nil
+}
+
+// listPackages returns the packages of the tenant after applying the include/exclude filter,
+// with their artifacts when withArtifacts is set
+func listPackages(ip *api.IntegrationPackage, includedIds []string, excludedIds []string, withArtifacts bool) ([]ListedPackage, error) {
+	ids, err := ip.GetPackagesList()
+	if err != nil {
+		return nil, err
+	}
+
+	packages := make([]ListedPackage, 0, len(ids))
+	for _, id := range ids {
+		if str.FilterIDs(id, includedIds, excludedIds) {
+			continue
+		}
+		listed := ListedPackage{ID: id}
+		if withArtifacts {
+			artifacts, err := ip.GetAllArtifacts(id)
+			if err != nil {
+				return nil, fmt.Errorf("failed to get artifacts of package %s: %w", id, err)
+			}
+			listed.Artifacts = make([]ListedArtifact, 0, len(artifacts))
+			for _, artifact := range artifacts {
+				listed.Artifacts = append(listed.Artifacts, ListedArtifact{
+					ID:      artifact.Id,
+					Name:    artifact.Name,
+					Type:    artifact.ArtifactType,
+					Version: artifact.Version,
+					IsDraft: artifact.IsDraft,
+				})
+			}
+		}
+		packages = append(packages, listed)
+	}
+	return packages, nil
+}
+
+func writeListJSON(w io.Writer, packages []ListedPackage) error {
+	encoder := json.NewEncoder(w)
+	encoder.SetIndent("", "  ")
+	if err := encoder.Encode(packages); err != nil {
+		return fmt.Errorf("failed to encode package list: %w", err)
+	}
+	return nil
+}
+
+func writeListTable(w io.Writer, packages []ListedPackage, withArtifacts bool) {
+	tw := tabwriter.NewWriter(w, 0, 0, 2, ' ', 0)
+	if !withArtifacts {
+		fmt.Fprintln(tw, "PACKAGE")
+		for _, p := range packages {
+			fmt.Fprintln(tw, p.ID)
+		}
+		tw.Flush()
+		return
+	}
+
+	fmt.Fprintln(tw, "PACKAGE\tARTIFACT\tTYPE\tVERSION")
+	for _, p := range packages {
+		if len(p.Artifacts) == 0 {
+			fmt.Fprintf(tw, "%s\t\t\t\n", p.ID)
+			continue
+		}
+		for _, a := range p.Artifacts {
+			fmt.Fprintf(tw, "%s\t%s\t%s\t%s\n", p.ID, a.ID, a.Type, a.Version)
+		}
+	}
+	tw.Flush()
+}
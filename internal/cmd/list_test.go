@@ -0,0 +1,60 @@
+package cmd
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/engswee/flashpipe/internal/api"
+	"github.com/engswee/flashpipe/internal/httpclnt"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestListPackages(t *testing.T) {
+	svr := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		switch r.URL.Path {
+		case "/api/v1/IntegrationPackages":
+			fmt.Fprint(w, `{"d":{"results":[{"Id":"Orders"},{"Id":"Invoices"},{"Id":"Empty"}]}}`)
+		case "/api/v1/IntegrationPackages('Orders')/IntegrationDesigntimeArtifacts":
+			fmt.Fprint(w, `{"d":{"results":[{"Id":"OrderFlow","Name":"Order Flow","Version":"1.0.2"}]}}`)
+		case "/api/v1/IntegrationPackages('Orders')/ScriptCollectionDesigntimeArtifacts":
+			fmt.Fprint(w, `{"d":{"results":[{"Id":"OrderScripts","Name":"Order Scripts","Version":"Active"}]}}`)
+		default:
+			fmt.Fprint(w, `{"d":{"results":[]}}`)
+		}
+	}))
+	defer svr.Close()
+
+	host, port := httpclnt.GetHostPort(svr.URL)
+	exe := httpclnt.New("", "", "", "", "user", "password", host, "http", port, false)
+	ip := api.NewIntegrationPackage(exe)
+
+	packages, err := listPackages(ip, nil, []string{"Invoices"}, false)
+	require.NoError(t, err)
+	assert.Equal(t, []ListedPackage{{ID: "Orders"}, {ID: "Empty"}}, packages)
+
+	packages, err = listPackages(ip, []string{"Orders", "Empty"}, nil, true)
+	require.NoError(t, err)
+	require.Len(t, packages, 2)
+	assert.Equal(t, []ListedArtifact{
+		{ID: "OrderFlow", Name: "Order Flow", Type: "Integration", Version: "1.0.2"},
+		{ID: "OrderScripts", Name: "Order Scripts", Type: "ScriptCollection", Version: "Active", IsDraft: true},
+	}, packages[0].Artifacts)
+	assert.Empty(t, packages[1].Artifacts)
+
+	var buf bytes.Buffer
+	require.NoError(t, writeListJSON(&buf, packages))
+	var decoded []map[string]interface{}
+	require.NoError(t, json.Unmarshal(buf.Bytes(), &decoded))
+	assert.Equal(t, "Orders", decoded[0]["id"])
+	assert.Len(t, decoded[0]["artifacts"], 2)
+
+	buf.Reset()
+	writeListTable(&buf, packages, true)
+	assert.Regexp(t, `Orders\s+OrderScripts\s+ScriptCollection\s+Active`, buf.String())
+	assert.Regexp(t, `(?m)^Empty\s*$`, buf.String())
+}
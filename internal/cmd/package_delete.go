@@ -0,0 +1,246 @@
+package cmd
+
+import (
+	"bufio"
+	"encoding/json"
+	"fmt"
+	"io"
+	"os"
+	"strings"
+	"text/tabwriter"
+	"time"
+
+	"github.com/engswee/flashpipe/internal/analytics"
+	"github.com/engswee/flashpipe/internal/api"
+	"github.com/engswee/flashpipe/internal/config"
+	"github.com/engswee/flashpipe/internal/httpclnt"
+	"github.com/rs/zerolog/log"
+	"github.com/spf13/cobra"
+)
+
+const (
+	deleteStatusDeleted = "deleted"
+	deleteStatusDryRun  = "would delete"
+	deleteStatusFailed  = "failed"
+	// deleteStatusWithPackage is used for the artifacts of read-only (configure-only) packages,
+	// which cannot be deleted individually and are removed with the package
+	deleteStatusWithPackage = "deleted with package"
+)
+
+// ArtifactDeleteResult is the result of deleting an artifact of a package
+type ArtifactDeleteResult struct {
+	ArtifactID   string `json:"artifactId"`
+	ArtifactType string `json:"artifactType"`
+	Undeployed   bool   `json:"undeployed"`
+	Status       string `json:"status"`
+	Error        string `json:"error,omitempty"`
+}
+
+func NewPackageDeleteCommand() *cobra.Command {
+
+	packageDeleteCmd := &cobra.Command{
+		Use:   "package-delete",
+		Short: "Delete an integration package and its artifacts from the tenant",
+		Long: `Delete an integration package from the SAP Integration Suite tenant.
+All artifacts of the package are undeployed and deleted first, then the
+package itself is deleted. The package is kept when any artifact fails.
+
+This cannot be undone. Without --dry-run the deletion must be confirmed,
+either with --confirm or by typing the package ID when asked.
+
+Configuration:
+  Settings can be loaded from the global config file (--config) under the
+  'packageDelete' section. CLI flags override config file settings.
+  --confirm is only read from the command line.`,
+		Example: `  # Show what would be deleted
+  flashpipe package-delete --package-id OrdersTest --dry-run
+
+  # Delete without asking, e.g. in a pipeline
+  flashpipe package-delete --package-id OrdersTest --confirm`,
+		PreRunE: func(cmd *cobra.Command, args []string) error {
+			if config.GetStringWithFallback(cmd, "package-id", "packageDelete.packageId") == "" {
+				return fmt.Errorf("required flag \"package-id\" not set")
+			}
+			output := config.GetStringWithFallback(cmd, "output", "packageDelete.output")
+			if output != "table" && output != "json" {
+				return fmt.Errorf("invalid output format %q: must be 'table' or 'json'", output)
+			}
+			return nil
+		},
+		RunE: func(cmd *cobra.Command, args []string) (err error) {
+			startTime := time.Now()
+			if err = runPackageDelete(cmd); err != nil {
+				cmd.SilenceUsage = true
+			}
+			analytics.Log(cmd, err, startTime)
+			return
+		},
+	}
+
+	// Define cobra flags, the default value has the lowest (least significant) precedence
+	// Note: These can be set in config file under 'packageDelete' key
+	packageDeleteCmd.Flags().String("package-id", "", "ID of the integration package to delete (config: packageDelete.packageId)")
+	packageDeleteCmd.Flags().Bool("dry-run", false, "Only list the artifacts that would be deleted (config: packageDelete.dryRun)")
+	packageDeleteCmd.Flags().Bool("confirm", false, "Delete without asking for confirmation")
+	packageDeleteCmd.Flags().String("output", "table", "Output format of the results: 'table' or 'json' (config: packageDelete.output)")
+
+	return packageDeleteCmd
+}
+
+func runPackageDelete(cmd *cobra.Command) error {
+	packageID := config.GetStringWithFallback(cmd, "package-id", "packageDelete.packageId")
+	dryRun := config.GetBoolWithFallback(cmd, "dry-run", "packageDelete.dryRun")
+	confirm := config.GetBool(cmd, "confirm")
+	output := config.GetStringWithFallback(cmd, "output", "packageDelete.output")
+
+	log.Info().Msg("Executing package-delete command")
+
+	serviceDetails := api.GetServiceDetails(cmd)
+	exe := api.InitHTTPExecuter(serviceDetails).WithContext(cmd.Context())
+	ip := api.NewIntegrationPackage(exe)
+
+	_, readOnly, exists, err := ip.Get(packageID)
+	if err != nil {
+		return err
+	}
+	if !exists {
+		return fmt.Errorf("package %s does not exist on the tenant", packageID)
+	}
+	artifacts, err := ip.GetAllArtifacts(packageID)
+	if err != nil {
+		return fmt.Errorf("failed to get artifacts of package %s: %w", packageID, err)
+	}
+
+	if !dryRun && !confirm {
+		in := cmd.InOrStdin()
+		if f, ok := in.(*os.File); ok && !isTerminal(f) {
+			return fmt.Errorf("deleting package %s requires --confirm when not run interactively", packageID)
+		}
+		confirmed, err := confirmPackageDelete(in, cmd.ErrOrStderr(), packageID, len(artifacts))
+		if err != nil {
+			return err
+		}
+		if !confirmed {
+			return fmt.Errorf("deletion of package %s not confirmed", packageID)
+		}
+	}
+
+	results, err := deletePackage(exe, packageID, artifacts, readOnly, dryRun)
+	if output == "json" {
+		if writeErr := writePackageDeleteJSON(cmd.OutOrStdout(), results); writeErr != nil {
+			return writeErr
+		}
+	} else {
+		writePackageDeleteTable(cmd.OutOrStdout(), results)
+	}
+	return err
+}
+
+// deletePackage undeploys and deletes all artifacts of the package, then the package itself.
+// The package is only deleted when all artifacts were deleted. With dryRun nothing is changed.
+func deletePackage(exe *httpclnt.HTTPExecuter, packageID string, artifacts []*api.ArtifactDetails, readOnly, dryRun bool) ([]ArtifactDeleteResult, error) {
+	rt := api.NewRuntime(exe)
+	results := make([]ArtifactDeleteResult, 0, len(artifacts))
+	failed := 0
+	for _, artifact := range artifacts {
+		result := ArtifactDeleteResult{ArtifactID: artifact.Id, ArtifactType: artifact.ArtifactType}
+		if dryRun {
+			result.Status = deleteStatusDryRun
+			results = append(results, result)
+			continue
+		}
+		undeployed, err := undeployAndDeleteArtifact(rt, exe, artifact.Id, artifact.ArtifactType, readOnly)
+		result.Undeployed = undeployed
+		switch {
+		case err != nil:
+			result.Status = deleteStatusFailed
+			result.Error = err.Error()
+			failed++
+			log.Error().Msgf("Failed to delete %s %s: %v", artifact.ArtifactType, artifact.Id, err)
+		case readOnly:
+			result.Status = deleteStatusWithPackage
+		default:
+			result.Status = deleteStatusDeleted
+		}
+		results = append(results, result)
+	}
+
+	if dryRun {
+		log.Info().Msgf("Dry run: package %s and %d artifact(s) would be deleted", packageID, len(artifacts))
+		return results, nil
+	}
+	if failed > 0 {
+		return results, fmt.Errorf("package %s not deleted: %d of %d artifact(s) could not be deleted", packageID, failed, len(artifacts))
+	}
+	if err := api.NewIntegrationPackage(exe).Delete(packageID); err != nil {
+		return results, fmt.Errorf("failed to delete package %s: %w", packageID, err)
+	}
+	log.Info().Msgf("Deleted package %s with %d artifact(s)", packageID, len(artifacts))
+	return results, nil
+}
+
+// undeployAndDeleteArtifact undeploys the artifact when it is deployed and deletes it, unless the
+// package is read-only. It reports whether the artifact was undeployed. It is shared by
+// package-delete and reconcile --apply.
+func undeployAndDeleteArtifact(rt *api.Runtime, exe *httpclnt.HTTPExecuter, artifactID string, artifactType string, readOnly bool) (bool, error) {
+	dt := api.NewDesigntimeArtifact(artifactType, exe)
+	if dt == nil {
+		return false, fmt.Errorf("unsupported artifact type %s", artifactType)
+	}
+	version, _, err := rt.Get(artifactID)
+	if err != nil {
+		return false, err
+	}
+	undeployed := false
+	if version != api.NotDeployed {
+		if err := rt.UnDeploy(artifactID); err != nil {
+			return false, fmt.Errorf("failed to undeploy: %w", err)
+		}
+		undeployed = true
+	}
+	if readOnly {
+		return undeployed, nil
+	}
+	if err := dt.Delete(artifactID); err != nil {
+		return undeployed, fmt.Errorf("failed to delete: %w", err)
+	}
+	return undeployed, nil
+}
+
+// confirmPackageDelete asks to type the package ID and reports whether it was typed correctly
+func confirmPackageDelete(in io.Reader, out io.Writer, packageID string, artifactCount int) (bool, error) {
+	fmt.Fprintf(out, "This deletes package %s and its %d artifact(s) from the tenant and cannot be undone.\n", packageID, artifactCount)
+	fmt.Fprint(out, "Type the package ID to confirm: ")
+	answer, err := bufio.NewReader(in).ReadString('\n')
+	if err != nil && err != io.EOF {
+		return false, fmt.Errorf("failed to read confirmation: %w", err)
+	}
+	return strings.TrimSpace(answer) == packageID, nil
+}
+
+// isTerminal reports whether f is an interactive terminal rather than a pipe or file
+func isTerminal(f *os.File) bool {
+	info, err := f.Stat()
+	if err != nil {
+		return false
+	}
+	return info.Mode()&os.ModeCharDevice != 0
+}
+
+func writePackageDeleteJSON(w io.Writer, results []ArtifactDeleteResult) error {
+	encoder := json.NewEncoder(w)
+	encoder.SetIndent("", "  ")
+	if err := encoder.Encode(results); err != nil {
+		return fmt.Errorf("failed to encode delete results: %w", err)
+	}
+	return nil
+}
+
+func writePackageDeleteTable(w io.Writer, results []ArtifactDeleteResult) {
+	tw := tabwriter.NewWriter(w, 0, 0, 2, ' ', 0)
+	fmt.Fprintln(tw, "ARTIFACT\tTYPE\tUNDEPLOYED\tSTATUS\tERROR")
+	for _, r := range results {
+		fmt.Fprintf(tw, "%s\t%s\t%t\t%s\t%s\n", r.ArtifactID, r.ArtifactType, r.Undeployed, r.Status, r.Error)
+	}
+	tw.Flush()
+}
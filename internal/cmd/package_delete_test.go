@@ -0,0 +1,100 @@
+package cmd
+
+import (
+	"bytes"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"sync"
+	"testing"
+
+	"github.com/engswee/flashpipe/internal/api"
+	"github.com/engswee/flashpipe/internal/httpclnt"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestDeletePackage(t *testing.T) {
+	var mu sync.Mutex
+	var deletes []string
+	svr := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		switch {
+		case r.Method == http.MethodGet && r.URL.Path == "/api/v1/":
+			w.Header().Set("x-csrf-token", "token")
+		case r.Method == http.MethodGet && r.URL.Path == "/api/v1/IntegrationRuntimeArtifacts('OrderFlow')":
+			fmt.Fprint(w, `{"d":{"Version":"1.0.0","Status":"STARTED"}}`)
+		case r.Method == http.MethodGet:
+			w.WriteHeader(http.StatusNotFound)
+		case r.Method == http.MethodDelete && strings.Contains(r.URL.Path, "BrokenScripts"):
+			w.WriteHeader(http.StatusInternalServerError)
+		case r.Method == http.MethodDelete:
+			mu.Lock()
+			deletes = append(deletes, r.URL.Path)
+			mu.Unlock()
+			if strings.HasPrefix(r.URL.Path, "/api/v1/IntegrationDesigntimeArtifacts") || strings.HasPrefix(r.URL.Path, "/api/v1/ScriptCollectionDesigntimeArtifacts") {
+				w.WriteHeader(http.StatusOK)
+			} else {
+				w.WriteHeader(http.StatusAccepted)
+			}
+		}
+	}))
+	defer svr.Close()
+
+	host, port := httpclnt.GetHostPort(svr.URL)
+	exe := httpclnt.New("", "", "", "", "user", "password", host, "http", port, false)
+	artifacts := []*api.ArtifactDetails{
+		{Id: "OrderFlow", ArtifactType: "Integration"},
+		{Id: "OrderScripts", ArtifactType: "ScriptCollection"},
+	}
+
+	// Dry run changes nothing
+	results, err := deletePackage(exe, "Orders", artifacts, false, true)
+	require.NoError(t, err)
+	assert.Equal(t, []ArtifactDeleteResult{
+		{ArtifactID: "OrderFlow", ArtifactType: "Integration", Status: deleteStatusDryRun},
+		{ArtifactID: "OrderScripts", ArtifactType: "ScriptCollection", Status: deleteStatusDryRun},
+	}, results)
+	assert.Empty(t, deletes)
+
+	results, err = deletePackage(exe, "Orders", artifacts, false, false)
+	require.NoError(t, err)
+	assert.Equal(t, []ArtifactDeleteResult{
+		{ArtifactID: "OrderFlow", ArtifactType: "Integration", Undeployed: true, Status: deleteStatusDeleted},
+		{ArtifactID: "OrderScripts", ArtifactType: "ScriptCollection", Status: deleteStatusDeleted},
+	}, results)
+	assert.Equal(t, []string{
+		"/api/v1/IntegrationRuntimeArtifacts('OrderFlow')",
+		"/api/v1/IntegrationDesigntimeArtifacts(Id='OrderFlow',Version='active')",
+		"/api/v1/ScriptCollectionDesigntimeArtifacts(Id='OrderScripts',Version='active')",
+		"/api/v1/IntegrationPackages('Orders')",
+	}, deletes)
+
+	// A failed artifact keeps the package
+	deletes = nil
+	results, err = deletePackage(exe, "Orders", append(artifacts[1:], &api.ArtifactDetails{Id: "BrokenScripts", ArtifactType: "ScriptCollection"}), false, false)
+	require.EqualError(t, err, "package Orders not deleted: 1 of 2 artifact(s) could not be deleted")
+	require.Len(t, results, 2)
+	assert.Equal(t, deleteStatusFailed, results[1].Status)
+	assert.NotContains(t, deletes, "/api/v1/IntegrationPackages('Orders')")
+
+	var buf bytes.Buffer
+	writePackageDeleteTable(&buf, results)
+	assert.Regexp(t, `BrokenScripts\s+ScriptCollection\s+false\s+failed`, buf.String())
+}
+
+func TestConfirmPackageDelete(t *testing.T) {
+	var out bytes.Buffer
+	confirmed, err := confirmPackageDelete(strings.NewReader("Orders\n"), &out, "Orders", 2)
+	require.NoError(t, err)
+	assert.True(t, confirmed)
+	assert.Contains(t, out.String(), "package Orders and its 2 artifact(s)")
+
+	confirmed, err = confirmPackageDelete(strings.NewReader("y\n"), &out, "Orders", 2)
+	require.NoError(t, err)
+	assert.False(t, confirmed)
+
+	confirmed, err = confirmPackageDelete(strings.NewReader(""), &out, "Orders", 2)
+	require.NoError(t, err)
+	assert.False(t, confirmed)
+}
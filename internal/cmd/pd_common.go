@@ -1,7 +1,16 @@
 package cmd
 
 import (
+	"fmt"
+	"path/filepath"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/engswee/flashpipe/internal/api"
 	"github.com/engswee/flashpipe/internal/config"
+	"github.com/engswee/flashpipe/internal/file"
+	"github.com/engswee/flashpipe/internal/repo"
 	"github.com/spf13/cobra"
 )
 
@@ -21,12 +30,68 @@ func getConfigBoolWithFallback(cmd *cobra.Command, flagName, configKey string) b
 	return config.GetBoolWithFallback(cmd, flagName, configKey)
 }
 
+// getConfigIntWithFallback reads an int value from command flag,
+// falling back to a nested config key if the flag wasn't explicitly set
+func getConfigIntWithFallback(cmd *cobra.Command, flagName, configKey string) int {
+	return config.GetIntWithFallback(cmd, flagName, configKey)
+}
+
 // getConfigStringSliceWithFallback reads a string slice value from command flag,
 // falling back to a nested config key if the flag wasn't explicitly set
 func getConfigStringSliceWithFallback(cmd *cobra.Command, flagName, configKey string) []string {
 	return config.GetStringSliceWithFallback(cmd, flagName, configKey)
 }
 
+// addRetryFlags defines the flags controlling retries of Partner Directory requests
+func addRetryFlags(cmd *cobra.Command) {
+	cmd.Flags().Int("max-retries", api.DefaultRetryPolicy.MaxRetries,
+		"Maximum retries for Partner Directory requests failing with connection errors or a retryable status code")
+	cmd.Flags().Int("retry-delay", int(api.DefaultRetryPolicy.BaseDelay/time.Second),
+		"Initial delay in seconds between retries, doubled after each retry (Retry-After is honored on 429)")
+	var defaultStatusCodes []string
+	for _, code := range api.DefaultRetryStatusCodes {
+		defaultStatusCodes = append(defaultStatusCodes, strconv.Itoa(code))
+	}
+	cmd.Flags().StringSlice("retry-status-codes", defaultStatusCodes,
+		"Comma separated list of HTTP status codes that trigger a retry (5xx codes are not retried for POST)")
+}
+
+// getRetryPolicy reads the retry flags defined by addRetryFlags, with fallback to the
+// config file section of the command
+func getRetryPolicy(cmd *cobra.Command, section string) (api.RetryPolicy, error) {
+	maxRetries := getConfigIntWithFallback(cmd, "max-retries", section+".max-retries")
+	retryDelay := getConfigIntWithFallback(cmd, "retry-delay", section+".retry-delay")
+	if maxRetries < 0 {
+		return api.RetryPolicy{}, fmt.Errorf("--max-retries must not be negative, got %d", maxRetries)
+	}
+	if retryDelay < 0 {
+		return api.RetryPolicy{}, fmt.Errorf("--retry-delay must not be negative, got %d", retryDelay)
+	}
+	statusCodes, err := parseRetryStatusCodes(getConfigStringSliceWithFallback(cmd, "retry-status-codes", section+".retry-status-codes"))
+	if err != nil {
+		return api.RetryPolicy{}, err
+	}
+	return api.RetryPolicy{
+		MaxRetries:  maxRetries,
+		BaseDelay:   time.Duration(retryDelay) * time.Second,
+		StatusCodes: statusCodes,
+	}, nil
+}
+
+// parseRetryStatusCodes converts the --retry-status-codes values into HTTP status codes.
+// An empty list disables retries on status codes, so only connection errors are retried.
+func parseRetryStatusCodes(values []string) ([]int, error) {
+	statusCodes := []int{}
+	for _, value := range values {
+		code, err := strconv.Atoi(strings.TrimSpace(value))
+		if err != nil || code < 100 || code > 599 {
+			return nil, fmt.Errorf("invalid --retry-status-codes value %q: must be an HTTP status code", value)
+		}
+		statusCodes = append(statusCodes, code)
+	}
+	return statusCodes, nil
+}
+
 // contains checks if a string slice contains a specific string
 func contains(slice []string, str string) bool {
 	for _, s := range slice {
@@ -36,3 +101,41 @@ func contains(slice []string, str string) bool {
 	}
 	return false
 }
+
+// copyPIDParameters reads the string and binary parameters of a PID from src and writes them to
+// dst, returning the number of parameters written of each type. A missing String.properties of
+// dst is first seeded with the file of src, so that its comments are kept.
+func copyPIDParameters(src, dst *repo.PartnerDirectory, pid string, replace bool) (int, int, error) {
+	stringParams, err := src.ReadStringParameters(pid)
+	if err != nil {
+		return 0, 0, fmt.Errorf("failed to read string parameters for PID %s: %w", pid, err)
+	}
+	binaryParams, err := src.ReadBinaryParameters(pid)
+	if err != nil {
+		return 0, 0, fmt.Errorf("failed to read binary parameters for PID %s: %w", pid, err)
+	}
+
+	stringWritten := 0
+	if len(stringParams) > 0 {
+		srcFile := filepath.Join(src.ResourcesPath, pid, "String.properties")
+		dstFile := filepath.Join(dst.ResourcesPath, pid, "String.properties")
+		stringReplace := replace
+		if !file.Exists(dstFile) {
+			if err := file.CopyFile(srcFile, dstFile); err != nil {
+				return 0, 0, fmt.Errorf("failed to copy string parameters for PID %s: %w", pid, err)
+			}
+			stringReplace = true
+		}
+		if stringWritten, err = dst.WriteStringParameters(pid, stringParams, stringReplace); err != nil {
+			return 0, 0, fmt.Errorf("failed to write string parameters for PID %s: %w", pid, err)
+		}
+	}
+
+	binaryWritten := 0
+	if len(binaryParams) > 0 {
+		if binaryWritten, err = dst.WriteBinaryParameters(pid, binaryParams, replace); err != nil {
+			return 0, 0, fmt.Errorf("failed to write binary parameters for PID %s: %w", pid, err)
+		}
+	}
+	return stringWritten, binaryWritten, nil
+}
@@ -0,0 +1,41 @@
+package cmd
+
+import (
+	"testing"
+
+	"github.com/engswee/flashpipe/internal/api"
+	"github.com/spf13/cobra"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestParseRetryStatusCodes(t *testing.T) {
+	codes, err := parseRetryStatusCodes([]string{"429", " 502", "408 "})
+	require.NoError(t, err)
+	assert.Equal(t, []int{429, 502, 408}, codes)
+
+	codes, err = parseRetryStatusCodes(nil)
+	require.NoError(t, err)
+	assert.Empty(t, codes)
+	assert.NotNil(t, codes)
+
+	_, err = parseRetryStatusCodes([]string{"429", "5xx"})
+	assert.ErrorContains(t, err, `invalid --retry-status-codes value "5xx"`)
+
+	_, err = parseRetryStatusCodes([]string{"999"})
+	assert.Error(t, err)
+}
+
+func TestGetRetryPolicy_DefaultStatusCodes(t *testing.T) {
+	cmd := &cobra.Command{}
+	addRetryFlags(cmd)
+
+	policy, err := getRetryPolicy(cmd, "pd-test")
+	require.NoError(t, err)
+	assert.Equal(t, api.DefaultRetryStatusCodes, policy.StatusCodes)
+
+	require.NoError(t, cmd.Flags().Set("retry-status-codes", "408,504"))
+	policy, err = getRetryPolicy(cmd, "pd-test")
+	require.NoError(t, err)
+	assert.Equal(t, []int{408, 504}, policy.StatusCodes)
+}
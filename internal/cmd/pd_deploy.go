@@ -1,6 +1,7 @@
 package cmd
 
 import (
+	"errors"
 	"fmt"
 	"strings"
 	"time"
@@ -33,6 +34,7 @@ The deploy operation supports several modes:
   - Replace mode (default): Updates existing parameters with local values
   - Add-only mode: Only creates new parameters, skips existing ones
   - Full sync mode: Deletes remote parameters not present locally (local is source of truth)
+  - Create-only mode: Only creates missing parameters, never updates or deletes (overrides --replace and --full-sync)
 
 Authentication is performed using OAuth 2.0 client credentials flow or Basic Auth.`,
 		Example: `  # Deploy with OAuth (environment variables)
@@ -56,11 +58,20 @@ Authentication is performed using OAuth 2.0 client credentials flow or Basic Aut
   # Deploy with full sync (delete remote parameters not in local)
   flashpipe pd-deploy --full-sync
 
+  # Append-only partner directory: never update or delete existing parameters
+  flashpipe pd-deploy --create-only
+
   # Deploy only specific PIDs
   flashpipe pd-deploy --pids "SAP_SYSTEM_001,CUSTOMER_API"
 
   # Dry run to see what would be changed
-  flashpipe pd-deploy --dry-run`,
+  flashpipe pd-deploy --dry-run
+
+  # Deploy using OData $batch requests (faster for large partner directories)
+  flashpipe pd-deploy --batch
+
+  # Abort before uploading if any binary parameter has an unsupported content type
+  flashpipe pd-deploy --content-type-check strict`,
 		RunE: func(cmd *cobra.Command, args []string) (err error) {
 			startTime := time.Now()
 			if err = runPDDeploy(cmd); err != nil {
@@ -79,14 +90,32 @@ Authentication is performed using OAuth 2.0 client credentials flow or Basic Aut
 		"Replace existing values (false = add only missing values)")
 	pdDeployCmd.Flags().Bool("full-sync", false,
 		"Delete remote parameters not present locally (local is source of truth)")
+	pdDeployCmd.Flags().Bool("create-only", false,
+		"Only create missing parameters, never update or delete (overrides --replace and --full-sync)")
 	pdDeployCmd.Flags().Bool("dry-run", false,
 		"Show what would be changed without making changes")
 	pdDeployCmd.Flags().StringSlice("pids", nil,
 		"Comma separated list of Partner IDs to deploy (e.g., 'PID1,PID2')")
+	pdDeployCmd.Flags().Bool("batch", false,
+		"Use OData $batch requests for creates, updates and deletions")
+	pdDeployCmd.Flags().Bool("strict-concurrency", false,
+		"Only update parameters that were not changed on the tenant since they were read (If-Match with ETag), report others as conflict")
+	pdDeployCmd.Flags().StringSlice("extra-content-types", nil,
+		"Comma separated list of additional binary content types to preserve as file extensions (e.g., 'pem,p12'), they are still checked by --content-type-check")
+	pdDeployCmd.Flags().Bool("decompress", false,
+		"Compress gz and zlib binary parameter files that are stored decompressed before uploading")
+	pdDeployCmd.Flags().String("content-type-check", contentTypeCheckWarn,
+		"How to handle binary parameters with content types not accepted by SAP CPI: 'warn' or 'strict' (fail before uploading)")
+	addRetryFlags(pdDeployCmd)
 
 	return pdDeployCmd
 }
 
+const (
+	contentTypeCheckWarn   = "warn"
+	contentTypeCheckStrict = "strict"
+)
+
 func runPDDeploy(cmd *cobra.Command) error {
 	serviceDetails := api.GetServiceDetails(cmd)
 
@@ -96,13 +125,33 @@ func runPDDeploy(cmd *cobra.Command) error {
 	resourcesPath := getConfigStringWithFallback(cmd, "resources-path", "pd-deploy.resources-path")
 	replace := getConfigBoolWithFallback(cmd, "replace", "pd-deploy.replace")
 	fullSync := getConfigBoolWithFallback(cmd, "full-sync", "pd-deploy.full-sync")
+	createOnly := getConfigBoolWithFallback(cmd, "create-only", "pd-deploy.create-only")
 	dryRun := getConfigBoolWithFallback(cmd, "dry-run", "pd-deploy.dry-run")
+	batch := getConfigBoolWithFallback(cmd, "batch", "pd-deploy.batch")
+	strictConcurrency := getConfigBoolWithFallback(cmd, "strict-concurrency", "pd-deploy.strict-concurrency")
 	pids := getConfigStringSliceWithFallback(cmd, "pids", "pd-deploy.pids")
+	extraContentTypes := getConfigStringSliceWithFallback(cmd, "extra-content-types", "pd-deploy.extra-content-types")
+	contentTypeCheck := getConfigStringWithFallback(cmd, "content-type-check", "pd-deploy.content-type-check")
+	decompress := getConfigBoolWithFallback(cmd, "decompress", "pd-deploy.decompress")
+
+	if contentTypeCheck != contentTypeCheckWarn && contentTypeCheck != contentTypeCheckStrict {
+		return fmt.Errorf("invalid content type check %q: must be '%s' or '%s'", contentTypeCheck, contentTypeCheckWarn, contentTypeCheckStrict)
+	}
+
+	retryPolicy, err := getRetryPolicy(cmd, "pd-deploy")
+	if err != nil {
+		return err
+	}
 
 	log.Info().Msgf("Resources Path: %s", resourcesPath)
 	log.Info().Msgf("Replace Mode: %v", replace)
 	log.Info().Msgf("Full Sync Mode: %v", fullSync)
+	log.Info().Msgf("Create Only Mode: %v", createOnly)
 	log.Info().Msgf("Dry Run: %v", dryRun)
+	log.Info().Msgf("Batch Mode: %v", batch)
+	log.Info().Msgf("Strict Concurrency: %v", strictConcurrency)
+	log.Info().Msgf("Content Type Check: %s", contentTypeCheck)
+	log.Info().Msgf("Decompress: %v", decompress)
 	if len(pids) > 0 {
 		log.Info().Msgf("Filter PIDs: %v", pids)
 	}
@@ -112,15 +161,27 @@ func runPDDeploy(cmd *cobra.Command) error {
 
 	// Initialise Partner Directory API
 	pdAPI := api.NewPartnerDirectory(exe)
+	pdAPI.SetRetryPolicy(retryPolicy)
+	pdAPI.SetStrictConcurrency(strictConcurrency)
 
 	// Initialise Partner Directory Repository
 	pdRepo := repo.NewPartnerDirectory(resourcesPath)
+	if len(extraContentTypes) > 0 {
+		log.Info().Msgf("Extra Content Types: %v", extraContentTypes)
+		pdRepo.AddContentTypes(extraContentTypes)
+	}
+	pdRepo.Decompress = decompress
 
 	// Trim PIDs
 	pids = str.TrimSlice(pids)
 
+	// Validate binary content types before any upload
+	if err := checkBinaryContentTypes(pdRepo, pids, contentTypeCheck == contentTypeCheckStrict); err != nil {
+		return err
+	}
+
 	// Execute deploy
-	if err := deployPartnerDirectory(pdAPI, pdRepo, replace, fullSync, dryRun, pids); err != nil {
+	if err := deployPartnerDirectory(pdAPI, pdRepo, replace, fullSync, createOnly, dryRun, batch, pids); err != nil {
 		return err
 	}
 
@@ -128,9 +189,22 @@ func runPDDeploy(cmd *cobra.Command) error {
 	return nil
 }
 
-func deployPartnerDirectory(pdAPI *api.PartnerDirectory, pdRepo *repo.PartnerDirectory, replace bool, fullSync bool, dryRun bool, pidsFilter []string) error {
+func deployPartnerDirectory(pdAPI *api.PartnerDirectory, pdRepo *repo.PartnerDirectory, replace bool, fullSync bool, createOnly bool, dryRun bool, batch bool, pidsFilter []string) error {
 	log.Info().Msg("Starting Partner Directory Deploy...")
 
+	// Create-only mode takes precedence over replace and full sync
+	if createOnly {
+		if replace {
+			log.Info().Msg("Create-only mode: ignoring --replace, existing parameters will not be updated")
+		}
+		if fullSync {
+			log.Warn().Msg("Create-only mode: ignoring --full-sync, remote parameters will not be deleted")
+		}
+		replace = false
+		fullSync = false
+		log.Info().Msg("Effective mode: create missing parameters only (no updates, no deletions)")
+	}
+
 	// Get locally managed PIDs
 	managedPIDs, err := pdRepo.GetLocalPIDs()
 	if err != nil {
@@ -159,13 +233,13 @@ func deployPartnerDirectory(pdAPI *api.PartnerDirectory, pdRepo *repo.PartnerDir
 	}
 
 	// Push string parameters
-	stringResults, err := deployStringParameters(pdAPI, pdRepo, replace, dryRun, pidsFilter)
+	stringResults, err := deployStringParameters(pdAPI, pdRepo, replace, dryRun, batch, pidsFilter)
 	if err != nil {
 		return fmt.Errorf("failed to deploy string parameters: %w", err)
 	}
 
 	// Push binary parameters
-	binaryResults, err := deployBinaryParameters(pdAPI, pdRepo, replace, dryRun, pidsFilter)
+	binaryResults, err := deployBinaryParameters(pdAPI, pdRepo, replace, dryRun, batch, pidsFilter)
 	if err != nil {
 		return fmt.Errorf("failed to deploy binary parameters: %w", err)
 	}
@@ -174,22 +248,22 @@ func deployPartnerDirectory(pdAPI *api.PartnerDirectory, pdRepo *repo.PartnerDir
 	var deletionResults *api.BatchResult
 	if fullSync && !dryRun {
 		log.Info().Msg("Executing full sync - deleting remote entries not present locally...")
-		deletionResults, err = deleteRemoteEntriesNotInLocal(pdAPI, pdRepo, managedPIDs)
+		deletionResults, err = deleteRemoteEntriesNotInLocal(pdAPI, pdRepo, managedPIDs, batch)
 		if err != nil {
 			log.Warn().Msgf("Error during full sync deletion: %v", err)
-		} else {
-			log.Info().Msgf("Parameters Deleted: %d", len(deletionResults.Deleted))
-			if len(deletionResults.Deleted) > 0 {
-				log.Info().Msg("Deleted parameters:")
-				for _, deleted := range deletionResults.Deleted {
-					log.Info().Msgf("  - %s", deleted)
-				}
+		}
+		// Parameters deleted before an error are reported as well
+		log.Info().Msgf("Parameters Deleted: %d", len(deletionResults.Deleted))
+		if len(deletionResults.Deleted) > 0 {
+			log.Info().Msg("Deleted parameters:")
+			for _, deleted := range deletionResults.Deleted {
+				log.Info().Msgf("  - %s", deleted)
 			}
-			if len(deletionResults.Errors) > 0 {
-				log.Info().Msgf("Deletion Errors: %d", len(deletionResults.Errors))
-				for _, err := range deletionResults.Errors {
-					log.Warn().Msg(err)
-				}
+		}
+		if len(deletionResults.Errors) > 0 {
+			log.Info().Msgf("Deletion Errors: %d", len(deletionResults.Errors))
+			for _, err := range deletionResults.Errors {
+				log.Warn().Msg(err)
 			}
 		}
 	} else if fullSync && dryRun {
@@ -199,10 +273,10 @@ func deployPartnerDirectory(pdAPI *api.PartnerDirectory, pdRepo *repo.PartnerDir
 	}
 
 	// Log summary
-	log.Info().Msgf("String Parameters - Created: %d, Updated: %d, Unchanged: %d, Errors: %d",
-		len(stringResults.Created), len(stringResults.Updated), len(stringResults.Unchanged), len(stringResults.Errors))
-	log.Info().Msgf("Binary Parameters - Created: %d, Updated: %d, Unchanged: %d, Errors: %d",
-		len(binaryResults.Created), len(binaryResults.Updated), len(binaryResults.Unchanged), len(binaryResults.Errors))
+	log.Info().Msgf("String Parameters - Created: %d, Updated: %d, Unchanged: %d, Conflicts: %d, Errors: %d",
+		len(stringResults.Created), len(stringResults.Updated), len(stringResults.Unchanged), len(stringResults.Conflicts), len(stringResults.Errors))
+	log.Info().Msgf("Binary Parameters - Created: %d, Updated: %d, Unchanged: %d, Conflicts: %d, Errors: %d",
+		len(binaryResults.Created), len(binaryResults.Updated), len(binaryResults.Unchanged), len(binaryResults.Conflicts), len(binaryResults.Errors))
 
 	if fullSync && deletionResults != nil {
 		log.Info().Msgf("Full Sync - Deleted: %d, Errors: %d",
@@ -212,6 +286,16 @@ func deployPartnerDirectory(pdAPI *api.PartnerDirectory, pdRepo *repo.PartnerDir
 		}
 	}
 
+	if len(stringResults.Conflicts) > 0 || len(binaryResults.Conflicts) > 0 {
+		log.Warn().Msg("Parameters changed on the tenant since they were read (not updated):")
+		for _, key := range stringResults.Conflicts {
+			log.Warn().Msgf("String: %s", key)
+		}
+		for _, key := range binaryResults.Conflicts {
+			log.Warn().Msgf("Binary: %s", key)
+		}
+	}
+
 	if len(stringResults.Errors) > 0 || len(binaryResults.Errors) > 0 {
 		log.Warn().Msg("Errors encountered during deploy:")
 		for _, err := range stringResults.Errors {
@@ -229,7 +313,7 @@ func deployPartnerDirectory(pdAPI *api.PartnerDirectory, pdRepo *repo.PartnerDir
 	return nil
 }
 
-func deployStringParameters(pdAPI *api.PartnerDirectory, pdRepo *repo.PartnerDirectory, replace bool, dryRun bool, pidsFilter []string) (*api.BatchResult, error) {
+func deployStringParameters(pdAPI *api.PartnerDirectory, pdRepo *repo.PartnerDirectory, replace bool, dryRun bool, batch bool, pidsFilter []string) (*api.BatchResult, error) {
 	log.Debug().Msg("Loading string parameters from local files")
 
 	// Get local PIDs
@@ -248,8 +332,12 @@ func deployStringParameters(pdAPI *api.PartnerDirectory, pdRepo *repo.PartnerDir
 		Updated:   []string{},
 		Unchanged: []string{},
 		Errors:    []string{},
+		Conflicts: []string{},
 	}
 
+	// Parameters collected for a single batch sync
+	var batchParams []api.StringParameter
+
 	// Load and deploy parameters for each PID
 	for _, pid := range localPIDs {
 		parameters, err := pdRepo.ReadStringParameters(pid)
@@ -258,6 +346,11 @@ func deployStringParameters(pdAPI *api.PartnerDirectory, pdRepo *repo.PartnerDir
 			continue
 		}
 
+		if batch && !dryRun {
+			batchParams = append(batchParams, parameters...)
+			continue
+		}
+
 		for _, param := range parameters {
 			key := fmt.Sprintf("%s/%s", param.Pid, param.ID)
 
@@ -272,7 +365,7 @@ func deployStringParameters(pdAPI *api.PartnerDirectory, pdRepo *repo.PartnerDir
 				if existing == nil {
 					results.Created = append(results.Created, key)
 					log.Info().Msgf("[DRY RUN] Would create: %s", key)
-				} else if replace && existing.Value != param.Value {
+				} else if replace && stringParameterChanged(existing, param) {
 					results.Updated = append(results.Updated, key)
 					log.Info().Msgf("[DRY RUN] Would update: %s", key)
 				} else {
@@ -296,9 +389,12 @@ func deployStringParameters(pdAPI *api.PartnerDirectory, pdRepo *repo.PartnerDir
 					results.Created = append(results.Created, key)
 					log.Debug().Msgf("Created: %s", key)
 				}
-			} else if replace && existing.Value != param.Value {
+			} else if replace && stringParameterChanged(existing, param) {
 				// Update existing parameter
-				if err := pdAPI.UpdateStringParameter(param); err != nil {
+				param.ETag = existing.ETag
+				if err := pdAPI.UpdateStringParameter(param); errors.Is(err, api.ErrConflict) {
+					results.Conflicts = append(results.Conflicts, key)
+				} else if err != nil {
 					results.Errors = append(results.Errors, fmt.Sprintf("%s: %v", key, err))
 				} else {
 					results.Updated = append(results.Updated, key)
@@ -310,10 +406,18 @@ func deployStringParameters(pdAPI *api.PartnerDirectory, pdRepo *repo.PartnerDir
 		}
 	}
 
+	if len(batchParams) > 0 {
+		batchResults, err := pdAPI.BatchSyncStringParameters(batchParams, api.DefaultBatchSize, replace)
+		if err != nil {
+			return nil, err
+		}
+		mergeBatchResults(results, batchResults)
+	}
+
 	return results, nil
 }
 
-func deployBinaryParameters(pdAPI *api.PartnerDirectory, pdRepo *repo.PartnerDirectory, replace bool, dryRun bool, pidsFilter []string) (*api.BatchResult, error) {
+func deployBinaryParameters(pdAPI *api.PartnerDirectory, pdRepo *repo.PartnerDirectory, replace bool, dryRun bool, batch bool, pidsFilter []string) (*api.BatchResult, error) {
 	log.Debug().Msg("Loading binary parameters from local files")
 
 	// Get local PIDs
@@ -332,8 +436,12 @@ func deployBinaryParameters(pdAPI *api.PartnerDirectory, pdRepo *repo.PartnerDir
 		Updated:   []string{},
 		Unchanged: []string{},
 		Errors:    []string{},
+		Conflicts: []string{},
 	}
 
+	// Parameters collected for a single batch sync
+	var batchParams []api.BinaryParameter
+
 	// Load and deploy parameters for each PID
 	for _, pid := range localPIDs {
 		parameters, err := pdRepo.ReadBinaryParameters(pid)
@@ -342,6 +450,11 @@ func deployBinaryParameters(pdAPI *api.PartnerDirectory, pdRepo *repo.PartnerDir
 			continue
 		}
 
+		if batch && !dryRun {
+			batchParams = append(batchParams, parameters...)
+			continue
+		}
+
 		for _, param := range parameters {
 			key := fmt.Sprintf("%s/%s", param.Pid, param.ID)
 
@@ -356,7 +469,7 @@ func deployBinaryParameters(pdAPI *api.PartnerDirectory, pdRepo *repo.PartnerDir
 				if existing == nil {
 					results.Created = append(results.Created, key)
 					log.Info().Msgf("[DRY RUN] Would create: %s", key)
-				} else if replace && existing.Value != param.Value {
+				} else if replace && api.BinaryParameterChanged(existing, param) {
 					results.Updated = append(results.Updated, key)
 					log.Info().Msgf("[DRY RUN] Would update: %s", key)
 				} else {
@@ -380,9 +493,12 @@ func deployBinaryParameters(pdAPI *api.PartnerDirectory, pdRepo *repo.PartnerDir
 					results.Created = append(results.Created, key)
 					log.Debug().Msgf("Created: %s", key)
 				}
-			} else if replace && existing.Value != param.Value {
+			} else if replace && api.BinaryParameterChanged(existing, param) {
 				// Update existing parameter
-				if err := pdAPI.UpdateBinaryParameter(param); err != nil {
+				param.ETag = existing.ETag
+				if err := pdAPI.UpdateBinaryParameter(param); errors.Is(err, api.ErrConflict) {
+					results.Conflicts = append(results.Conflicts, key)
+				} else if err != nil {
 					results.Errors = append(results.Errors, fmt.Sprintf("%s: %v", key, err))
 				} else {
 					results.Updated = append(results.Updated, key)
@@ -394,10 +510,61 @@ func deployBinaryParameters(pdAPI *api.PartnerDirectory, pdRepo *repo.PartnerDir
 		}
 	}
 
+	if len(batchParams) > 0 {
+		batchResults, err := pdAPI.BatchSyncBinaryParameters(batchParams, api.DefaultBatchSize, replace)
+		if err != nil {
+			return nil, err
+		}
+		mergeBatchResults(results, batchResults)
+	}
+
 	return results, nil
 }
 
-func deleteRemoteEntriesNotInLocal(pdAPI *api.PartnerDirectory, pdRepo *repo.PartnerDirectory, managedPIDs []string) (*api.BatchResult, error) {
+// checkBinaryContentTypes verifies that local binary parameters use content types
+// accepted by SAP CPI. Unsupported types are logged as warnings, or returned as an
+// error when strict is set. Extra content types only control the file extensions
+// and are checked like any other content type.
+func checkBinaryContentTypes(pdRepo *repo.PartnerDirectory, pidsFilter []string, strict bool) error {
+	localPIDs, err := pdRepo.GetLocalPIDs()
+	if err != nil {
+		return fmt.Errorf("failed to get local PIDs: %w", err)
+	}
+	if len(pidsFilter) > 0 {
+		localPIDs = filterPIDs(localPIDs, pidsFilter)
+	}
+
+	var unsupported []string
+	for _, pid := range localPIDs {
+		parameters, err := pdRepo.ReadBinaryParameters(pid)
+		if err != nil {
+			// Read errors are reported by deployBinaryParameters
+			continue
+		}
+		for _, param := range parameters {
+			if !repo.IsAcceptedContentType(param.ContentType) {
+				entry := fmt.Sprintf("%s/%s (%s)", param.Pid, param.ID, param.ContentType)
+				unsupported = append(unsupported, entry)
+				log.Warn().Msgf("Unsupported binary content type: %s", entry)
+			}
+		}
+	}
+
+	if len(unsupported) > 0 && strict {
+		return fmt.Errorf("%d binary parameter(s) have unsupported content types: %s",
+			len(unsupported), strings.Join(unsupported, ", "))
+	}
+	return nil
+}
+
+// stringParameterChanged reports whether the local value differs from the existing remote parameter
+func stringParameterChanged(existing *api.StringParameter, local api.StringParameter) bool {
+	return existing.Value != local.Value
+}
+
+// deleteRemoteEntriesNotInLocal deletes the remote parameters of the managed PIDs that do not exist
+// locally. The results are also returned with an error, listing the parameters deleted until then.
+func deleteRemoteEntriesNotInLocal(pdAPI *api.PartnerDirectory, pdRepo *repo.PartnerDirectory, managedPIDs []string, batch bool) (*api.BatchResult, error) {
 	results := &api.BatchResult{
 		Deleted: []string{},
 		Errors:  []string{},
@@ -438,16 +605,21 @@ func deleteRemoteEntriesNotInLocal(pdAPI *api.PartnerDirectory, pdRepo *repo.Par
 	// Get all remote string parameters
 	remoteStringParams, err := pdAPI.GetStringParameters("Pid,Id")
 	if err != nil {
-		return nil, fmt.Errorf("failed to get remote string parameters: %w", err)
+		return results, fmt.Errorf("failed to get remote string parameters: %w", err)
 	}
 
 	// Delete string parameters not in local for managed PIDs
+	var stringToDelete []struct{ Pid, ID string }
 	for _, param := range remoteStringParams {
 		if !contains(managedPIDs, param.Pid) {
 			continue // Skip PIDs we don't manage
 		}
 
 		if localStringParams[param.Pid] == nil || !localStringParams[param.Pid][param.ID] {
+			if batch {
+				stringToDelete = append(stringToDelete, struct{ Pid, ID string }{Pid: param.Pid, ID: param.ID})
+				continue
+			}
 			key := fmt.Sprintf("%s/%s", param.Pid, param.ID)
 			if err := pdAPI.DeleteStringParameter(param.Pid, param.ID); err != nil {
 				results.Errors = append(results.Errors, fmt.Sprintf("Failed to delete string %s: %v", key, err))
@@ -458,19 +630,32 @@ func deleteRemoteEntriesNotInLocal(pdAPI *api.PartnerDirectory, pdRepo *repo.Par
 		}
 	}
 
+	if len(stringToDelete) > 0 {
+		batchResults, err := pdAPI.BatchDeleteStringParameters(stringToDelete, api.DefaultBatchSize)
+		if err != nil {
+			return results, fmt.Errorf("failed to delete remote string parameters: %w", err)
+		}
+		mergeBatchResults(results, batchResults)
+	}
+
 	// Get all remote binary parameters
 	remoteBinaryParams, err := pdAPI.GetBinaryParameters("Pid,Id")
 	if err != nil {
-		return nil, fmt.Errorf("failed to get remote binary parameters: %w", err)
+		return results, fmt.Errorf("failed to get remote binary parameters: %w", err)
 	}
 
 	// Delete binary parameters not in local for managed PIDs
+	var binaryToDelete []struct{ Pid, ID string }
 	for _, param := range remoteBinaryParams {
 		if !contains(managedPIDs, param.Pid) {
 			continue // Skip PIDs we don't manage
 		}
 
 		if localBinaryParams[param.Pid] == nil || !localBinaryParams[param.Pid][param.ID] {
+			if batch {
+				binaryToDelete = append(binaryToDelete, struct{ Pid, ID string }{Pid: param.Pid, ID: param.ID})
+				continue
+			}
 			key := fmt.Sprintf("%s/%s", param.Pid, param.ID)
 			if err := pdAPI.DeleteBinaryParameter(param.Pid, param.ID); err != nil {
 				results.Errors = append(results.Errors, fmt.Sprintf("Failed to delete binary %s: %v", key, err))
@@ -481,6 +666,14 @@ func deleteRemoteEntriesNotInLocal(pdAPI *api.PartnerDirectory, pdRepo *repo.Par
 		}
 	}
 
+	if len(binaryToDelete) > 0 {
+		batchResults, err := pdAPI.BatchDeleteBinaryParameters(binaryToDelete, api.DefaultBatchSize)
+		if err != nil {
+			return results, fmt.Errorf("failed to delete remote binary parameters: %w", err)
+		}
+		mergeBatchResults(results, batchResults)
+	}
+
 	return results, nil
 }
 
@@ -497,3 +690,13 @@ func filterPIDs(pids []string, filter []string) []string {
 	}
 	return result
 }
+
+// mergeBatchResults appends the outcome of a batch operation to the overall results
+func mergeBatchResults(results *api.BatchResult, batchResults *api.BatchResult) {
+	results.Created = append(results.Created, batchResults.Created...)
+	results.Updated = append(results.Updated, batchResults.Updated...)
+	results.Unchanged = append(results.Unchanged, batchResults.Unchanged...)
+	results.Deleted = append(results.Deleted, batchResults.Deleted...)
+	results.Errors = append(results.Errors, batchResults.Errors...)
+	results.Conflicts = append(results.Conflicts, batchResults.Conflicts...)
+}
@@ -0,0 +1,126 @@
+package cmd
+
+import (
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"strings"
+	"sync"
+	"testing"
+
+	"github.com/engswee/flashpipe/internal/api"
+	"github.com/engswee/flashpipe/internal/httpclnt"
+	"github.com/engswee/flashpipe/internal/repo"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestCheckBinaryContentTypes(t *testing.T) {
+	tempDir, err := os.MkdirTemp("", "pd-deploy-test-*")
+	require.NoError(t, err)
+	defer os.RemoveAll(tempDir)
+
+	binaryDir := filepath.Join(tempDir, "PID1", "Binary")
+	require.NoError(t, os.MkdirAll(binaryDir, 0755))
+	require.NoError(t, os.WriteFile(filepath.Join(binaryDir, "mapping.xml"), []byte("<a/>"), 0644))
+	require.NoError(t, os.WriteFile(filepath.Join(binaryDir, "keystore.pem"), []byte("key"), 0644))
+
+	pdRepo := repo.NewPartnerDirectory(tempDir)
+
+	// Warn mode never fails
+	assert.NoError(t, checkBinaryContentTypes(pdRepo, nil, false))
+
+	// Strict mode fails on the unsupported pem file
+	err = checkBinaryContentTypes(pdRepo, nil, true)
+	require.Error(t, err)
+	assert.Contains(t, err.Error(), "PID1/keystore (pem)")
+	assert.NotContains(t, err.Error(), "mapping")
+
+	// Registered extra content types only control the file extension and are still checked
+	pdRepo.AddContentTypes([]string{"pem"})
+	err = checkBinaryContentTypes(pdRepo, nil, true)
+	require.Error(t, err)
+	assert.Contains(t, err.Error(), "PID1/keystore (pem)")
+
+	// PIDs outside the filter are not checked
+	assert.NoError(t, checkBinaryContentTypes(repo.NewPartnerDirectory(tempDir), []string{"OTHER"}, true))
+}
+
+func TestDeployPartnerDirectory_CreateOnly(t *testing.T) {
+	tempDir, err := os.MkdirTemp("", "pd-deploy-test-*")
+	require.NoError(t, err)
+	defer os.RemoveAll(tempDir)
+
+	pdRepo := repo.NewPartnerDirectory(tempDir)
+	_, err = pdRepo.WriteStringParameters("PID1", []api.StringParameter{
+		{Pid: "PID1", ID: "Existing", Value: "local"},
+		{Pid: "PID1", ID: "Added", Value: "new"},
+	}, true)
+	require.NoError(t, err)
+
+	var mu sync.Mutex
+	var requests []string
+	svr := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		mu.Lock()
+		requests = append(requests, r.Method+" "+r.URL.Path)
+		mu.Unlock()
+
+		switch {
+		case r.Method == http.MethodPost:
+			w.WriteHeader(http.StatusCreated)
+		case strings.Contains(r.URL.Path, "Id='Existing'"):
+			fmt.Fprint(w, `{"d":{"Pid":"PID1","Id":"Existing","Value":"remote"}}`)
+		default:
+			http.NotFound(w, r)
+		}
+	}))
+	defer svr.Close()
+	host, port := httpclnt.GetHostPort(svr.URL)
+	pdAPI := api.NewPartnerDirectory(httpclnt.New("", "", "", "", "user", "password", host, "http", port, false))
+
+	// Replace and full sync are requested but overridden by create-only
+	require.NoError(t, deployPartnerDirectory(pdAPI, pdRepo, true, true, true, false, false, nil))
+
+	for _, req := range requests {
+		assert.NotContains(t, req, http.MethodPut)
+		assert.NotContains(t, req, http.MethodDelete)
+	}
+	assert.Contains(t, requests, "POST /api/v1/StringParameters")
+	// Full sync would list all remote string parameters
+	assert.NotContains(t, requests, "GET /api/v1/StringParameters")
+}
+
+func TestDeleteRemoteEntriesNotInLocal_PartialResults(t *testing.T) {
+	tempDir, err := os.MkdirTemp("", "pd-deploy-test-*")
+	require.NoError(t, err)
+	defer os.RemoveAll(tempDir)
+
+	pdRepo := repo.NewPartnerDirectory(tempDir)
+	_, err = pdRepo.WriteStringParameters("PID1", []api.StringParameter{
+		{Pid: "PID1", ID: "Kept", Value: "local"},
+	}, true)
+	require.NoError(t, err)
+
+	svr := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		switch {
+		case r.Method == http.MethodDelete:
+			w.WriteHeader(http.StatusNoContent)
+		case r.URL.Path == "/api/v1/StringParameters":
+			fmt.Fprint(w, `{"d":{"results":[{"Pid":"PID1","Id":"Kept"},{"Pid":"PID1","Id":"Removed"}]}}`)
+		default:
+			// Listing the binary parameters fails after the string parameters were deleted
+			w.WriteHeader(http.StatusBadRequest)
+		}
+	}))
+	defer svr.Close()
+	host, port := httpclnt.GetHostPort(svr.URL)
+	pdAPI := api.NewPartnerDirectory(httpclnt.New("", "", "", "", "user", "password", host, "http", port, false))
+
+	results, err := deleteRemoteEntriesNotInLocal(pdAPI, pdRepo, []string{"PID1"}, false)
+	require.Error(t, err)
+	assert.Contains(t, err.Error(), "failed to get remote binary parameters")
+	require.NotNil(t, results)
+	assert.Equal(t, []string{"PID1/Removed"}, results.Deleted)
+}
@@ -0,0 +1,298 @@
+package cmd
+
+import (
+	"encoding/json"
+	"fmt"
+	"io"
+	"sort"
+	"text/tabwriter"
+	"time"
+
+	"github.com/engswee/flashpipe/internal/analytics"
+	"github.com/engswee/flashpipe/internal/api"
+	"github.com/engswee/flashpipe/internal/repo"
+	"github.com/engswee/flashpipe/internal/str"
+	"github.com/rs/zerolog/log"
+	"github.com/spf13/cobra"
+)
+
+// PDParameterDiff groups parameter IDs of one type by how they compare between local and remote
+type PDParameterDiff struct {
+	OnlyLocal  []string `json:"onlyLocal"`
+	OnlyRemote []string `json:"onlyRemote"`
+	Different  []string `json:"different"`
+	Identical  []string `json:"identical"`
+}
+
+// PDPidDiff is the comparison result for a single Partner ID
+type PDPidDiff struct {
+	Pid    string          `json:"pid"`
+	String PDParameterDiff `json:"string"`
+	Binary PDParameterDiff `json:"binary"`
+}
+
+func NewPDDiffCommand() *cobra.Command {
+
+	pdDiffCmd := &cobra.Command{
+		Use:   "pd-diff",
+		Short: "Preview differences between local partner directory files and SAP CPI",
+		Long: `Compare partner directory parameters in local files with SAP CPI without making changes.
+
+For each locally managed Partner ID, parameters are reported as:
+  - Only local:   would be created by pd-deploy
+  - Only remote:  would be deleted by pd-deploy --full-sync
+  - Different:    would be updated by pd-deploy
+  - Identical:    no change
+
+Authentication is performed using OAuth 2.0 client credentials flow or Basic Auth.`,
+		Example: `  # Show differences for all local PIDs
+  flashpipe pd-diff --resources-path "./partner-directory"
+
+  # Show differences for specific PIDs as JSON
+  flashpipe pd-diff --pids "SAP_SYSTEM_001,CUSTOMER_API" --output json`,
+		RunE: func(cmd *cobra.Command, args []string) (err error) {
+			startTime := time.Now()
+			if err = runPDDiff(cmd); err != nil {
+				cmd.SilenceUsage = true
+			}
+			analytics.Log(cmd, err, startTime)
+			return
+		},
+	}
+
+	// Define flags
+	// Note: These can be set in config file under 'pd-diff' key
+	pdDiffCmd.Flags().String("resources-path", "./partner-directory",
+		"Path to partner directory parameters")
+	pdDiffCmd.Flags().StringSlice("pids", nil,
+		"Comma separated list of Partner IDs to compare (e.g., 'PID1,PID2')")
+	pdDiffCmd.Flags().String("output", "table",
+		"Output format: 'table' or 'json'")
+	pdDiffCmd.Flags().StringSlice("extra-content-types", nil,
+		"Comma separated list of additional binary content types to preserve as file extensions (e.g., 'pem,p12')")
+	addRetryFlags(pdDiffCmd)
+
+	return pdDiffCmd
+}
+
+func runPDDiff(cmd *cobra.Command) error {
+	serviceDetails := api.GetServiceDetails(cmd)
+
+	log.Info().Msg("Executing Partner Directory Diff command")
+
+	resourcesPath := getConfigStringWithFallback(cmd, "resources-path", "pd-diff.resources-path")
+	pids := getConfigStringSliceWithFallback(cmd, "pids", "pd-diff.pids")
+	output := getConfigStringWithFallback(cmd, "output", "pd-diff.output")
+	extraContentTypes := getConfigStringSliceWithFallback(cmd, "extra-content-types", "pd-diff.extra-content-types")
+
+	if output != "table" && output != "json" {
+		return fmt.Errorf("invalid output format %q: must be 'table' or 'json'", output)
+	}
+
+	retryPolicy, err := getRetryPolicy(cmd, "pd-diff")
+	if err != nil {
+		return err
+	}
+
+	log.Info().Msgf("Resources Path: %s", resourcesPath)
+	if len(pids) > 0 {
+		log.Info().Msgf("Filter PIDs: %v", pids)
+	}
+
+	// Initialise HTTP executer
+	exe := api.InitHTTPExecuter(serviceDetails)
+
+	// Initialise Partner Directory API
+	pdAPI := api.NewPartnerDirectory(exe)
+	pdAPI.SetRetryPolicy(retryPolicy)
+
+	// Initialise Partner Directory Repository
+	pdRepo := repo.NewPartnerDirectory(resourcesPath)
+	if len(extraContentTypes) > 0 {
+		pdRepo.AddContentTypes(extraContentTypes)
+	}
+
+	diffs, err := diffPartnerDirectory(pdAPI, pdRepo, str.TrimSlice(pids))
+	if err != nil {
+		return err
+	}
+
+	if output == "json" {
+		return writePDDiffJSON(cmd.OutOrStdout(), diffs)
+	}
+	writePDDiffTable(cmd.OutOrStdout(), diffs)
+	return nil
+}
+
+func diffPartnerDirectory(pdAPI *api.PartnerDirectory, pdRepo *repo.PartnerDirectory, pidsFilter []string) ([]PDPidDiff, error) {
+	managedPIDs, err := pdRepo.GetLocalPIDs()
+	if err != nil {
+		return nil, fmt.Errorf("failed to get local PIDs: %w", err)
+	}
+	if len(pidsFilter) > 0 {
+		managedPIDs = filterPIDs(managedPIDs, pidsFilter)
+		if len(managedPIDs) == 0 {
+			return nil, fmt.Errorf("no PIDs match the filter: %v", pidsFilter)
+		}
+	}
+
+	remoteStringParams, err := pdAPI.GetStringParameters("Pid,Id,Value")
+	if err != nil {
+		return nil, fmt.Errorf("failed to get remote string parameters: %w", err)
+	}
+	remoteBinaryParams, err := pdAPI.GetBinaryParameters("")
+	if err != nil {
+		return nil, fmt.Errorf("failed to get remote binary parameters: %w", err)
+	}
+
+	remoteStringByPid := make(map[string][]api.StringParameter)
+	for _, param := range remoteStringParams {
+		remoteStringByPid[param.Pid] = append(remoteStringByPid[param.Pid], param)
+	}
+	remoteBinaryByPid := make(map[string][]api.BinaryParameter)
+	for _, param := range remoteBinaryParams {
+		remoteBinaryByPid[param.Pid] = append(remoteBinaryByPid[param.Pid], param)
+	}
+
+	var diffs []PDPidDiff
+	for _, pid := range managedPIDs {
+		localString, err := pdRepo.ReadStringParameters(pid)
+		if err != nil {
+			return nil, fmt.Errorf("failed to read string parameters for PID %s: %w", pid, err)
+		}
+		localBinary, err := pdRepo.ReadBinaryParameters(pid)
+		if err != nil {
+			return nil, fmt.Errorf("failed to read binary parameters for PID %s: %w", pid, err)
+		}
+
+		diffs = append(diffs, PDPidDiff{
+			Pid:    pid,
+			String: diffStringParameters(localString, remoteStringByPid[pid]),
+			Binary: diffBinaryParameters(localBinary, remoteBinaryByPid[pid]),
+		})
+	}
+
+	return diffs, nil
+}
+
+// diffStringParameters compares local and remote string parameters of a single PID
+func diffStringParameters(local, remote []api.StringParameter) PDParameterDiff {
+	remoteByID := make(map[string]*api.StringParameter)
+	for i := range remote {
+		remoteByID[remote[i].ID] = &remote[i]
+	}
+
+	diff := newPDParameterDiff()
+	localIDs := make(map[string]bool)
+	for _, param := range local {
+		localIDs[param.ID] = true
+		existing := remoteByID[param.ID]
+		if existing == nil {
+			diff.OnlyLocal = append(diff.OnlyLocal, param.ID)
+		} else if stringParameterChanged(existing, param) {
+			diff.Different = append(diff.Different, param.ID)
+		} else {
+			diff.Identical = append(diff.Identical, param.ID)
+		}
+	}
+	for _, param := range remote {
+		if !localIDs[param.ID] {
+			diff.OnlyRemote = append(diff.OnlyRemote, param.ID)
+		}
+	}
+
+	diff.sort()
+	return diff
+}
+
+// diffBinaryParameters compares local and remote binary parameters of a single PID
+func diffBinaryParameters(local, remote []api.BinaryParameter) PDParameterDiff {
+	remoteByID := make(map[string]*api.BinaryParameter)
+	for i := range remote {
+		remoteByID[remote[i].ID] = &remote[i]
+	}
+
+	diff := newPDParameterDiff()
+	localIDs := make(map[string]bool)
+	for _, param := range local {
+		localIDs[param.ID] = true
+		existing := remoteByID[param.ID]
+		if existing == nil {
+			diff.OnlyLocal = append(diff.OnlyLocal, param.ID)
+		} else if api.BinaryParameterChanged(existing, param) {
+			diff.Different = append(diff.Different, param.ID)
+		} else {
+			diff.Identical = append(diff.Identical, param.ID)
+		}
+	}
+	for _, param := range remote {
+		if !localIDs[param.ID] {
+			diff.OnlyRemote = append(diff.OnlyRemote, param.ID)
+		}
+	}
+
+	diff.sort()
+	return diff
+}
+
+func newPDParameterDiff() PDParameterDiff {
+	return PDParameterDiff{
+		OnlyLocal:  []string{},
+		OnlyRemote: []string{},
+		Different:  []string{},
+		Identical:  []string{},
+	}
+}
+
+func (d *PDParameterDiff) sort() {
+	sort.Strings(d.OnlyLocal)
+	sort.Strings(d.OnlyRemote)
+	sort.Strings(d.Different)
+	sort.Strings(d.Identical)
+}
+
+func writePDDiffJSON(w io.Writer, diffs []PDPidDiff) error {
+	if diffs == nil {
+		diffs = []PDPidDiff{}
+	}
+	encoder := json.NewEncoder(w)
+	encoder.SetIndent("", "  ")
+	if err := encoder.Encode(diffs); err != nil {
+		return fmt.Errorf("failed to encode diff result: %w", err)
+	}
+	return nil
+}
+
+func writePDDiffTable(w io.Writer, diffs []PDPidDiff) {
+	tw := tabwriter.NewWriter(w, 0, 0, 2, ' ', 0)
+	fmt.Fprintln(tw, "PID\tTYPE\tPARAMETER\tSTATUS")
+	for _, diff := range diffs {
+		writePDDiffRows(tw, diff.Pid, "String", diff.String)
+		writePDDiffRows(tw, diff.Pid, "Binary", diff.Binary)
+	}
+	tw.Flush()
+
+	fmt.Fprintln(w)
+	tw = tabwriter.NewWriter(w, 0, 0, 2, ' ', 0)
+	fmt.Fprintln(tw, "PID\tCREATE\tUPDATE\tDELETE (FULL-SYNC)\tIDENTICAL")
+	for _, diff := range diffs {
+		fmt.Fprintf(tw, "%s\t%d\t%d\t%d\t%d\n", diff.Pid,
+			len(diff.String.OnlyLocal)+len(diff.Binary.OnlyLocal),
+			len(diff.String.Different)+len(diff.Binary.Different),
+			len(diff.String.OnlyRemote)+len(diff.Binary.OnlyRemote),
+			len(diff.String.Identical)+len(diff.Binary.Identical))
+	}
+	tw.Flush()
+}
+
+func writePDDiffRows(w io.Writer, pid, paramType string, diff PDParameterDiff) {
+	for _, id := range diff.OnlyLocal {
+		fmt.Fprintf(w, "%s\t%s\t%s\tonly local (create)\n", pid, paramType, id)
+	}
+	for _, id := range diff.Different {
+		fmt.Fprintf(w, "%s\t%s\t%s\tdiffers (update)\n", pid, paramType, id)
+	}
+	for _, id := range diff.OnlyRemote {
+		fmt.Fprintf(w, "%s\t%s\t%s\tonly remote (delete with full-sync)\n", pid, paramType, id)
+	}
+}
@@ -0,0 +1,64 @@
+package cmd
+
+import (
+	"bytes"
+	"encoding/json"
+	"testing"
+
+	"github.com/engswee/flashpipe/internal/api"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestDiffStringParameters(t *testing.T) {
+	local := []api.StringParameter{
+		{Pid: "PID1", ID: "New", Value: "n"},
+		{Pid: "PID1", ID: "Same", Value: "a"},
+		{Pid: "PID1", ID: "Changed", Value: "new"},
+	}
+	remote := []api.StringParameter{
+		{Pid: "PID1", ID: "Same", Value: "a"},
+		{Pid: "PID1", ID: "Changed", Value: "old"},
+		{Pid: "PID1", ID: "Obsolete", Value: "x"},
+	}
+
+	diff := diffStringParameters(local, remote)
+
+	assert.Equal(t, []string{"New"}, diff.OnlyLocal)
+	assert.Equal(t, []string{"Obsolete"}, diff.OnlyRemote)
+	assert.Equal(t, []string{"Changed"}, diff.Different)
+	assert.Equal(t, []string{"Same"}, diff.Identical)
+}
+
+func TestDiffBinaryParameters_NoRemote(t *testing.T) {
+	local := []api.BinaryParameter{
+		{Pid: "PID1", ID: "b", Value: "Yg==", ContentType: "txt"},
+		{Pid: "PID1", ID: "a", Value: "YQ==", ContentType: "txt"},
+	}
+
+	diff := diffBinaryParameters(local, nil)
+
+	assert.Equal(t, []string{"a", "b"}, diff.OnlyLocal)
+	assert.Empty(t, diff.OnlyRemote)
+	assert.Empty(t, diff.Different)
+	assert.Empty(t, diff.Identical)
+}
+
+func TestWritePDDiffOutput(t *testing.T) {
+	diffs := []PDPidDiff{{
+		Pid:    "PID1",
+		String: diffStringParameters([]api.StringParameter{{Pid: "PID1", ID: "New", Value: "n"}}, nil),
+		Binary: diffBinaryParameters(nil, []api.BinaryParameter{{Pid: "PID1", ID: "Old", Value: "YQ=="}}),
+	}}
+
+	var jsonOut bytes.Buffer
+	require.NoError(t, writePDDiffJSON(&jsonOut, diffs))
+	var decoded []PDPidDiff
+	require.NoError(t, json.Unmarshal(jsonOut.Bytes(), &decoded))
+	assert.Equal(t, diffs, decoded)
+
+	var tableOut bytes.Buffer
+	writePDDiffTable(&tableOut, diffs)
+	assert.Contains(t, tableOut.String(), "only local (create)")
+	assert.Contains(t, tableOut.String(), "only remote (delete with full-sync)")
+}
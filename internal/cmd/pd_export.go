@@ -0,0 +1,140 @@
+package cmd
+
+import (
+	"fmt"
+	"os"
+	"time"
+
+	"github.com/engswee/flashpipe/internal/analytics"
+	"github.com/engswee/flashpipe/internal/file"
+	"github.com/engswee/flashpipe/internal/repo"
+	"github.com/engswee/flashpipe/internal/str"
+	"github.com/rs/zerolog/log"
+	"github.com/spf13/cobra"
+)
+
+func NewPDExportCommand() *cobra.Command {
+
+	pdExportCmd := &cobra.Command{
+		Use:   "pd-export",
+		Short: "Export local partner directory files to a zip archive",
+		Long: `Export the partner directory parameters of a local directory, e.g. written by
+pd-snapshot, to a single zip archive for backups or to move them between machines.
+
+The archive keeps the PID layout of the directory:
+
+  {PID}/
+    String.properties    - String parameters as key=value pairs
+    Binary/              - Binary parameters as individual files
+      {ParamId}.{ext}    - Binary parameter files
+      _metadata.json     - Content type metadata
+
+The parameters are read and written like pd-deploy and pd-snapshot do, so
+binary values are exported as they are stored on the tenant. The archive
+is restored with pd-import. No connection to SAP CPI is made.`,
+		Example: `  # Export all PIDs
+  flashpipe pd-export --resources-path "./partner-directory" --out pd.zip
+
+  # Export specific PIDs
+  flashpipe pd-export --pids "SAP_SYSTEM_001,CUSTOMER_API" --out pd.zip`,
+		RunE: func(cmd *cobra.Command, args []string) (err error) {
+			startTime := time.Now()
+			if err = runPDExport(cmd); err != nil {
+				cmd.SilenceUsage = true
+			}
+			analytics.Log(cmd, err, startTime)
+			return
+		},
+	}
+
+	// Define flags
+	// Note: These can be set in config file under 'pd-export' key
+	pdExportCmd.Flags().String("resources-path", "./partner-directory",
+		"Path to partner directory parameters")
+	pdExportCmd.Flags().String("out", "",
+		"Zip archive to write")
+	pdExportCmd.Flags().StringSlice("pids", nil,
+		"Comma separated list of Partner IDs to export (e.g., 'PID1,PID2')")
+	pdExportCmd.Flags().StringSlice("extra-content-types", nil,
+		"Comma separated list of additional binary content types to preserve as file extensions (e.g., 'pem,p12')")
+
+	return pdExportCmd
+}
+
+func runPDExport(cmd *cobra.Command) error {
+	log.Info().Msg("Executing Partner Directory Export command")
+
+	resourcesPath := getConfigStringWithFallback(cmd, "resources-path", "pd-export.resources-path")
+	out := getConfigStringWithFallback(cmd, "out", "pd-export.out")
+	pids := str.TrimSlice(getConfigStringSliceWithFallback(cmd, "pids", "pd-export.pids"))
+	extraContentTypes := getConfigStringSliceWithFallback(cmd, "extra-content-types", "pd-export.extra-content-types")
+
+	if out == "" {
+		return fmt.Errorf("--out is required (set via CLI flag or in config file under 'pd-export.out')")
+	}
+
+	log.Info().Msgf("Resources Path: %s", resourcesPath)
+	log.Info().Msgf("Archive: %s", out)
+	if len(pids) > 0 {
+		log.Info().Msgf("Filter PIDs: %v", pids)
+	}
+
+	pdRepo := repo.NewPartnerDirectory(resourcesPath)
+	if len(extraContentTypes) > 0 {
+		pdRepo.AddContentTypes(extraContentTypes)
+	}
+
+	count, err := exportPartnerDirectory(pdRepo, pids, extraContentTypes, out)
+	if err != nil {
+		return err
+	}
+
+	log.Info().Msgf("🏆 Exported %d PIDs to %s", count, out)
+	return nil
+}
+
+// exportPartnerDirectory writes the parameters of the local PIDs to a zip archive and returns
+// the number of exported PIDs. The parameters are written to a staging directory first, so that
+// only partner directory content ends up in the archive. The extra content types keep the file
+// extensions, from which the content types are restored on import.
+func exportPartnerDirectory(pdRepo *repo.PartnerDirectory, pidsFilter []string, extraContentTypes []string, out string) (int, error) {
+	pids, err := pdRepo.GetLocalPIDs()
+	if err != nil {
+		return 0, err
+	}
+	if len(pidsFilter) > 0 {
+		filtered := make([]string, 0)
+		for _, pid := range pids {
+			if contains(pidsFilter, pid) {
+				filtered = append(filtered, pid)
+			}
+		}
+		pids = filtered
+	}
+	if len(pids) == 0 {
+		return 0, fmt.Errorf("no PIDs found in %s", pdRepo.ResourcesPath)
+	}
+
+	stagingDir, err := os.MkdirTemp("", "flashpipe-pd-export-*")
+	if err != nil {
+		return 0, fmt.Errorf("failed to create staging directory: %w", err)
+	}
+	defer os.RemoveAll(stagingDir)
+	staging := repo.NewPartnerDirectory(stagingDir)
+	if len(extraContentTypes) > 0 {
+		staging.AddContentTypes(extraContentTypes)
+	}
+
+	for _, pid := range pids {
+		stringCount, binaryCount, err := copyPIDParameters(pdRepo, staging, pid, true)
+		if err != nil {
+			return 0, err
+		}
+		log.Info().Msgf("Exporting PID %s: %d string, %d binary parameters", pid, stringCount, binaryCount)
+	}
+
+	if err := file.ZipDir(stagingDir, out, false); err != nil {
+		return 0, fmt.Errorf("failed to write archive %s: %w", out, err)
+	}
+	return len(pids), nil
+}
@@ -0,0 +1,106 @@
+package cmd
+
+import (
+	"encoding/base64"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/engswee/flashpipe/internal/api"
+	"github.com/engswee/flashpipe/internal/repo"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestExportImportPartnerDirectory(t *testing.T) {
+	sourceDir := t.TempDir()
+	source := repo.NewPartnerDirectory(sourceDir)
+	extraContentTypes := []string{"pkcs12"}
+	source.AddContentTypes(extraContentTypes)
+
+	stringParams := []api.StringParameter{
+		{Pid: "PID1", ID: "Endpoint", Value: "https://example.com"},
+		{Pid: "PID1", ID: "key=with:separators", Value: "a=b"},
+	}
+	_, err := source.WriteStringParameters("PID1", stringParams, true)
+	require.NoError(t, err)
+	propertiesFile := filepath.Join(sourceDir, "PID1", "String.properties")
+	content, err := os.ReadFile(propertiesFile)
+	require.NoError(t, err)
+	require.NoError(t, os.WriteFile(propertiesFile, append([]byte("# Order system\n\n"), content...), 0644))
+
+	binaryParams := []api.BinaryParameter{
+		{Pid: "PID2", ID: "keystore", Value: base64.StdEncoding.EncodeToString([]byte{0x30, 0x82, 0x00}), ContentType: "pkcs12"},
+		{Pid: "PID2", ID: "mapping.xml", Value: base64.StdEncoding.EncodeToString([]byte("<a/>")), ContentType: "xml; encoding=UTF-8"},
+	}
+	_, err = source.WriteBinaryParameters("PID2", binaryParams, true)
+	require.NoError(t, err)
+	require.NoError(t, os.MkdirAll(filepath.Join(sourceDir, "PID3"), 0755))
+
+	archive := filepath.Join(t.TempDir(), "pd.zip")
+	count, err := exportPartnerDirectory(source, []string{"PID1", "PID2"}, extraContentTypes, archive)
+	require.NoError(t, err)
+	assert.Equal(t, 2, count)
+
+	targetDir := t.TempDir()
+	target := repo.NewPartnerDirectory(targetDir)
+	target.AddContentTypes(extraContentTypes)
+	count, err = importPartnerDirectory(archive, target, nil, true)
+	require.NoError(t, err)
+	assert.Equal(t, 2, count)
+
+	readStrings, err := target.ReadStringParameters("PID1")
+	require.NoError(t, err)
+	assert.ElementsMatch(t, stringParams, readStrings)
+	imported, err := os.ReadFile(filepath.Join(targetDir, "PID1", "String.properties"))
+	require.NoError(t, err)
+	assert.Contains(t, string(imported), "# Order system\n\n")
+
+	readBinaries, err := target.ReadBinaryParameters("PID2")
+	require.NoError(t, err)
+	assert.ElementsMatch(t, binaryParams, readBinaries)
+	assert.NoDirExists(t, filepath.Join(targetDir, "PID3"))
+}
+
+func TestImportPartnerDirectory_AddOnly(t *testing.T) {
+	source := repo.NewPartnerDirectory(t.TempDir())
+	_, err := source.WriteStringParameters("PID1", []api.StringParameter{
+		{Pid: "PID1", ID: "Existing", Value: "archive"},
+		{Pid: "PID1", ID: "Added", Value: "archive"},
+	}, true)
+	require.NoError(t, err)
+	archive := filepath.Join(t.TempDir(), "pd.zip")
+	_, err = exportPartnerDirectory(source, nil, nil, archive)
+	require.NoError(t, err)
+
+	target := repo.NewPartnerDirectory(t.TempDir())
+	_, err = target.WriteStringParameters("PID1", []api.StringParameter{{Pid: "PID1", ID: "Existing", Value: "local"}}, true)
+	require.NoError(t, err)
+
+	_, err = importPartnerDirectory(archive, target, nil, false)
+	require.NoError(t, err)
+
+	params, err := target.ReadStringParameters("PID1")
+	require.NoError(t, err)
+	assert.ElementsMatch(t, []api.StringParameter{
+		{Pid: "PID1", ID: "Added", Value: "archive"},
+		{Pid: "PID1", ID: "Existing", Value: "local"},
+	}, params)
+}
+
+func TestExportImportPartnerDirectory_Errors(t *testing.T) {
+	tempDir := t.TempDir()
+	notZip := filepath.Join(tempDir, "pd.txt")
+	require.NoError(t, os.WriteFile(notZip, []byte("not a zip"), 0644))
+	_, err := importPartnerDirectory(notZip, repo.NewPartnerDirectory(t.TempDir()), nil, true)
+	assert.ErrorContains(t, err, "is not a zip archive")
+
+	corrupt := filepath.Join(tempDir, "pd.zip")
+	require.NoError(t, os.WriteFile(corrupt, []byte("not a zip"), 0644))
+	_, err = importPartnerDirectory(corrupt, repo.NewPartnerDirectory(t.TempDir()), nil, true)
+	assert.ErrorContains(t, err, "failed to extract archive")
+
+	_, err = exportPartnerDirectory(repo.NewPartnerDirectory(t.TempDir()), nil, nil, filepath.Join(tempDir, "empty.zip"))
+	assert.ErrorContains(t, err, "no PIDs found")
+	assert.NoFileExists(t, filepath.Join(tempDir, "empty.zip"))
+}
@@ -0,0 +1,145 @@
+package cmd
+
+import (
+	"fmt"
+	"os"
+	"time"
+
+	"github.com/engswee/flashpipe/internal/analytics"
+	"github.com/engswee/flashpipe/internal/file"
+	"github.com/engswee/flashpipe/internal/repo"
+	"github.com/engswee/flashpipe/internal/str"
+	"github.com/rs/zerolog/log"
+	"github.com/spf13/cobra"
+)
+
+func NewPDImportCommand() *cobra.Command {
+
+	pdImportCmd := &cobra.Command{
+		Use:   "pd-import",
+		Short: "Import partner directory files from a zip archive",
+		Long: `Import the partner directory parameters of a zip archive written by pd-export
+into a local directory, keeping the PID layout of the archive.
+
+The import supports two modes:
+  - Replace mode (default): Overwrites existing local values
+  - Add-only mode: Only adds new parameters, preserves existing values
+
+Parameters of the local directory that are not in the archive are kept.
+The imported files can be deployed with pd-deploy. No connection to SAP CPI
+is made.`,
+		Example: `  # Import an archive
+  flashpipe pd-import --in pd.zip --resources-path "./partner-directory"
+
+  # Import without overwriting existing values
+  flashpipe pd-import --in pd.zip --replace=false`,
+		RunE: func(cmd *cobra.Command, args []string) (err error) {
+			startTime := time.Now()
+			if err = runPDImport(cmd); err != nil {
+				cmd.SilenceUsage = true
+			}
+			analytics.Log(cmd, err, startTime)
+			return
+		},
+	}
+
+	// Define flags
+	// Note: These can be set in config file under 'pd-import' key
+	pdImportCmd.Flags().String("in", "",
+		"Zip archive written by pd-export")
+	pdImportCmd.Flags().String("resources-path", "./partner-directory",
+		"Path to write partner directory parameters to")
+	pdImportCmd.Flags().Bool("replace", true,
+		"Replace existing values (false = add only missing values)")
+	pdImportCmd.Flags().StringSlice("pids", nil,
+		"Comma separated list of Partner IDs to import (e.g., 'PID1,PID2')")
+	pdImportCmd.Flags().StringSlice("extra-content-types", nil,
+		"Comma separated list of additional binary content types to preserve as file extensions (e.g., 'pem,p12')")
+
+	return pdImportCmd
+}
+
+func runPDImport(cmd *cobra.Command) error {
+	log.Info().Msg("Executing Partner Directory Import command")
+
+	in := getConfigStringWithFallback(cmd, "in", "pd-import.in")
+	resourcesPath := getConfigStringWithFallback(cmd, "resources-path", "pd-import.resources-path")
+	replace := getConfigBoolWithFallback(cmd, "replace", "pd-import.replace")
+	pids := str.TrimSlice(getConfigStringSliceWithFallback(cmd, "pids", "pd-import.pids"))
+	extraContentTypes := getConfigStringSliceWithFallback(cmd, "extra-content-types", "pd-import.extra-content-types")
+
+	if in == "" {
+		return fmt.Errorf("--in is required (set via CLI flag or in config file under 'pd-import.in')")
+	}
+
+	log.Info().Msgf("Archive: %s", in)
+	log.Info().Msgf("Resources Path: %s", resourcesPath)
+	log.Info().Msgf("Replace Mode: %v", replace)
+	if len(pids) > 0 {
+		log.Info().Msgf("Filter PIDs: %v", pids)
+	}
+
+	pdRepo := repo.NewPartnerDirectory(resourcesPath)
+	if len(extraContentTypes) > 0 {
+		pdRepo.AddContentTypes(extraContentTypes)
+	}
+
+	count, err := importPartnerDirectory(in, pdRepo, pids, replace)
+	if err != nil {
+		return err
+	}
+
+	log.Info().Msgf("🏆 Imported %d PIDs to %s", count, resourcesPath)
+	return nil
+}
+
+// importPartnerDirectory writes the parameters of the PIDs in a zip archive to the repository and
+// returns the number of imported PIDs
+func importPartnerDirectory(in string, pdRepo *repo.PartnerDirectory, pidsFilter []string, replace bool) (int, error) {
+	if !file.Exists(in) {
+		return 0, fmt.Errorf("archive %s does not exist", in)
+	}
+	isZip, err := file.IsZipArchive(in)
+	if err != nil {
+		return 0, err
+	}
+	if !isZip {
+		return 0, fmt.Errorf("%s is not a zip archive", in)
+	}
+
+	extractDir, err := os.MkdirTemp("", "flashpipe-pd-import-*")
+	if err != nil {
+		return 0, fmt.Errorf("failed to create working directory: %w", err)
+	}
+	defer os.RemoveAll(extractDir)
+	if err := file.UnzipSource(in, extractDir); err != nil {
+		return 0, fmt.Errorf("failed to extract archive %s: %w", in, err)
+	}
+
+	archive := repo.NewPartnerDirectory(extractDir)
+	pids, err := archive.GetLocalPIDs()
+	if err != nil {
+		return 0, err
+	}
+	if len(pidsFilter) > 0 {
+		filtered := make([]string, 0)
+		for _, pid := range pids {
+			if contains(pidsFilter, pid) {
+				filtered = append(filtered, pid)
+			}
+		}
+		pids = filtered
+	}
+	if len(pids) == 0 {
+		return 0, fmt.Errorf("no PIDs found in archive %s", in)
+	}
+
+	for _, pid := range pids {
+		stringCount, binaryCount, err := copyPIDParameters(archive, pdRepo, pid, replace)
+		if err != nil {
+			return 0, err
+		}
+		log.Info().Msgf("Imported PID %s: %d string, %d binary parameters written", pid, stringCount, binaryCount)
+	}
+	return len(pids), nil
+}
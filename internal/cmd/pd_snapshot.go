@@ -1,7 +1,15 @@
 package cmd
 
 import (
+	"encoding/json"
+	"errors"
 	"fmt"
+	"io"
+	"os"
+	"sort"
+	"sync"
+	"sync/atomic"
+	"text/tabwriter"
 	"time"
 
 	"github.com/engswee/flashpipe/internal/analytics"
@@ -10,8 +18,66 @@ import (
 	"github.com/engswee/flashpipe/internal/str"
 	"github.com/rs/zerolog/log"
 	"github.com/spf13/cobra"
+	"gopkg.in/yaml.v3"
 )
 
+// PDSnapshotSummary counts the parameters written by pd-snapshot per PID. Parameters skipped
+// are existing local values kept in add-only mode (--replace=false).
+type PDSnapshotSummary struct {
+	Pids   []*PDSnapshotPid `json:"pids" yaml:"pids"`
+	String PDSnapshotCounts `json:"string" yaml:"string"`
+	Binary PDSnapshotCounts `json:"binary" yaml:"binary"`
+
+	mu    sync.Mutex
+	byPid map[string]*PDSnapshotPid
+}
+
+// PDSnapshotPid counts the parameters of a PID
+type PDSnapshotPid struct {
+	Pid    string           `json:"pid" yaml:"pid"`
+	String PDSnapshotCounts `json:"string" yaml:"string"`
+	Binary PDSnapshotCounts `json:"binary" yaml:"binary"`
+}
+
+// PDSnapshotCounts counts written and skipped parameters
+type PDSnapshotCounts struct {
+	Written int `json:"written" yaml:"written"`
+	Skipped int `json:"skipped" yaml:"skipped"`
+}
+
+func newPDSnapshotSummary() *PDSnapshotSummary {
+	return &PDSnapshotSummary{byPid: make(map[string]*PDSnapshotPid)}
+}
+
+// record adds the written and skipped parameters of a PID. It is safe for concurrent use.
+func (s *PDSnapshotSummary) record(pid string, binary bool, written, skipped int) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	pidSummary := s.byPid[pid]
+	if pidSummary == nil {
+		pidSummary = &PDSnapshotPid{Pid: pid}
+		s.byPid[pid] = pidSummary
+	}
+	counts, total := &pidSummary.String, &s.String
+	if binary {
+		counts, total = &pidSummary.Binary, &s.Binary
+	}
+	counts.Written += written
+	counts.Skipped += skipped
+	total.Written += written
+	total.Skipped += skipped
+}
+
+// sortPids sets Pids to the recorded PIDs in sorted order
+func (s *PDSnapshotSummary) sortPids() {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.Pids = make([]*PDSnapshotPid, 0, len(s.byPid))
+	for _, pid := range sortedKeys(s.byPid) {
+		s.Pids = append(s.Pids, s.byPid[pid])
+	}
+}
+
 func NewPDSnapshotCommand() *cobra.Command {
 
 	pdSnapshotCmd := &cobra.Command{
@@ -27,6 +93,8 @@ and organizes them in a local directory structure:
     Binary/              - Binary parameters as individual files
       {ParamId}.{ext}    - Binary parameter files
       _metadata.json     - Content type metadata
+      .compressed/       - Original bytes of decompressed files (only with --decompress)
+    _audit.json          - Audit fields (only with --include-metadata)
 
 The snapshot operation supports two modes:
   - Replace mode (default): Overwrites existing local files
@@ -52,7 +120,25 @@ Authentication is performed using OAuth 2.0 client credentials flow or Basic Aut
   flashpipe pd-snapshot --replace=false
 
   # Snapshot only specific PIDs
-  flashpipe pd-snapshot --pids "SAP_SYSTEM_001,CUSTOMER_API"`,
+  flashpipe pd-snapshot --pids "SAP_SYSTEM_001,CUSTOMER_API"
+
+  # Keep additional binary content types as file extensions
+  flashpipe pd-snapshot --extra-content-types "pem,pkcs12"
+
+  # Keep who created and last changed each parameter
+  flashpipe pd-snapshot --include-metadata
+
+  # Write up to 4 PIDs concurrently
+  flashpipe pd-snapshot --parallel 4
+
+  # Download binary parameter values with 8 concurrent requests
+  flashpipe pd-snapshot --parallel-downloads 8
+
+  # Write the summary of the parameters per PID as JSON file
+  flashpipe pd-snapshot --format json --output pd-snapshot-summary.json
+
+  # Only update parameters changed on the tenant in the last 7 days
+  flashpipe pd-snapshot --modified-after 7d`,
 		RunE: func(cmd *cobra.Command, args []string) (err error) {
 			startTime := time.Now()
 			if err = runPDSnapshot(cmd); err != nil {
@@ -71,10 +157,30 @@ Authentication is performed using OAuth 2.0 client credentials flow or Basic Aut
 		"Replace existing values (false = add only missing values)")
 	pdSnapshotCmd.Flags().StringSlice("pids", nil,
 		"Comma separated list of Partner IDs to snapshot (e.g., 'PID1,PID2')")
+	pdSnapshotCmd.Flags().StringSlice("extra-content-types", nil,
+		"Comma separated list of additional binary content types to preserve as file extensions (e.g., 'pem,p12')")
+	pdSnapshotCmd.Flags().Bool("decompress", false,
+		"Write gz and zlib binary parameters decompressed, keeping the original bytes in Binary/.compressed")
+	pdSnapshotCmd.Flags().Bool("include-metadata", false,
+		"Write audit fields (CreatedBy, CreatedTime, LastModifiedBy, LastModifiedTime) to _audit.json per PID")
+	pdSnapshotCmd.Flags().Int("parallel", 1,
+		fmt.Sprintf("Number of PIDs to write concurrently (1-%d)", maxSnapshotParallel))
+	pdSnapshotCmd.Flags().Int("parallel-downloads", 0,
+		fmt.Sprintf("Download binary parameter values individually with this many concurrent requests (1-%d, 0 = single bulk request)", maxSnapshotParallel))
+	pdSnapshotCmd.Flags().String("format", "table",
+		"Format of the summary of the parameters per PID: 'table', 'json' or 'yaml'")
+	pdSnapshotCmd.Flags().String("output", "",
+		"File to write the summary to (default stdout)")
+	pdSnapshotCmd.Flags().String("modified-after", "",
+		"Only write parameters last modified within this duration (e.g. 24h, 7d) or since this timestamp (RFC 3339 or YYYY-MM-DD)")
+	addRetryFlags(pdSnapshotCmd)
 
 	return pdSnapshotCmd
 }
 
+// maxSnapshotParallel caps concurrent PID writes to avoid overwhelming the disk
+const maxSnapshotParallel = 16
+
 func runPDSnapshot(cmd *cobra.Command) error {
 	serviceDetails := api.GetServiceDetails(cmd)
 
@@ -84,12 +190,47 @@ func runPDSnapshot(cmd *cobra.Command) error {
 	resourcesPath := getConfigStringWithFallback(cmd, "resources-path", "pd-snapshot.resources-path")
 	replace := getConfigBoolWithFallback(cmd, "replace", "pd-snapshot.replace")
 	pids := getConfigStringSliceWithFallback(cmd, "pids", "pd-snapshot.pids")
+	extraContentTypes := getConfigStringSliceWithFallback(cmd, "extra-content-types", "pd-snapshot.extra-content-types")
+	parallel := getConfigIntWithFallback(cmd, "parallel", "pd-snapshot.parallel")
+	includeMetadata := getConfigBoolWithFallback(cmd, "include-metadata", "pd-snapshot.include-metadata")
+	decompress := getConfigBoolWithFallback(cmd, "decompress", "pd-snapshot.decompress")
+	parallelDownloads := getConfigIntWithFallback(cmd, "parallel-downloads", "pd-snapshot.parallel-downloads")
+	format := getConfigStringWithFallback(cmd, "format", "pd-snapshot.format")
+	output := getConfigStringWithFallback(cmd, "output", "pd-snapshot.output")
+	modifiedAfter, err := parsePointInTime("--modified-after", getConfigStringWithFallback(cmd, "modified-after", "pd-snapshot.modified-after"), time.Now())
+	if err != nil {
+		return err
+	}
+
+	if format != "table" && format != "json" && format != "yaml" {
+		return fmt.Errorf("invalid summary format %q: must be 'table', 'json' or 'yaml'", format)
+	}
+
+	if parallel < 1 || parallel > maxSnapshotParallel {
+		return fmt.Errorf("--parallel must be between 1 and %d, got %d", maxSnapshotParallel, parallel)
+	}
+	if parallelDownloads < 0 || parallelDownloads > maxSnapshotParallel {
+		return fmt.Errorf("--parallel-downloads must be between 0 and %d, got %d", maxSnapshotParallel, parallelDownloads)
+	}
+
+	retryPolicy, err := getRetryPolicy(cmd, "pd-snapshot")
+	if err != nil {
+		return err
+	}
 
 	log.Info().Msgf("Resources Path: %s", resourcesPath)
 	log.Info().Msgf("Replace Mode: %v", replace)
+	log.Info().Msgf("Parallel: %d", parallel)
+	log.Info().Msgf("Decompress: %v", decompress)
+	if parallelDownloads > 0 {
+		log.Info().Msgf("Parallel Downloads: %d", parallelDownloads)
+	}
 	if len(pids) > 0 {
 		log.Info().Msgf("Filter PIDs: %v", pids)
 	}
+	if !modifiedAfter.IsZero() {
+		log.Info().Msgf("Modified After: %v", modifiedAfter.Format(time.RFC3339))
+	}
 
 	// Trim PIDs
 	pids = str.TrimSlice(pids)
@@ -99,12 +240,24 @@ func runPDSnapshot(cmd *cobra.Command) error {
 
 	// Initialise Partner Directory API
 	pdAPI := api.NewPartnerDirectory(exe)
+	pdAPI.SetRetryPolicy(retryPolicy)
 
 	// Initialise Partner Directory Repository
 	pdRepo := repo.NewPartnerDirectory(resourcesPath)
+	if len(extraContentTypes) > 0 {
+		log.Info().Msgf("Extra Content Types: %v", extraContentTypes)
+		pdRepo.AddContentTypes(extraContentTypes)
+	}
+	pdRepo.IncludeAudit = includeMetadata
+	pdRepo.Decompress = decompress
 
 	// Execute snapshot
-	if err := snapshotPartnerDirectory(pdAPI, pdRepo, replace, pids); err != nil {
+	summary, err := snapshotPartnerDirectory(pdAPI, pdRepo, replace, pids, modifiedAfter, parallel, parallelDownloads)
+	if err != nil {
+		return err
+	}
+
+	if err := writePDSnapshotSummaryTo(cmd.OutOrStdout(), output, summary, format); err != nil {
 		return err
 	}
 
@@ -112,30 +265,45 @@ func runPDSnapshot(cmd *cobra.Command) error {
 	return nil
 }
 
-func snapshotPartnerDirectory(pdAPI *api.PartnerDirectory, pdRepo *repo.PartnerDirectory, replace bool, pidsFilter []string) error {
+func snapshotPartnerDirectory(pdAPI *api.PartnerDirectory, pdRepo *repo.PartnerDirectory, replace bool, pidsFilter []string, modifiedAfter time.Time,
+	parallel, parallelDownloads int) (*PDSnapshotSummary, error) {
 	log.Info().Msg("Starting Partner Directory Snapshot...")
+	summary := newPDSnapshotSummary()
 
 	// Download string parameters
-	stringCount, err := snapshotStringParameters(pdAPI, pdRepo, replace, pidsFilter)
+	stringCount, err := snapshotStringParameters(pdAPI, pdRepo, replace, pidsFilter, modifiedAfter, parallel, summary)
 	if err != nil {
-		return fmt.Errorf("failed to download string parameters: %w", err)
+		return nil, fmt.Errorf("failed to download string parameters: %w", err)
 	}
 	log.Info().Msgf("Downloaded %d string parameters", stringCount)
 
 	// Download binary parameters
-	binaryCount, err := snapshotBinaryParameters(pdAPI, pdRepo, replace, pidsFilter)
+	var binaryCount int
+	if parallelDownloads > 0 {
+		binaryCount, err = downloadBinaryParameters(pdAPI, pdRepo, replace, pidsFilter, modifiedAfter, parallelDownloads, summary)
+	} else {
+		binaryCount, err = snapshotBinaryParameters(pdAPI, pdRepo, replace, pidsFilter, modifiedAfter, parallel, summary)
+	}
 	if err != nil {
-		return fmt.Errorf("failed to download binary parameters: %w", err)
+		return nil, fmt.Errorf("failed to download binary parameters: %w", err)
 	}
 	log.Info().Msgf("Downloaded %d binary parameters", binaryCount)
 
-	return nil
+	summary.sortPids()
+	return summary, nil
 }
 
-func snapshotStringParameters(pdAPI *api.PartnerDirectory, pdRepo *repo.PartnerDirectory, replace bool, pidsFilter []string) (int, error) {
+func snapshotStringParameters(pdAPI *api.PartnerDirectory, pdRepo *repo.PartnerDirectory, replace bool, pidsFilter []string, modifiedAfter time.Time,
+	parallel int, summary *PDSnapshotSummary) (int, error) {
 	log.Debug().Msg("Fetching string parameters from Partner Directory")
 
-	parameters, err := pdAPI.GetStringParameters("Pid,Id,Value")
+	selectFields := "Pid,Id,Value"
+	if pdRepo.IncludeAudit {
+		selectFields += ",CreatedBy,LastModifiedBy,CreatedTime,LastModifiedTime"
+	} else if !modifiedAfter.IsZero() {
+		selectFields += ",LastModifiedTime"
+	}
+	parameters, err := pdAPI.GetStringParameters(selectFields)
 	if err != nil {
 		return 0, err
 	}
@@ -150,6 +318,7 @@ func snapshotStringParameters(pdAPI *api.PartnerDirectory, pdRepo *repo.PartnerD
 		}
 		parameters = filtered
 	}
+	parameters = filterModifiedAfter(parameters, modifiedAfter, func(p api.StringParameter) string { return p.LastModifiedTime })
 
 	log.Debug().Msgf("Fetched %d string parameters from Partner Directory", len(parameters))
 
@@ -160,18 +329,38 @@ func snapshotStringParameters(pdAPI *api.PartnerDirectory, pdRepo *repo.PartnerD
 	}
 
 	// Process each PID
-	for pid, pidParams := range paramsByPid {
+	err = processPIDsParallel(sortedKeys(paramsByPid), parallel, func(pid string) error {
+		pidParams := paramsByPid[pid]
 		log.Debug().Msgf("Processing PID: %s with %d string parameters", pid, len(pidParams))
 
-		if err := pdRepo.WriteStringParameters(pid, pidParams, replace); err != nil {
-			return 0, fmt.Errorf("failed to write string parameters for PID %s: %w", pid, err)
+		// All parameters of a PID are in one file, which must keep the parameters that were not
+		// modified when it is replaced
+		toWrite := pidParams
+		if replace && !modifiedAfter.IsZero() {
+			local, err := pdRepo.ReadStringParameters(pid)
+			if err != nil {
+				return fmt.Errorf("failed to read string parameters for PID %s: %w", pid, err)
+			}
+			toWrite = mergeStringParameters(local, pidParams)
+		}
+
+		written, err := pdRepo.WriteStringParameters(pid, toWrite, replace)
+		if err != nil {
+			return fmt.Errorf("failed to write string parameters for PID %s: %w", pid, err)
 		}
+		written = min(written, len(pidParams))
+		summary.record(pid, false, written, len(pidParams)-written)
+		return nil
+	})
+	if err != nil {
+		return 0, err
 	}
 
 	return len(parameters), nil
 }
 
-func snapshotBinaryParameters(pdAPI *api.PartnerDirectory, pdRepo *repo.PartnerDirectory, replace bool, pidsFilter []string) (int, error) {
+func snapshotBinaryParameters(pdAPI *api.PartnerDirectory, pdRepo *repo.PartnerDirectory, replace bool, pidsFilter []string, modifiedAfter time.Time,
+	parallel int, summary *PDSnapshotSummary) (int, error) {
 	log.Debug().Msg("Fetching binary parameters from Partner Directory")
 
 	parameters, err := pdAPI.GetBinaryParameters("")
@@ -189,6 +378,7 @@ func snapshotBinaryParameters(pdAPI *api.PartnerDirectory, pdRepo *repo.PartnerD
 		}
 		parameters = filtered
 	}
+	parameters = filterModifiedAfter(parameters, modifiedAfter, func(p api.BinaryParameter) string { return p.LastModifiedTime })
 
 	log.Debug().Msgf("Fetched %d binary parameters from Partner Directory", len(parameters))
 
@@ -199,13 +389,229 @@ func snapshotBinaryParameters(pdAPI *api.PartnerDirectory, pdRepo *repo.PartnerD
 	}
 
 	// Process each PID
-	for pid, pidParams := range paramsByPid {
+	err = processPIDsParallel(sortedKeys(paramsByPid), parallel, func(pid string) error {
+		pidParams := paramsByPid[pid]
 		log.Debug().Msgf("Processing PID: %s with %d binary parameters", pid, len(pidParams))
 
-		if err := pdRepo.WriteBinaryParameters(pid, pidParams, replace); err != nil {
-			return 0, fmt.Errorf("failed to write binary parameters for PID %s: %w", pid, err)
+		if replace && modifiedAfter.IsZero() {
+			if err := pdRepo.ResetBinaryAudit(pid); err != nil {
+				return fmt.Errorf("failed to reset audit of binary parameters for PID %s: %w", pid, err)
+			}
+		}
+		written, err := pdRepo.WriteBinaryParameters(pid, pidParams, replace)
+		if err != nil {
+			return fmt.Errorf("failed to write binary parameters for PID %s: %w", pid, err)
 		}
+		summary.record(pid, true, written, len(pidParams)-written)
+		return nil
+	})
+	if err != nil {
+		return 0, err
 	}
 
 	return len(parameters), nil
 }
+
+// downloadBinaryParameters lists binary parameters without their values and then fetches
+// each value individually with at most parallelDownloads concurrent requests. Parameters
+// are written as they arrive; writes to the same PID are serialised. Like the bulk download, it
+// returns the number of downloaded parameters, including the ones kept with replace=false.
+func downloadBinaryParameters(pdAPI *api.PartnerDirectory, pdRepo *repo.PartnerDirectory, replace bool, pidsFilter []string, modifiedAfter time.Time,
+	parallelDownloads int, summary *PDSnapshotSummary) (int, error) {
+	log.Debug().Msg("Listing binary parameters from Partner Directory")
+
+	parameters, err := pdAPI.GetBinaryParameters("Pid,Id,ContentType,CreatedBy,LastModifiedBy,CreatedTime,LastModifiedTime")
+	if err != nil {
+		return 0, err
+	}
+
+	// Filter by PIDs if specified
+	if len(pidsFilter) > 0 {
+		filtered := make([]api.BinaryParameter, 0)
+		for _, param := range parameters {
+			if contains(pidsFilter, param.Pid) {
+				filtered = append(filtered, param)
+			}
+		}
+		parameters = filtered
+	}
+	parameters = filterModifiedAfter(parameters, modifiedAfter, func(p api.BinaryParameter) string { return p.LastModifiedTime })
+
+	total := len(parameters)
+	log.Info().Msgf("Downloading %d binary parameters with %d concurrent requests", total, parallelDownloads)
+
+	pidLocks := make(map[string]*sync.Mutex)
+	for _, param := range parameters {
+		if pidLocks[param.Pid] == nil {
+			pidLocks[param.Pid] = &sync.Mutex{}
+		}
+	}
+	if replace && modifiedAfter.IsZero() {
+		for _, pid := range sortedKeys(pidLocks) {
+			if err := pdRepo.ResetBinaryAudit(pid); err != nil {
+				return 0, fmt.Errorf("failed to reset audit of binary parameters for PID %s: %w", pid, err)
+			}
+		}
+	}
+
+	var downloaded int32
+	err = processParallel(parameters, parallelDownloads, func(listed api.BinaryParameter) error {
+		param, err := pdAPI.GetBinaryParameter(listed.Pid, listed.ID)
+		if err != nil {
+			return fmt.Errorf("failed to download binary parameter %s/%s: %w", listed.Pid, listed.ID, err)
+		}
+		if param == nil {
+			log.Warn().Msgf("Binary parameter %s/%s no longer exists - skipping", listed.Pid, listed.ID)
+			return nil
+		}
+		done := atomic.AddInt32(&downloaded, 1)
+		log.Info().Msgf("Downloaded binary parameter %s/%s (%d/%d)", param.Pid, param.ID, done, total)
+
+		lock := pidLocks[param.Pid]
+		lock.Lock()
+		defer lock.Unlock()
+		paramWritten, err := pdRepo.WriteBinaryParameters(param.Pid, []api.BinaryParameter{*param}, replace)
+		if err != nil {
+			return fmt.Errorf("failed to write binary parameter %s/%s: %w", param.Pid, param.ID, err)
+		}
+		summary.record(param.Pid, true, paramWritten, 1-paramWritten)
+		return nil
+	})
+	if err != nil {
+		return 0, err
+	}
+
+	return int(downloaded), nil
+}
+
+// filterModifiedAfter returns the parameters last modified at or after since. Parameters without a
+// LastModifiedTime are kept so that no change is missed. A zero since keeps all parameters.
+func filterModifiedAfter[T any](params []T, since time.Time, lastModified func(T) string) []T {
+	if since.IsZero() {
+		return params
+	}
+	filtered := make([]T, 0, len(params))
+	for _, param := range params {
+		modified := api.ParseODataTime(lastModified(param))
+		if modified.IsZero() || !modified.Before(since) {
+			filtered = append(filtered, param)
+		}
+	}
+	log.Info().Msgf("%d of %d parameters modified since %v", len(filtered), len(params), since.Format(time.RFC3339))
+	return filtered
+}
+
+// mergeStringParameters returns the local parameters with the modified parameters added or
+// replacing the local ones of the same ID
+func mergeStringParameters(local, modified []api.StringParameter) []api.StringParameter {
+	merged := make([]api.StringParameter, 0, len(local)+len(modified))
+	modifiedIDs := make(map[string]bool, len(modified))
+	for _, param := range modified {
+		modifiedIDs[param.ID] = true
+	}
+	for _, param := range local {
+		if !modifiedIDs[param.ID] {
+			merged = append(merged, param)
+		}
+	}
+	return append(merged, modified...)
+}
+
+// writePDSnapshotSummaryTo writes the summary to the output file, or to w when no file is set
+func writePDSnapshotSummaryTo(w io.Writer, output string, summary *PDSnapshotSummary, format string) error {
+	if output == "" {
+		return writePDSnapshotSummary(w, summary, format)
+	}
+	f, err := os.Create(output)
+	if err != nil {
+		return fmt.Errorf("failed to create summary file %s: %w", output, err)
+	}
+	if err := writePDSnapshotSummary(f, summary, format); err != nil {
+		f.Close()
+		return err
+	}
+	if err := f.Close(); err != nil {
+		return fmt.Errorf("failed to write summary file %s: %w", output, err)
+	}
+	log.Info().Msgf("Summary written to %s", output)
+	return nil
+}
+
+// writePDSnapshotSummary writes the summary as table, JSON or YAML
+func writePDSnapshotSummary(w io.Writer, summary *PDSnapshotSummary, format string) error {
+	switch format {
+	case "json":
+		data, err := json.MarshalIndent(summary, "", "  ")
+		if err != nil {
+			return fmt.Errorf("failed to encode summary: %w", err)
+		}
+		_, err = fmt.Fprintln(w, string(data))
+		return err
+	case "yaml":
+		data, err := yaml.Marshal(summary)
+		if err != nil {
+			return fmt.Errorf("failed to encode summary: %w", err)
+		}
+		_, err = w.Write(data)
+		return err
+	}
+
+	tw := tabwriter.NewWriter(w, 0, 0, 2, ' ', 0)
+	fmt.Fprintln(tw, "PID\tSTRING WRITTEN\tSTRING SKIPPED\tBINARY WRITTEN\tBINARY SKIPPED")
+	for _, pid := range summary.Pids {
+		fmt.Fprintf(tw, "%s\t%d\t%d\t%d\t%d\n", pid.Pid, pid.String.Written, pid.String.Skipped, pid.Binary.Written, pid.Binary.Skipped)
+	}
+	fmt.Fprintf(tw, "TOTAL\t%d\t%d\t%d\t%d\n", summary.String.Written, summary.String.Skipped, summary.Binary.Written, summary.Binary.Skipped)
+	return tw.Flush()
+}
+
+// processPIDsParallel runs fn for each PID using at most parallel workers.
+// All PIDs are processed; errors are collected and returned together.
+func processPIDsParallel(pids []string, parallel int, fn func(pid string) error) error {
+	return processParallel(pids, parallel, fn)
+}
+
+// processParallel runs fn for each item using at most parallel workers.
+// All items are processed; errors are collected and returned together.
+func processParallel[T any](items []T, parallel int, fn func(item T) error) error {
+	if parallel < 1 {
+		parallel = 1
+	}
+
+	var (
+		wg        sync.WaitGroup
+		mu        sync.Mutex
+		errs      []error
+		semaphore = make(chan struct{}, parallel)
+	)
+
+	for _, item := range items {
+		wg.Add(1)
+		go func(item T) {
+			defer wg.Done()
+
+			// Acquire semaphore
+			semaphore <- struct{}{}
+			defer func() { <-semaphore }()
+
+			if err := fn(item); err != nil {
+				mu.Lock()
+				errs = append(errs, err)
+				mu.Unlock()
+			}
+		}(item)
+	}
+
+	wg.Wait()
+	return errors.Join(errs...)
+}
+
+// sortedKeys returns the keys of a PID-grouped map in sorted order
+func sortedKeys[T any](m map[string]T) []string {
+	keys := make([]string, 0, len(m))
+	for k := range m {
+		keys = append(keys, k)
+	}
+	sort.Strings(keys)
+	return keys
+}
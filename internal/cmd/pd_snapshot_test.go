@@ -0,0 +1,233 @@
+package cmd
+
+import (
+	"bytes"
+	"encoding/base64"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"strings"
+	"sync"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/engswee/flashpipe/internal/api"
+	"github.com/engswee/flashpipe/internal/httpclnt"
+	"github.com/engswee/flashpipe/internal/repo"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestProcessPIDsParallel(t *testing.T) {
+	pids := make([]string, 50)
+	for i := range pids {
+		pids[i] = fmt.Sprintf("PID%02d", i)
+	}
+
+	var (
+		mu        sync.Mutex
+		processed = make(map[string]bool)
+		running   int32
+		maxSeen   int32
+	)
+
+	err := processPIDsParallel(pids, 4, func(pid string) error {
+		current := atomic.AddInt32(&running, 1)
+		defer atomic.AddInt32(&running, -1)
+		for {
+			seen := atomic.LoadInt32(&maxSeen)
+			if current <= seen || atomic.CompareAndSwapInt32(&maxSeen, seen, current) {
+				break
+			}
+		}
+
+		mu.Lock()
+		processed[pid] = true
+		mu.Unlock()
+
+		if pid == "PID07" || pid == "PID42" {
+			return fmt.Errorf("failed to write %s", pid)
+		}
+		return nil
+	})
+
+	assert.Len(t, processed, len(pids))
+	assert.LessOrEqual(t, maxSeen, int32(4))
+	assert.ErrorContains(t, err, "failed to write PID07")
+	assert.ErrorContains(t, err, "failed to write PID42")
+}
+
+func TestSortedKeys(t *testing.T) {
+	m := map[string][]int{"b": nil, "a": nil, "c": nil}
+	assert.Equal(t, []string{"a", "b", "c"}, sortedKeys(m))
+}
+
+func TestDownloadBinaryParameters(t *testing.T) {
+	responses := map[string]string{}
+	var listing []string
+	for _, pid := range []string{"PID1", "PID2"} {
+		for i := 0; i < 5; i++ {
+			id := fmt.Sprintf("Param%d", i)
+			responses[fmt.Sprintf("Pid='%s',Id='%s'", pid, id)] = fmt.Sprintf(`{"d":{"Pid":"%s","Id":"%s","Value":"%s","ContentType":"txt"}}`,
+				pid, id, base64.StdEncoding.EncodeToString([]byte(pid+id)))
+			listing = append(listing, fmt.Sprintf(`{"Pid":"%s","Id":"%s","ContentType":"txt"}`, pid, id))
+		}
+	}
+
+	svr := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Path == "/api/v1/BinaryParameters" {
+			assert.NotContains(t, r.URL.Query().Get("$select"), "Value")
+			fmt.Fprintf(w, `{"d":{"results":[%s]}}`, strings.Join(listing, ","))
+			return
+		}
+		key := strings.TrimSuffix(strings.TrimPrefix(r.URL.Path, "/api/v1/BinaryParameters("), ")")
+		response, ok := responses[key]
+		if !ok {
+			http.NotFound(w, r)
+			return
+		}
+		fmt.Fprint(w, response)
+	}))
+	defer svr.Close()
+	host, port := httpclnt.GetHostPort(svr.URL)
+	pdAPI := api.NewPartnerDirectory(httpclnt.New("", "", "", "", "user", "password", host, "http", port, false))
+
+	tempDir, err := os.MkdirTemp("", "pd-snapshot-*")
+	require.NoError(t, err)
+	defer os.RemoveAll(tempDir)
+	pdRepo := repo.NewPartnerDirectory(tempDir)
+
+	summary := newPDSnapshotSummary()
+	count, err := downloadBinaryParameters(pdAPI, pdRepo, true, []string{"PID2"}, time.Time{}, 3, summary)
+	require.NoError(t, err)
+	assert.Equal(t, 5, count)
+	assert.Equal(t, PDSnapshotCounts{Written: 5}, summary.Binary)
+
+	params, err := pdRepo.ReadBinaryParameters("PID2")
+	require.NoError(t, err)
+	assert.Len(t, params, 5)
+	assert.NoDirExists(t, filepath.Join(tempDir, "PID1"))
+
+	// Parameters kept with replace=false are counted as downloaded, like with the bulk download,
+	// and parameters deleted after the listing are not
+	delete(responses, "Pid='PID2',Id='Param0'")
+	summary = newPDSnapshotSummary()
+	count, err = downloadBinaryParameters(pdAPI, pdRepo, false, []string{"PID2"}, time.Time{}, 3, summary)
+	require.NoError(t, err)
+	assert.Equal(t, 4, count)
+	assert.Equal(t, PDSnapshotCounts{Skipped: 4}, summary.Binary)
+}
+
+func TestPDSnapshotSummary(t *testing.T) {
+	summary := newPDSnapshotSummary()
+	summary.record("PID2", false, 3, 1)
+	summary.record("PID1", true, 1, 0)
+	summary.record("PID1", true, 0, 1)
+	summary.record("PID1", false, 2, 0)
+	summary.sortPids()
+
+	require.Len(t, summary.Pids, 2)
+	assert.Equal(t, PDSnapshotPid{Pid: "PID1", String: PDSnapshotCounts{Written: 2}, Binary: PDSnapshotCounts{Written: 1, Skipped: 1}}, *summary.Pids[0])
+	assert.Equal(t, "PID2", summary.Pids[1].Pid)
+	assert.Equal(t, PDSnapshotCounts{Written: 5, Skipped: 1}, summary.String)
+	assert.Equal(t, PDSnapshotCounts{Written: 1, Skipped: 1}, summary.Binary)
+
+	var buf bytes.Buffer
+	require.NoError(t, writePDSnapshotSummary(&buf, summary, "json"))
+	var decoded struct {
+		Pids []struct {
+			Pid    string `json:"pid"`
+			String struct {
+				Written int `json:"written"`
+				Skipped int `json:"skipped"`
+			} `json:"string"`
+		} `json:"pids"`
+	}
+	require.NoError(t, json.Unmarshal(buf.Bytes(), &decoded))
+	require.Len(t, decoded.Pids, 2)
+	assert.Equal(t, "PID2", decoded.Pids[1].Pid)
+	assert.Equal(t, 1, decoded.Pids[1].String.Skipped)
+
+	buf.Reset()
+	require.NoError(t, writePDSnapshotSummary(&buf, summary, "yaml"))
+	assert.Contains(t, buf.String(), "- pid: PID1\n")
+
+	buf.Reset()
+	require.NoError(t, writePDSnapshotSummary(&buf, summary, "table"))
+	lines := strings.Split(strings.TrimSpace(buf.String()), "\n")
+	require.Len(t, lines, 4)
+	assert.Equal(t, []string{"PID1", "2", "0", "1", "1"}, strings.Fields(lines[1]))
+	assert.Equal(t, []string{"TOTAL", "5", "1", "1", "1"}, strings.Fields(lines[3]))
+}
+
+func TestWritePDSnapshotSummaryToFile(t *testing.T) {
+	summary := newPDSnapshotSummary()
+	summary.record("PID1", false, 1, 0)
+	summary.sortPids()
+
+	output := filepath.Join(t.TempDir(), "summary.yaml")
+	var stdout bytes.Buffer
+	require.NoError(t, writePDSnapshotSummaryTo(&stdout, output, summary, "yaml"))
+	assert.Empty(t, stdout.String())
+
+	content, err := os.ReadFile(output)
+	require.NoError(t, err)
+	assert.Contains(t, string(content), "pid: PID1")
+}
+
+func TestFilterModifiedAfter(t *testing.T) {
+	since := time.Date(2024, 3, 1, 0, 0, 0, 0, time.UTC)
+	params := []api.StringParameter{
+		{ID: "Old", LastModifiedTime: fmt.Sprintf("/Date(%d)/", since.Add(-time.Hour).UnixMilli())},
+		{ID: "New", LastModifiedTime: fmt.Sprintf("/Date(%d)/", since.Add(time.Hour).UnixMilli())},
+		{ID: "Exact", LastModifiedTime: fmt.Sprintf("/Date(%d)/", since.UnixMilli())},
+		{ID: "Unknown"},
+	}
+	lastModified := func(p api.StringParameter) string { return p.LastModifiedTime }
+
+	var ids []string
+	for _, p := range filterModifiedAfter(params, since, lastModified) {
+		ids = append(ids, p.ID)
+	}
+	assert.Equal(t, []string{"New", "Exact", "Unknown"}, ids)
+	assert.Len(t, filterModifiedAfter(params, time.Time{}, lastModified), 4)
+}
+
+func TestSnapshotStringParameters_ModifiedAfterKeepsLocalParameters(t *testing.T) {
+	since := time.Date(2024, 3, 1, 0, 0, 0, 0, time.UTC)
+	svr := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		assert.Contains(t, r.URL.Query().Get("$select"), "LastModifiedTime")
+		fmt.Fprintf(w, `{"d":{"results":[{"Pid":"PID1","Id":"Old","Value":"tenant","LastModifiedTime":"/Date(%d)/"},`+
+			`{"Pid":"PID1","Id":"New","Value":"tenant","LastModifiedTime":"/Date(%d)/"}]}}`,
+			since.Add(-time.Hour).UnixMilli(), since.Add(time.Hour).UnixMilli())
+	}))
+	defer svr.Close()
+	host, port := httpclnt.GetHostPort(svr.URL)
+	pdAPI := api.NewPartnerDirectory(httpclnt.New("", "", "", "", "user", "password", host, "http", port, false))
+
+	pdRepo := repo.NewPartnerDirectory(t.TempDir())
+	_, err := pdRepo.WriteStringParameters("PID1", []api.StringParameter{
+		{Pid: "PID1", ID: "Old", Value: "local"},
+		{Pid: "PID1", ID: "New", Value: "local"},
+		{Pid: "PID1", ID: "LocalOnly", Value: "local"},
+	}, true)
+	require.NoError(t, err)
+
+	summary := newPDSnapshotSummary()
+	count, err := snapshotStringParameters(pdAPI, pdRepo, true, nil, since, 1, summary)
+	require.NoError(t, err)
+	assert.Equal(t, 1, count)
+	assert.Equal(t, PDSnapshotCounts{Written: 1}, summary.String)
+
+	params, err := pdRepo.ReadStringParameters("PID1")
+	require.NoError(t, err)
+	values := make(map[string]string)
+	for _, p := range params {
+		values[p.ID] = p.Value
+	}
+	assert.Equal(t, map[string]string{"Old": "local", "New": "tenant", "LocalOnly": "local"}, values)
+}
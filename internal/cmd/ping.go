@@ -0,0 +1,104 @@
+package cmd
+
+import (
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"time"
+
+	"github.com/engswee/flashpipe/internal/analytics"
+	"github.com/engswee/flashpipe/internal/api"
+	"github.com/engswee/flashpipe/internal/httpclnt"
+	"github.com/engswee/flashpipe/internal/str"
+	"github.com/rs/zerolog/log"
+	"github.com/spf13/cobra"
+	"golang.org/x/oauth2"
+)
+
+// pingPath is the cheap authenticated call of the ping command
+const pingPath = "/api/v1/IntegrationPackages?$top=1"
+
+func NewPingCommand() *cobra.Command {
+
+	pingCmd := &cobra.Command{
+		Use:   "ping",
+		Short: "Check the credentials and connectivity of the tenant",
+		Long: `Check that the tenant can be reached with the configured credentials,
+e.g. before a long pipeline step.
+
+The credentials are resolved like in the other commands, from the CLI
+flags, environment variables or the global config file. A single
+integration package is read from the tenant, nothing is changed.
+
+The command fails when the tenant cannot be reached or the credentials
+are rejected.`,
+		Example: `  # Check the credentials of the config file
+  flashpipe ping --config ./flashpipe-qa.yaml`,
+		RunE: func(cmd *cobra.Command, args []string) (err error) {
+			startTime := time.Now()
+			if err = runPing(cmd); err != nil {
+				cmd.SilenceUsage = true
+			}
+			analytics.Log(cmd, err, startTime)
+			return
+		},
+	}
+
+	return pingCmd
+}
+
+func runPing(cmd *cobra.Command) error {
+	log.Info().Msg("Executing ping command")
+
+	serviceDetails := getServiceDetailsFromViperOrCmd(cmd)
+	if serviceDetails == nil || serviceDetails.Host == "" {
+		return fmt.Errorf("no tenant configured: set --tmn-host and the credentials via CLI flags, environment variables or the config file")
+	}
+	exe := api.InitHTTPExecuter(serviceDetails).WithContext(cmd.Context())
+
+	startTime := time.Now()
+	if err := pingTenant(exe, serviceDetails); err != nil {
+		return err
+	}
+
+	fmt.Fprintf(cmd.OutOrStdout(), "Connected to %s using %s in %s\n", serviceDetails.Host, authMethod(serviceDetails),
+		time.Since(startTime).Round(time.Millisecond))
+	return nil
+}
+
+// pingTenant reads a single integration package to check the credentials and connectivity
+func pingTenant(exe *httpclnt.HTTPExecuter, serviceDetails *api.ServiceDetails) error {
+	resp, err := exe.ExecGetRequest(pingPath, map[string]string{"Accept": "application/json"})
+	if err != nil {
+		var retrieveErr *oauth2.RetrieveError
+		if errors.As(err, &retrieveErr) {
+			return fmt.Errorf("authentication failed: OAuth token request to %s%s returned response code %d, check the OAuth client ID and secret",
+				serviceDetails.OauthHost, serviceDetails.OauthPath, retrieveErr.Response.StatusCode)
+		}
+		return fmt.Errorf("failed to connect to %s: %w", serviceDetails.Host, err)
+	}
+	defer resp.Body.Close()
+	_, _ = io.Copy(io.Discard, resp.Body)
+
+	switch resp.StatusCode {
+	case http.StatusOK:
+		return nil
+	case http.StatusUnauthorized:
+		return fmt.Errorf("authentication failed: %s rejected the credentials of %s (response code %d)",
+			serviceDetails.Host, authMethod(serviceDetails), resp.StatusCode)
+	case http.StatusForbidden:
+		return fmt.Errorf("authorization failed: the credentials of %s are valid but not allowed to read integration packages on %s (response code %d)",
+			authMethod(serviceDetails), serviceDetails.Host, resp.StatusCode)
+	default:
+		return fmt.Errorf("ping of %s failed with response code %d", serviceDetails.Host, resp.StatusCode)
+	}
+}
+
+// authMethod describes the authentication of the service details with the masked client or user ID
+func authMethod(serviceDetails *api.ServiceDetails) string {
+	if serviceDetails.OauthHost != "" {
+		return fmt.Sprintf("OAuth (client %s)", str.Mask(serviceDetails.OauthClientId))
+	}
+	return fmt.Sprintf("Basic Auth (user %s)", str.Mask(serviceDetails.Userid))
+}
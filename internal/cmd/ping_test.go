@@ -0,0 +1,51 @@
+package cmd
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/engswee/flashpipe/internal/api"
+	"github.com/engswee/flashpipe/internal/httpclnt"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestPingTenant(t *testing.T) {
+	svr := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		switch r.URL.Path {
+		case "/oauth/token":
+			w.WriteHeader(http.StatusUnauthorized)
+			return
+		case "/api/v1/IntegrationPackages":
+			assert.Equal(t, "1", r.URL.Query().Get("$top"))
+		}
+		user, password, _ := r.BasicAuth()
+		switch {
+		case user == "reader" && password == "password":
+			w.WriteHeader(http.StatusForbidden)
+		case user != "user" || password != "password":
+			w.WriteHeader(http.StatusUnauthorized)
+		default:
+			w.Write([]byte(`{"d":{"results":[]}}`))
+		}
+	}))
+	defer svr.Close()
+	host, port := httpclnt.GetHostPort(svr.URL)
+
+	serviceDetails := &api.ServiceDetails{Host: host, Userid: "user", Password: "password"}
+	exe := httpclnt.New("", "", "", "", "user", "password", host, "http", port, false)
+	assert.NoError(t, pingTenant(exe, serviceDetails))
+
+	exe = httpclnt.New("", "", "", "", "user", "wrong", host, "http", port, false)
+	assert.ErrorContains(t, pingTenant(exe, serviceDetails), "authentication failed: "+host+" rejected the credentials of Basic Auth (user ****) (response code 401)")
+
+	exe = httpclnt.New("", "", "", "", "reader", "password", host, "http", port, false)
+	assert.ErrorContains(t, pingTenant(exe, serviceDetails), "authorization failed")
+
+	serviceDetails = &api.ServiceDetails{Host: host, OauthHost: host, OauthPath: "/oauth/token", OauthClientId: "sb-clientid-1234567890"}
+	exe = httpclnt.New(host, "/oauth/token", "sb-clientid-1234567890", "wrong", "", "", host, "http", port, false)
+	err := pingTenant(exe, serviceDetails)
+	assert.ErrorContains(t, err, "authentication failed: OAuth token request")
+	assert.ErrorContains(t, err, "response code 401")
+	assert.Equal(t, "OAuth (client sb-****890)", authMethod(serviceDetails))
+}
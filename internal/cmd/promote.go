@@ -0,0 +1,299 @@
+package cmd
+
+import (
+	"context"
+	"fmt"
+	"os"
+	"path/filepath"
+	"slices"
+	"time"
+
+	"github.com/engswee/flashpipe/internal/analytics"
+	"github.com/engswee/flashpipe/internal/api"
+	"github.com/engswee/flashpipe/internal/config"
+	"github.com/engswee/flashpipe/internal/deploy"
+	"github.com/engswee/flashpipe/internal/httpclnt"
+	"github.com/engswee/flashpipe/internal/models"
+	"github.com/engswee/flashpipe/internal/str"
+	"github.com/engswee/flashpipe/internal/sync"
+	"github.com/rs/zerolog/log"
+	"github.com/spf13/cobra"
+)
+
+// promoteResult counts the work of a promote run
+type promoteResult struct {
+	Packages  int
+	Artifacts int
+	Failed    []string
+}
+
+func NewPromoteCommand() *cobra.Command {
+
+	promoteCmd := &cobra.Command{
+		Use:   "promote",
+		Short: "Promote integration packages from a source tenant to the target tenant",
+		Long: `Copy integration packages and their artifacts from a source tenant, e.g.
+DEV, to the target tenant, e.g. QA, without storing them in Git.
+
+The artifacts are downloaded from the source tenant into a temporary
+working directory and created or updated on the target tenant. The target
+tenant is set with the global flags (--tmn-host, --oauth-host, ...), the
+source tenant with the --source-* flags.
+
+With --deployment-prefix, the package and artifact IDs are prefixed on the
+target tenant like in the orchestrator. Artifacts are not deployed.
+
+Configuration:
+  Settings can be loaded from the global config file (--config) under the
+  'promote' section. CLI flags override config file settings.`,
+		Example: `  # Promote two packages from DEV to QA
+  flashpipe promote --package-ids Orders,Invoices \
+    --source-tmn-host dev-tenant.it-cpi001.cfapps.eu10.hana.ondemand.com \
+    --source-oauth-host dev-tenant.authentication.eu10.hana.ondemand.com \
+    --source-oauth-clientid $DEV_CLIENT_ID --source-oauth-clientsecret $DEV_CLIENT_SECRET \
+    --config ./flashpipe-qa.yaml
+
+  # Promote a single artifact with prefixed IDs
+  flashpipe promote --package-ids Orders --artifact-ids Order_Create \
+    --deployment-prefix QA --source-tmn-host dev-tenant.example.com \
+    --source-tmn-userid $DEV_USER --source-tmn-password $DEV_PASSWORD`,
+		PreRunE: func(cmd *cobra.Command, args []string) error {
+			draftHandling := config.GetStringWithFallback(cmd, "draft-handling", "promote.draftHandling")
+			switch draftHandling {
+			case "SKIP", "ADD", "ERROR":
+			default:
+				return fmt.Errorf("invalid value for --draft-handling = %v", draftHandling)
+			}
+			return nil
+		},
+		RunE: func(cmd *cobra.Command, args []string) (err error) {
+			startTime := time.Now()
+			if err = runPromote(cmd); err != nil {
+				cmd.SilenceUsage = true
+			}
+			analytics.Log(cmd, err, startTime)
+			return
+		},
+	}
+
+	// Define cobra flags, the default value has the lowest (least significant) precedence
+	// Note: These can be set in config file under 'promote' key
+	promoteCmd.Flags().StringSlice("package-ids", nil, "IDs of the integration packages on the source tenant (config: promote.packageIds)")
+	promoteCmd.Flags().StringSlice("artifact-ids", nil, "IDs of the artifacts to promote, all artifacts of the packages if not set (config: promote.artifactIds)")
+	promoteCmd.Flags().StringP("deployment-prefix", "p", "", "Deployment prefix for package/artifact IDs on the target tenant (config: promote.deploymentPrefix)")
+	promoteCmd.Flags().String("draft-handling", "SKIP", "Handling when artifact is in draft version. Allowed values: SKIP, ADD, ERROR (config: promote.draftHandling)")
+	promoteCmd.Flags().String("dir-work", "", "Working directory for in-transit files, a temporary directory if not set (config: promote.dirWork)")
+	promoteCmd.Flags().String("source-tmn-host", "", "Host for tenant management node of the source tenant excluding https:// (config: promote.sourceTmnHost)")
+	promoteCmd.Flags().String("source-tmn-userid", "", "User ID for Basic Auth on the source tenant (config: promote.sourceTmnUserid)")
+	promoteCmd.Flags().String("source-tmn-password", "", "Password for Basic Auth on the source tenant (config: promote.sourceTmnPassword)")
+	promoteCmd.Flags().String("source-oauth-host", "", "Host for OAuth token server of the source tenant excluding https:// (config: promote.sourceOauthHost)")
+	promoteCmd.Flags().String("source-oauth-clientid", "", "Client ID for using OAuth on the source tenant (config: promote.sourceOauthClientid)")
+	promoteCmd.Flags().String("source-oauth-clientsecret", "", "Client Secret for using OAuth on the source tenant (config: promote.sourceOauthClientsecret)")
+	promoteCmd.Flags().String("source-oauth-path", "", "Path for OAuth token server of the source tenant, defaults to --oauth-path (config: promote.sourceOauthPath)")
+
+	promoteCmd.MarkFlagsRequiredTogether("source-tmn-userid", "source-tmn-password")
+	promoteCmd.MarkFlagsRequiredTogether("source-oauth-clientid", "source-oauth-clientsecret")
+
+	return promoteCmd
+}
+
+func runPromote(cmd *cobra.Command) error {
+	log.Info().Msg("Executing promote command")
+
+	packageIds := str.TrimSlice(config.GetStringSliceWithFallback(cmd, "package-ids", "promote.packageIds"))
+	artifactIds := str.TrimSlice(config.GetStringSliceWithFallback(cmd, "artifact-ids", "promote.artifactIds"))
+	prefix := config.GetStringWithFallback(cmd, "deployment-prefix", "promote.deploymentPrefix")
+	draftHandling := config.GetStringWithFallback(cmd, "draft-handling", "promote.draftHandling")
+	workDir, err := config.GetStringWithEnvExpandAndFallback(cmd, "dir-work", "promote.dirWork")
+	if err != nil {
+		return fmt.Errorf("security alert for --dir-work: %w", err)
+	}
+
+	if len(packageIds) == 0 {
+		return fmt.Errorf("--package-ids is required (set via CLI flag or in config file under 'promote.packageIds')")
+	}
+
+	sourceDetails, err := getSourceServiceDetails(cmd)
+	if err != nil {
+		return err
+	}
+	targetDetails := getServiceDetailsFromViperOrCmd(cmd)
+	if targetDetails == nil || targetDetails.Host == "" {
+		return fmt.Errorf("no target tenant configured: set --tmn-host and the credentials via CLI flags, environment variables or the config file")
+	}
+	if sourceDetails.Host == targetDetails.Host && prefix == "" {
+		return fmt.Errorf("source and target tenant are both %s: set --deployment-prefix to promote the packages within the same tenant", targetDetails.Host)
+	}
+
+	if workDir == "" {
+		workDir, err = os.MkdirTemp("", "flashpipe-promote-*")
+		if err != nil {
+			return fmt.Errorf("failed to create working directory: %w", err)
+		}
+		defer os.RemoveAll(workDir)
+	}
+
+	log.Info().Msgf("Promoting from %s to %s", sourceDetails.Host, targetDetails.Host)
+	source := api.InitHTTPExecuter(sourceDetails).WithContext(cmd.Context())
+	target := api.InitHTTPExecuter(targetDetails).WithContext(cmd.Context())
+
+	result := &promoteResult{}
+	for _, packageId := range packageIds {
+		if err := cmd.Context().Err(); err != nil {
+			return err
+		}
+		if err := promotePackage(source, target, packageId, prefix, artifactIds, draftHandling, filepath.Join(workDir, packageId), result); err != nil {
+			return err
+		}
+	}
+
+	log.Info().Msg("---------------------------------------------------------------------------------")
+	log.Info().Msgf("🏆 Promoted %d artifact(s) in %d package(s) to %s", result.Artifacts, result.Packages, targetDetails.Host)
+	if len(result.Failed) > 0 {
+		return fmt.Errorf("promotion failed for %d artifact(s): %v", len(result.Failed), result.Failed)
+	}
+	return nil
+}
+
+// getSourceServiceDetails returns the service details of the source tenant from the --source-*
+// flags. Like the global flags, OAuth is used when the OAuth host is set.
+func getSourceServiceDetails(cmd *cobra.Command) (*api.ServiceDetails, error) {
+	host := config.GetStringWithFallback(cmd, "source-tmn-host", "promote.sourceTmnHost")
+	if host == "" {
+		return nil, fmt.Errorf("--source-tmn-host is required (set via CLI flag or in config file under 'promote.sourceTmnHost')")
+	}
+
+	oauthHost := config.GetStringWithFallback(cmd, "source-oauth-host", "promote.sourceOauthHost")
+	if oauthHost == "" {
+		userid := config.GetStringWithFallback(cmd, "source-tmn-userid", "promote.sourceTmnUserid")
+		if userid == "" {
+			return nil, fmt.Errorf("--source-tmn-userid (Basic Auth) or --source-oauth-host (OAuth) is required for the source tenant")
+		}
+		return &api.ServiceDetails{
+			Host:     host,
+			Userid:   userid,
+			Password: config.GetStringWithFallback(cmd, "source-tmn-password", "promote.sourceTmnPassword"),
+		}, nil
+	}
+
+	oauthPath := config.GetStringWithFallback(cmd, "source-oauth-path", "promote.sourceOauthPath")
+	if oauthPath == "" {
+		oauthPath = config.GetString(cmd, "oauth-path")
+	}
+	return &api.ServiceDetails{
+		Host:              host,
+		OauthHost:         oauthHost,
+		OauthClientId:     config.GetStringWithFallback(cmd, "source-oauth-clientid", "promote.sourceOauthClientid"),
+		OauthClientSecret: config.GetStringWithFallback(cmd, "source-oauth-clientsecret", "promote.sourceOauthClientsecret"),
+		OauthPath:         api.OauthPathOrDefault(oauthPath),
+	}, nil
+}
+
+// promotePackage downloads the artifacts of a package from the source tenant and creates or
+// updates the package and its artifacts on the target tenant
+func promotePackage(source, target *httpclnt.HTTPExecuter, packageId, prefix string, artifactIds []string,
+	draftHandling, workDir string, result *promoteResult) error {
+
+	log.Info().Msg("---------------------------------------------------------------------------------")
+	log.Info().Msgf("📦 Package: %s", packageId)
+
+	downloader := sync.New(source)
+	packageData, readOnly, _, err := downloader.VerifyDownloadablePackage(packageId)
+	if err != nil {
+		return err
+	}
+	if readOnly {
+		return nil
+	}
+
+	artifacts, err := api.NewIntegrationPackage(source).GetAllArtifacts(packageId)
+	if err != nil {
+		return err
+	}
+	artifacts = promotedArtifacts(artifacts, artifactIds)
+	if len(artifacts) == 0 {
+		log.Info().Msgf("No artifacts to promote in package %s", packageId)
+		return nil
+	}
+
+	// Download the artifacts, drafts are handled like in snapshot
+	artifactsDir := filepath.Join(workDir, "artifacts")
+	includedIds := make([]string, 0, len(artifacts))
+	for _, artifact := range artifacts {
+		includedIds = append(includedIds, artifact.Id)
+	}
+	if err := downloader.ArtifactsToGit(packageId, workDir, artifactsDir, includedIds, nil, draftHandling, "ID", nil); err != nil {
+		return err
+	}
+
+	pkg := promotedPackage(packageData)
+	finalPackageID, finalPackageName := packageIdentity(&pkg, prefix, true)
+	ip := api.NewIntegrationPackage(target)
+	exists, readOnly, err := tenantPackageState(ip, finalPackageID)
+	if err != nil {
+		return err
+	}
+	if readOnly {
+		return fmt.Errorf("package %s is Configure-only (read-only) on the target tenant and cannot be updated", finalPackageID)
+	}
+	if err := updatePackage(context.Background(), ip, &pkg, finalPackageID, finalPackageName, exists); err != nil {
+		return err
+	}
+	result.Packages++
+
+	uploader := sync.New(target)
+	for _, artifact := range artifacts {
+		artifactDir := filepath.Join(artifactsDir, artifact.Id)
+		if !deploy.DirExists(artifactDir) {
+			// Skipped as draft
+			continue
+		}
+
+		finalArtifactID := prefixedArtifactID(prefix, models.Artifact{Id: artifact.Id})
+		log.Info().Msgf("  Promoting: %s", finalArtifactID)
+		if finalArtifactID != artifact.Id {
+			manifestPath := filepath.Join(artifactDir, "META-INF", "MANIFEST.MF")
+			if err := deploy.UpdateManifestBundleName(manifestPath, finalArtifactID, artifact.Name, manifestPath); err != nil {
+				log.Warn().Msgf("Failed to update MANIFEST.MF: %v", err)
+			}
+		}
+
+		err := uploader.SingleArtifactToTenant(finalArtifactID, artifact.Name, artifact.ArtifactType, finalPackageID,
+			artifactDir, filepath.Join(workDir, "upload", artifact.Id), "", nil)
+		if err != nil {
+			log.Error().Msgf("Promotion failed for %s: %v", finalArtifactID, err)
+			result.Failed = append(result.Failed, finalArtifactID)
+			continue
+		}
+		result.Artifacts++
+	}
+	return nil
+}
+
+// promotedArtifacts returns the artifacts of a package selected by --artifact-ids. IDs that are
+// not in the package are ignored, as they may belong to another package.
+func promotedArtifacts(artifacts []*api.ArtifactDetails, artifactIds []string) []*api.ArtifactDetails {
+	if len(artifactIds) == 0 {
+		return artifacts
+	}
+	var selected []*api.ArtifactDetails
+	for _, artifact := range artifacts {
+		if slices.Contains(artifactIds, artifact.Id) {
+			selected = append(selected, artifact)
+		}
+	}
+	return selected
+}
+
+// promotedPackage returns the package of the source tenant in the form of a deploy config
+// package, so that it is created on the target tenant like by the orchestrator
+func promotedPackage(packageData *api.PackageSingleData) models.Package {
+	return models.Package{
+		ID:          packageData.Root.Id,
+		DisplayName: packageData.Root.Name,
+		Description: packageData.Root.Description,
+		ShortText:   packageData.Root.ShortText,
+		Version:     packageData.Root.Version,
+		Vendor:      packageData.Root.Vendor,
+	}
+}
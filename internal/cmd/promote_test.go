@@ -0,0 +1,80 @@
+package cmd
+
+import (
+	"testing"
+
+	"github.com/engswee/flashpipe/internal/api"
+	"github.com/spf13/cobra"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestGetSourceServiceDetails(t *testing.T) {
+	newCmd := func(flags map[string]string) *cobra.Command {
+		cmd := NewPromoteCommand()
+		cmd.Flags().String("oauth-path", "/oauth2/api/v1/token", "")
+		for name, value := range flags {
+			require.NoError(t, cmd.Flags().Set(name, value))
+		}
+		return cmd
+	}
+
+	_, err := getSourceServiceDetails(newCmd(nil))
+	assert.ErrorContains(t, err, "--source-tmn-host is required")
+
+	_, err = getSourceServiceDetails(newCmd(map[string]string{"source-tmn-host": "dev.example.com"}))
+	assert.ErrorContains(t, err, "--source-tmn-userid (Basic Auth) or --source-oauth-host (OAuth) is required")
+
+	serviceDetails, err := getSourceServiceDetails(newCmd(map[string]string{
+		"source-tmn-host":     "dev.example.com",
+		"source-tmn-userid":   "user",
+		"source-tmn-password": "password",
+	}))
+	require.NoError(t, err)
+	assert.Equal(t, &api.ServiceDetails{Host: "dev.example.com", Userid: "user", Password: "password"}, serviceDetails)
+
+	// The OAuth path of the target tenant is used unless the source sets its own
+	serviceDetails, err = getSourceServiceDetails(newCmd(map[string]string{
+		"source-tmn-host":           "dev.example.com",
+		"source-oauth-host":         "dev.authentication.example.com",
+		"source-oauth-clientid":     "clientid",
+		"source-oauth-clientsecret": "secret",
+	}))
+	require.NoError(t, err)
+	assert.Equal(t, "/oauth2/api/v1/token", serviceDetails.OauthPath)
+	assert.Equal(t, "clientid", serviceDetails.OauthClientId)
+
+	serviceDetails, err = getSourceServiceDetails(newCmd(map[string]string{
+		"source-tmn-host":   "dev.example.com",
+		"source-oauth-host": "dev.authentication.example.com",
+		"source-oauth-path": "/oauth/token",
+	}))
+	require.NoError(t, err)
+	assert.Equal(t, "/oauth/token", serviceDetails.OauthPath)
+}
+
+func TestPromotedArtifacts(t *testing.T) {
+	artifacts := []*api.ArtifactDetails{
+		{Id: "Order_Create", ArtifactType: "Integration"},
+		{Id: "Order_Mapping", ArtifactType: "MessageMapping"},
+	}
+
+	assert.Equal(t, artifacts, promotedArtifacts(artifacts, nil))
+	// IDs of other packages are ignored
+	assert.Equal(t, artifacts[1:], promotedArtifacts(artifacts, []string{"Order_Mapping", "Invoice_Create"}))
+	assert.Empty(t, promotedArtifacts(artifacts, []string{"Invoice_Create"}))
+}
+
+func TestPromotedPackage(t *testing.T) {
+	packageData := new(api.PackageSingleData)
+	packageData.Root.Id = "Orders"
+	packageData.Root.Name = "Order Processing"
+	packageData.Root.ShortText = "Orders"
+	packageData.Root.Version = "1.0.2"
+
+	pkg := promotedPackage(packageData)
+	finalPackageID, finalPackageName := packageIdentity(&pkg, "QA", true)
+	assert.Equal(t, "QAOrders", finalPackageID)
+	assert.Equal(t, "QA - Order Processing", finalPackageName)
+	assert.Equal(t, "1.0.2", pkg.Version)
+}
@@ -0,0 +1,503 @@
+package cmd
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+	"text/tabwriter"
+	"time"
+
+	"github.com/engswee/flashpipe/internal/analytics"
+	"github.com/engswee/flashpipe/internal/api"
+	"github.com/engswee/flashpipe/internal/config"
+	"github.com/engswee/flashpipe/internal/deploy"
+	"github.com/engswee/flashpipe/internal/httpclnt"
+	"github.com/engswee/flashpipe/internal/models"
+	"github.com/rs/zerolog/log"
+	"github.com/spf13/cobra"
+	"github.com/spf13/viper"
+)
+
+// ReconcileAction is the change needed to bring an artifact on the tenant to the state of the deploy config
+type ReconcileAction string
+
+const (
+	ReconcileCreate ReconcileAction = "create"
+	ReconcileUpdate ReconcileAction = "update"
+	ReconcileDelete ReconcileAction = "delete"
+	// ReconcileUnmanaged marks artifacts on the tenant that are not in the config and are kept without --prune
+	ReconcileUnmanaged ReconcileAction = "unmanaged"
+)
+
+// ReconcileChange is a single planned change. PackageID and ArtifactID are the IDs on the
+// tenant, i.e. including the deployment prefix.
+type ReconcileChange struct {
+	Action       ReconcileAction `json:"action"`
+	PackageID    string          `json:"packageId"`
+	ArtifactID   string          `json:"artifactId"`
+	ArtifactType string          `json:"artifactType"`
+	Reasons      []string        `json:"reasons,omitempty"`
+
+	// IDs as written in the deploy config, used to filter the orchestrator run on apply
+	configPackageID  string
+	configArtifactID string
+}
+
+// ReconcilePlan is the drift between the deploy config (desired state) and the tenant (actual state)
+type ReconcilePlan struct {
+	Changes []ReconcileChange `json:"changes"`
+	InSync  int               `json:"inSync"`
+}
+
+// count returns the number of changes with the given action
+func (p *ReconcilePlan) count(action ReconcileAction) int {
+	n := 0
+	for _, c := range p.Changes {
+		if c.Action == action {
+			n++
+		}
+	}
+	return n
+}
+
+func NewReconcileCommand() *cobra.Command {
+
+	reconcileCmd := &cobra.Command{
+		Use:   "reconcile",
+		Short: "Reconcile the tenant with a deployment config",
+		Long: `Compare a deployment config (desired state) with the artifacts on the
+SAP Integration Suite tenant (actual state) and apply only the necessary changes.
+
+Artifacts are reported as:
+  - create:    in the config but missing on the tenant
+  - update:    version or configuration parameters differ from the config
+  - delete:    on the tenant but not in the config (only with --prune)
+  - unmanaged: on the tenant but not in the config, kept without --prune
+
+Without --apply only the plan is shown, so it can be reviewed first. With
+--apply and --plan-file, the plan file is the reviewed plan: the changes are
+only applied when the plan computed from the tenant still matches it.
+
+Configuration:
+  Settings can be loaded from the global config file (--config) under the
+  'reconcile' section. CLI flags override config file settings.`,
+		Example: `  # Show the plan
+  flashpipe reconcile --deploy-config ./001-deploy-config.yml --packages-dir ./packages
+
+  # Apply the plan, deleting artifacts that are no longer in the config
+  flashpipe reconcile --deploy-config ./001-deploy-config.yml --packages-dir ./packages --prune --apply
+
+  # Write the plan for review, then apply it only if the tenant has not changed since
+  flashpipe reconcile --deploy-config ./001-deploy-config.yml --packages-dir ./packages --plan-file plan.json
+  flashpipe reconcile --deploy-config ./001-deploy-config.yml --packages-dir ./packages --plan-file plan.json --apply`,
+		RunE: func(cmd *cobra.Command, args []string) (err error) {
+			startTime := time.Now()
+			if err = runReconcile(cmd); err != nil {
+				cmd.SilenceUsage = true
+			}
+			analytics.Log(cmd, err, startTime)
+			return
+		},
+	}
+
+	// Define cobra flags, the default value has the lowest (least significant) precedence
+	// Note: These can be set in config file under 'reconcile' key
+	reconcileCmd.Flags().StringP("deploy-config", "c", "", "Path to deployment config file/folder/URL (config: reconcile.deployConfig)")
+	reconcileCmd.Flags().StringP("packages-dir", "d", "", "Directory containing packages (config: reconcile.packagesDir)")
+	reconcileCmd.Flags().StringP("deployment-prefix", "p", "", "Deployment prefix for package/artifact IDs (config: reconcile.deploymentPrefix)")
+	reconcileCmd.Flags().Bool("prune", false, "Delete artifacts of the configured packages that are not in the config (config: reconcile.prune)")
+	reconcileCmd.Flags().Bool("apply", false, "Apply the plan instead of only showing it (config: reconcile.apply)")
+	reconcileCmd.Flags().String("output", "table", "Output format of the plan: 'table' or 'json' (config: reconcile.output)")
+	reconcileCmd.Flags().String("plan-file", "", "Also write the plan as JSON to this file, with --apply the reviewed plan that must still match (config: reconcile.planFile)")
+
+	_ = reconcileCmd.MarkFlagRequired("deploy-config")
+	return reconcileCmd
+}
+
+func runReconcile(cmd *cobra.Command) error {
+	deployConfig := config.GetStringWithFallback(cmd, "deploy-config", "reconcile.deployConfig")
+	packagesDir := config.GetStringWithFallback(cmd, "packages-dir", "reconcile.packagesDir")
+	deploymentPrefix := config.GetStringWithFallback(cmd, "deployment-prefix", "reconcile.deploymentPrefix")
+	prune := config.GetBoolWithFallback(cmd, "prune", "reconcile.prune")
+	apply := config.GetBoolWithFallback(cmd, "apply", "reconcile.apply")
+	output := config.GetStringWithFallback(cmd, "output", "reconcile.output")
+	planFile := config.GetStringWithFallback(cmd, "plan-file", "reconcile.planFile")
+
+	if output != "table" && output != "json" {
+		return fmt.Errorf("invalid output format %q: must be 'table' or 'json'", output)
+	}
+	if err := deploy.ValidateDeploymentPrefix(deploymentPrefix); err != nil {
+		return err
+	}
+
+	log.Info().Msg("Executing reconcile command")
+
+	configLoader := deploy.NewConfigLoader()
+	if err := configLoader.DetectSource(deployConfig); err != nil {
+		return &ConfigLoadError{Source: deployConfig, Err: fmt.Errorf("failed to detect config source: %w", err)}
+	}
+	configFiles, err := configLoader.LoadConfigsContext(cmd.Context())
+	if err != nil {
+		return &ConfigLoadError{Source: deployConfig, Err: fmt.Errorf("failed to load deployment config: %w", err)}
+	}
+	if err := resolveArtifactTypes(configFiles, viper.GetStringMapString("artifactTypeAliases")); err != nil {
+		return &ConfigLoadError{Source: deployConfig, Err: err}
+	}
+	if deploymentPrefix != "" {
+		for _, configFile := range configFiles {
+			configFile.Config.DeploymentPrefix = deploymentPrefix
+		}
+	}
+
+	serviceDetails := getServiceDetailsFromViperOrCmd(cmd)
+	exe := api.InitHTTPExecuter(serviceDetails).WithContext(cmd.Context())
+
+	plan, err := buildReconcilePlan(configFiles, packagesDir, prune, exe)
+	if err != nil {
+		return err
+	}
+
+	if output == "json" {
+		if err := writeReconcilePlanJSON(cmd.OutOrStdout(), plan); err != nil {
+			return err
+		}
+	} else {
+		writeReconcilePlanTable(cmd.OutOrStdout(), plan)
+	}
+
+	if !apply {
+		if planFile != "" {
+			if err := writeReconcilePlanFile(plan, planFile); err != nil {
+				return err
+			}
+			log.Info().Msgf("Plan written to %s", planFile)
+		}
+		log.Info().Msg("Plan only, run with --apply to apply the changes")
+		return nil
+	}
+
+	// The reviewed plan is not applied as it is, as the tenant may have changed since it was written
+	if planFile != "" {
+		reviewed, err := readReconcilePlanFile(planFile)
+		if err != nil {
+			return err
+		}
+		if err := verifyReconcilePlan(reviewed, plan); err != nil {
+			return err
+		}
+		log.Info().Msgf("Plan matches the reviewed plan %s", planFile)
+	}
+	return applyReconcilePlan(cmd.Context(), plan, deployConfig, packagesDir, deploymentPrefix, serviceDetails, exe)
+}
+
+// buildReconcilePlan compares the packages of all configs with the tenant
+func buildReconcilePlan(configFiles []*deploy.DeployConfigFile, packagesDir string, prune bool, exe *httpclnt.HTTPExecuter) (*ReconcilePlan, error) {
+	plan := &ReconcilePlan{Changes: []ReconcileChange{}}
+	ip := api.NewIntegrationPackage(exe)
+	configuration := api.NewConfiguration(exe)
+
+	for _, configFile := range configFiles {
+		prefix := configFile.Config.DeploymentPrefix
+		for _, pkg := range configFile.Config.Packages {
+			if !pkg.Sync {
+				log.Debug().Msgf("Skipping package %s (sync=false)", pkg.ID)
+				continue
+			}
+			finalPackageID := prefix + pkg.ID
+
+			_, _, exists, err := ip.Get(finalPackageID)
+			if err != nil {
+				return nil, fmt.Errorf("failed to get package %s: %w", finalPackageID, err)
+			}
+			var tenantArtifacts []*api.ArtifactDetails
+			if exists {
+				if tenantArtifacts, err = ip.GetAllArtifacts(finalPackageID); err != nil {
+					return nil, fmt.Errorf("failed to get artifacts of package %s: %w", finalPackageID, err)
+				}
+			}
+
+			packageDir := filepath.Join(packagesDir, pkg.PackageDir)
+			if err := planPackage(plan, &pkg, finalPackageID, prefix, packageDir, tenantArtifacts, prune, configuration); err != nil {
+				return nil, err
+			}
+		}
+	}
+	return plan, nil
+}
+
+// planPackage adds the changes of a single package to the plan
+func planPackage(plan *ReconcilePlan, pkg *models.Package, finalPackageID, prefix, packageDir string,
+	tenantArtifacts []*api.ArtifactDetails, prune bool, configuration *api.Configuration) error {
+
+	tenantByID := make(map[string]*api.ArtifactDetails)
+	for _, a := range tenantArtifacts {
+		tenantByID[a.Id] = a
+	}
+
+	managed := make(map[string]bool)
+	for _, artifact := range pkg.Artifacts {
+		finalArtifactID := prefixedArtifactID(prefix, artifact)
+		managed[finalArtifactID] = true
+		if !artifact.Sync {
+			continue
+		}
+
+		change := ReconcileChange{
+			PackageID:        finalPackageID,
+			ArtifactID:       finalArtifactID,
+			ArtifactType:     mapArtifactTypeForSync(artifact.Type),
+			configPackageID:  pkg.ID,
+			configArtifactID: artifact.Id,
+		}
+
+		tenantArtifact := tenantByID[finalArtifactID]
+		if tenantArtifact == nil {
+			change.Action = ReconcileCreate
+			change.Reasons = []string{"missing on tenant"}
+			plan.Changes = append(plan.Changes, change)
+			continue
+		}
+
+		reasons, err := artifactDrift(artifact, filepath.Join(packageDir, artifact.ArtifactDir), tenantArtifact, configuration)
+		if err != nil {
+			return err
+		}
+		if len(reasons) == 0 {
+			plan.InSync++
+			continue
+		}
+		change.Action = ReconcileUpdate
+		change.Reasons = reasons
+		plan.Changes = append(plan.Changes, change)
+	}
+
+	for _, a := range tenantArtifacts {
+		if managed[a.Id] {
+			continue
+		}
+		action := ReconcileUnmanaged
+		if prune {
+			action = ReconcileDelete
+		}
+		plan.Changes = append(plan.Changes, ReconcileChange{
+			Action:       action,
+			PackageID:    finalPackageID,
+			ArtifactID:   a.Id,
+			ArtifactType: a.ArtifactType,
+			Reasons:      []string{"not in config"},
+		})
+	}
+	return nil
+}
+
+// artifactDrift returns why an existing artifact differs from the config: a different
+// version in MANIFEST.MF, a draft on the tenant or configuration parameters that do not
+// match the overrides of an integration flow
+func artifactDrift(artifact models.Artifact, artifactDir string, tenantArtifact *api.ArtifactDetails, configuration *api.Configuration) ([]string, error) {
+	var reasons []string
+
+	headers, err := deploy.GetManifestHeaders(filepath.Join(artifactDir, "META-INF", "MANIFEST.MF"))
+	if err != nil {
+		return nil, err
+	}
+	if tenantArtifact.IsDraft {
+		reasons = append(reasons, "draft version on tenant")
+	} else if localVersion := headers["Bundle-Version"]; localVersion != "" && localVersion != tenantArtifact.Version {
+		reasons = append(reasons, fmt.Sprintf("version %s -> %s", tenantArtifact.Version, localVersion))
+	}
+
+	if tenantArtifact.ArtifactType != "Integration" {
+		return reasons, nil
+	}
+	overrides, err := deploy.LoadArtifactOverrides(artifact)
+	if err != nil {
+		return nil, err
+	}
+	if len(overrides) == 0 {
+		return reasons, nil
+	}
+	parameters, err := configuration.Get(tenantArtifact.Id, "active")
+	if err != nil {
+		return nil, fmt.Errorf("failed to get configuration of %s: %w", tenantArtifact.Id, err)
+	}
+	for _, key := range sortedKeys(overrides) {
+		// Secret values are not resolved for the comparison
+		if deploy.HasSecretReference(overrides[key]) {
+			continue
+		}
+		parameter := api.FindParameterByKey(key, parameters.Root.Results)
+		if parameter == nil || parameter.ParameterValue != deploy.FormatOverrideValue(overrides[key]) {
+			reasons = append(reasons, fmt.Sprintf("parameter %s differs", key))
+		}
+	}
+	return reasons, nil
+}
+
+// applyReconcilePlan creates and updates artifacts with the orchestrator, restricted to
+// the artifacts in the plan, and deletes the artifacts planned for deletion
+func applyReconcilePlan(ctx context.Context, plan *ReconcilePlan, deployConfig, packagesDir, deploymentPrefix string,
+	serviceDetails *api.ServiceDetails, exe *httpclnt.HTTPExecuter) error {
+
+	// The artifact filter of the orchestrator applies to all packages, so each package is
+	// applied separately. Otherwise an in-sync artifact would be updated when an artifact
+	// with the same ID changes in another package.
+	artifactFilters := reconcileArtifactFilters(plan)
+	for _, packageID := range sortedKeys(artifactFilters) {
+		if err := ctx.Err(); err != nil {
+			return err
+		}
+		log.Info().Msgf("Applying %d create/update changes of package %s", len(artifactFilters[packageID]), packageID)
+		_, err := NewOrchestrator(Options{
+			DeployConfig:        deployConfig,
+			PackagesDir:         packagesDir,
+			DeploymentPrefix:    deploymentPrefix,
+			PackageFilter:       []string{packageID},
+			ArtifactFilter:      artifactFilters[packageID],
+			ServiceDetails:      serviceDetails,
+			ArtifactTypeAliases: viper.GetStringMapString("artifactTypeAliases"),
+			SecretsFile:         viper.GetString("orchestrator.secretsFile"),
+			Debug:               viper.GetBool("debug"),
+		}).Run(ctx)
+		if err != nil {
+			return err
+		}
+	}
+
+	rt := api.NewRuntime(exe)
+	for _, c := range plan.Changes {
+		if c.Action != ReconcileDelete {
+			continue
+		}
+		if err := ctx.Err(); err != nil {
+			return err
+		}
+		if err := deleteArtifact(c, rt, exe); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// reconcileArtifactFilters returns the config IDs of the artifacts to create or update by the
+// config ID of their package
+func reconcileArtifactFilters(plan *ReconcilePlan) map[string][]string {
+	filters := make(map[string][]string)
+	for _, c := range plan.Changes {
+		if c.Action == ReconcileCreate || c.Action == ReconcileUpdate {
+			filters[c.configPackageID] = append(filters[c.configPackageID], c.configArtifactID)
+		}
+	}
+	for _, artifactIDs := range filters {
+		sort.Strings(artifactIDs)
+	}
+	return filters
+}
+
+// verifyReconcilePlan returns an error when the create, update and delete changes of plan differ
+// from the reviewed plan. Unmanaged artifacts are not applied, so they are not compared.
+func verifyReconcilePlan(reviewed, plan *ReconcilePlan) error {
+	reviewedChanges := reconcileChangeKeys(reviewed)
+	planChanges := reconcileChangeKeys(plan)
+
+	var differences []string
+	for _, key := range sortedKeys(planChanges) {
+		if !reviewedChanges[key] {
+			differences = append(differences, "new: "+key)
+		}
+	}
+	for _, key := range sortedKeys(reviewedChanges) {
+		if !planChanges[key] {
+			differences = append(differences, "no longer planned: "+key)
+		}
+	}
+	if len(differences) > 0 {
+		return fmt.Errorf("plan differs from the reviewed plan, review the current plan before applying it:\n  %s",
+			strings.Join(differences, "\n  "))
+	}
+	return nil
+}
+
+func reconcileChangeKeys(plan *ReconcilePlan) map[string]bool {
+	keys := make(map[string]bool)
+	for _, c := range plan.Changes {
+		if c.Action == ReconcileUnmanaged {
+			continue
+		}
+		key := fmt.Sprintf("%s %s %s/%s", c.Action, c.ArtifactType, c.PackageID, c.ArtifactID)
+		if len(c.Reasons) > 0 {
+			key += " (" + strings.Join(c.Reasons, ", ") + ")"
+		}
+		keys[key] = true
+	}
+	return keys
+}
+
+// deleteArtifact undeploys an artifact if it is deployed and deletes it from the package
+func deleteArtifact(c ReconcileChange, rt *api.Runtime, exe *httpclnt.HTTPExecuter) error {
+	if _, err := undeployAndDeleteArtifact(rt, exe, c.ArtifactID, c.ArtifactType, false); err != nil {
+		return fmt.Errorf("cannot delete %s: %w", c.ArtifactID, err)
+	}
+	log.Info().Msgf("Deleted %s %s from package %s", c.ArtifactType, c.ArtifactID, c.PackageID)
+	return nil
+}
+
+func writeReconcilePlanJSON(w io.Writer, plan *ReconcilePlan) error {
+	encoder := json.NewEncoder(w)
+	encoder.SetIndent("", "  ")
+	if err := encoder.Encode(plan); err != nil {
+		return fmt.Errorf("failed to encode plan: %w", err)
+	}
+	return nil
+}
+
+func writeReconcilePlanFile(plan *ReconcilePlan, path string) error {
+	if dir := filepath.Dir(path); dir != "" {
+		if err := os.MkdirAll(dir, 0755); err != nil {
+			return fmt.Errorf("failed to create directory for %s: %w", path, err)
+		}
+	}
+	f, err := os.Create(path)
+	if err != nil {
+		return fmt.Errorf("failed to write %s: %w", path, err)
+	}
+	defer f.Close()
+	return writeReconcilePlanJSON(f, plan)
+}
+
+func readReconcilePlanFile(path string) (*ReconcilePlan, error) {
+	content, err := os.ReadFile(path)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read reviewed plan: %w", err)
+	}
+	plan := new(ReconcilePlan)
+	if err := json.Unmarshal(content, plan); err != nil {
+		return nil, fmt.Errorf("failed to parse reviewed plan %s: %w", path, err)
+	}
+	return plan, nil
+}
+
+func writeReconcilePlanTable(w io.Writer, plan *ReconcilePlan) {
+	changes := append([]ReconcileChange(nil), plan.Changes...)
+	sort.SliceStable(changes, func(i, j int) bool {
+		if changes[i].PackageID != changes[j].PackageID {
+			return changes[i].PackageID < changes[j].PackageID
+		}
+		return changes[i].ArtifactID < changes[j].ArtifactID
+	})
+
+	tw := tabwriter.NewWriter(w, 0, 0, 2, ' ', 0)
+	fmt.Fprintln(tw, "ACTION\tPACKAGE\tARTIFACT\tTYPE\tREASON")
+	for _, c := range changes {
+		fmt.Fprintf(tw, "%s\t%s\t%s\t%s\t%s\n", c.Action, c.PackageID, c.ArtifactID, c.ArtifactType, strings.Join(c.Reasons, ", "))
+	}
+	tw.Flush()
+
+	fmt.Fprintf(w, "\nPlan: %d to create, %d to update, %d to delete, %d unmanaged, %d in sync\n",
+		plan.count(ReconcileCreate), plan.count(ReconcileUpdate), plan.count(ReconcileDelete),
+		plan.count(ReconcileUnmanaged), plan.InSync)
+}
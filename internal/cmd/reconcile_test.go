@@ -0,0 +1,202 @@
+package cmd
+
+import (
+	"bytes"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/engswee/flashpipe/internal/api"
+	"github.com/engswee/flashpipe/internal/deploy"
+	"github.com/engswee/flashpipe/internal/httpclnt"
+	"github.com/engswee/flashpipe/internal/models"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func writeTestManifest(t *testing.T, dir, version string) {
+	require.NoError(t, os.MkdirAll(filepath.Join(dir, "META-INF"), 0755))
+	manifest := fmt.Sprintf("Manifest-Version: 1.0\nBundle-Version: %s\n", version)
+	require.NoError(t, os.WriteFile(filepath.Join(dir, "META-INF", "MANIFEST.MF"), []byte(manifest), 0644))
+}
+
+func TestBuildReconcilePlan(t *testing.T) {
+	svr := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		switch r.URL.Path {
+		case "/api/v1/IntegrationPackages('DEVOrders')":
+			fmt.Fprint(w, `{"d":{"Id":"DEVOrders","Name":"Orders"}}`)
+		case "/api/v1/IntegrationPackages('DEVOrders')/IntegrationDesigntimeArtifacts":
+			fmt.Fprint(w, `{"d":{"results":[`+
+				`{"Id":"DEV_OrderFlow","Name":"Order Flow","Version":"1.0.0"},`+
+				`{"Id":"DEV_StableFlow","Name":"Stable Flow","Version":"2.0.0"},`+
+				`{"Id":"DEV_Legacy","Name":"Legacy","Version":"1.0.0"}]}}`)
+		case "/api/v1/IntegrationDesigntimeArtifacts(Id='DEV_OrderFlow',Version='active')/Configurations":
+			fmt.Fprint(w, `{"d":{"results":[{"ParameterKey":"Timeout","ParameterValue":"30000"}]}}`)
+		case "/api/v1/IntegrationPackages('DEVNew')":
+			w.WriteHeader(http.StatusNotFound)
+		default:
+			fmt.Fprint(w, `{"d":{"results":[]}}`)
+		}
+	}))
+	defer svr.Close()
+
+	packagesDir, err := os.MkdirTemp("", "reconcile-packages-*")
+	require.NoError(t, err)
+	defer os.RemoveAll(packagesDir)
+	writeTestManifest(t, filepath.Join(packagesDir, "Orders", "OrderFlow"), "1.0.1")
+	writeTestManifest(t, filepath.Join(packagesDir, "Orders", "StableFlow"), "2.0.0")
+
+	configFiles := []*deploy.DeployConfigFile{{Config: &models.DeployConfig{
+		DeploymentPrefix: "DEV",
+		Packages: []models.Package{
+			{ID: "Orders", PackageDir: "Orders", Sync: true, Deploy: true, Artifacts: []models.Artifact{
+				{Id: "OrderFlow", ArtifactDir: "OrderFlow", Type: "Integration", Sync: true, Deploy: true,
+					ConfigOverrides: map[string]interface{}{"Timeout": 60000}},
+				{Id: "StableFlow", ArtifactDir: "StableFlow", Type: "Integration", Sync: true, Deploy: true},
+				{Id: "NewFlow", ArtifactDir: "NewFlow", Type: "Integration", Sync: true, Deploy: true},
+			}},
+			{ID: "New", PackageDir: "New", Sync: true, Deploy: true, Artifacts: []models.Artifact{
+				{Id: "Scripts", ArtifactDir: "Scripts", Type: "ScriptCollection", Sync: true},
+			}},
+		},
+	}}}
+
+	host, port := httpclnt.GetHostPort(svr.URL)
+	exe := httpclnt.New("", "", "", "", "user", "password", host, "http", port, false)
+
+	plan, err := buildReconcilePlan(configFiles, packagesDir, false, exe)
+	require.NoError(t, err)
+	assert.Equal(t, 1, plan.InSync)
+	require.Len(t, plan.Changes, 4)
+
+	assert.Equal(t, ReconcileUpdate, plan.Changes[0].Action)
+	assert.Equal(t, "DEV_OrderFlow", plan.Changes[0].ArtifactID)
+	assert.Equal(t, []string{"version 1.0.0 -> 1.0.1", "parameter Timeout differs"}, plan.Changes[0].Reasons)
+	assert.Equal(t, "OrderFlow", plan.Changes[0].configArtifactID)
+
+	assert.Equal(t, ReconcileCreate, plan.Changes[1].Action)
+	assert.Equal(t, "DEV_NewFlow", plan.Changes[1].ArtifactID)
+
+	assert.Equal(t, ReconcileUnmanaged, plan.Changes[2].Action)
+	assert.Equal(t, "DEV_Legacy", plan.Changes[2].ArtifactID)
+
+	assert.Equal(t, ReconcileCreate, plan.Changes[3].Action)
+	assert.Equal(t, "DEVNew", plan.Changes[3].PackageID)
+	assert.Equal(t, "ScriptCollection", plan.Changes[3].ArtifactType)
+
+	// Extras are deleted with --prune
+	plan, err = buildReconcilePlan(configFiles, packagesDir, true, exe)
+	require.NoError(t, err)
+	assert.Equal(t, ReconcileDelete, plan.Changes[2].Action)
+
+	var out bytes.Buffer
+	writeReconcilePlanTable(&out, plan)
+	assert.Contains(t, out.String(), "Plan: 2 to create, 1 to update, 1 to delete, 0 unmanaged, 1 in sync")
+	assert.True(t, strings.HasPrefix(out.String(), "ACTION"))
+}
+
+func TestWriteReconcilePlanFile(t *testing.T) {
+	tempDir, err := os.MkdirTemp("", "reconcile-plan-*")
+	require.NoError(t, err)
+	defer os.RemoveAll(tempDir)
+
+	plan := &ReconcilePlan{Changes: []ReconcileChange{{
+		Action: ReconcileCreate, PackageID: "Orders", ArtifactID: "OrderFlow", ArtifactType: "Integration",
+		Reasons: []string{"missing on tenant"}, configArtifactID: "OrderFlow",
+	}}, InSync: 2}
+
+	path := filepath.Join(tempDir, "plans", "plan.json")
+	require.NoError(t, writeReconcilePlanFile(plan, path))
+
+	content, err := os.ReadFile(path)
+	require.NoError(t, err)
+	assert.JSONEq(t, `{"changes":[{"action":"create","packageId":"Orders","artifactId":"OrderFlow",`+
+		`"artifactType":"Integration","reasons":["missing on tenant"]}],"inSync":2}`, string(content))
+}
+
+func TestVerifyReconcilePlan(t *testing.T) {
+	tempDir := t.TempDir()
+	reviewed := &ReconcilePlan{Changes: []ReconcileChange{
+		{Action: ReconcileUpdate, PackageID: "DEVOrders", ArtifactID: "DEV_OrderFlow", ArtifactType: "Integration", Reasons: []string{"version 1.0.0 -> 1.0.1"}},
+		{Action: ReconcileUnmanaged, PackageID: "DEVOrders", ArtifactID: "DEV_Legacy", ArtifactType: "Integration", Reasons: []string{"not in config"}},
+	}, InSync: 1}
+	path := filepath.Join(tempDir, "plan.json")
+	require.NoError(t, writeReconcilePlanFile(reviewed, path))
+	reviewed, err := readReconcilePlanFile(path)
+	require.NoError(t, err)
+
+	// Unmanaged artifacts and the in-sync count are not applied, so they may change
+	plan := &ReconcilePlan{Changes: []ReconcileChange{
+		{Action: ReconcileUpdate, PackageID: "DEVOrders", ArtifactID: "DEV_OrderFlow", ArtifactType: "Integration", Reasons: []string{"version 1.0.0 -> 1.0.1"},
+			configPackageID: "Orders", configArtifactID: "OrderFlow"},
+	}, InSync: 3}
+	assert.NoError(t, verifyReconcilePlan(reviewed, plan))
+
+	// The tenant changed since the plan was reviewed
+	plan.Changes[0].Reasons = []string{"version 1.0.2 -> 1.0.1"}
+	plan.Changes = append(plan.Changes, ReconcileChange{Action: ReconcileDelete, PackageID: "DEVOrders", ArtifactID: "DEV_Other", ArtifactType: "Integration"})
+	err = verifyReconcilePlan(reviewed, plan)
+	require.Error(t, err)
+	assert.Contains(t, err.Error(), "new: delete Integration DEVOrders/DEV_Other")
+	assert.Contains(t, err.Error(), "new: update Integration DEVOrders/DEV_OrderFlow (version 1.0.2 -> 1.0.1)")
+	assert.Contains(t, err.Error(), "no longer planned: update Integration DEVOrders/DEV_OrderFlow (version 1.0.0 -> 1.0.1)")
+
+	_, err = readReconcilePlanFile(filepath.Join(tempDir, "missing.json"))
+	assert.ErrorContains(t, err, "failed to read reviewed plan")
+}
+
+func TestReconcileArtifactFilters(t *testing.T) {
+	plan := &ReconcilePlan{Changes: []ReconcileChange{
+		{Action: ReconcileUpdate, configPackageID: "Orders", configArtifactID: "Mapping"},
+		{Action: ReconcileCreate, configPackageID: "Invoices", configArtifactID: "InvoiceFlow"},
+		{Action: ReconcileCreate, configPackageID: "Orders", configArtifactID: "OrderFlow"},
+		{Action: ReconcileUnmanaged, PackageID: "DEVInvoices", ArtifactID: "DEV_Mapping"},
+		{Action: ReconcileDelete, PackageID: "DEVInvoices", ArtifactID: "DEV_Old"},
+	}}
+
+	// Mapping of Invoices is in sync, so it is not in the filter of that package
+	assert.Equal(t, map[string][]string{
+		"Orders":   {"Mapping", "OrderFlow"},
+		"Invoices": {"InvoiceFlow"},
+	}, reconcileArtifactFilters(plan))
+}
+
+func TestDeleteArtifact(t *testing.T) {
+	var deletes []string
+	svr := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		switch {
+		case r.Method == http.MethodGet && r.URL.Path == "/api/v1/":
+			w.Header().Set("x-csrf-token", "token")
+		case r.Method == http.MethodGet && r.URL.Path == "/api/v1/IntegrationRuntimeArtifacts('OrderFlow')":
+			fmt.Fprint(w, `{"d":{"Version":"1.0.0","Status":"STARTED"}}`)
+		case r.Method == http.MethodGet:
+			w.WriteHeader(http.StatusNotFound)
+		case r.Method == http.MethodDelete:
+			deletes = append(deletes, r.URL.Path)
+			if strings.HasPrefix(r.URL.Path, "/api/v1/IntegrationDesigntimeArtifacts") {
+				w.WriteHeader(http.StatusOK)
+			} else {
+				w.WriteHeader(http.StatusAccepted)
+			}
+		}
+	}))
+	defer svr.Close()
+
+	host, port := httpclnt.GetHostPort(svr.URL)
+	exe := httpclnt.New("", "", "", "", "user", "password", host, "http", port, false)
+	rt := api.NewRuntime(exe)
+
+	// Deployed artifacts are undeployed before they are deleted
+	require.NoError(t, deleteArtifact(ReconcileChange{PackageID: "Orders", ArtifactID: "OrderFlow", ArtifactType: "Integration"}, rt, exe))
+	assert.Equal(t, []string{
+		"/api/v1/IntegrationRuntimeArtifacts('OrderFlow')",
+		"/api/v1/IntegrationDesigntimeArtifacts(Id='OrderFlow',Version='active')",
+	}, deletes)
+
+	err := deleteArtifact(ReconcileChange{PackageID: "Orders", ArtifactID: "Other", ArtifactType: "Unknown"}, rt, exe)
+	assert.EqualError(t, err, "cannot delete Other: unsupported artifact type Unknown")
+}
@@ -1,11 +1,17 @@
 package cmd
 
 import (
+	"context"
+	"errors"
 	"fmt"
+	"net/url"
 	"os"
 	"strings"
+	"time"
 
+	"github.com/engswee/flashpipe/internal/api"
 	"github.com/engswee/flashpipe/internal/config"
+	"github.com/engswee/flashpipe/internal/httpclnt"
 	"github.com/engswee/flashpipe/internal/logger"
 	"github.com/rs/zerolog/log"
 	"github.com/spf13/cobra"
@@ -13,9 +19,16 @@ import (
 	"github.com/spf13/viper"
 )
 
+// timeoutGracePeriod is how long a command may keep running after --timeout is reached
+// before it is forcibly stopped, allowing context-aware commands to print their summary
+const timeoutGracePeriod = time.Minute
+
 func NewCmdRoot() *cobra.Command {
 	var version = "3.7.0" // FLASHPIPE_VERSION
 
+	// stopTimeout releases the resources of the command timeout once the command has finished
+	stopTimeout := func() {}
+
 	// rootCmd represents the base command when called without any subcommands
 	rootCmd := &cobra.Command{
 		Use:     "flashpipe",
@@ -32,11 +45,26 @@ automating time-consuming manual tasks like:
 		SilenceErrors: true,
 		PersistentPreRunE: func(cmd *cobra.Command, args []string) error {
 			// You can bind cobra and viper in a few locations, but PersistencePreRunE on the root command works well
-			return initializeConfig(cmd)
+			if err := initializeConfig(cmd); err != nil {
+				return err
+			}
+			if err := configureHTTPClient(cmd); err != nil {
+				return err
+			}
+			stopTimeout = applyTimeout(cmd, config.GetDuration(cmd, "timeout"))
+			if traceFile := config.GetString(cmd, "trace-file"); traceFile != "" {
+				httpclnt.StartTrace(traceFile, version)
+				log.Debug().Msgf("Recording HTTP requests to %s", traceFile)
+			}
+			return nil
+		},
+		PersistentPostRun: func(cmd *cobra.Command, args []string) {
+			stopTimeout()
 		},
 	}
 
 	rootCmd.PersistentFlags().String("config", "", "config file (default is $HOME/flashpipe.yaml)")
+	rootCmd.PersistentFlags().String("env-file", "", ".env file with environment variables to load, e.g. credentials")
 
 	// Define cobra flags, the default value has the lowest (least significant) precedence
 	rootCmd.PersistentFlags().String("tmn-host", "", "Host for tenant management node of Cloud Integration or API Portal node of APIM excluding https://")
@@ -45,9 +73,15 @@ automating time-consuming manual tasks like:
 	rootCmd.PersistentFlags().String("oauth-host", "", "Host for OAuth token server excluding https:// ")
 	rootCmd.PersistentFlags().String("oauth-clientid", "", "Client ID for using OAuth")
 	rootCmd.PersistentFlags().String("oauth-clientsecret", "", "Client Secret for using OAuth")
-	rootCmd.PersistentFlags().String("oauth-path", "/oauth/token", "Path for OAuth token server")
+	rootCmd.PersistentFlags().String("oauth-path", api.DefaultOauthPath, "Path for OAuth token server")
 
 	rootCmd.PersistentFlags().Bool("debug", false, "Show debug logs")
+	rootCmd.PersistentFlags().Duration("timeout", 0, "Maximum duration of the entire command, e.g. 30m (0 means no limit)")
+	rootCmd.PersistentFlags().String("proxy", "", "Proxy URL for the connections to the tenant, e.g. http://proxy.example.com:8080 (default from HTTPS_PROXY)")
+	rootCmd.PersistentFlags().String("ca-cert", "", "PEM file with CA certificates to trust in addition to the system ones, e.g. of a TLS-intercepting proxy")
+	rootCmd.PersistentFlags().Bool("insecure", false, "Skip the verification of TLS certificates of the tenant (insecure, for non-productive tenants only)")
+	rootCmd.PersistentFlags().Float64("max-rps", 0, "Maximum number of requests per second to the tenant, shared by all parallel operations (0 means no limit)")
+	rootCmd.PersistentFlags().String("trace-file", "", "Record the HTTP requests to the tenant in this HAR file for troubleshooting, with credentials redacted")
 
 	_ = rootCmd.MarkPersistentFlagRequired("tmn-host")
 	rootCmd.MarkFlagsRequiredTogether("tmn-userid", "tmn-password")
@@ -62,6 +96,9 @@ func Execute() {
 
 	rootCmd := NewCmdRoot()
 	rootCmd.AddCommand(NewDeployCommand())
+	rootCmd.AddCommand(NewDeployStatusCommand())
+	rootCmd.AddCommand(NewListCommand())
+	rootCmd.AddCommand(NewPackageDeleteCommand())
 	syncCmd := NewSyncCommand()
 	syncCmd.AddCommand(NewAPIProxyCommand())
 	syncCmd.AddCommand(NewAPIProductCommand())
@@ -75,19 +112,72 @@ func Execute() {
 	rootCmd.AddCommand(snapshotCmd)
 	rootCmd.AddCommand(NewPDSnapshotCommand())
 	rootCmd.AddCommand(NewPDDeployCommand())
+	rootCmd.AddCommand(NewPDDiffCommand())
+	rootCmd.AddCommand(NewPDExportCommand())
+	rootCmd.AddCommand(NewPDImportCommand())
 	rootCmd.AddCommand(NewConfigGenerateCommand())
 	rootCmd.AddCommand(NewFlashpipeOrchestratorCommand())
+	rootCmd.AddCommand(NewSmokeTestCommand())
+	rootCmd.AddCommand(NewReconcileCommand())
+	rootCmd.AddCommand(NewPingCommand())
+	rootCmd.AddCommand(NewPromoteCommand())
+	rootCmd.AddCommand(NewArtifactDiffCommand())
+
+	err := rootCmd.ExecuteContext(context.Background())
 
-	err := rootCmd.Execute()
+	// The trace is also written when the command failed, as that is when it is needed
+	if traceErr := httpclnt.StopTrace(); traceErr != nil {
+		log.Warn().Msgf("%v", traceErr)
+	}
 
 	if err != nil {
+		if errors.Is(err, context.DeadlineExceeded) {
+			log.Fatal().Msgf("Command timed out: %v", err)
+		}
 		// Display stack trace based on type of error
 		msg := logger.GetErrorDetails(err)
 		log.Fatal().Msg(msg)
 	}
 }
 
+// applyTimeout bounds the command with a deadline on its context. Context-aware operations
+// stop when the deadline is reached; commands still running after the grace period are terminated.
+// The returned function cancels the context and the termination timer.
+func applyTimeout(cmd *cobra.Command, timeout time.Duration) func() {
+	if timeout <= 0 {
+		return func() {}
+	}
+	parent := cmd.Context()
+	if parent == nil {
+		parent = context.Background()
+	}
+	ctx, cancel := context.WithTimeout(parent, timeout)
+	cmd.SetContext(ctx)
+
+	timer := time.AfterFunc(timeout+timeoutGracePeriod, func() {
+		log.Fatal().Msgf("Command timed out after %v and did not stop within %v", timeout, timeoutGracePeriod)
+	})
+	log.Debug().Msgf("Command timeout set to %v", timeout)
+
+	return func() {
+		timer.Stop()
+		cancel()
+	}
+}
+
 func initializeConfig(cmd *cobra.Command) error {
+	// Variables of the env file are read like any other environment variable, so they
+	// are also covered by the checks for sensitive content
+	envFile := config.GetString(cmd, "env-file")
+	var envFileVars []string
+	if envFile != "" {
+		vars, err := config.LoadEnvFile(envFile)
+		if err != nil {
+			return err
+		}
+		envFileVars = vars
+	}
+
 	cfgFile := config.GetString(cmd, "config")
 	if cfgFile != "" {
 		// Use config file from the flag.
@@ -133,6 +223,35 @@ func initializeConfig(cmd *cobra.Command) error {
 
 	logger.InitConsoleLogger(viper.GetBool("debug"))
 
+	if envFile != "" {
+		// Only the names are logged, the values may be secrets
+		log.Debug().Msgf("Loaded %d variable(s) from env file %s: %s", len(envFileVars), envFile, strings.Join(envFileVars, ", "))
+	}
+
+	return nil
+}
+
+// configureHTTPClient applies --proxy, --ca-cert, --insecure and --max-rps to the connections to the tenant
+func configureHTTPClient(cmd *cobra.Command) error {
+	if err := httpclnt.SetRateLimit(config.GetFloat64(cmd, "max-rps")); err != nil {
+		return err
+	}
+
+	opts := httpclnt.TransportOptions{
+		Proxy:      config.GetString(cmd, "proxy"),
+		CACertFile: config.GetString(cmd, "ca-cert"),
+		Insecure:   config.GetBool(cmd, "insecure"),
+	}
+	if opts.Insecure {
+		log.Warn().Msg("TLS certificate verification is disabled (--insecure), connections to the tenant are not protected against interception")
+	}
+	if err := httpclnt.ConfigureTransport(opts); err != nil {
+		return err
+	}
+	if proxyURL, err := url.Parse(opts.Proxy); err == nil && opts.Proxy != "" {
+		// The password of the proxy is not logged
+		log.Debug().Msgf("Connecting to the tenant through proxy %s", proxyURL.Redacted())
+	}
 	return nil
 }
 
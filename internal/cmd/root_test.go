@@ -0,0 +1,54 @@
+package cmd
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/spf13/cobra"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestApplyTimeout(t *testing.T) {
+	cmd := &cobra.Command{}
+	cmd.SetContext(context.Background())
+
+	stop := applyTimeout(cmd, 20*time.Millisecond)
+	defer stop()
+
+	deadline, ok := cmd.Context().Deadline()
+	require.True(t, ok)
+	assert.WithinDuration(t, time.Now().Add(20*time.Millisecond), deadline, time.Second)
+
+	<-cmd.Context().Done()
+	assert.ErrorIs(t, cmd.Context().Err(), context.DeadlineExceeded)
+}
+
+func TestApplyTimeout_NoLimit(t *testing.T) {
+	cmd := &cobra.Command{}
+	cmd.SetContext(context.Background())
+
+	stop := applyTimeout(cmd, 0)
+	stop()
+
+	_, ok := cmd.Context().Deadline()
+	assert.False(t, ok)
+}
+
+func TestRootTimeout_CancelsCommandContext(t *testing.T) {
+	var cmdErr error
+	rootCmd := NewCmdRoot()
+	rootCmd.AddCommand(&cobra.Command{
+		Use: "wait",
+		RunE: func(cmd *cobra.Command, args []string) error {
+			<-cmd.Context().Done()
+			cmdErr = cmd.Context().Err()
+			return nil
+		},
+	})
+
+	_, _, err := ExecuteCommandC(rootCmd, "wait", "--tmn-host", "localhost", "--tmn-userid", "user", "--tmn-password", "password", "--timeout", "20ms")
+	require.NoError(t, err)
+	assert.ErrorIs(t, cmdErr, context.DeadlineExceeded)
+}
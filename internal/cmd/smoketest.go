@@ -0,0 +1,266 @@
+package cmd
+
+import (
+	"bytes"
+	"fmt"
+	"io"
+	"mime"
+	"net/http"
+	"os"
+	"path/filepath"
+	"strings"
+	"time"
+
+	"github.com/engswee/flashpipe/internal/analytics"
+	"github.com/engswee/flashpipe/internal/api"
+	"github.com/engswee/flashpipe/internal/config"
+	"github.com/engswee/flashpipe/internal/httpclnt"
+	"github.com/rs/zerolog/log"
+	"github.com/spf13/cobra"
+)
+
+// smokeTestRequest describes the test message sent to a deployed integration flow
+type smokeTestRequest struct {
+	Method             string
+	Path               string
+	ContentType        string
+	Headers            map[string]string
+	Payload            []byte
+	ExpectStatus       int
+	ExpectBodyContains string
+}
+
+func NewSmokeTestCommand() *cobra.Command {
+
+	smokeTestCmd := &cobra.Command{
+		Use:   "smoke-test",
+		Short: "Send a test message to a deployed integration flow",
+		Long: `Send a test message to the endpoint of a deployed integration flow
+and verify the response.
+
+The integration flow must be in status STARTED. Use --deploy to deploy it
+first and wait for the deployment to complete.
+
+The message is sent to the runtime node (--runtime-host). Unless runtime
+credentials are provided, the credentials of the tenant management node
+are used.
+
+Configuration:
+  Settings can be loaded from the global config file (--config) under the
+  'smokeTest' section. CLI flags override config file settings.`,
+		Example: `  # Send a payload and expect HTTP 200
+  flashpipe smoke-test --artifact-id Order_Create \
+    --runtime-host my-tenant.it-cpi001-rt.cfapps.eu10.hana.ondemand.com \
+    --endpoint-path /http/orders --payload ./test/order.xml --expect-status 200
+
+  # Deploy first and check the response content
+  flashpipe smoke-test --artifact-id Order_Create --deploy \
+    --runtime-host my-tenant.it-cpi001-rt.cfapps.eu10.hana.ondemand.com \
+    --endpoint-path /http/orders --payload ./test/order.xml \
+    --expect-body-contains "<Status>OK</Status>"`,
+		RunE: func(cmd *cobra.Command, args []string) (err error) {
+			startTime := time.Now()
+			if err = runSmokeTest(cmd); err != nil {
+				cmd.SilenceUsage = true
+			}
+			analytics.Log(cmd, err, startTime)
+			return
+		},
+	}
+
+	// Define cobra flags, the default value has the lowest (least significant) precedence
+	// Note: These can be set in config file under 'smokeTest' key
+	smokeTestCmd.Flags().String("artifact-id", "", "ID of the integration flow (config: smokeTest.artifactId)")
+	smokeTestCmd.Flags().String("runtime-host", "", "Host of the runtime node excluding https:// (config: smokeTest.runtimeHost)")
+	smokeTestCmd.Flags().String("endpoint-path", "", "Path of the integration flow endpoint, e.g. /http/test (config: smokeTest.endpointPath)")
+	smokeTestCmd.Flags().String("payload", "", "File containing the message body, empty body if not set (config: smokeTest.payload)")
+	smokeTestCmd.Flags().String("content-type", "", "Content type of the message, derived from the payload file extension if not set (config: smokeTest.contentType)")
+	smokeTestCmd.Flags().String("method", http.MethodPost, "HTTP method of the test message (config: smokeTest.method)")
+	smokeTestCmd.Flags().StringSlice("header", nil, "Additional HTTP headers as 'Name: Value', can be repeated (config: smokeTest.headers)")
+	smokeTestCmd.Flags().Int("expect-status", http.StatusOK, "Expected HTTP response status code (config: smokeTest.expectStatus)")
+	smokeTestCmd.Flags().String("expect-body-contains", "", "Text the response body must contain (config: smokeTest.expectBodyContains)")
+	smokeTestCmd.Flags().Bool("deploy", false, "Deploy the integration flow and wait for it to start before sending the message (config: smokeTest.deploy)")
+	smokeTestCmd.Flags().Int("delay-length", 30, "Delay (in seconds) between each check of artifact deployment status when using --deploy (config: smokeTest.delayLength)")
+	smokeTestCmd.Flags().Int("max-check-limit", 10, "Max number of times to check for artifact deployment status when using --deploy (config: smokeTest.maxCheckLimit)")
+	smokeTestCmd.Flags().String("runtime-oauth-clientid", "", "Client ID for OAuth on the runtime node, uses --oauth-host for the token (config: smokeTest.runtimeOauthClientId)")
+	smokeTestCmd.Flags().String("runtime-oauth-clientsecret", "", "Client Secret for OAuth on the runtime node (config: smokeTest.runtimeOauthClientSecret)")
+	smokeTestCmd.Flags().String("runtime-userid", "", "User ID for Basic Auth on the runtime node (config: smokeTest.runtimeUserId)")
+	smokeTestCmd.Flags().String("runtime-password", "", "Password for Basic Auth on the runtime node (config: smokeTest.runtimePassword)")
+
+	smokeTestCmd.MarkFlagsRequiredTogether("runtime-oauth-clientid", "runtime-oauth-clientsecret")
+	smokeTestCmd.MarkFlagsRequiredTogether("runtime-userid", "runtime-password")
+
+	return smokeTestCmd
+}
+
+func runSmokeTest(cmd *cobra.Command) error {
+	log.Info().Msg("Executing smoke-test command")
+
+	serviceDetails := api.GetServiceDetails(cmd)
+
+	// Support reading from config file under 'smokeTest' key
+	artifactId := config.GetStringWithFallback(cmd, "artifact-id", "smokeTest.artifactId")
+	runtimeHost := config.GetStringWithFallback(cmd, "runtime-host", "smokeTest.runtimeHost")
+	endpointPath := config.GetStringWithFallback(cmd, "endpoint-path", "smokeTest.endpointPath")
+	payloadFile, err := config.GetStringWithEnvExpandAndFallback(cmd, "payload", "smokeTest.payload")
+	if err != nil {
+		return fmt.Errorf("security alert for --payload: %w", err)
+	}
+	contentType := config.GetStringWithFallback(cmd, "content-type", "smokeTest.contentType")
+	method := strings.ToUpper(config.GetStringWithFallback(cmd, "method", "smokeTest.method"))
+	headerValues := config.GetStringSliceWithFallback(cmd, "header", "smokeTest.headers")
+	expectStatus := config.GetIntWithFallback(cmd, "expect-status", "smokeTest.expectStatus")
+	expectBodyContains := config.GetStringWithFallback(cmd, "expect-body-contains", "smokeTest.expectBodyContains")
+	deployFirst := config.GetBoolWithFallback(cmd, "deploy", "smokeTest.deploy")
+	delayLength := config.GetIntWithFallback(cmd, "delay-length", "smokeTest.delayLength")
+	maxCheckLimit := config.GetIntWithFallback(cmd, "max-check-limit", "smokeTest.maxCheckLimit")
+
+	if artifactId == "" {
+		return fmt.Errorf("--artifact-id is required (set via CLI flag or in config file under 'smokeTest.artifactId')")
+	}
+	if runtimeHost == "" {
+		return fmt.Errorf("--runtime-host is required (set via CLI flag or in config file under 'smokeTest.runtimeHost')")
+	}
+	if endpointPath == "" {
+		return fmt.Errorf("--endpoint-path is required (set via CLI flag or in config file under 'smokeTest.endpointPath')")
+	}
+	if !strings.HasPrefix(endpointPath, "/") {
+		endpointPath = "/" + endpointPath
+	}
+
+	headers, err := parseSmokeTestHeaders(headerValues)
+	if err != nil {
+		return err
+	}
+
+	var payload []byte
+	if payloadFile != "" {
+		payload, err = os.ReadFile(payloadFile)
+		if err != nil {
+			return fmt.Errorf("failed to read payload file: %w", err)
+		}
+		if contentType == "" {
+			contentType = mime.TypeByExtension(filepath.Ext(payloadFile))
+		}
+	}
+
+	// Make sure the integration flow is running before sending the message
+	if deployFirst {
+		if err := deployArtifacts(cmd.Context(), []string{artifactId}, "Integration", delayLength, maxCheckLimit, true, serviceDetails); err != nil {
+			return err
+		}
+	} else {
+		rt := api.NewRuntime(api.InitHTTPExecuter(serviceDetails))
+		version, status, err := rt.Get(artifactId)
+		if err != nil {
+			return err
+		}
+		if version == api.NotDeployed {
+			return fmt.Errorf("Integration flow %v is not deployed - use --deploy to deploy it first", artifactId)
+		}
+		if status != "STARTED" {
+			return fmt.Errorf("Integration flow %v has runtime status %v, expected STARTED", artifactId, status)
+		}
+	}
+
+	runtimeDetails := getRuntimeServiceDetails(cmd, serviceDetails, runtimeHost)
+	exe := api.InitHTTPExecuter(runtimeDetails)
+
+	err = sendSmokeTestMessage(exe, smokeTestRequest{
+		Method:             method,
+		Path:               endpointPath,
+		ContentType:        contentType,
+		Headers:            headers,
+		Payload:            payload,
+		ExpectStatus:       expectStatus,
+		ExpectBodyContains: expectBodyContains,
+	})
+	if err != nil {
+		return err
+	}
+
+	log.Info().Msgf("🏆 Smoke test of integration flow %v completed successfully", artifactId)
+	return nil
+}
+
+// getRuntimeServiceDetails returns the connection details for the runtime node. Runtime credentials
+// are used if provided, otherwise the credentials of the tenant management node are reused.
+func getRuntimeServiceDetails(cmd *cobra.Command, serviceDetails *api.ServiceDetails, runtimeHost string) *api.ServiceDetails {
+	runtimeClientId := config.GetStringWithFallback(cmd, "runtime-oauth-clientid", "smokeTest.runtimeOauthClientId")
+	runtimeUserId := config.GetStringWithFallback(cmd, "runtime-userid", "smokeTest.runtimeUserId")
+
+	switch {
+	case runtimeClientId != "":
+		return &api.ServiceDetails{
+			Host:              runtimeHost,
+			OauthHost:         serviceDetails.OauthHost,
+			OauthPath:         serviceDetails.OauthPath,
+			OauthClientId:     runtimeClientId,
+			OauthClientSecret: config.GetStringWithFallback(cmd, "runtime-oauth-clientsecret", "smokeTest.runtimeOauthClientSecret"),
+		}
+	case runtimeUserId != "":
+		return &api.ServiceDetails{
+			Host:     runtimeHost,
+			Userid:   runtimeUserId,
+			Password: config.GetStringWithFallback(cmd, "runtime-password", "smokeTest.runtimePassword"),
+		}
+	default:
+		runtimeDetails := *serviceDetails
+		runtimeDetails.Host = runtimeHost
+		return &runtimeDetails
+	}
+}
+
+// parseSmokeTestHeaders converts 'Name: Value' entries into a header map
+func parseSmokeTestHeaders(values []string) (map[string]string, error) {
+	headers := map[string]string{}
+	for _, value := range values {
+		name, headerValue, found := strings.Cut(value, ":")
+		name = strings.TrimSpace(name)
+		if !found || name == "" {
+			return nil, fmt.Errorf("invalid header %q: expected 'Name: Value'", value)
+		}
+		headers[name] = strings.TrimSpace(headerValue)
+	}
+	return headers, nil
+}
+
+// sendSmokeTestMessage sends the test message and verifies the response status and body
+func sendSmokeTestMessage(exe *httpclnt.HTTPExecuter, req smokeTestRequest) error {
+	headers := map[string]string{}
+	if req.ContentType != "" {
+		headers["Content-Type"] = req.ContentType
+	}
+	for name, value := range req.Headers {
+		headers[name] = value
+	}
+
+	log.Info().Msgf("Sending %v request to %v", req.Method, req.Path)
+	start := time.Now()
+	resp, err := exe.ExecRequestWithCookies(req.Method, req.Path, bytes.NewReader(req.Payload), headers, nil)
+	if err != nil {
+		return fmt.Errorf("smoke test request failed: %w", err)
+	}
+	body, err := io.ReadAll(resp.Body)
+	resp.Body.Close()
+	if err != nil {
+		return fmt.Errorf("failed to read smoke test response: %w", err)
+	}
+	log.Info().Msgf("Received response code %d after %v", resp.StatusCode, time.Since(start).Round(time.Millisecond))
+	log.Debug().Msgf("Response body = %s", body)
+
+	if resp.StatusCode != req.ExpectStatus {
+		return fmt.Errorf("smoke test failed: expected response code %d, got %d. Response body = %s", req.ExpectStatus, resp.StatusCode, truncateBody(body, 500))
+	}
+	if req.ExpectBodyContains != "" && !strings.Contains(string(body), req.ExpectBodyContains) {
+		return fmt.Errorf("smoke test failed: response body does not contain %q. Response body = %s", req.ExpectBodyContains, truncateBody(body, 500))
+	}
+	return nil
+}
+
+func truncateBody(body []byte, limit int) string {
+	if len(body) <= limit {
+		return string(body)
+	}
+	return string(body[:limit]) + "..."
+}
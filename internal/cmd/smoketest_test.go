@@ -0,0 +1,63 @@
+package cmd
+
+import (
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/engswee/flashpipe/internal/httpclnt"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestSendSmokeTestMessage(t *testing.T) {
+	svr := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		user, password, _ := r.BasicAuth()
+		assert.Equal(t, "user", user)
+		assert.Equal(t, "password", password)
+		assert.Equal(t, http.MethodPost, r.Method)
+		assert.Equal(t, "/http/orders", r.URL.Path)
+		assert.Equal(t, "application/xml", r.Header.Get("Content-Type"))
+		assert.Equal(t, "smoke", r.Header.Get("X-Test-Run"))
+		body, _ := io.ReadAll(r.Body)
+		if string(body) == "<Order/>" {
+			w.Write([]byte("<Status>OK</Status>"))
+			return
+		}
+		http.Error(w, "invalid order", http.StatusInternalServerError)
+	}))
+	defer svr.Close()
+	host, port := httpclnt.GetHostPort(svr.URL)
+	exe := httpclnt.New("", "", "", "", "user", "password", host, "http", port, false)
+
+	req := smokeTestRequest{
+		Method:             http.MethodPost,
+		Path:               "/http/orders",
+		ContentType:        "application/xml",
+		Headers:            map[string]string{"X-Test-Run": "smoke"},
+		Payload:            []byte("<Order/>"),
+		ExpectStatus:       http.StatusOK,
+		ExpectBodyContains: "<Status>OK</Status>",
+	}
+	assert.NoError(t, sendSmokeTestMessage(exe, req))
+
+	req.ExpectBodyContains = "<Status>FAILED</Status>"
+	assert.ErrorContains(t, sendSmokeTestMessage(exe, req), "does not contain")
+
+	req.Payload = []byte("<Invalid/>")
+	req.ExpectBodyContains = ""
+	err := sendSmokeTestMessage(exe, req)
+	require.Error(t, err)
+	assert.Contains(t, err.Error(), "expected response code 200, got 500")
+	assert.Contains(t, err.Error(), "invalid order")
+}
+
+func TestParseSmokeTestHeaders(t *testing.T) {
+	headers, err := parseSmokeTestHeaders([]string{"SOAPAction: urn:test", "X-Empty:"})
+	require.NoError(t, err)
+	assert.Equal(t, map[string]string{"SOAPAction": "urn:test", "X-Empty": ""}, headers)
+
+	_, err = parseSmokeTestHeaders([]string{"NoSeparator"})
+	assert.ErrorContains(t, err, "invalid header")
+}
@@ -4,6 +4,7 @@ import (
 	"fmt"
 	"os"
 	"path/filepath"
+	"strconv"
 	"strings"
 	"time"
 
@@ -36,6 +37,17 @@ Configuration:
 			default:
 				return fmt.Errorf("invalid value for --draft-handling = %v", draftHandling)
 			}
+			// Validate Directory Naming Type
+			dirNamingType := config.GetStringWithFallback(cmd, "dir-naming-type", "snapshot.dirNamingType")
+			switch dirNamingType {
+			case "ID", "NAME", "COMBINED":
+			default:
+				return fmt.Errorf("invalid value for --dir-naming-type = %v", dirNamingType)
+			}
+			// Validate since
+			if _, err := parseSince(config.GetStringWithFallback(cmd, "since", "snapshot.since"), time.Now()); err != nil {
+				return err
+			}
 			// If artifacts directory is provided, validate that is it a subdirectory of Git repo
 			gitRepoDir, err := config.GetStringWithEnvExpandAndFallback(cmd, "dir-git-repo", "snapshot.dirGitRepo")
 			if err != nil {
@@ -70,8 +82,10 @@ Configuration:
 	snapshotCmd.PersistentFlags().String("dir-artifacts", "", "Directory containing contents of artifacts (grouped into packages) (config: snapshot.dirArtifacts)")
 	snapshotCmd.PersistentFlags().String("dir-work", "/tmp", "Working directory for in-transit files (config: snapshot.dirWork)")
 	snapshotCmd.Flags().String("draft-handling", "SKIP", "Handling when artifact is in draft version. Allowed values: SKIP, ADD, ERROR (config: snapshot.draftHandling)")
+	snapshotCmd.Flags().String("dir-naming-type", "ID", "Name artifact directory by ID, Name or both as <id>__<name>. Allowed values: ID, NAME, COMBINED (config: snapshot.dirNamingType)")
 	snapshotCmd.PersistentFlags().StringSlice("ids-include", nil, "List of included package IDs (config: snapshot.idsInclude)")
 	snapshotCmd.PersistentFlags().StringSlice("ids-exclude", nil, "List of excluded package IDs (config: snapshot.idsExclude)")
+	snapshotCmd.Flags().String("since", "", "Only snapshot artifacts modified within this duration (e.g. 24h, 7d) or since this timestamp (RFC 3339 or YYYY-MM-DD) (config: snapshot.since)")
 
 	snapshotCmd.Flags().String("git-commit-msg", "Tenant snapshot of "+time.Now().Format(time.UnixDate), "Message used in commit (config: snapshot.gitCommitMsg)")
 	snapshotCmd.Flags().String("git-commit-user", "github-actions[bot]", "User used in commit (config: snapshot.gitCommitUser)")
@@ -102,6 +116,7 @@ func runSnapshot(cmd *cobra.Command) error {
 		return fmt.Errorf("security alert for --dir-work: %w", err)
 	}
 	draftHandling := config.GetStringWithFallback(cmd, "draft-handling", "snapshot.draftHandling")
+	dirNamingType := config.GetStringWithFallback(cmd, "dir-naming-type", "snapshot.dirNamingType")
 	includedIds := str.TrimSlice(config.GetStringSliceWithFallback(cmd, "ids-include", "snapshot.idsInclude"))
 	excludedIds := str.TrimSlice(config.GetStringSliceWithFallback(cmd, "ids-exclude", "snapshot.idsExclude"))
 	commitMsg := config.GetStringWithFallback(cmd, "git-commit-msg", "snapshot.gitCommitMsg")
@@ -109,9 +124,13 @@ func runSnapshot(cmd *cobra.Command) error {
 	commitEmail := config.GetStringWithFallback(cmd, "git-commit-email", "snapshot.gitCommitEmail")
 	skipCommit := config.GetBoolWithFallback(cmd, "git-skip-commit", "snapshot.gitSkipCommit")
 	syncPackageLevelDetails := config.GetBoolWithFallback(cmd, "sync-package-details", "snapshot.syncPackageDetails")
+	since, err := parseSince(config.GetStringWithFallback(cmd, "since", "snapshot.since"), time.Now())
+	if err != nil {
+		return err
+	}
 
 	serviceDetails := api.GetServiceDetails(cmd)
-	err = getTenantSnapshot(serviceDetails, artifactsBaseDir, workDir, draftHandling, syncPackageLevelDetails, includedIds, excludedIds)
+	err = getTenantSnapshot(serviceDetails, artifactsBaseDir, workDir, draftHandling, dirNamingType, syncPackageLevelDetails, includedIds, excludedIds, since)
 	if err != nil {
 		return err
 	}
@@ -125,7 +144,7 @@ func runSnapshot(cmd *cobra.Command) error {
 	return nil
 }
 
-func getTenantSnapshot(serviceDetails *api.ServiceDetails, artifactsBaseDir string, workDir string, draftHandling string, syncPackageLevelDetails bool, includedIds []string, excludedIds []string) error {
+func getTenantSnapshot(serviceDetails *api.ServiceDetails, artifactsBaseDir string, workDir string, draftHandling string, dirNamingType string, syncPackageLevelDetails bool, includedIds []string, excludedIds []string, since time.Time) error {
 	log.Info().Msg("---------------------------------------------------------------------------------")
 	log.Info().Msg("📢 Begin taking a snapshot of the tenant")
 
@@ -142,6 +161,10 @@ func getTenantSnapshot(serviceDetails *api.ServiceDetails, artifactsBaseDir stri
 		return fmt.Errorf("No packages found in the tenant")
 	}
 
+	if !since.IsZero() {
+		log.Info().Msgf("Only artifacts modified since %v are processed", since.Format(time.RFC3339))
+	}
+
 	log.Info().Msgf("Processing %d packages", len(ids))
 	synchroniser := sync.New(exe)
 	for i, id := range ids {
@@ -158,13 +181,32 @@ func getTenantSnapshot(serviceDetails *api.ServiceDetails, artifactsBaseDir stri
 			if str.FilterIDs(id, includedIds, excludedIds) {
 				continue
 			}
+			var artifacts []*api.ArtifactDetails
+			var artifactIds []string
+			if !since.IsZero() {
+				artifacts, err = ip.GetAllArtifacts(id)
+				if err != nil {
+					return err
+				}
+				artifactIds = modifiedArtifactIds(artifacts, since)
+				if len(artifactIds) == 0 {
+					log.Info().Msgf("Package %v has no artifacts modified since %v, skipping", id, since.Format(time.RFC3339))
+					continue
+				}
+				log.Info().Msgf("%d of %d artifacts in package %v modified since %v", len(artifactIds), len(artifacts), id, since.Format(time.RFC3339))
+			}
 			if syncPackageLevelDetails {
 				err = synchroniser.PackageToGit(packageDataFromTenant, id, packageWorkingDir, packageArtifactsDir)
 				if err != nil {
 					return err
 				}
 			}
-			err = synchroniser.ArtifactsToGit(id, packageWorkingDir, packageArtifactsDir, nil, nil, draftHandling, "ID", nil)
+			if !since.IsZero() {
+				// The artifacts listed for the modified check are reused instead of being retrieved again
+				err = synchroniser.ListedArtifactsToGit(id, artifacts, packageWorkingDir, packageArtifactsDir, artifactIds, nil, draftHandling, dirNamingType, nil)
+			} else {
+				err = synchroniser.ArtifactsToGit(id, packageWorkingDir, packageArtifactsDir, nil, nil, draftHandling, dirNamingType, nil)
+			}
 			if err != nil {
 				return err
 			}
@@ -176,3 +218,45 @@ func getTenantSnapshot(serviceDetails *api.ServiceDetails, artifactsBaseDir stri
 	log.Info().Msg("🏆 Completed taking a snapshot of the tenant")
 	return nil
 }
+
+// parseSince converts the --since value into a point in time. It accepts a duration relative
+// to now (e.g. 90m, 24h, or 7d for days), an RFC 3339 timestamp or a date (YYYY-MM-DD).
+// An empty value returns the zero time, meaning no filtering.
+func parseSince(value string, now time.Time) (time.Time, error) {
+	return parsePointInTime("--since", value, now)
+}
+
+// parsePointInTime converts the value of a time filter flag like parseSince
+func parsePointInTime(flag string, value string, now time.Time) (time.Time, error) {
+	value = strings.TrimSpace(value)
+	if value == "" {
+		return time.Time{}, nil
+	}
+	if days, found := strings.CutSuffix(value, "d"); found {
+		if n, err := strconv.Atoi(days); err == nil && n >= 0 {
+			return now.AddDate(0, 0, -n), nil
+		}
+	}
+	if duration, err := time.ParseDuration(value); err == nil && duration >= 0 {
+		return now.Add(-duration), nil
+	}
+	if timestamp, err := time.Parse(time.RFC3339, value); err == nil {
+		return timestamp, nil
+	}
+	if date, err := time.ParseInLocation(time.DateOnly, value, time.Local); err == nil {
+		return date, nil
+	}
+	return time.Time{}, fmt.Errorf("invalid value for %v = %v: expected a duration (e.g. 24h, 7d), an RFC 3339 timestamp or a date (YYYY-MM-DD)", flag, value)
+}
+
+// modifiedArtifactIds returns the IDs of artifacts modified at or after since. Artifacts without
+// a modified timestamp are always included so that no change is missed.
+func modifiedArtifactIds(artifacts []*api.ArtifactDetails, since time.Time) []string {
+	var ids []string
+	for _, artifact := range artifacts {
+		if artifact.ModifiedAt.IsZero() || !artifact.ModifiedAt.Before(since) {
+			ids = append(ids, artifact.Id)
+		}
+	}
+	return ids
+}
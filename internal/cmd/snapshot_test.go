@@ -0,0 +1,78 @@
+package cmd
+
+import (
+	"fmt"
+	"net/http"
+	"strings"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/engswee/flashpipe/internal/api"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestParseSince(t *testing.T) {
+	now := time.Date(2024, 3, 10, 12, 0, 0, 0, time.UTC)
+
+	since, err := parseSince("", now)
+	require.NoError(t, err)
+	assert.True(t, since.IsZero())
+
+	since, err = parseSince("24h", now)
+	require.NoError(t, err)
+	assert.Equal(t, now.Add(-24*time.Hour), since)
+
+	since, err = parseSince("7d", now)
+	require.NoError(t, err)
+	assert.Equal(t, time.Date(2024, 3, 3, 12, 0, 0, 0, time.UTC), since)
+
+	since, err = parseSince("2024-03-01T08:00:00Z", now)
+	require.NoError(t, err)
+	assert.Equal(t, time.Date(2024, 3, 1, 8, 0, 0, 0, time.UTC), since)
+
+	since, err = parseSince("2024-03-01", now)
+	require.NoError(t, err)
+	assert.Equal(t, time.Date(2024, 3, 1, 0, 0, 0, 0, time.Local), since)
+
+	_, err = parseSince("yesterday", now)
+	assert.ErrorContains(t, err, "invalid value for --since")
+}
+
+func TestModifiedArtifactIds(t *testing.T) {
+	since := time.Date(2024, 3, 1, 0, 0, 0, 0, time.UTC)
+	artifacts := []*api.ArtifactDetails{
+		{Id: "Old", ModifiedAt: since.Add(-time.Hour)},
+		{Id: "New", ModifiedAt: since.Add(time.Hour)},
+		{Id: "Exact", ModifiedAt: since},
+		{Id: "Unknown"},
+	}
+
+	assert.Equal(t, []string{"New", "Exact", "Unknown"}, modifiedArtifactIds(artifacts, since))
+	assert.Empty(t, modifiedArtifactIds(artifacts[:1], since))
+}
+
+func TestGetTenantSnapshot_SinceListsArtifactsOnce(t *testing.T) {
+	var listings atomic.Int32
+	modifiedAt := time.Now().UnixMilli()
+	startTestTenant(t, func(w http.ResponseWriter, r *http.Request) {
+		switch {
+		case r.URL.Path == "/api/v1/IntegrationPackages":
+			fmt.Fprint(w, `{"d":{"results":[{"Id":"Package1"}]}}`)
+		case r.URL.Path == "/api/v1/IntegrationPackages('Package1')":
+			fmt.Fprint(w, `{"d":{"Id":"Package1","Mode":"EDIT_ALLOWED"}}`)
+		case strings.HasSuffix(r.URL.Path, "/IntegrationDesigntimeArtifacts"):
+			listings.Add(1)
+			// A draft, which is skipped without being downloaded
+			fmt.Fprintf(w, `{"d":{"results":[{"Id":"Flow1","Version":"Active","ModifiedAt":"/Date(%d)/"}]}}`, modifiedAt)
+		default:
+			fmt.Fprint(w, `{"d":{"results":[]}}`)
+		}
+	})
+
+	serviceDetails := &api.ServiceDetails{Host: "tenant.example.com", Userid: "user", Password: "password"}
+	err := getTenantSnapshot(serviceDetails, t.TempDir(), t.TempDir(), "SKIP", "ID", false, nil, nil, time.Now().Add(-time.Hour))
+	require.NoError(t, err)
+	assert.Equal(t, int32(1), listings.Load(), "the artifacts of the package are listed once")
+}
@@ -33,7 +33,7 @@ Configuration:
 			// Validate Directory Naming Type
 			dirNamingType := config.GetStringWithFallback(cmd, "dir-naming-type", "sync.dirNamingType")
 			switch dirNamingType {
-			case "ID", "NAME":
+			case "ID", "NAME", "COMBINED":
 			default:
 				return fmt.Errorf("invalid value for --dir-naming-type = %v", dirNamingType)
 			}
@@ -84,7 +84,7 @@ Configuration:
 	syncCmd.PersistentFlags().String("dir-git-repo", "", "Directory of Git repository (config: sync.dirGitRepo)")
 	syncCmd.PersistentFlags().String("dir-artifacts", "", "Directory containing contents of artifacts (config: sync.dirArtifacts)")
 	syncCmd.PersistentFlags().String("dir-work", "/tmp", "Working directory for in-transit files (config: sync.dirWork)")
-	syncCmd.Flags().String("dir-naming-type", "ID", "Name artifact directory by ID or Name. Allowed values: ID, NAME (config: sync.dirNamingType)")
+	syncCmd.Flags().String("dir-naming-type", "ID", "Name artifact directory by ID, Name or both as <id>__<name>. Allowed values: ID, NAME, COMBINED (config: sync.dirNamingType)")
 	syncCmd.Flags().String("draft-handling", "SKIP", "Handling when artifact is in draft version. Allowed values: SKIP, ADD, ERROR (config: sync.draftHandling)")
 	syncCmd.PersistentFlags().StringSlice("ids-include", nil, "List of included artifact IDs (config: sync.idsInclude)")
 	syncCmd.PersistentFlags().StringSlice("ids-exclude", nil, "List of excluded artifact IDs (config: sync.idsExclude)")
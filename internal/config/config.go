@@ -4,6 +4,7 @@ import (
 	"fmt"
 	"os"
 	"strings"
+	"time"
 
 	"github.com/spf13/cobra"
 	"github.com/spf13/viper"
@@ -37,17 +38,43 @@ func GetBool(cmd *cobra.Command, flagName string) bool {
 	return val
 }
 
-func GetStringWithEnvExpand(cmd *cobra.Command, flagName string) (string, error) {
-	val := os.ExpandEnv(GetString(cmd, flagName))
+func GetFloat64(cmd *cobra.Command, flagName string) float64 {
+	val, _ := cmd.Flags().GetFloat64(flagName)
+	return val
+}
 
-	isNoSensContFound, err := verifyNoSensitiveContent(val)
-	if !isNoSensContFound {
+func GetDuration(cmd *cobra.Command, flagName string) time.Duration {
+	val, _ := cmd.Flags().GetDuration(flagName)
+	return val
+}
+
+func GetStringWithEnvExpand(cmd *cobra.Command, flagName string) (string, error) {
+	val, err := ExpandEnv(GetString(cmd, flagName))
+	if err != nil {
 		return "", fmt.Errorf("Sensitive content found in flag %v: %w", flagName, err)
 	}
 
 	return val, nil
 }
 
+// ExpandEnv replaces ${var} or $var in the value with environment variables and
+// rejects the result if it contains the credentials of the tenant
+func ExpandEnv(value string) (string, error) {
+	val := os.ExpandEnv(value)
+
+	if err := VerifyNoSensitiveContent(val); err != nil {
+		return "", err
+	}
+
+	return val, nil
+}
+
+// VerifyNoSensitiveContent returns an error if the value contains the credentials of the tenant
+func VerifyNoSensitiveContent(value string) error {
+	_, err := verifyNoSensitiveContent(value)
+	return err
+}
+
 func GetStringWithEnvExpandWithDefault(cmd *cobra.Command, flagName string, defaultValue string) (string, error) {
 	val, err := GetStringWithEnvExpand(cmd, flagName)
 	if err != nil {
@@ -0,0 +1,79 @@
+package config
+
+import (
+	"bufio"
+	"fmt"
+	"os"
+	"strings"
+)
+
+// LoadEnvFile sets the KEY=VALUE pairs of a .env file as environment variables and returns the
+// names of the variables that were set. Variables that are already set in the environment are
+// not overridden. Blank lines, # comments and an optional 'export ' prefix are ignored, and
+// values may be enclosed in single or double quotes.
+func LoadEnvFile(path string) ([]string, error) {
+	f, err := os.Open(path)
+	if err != nil {
+		return nil, fmt.Errorf("failed to open env file: %w", err)
+	}
+	defer f.Close()
+
+	var loaded []string
+	scanner := bufio.NewScanner(f)
+	lineNumber := 0
+	for scanner.Scan() {
+		lineNumber++
+		line := strings.TrimSpace(scanner.Text())
+		if lineNumber == 1 {
+			line = strings.TrimPrefix(line, "\uFEFF")
+		}
+		if line == "" || strings.HasPrefix(line, "#") {
+			continue
+		}
+
+		// The line is not part of the error as it may contain a secret
+		key, value, found := strings.Cut(strings.TrimPrefix(line, "export "), "=")
+		key = strings.TrimSpace(key)
+		if !found || key == "" || strings.ContainsAny(key, " \t") {
+			return nil, fmt.Errorf("invalid line %d in env file %s: expected KEY=VALUE", lineNumber, path)
+		}
+		value, err = envFileValue(strings.TrimSpace(value))
+		if err != nil {
+			return nil, fmt.Errorf("invalid line %d in env file %s: %w", lineNumber, path, err)
+		}
+
+		if _, exists := os.LookupEnv(key); exists {
+			continue
+		}
+		if err := os.Setenv(key, value); err != nil {
+			return nil, fmt.Errorf("failed to set %s from env file: %w", key, err)
+		}
+		loaded = append(loaded, key)
+	}
+	if err := scanner.Err(); err != nil {
+		return nil, fmt.Errorf("failed to read env file: %w", err)
+	}
+	return loaded, nil
+}
+
+// envFileValue removes the quotes of a quoted value, or a trailing # comment of an unquoted value
+func envFileValue(value string) (string, error) {
+	if value == "" {
+		return value, nil
+	}
+	if quote := value[0]; quote == '"' || quote == '\'' {
+		end := strings.IndexByte(value[1:], quote)
+		if end < 0 {
+			return "", fmt.Errorf("missing closing quote")
+		}
+		unquoted := value[1 : end+1]
+		if quote == '"' {
+			unquoted = strings.ReplaceAll(unquoted, `\n`, "\n")
+		}
+		return unquoted, nil
+	}
+	if i := strings.Index(value, " #"); i >= 0 {
+		value = strings.TrimSpace(value[:i])
+	}
+	return value, nil
+}
@@ -0,0 +1,53 @@
+package config
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestLoadEnvFile(t *testing.T) {
+	envFile := filepath.Join(t.TempDir(), ".env")
+	content := "\uFEFF# Tenant credentials\n" +
+		"FLASHPIPE_TEST_HOST=tenant.example.com\n" +
+		"export FLASHPIPE_TEST_USER = \"user@example.com\"\n" +
+		"FLASHPIPE_TEST_PASSWORD='p#ss word'\n" +
+		"FLASHPIPE_TEST_PATH=/oauth/token # default path\n" +
+		"\n" +
+		"FLASHPIPE_TEST_EXISTING=from-file\n"
+	require.NoError(t, os.WriteFile(envFile, []byte(content), 0600))
+	t.Setenv("FLASHPIPE_TEST_EXISTING", "from-environment")
+	for _, key := range []string{"FLASHPIPE_TEST_HOST", "FLASHPIPE_TEST_USER", "FLASHPIPE_TEST_PASSWORD", "FLASHPIPE_TEST_PATH"} {
+		t.Setenv(key, "")
+		require.NoError(t, os.Unsetenv(key))
+	}
+
+	loaded, err := LoadEnvFile(envFile)
+	require.NoError(t, err)
+	assert.Equal(t, []string{"FLASHPIPE_TEST_HOST", "FLASHPIPE_TEST_USER", "FLASHPIPE_TEST_PASSWORD", "FLASHPIPE_TEST_PATH"}, loaded)
+	assert.Equal(t, "tenant.example.com", os.Getenv("FLASHPIPE_TEST_HOST"))
+	assert.Equal(t, "user@example.com", os.Getenv("FLASHPIPE_TEST_USER"))
+	assert.Equal(t, "p#ss word", os.Getenv("FLASHPIPE_TEST_PASSWORD"))
+	assert.Equal(t, "/oauth/token", os.Getenv("FLASHPIPE_TEST_PATH"))
+	assert.Equal(t, "from-environment", os.Getenv("FLASHPIPE_TEST_EXISTING"))
+}
+
+func TestLoadEnvFile_Invalid(t *testing.T) {
+	envFile := filepath.Join(t.TempDir(), ".env")
+	require.NoError(t, os.WriteFile(envFile, []byte("FLASHPIPE_TEST_A=1\nsecret-value\n"), 0600))
+	t.Setenv("FLASHPIPE_TEST_A", "")
+	require.NoError(t, os.Unsetenv("FLASHPIPE_TEST_A"))
+
+	_, err := LoadEnvFile(envFile)
+	require.EqualError(t, err, "invalid line 2 in env file "+envFile+": expected KEY=VALUE")
+
+	require.NoError(t, os.WriteFile(envFile, []byte("FLASHPIPE_TEST_A=\"unterminated\n"), 0600))
+	_, err = LoadEnvFile(envFile)
+	assert.EqualError(t, err, "invalid line 1 in env file "+envFile+": missing closing quote")
+
+	_, err = LoadEnvFile(filepath.Join(t.TempDir(), "missing.env"))
+	assert.ErrorContains(t, err, "failed to open env file")
+}
@@ -0,0 +1,105 @@
+package deploy
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"os"
+	"path/filepath"
+	"time"
+)
+
+// configCacheEntry is the metadata of a cached remote config, stored next to its content
+type configCacheEntry struct {
+	URL          string    `json:"url"`
+	ETag         string    `json:"etag,omitempty"`
+	LastModified string    `json:"lastModified,omitempty"`
+	FetchedAt    time.Time `json:"fetchedAt"`
+
+	body []byte
+}
+
+// setConditionalHeaders makes the request return 304 when the cached copy is still current
+func (e *configCacheEntry) setConditionalHeaders(req *http.Request) {
+	if e.ETag != "" {
+		req.Header.Set("If-None-Match", e.ETag)
+	}
+	if e.LastModified != "" {
+		req.Header.Set("If-Modified-Since", e.LastModified)
+	}
+}
+
+// cachePaths returns the content and metadata file of the URL in the cache directory
+func (cl *ConfigLoader) cachePaths() (string, string) {
+	sum := sha256.Sum256([]byte(cl.URL))
+	base := filepath.Join(cl.CacheDir, hex.EncodeToString(sum[:]))
+	return base + ".yml", base + ".json"
+}
+
+// readCache returns the cached copy of the URL, or nil when caching is disabled or there
+// is no usable copy
+func (cl *ConfigLoader) readCache() *configCacheEntry {
+	if cl.CacheDir == "" {
+		return nil
+	}
+	contentPath, metaPath := cl.cachePaths()
+	meta, err := os.ReadFile(metaPath)
+	if err != nil {
+		return nil
+	}
+	var entry configCacheEntry
+	if err := json.Unmarshal(meta, &entry); err != nil || entry.URL != cl.URL {
+		return nil
+	}
+	if entry.body, err = os.ReadFile(contentPath); err != nil {
+		return nil
+	}
+	return &entry
+}
+
+// writeCache stores a fetched config with its validators in the cache directory
+func (cl *ConfigLoader) writeCache(resp *urlResponse) error {
+	if cl.CacheDir == "" {
+		return nil
+	}
+	if err := os.MkdirAll(cl.CacheDir, 0700); err != nil {
+		return fmt.Errorf("failed to create config cache directory %s: %w", cl.CacheDir, err)
+	}
+	meta, err := json.Marshal(configCacheEntry{
+		URL:          cl.URL,
+		ETag:         resp.etag,
+		LastModified: resp.lastModified,
+		FetchedAt:    time.Now().UTC(),
+	})
+	if err != nil {
+		return fmt.Errorf("failed to encode config cache entry: %w", err)
+	}
+	contentPath, metaPath := cl.cachePaths()
+	if err := writeFileAtomic(contentPath, resp.body); err != nil {
+		return fmt.Errorf("failed to write config cache: %w", err)
+	}
+	if err := writeFileAtomic(metaPath, meta); err != nil {
+		return fmt.Errorf("failed to write config cache: %w", err)
+	}
+	return nil
+}
+
+// writeFileAtomic replaces a file through a rename, so that concurrent runs never read a
+// partially written cache entry
+func writeFileAtomic(path string, data []byte) error {
+	tempFile, err := os.CreateTemp(filepath.Dir(path), filepath.Base(path)+".*.tmp")
+	if err != nil {
+		return err
+	}
+	defer os.Remove(tempFile.Name())
+	if _, err := tempFile.Write(data); err != nil {
+		tempFile.Close()
+		return err
+	}
+	if err := tempFile.Close(); err != nil {
+		return err
+	}
+	return os.Rename(tempFile.Name(), path)
+}
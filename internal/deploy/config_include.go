@@ -0,0 +1,129 @@
+package deploy
+
+import (
+	"fmt"
+	"path/filepath"
+	"strings"
+
+	"github.com/engswee/flashpipe/internal/models"
+)
+
+// resolveIncludes merges the config files listed in the include section into config. Later
+// includes take precedence over earlier ones and config itself over all of them:
+//   - packages are matched by integrationSuiteId, a package only in an include is added
+//   - of a package in both, the settings set locally win, sync and deploy are taken from the
+//     local package as they default to true
+//   - artifacts of a package are matched by artifactId, a local artifact replaces the
+//     included one as a whole
+//
+// Relative include paths are resolved against baseDir, remote configs have no baseDir and
+// only accept absolute paths. chain holds the files currently being included to detect
+// cycles. The absolute paths of all included files are returned.
+func resolveIncludes(config *models.DeployConfig, baseDir string, strict bool, chain []string) ([]string, error) {
+	if len(config.Include) == 0 {
+		return nil, nil
+	}
+
+	merged := &models.DeployConfig{}
+	var includedFiles []string
+	for _, include := range config.Include {
+		path := include
+		if !filepath.IsAbs(path) {
+			if baseDir == "" {
+				return nil, fmt.Errorf("include %s must be an absolute path for remote configs", include)
+			}
+			path = filepath.Join(baseDir, path)
+		}
+		path, err := filepath.Abs(path)
+		if err != nil {
+			return nil, fmt.Errorf("failed to resolve include %s: %w", include, err)
+		}
+		for _, file := range chain {
+			if file == path {
+				return nil, fmt.Errorf("include cycle: %s -> %s", strings.Join(chain, " -> "), path)
+			}
+		}
+
+		var included models.DeployConfig
+		if err := readYAML(path, &included, strict); err != nil {
+			return nil, fmt.Errorf("failed to load include %s: %w", include, err)
+		}
+		if err := resolveArtifactFiles(&included, filepath.Dir(path)); err != nil {
+			return nil, fmt.Errorf("failed to load include %s: %w", include, err)
+		}
+		nested, err := resolveIncludes(&included, filepath.Dir(path), strict, append(chain[:len(chain):len(chain)], path))
+		if err != nil {
+			return nil, err
+		}
+		mergeIncludedConfig(merged, &included)
+		includedFiles = append(append(includedFiles, path), nested...)
+	}
+
+	mergeIncludedConfig(merged, config)
+	merged.Include = nil
+	*config = *merged
+	return includedFiles, nil
+}
+
+// mergeIncludedConfig merges src into dst, where src takes precedence
+func mergeIncludedConfig(dst, src *models.DeployConfig) {
+	if src.DeploymentPrefix != "" {
+		dst.DeploymentPrefix = src.DeploymentPrefix
+	}
+	if src.Orchestrator != nil {
+		dst.Orchestrator = src.Orchestrator
+	}
+	for _, pkg := range src.Packages {
+		found := false
+		for i := range dst.Packages {
+			if dst.Packages[i].ID == pkg.ID {
+				dst.Packages[i] = mergeIncludedPackage(dst.Packages[i], pkg)
+				found = true
+				break
+			}
+		}
+		if !found {
+			dst.Packages = append(dst.Packages, pkg)
+		}
+	}
+}
+
+// mergeIncludedPackage returns the package defined in both an include (base) and a config
+// including it (local)
+func mergeIncludedPackage(base, local models.Package) models.Package {
+	merged := local
+	merged.PackageDir = firstNonEmpty(local.PackageDir, base.PackageDir)
+	merged.DisplayName = firstNonEmpty(local.DisplayName, base.DisplayName)
+	merged.Description = firstNonEmpty(local.Description, base.Description)
+	merged.ShortText = firstNonEmpty(local.ShortText, base.ShortText)
+	merged.Version = firstNonEmpty(local.Version, base.Version)
+	merged.Vendor = firstNonEmpty(local.Vendor, base.Vendor)
+	if merged.DeployOrder == 0 {
+		merged.DeployOrder = base.DeployOrder
+	}
+
+	merged.Artifacts = append([]models.Artifact(nil), base.Artifacts...)
+	for _, artifact := range local.Artifacts {
+		found := false
+		for i := range merged.Artifacts {
+			if merged.Artifacts[i].Id == artifact.Id {
+				merged.Artifacts[i] = artifact
+				found = true
+				break
+			}
+		}
+		if !found {
+			merged.Artifacts = append(merged.Artifacts, artifact)
+		}
+	}
+	return merged
+}
+
+func firstNonEmpty(values ...string) string {
+	for _, value := range values {
+		if value != "" {
+			return value
+		}
+	}
+	return ""
+}
@@ -0,0 +1,131 @@
+package deploy
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func writeConfigFiles(t *testing.T, dir string, files map[string]string) {
+	t.Helper()
+	for name, content := range files {
+		path := filepath.Join(dir, name)
+		require.NoError(t, os.MkdirAll(filepath.Dir(path), 0755))
+		require.NoError(t, os.WriteFile(path, []byte(content), 0644))
+	}
+}
+
+func TestLoadSingleFile_Include(t *testing.T) {
+	tempDir := t.TempDir()
+	writeConfigFiles(t, tempDir, map[string]string{
+		"common/common.yml": `
+packages:
+  - integrationSuiteId: Orders
+    packageDir: OrdersDir
+    displayName: Orders
+    deployOrder: 2
+    artifacts:
+      - artifactId: OrderFlow
+        configOverridesFile: overrides.yml
+      - artifactId: InvoiceFlow
+        configOverrides:
+          Timeout: 30
+  - integrationSuiteId: Shared
+    artifacts:
+      - artifactId: SharedScripts
+        type: ScriptCollection
+`,
+		"deploy.yml": `
+include:
+  - common/common.yml
+deploymentPrefix: DEV
+packages:
+  - integrationSuiteId: Orders
+    deploy: false
+    artifacts:
+      - artifactId: InvoiceFlow
+        configOverrides:
+          Timeout: 60
+      - artifactId: CancelFlow
+`,
+	})
+
+	loader := NewConfigLoader()
+	loader.Path = filepath.Join(tempDir, "deploy.yml")
+	loader.Source = SourceFile
+	loader.StrictFields = true
+
+	configs, err := loader.LoadConfigs()
+	require.NoError(t, err)
+	require.Len(t, configs, 1)
+	config := configs[0].Config
+	assert.Equal(t, "DEV", config.DeploymentPrefix)
+	assert.Empty(t, config.Include)
+	require.Len(t, config.Packages, 2)
+
+	orders := config.Packages[0]
+	assert.Equal(t, "Orders", orders.ID)
+	assert.Equal(t, "OrdersDir", orders.PackageDir)
+	assert.Equal(t, 2, orders.DeployOrder)
+	assert.False(t, orders.Deploy, "sync and deploy are taken from the local package")
+	require.Len(t, orders.Artifacts, 3)
+	assert.Equal(t, "OrderFlow", orders.Artifacts[0].Id)
+	assert.Equal(t, filepath.Join(tempDir, "common", "overrides.yml"), orders.Artifacts[0].ConfigOverridesFile,
+		"paths of an include are relative to the include")
+	assert.Equal(t, "InvoiceFlow", orders.Artifacts[1].Id)
+	assert.Equal(t, 60, orders.Artifacts[1].ConfigOverrides["Timeout"])
+	assert.Equal(t, "CancelFlow", orders.Artifacts[2].Id)
+
+	assert.Equal(t, "Shared", config.Packages[1].ID)
+	assert.True(t, config.Packages[1].Deploy)
+}
+
+func TestLoadSingleFile_IncludeCycle(t *testing.T) {
+	tempDir := t.TempDir()
+	writeConfigFiles(t, tempDir, map[string]string{
+		"a.yml": "include: [b.yml]\npackages: []\n",
+		"b.yml": "include: [a.yml]\npackages: []\n",
+	})
+
+	loader := NewConfigLoader()
+	loader.Path = filepath.Join(tempDir, "a.yml")
+	loader.Source = SourceFile
+
+	_, err := loader.LoadConfigs()
+	assert.ErrorContains(t, err, "include cycle: "+filepath.Join(tempDir, "a.yml")+" -> "+
+		filepath.Join(tempDir, "b.yml")+" -> "+filepath.Join(tempDir, "a.yml"))
+
+	// The same file may be included twice as long as it does not include itself
+	writeConfigFiles(t, tempDir, map[string]string{
+		"a.yml":      "include: [b.yml, c.yml]\npackages: []\n",
+		"b.yml":      "include: [common.yml]\npackages: []\n",
+		"c.yml":      "include: [common.yml]\npackages: []\n",
+		"common.yml": "packages:\n  - integrationSuiteId: Common\n",
+	})
+	configs, err := loader.LoadConfigs()
+	require.NoError(t, err)
+	require.Len(t, configs[0].Config.Packages, 1)
+}
+
+func TestLoadFolder_SkipsIncludedFiles(t *testing.T) {
+	tempDir := t.TempDir()
+	writeConfigFiles(t, tempDir, map[string]string{
+		"000-common.yml":        "packages:\n  - integrationSuiteId: Common\n",
+		"001-deploy-config.yml": "include: [000-common.yml]\ndeploymentPrefix: DEV\npackages: []\n",
+		"002-deploy-config.yml": "deploymentPrefix: QA\npackages:\n  - integrationSuiteId: Other\n",
+	})
+
+	loader := NewConfigLoader()
+	loader.Path = tempDir
+	loader.Source = SourceFolder
+
+	configs, err := loader.LoadConfigs()
+	require.NoError(t, err)
+	require.Len(t, configs, 2)
+	assert.Equal(t, "001-deploy-config.yml", configs[0].FileName)
+	assert.Equal(t, "Common", configs[0].Config.Packages[0].ID)
+	assert.Equal(t, "002-deploy-config.yml", configs[1].FileName)
+}
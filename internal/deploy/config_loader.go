@@ -1,6 +1,7 @@
 package deploy
 
 import (
+	"context"
 	"fmt"
 	"io"
 	"net/http"
@@ -8,8 +9,12 @@ import (
 	"path/filepath"
 	"sort"
 	"strings"
+	"time"
 
 	"github.com/engswee/flashpipe/internal/models"
+	"github.com/engswee/flashpipe/internal/str"
+	"golang.org/x/oauth2"
+	"golang.org/x/oauth2/clientcredentials"
 	"gopkg.in/yaml.v3"
 )
 
@@ -20,6 +25,8 @@ const (
 	SourceFile   ConfigSource = "file"
 	SourceFolder ConfigSource = "folder"
 	SourceURL    ConfigSource = "url"
+	// SourceMultiple is used for a comma-separated list of files, folders and URLs
+	SourceMultiple ConfigSource = "multiple"
 )
 
 // ConfigLoader handles loading deployment configurations from various sources
@@ -33,6 +40,35 @@ type ConfigLoader struct {
 	Password    string // for basic auth
 	FilePattern string // pattern for config files in folders
 	Debug       bool
+	// StrictFields rejects config files containing keys unknown to the config structs
+	StrictFields bool
+
+	// OAuth client credentials for remote URLs, used when AuthToken is not set
+	OAuthClientID     string
+	OAuthClientSecret string
+	OAuthTokenURL     string
+
+	// Timeout limits each request for remote URLs (0 for no timeout)
+	Timeout time.Duration
+	// MaxRetries is the number of retries after the first request for a remote URL, for
+	// connection errors and 5xx responses (0 disables retries)
+	MaxRetries int
+	// RetryDelay is doubled after every retry
+	RetryDelay time.Duration
+	// CacheDir keeps the last copy of each remote URL, revalidated with its ETag and used
+	// when the URL cannot be fetched (empty to disable caching)
+	CacheDir string
+
+	// oauthToken caches the fetched bearer token for the duration of the load
+	oauthToken *oauth2.Token
+
+	// sources holds the entries of a comma-separated list, each detected independently
+	sources []sourceEntry
+}
+
+type sourceEntry struct {
+	source   ConfigSource
+	location string
 }
 
 // DeployConfigFile represents a loaded config file with metadata
@@ -41,6 +77,8 @@ type DeployConfigFile struct {
 	Source   string // original source path/URL
 	FileName string // base filename
 	Order    int    // processing order
+	// CacheFallback is the fetch error when the cached copy of a remote URL was loaded instead
+	CacheFallback string
 }
 
 // NewConfigLoader creates a new config loader
@@ -49,56 +87,142 @@ func NewConfigLoader() *ConfigLoader {
 		Source:      SourceFile,
 		FilePattern: "*.y*ml", // default pattern matches .yml and .yaml
 		AuthType:    "bearer",
+		Timeout:     30 * time.Second,
+		MaxRetries:  3,
+		RetryDelay:  time.Second,
 	}
 }
 
-// DetectSource automatically detects the source type based on the path
+// DetectSource automatically detects the source type based on the path.
+// A comma-separated list of files, folders and URLs is also accepted, where each
+// entry is detected independently.
 func (cl *ConfigLoader) DetectSource(path string) error {
+	var locations []string
+	for _, location := range strings.Split(path, ",") {
+		if location = strings.TrimSpace(location); location != "" {
+			locations = append(locations, location)
+		}
+	}
+	if len(locations) == 0 {
+		return fmt.Errorf("no config source specified")
+	}
+
+	cl.sources = nil
+	if len(locations) == 1 {
+		source, err := detectSourceType(locations[0])
+		if err != nil {
+			return err
+		}
+		cl.setLocation(source, locations[0])
+		return nil
+	}
+
+	for _, location := range locations {
+		source, err := detectSourceType(location)
+		if err != nil {
+			return err
+		}
+		cl.sources = append(cl.sources, sourceEntry{source: source, location: location})
+	}
+	cl.Source = SourceMultiple
+	cl.Path = path
+	return nil
+}
+
+func detectSourceType(path string) (ConfigSource, error) {
 	// Check if it's a URL
 	if strings.HasPrefix(path, "http://") || strings.HasPrefix(path, "https://") {
-		cl.Source = SourceURL
-		cl.URL = path
-		return nil
+		return SourceURL, nil
 	}
 
 	// Check if path exists
 	info, err := os.Stat(path)
 	if err != nil {
-		return fmt.Errorf("path does not exist: %s", path)
+		return "", fmt.Errorf("path does not exist: %s", path)
 	}
 
 	// Determine if it's a file or directory
 	if info.IsDir() {
-		cl.Source = SourceFolder
-		cl.Path = path
-	} else {
-		cl.Source = SourceFile
-		cl.Path = path
+		return SourceFolder, nil
 	}
+	return SourceFile, nil
+}
 
-	return nil
+func (cl *ConfigLoader) setLocation(source ConfigSource, location string) {
+	cl.Source = source
+	if source == SourceURL {
+		cl.URL = location
+	} else {
+		cl.Path = location
+	}
 }
 
 // LoadConfigs loads all configuration files based on the source type
 func (cl *ConfigLoader) LoadConfigs() ([]*DeployConfigFile, error) {
+	return cl.LoadConfigsContext(context.Background())
+}
+
+// LoadConfigsContext is like LoadConfigs. Fetching a remote config, including the waits between
+// retries, stops when the context is cancelled.
+func (cl *ConfigLoader) LoadConfigsContext(ctx context.Context) ([]*DeployConfigFile, error) {
 	switch cl.Source {
 	case SourceFile:
 		return cl.loadSingleFile()
 	case SourceFolder:
 		return cl.loadFolder()
 	case SourceURL:
-		return cl.loadURL()
+		return cl.loadURL(ctx)
+	case SourceMultiple:
+		return cl.loadMultiple(ctx)
 	default:
 		return nil, fmt.Errorf("unsupported source type: %s", cl.Source)
 	}
 }
 
+// loadMultiple loads each source of a comma-separated list in the given order.
+// Orders are renumbered across all sources so they stay unique, also when
+// different sources contain files with the same name.
+func (cl *ConfigLoader) loadMultiple(ctx context.Context) ([]*DeployConfigFile, error) {
+	var configFiles []*DeployConfigFile
+	for _, entry := range cl.sources {
+		sourceLoader := *cl
+		sourceLoader.sources = nil
+		sourceLoader.setLocation(entry.source, entry.location)
+
+		if cl.Debug {
+			fmt.Printf("Loading config source: %s (type: %s)\n", entry.location, entry.source)
+		}
+		files, err := sourceLoader.LoadConfigsContext(ctx)
+		if err != nil {
+			return nil, fmt.Errorf("failed to load config source %s: %w", entry.location, err)
+		}
+		configFiles = append(configFiles, files...)
+		// Keep the token so later URL sources don't fetch a new one
+		cl.oauthToken = sourceLoader.oauthToken
+	}
+
+	for i, configFile := range configFiles {
+		configFile.Order = i
+	}
+
+	return configFiles, nil
+}
+
 // loadSingleFile loads a single configuration file
 func (cl *ConfigLoader) loadSingleFile() ([]*DeployConfigFile, error) {
 	var config models.DeployConfig
-	if err := readYAML(cl.Path, &config); err != nil {
+	if err := readYAML(cl.Path, &config, cl.StrictFields); err != nil {
 		return nil, fmt.Errorf("failed to load config file %s: %w", cl.Path, err)
 	}
+	if err := resolveArtifactFiles(&config, filepath.Dir(cl.Path)); err != nil {
+		return nil, fmt.Errorf("failed to load config file %s: %w", cl.Path, err)
+	}
+	if _, err := cl.resolveFileIncludes(&config, cl.Path); err != nil {
+		return nil, fmt.Errorf("failed to load config file %s: %w", cl.Path, err)
+	}
+	if err := ValidateDeployConfig(&config); err != nil {
+		return nil, fmt.Errorf("invalid config file %s: %w", cl.Path, err)
+	}
 
 	return []*DeployConfigFile{
 		{
@@ -181,15 +305,33 @@ func (cl *ConfigLoader) loadFolder() ([]*DeployConfigFile, error) {
 
 	// Load each file
 	successCount := 0
+	includedFiles := make(map[string]bool)
 	for i, filePath := range files {
 		var config models.DeployConfig
-		if err := readYAML(filePath, &config); err != nil {
+		if err := readYAML(filePath, &config, cl.StrictFields); err != nil {
 			relPath, _ := filepath.Rel(cl.Path, filePath)
+			if cl.StrictFields {
+				// Skipping the file would hide the typo that strict mode is meant to report
+				return nil, fmt.Errorf("failed to load config file %s: %w", relPath, err)
+			}
 			if cl.Debug {
 				fmt.Printf("Warning: Failed to load config file %s: %v\n", relPath, err)
 			}
 			continue
 		}
+		if err := resolveArtifactFiles(&config, filepath.Dir(filePath)); err != nil {
+			return nil, fmt.Errorf("failed to load config file %s: %w", filePath, err)
+		}
+		included, err := cl.resolveFileIncludes(&config, filePath)
+		if err != nil {
+			return nil, fmt.Errorf("failed to load config file %s: %w", filePath, err)
+		}
+		for _, includedFile := range included {
+			includedFiles[includedFile] = true
+		}
+		if err := ValidateDeployConfig(&config); err != nil {
+			return nil, fmt.Errorf("invalid config file %s: %w", filePath, err)
+		}
 
 		// Get relative path from base directory for better display
 		relPath, _ := filepath.Rel(cl.Path, filePath)
@@ -207,6 +349,22 @@ func (cl *ConfigLoader) loadFolder() ([]*DeployConfigFile, error) {
 		}
 	}
 
+	// Files included by other configs in the folder are not deployed on their own
+	if len(includedFiles) > 0 {
+		var remaining []*DeployConfigFile
+		for _, configFile := range configFiles {
+			if absPath, err := filepath.Abs(configFile.Source); err == nil && includedFiles[absPath] {
+				if cl.Debug {
+					fmt.Printf("Skipping included config file: %s\n", configFile.FileName)
+				}
+				successCount--
+				continue
+			}
+			remaining = append(remaining, configFile)
+		}
+		configFiles = remaining
+	}
+
 	if len(configFiles) == 0 {
 		return nil, fmt.Errorf("no valid config files found in %s (found %d file(s) but all failed to parse)", cl.Path, len(files))
 	}
@@ -219,16 +377,13 @@ func (cl *ConfigLoader) loadFolder() ([]*DeployConfigFile, error) {
 }
 
 // loadURL loads a configuration file from a remote URL
-func (cl *ConfigLoader) loadURL() ([]*DeployConfigFile, error) {
+func (cl *ConfigLoader) loadURL(ctx context.Context) ([]*DeployConfigFile, error) {
 	if cl.Debug {
 		fmt.Printf("Fetching config from URL: %s\n", cl.URL)
 	}
 
-	// Create HTTP client
-	client := &http.Client{}
-
 	// Create request
-	req, err := http.NewRequest("GET", cl.URL, nil)
+	req, err := http.NewRequestWithContext(ctx, "GET", cl.URL, nil)
 	if err != nil {
 		return nil, fmt.Errorf("failed to create request: %w", err)
 	}
@@ -246,6 +401,15 @@ func (cl *ConfigLoader) loadURL() ([]*DeployConfigFile, error) {
 				fmt.Printf("Using Basic authentication with username: %s\n", cl.Username)
 			}
 		}
+	} else if cl.OAuthTokenURL != "" {
+		token, err := cl.fetchOAuthToken(ctx)
+		if err != nil {
+			return nil, err
+		}
+		token.SetAuthHeader(req)
+		if cl.Debug {
+			fmt.Println("Using OAuth 2.0 client credentials authentication")
+		}
 	} else if cl.Username != "" && cl.Password != "" {
 		// Use basic auth if username/password provided without token
 		req.SetBasicAuth(cl.Username, cl.Password)
@@ -254,26 +418,38 @@ func (cl *ConfigLoader) loadURL() ([]*DeployConfigFile, error) {
 		}
 	}
 
-	// Make request
-	resp, err := client.Do(req)
-	if err != nil {
-		return nil, fmt.Errorf("failed to fetch URL: %w", err)
-	}
-	defer resp.Body.Close()
-
-	// Check response status
-	if resp.StatusCode != http.StatusOK {
-		return nil, fmt.Errorf("failed to fetch URL: status %d", resp.StatusCode)
+	cached := cl.readCache()
+	if cached != nil {
+		cached.setConditionalHeaders(req)
 	}
 
-	if cl.Debug {
-		fmt.Printf("Successfully fetched config (status: %d)\n", resp.StatusCode)
-	}
-
-	// Read response body
-	body, err := io.ReadAll(resp.Body)
-	if err != nil {
-		return nil, fmt.Errorf("failed to read response: %w", err)
+	var body []byte
+	var cacheFallback string
+	resp, retryable, err := cl.fetchWithRetry(req)
+	switch {
+	case err != nil:
+		// Only transient failures fall back to the cache, an error response such as 401 or 404 is reported
+		if cached == nil || !retryable {
+			return nil, err
+		}
+		cacheFallback = err.Error()
+		body = cached.body
+		if cl.Debug {
+			fmt.Printf("Warning: %v - using cached copy from %s\n", err, cached.FetchedAt.Format(time.RFC3339))
+		}
+	case resp.notModified:
+		if cached == nil {
+			return nil, fmt.Errorf("failed to fetch URL: status %d without cached copy", http.StatusNotModified)
+		}
+		body = cached.body
+		if cl.Debug {
+			fmt.Println("Config not modified, using cached copy")
+		}
+	default:
+		body = resp.body
+		if err := cl.writeCache(resp); err != nil && cl.Debug {
+			fmt.Printf("Warning: %v\n", err)
+		}
 	}
 
 	// Save to temporary file for YAML parsing
@@ -290,8 +466,17 @@ func (cl *ConfigLoader) loadURL() ([]*DeployConfigFile, error) {
 
 	// Parse YAML
 	var config models.DeployConfig
-	if err := readYAML(tempFile.Name(), &config); err != nil {
-		return nil, fmt.Errorf("failed to parse config from URL: %w", err)
+	if err := readYAML(tempFile.Name(), &config, cl.StrictFields); err != nil {
+		return nil, fmt.Errorf("failed to parse config from URL %s: %w", cl.URL, err)
+	}
+	if err := resolveArtifactFiles(&config, ""); err != nil {
+		return nil, fmt.Errorf("failed to parse config from URL %s: %w", cl.URL, err)
+	}
+	if _, err := resolveIncludes(&config, "", cl.StrictFields, nil); err != nil {
+		return nil, fmt.Errorf("failed to parse config from URL %s: %w", cl.URL, err)
+	}
+	if err := ValidateDeployConfig(&config); err != nil {
+		return nil, fmt.Errorf("invalid config from URL %s: %w", cl.URL, err)
 	}
 
 	// Extract filename from URL
@@ -307,14 +492,112 @@ func (cl *ConfigLoader) loadURL() ([]*DeployConfigFile, error) {
 
 	return []*DeployConfigFile{
 		{
-			Config:   &config,
-			Source:   cl.URL,
-			FileName: fileName,
-			Order:    0,
+			Config:        &config,
+			Source:        cl.URL,
+			FileName:      fileName,
+			Order:         0,
+			CacheFallback: cacheFallback,
 		},
 	}, nil
 }
 
+// urlResponse is a successful response for a remote config
+type urlResponse struct {
+	body         []byte
+	etag         string
+	lastModified string
+	notModified  bool // 304 for a conditional request, body is empty
+}
+
+// fetchWithRetry executes the GET request of a remote config. Connection errors and 5xx
+// responses are retried with backoff, other responses than 200 and 304 fail immediately.
+// It reports whether the last failure was transient. A cancelled request context stops the
+// retries and is not reported as transient, so that the cached copy is not used instead.
+func (cl *ConfigLoader) fetchWithRetry(req *http.Request) (*urlResponse, bool, error) {
+	ctx := req.Context()
+	client := &http.Client{Timeout: cl.Timeout}
+	for attempt := 0; ; attempt++ {
+		resp, retryable, err := cl.fetch(client, req)
+		if err == nil {
+			return resp, false, nil
+		}
+		if ctx.Err() != nil {
+			return nil, false, fmt.Errorf("failed to fetch URL: %w", ctx.Err())
+		}
+		if !retryable || attempt >= cl.MaxRetries {
+			if attempt > 0 {
+				return nil, retryable, fmt.Errorf("%w (after %d attempts)", err, attempt+1)
+			}
+			return nil, retryable, err
+		}
+		delay := cl.RetryDelay << attempt
+		if cl.Debug {
+			fmt.Printf("%v - retrying in %v (%d/%d)\n", err, delay, attempt+1, cl.MaxRetries)
+		}
+		select {
+		case <-ctx.Done():
+			return nil, false, fmt.Errorf("failed to fetch URL: %w", ctx.Err())
+		case <-time.After(delay):
+		}
+	}
+}
+
+// fetch executes a single request and reports whether a failure can be retried
+func (cl *ConfigLoader) fetch(client *http.Client, req *http.Request) (*urlResponse, bool, error) {
+	resp, err := client.Do(req)
+	if err != nil {
+		return nil, true, fmt.Errorf("failed to fetch URL: %w", err)
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode == http.StatusNotModified {
+		return &urlResponse{notModified: true}, false, nil
+	}
+	if resp.StatusCode != http.StatusOK {
+		io.Copy(io.Discard, resp.Body)
+		return nil, resp.StatusCode >= 500, fmt.Errorf("failed to fetch URL: status %d", resp.StatusCode)
+	}
+
+	if cl.Debug {
+		fmt.Printf("Successfully fetched config (status: %d)\n", resp.StatusCode)
+	}
+
+	body, err := io.ReadAll(resp.Body)
+	if err != nil {
+		return nil, true, fmt.Errorf("failed to read response: %w", err)
+	}
+	return &urlResponse{
+		body:         body,
+		etag:         resp.Header.Get("ETag"),
+		lastModified: resp.Header.Get("Last-Modified"),
+	}, false, nil
+}
+
+// fetchOAuthToken retrieves a bearer token using the OAuth 2.0 client credentials flow.
+// The token is cached and reused while it is still valid.
+func (cl *ConfigLoader) fetchOAuthToken(ctx context.Context) (*oauth2.Token, error) {
+	if cl.oauthToken.Valid() {
+		return cl.oauthToken, nil
+	}
+
+	if cl.Debug {
+		fmt.Printf("Fetching OAuth token from: %s\n", cl.OAuthTokenURL)
+	}
+
+	// Reference https://pkg.go.dev/golang.org/x/oauth2/clientcredentials#pkg-overview
+	conf := &clientcredentials.Config{
+		ClientID:     cl.OAuthClientID,
+		ClientSecret: cl.OAuthClientSecret,
+		TokenURL:     cl.OAuthTokenURL,
+	}
+	token, err := conf.Token(ctx)
+	if err != nil {
+		return nil, fmt.Errorf("failed to fetch OAuth token: %w", err)
+	}
+	cl.oauthToken = token
+	return token, nil
+}
+
 // MergeConfigs merges multiple deployment configs into a single config
 func MergeConfigs(configs []*DeployConfigFile) (*models.DeployConfig, error) {
 	if len(configs) == 0 {
@@ -360,10 +643,12 @@ func MergeConfigs(configs []*DeployConfigFile) (*models.DeployConfig, error) {
 					fullyQualifiedID, configFile.FileName, existingSource)
 			}
 
-			// Apply prefix to all artifact IDs as well
+			// Apply prefix to all artifact IDs as well, except for artifacts with skipPrefix
 			if configPrefix != "" {
 				for i := range mergedPkg.Artifacts {
-					mergedPkg.Artifacts[i].Id = configPrefix + "_" + mergedPkg.Artifacts[i].Id
+					if !mergedPkg.Artifacts[i].SkipPrefix {
+						mergedPkg.Artifacts[i].Id = configPrefix + "_" + mergedPkg.Artifacts[i].Id
+					}
 				}
 			}
 
@@ -375,14 +660,90 @@ func MergeConfigs(configs []*DeployConfigFile) (*models.DeployConfig, error) {
 	return merged, nil
 }
 
-// readYAML reads and unmarshals a YAML file
-func readYAML(path string, v interface{}) error {
+// ValidateDeployConfig checks a loaded deployment config for mistakes that would otherwise
+// surface only during the update, such as an artifact listed twice in the same package
+func ValidateDeployConfig(config *models.DeployConfig) error {
+	for _, pkg := range config.Packages {
+		seen := make(map[string]bool, len(pkg.Artifacts))
+		for _, artifact := range pkg.Artifacts {
+			if artifact.Id == "" {
+				continue
+			}
+			if seen[artifact.Id] {
+				return fmt.Errorf("duplicate artifact ID '%s' in package '%s'", artifact.Id, pkg.ID)
+			}
+			seen[artifact.Id] = true
+		}
+	}
+	return nil
+}
+
+// resolveFileIncludes resolves the includes of the config file at path, see resolveIncludes
+func (cl *ConfigLoader) resolveFileIncludes(config *models.DeployConfig, path string) ([]string, error) {
+	absPath, err := filepath.Abs(path)
+	if err != nil {
+		return nil, err
+	}
+	return resolveIncludes(config, filepath.Dir(absPath), cl.StrictFields, []string{absPath})
+}
+
+// resolveArtifactFiles makes the configOverridesFile and valueMappingFile references of all
+// artifacts relative to baseDir. Remote configs have no baseDir, so they only accept absolute paths.
+func resolveArtifactFiles(config *models.DeployConfig, baseDir string) error {
+	for i := range config.Packages {
+		for j := range config.Packages[i].Artifacts {
+			artifact := &config.Packages[i].Artifacts[j]
+			var err error
+			if artifact.ConfigOverridesFile, err = resolveArtifactFile("configOverridesFile", artifact.ConfigOverridesFile, artifact.Id, baseDir); err != nil {
+				return err
+			}
+			if artifact.ValueMappingFile, err = resolveArtifactFile("valueMappingFile", artifact.ValueMappingFile, artifact.Id, baseDir); err != nil {
+				return err
+			}
+		}
+	}
+	return nil
+}
+
+func resolveArtifactFile(field, path, artifactID, baseDir string) (string, error) {
+	if path == "" || filepath.IsAbs(path) {
+		return path, nil
+	}
+	if baseDir == "" {
+		return "", fmt.Errorf("%s %s of artifact %s must be an absolute path for remote configs", field, path, artifactID)
+	}
+	return filepath.Join(baseDir, path), nil
+}
+
+// LoadArtifactOverrides returns the config overrides of an artifact, combining the
+// values of its configOverridesFile with the inline configOverrides, which take precedence
+func LoadArtifactOverrides(artifact models.Artifact) (map[string]interface{}, error) {
+	if artifact.ConfigOverridesFile == "" {
+		return artifact.ConfigOverrides, nil
+	}
+
+	overrides := make(map[string]interface{})
+	if err := readYAML(artifact.ConfigOverridesFile, &overrides, false); err != nil {
+		return nil, fmt.Errorf("failed to load configOverridesFile %s: %w", artifact.ConfigOverridesFile, err)
+	}
+	for key, value := range artifact.ConfigOverrides {
+		overrides[key] = value
+	}
+	return overrides, nil
+}
+
+// readYAML reads and unmarshals a YAML file, rejecting unknown keys when strict is set
+func readYAML(path string, v interface{}, strict bool) error {
 	data, err := os.ReadFile(path)
 	if err != nil {
 		return fmt.Errorf("failed to read file: %w", err)
 	}
 
-	if err := yaml.Unmarshal(data, v); err != nil {
+	decoder := yaml.NewDecoder(strings.NewReader(str.TrimBOM(string(data))))
+	// In strict mode unknown keys such as a mistyped "artifactID" are reported
+	// instead of silently leaving the intended field empty
+	decoder.KnownFields(strict)
+	if err := decoder.Decode(v); err != nil && err != io.EOF {
 		return fmt.Errorf("failed to parse YAML: %w", err)
 	}
 
@@ -1,11 +1,14 @@
 package deploy
 
 import (
+	"context"
 	"net/http"
 	"net/http/httptest"
 	"os"
 	"path/filepath"
+	"strings"
 	"testing"
+	"time"
 
 	"github.com/engswee/flashpipe/internal/models"
 	"github.com/stretchr/testify/assert"
@@ -115,6 +118,91 @@ packages:
 	assert.Equal(t, "test-config.yml", configs[0].FileName)
 }
 
+func TestLoadSingleFile_WithBOM(t *testing.T) {
+	tempDir, err := os.MkdirTemp("", "config-loader-test-*")
+	require.NoError(t, err)
+	defer os.RemoveAll(tempDir)
+
+	configPath := filepath.Join(tempDir, "config.yml")
+	configContent := "\uFEFFdeploymentPrefix: BOM\npackages:\n  - integrationSuiteId: Package1\n"
+	require.NoError(t, os.WriteFile(configPath, []byte(configContent), 0644))
+
+	loader := NewConfigLoader()
+	loader.Source = SourceFile
+	loader.Path = configPath
+
+	configs, err := loader.LoadConfigs()
+	require.NoError(t, err)
+	require.Len(t, configs, 1)
+	assert.Equal(t, "BOM", configs[0].Config.DeploymentPrefix)
+	require.Len(t, configs[0].Config.Packages, 1)
+	assert.Equal(t, "Package1", configs[0].Config.Packages[0].ID)
+}
+
+func TestLoadArtifactOverrides_FromFile(t *testing.T) {
+	tempDir, err := os.MkdirTemp("", "config-loader-test-*")
+	require.NoError(t, err)
+	defer os.RemoveAll(tempDir)
+
+	require.NoError(t, os.MkdirAll(filepath.Join(tempDir, "configs", "overrides"), 0755))
+	overridesContent := "Endpoint: https://qa.example.com\nTimeout: 30\n"
+	require.NoError(t, os.WriteFile(filepath.Join(tempDir, "configs", "overrides", "flow1-qa.yml"), []byte(overridesContent), 0644))
+
+	configPath := filepath.Join(tempDir, "configs", "qa.yml")
+	configContent := `packages:
+  - integrationSuiteId: Package1
+    artifacts:
+      - artifactId: Flow1
+        type: Integration
+        configOverridesFile: overrides/flow1-qa.yml
+        configOverrides:
+          Timeout: 60
+`
+	require.NoError(t, os.WriteFile(configPath, []byte(configContent), 0644))
+
+	loader := NewConfigLoader()
+	loader.Source = SourceFile
+	loader.Path = configPath
+	configs, err := loader.LoadConfigs()
+	require.NoError(t, err)
+
+	artifact := configs[0].Config.Packages[0].Artifacts[0]
+	assert.Equal(t, filepath.Join(tempDir, "configs", "overrides", "flow1-qa.yml"), artifact.ConfigOverridesFile)
+
+	overrides, err := LoadArtifactOverrides(artifact)
+	require.NoError(t, err)
+	assert.Equal(t, map[string]interface{}{
+		"Endpoint": "https://qa.example.com",
+		"Timeout":  60, // inline value wins
+	}, overrides)
+
+	// Inline overrides are returned unchanged without a file
+	inlineOnly := models.Artifact{ConfigOverrides: map[string]interface{}{"Key": "value"}}
+	overrides, err = LoadArtifactOverrides(inlineOnly)
+	require.NoError(t, err)
+	assert.Equal(t, inlineOnly.ConfigOverrides, overrides)
+
+	// A missing file is an error
+	artifact.ConfigOverridesFile = filepath.Join(tempDir, "missing.yml")
+	_, err = LoadArtifactOverrides(artifact)
+	assert.ErrorContains(t, err, "missing.yml")
+}
+
+func TestLoadURL_RelativeConfigOverridesFile(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Write([]byte("packages:\n  - integrationSuiteId: Package1\n    artifacts:\n      - artifactId: Flow1\n        configOverridesFile: overrides/flow1.yml\n"))
+	}))
+	defer server.Close()
+
+	loader := NewConfigLoader()
+	loader.URL = server.URL
+	loader.Source = SourceURL
+
+	_, err := loader.LoadConfigs()
+	require.Error(t, err)
+	assert.Contains(t, err.Error(), "must be an absolute path for remote configs")
+}
+
 func TestLoadFolder_SingleFile(t *testing.T) {
 	tempDir, err := os.MkdirTemp("", "config-test-*")
 	require.NoError(t, err)
@@ -274,6 +362,107 @@ func TestLoadFolder_CustomPattern(t *testing.T) {
 	assert.Equal(t, "JSON", configs[0].Config.DeploymentPrefix)
 }
 
+func TestLoadSingleFile_StrictFields(t *testing.T) {
+	tempDir, err := os.MkdirTemp("", "config-test-*")
+	require.NoError(t, err)
+	defer os.RemoveAll(tempDir)
+
+	tests := []struct {
+		name    string
+		content string
+		field   string
+	}{
+		{
+			name:    "unknown package field",
+			content: "packages:\n  - integrationSuiteID: Package1\n    artifacts: []\n",
+			field:   "integrationSuiteID",
+		},
+		{
+			name:    "unknown artifact field",
+			content: "packages:\n  - integrationSuiteId: Package1\n    artifacts:\n      - artifactID: Flow1\n        type: Integration\n",
+			field:   "artifactID",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			configPath := filepath.Join(tempDir, "config.yml")
+			require.NoError(t, os.WriteFile(configPath, []byte(tt.content), 0644))
+
+			// Lenient mode ignores the unknown key
+			loader := NewConfigLoader()
+			loader.Source = SourceFile
+			loader.Path = configPath
+			_, err := loader.LoadConfigs()
+			require.NoError(t, err)
+
+			loader.StrictFields = true
+			_, err = loader.LoadConfigs()
+			require.Error(t, err)
+			assert.Contains(t, err.Error(), configPath)
+			assert.Contains(t, err.Error(), "field "+tt.field+" not found")
+		})
+	}
+}
+
+func TestLoadSingleFile_DuplicateArtifactID(t *testing.T) {
+	tempDir, err := os.MkdirTemp("", "config-test-*")
+	require.NoError(t, err)
+	defer os.RemoveAll(tempDir)
+
+	content := `packages:
+  - integrationSuiteId: Package1
+    artifacts:
+      - artifactId: Flow1
+        type: Integration
+      - artifactId: Flow1
+        type: Integration
+  - integrationSuiteId: Package2
+    artifacts:
+      - artifactId: Flow2
+        type: Integration
+`
+	configPath := filepath.Join(tempDir, "config.yml")
+	require.NoError(t, os.WriteFile(configPath, []byte(content), 0644))
+
+	loader := NewConfigLoader()
+	loader.Source = SourceFile
+	loader.Path = configPath
+	_, err = loader.LoadConfigs()
+	require.Error(t, err)
+	assert.Contains(t, err.Error(), configPath)
+	assert.Contains(t, err.Error(), "duplicate artifact ID 'Flow1' in package 'Package1'")
+
+	// The same artifact ID in different packages is allowed
+	content = strings.Replace(content, "Flow2", "Flow1", 1)
+	content = strings.Replace(content, "      - artifactId: Flow1\n        type: Integration\n  - integrationSuiteId: Package2", "  - integrationSuiteId: Package2", 1)
+	require.NoError(t, os.WriteFile(configPath, []byte(content), 0644))
+	configFiles, err := loader.LoadConfigs()
+	require.NoError(t, err)
+	assert.Len(t, configFiles[0].Config.Packages[0].Artifacts, 1)
+}
+
+func TestLoadFolder_StrictFields(t *testing.T) {
+	tempDir, err := os.MkdirTemp("", "config-test-*")
+	require.NoError(t, err)
+	defer os.RemoveAll(tempDir)
+
+	err = os.WriteFile(filepath.Join(tempDir, "valid.yml"), []byte("deploymentPrefix: OK\npackages: []"), 0644)
+	require.NoError(t, err)
+	err = os.WriteFile(filepath.Join(tempDir, "typo.yml"), []byte("deploymentPrefx: TYPO\npackages: []"), 0644)
+	require.NoError(t, err)
+
+	loader := NewConfigLoader()
+	loader.Path = tempDir
+	loader.Source = SourceFolder
+	loader.StrictFields = true
+
+	_, err = loader.LoadConfigs()
+	require.Error(t, err)
+	assert.Contains(t, err.Error(), "typo.yml")
+	assert.Contains(t, err.Error(), "deploymentPrefx")
+}
+
 func TestLoadFolder_InvalidYAML(t *testing.T) {
 	tempDir, err := os.MkdirTemp("", "config-test-*")
 	require.NoError(t, err)
@@ -379,6 +568,45 @@ func TestLoadURL_WithBasicAuth(t *testing.T) {
 	assert.Equal(t, "BASIC", configs[0].Config.DeploymentPrefix)
 }
 
+func TestLoadURL_WithOAuth(t *testing.T) {
+	tokenRequests := 0
+	tokenServer := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		tokenRequests++
+		clientID, clientSecret, ok := r.BasicAuth()
+		if !ok || clientID != "client" || clientSecret != "secret" {
+			w.WriteHeader(http.StatusUnauthorized)
+			return
+		}
+		w.Header().Set("Content-Type", "application/json")
+		w.Write([]byte(`{"access_token":"oauth-token-123","token_type":"bearer","expires_in":3600}`))
+	}))
+	defer tokenServer.Close()
+
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.Header.Get("Authorization") != "Bearer oauth-token-123" {
+			w.WriteHeader(http.StatusUnauthorized)
+			return
+		}
+		w.WriteHeader(http.StatusOK)
+		w.Write([]byte("deploymentPrefix: OAUTH\npackages: []"))
+	}))
+	defer server.Close()
+
+	loader := NewConfigLoader()
+	require.NoError(t, loader.DetectSource(server.URL+"/a.yml,"+server.URL+"/b.yml"))
+	loader.OAuthClientID = "client"
+	loader.OAuthClientSecret = "secret"
+	loader.OAuthTokenURL = tokenServer.URL
+
+	configs, err := loader.LoadConfigs()
+	require.NoError(t, err)
+	require.Len(t, configs, 2)
+	assert.Equal(t, "OAUTH", configs[0].Config.DeploymentPrefix)
+	assert.Equal(t, "OAUTH", configs[1].Config.DeploymentPrefix)
+	// Token is fetched once and reused for the second URL
+	assert.Equal(t, 1, tokenRequests)
+}
+
 func TestLoadURL_HTTPError(t *testing.T) {
 	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
 		w.WriteHeader(http.StatusNotFound)
@@ -392,6 +620,129 @@ func TestLoadURL_HTTPError(t *testing.T) {
 	_, err := loader.LoadConfigs()
 	assert.Error(t, err)
 	assert.Contains(t, err.Error(), "status 404")
+	assert.NotContains(t, err.Error(), "attempts", "4xx responses are not retried")
+}
+
+func TestLoadURL_Retry(t *testing.T) {
+	requests := 0
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		requests++
+		if requests <= 2 {
+			w.WriteHeader(http.StatusServiceUnavailable)
+			return
+		}
+		w.WriteHeader(http.StatusOK)
+		w.Write([]byte("deploymentPrefix: RETRY\npackages: []"))
+	}))
+	defer server.Close()
+
+	loader := NewConfigLoader()
+	loader.URL = server.URL
+	loader.Source = SourceURL
+	loader.RetryDelay = time.Millisecond
+
+	configs, err := loader.LoadConfigs()
+	require.NoError(t, err)
+	require.Len(t, configs, 1)
+	assert.Equal(t, "RETRY", configs[0].Config.DeploymentPrefix)
+	assert.Equal(t, 3, requests)
+
+	// The request fails once the retries are used up
+	requests = 0
+	loader.MaxRetries = 1
+	_, err = loader.LoadConfigs()
+	assert.ErrorContains(t, err, "status 503 (after 2 attempts)")
+	assert.Equal(t, 2, requests)
+}
+
+func TestLoadURL_RetryCancelled(t *testing.T) {
+	ctx, cancel := context.WithCancel(context.Background())
+	requests := 0
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		requests++
+		cancel()
+		w.WriteHeader(http.StatusServiceUnavailable)
+	}))
+	defer server.Close()
+
+	loader := NewConfigLoader()
+	loader.URL = server.URL
+	loader.Source = SourceURL
+	loader.CacheDir = t.TempDir()
+	require.NoError(t, loader.writeCache(&urlResponse{body: []byte("deploymentPrefix: CACHED\npackages: []")}))
+	loader.RetryDelay = time.Hour
+
+	// The wait before the retry ends with the cancellation, the cached copy is not used
+	start := time.Now()
+	_, err := loader.LoadConfigsContext(ctx)
+	assert.ErrorIs(t, err, context.Canceled)
+	assert.Equal(t, 1, requests)
+	assert.Less(t, time.Since(start), time.Minute)
+}
+
+func TestLoadURL_Cache(t *testing.T) {
+	requests := 0
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		requests++
+		if r.Header.Get("If-None-Match") == `"v1"` {
+			w.WriteHeader(http.StatusNotModified)
+			return
+		}
+		w.Header().Set("ETag", `"v1"`)
+		w.WriteHeader(http.StatusOK)
+		w.Write([]byte("deploymentPrefix: CACHED\npackages: []"))
+	}))
+
+	loader := NewConfigLoader()
+	loader.URL = server.URL
+	loader.Source = SourceURL
+	loader.CacheDir = t.TempDir()
+	loader.MaxRetries = 0
+
+	// The first request fills the cache
+	configs, err := loader.LoadConfigs()
+	require.NoError(t, err)
+	assert.Equal(t, "CACHED", configs[0].Config.DeploymentPrefix)
+
+	// The cached copy is used when the server reports it as not modified
+	configs, err = loader.LoadConfigs()
+	require.NoError(t, err)
+	assert.Equal(t, "CACHED", configs[0].Config.DeploymentPrefix)
+	assert.Empty(t, configs[0].CacheFallback)
+	assert.Equal(t, 2, requests)
+
+	// The cached copy is used when the server is unreachable
+	server.Close()
+	configs, err = loader.LoadConfigs()
+	require.NoError(t, err)
+	assert.Equal(t, "CACHED", configs[0].Config.DeploymentPrefix)
+	assert.Contains(t, configs[0].CacheFallback, "failed to fetch URL")
+
+	// Without cache the error is reported
+	loader.CacheDir = ""
+	_, err = loader.LoadConfigs()
+	assert.ErrorContains(t, err, "failed to fetch URL")
+}
+
+func TestLoadURL_CacheNotUsedForErrorResponse(t *testing.T) {
+	status := http.StatusOK
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(status)
+		w.Write([]byte("deploymentPrefix: CACHED\npackages: []"))
+	}))
+	defer server.Close()
+
+	loader := NewConfigLoader()
+	loader.URL = server.URL
+	loader.Source = SourceURL
+	loader.CacheDir = t.TempDir()
+
+	_, err := loader.LoadConfigs()
+	require.NoError(t, err)
+
+	status = http.StatusUnauthorized
+	_, err = loader.LoadConfigs()
+	assert.ErrorContains(t, err, "status 401")
 }
 
 func TestMergeConfigs_Single(t *testing.T) {
@@ -510,6 +861,7 @@ func TestMergeConfigs_ArtifactPrefixing(t *testing.T) {
 						Artifacts: []models.Artifact{
 							{Id: "artifact1", Type: "Integration"},
 							{Id: "artifact2", Type: "Integration"},
+							{Id: "shared", Type: "Integration", SkipPrefix: true},
 						},
 					},
 				},
@@ -522,11 +874,12 @@ func TestMergeConfigs_ArtifactPrefixing(t *testing.T) {
 	require.NoError(t, err)
 
 	require.Len(t, merged.Packages, 1)
-	require.Len(t, merged.Packages[0].Artifacts, 2)
+	require.Len(t, merged.Packages[0].Artifacts, 3)
 
-	// Verify artifact IDs are prefixed
+	// Verify artifact IDs are prefixed, except with skipPrefix
 	assert.Equal(t, "TEST_artifact1", merged.Packages[0].Artifacts[0].Id)
 	assert.Equal(t, "TEST_artifact2", merged.Packages[0].Artifacts[1].Id)
+	assert.Equal(t, "shared", merged.Packages[0].Artifacts[2].Id)
 }
 
 func TestMergeConfigs_Empty(t *testing.T) {
@@ -556,3 +909,56 @@ func TestMergeConfigs_DisplayNameGeneration(t *testing.T) {
 	// Display name should be generated from prefix and ID
 	assert.Equal(t, "PREFIX - Package1", merged.Packages[0].DisplayName)
 }
+
+func TestLoadMultipleSources(t *testing.T) {
+	tempDir, err := os.MkdirTemp("", "config-test-*")
+	require.NoError(t, err)
+	defer os.RemoveAll(tempDir)
+
+	// Two folders with the same file name and a single file
+	for _, env := range []string{"dev", "qa"} {
+		require.NoError(t, os.MkdirAll(filepath.Join(tempDir, env), 0755))
+		content := "deploymentPrefix: " + strings.ToUpper(env) + "\npackages:\n  - integrationSuiteId: Pkg\n"
+		require.NoError(t, os.WriteFile(filepath.Join(tempDir, env, "config.yml"), []byte(content), 0644))
+	}
+	singleFile := filepath.Join(tempDir, "extra.yml")
+	require.NoError(t, os.WriteFile(singleFile, []byte("deploymentPrefix: EXTRA\npackages: []\n"), 0644))
+
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Write([]byte("deploymentPrefix: REMOTE\npackages: []\n"))
+	}))
+	defer server.Close()
+	remoteURL := server.URL + "/configs/remote.yml"
+
+	loader := NewConfigLoader()
+	err = loader.DetectSource(strings.Join([]string{
+		filepath.Join(tempDir, "qa"), remoteURL, " " + singleFile, filepath.Join(tempDir, "dev"),
+	}, ","))
+	require.NoError(t, err)
+	assert.Equal(t, SourceMultiple, loader.Source)
+
+	configs, err := loader.LoadConfigs()
+	require.NoError(t, err)
+	require.Len(t, configs, 4)
+
+	var prefixes, fileNames []string
+	for i, cfg := range configs {
+		assert.Equal(t, i, cfg.Order)
+		prefixes = append(prefixes, cfg.Config.DeploymentPrefix)
+		fileNames = append(fileNames, cfg.FileName)
+	}
+	assert.Equal(t, []string{"QA", "REMOTE", "EXTRA", "DEV"}, prefixes)
+	assert.Equal(t, []string{"config.yml", "remote.yml", "extra.yml", "config.yml"}, fileNames)
+	assert.Equal(t, remoteURL, configs[1].Source)
+}
+
+func TestDetectSource_MultipleWithMissingEntry(t *testing.T) {
+	tempDir, err := os.MkdirTemp("", "config-test-*")
+	require.NoError(t, err)
+	defer os.RemoveAll(tempDir)
+
+	loader := NewConfigLoader()
+	err = loader.DetectSource(tempDir + ",/nonexistent/config.yml")
+	require.Error(t, err)
+	assert.Contains(t, err.Error(), "path does not exist: /nonexistent/config.yml")
+}
@@ -0,0 +1,115 @@
+package deploy
+
+import (
+	"fmt"
+	"os"
+	"regexp"
+	"sort"
+	"strings"
+)
+
+// secretReference matches {{secret:NAME}} in config override values. A reference preceded by a
+// backslash is an escaped literal.
+var secretReference = regexp.MustCompile(`\\?\{\{secret:([^{}]*)\}\}`)
+
+// tenantCredentials are the environment variables holding the credentials of the tenant. They
+// cannot be referenced as secrets, so that a deployment config cannot write them to an artifact.
+var tenantCredentials = map[string]bool{
+	"FLASHPIPE_TMN_USERID":         true,
+	"FLASHPIPE_TMN_PASSWORD":       true,
+	"FLASHPIPE_OAUTH_CLIENTID":     true,
+	"FLASHPIPE_OAUTH_CLIENTSECRET": true,
+}
+
+// SecretResolver resolves {{secret:NAME}} references in config override values, so that secrets
+// are kept out of the deployment configs. Secrets are looked up in the environment first and
+// then in the secrets file.
+type SecretResolver struct {
+	secrets map[string]string
+}
+
+// NewSecretResolver returns a SecretResolver using the environment and, when secretsFile is set,
+// the NAME: value pairs of the YAML secrets file
+func NewSecretResolver(secretsFile string) (*SecretResolver, error) {
+	r := &SecretResolver{secrets: make(map[string]string)}
+	if secretsFile == "" {
+		return r, nil
+	}
+	values := make(map[string]interface{})
+	if err := readYAML(secretsFile, &values, false); err != nil {
+		// The error of the YAML parser is not included as it may quote a secret
+		if _, statErr := os.Stat(secretsFile); statErr != nil {
+			return nil, fmt.Errorf("failed to read secrets file %s: %w", secretsFile, statErr)
+		}
+		return nil, fmt.Errorf("failed to parse secrets file %s: expected NAME: value pairs", secretsFile)
+	}
+	for name, value := range values {
+		r.secrets[name] = FormatOverrideValue(value)
+	}
+	return r, nil
+}
+
+func (r *SecretResolver) lookup(name string) (string, bool) {
+	if value, ok := os.LookupEnv(name); ok {
+		return value, true
+	}
+	if r == nil {
+		return "", false
+	}
+	value, ok := r.secrets[name]
+	return value, ok
+}
+
+// ResolveOverrides returns the overrides with all secret references replaced. It returns an
+// error naming the config overrides and secrets that could not be resolved or that reference the
+// credentials of the tenant. A nil resolver only uses the environment.
+func (r *SecretResolver) ResolveOverrides(overrides map[string]interface{}) (map[string]interface{}, error) {
+	resolved := make(map[string]interface{}, len(overrides))
+	var missing, refused []string
+	for key, value := range overrides {
+		text, ok := value.(string)
+		if !ok || !strings.Contains(text, "{{secret:") {
+			resolved[key] = value
+			continue
+		}
+		resolved[key] = secretReference.ReplaceAllStringFunc(text, func(reference string) string {
+			if strings.HasPrefix(reference, `\`) {
+				return reference[1:]
+			}
+			name := strings.TrimSpace(secretReference.FindStringSubmatch(reference)[1])
+			if tenantCredentials[strings.ToUpper(name)] {
+				refused = append(refused, fmt.Sprintf("secret '%s' of config override %s", name, key))
+				return reference
+			}
+			secret, ok := r.lookup(name)
+			if !ok || name == "" {
+				missing = append(missing, fmt.Sprintf("secret '%s' of config override %s", name, key))
+				return reference
+			}
+			return secret
+		})
+	}
+	if len(refused) > 0 {
+		sort.Strings(refused)
+		return nil, fmt.Errorf("%s: credentials of the tenant cannot be used as secrets", strings.Join(refused, ", "))
+	}
+	if len(missing) > 0 {
+		sort.Strings(missing)
+		return nil, fmt.Errorf("unresolved %s: set it as environment variable or in the secrets file", strings.Join(missing, ", "))
+	}
+	return resolved, nil
+}
+
+// HasSecretReference reports whether a config override value references a secret
+func HasSecretReference(value interface{}) bool {
+	text, ok := value.(string)
+	if !ok {
+		return false
+	}
+	for _, match := range secretReference.FindAllString(text, -1) {
+		if !strings.HasPrefix(match, `\`) {
+			return true
+		}
+	}
+	return false
+}
@@ -0,0 +1,87 @@
+package deploy
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestSecretResolver_ResolveOverrides(t *testing.T) {
+	secretsFile := filepath.Join(t.TempDir(), "secrets.yml")
+	require.NoError(t, os.WriteFile(secretsFile, []byte("API_KEY: from-file\nDB_PASSWORD: pa$$word\nPORT: 8443\n"), 0644))
+	t.Setenv("API_KEY", "from-env")
+
+	resolver, err := NewSecretResolver(secretsFile)
+	require.NoError(t, err)
+
+	resolved, err := resolver.ResolveOverrides(map[string]interface{}{
+		"ApiKey":    "{{secret:API_KEY}}",
+		"Password":  "{{secret:DB_PASSWORD}}",
+		"Endpoint":  "https://host:{{secret:PORT}}/api",
+		"Template":  `\{{secret:API_KEY}}`,
+		"Timeout":   30,
+		"PlainText": "{{not a secret}}",
+	})
+	require.NoError(t, err)
+	assert.Equal(t, "from-env", resolved["ApiKey"], "the environment takes precedence")
+	assert.Equal(t, "pa$$word", resolved["Password"])
+	assert.Equal(t, "https://host:8443/api", resolved["Endpoint"])
+	assert.Equal(t, "{{secret:API_KEY}}", resolved["Template"])
+	assert.Equal(t, 30, resolved["Timeout"])
+	assert.Equal(t, "{{not a secret}}", resolved["PlainText"])
+}
+
+func TestSecretResolver_Unresolved(t *testing.T) {
+	resolver, err := NewSecretResolver("")
+	require.NoError(t, err)
+
+	_, err = resolver.ResolveOverrides(map[string]interface{}{
+		"Password": "{{secret:MISSING_SECRET}}",
+	})
+	assert.EqualError(t, err, "unresolved secret 'MISSING_SECRET' of config override Password: set it as environment variable or in the secrets file")
+
+	// A nil resolver uses the environment only
+	t.Setenv("ONLY_IN_ENV", "value")
+	var nilResolver *SecretResolver
+	resolved, err := nilResolver.ResolveOverrides(map[string]interface{}{"Key": "{{secret:ONLY_IN_ENV}}"})
+	require.NoError(t, err)
+	assert.Equal(t, "value", resolved["Key"])
+}
+
+func TestSecretResolver_TenantCredentials(t *testing.T) {
+	t.Setenv("FLASHPIPE_TMN_PASSWORD", "tenant-secret")
+	secretsFile := filepath.Join(t.TempDir(), "secrets.yml")
+	require.NoError(t, os.WriteFile(secretsFile, []byte("FLASHPIPE_OAUTH_CLIENTSECRET: client-secret\n"), 0644))
+
+	resolver, err := NewSecretResolver(secretsFile)
+	require.NoError(t, err)
+
+	_, err = resolver.ResolveOverrides(map[string]interface{}{
+		"Password": "{{secret:FLASHPIPE_TMN_PASSWORD}}",
+		"Secret":   "{{secret:FLASHPIPE_OAUTH_CLIENTSECRET}}",
+	})
+	assert.EqualError(t, err, "secret 'FLASHPIPE_OAUTH_CLIENTSECRET' of config override Secret, secret 'FLASHPIPE_TMN_PASSWORD' of config override Password: credentials of the tenant cannot be used as secrets")
+	assert.NotContains(t, err.Error(), "tenant-secret")
+}
+
+func TestNewSecretResolver_InvalidFile(t *testing.T) {
+	_, err := NewSecretResolver(filepath.Join(t.TempDir(), "missing.yml"))
+	assert.ErrorContains(t, err, "failed to read secrets file")
+
+	secretsFile := filepath.Join(t.TempDir(), "secrets.yml")
+	require.NoError(t, os.WriteFile(secretsFile, []byte("- top-secret\n"), 0644))
+	_, err = NewSecretResolver(secretsFile)
+	assert.ErrorContains(t, err, "failed to parse secrets file")
+	assert.NotContains(t, err.Error(), "top-secret")
+}
+
+func TestHasSecretReference(t *testing.T) {
+	assert.True(t, HasSecretReference("{{secret:API_KEY}}"))
+	assert.True(t, HasSecretReference(`\{{secret:A}} {{secret:B}}`))
+	assert.False(t, HasSecretReference(`\{{secret:API_KEY}}`))
+	assert.False(t, HasSecretReference("plain"))
+	assert.False(t, HasSecretReference(42))
+}
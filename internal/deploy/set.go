@@ -0,0 +1,150 @@
+package deploy
+
+import (
+	"fmt"
+	"strconv"
+	"strings"
+
+	"github.com/engswee/flashpipe/internal/models"
+)
+
+// SetOverride is a single --set expression on the deployment config, e.g. package.Orders.deploy=false
+type SetOverride struct {
+	Expression string
+	Scope      string // "deploymentPrefix", "package" or "artifact"
+	ID         string // package or artifact ID, empty for deploymentPrefix
+	Field      string // YAML name of the package or artifact field
+	Value      string
+}
+
+// ParseSetOverride parses an expression of the form deploymentPrefix=<value>,
+// package.<id>.<field>=<value> or artifact.<id>.<field>=<value>.
+// IDs may contain dots, the field is the part after the last dot.
+func ParseSetOverride(expression string) (SetOverride, error) {
+	path, value, found := strings.Cut(expression, "=")
+	if !found {
+		return SetOverride{}, fmt.Errorf("invalid --set %q: expected <path>=<value>", expression)
+	}
+	override := SetOverride{Expression: expression, Value: value}
+	if path == "deploymentPrefix" {
+		override.Scope = path
+		if err := ValidateDeploymentPrefix(value); err != nil {
+			return SetOverride{}, fmt.Errorf("invalid --set %q: %w", expression, err)
+		}
+		return override, nil
+	}
+
+	scope, rest, _ := strings.Cut(path, ".")
+	lastDot := strings.LastIndex(rest, ".")
+	if (scope != "package" && scope != "artifact") || lastDot <= 0 || lastDot == len(rest)-1 {
+		return SetOverride{}, fmt.Errorf("invalid --set path %q: expected deploymentPrefix, package.<id>.<field> or artifact.<id>.<field>", path)
+	}
+	override.Scope = scope
+	override.ID = rest[:lastDot]
+	override.Field = rest[lastDot+1:]
+
+	// Check the field and value without a config to catch errors before anything is loaded
+	var err error
+	if scope == "package" {
+		err = setPackageField(&models.Package{}, override.Field, value)
+	} else {
+		err = setArtifactField(&models.Artifact{}, override.Field, value)
+	}
+	if err != nil {
+		return SetOverride{}, fmt.Errorf("invalid --set %q: %w", expression, err)
+	}
+	return override, nil
+}
+
+// ApplySetOverrides applies the overrides to the loaded config files. An artifact override
+// applies to the artifact in every package that contains it. Overrides that match no package
+// or artifact in any of the configs are reported as error.
+func ApplySetOverrides(configFiles []*DeployConfigFile, overrides []SetOverride) error {
+	for _, override := range overrides {
+		matched := false
+		for _, configFile := range configFiles {
+			config := configFile.Config
+			switch override.Scope {
+			case "deploymentPrefix":
+				config.DeploymentPrefix = override.Value
+				matched = true
+			case "package":
+				for i := range config.Packages {
+					if config.Packages[i].ID == override.ID {
+						if err := setPackageField(&config.Packages[i], override.Field, override.Value); err != nil {
+							return err
+						}
+						matched = true
+					}
+				}
+			case "artifact":
+				for i := range config.Packages {
+					artifacts := config.Packages[i].Artifacts
+					for j := range artifacts {
+						if artifacts[j].Id == override.ID {
+							if err := setArtifactField(&artifacts[j], override.Field, override.Value); err != nil {
+								return err
+							}
+							matched = true
+						}
+					}
+				}
+			}
+		}
+		if !matched && override.Scope != "deploymentPrefix" {
+			return fmt.Errorf("invalid --set %q: %s %s not found in deployment config", override.Expression, override.Scope, override.ID)
+		}
+	}
+	return nil
+}
+
+func setPackageField(pkg *models.Package, field, value string) error {
+	switch field {
+	case "sync":
+		return setBool(&pkg.Sync, field, value)
+	case "deploy":
+		return setBool(&pkg.Deploy, field, value)
+	case "packageDir":
+		pkg.PackageDir = value
+	case "displayName":
+		pkg.DisplayName = value
+	case "description":
+		pkg.Description = value
+	case "short_text":
+		pkg.ShortText = value
+	case "version":
+		pkg.Version = value
+	case "vendor":
+		pkg.Vendor = value
+	default:
+		return fmt.Errorf("unknown package field %q: allowed fields are sync, deploy, packageDir, displayName, description, short_text, version, vendor", field)
+	}
+	return nil
+}
+
+func setArtifactField(artifact *models.Artifact, field, value string) error {
+	switch field {
+	case "sync":
+		return setBool(&artifact.Sync, field, value)
+	case "deploy":
+		return setBool(&artifact.Deploy, field, value)
+	case "artifactDir":
+		artifact.ArtifactDir = value
+	case "displayName":
+		artifact.DisplayName = value
+	case "type":
+		artifact.Type = value
+	default:
+		return fmt.Errorf("unknown artifact field %q: allowed fields are sync, deploy, artifactDir, displayName, type", field)
+	}
+	return nil
+}
+
+func setBool(target *bool, field, value string) error {
+	b, err := strconv.ParseBool(value)
+	if err != nil {
+		return fmt.Errorf("value of %s must be true or false, got %q", field, value)
+	}
+	*target = b
+	return nil
+}
@@ -0,0 +1,68 @@
+package deploy
+
+import (
+	"testing"
+
+	"github.com/engswee/flashpipe/internal/models"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestParseSetOverride(t *testing.T) {
+	override, err := ParseSetOverride("package.com.example.Orders.deploy=false")
+	require.NoError(t, err)
+	assert.Equal(t, SetOverride{Expression: "package.com.example.Orders.deploy=false", Scope: "package",
+		ID: "com.example.Orders", Field: "deploy", Value: "false"}, override)
+
+	override, err = ParseSetOverride("artifact.OrderFlow.displayName=Order Flow = v2")
+	require.NoError(t, err)
+	assert.Equal(t, "OrderFlow", override.ID)
+	assert.Equal(t, "Order Flow = v2", override.Value)
+
+	_, err = ParseSetOverride("deploymentPrefix=QA")
+	require.NoError(t, err)
+
+	_, err = ParseSetOverride("package.Orders.deploy")
+	assert.EqualError(t, err, `invalid --set "package.Orders.deploy": expected <path>=<value>`)
+	_, err = ParseSetOverride("iflow.Orders.deploy=false")
+	assert.ErrorContains(t, err, `invalid --set path "iflow.Orders.deploy"`)
+	_, err = ParseSetOverride("package.Orders=false")
+	assert.ErrorContains(t, err, `invalid --set path "package.Orders"`)
+	_, err = ParseSetOverride("package.Orders.deploy=no")
+	assert.EqualError(t, err, `invalid --set "package.Orders.deploy=no": value of deploy must be true or false, got "no"`)
+	_, err = ParseSetOverride("artifact.OrderFlow.configOverrides=x")
+	assert.ErrorContains(t, err, `unknown artifact field "configOverrides"`)
+	_, err = ParseSetOverride("deploymentPrefix=QA-1")
+	assert.ErrorContains(t, err, "deployment prefix can only contain")
+}
+
+func TestApplySetOverrides(t *testing.T) {
+	configFiles := []*DeployConfigFile{
+		{Config: &models.DeployConfig{Packages: []models.Package{
+			{ID: "Orders", Deploy: true, Artifacts: []models.Artifact{{Id: "OrderFlow", Sync: false}}},
+		}}},
+		{Config: &models.DeployConfig{Packages: []models.Package{
+			{ID: "Invoices", Deploy: true, Artifacts: []models.Artifact{{Id: "OrderFlow", Sync: false}}},
+		}}},
+	}
+
+	var overrides []SetOverride
+	for _, expression := range []string{"package.Orders.deploy=false", "artifact.OrderFlow.sync=true", "deploymentPrefix=QA"} {
+		override, err := ParseSetOverride(expression)
+		require.NoError(t, err)
+		overrides = append(overrides, override)
+	}
+	require.NoError(t, ApplySetOverrides(configFiles, overrides))
+
+	assert.False(t, configFiles[0].Config.Packages[0].Deploy)
+	assert.True(t, configFiles[1].Config.Packages[0].Deploy)
+	assert.True(t, configFiles[0].Config.Packages[0].Artifacts[0].Sync)
+	assert.True(t, configFiles[1].Config.Packages[0].Artifacts[0].Sync)
+	assert.Equal(t, "QA", configFiles[0].Config.DeploymentPrefix)
+	assert.Equal(t, "QA", configFiles[1].Config.DeploymentPrefix)
+
+	override, err := ParseSetOverride("package.Payments.deploy=false")
+	require.NoError(t, err)
+	err = ApplySetOverrides(configFiles, []SetOverride{override})
+	assert.EqualError(t, err, `invalid --set "package.Payments.deploy=false": package Payments not found in deployment config`)
+}
@@ -6,7 +6,12 @@ import (
 	"os"
 	"path/filepath"
 	"regexp"
+	"sort"
+	"strconv"
 	"strings"
+
+	"github.com/engswee/flashpipe/internal/models"
+	"github.com/engswee/flashpipe/internal/str"
 )
 
 // FileExists checks if a file exists
@@ -24,6 +29,17 @@ func DirExists(path string) bool {
 	return err == nil && info.IsDir()
 }
 
+// SortArtifactsByOrder returns a copy of the artifacts sorted by their order value.
+// Artifacts with equal order keep their declaration order.
+func SortArtifactsByOrder(artifacts []models.Artifact) []models.Artifact {
+	sorted := make([]models.Artifact, len(artifacts))
+	copy(sorted, artifacts)
+	sort.SliceStable(sorted, func(i, j int) bool {
+		return sorted[i].Order < sorted[j].Order
+	})
+	return sorted
+}
+
 // ValidateDeploymentPrefix validates that the deployment prefix only contains allowed characters
 func ValidateDeploymentPrefix(prefix string) error {
 	if prefix == "" {
@@ -149,7 +165,7 @@ func MergeParametersFile(paramsPath string, overrides map[string]interface{}, ou
 		}
 
 		// Detect line ending style
-		content := string(data)
+		content := str.TrimBOM(string(data))
 		if strings.Contains(content, "\r\n") {
 			lineEnding = "\r\n"
 		}
@@ -178,7 +194,7 @@ func MergeParametersFile(paramsPath string, overrides map[string]interface{}, ou
 
 	// Apply overrides
 	for key, value := range overrides {
-		valStr := fmt.Sprintf("%v", value)
+		valStr := FormatOverrideValue(value)
 		if _, exists := params[key]; !exists {
 			// New key, add to order
 			paramKeys = append(paramKeys, key)
@@ -210,6 +226,38 @@ func MergeParametersFile(paramsPath string, overrides map[string]interface{}, ou
 	return nil
 }
 
+// FormatOverrideValue renders a config override value as it is written to parameters.prop.
+// Floats are written without exponent and lists as comma-separated values. YAML drops
+// trailing zeros of numbers such as 1.50, so such values need to be quoted in the config.
+func FormatOverrideValue(value interface{}) string {
+	switch v := value.(type) {
+	case nil:
+		return ""
+	case string:
+		return v
+	case bool:
+		return strconv.FormatBool(v)
+	case int:
+		return strconv.Itoa(v)
+	case int64:
+		return strconv.FormatInt(v, 10)
+	case uint64:
+		return strconv.FormatUint(v, 10)
+	case float64:
+		return strconv.FormatFloat(v, 'f', -1, 64)
+	case []interface{}:
+		items := make([]string, 0, len(v))
+		for _, item := range v {
+			items = append(items, FormatOverrideValue(item))
+		}
+		return strings.Join(items, ",")
+	case []string:
+		return strings.Join(v, ",")
+	default:
+		return fmt.Sprintf("%v", v)
+	}
+}
+
 // FindParametersFile finds parameters.prop in various possible locations
 func FindParametersFile(artifactDir string) string {
 	possiblePaths := []string{
@@ -6,8 +6,10 @@ import (
 	"strings"
 	"testing"
 
+	"github.com/engswee/flashpipe/internal/models"
 	"github.com/stretchr/testify/assert"
 	"github.com/stretchr/testify/require"
+	"gopkg.in/yaml.v3"
 )
 
 func TestFileExists(t *testing.T) {
@@ -76,6 +78,27 @@ func TestDirExists(t *testing.T) {
 	}
 }
 
+func TestSortArtifactsByOrder(t *testing.T) {
+	artifacts := []models.Artifact{
+		{Id: "DependentFlow", Order: 2},
+		{Id: "Unordered1"},
+		{Id: "BaseMapping", Order: 1},
+		{Id: "Unordered2"},
+		{Id: "AnotherFlow", Order: 2},
+	}
+
+	sorted := SortArtifactsByOrder(artifacts)
+
+	var ids []string
+	for _, a := range sorted {
+		ids = append(ids, a.Id)
+	}
+	assert.Equal(t, []string{"Unordered1", "Unordered2", "BaseMapping", "DependentFlow", "AnotherFlow"}, ids)
+
+	// Original slice is left untouched
+	assert.Equal(t, "DependentFlow", artifacts[0].Id)
+}
+
 func TestValidateDeploymentPrefix_Valid(t *testing.T) {
 	tests := []struct {
 		name   string
@@ -421,6 +444,79 @@ func TestMergeParametersFile_PreservesLineEndings_CRLF(t *testing.T) {
 	assert.Contains(t, string(content), "\r\n")
 }
 
+func TestMergeParametersFile_StripsBOM(t *testing.T) {
+	tempDir, err := os.MkdirTemp("", "utils-test-*")
+	require.NoError(t, err)
+	defer os.RemoveAll(tempDir)
+
+	existingContent := "\uFEFFparam1=value1\r\nparam2=value2\r\n"
+	paramsPath := filepath.Join(tempDir, "parameters.prop")
+	err = os.WriteFile(paramsPath, []byte(existingContent), 0644)
+	require.NoError(t, err)
+
+	outputPath := filepath.Join(tempDir, "output.prop")
+
+	overrides := map[string]interface{}{"param1": "newValue1"}
+	err = MergeParametersFile(paramsPath, overrides, outputPath)
+	require.NoError(t, err)
+
+	content, err := os.ReadFile(outputPath)
+	require.NoError(t, err)
+
+	assert.Equal(t, "param1=newValue1\r\nparam2=value2\r\n", string(content))
+}
+
+func TestFormatOverrideValue(t *testing.T) {
+	tests := []struct {
+		name     string
+		value    interface{}
+		expected string
+	}{
+		{"string", "1.50", "1.50"},
+		{"bool", true, "true"},
+		{"int", 123, "123"},
+		{"int64", int64(-42), "-42"},
+		{"float", 1.5, "1.5"},
+		{"large float without exponent", 1500000.0, "1500000"},
+		{"small float without exponent", 0.000001, "0.000001"},
+		{"list", []interface{}{"a", 1, true}, "a,1,true"},
+		{"empty list", []interface{}{}, ""},
+		{"nil", nil, ""},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			assert.Equal(t, tt.expected, FormatOverrideValue(tt.value))
+		})
+	}
+}
+
+func TestMergeParametersFile_TypedValues(t *testing.T) {
+	tempDir, err := os.MkdirTemp("", "utils-test-*")
+	require.NoError(t, err)
+	defer os.RemoveAll(tempDir)
+
+	outputPath := filepath.Join(tempDir, "output.prop")
+
+	// Values as decoded from the YAML config
+	var overrides map[string]interface{}
+	err = yaml.Unmarshal([]byte("Enabled: true\nRetries: 3\nFactor: 2.25\nLimit: 1e6\nQuoted: \"1.50\"\nHosts: [a.example.com, b.example.com]\n"), &overrides)
+	require.NoError(t, err)
+
+	err = MergeParametersFile(filepath.Join(tempDir, "missing.prop"), overrides, outputPath)
+	require.NoError(t, err)
+
+	content, err := os.ReadFile(outputPath)
+	require.NoError(t, err)
+
+	assert.Contains(t, string(content), "Enabled=true\n")
+	assert.Contains(t, string(content), "Retries=3\n")
+	assert.Contains(t, string(content), "Factor=2.25\n")
+	assert.Contains(t, string(content), "Limit=1000000\n")
+	assert.Contains(t, string(content), "Quoted=1.50\n")
+	assert.Contains(t, string(content), "Hosts=a.example.com,b.example.com\n")
+}
+
 func TestFindParametersFile(t *testing.T) {
 	tempDir, err := os.MkdirTemp("", "utils-test-*")
 	require.NoError(t, err)
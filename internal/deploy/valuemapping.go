@@ -0,0 +1,170 @@
+package deploy
+
+import (
+	"bytes"
+	"encoding/xml"
+	"fmt"
+	"os"
+	"path/filepath"
+
+	"github.com/engswee/flashpipe/internal/str"
+)
+
+// ValueMappingEntry identifies one side of a value mapping by agency, identifier and value
+type ValueMappingEntry struct {
+	Agency     string `yaml:"agency"`
+	Identifier string `yaml:"identifier"`
+	Value      string `yaml:"value"`
+}
+
+// ValueMappingReplacement sets the target value of the mapping group containing the source entry
+type ValueMappingReplacement struct {
+	Source ValueMappingEntry `yaml:"source"`
+	Target ValueMappingEntry `yaml:"target"`
+}
+
+// valueMappingValues is the content of a per-environment valueMappingFile
+type valueMappingValues struct {
+	Replacements []ValueMappingReplacement `yaml:"replacements"`
+}
+
+// value_mapping.xml structure, unknown attributes and elements are kept when the content is patched
+type vmDocument struct {
+	XMLName xml.Name   `xml:"vm"`
+	Attrs   []xml.Attr `xml:",any,attr"`
+	Groups  []vmGroup  `xml:"group"`
+	Other   []vmNode   `xml:",any"`
+}
+
+type vmGroup struct {
+	Attrs   []xml.Attr `xml:",any,attr"`
+	Entries []vmEntry  `xml:"entry"`
+	Other   []vmNode   `xml:",any"`
+}
+
+type vmEntry struct {
+	Attrs  []xml.Attr `xml:",any,attr"`
+	Agency string     `xml:"agency"`
+	Schema string     `xml:"schema"`
+	Value  string     `xml:"value"`
+	Other  []vmNode   `xml:",any"`
+}
+
+// vmNode is an element that is not modelled, written back with its original content
+type vmNode struct {
+	XMLName xml.Name
+	Attrs   []xml.Attr `xml:",any,attr"`
+	Content []byte     `xml:",innerxml"`
+}
+
+// LoadValueMappingReplacements reads the replacements of a valueMappingFile
+func LoadValueMappingReplacements(path string) ([]ValueMappingReplacement, error) {
+	var values valueMappingValues
+	if err := readYAML(path, &values, true); err != nil {
+		return nil, fmt.Errorf("failed to load valueMappingFile %s: %w", path, err)
+	}
+	for i, r := range values.Replacements {
+		if r.Source.Agency == "" || r.Source.Identifier == "" || r.Source.Value == "" {
+			return nil, fmt.Errorf("replacement %d in valueMappingFile %s: source agency, identifier and value are required", i+1, path)
+		}
+		if r.Target.Agency == "" || r.Target.Identifier == "" {
+			return nil, fmt.Errorf("replacement %d in valueMappingFile %s: target agency and identifier are required", i+1, path)
+		}
+	}
+	return values.Replacements, nil
+}
+
+// ApplyValueMappingFile applies the replacements of a valueMappingFile to the value_mapping.xml of
+// the artifact in artifactDir, and returns the number of replacements
+func ApplyValueMappingFile(valueMappingFile string, artifactDir string) (int, error) {
+	replacements, err := LoadValueMappingReplacements(valueMappingFile)
+	if err != nil {
+		return 0, err
+	}
+	if err := PatchValueMappingFile(filepath.Join(artifactDir, "value_mapping.xml"), replacements); err != nil {
+		return 0, err
+	}
+	return len(replacements), nil
+}
+
+// PatchValueMappingFile applies the replacements to a value_mapping.xml file in place
+func PatchValueMappingFile(path string, replacements []ValueMappingReplacement) error {
+	content, err := os.ReadFile(path)
+	if err != nil {
+		return fmt.Errorf("failed to read value mapping: %w", err)
+	}
+	patched, err := PatchValueMapping(content, replacements)
+	if err != nil {
+		return err
+	}
+	if err := os.WriteFile(path, patched, 0644); err != nil {
+		return fmt.Errorf("failed to write value mapping: %w", err)
+	}
+	return nil
+}
+
+// PatchValueMapping replaces target values in value mapping XML content. Each replacement
+// updates every group containing the source entry; a source entry or target side that
+// does not exist is reported as an error so that typos do not go unnoticed.
+func PatchValueMapping(content []byte, replacements []ValueMappingReplacement) ([]byte, error) {
+	content = []byte(str.TrimBOM(string(content)))
+
+	var doc vmDocument
+	if err := xml.Unmarshal(content, &doc); err != nil {
+		return nil, fmt.Errorf("failed to parse value mapping: %w", err)
+	}
+
+	for _, r := range replacements {
+		matched := false
+		for i := range doc.Groups {
+			group := &doc.Groups[i]
+			if !group.contains(r.Source) {
+				continue
+			}
+			matched = true
+			target := group.find(r.Target.Agency, r.Target.Identifier)
+			if target == nil {
+				return nil, fmt.Errorf("value mapping for %s has no target %s", r.Source, r.Target.side())
+			}
+			target.Value = r.Target.Value
+		}
+		if !matched {
+			return nil, fmt.Errorf("value mapping entry %s not found", r.Source)
+		}
+	}
+
+	patched, err := xml.Marshal(doc)
+	if err != nil {
+		return nil, fmt.Errorf("failed to encode value mapping: %w", err)
+	}
+	if bytes.HasPrefix(bytes.TrimSpace(content), []byte("<?xml")) {
+		patched = append([]byte(xml.Header), patched...)
+	}
+	return patched, nil
+}
+
+func (g *vmGroup) contains(e ValueMappingEntry) bool {
+	for _, entry := range g.Entries {
+		if entry.Agency == e.Agency && entry.Schema == e.Identifier && entry.Value == e.Value {
+			return true
+		}
+	}
+	return false
+}
+
+func (g *vmGroup) find(agency, identifier string) *vmEntry {
+	for i := range g.Entries {
+		if g.Entries[i].Agency == agency && g.Entries[i].Schema == identifier {
+			return &g.Entries[i]
+		}
+	}
+	return nil
+}
+
+func (e ValueMappingEntry) side() string {
+	return e.Agency + "/" + e.Identifier
+}
+
+func (e ValueMappingEntry) String() string {
+	return fmt.Sprintf("%s/%s=%s", e.Agency, e.Identifier, e.Value)
+}
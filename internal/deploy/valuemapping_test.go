@@ -0,0 +1,118 @@
+package deploy
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+const testValueMapping = `<vm version="2.0">` +
+	`<group id="g1"><entry><agency>ERP</agency><schema>InfoType</schema><value>P0000</value></entry>` +
+	`<entry><agency>SFSF</agency><schema>RecordType</schema><value>PersonalData</value></entry></group>` +
+	`<group id="g2"><entry><agency>ERP</agency><schema>InfoType</schema><value>P0001</value></entry>` +
+	`<entry><agency>SFSF</agency><schema>RecordType</schema><value>Employment</value></entry></group>` +
+	`</vm>`
+
+func TestPatchValueMapping(t *testing.T) {
+	patched, err := PatchValueMapping([]byte(testValueMapping), []ValueMappingReplacement{{
+		Source: ValueMappingEntry{Agency: "ERP", Identifier: "InfoType", Value: "P0001"},
+		Target: ValueMappingEntry{Agency: "SFSF", Identifier: "RecordType", Value: "Employment_QA & <Test>"},
+	}})
+	require.NoError(t, err)
+
+	expected := `<vm version="2.0">` +
+		`<group id="g1"><entry><agency>ERP</agency><schema>InfoType</schema><value>P0000</value></entry>` +
+		`<entry><agency>SFSF</agency><schema>RecordType</schema><value>PersonalData</value></entry></group>` +
+		`<group id="g2"><entry><agency>ERP</agency><schema>InfoType</schema><value>P0001</value></entry>` +
+		`<entry><agency>SFSF</agency><schema>RecordType</schema><value>Employment_QA &amp; &lt;Test&gt;</value></entry></group>` +
+		`</vm>`
+	assert.Equal(t, expected, string(patched))
+}
+
+func TestPatchValueMapping_ReverseDirectionAndHeader(t *testing.T) {
+	content := "\uFEFF<?xml version=\"1.0\" encoding=\"UTF-8\"?>\n" + testValueMapping
+	patched, err := PatchValueMapping([]byte(content), []ValueMappingReplacement{{
+		Source: ValueMappingEntry{Agency: "SFSF", Identifier: "RecordType", Value: "PersonalData"},
+		Target: ValueMappingEntry{Agency: "ERP", Identifier: "InfoType", Value: "P9000"},
+	}})
+	require.NoError(t, err)
+	assert.Contains(t, string(patched), `<?xml version="1.0" encoding="UTF-8"?>`)
+	assert.Contains(t, string(patched), `<group id="g1"><entry><agency>ERP</agency><schema>InfoType</schema><value>P9000</value></entry>`)
+	assert.Contains(t, string(patched), `<value>P0001</value>`)
+}
+
+func TestPatchValueMapping_KeepsUnknownContent(t *testing.T) {
+	content := `<vm version="2.0">` +
+		`<group id="g1"><entry isDefault="true"><agency>ERP</agency><schema>InfoType</schema><value>P0000</value><note lang="en">HR <b>master</b></note></entry>` +
+		`<entry><agency>SFSF</agency><schema>RecordType</schema><value>PersonalData</value></entry><description>Personal data</description></group>` +
+		`<metadata owner="HR"></metadata></vm>`
+	patched, err := PatchValueMapping([]byte(content), []ValueMappingReplacement{{
+		Source: ValueMappingEntry{Agency: "ERP", Identifier: "InfoType", Value: "P0000"},
+		Target: ValueMappingEntry{Agency: "SFSF", Identifier: "RecordType", Value: "PersonalData_QA"},
+	}})
+	require.NoError(t, err)
+
+	expected := `<vm version="2.0">` +
+		`<group id="g1"><entry isDefault="true"><agency>ERP</agency><schema>InfoType</schema><value>P0000</value><note lang="en">HR <b>master</b></note></entry>` +
+		`<entry><agency>SFSF</agency><schema>RecordType</schema><value>PersonalData_QA</value></entry><description>Personal data</description></group>` +
+		`<metadata owner="HR"></metadata></vm>`
+	assert.Equal(t, expected, string(patched))
+}
+
+func TestPatchValueMapping_Errors(t *testing.T) {
+	_, err := PatchValueMapping([]byte(testValueMapping), []ValueMappingReplacement{{
+		Source: ValueMappingEntry{Agency: "ERP", Identifier: "InfoType", Value: "P0099"},
+		Target: ValueMappingEntry{Agency: "SFSF", Identifier: "RecordType", Value: "X"},
+	}})
+	assert.EqualError(t, err, "value mapping entry ERP/InfoType=P0099 not found")
+
+	_, err = PatchValueMapping([]byte(testValueMapping), []ValueMappingReplacement{{
+		Source: ValueMappingEntry{Agency: "ERP", Identifier: "InfoType", Value: "P0000"},
+		Target: ValueMappingEntry{Agency: "SFSF", Identifier: "Unknown", Value: "X"},
+	}})
+	assert.EqualError(t, err, "value mapping for ERP/InfoType=P0000 has no target SFSF/Unknown")
+
+	_, err = PatchValueMapping([]byte("<vm><group>"), nil)
+	assert.ErrorContains(t, err, "failed to parse value mapping")
+}
+
+func TestLoadValueMappingReplacementsAndPatchFile(t *testing.T) {
+	tempDir, err := os.MkdirTemp("", "valuemapping-*")
+	require.NoError(t, err)
+	defer os.RemoveAll(tempDir)
+
+	valuesPath := filepath.Join(tempDir, "qa-values.yml")
+	values := `replacements:
+  - source: {agency: ERP, identifier: InfoType, value: P0000}
+    target: {agency: SFSF, identifier: RecordType, value: PersonalData_QA}
+`
+	require.NoError(t, os.WriteFile(valuesPath, []byte(values), 0644))
+	vmPath := filepath.Join(tempDir, "value_mapping.xml")
+	require.NoError(t, os.WriteFile(vmPath, []byte(testValueMapping), 0644))
+
+	replacements, err := LoadValueMappingReplacements(valuesPath)
+	require.NoError(t, err)
+	require.Len(t, replacements, 1)
+	require.NoError(t, PatchValueMappingFile(vmPath, replacements))
+
+	content, err := os.ReadFile(vmPath)
+	require.NoError(t, err)
+	assert.Contains(t, string(content), "<value>PersonalData_QA</value>")
+
+	// The value mapping of an artifact directory is patched from the file
+	require.NoError(t, os.WriteFile(vmPath, []byte(testValueMapping), 0644))
+	count, err := ApplyValueMappingFile(valuesPath, tempDir)
+	require.NoError(t, err)
+	assert.Equal(t, 1, count)
+	content, err = os.ReadFile(vmPath)
+	require.NoError(t, err)
+	assert.Contains(t, string(content), "<value>PersonalData_QA</value>")
+
+	// Incomplete replacements are rejected
+	require.NoError(t, os.WriteFile(valuesPath, []byte("replacements:\n  - source: {agency: ERP, identifier: InfoType}\n"), 0644))
+	_, err = LoadValueMappingReplacements(valuesPath)
+	assert.ErrorContains(t, err, "source agency, identifier and value are required")
+}
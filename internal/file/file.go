@@ -181,6 +181,32 @@ func Exists(filePath string) bool {
 	return !errors.Is(err, os.ErrNotExist)
 }
 
+// IsZipArchive reports whether the file is a zip archive, by its .zip extension or its signature
+func IsZipArchive(filePath string) (bool, error) {
+	info, err := os.Stat(filePath)
+	if err != nil {
+		return false, errors.Wrap(err, 0)
+	}
+	if info.IsDir() {
+		return false, nil
+	}
+	if strings.EqualFold(filepath.Ext(filePath), ".zip") {
+		return true, nil
+	}
+
+	f, err := os.Open(filePath)
+	if err != nil {
+		return false, errors.Wrap(err, 0)
+	}
+	defer f.Close()
+	signature := make([]byte, 4)
+	if _, err := io.ReadFull(f, signature); err != nil {
+		return false, nil
+	}
+	// Local file header, or end of central directory of an empty archive
+	return string(signature) == "PK\x03\x04" || string(signature) == "PK\x05\x06", nil
+}
+
 func ReplaceDir(src string, dst string) (err error) {
 	err = os.RemoveAll(dst)
 	if err != nil {
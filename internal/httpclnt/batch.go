@@ -2,12 +2,15 @@ package httpclnt
 
 import (
 	"bytes"
+	"crypto/rand"
+	"encoding/hex"
 	"encoding/json"
 	"fmt"
 	"io"
 	"mime"
 	"mime/multipart"
 	"net/http"
+	"net/url"
 	"strconv"
 	"strings"
 
@@ -21,6 +24,9 @@ const (
 	// Batch boundary prefixes (must match OData multipart/mixed format)
 	batchBoundaryPrefix     = "batch_"
 	changesetBoundaryPrefix = "changeset_"
+
+	// maxBoundaryAttempts limits regenerating boundaries that occur in the operation content
+	maxBoundaryAttempts = 10
 )
 
 // BatchOperation represents a single operation in a batch request
@@ -38,7 +44,20 @@ type BatchResponse struct {
 	Operations []BatchOperationResponse
 }
 
-// BatchOperationResponse represents a single operation response
+// ByContentID returns the operation responses keyed by their Content-ID.
+// Responses without a Content-ID are not included.
+func (r *BatchResponse) ByContentID() map[string]BatchOperationResponse {
+	byID := make(map[string]BatchOperationResponse, len(r.Operations))
+	for _, op := range r.Operations {
+		if op.ContentID != "" {
+			byID[op.ContentID] = op
+		}
+	}
+	return byID
+}
+
+// BatchOperationResponse represents a single operation response. For failed operations
+// with an OData error body, Error is a *BatchOperationError; Body keeps the raw response.
 type BatchOperationResponse struct {
 	ContentID  string
 	StatusCode int
@@ -47,19 +66,74 @@ type BatchOperationResponse struct {
 	Error      error
 }
 
-// BatchRequest handles building and executing OData $batch requests
+// BatchOperationError is the OData error returned for a failed operation of a batch
+type BatchOperationError struct {
+	StatusCode int
+	Code       string
+	Message    string
+}
+
+func (e *BatchOperationError) Error() string {
+	msg := fmt.Sprintf("HTTP %d", e.StatusCode)
+	if e.Code != "" {
+		msg += " " + e.Code
+	}
+	if e.Message != "" {
+		msg += ": " + e.Message
+	}
+	return msg
+}
+
+// odataErrorBody is the JSON error body of OData V2 services
+type odataErrorBody struct {
+	Error struct {
+		Code    string `json:"code"`
+		Message struct {
+			Value string `json:"value"`
+		} `json:"message"`
+	} `json:"error"`
+}
+
+// parseODataError returns the error described by an OData JSON error body,
+// or nil if the body is not one
+func parseODataError(statusCode int, body []byte) error {
+	var errorBody odataErrorBody
+	if err := json.Unmarshal(body, &errorBody); err != nil {
+		return nil
+	}
+	if errorBody.Error.Code == "" && errorBody.Error.Message.Value == "" {
+		return nil
+	}
+	return &BatchOperationError{
+		StatusCode: statusCode,
+		Code:       errorBody.Error.Code,
+		Message:    errorBody.Error.Message.Value,
+	}
+}
+
+// RequestExecuter sends the $batch requests of a BatchRequest. It is implemented by HTTPExecuter,
+// and by fakes in tests.
+type RequestExecuter interface {
+	ExecRequestWithCookies(method string, path string, body io.Reader, headers map[string]string, cookies []*http.Cookie) (*http.Response, error)
+}
+
+// BatchRequest handles building and executing OData $batch requests.
+// Separate instances can be built and executed concurrently, as they share no state.
+// A single instance is not safe for concurrent use, e.g. AddOperation from multiple goroutines.
 type BatchRequest struct {
-	exe               *HTTPExecuter
+	exe               RequestExecuter
 	operations        []BatchOperation
 	batchBoundary     string
 	changesetBoundary string
 }
 
-// boundaryCounter is used to generate unique boundary strings
-var boundaryCounter = 0
-
 // NewBatchRequest creates a new batch request builder
 func (e *HTTPExecuter) NewBatchRequest() *BatchRequest {
+	return NewBatchRequest(e)
+}
+
+// NewBatchRequest creates a new batch request builder that sends the batch with exe
+func NewBatchRequest(e RequestExecuter) *BatchRequest {
 	return &BatchRequest{
 		exe:               e,
 		operations:        make([]BatchOperation, 0),
@@ -68,7 +142,7 @@ func (e *HTTPExecuter) NewBatchRequest() *BatchRequest {
 	}
 }
 
-// AddOperation adds an operation to the batch
+// AddOperation adds an operation to the batch. It must not be called concurrently on the same batch.
 func (br *BatchRequest) AddOperation(op BatchOperation) {
 	br.operations = append(br.operations, op)
 }
@@ -123,7 +197,7 @@ func (br *BatchRequest) ExecuteInBatches(batchSize int) (*BatchResponse, error)
 		}
 
 		// Create a batch for this chunk
-		batch := br.exe.NewBatchRequest()
+		batch := NewBatchRequest(br.exe)
 		batch.operations = allOps[i:end]
 
 		// Execute this batch
@@ -140,6 +214,10 @@ func (br *BatchRequest) ExecuteInBatches(batchSize int) (*BatchResponse, error)
 
 // buildBatchBody constructs the multipart batch request body
 func (br *BatchRequest) buildBatchBody() ([]byte, error) {
+	if err := br.ensureUniqueBoundaries(); err != nil {
+		return nil, err
+	}
+
 	var buf bytes.Buffer
 
 	// Separate query and changeset operations
@@ -154,21 +232,17 @@ func (br *BatchRequest) buildBatchBody() ([]byte, error) {
 		}
 	}
 
-	// Start batch boundary
-	fmt.Fprintf(&buf, "--%s\r\n", br.batchBoundary)
-
 	// Add query operations (if any) - these go directly in batch, not in changeset
-	if len(queryOps) > 0 {
-		for _, op := range queryOps {
-			if err := br.writeQueryOperation(&buf, op); err != nil {
-				return nil, err
-			}
-			fmt.Fprintf(&buf, "--%s\r\n", br.batchBoundary)
+	for _, op := range queryOps {
+		fmt.Fprintf(&buf, "--%s\r\n", br.batchBoundary)
+		if err := br.writeQueryOperation(&buf, op); err != nil {
+			return nil, err
 		}
 	}
 
 	// Add changeset for modifying operations (POST, PUT, DELETE, PATCH)
 	if len(changesetOps) > 0 {
+		fmt.Fprintf(&buf, "--%s\r\n", br.batchBoundary)
 		fmt.Fprintf(&buf, "Content-Type: multipart/mixed; boundary=%s\r\n", br.changesetBoundary)
 		fmt.Fprintf(&buf, "\r\n")
 
@@ -209,7 +283,8 @@ func (br *BatchRequest) writeQueryOperation(buf *bytes.Buffer, op BatchOperation
 		fmt.Fprintf(buf, "%s: %s\r\n", key, value)
 	}
 
-	fmt.Fprintf(buf, "\r\n")
+	// Empty line ending the headers, followed by the line break that belongs to the next boundary
+	fmt.Fprintf(buf, "\r\n\r\n")
 
 	return nil
 }
@@ -411,22 +486,93 @@ func (br *BatchRequest) parseOperationResponseFromPart(part *multipart.Part) (Ba
 		body = []byte(strings.TrimSpace(bodyStr))
 	}
 
-	return BatchOperationResponse{
+	opResp := BatchOperationResponse{
 		ContentID:  contentID,
 		StatusCode: statusCode,
 		Headers:    headers,
 		Body:       body,
-	}, nil
+	}
+	if statusCode < 200 || statusCode >= 300 {
+		opResp.Error = parseODataError(statusCode, body)
+	}
+	return opResp, nil
 }
 
-// generateBoundary generates a unique boundary string
+// generateBoundary generates a boundary string from the prefix and random hex characters
 func generateBoundary(prefix string) string {
-	boundaryCounter++
-	return fmt.Sprintf("%s%d", prefix, boundaryCounter)
+	b := make([]byte, 16)
+	// crypto/rand.Read never returns an error, it aborts the program if randomness is unavailable
+	_, _ = rand.Read(b)
+	return prefix + hex.EncodeToString(b)
+}
+
+// ensureUniqueBoundaries regenerates the boundaries while either of them occurs in the
+// content of an operation, since the multipart body could not be parsed correctly otherwise
+func (br *BatchRequest) ensureUniqueBoundaries() error {
+	for attempt := 0; attempt < maxBoundaryAttempts; attempt++ {
+		if !br.contentContains(br.batchBoundary) && !br.contentContains(br.changesetBoundary) {
+			return nil
+		}
+		log.Debug().Msg("Batch boundary occurs in the operation content, generating a new one")
+		br.batchBoundary = generateBoundary(batchBoundaryPrefix)
+		br.changesetBoundary = generateBoundary(changesetBoundaryPrefix)
+	}
+	return fmt.Errorf("failed to generate batch boundaries that do not occur in the operation content")
+}
+
+// contentContains reports whether the path, headers or body of any operation contain s
+func (br *BatchRequest) contentContains(s string) bool {
+	for _, op := range br.operations {
+		if strings.Contains(op.Path, s) || bytes.Contains(op.Body, []byte(s)) {
+			return true
+		}
+		for key, value := range op.Headers {
+			if strings.Contains(key, s) || strings.Contains(value, s) {
+				return true
+			}
+		}
+	}
+	return false
+}
+
+// EscapeODataKey escapes a value for use as a string literal in an OData key predicate.
+// Single quotes are doubled as required by OData, and the result is percent-encoded so
+// that spaces and other reserved characters survive in the URL path. '+' is encoded as
+// well since some servers decode it as a space.
+func EscapeODataKey(value string) string {
+	escaped := url.PathEscape(strings.ReplaceAll(value, "'", "''"))
+	return strings.ReplaceAll(escaped, "+", "%2B")
+}
+
+// ParameterPath returns the path of a single partner directory parameter in the given entity set
+func ParameterPath(entitySet, pid, id string) string {
+	return fmt.Sprintf("/api/v1/%s(Pid='%s',Id='%s')", entitySet, EscapeODataKey(pid), EscapeODataKey(id))
 }
 
 // Helper functions for building batch operations
 
+// AddGetStringParameterOp adds a GET operation for a string parameter to the batch
+func AddGetStringParameterOp(batch *BatchRequest, pid, id, contentID string) {
+	addGetParameterOp(batch, "StringParameters", pid, id, contentID)
+}
+
+// AddGetBinaryParameterOp adds a GET operation for a binary parameter to the batch
+func AddGetBinaryParameterOp(batch *BatchRequest, pid, id, contentID string) {
+	addGetParameterOp(batch, "BinaryParameters", pid, id, contentID)
+}
+
+func addGetParameterOp(batch *BatchRequest, entitySet, pid, id, contentID string) {
+	batch.AddOperation(BatchOperation{
+		Method:    "GET",
+		Path:      ParameterPath(entitySet, pid, id),
+		ContentID: contentID,
+		Headers: map[string]string{
+			"Accept": "application/json",
+		},
+		IsQuery: true,
+	})
+}
+
 // AddCreateStringParameterOp adds a CREATE operation for a string parameter to the batch
 func AddCreateStringParameterOp(batch *BatchRequest, pid, id, value, contentID string) {
 	body := map[string]string{
@@ -447,14 +593,15 @@ func AddCreateStringParameterOp(batch *BatchRequest, pid, id, value, contentID s
 	})
 }
 
-// AddUpdateStringParameterOp adds an UPDATE operation for a string parameter to the batch
-func AddUpdateStringParameterOp(batch *BatchRequest, pid, id, value, contentID string) {
+// AddUpdateStringParameterOp adds an UPDATE operation for a string parameter to the batch.
+// ifMatch is the ETag the parameter must still have, or "*" (also used when empty) to overwrite it unconditionally.
+func AddUpdateStringParameterOp(batch *BatchRequest, pid, id, value, ifMatch, contentID string) {
 	body := map[string]string{
 		"Value": value,
 	}
 	bodyJSON, _ := json.Marshal(body)
 
-	path := fmt.Sprintf("/api/v1/StringParameters(Pid='%s',Id='%s')", pid, id)
+	path := ParameterPath("StringParameters", pid, id)
 
 	batch.AddOperation(BatchOperation{
 		Method:    "PUT",
@@ -463,14 +610,14 @@ func AddUpdateStringParameterOp(batch *BatchRequest, pid, id, value, contentID s
 		ContentID: contentID,
 		Headers: map[string]string{
 			"Content-Type": "application/json",
-			"If-Match":     "*",
+			"If-Match":     ifMatchOrAny(ifMatch),
 		},
 	})
 }
 
 // AddDeleteStringParameterOp adds a DELETE operation for a string parameter to the batch
 func AddDeleteStringParameterOp(batch *BatchRequest, pid, id, contentID string) {
-	path := fmt.Sprintf("/api/v1/StringParameters(Pid='%s',Id='%s')", pid, id)
+	path := ParameterPath("StringParameters", pid, id)
 
 	batch.AddOperation(BatchOperation{
 		Method:    "DELETE",
@@ -503,15 +650,16 @@ func AddCreateBinaryParameterOp(batch *BatchRequest, pid, id, value, contentType
 	})
 }
 
-// AddUpdateBinaryParameterOp adds an UPDATE operation for a binary parameter to the batch
-func AddUpdateBinaryParameterOp(batch *BatchRequest, pid, id, value, contentType, contentID string) {
+// AddUpdateBinaryParameterOp adds an UPDATE operation for a binary parameter to the batch.
+// ifMatch is the ETag the parameter must still have, or "*" (also used when empty) to overwrite it unconditionally.
+func AddUpdateBinaryParameterOp(batch *BatchRequest, pid, id, value, contentType, ifMatch, contentID string) {
 	body := map[string]string{
 		"Value":       value,
 		"ContentType": contentType,
 	}
 	bodyJSON, _ := json.Marshal(body)
 
-	path := fmt.Sprintf("/api/v1/BinaryParameters(Pid='%s',Id='%s')", pid, id)
+	path := ParameterPath("BinaryParameters", pid, id)
 
 	batch.AddOperation(BatchOperation{
 		Method:    "PUT",
@@ -520,14 +668,22 @@ func AddUpdateBinaryParameterOp(batch *BatchRequest, pid, id, value, contentType
 		ContentID: contentID,
 		Headers: map[string]string{
 			"Content-Type": "application/json",
-			"If-Match":     "*",
+			"If-Match":     ifMatchOrAny(ifMatch),
 		},
 	})
 }
 
+// ifMatchOrAny returns the If-Match header value, matching any version when no ETag is given
+func ifMatchOrAny(ifMatch string) string {
+	if ifMatch == "" {
+		return "*"
+	}
+	return ifMatch
+}
+
 // AddDeleteBinaryParameterOp adds a DELETE operation for a binary parameter to the batch
 func AddDeleteBinaryParameterOp(batch *BatchRequest, pid, id, contentID string) {
-	path := fmt.Sprintf("/api/v1/BinaryParameters(Pid='%s',Id='%s')", pid, id)
+	path := ParameterPath("BinaryParameters", pid, id)
 
 	batch.AddOperation(BatchOperation{
 		Method:    "DELETE",
@@ -0,0 +1,155 @@
+package httpclnt
+
+import (
+	"bytes"
+	"fmt"
+	"io"
+	"mime/multipart"
+	"net/http"
+	"strconv"
+	"strings"
+	"sync"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestEscapeODataKey(t *testing.T) {
+	assert.Equal(t, "SAP_SYSTEM_001", EscapeODataKey("SAP_SYSTEM_001"))
+	assert.Equal(t, "O%27%27Brien", EscapeODataKey("O'Brien"))
+	assert.Equal(t, "Key%20With%20Spaces", EscapeODataKey("Key With Spaces"))
+	assert.Equal(t, "a%2Fb%3Fc%23d%2Be%25f", EscapeODataKey("a/b?c#d+e%f"))
+}
+
+func TestParameterPath(t *testing.T) {
+	assert.Equal(t, "/api/v1/StringParameters(Pid='PID%201',Id='it%27%27s')",
+		ParameterPath("StringParameters", "PID 1", "it's"))
+}
+
+func TestBatchOperationsEscapeKeys(t *testing.T) {
+	exe := New("", "", "", "", "user", "password", "localhost", "http", 8080, false)
+	batch := exe.NewBatchRequest()
+	AddUpdateStringParameterOp(batch, "PID 1", "it's", "value", "", "1")
+	AddDeleteBinaryParameterOp(batch, "PID 1", "cert #1", "2")
+
+	body, err := batch.buildBatchBody()
+	require.NoError(t, err)
+	assert.Contains(t, string(body), "StringParameters(Pid='PID%201',Id='it%27%27s') HTTP/1.1")
+	assert.Contains(t, string(body), "BinaryParameters(Pid='PID%201',Id='cert%20%231') HTTP/1.1")
+}
+
+func TestGenerateBoundary(t *testing.T) {
+	first := generateBoundary(batchBoundaryPrefix)
+	second := generateBoundary(batchBoundaryPrefix)
+
+	assert.Regexp(t, `^batch_[0-9a-f]{32}$`, first)
+	assert.NotEqual(t, first, second)
+}
+
+func TestBuildBatchBody_RegeneratesConflictingBoundary(t *testing.T) {
+	exe := New("", "", "", "", "user", "password", "localhost", "http", 8080, false)
+	batch := exe.NewBatchRequest()
+	conflicting := batch.changesetBoundary
+	AddCreateStringParameterOp(batch, "PID", "Boundary", "value with --"+conflicting+" inside", "1")
+
+	body, err := batch.buildBatchBody()
+	require.NoError(t, err)
+	assert.NotEqual(t, conflicting, batch.changesetBoundary)
+	assert.Contains(t, string(body), "--"+batch.changesetBoundary+"\r\n")
+	assert.Equal(t, 1, strings.Count(string(body), conflicting))
+}
+
+// Run with -race to detect shared state between batches built concurrently
+func TestBatchRequest_ConcurrentBatches(t *testing.T) {
+	exe := New("", "", "", "", "user", "password", "localhost", "http", 8080, false)
+
+	const workers = 50
+	boundaries := make([]string, workers)
+	errs := make([]error, workers)
+	var wg sync.WaitGroup
+	for i := 0; i < workers; i++ {
+		wg.Add(1)
+		go func(i int) {
+			defer wg.Done()
+			batch := exe.NewBatchRequest()
+			for j := 0; j < 10; j++ {
+				AddCreateStringParameterOp(batch, "PID", fmt.Sprintf("Param%d_%d", i, j), "value", strconv.Itoa(j))
+			}
+			_, errs[i] = batch.buildBatchBody()
+			boundaries[i] = batch.batchBoundary
+		}(i)
+	}
+	wg.Wait()
+
+	seen := make(map[string]bool, workers)
+	for i := 0; i < workers; i++ {
+		require.NoError(t, errs[i])
+		assert.False(t, seen[boundaries[i]], "duplicate boundary %s", boundaries[i])
+		seen[boundaries[i]] = true
+	}
+}
+
+func TestParseBatchResponse_ODataError(t *testing.T) {
+	body := "--batchresp\r\n" +
+		"Content-Type: multipart/mixed; boundary=csresp\r\n\r\n" +
+		"--csresp\r\nContent-Type: application/http\r\nContent-ID: 1\r\n\r\n" +
+		"HTTP/1.1 400 Bad Request\r\nContent-Type: application/json\r\n\r\n" +
+		`{"error":{"code":"Bad Request","message":{"lang":"en","value":"Value exceeds maximum length"}}}` + "\r\n" +
+		"--csresp\r\nContent-Type: application/http\r\nContent-ID: 2\r\n\r\nHTTP/1.1 500 Internal Server Error\r\n\r\nboom\r\n" +
+		"--csresp\r\nContent-Type: application/http\r\nContent-ID: 3\r\n\r\nHTTP/1.1 201 Created\r\n\r\n\r\n" +
+		"--csresp--\r\n\r\n" +
+		"--batchresp--\r\n"
+	resp := &http.Response{
+		Header: http.Header{"Content-Type": []string{"multipart/mixed; boundary=batchresp"}},
+		Body:   io.NopCloser(strings.NewReader(body)),
+	}
+
+	exe := New("", "", "", "", "user", "password", "localhost", "http", 8080, false)
+	batchResp, err := exe.NewBatchRequest().parseBatchResponse(resp)
+	require.NoError(t, err)
+	require.Len(t, batchResp.Operations, 3)
+
+	var opErr *BatchOperationError
+	require.ErrorAs(t, batchResp.Operations[0].Error, &opErr)
+	assert.Equal(t, &BatchOperationError{StatusCode: 400, Code: "Bad Request", Message: "Value exceeds maximum length"}, opErr)
+	assert.EqualError(t, opErr, "HTTP 400 Bad Request: Value exceeds maximum length")
+	assert.Contains(t, string(batchResp.Operations[0].Body), `"Value exceeds maximum length"`)
+
+	// Bodies that are no OData error only keep the status code
+	assert.NoError(t, batchResp.Operations[1].Error)
+	assert.Equal(t, 500, batchResp.Operations[1].StatusCode)
+	assert.Equal(t, "boom", string(batchResp.Operations[1].Body))
+
+	assert.NoError(t, batchResp.Operations[2].Error)
+}
+
+func TestBuildBatchBody_QueryOperations(t *testing.T) {
+	exe := New("", "", "", "", "user", "password", "localhost", "http", 8080, false)
+	batch := exe.NewBatchRequest()
+	AddGetStringParameterOp(batch, "PID 1", "Param1", "1")
+	AddGetBinaryParameterOp(batch, "PID 1", "Cert", "2")
+
+	body, err := batch.buildBatchBody()
+	require.NoError(t, err)
+
+	// Each query is a separate part of the batch, without a changeset
+	mr := multipart.NewReader(bytes.NewReader(body), batch.batchBoundary)
+	var requests []string
+	for {
+		part, err := mr.NextPart()
+		if err == io.EOF {
+			break
+		}
+		require.NoError(t, err)
+		assert.Equal(t, "application/http", part.Header.Get("Content-Type"))
+		content, err := io.ReadAll(part)
+		require.NoError(t, err)
+		requests = append(requests, strings.SplitN(string(content), "\r\n", 2)[0])
+	}
+	assert.Equal(t, []string{
+		"GET /api/v1/StringParameters(Pid='PID%201',Id='Param1') HTTP/1.1",
+		"GET /api/v1/BinaryParameters(Pid='PID%201',Id='Cert') HTTP/1.1",
+	}, requests)
+	assert.NotContains(t, string(body), batch.changesetBoundary)
+}
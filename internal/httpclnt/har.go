@@ -0,0 +1,386 @@
+package httpclnt
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"os"
+	"regexp"
+	"strings"
+	"sync"
+	"sync/atomic"
+	"time"
+)
+
+// redacted replaces credentials and secrets in the trace file
+const redacted = "[REDACTED]"
+
+// maxTracedBodySize is the maximum size of a request or response body written to the trace file
+const maxTracedBodySize = 1 << 20
+
+// activeTrace is set while a trace file is recorded. When it is nil, requests are executed
+// without any tracing overhead.
+var activeTrace atomic.Pointer[traceRecorder]
+
+var (
+	sensitiveHeaders = map[string]bool{
+		"authorization":       true,
+		"proxy-authorization": true,
+		"cookie":              true,
+		"set-cookie":          true,
+		"x-csrf-token":        true,
+	}
+	sensitiveName = regexp.MustCompile(`(?i)password|secret|token|credential|passphrase|private`)
+	// sensitiveJSONField matches "name": "value" pairs with a sensitive name in text that is not
+	// valid JSON as a whole, e.g. the parts of a $batch request
+	sensitiveJSONField = regexp.MustCompile(`(?i)("[^"]*(?:password|secret|token|credential|passphrase|private)[^"]*"\s*:\s*)"(?:[^"\\]|\\.)*"`)
+	// configurationKey extracts the key of a configuration parameter from its URL
+	configurationKey = regexp.MustCompile(`Configurations\('([^']*)'\)`)
+	// partnerDirectoryKey extracts the Id of a partner directory parameter from its URL
+	partnerDirectoryKey = regexp.MustCompile(`(?:String|Binary)Parameters\(Pid='(?:[^']|'')*',Id='((?:[^']|'')*)'\)`)
+)
+
+// traceRecorder collects the HTTP requests of a command for the trace file
+type traceRecorder struct {
+	path    string
+	version string
+	mu      sync.Mutex
+	entries []harEntry
+}
+
+// StartTrace records all requests executed through HTTPExecuter until StopTrace is called,
+// which writes them to path in HAR format. Credentials and secrets are redacted.
+func StartTrace(path string, version string) {
+	activeTrace.Store(&traceRecorder{path: path, version: version})
+}
+
+// StopTrace stops recording requests and writes the trace file. It does nothing when no trace
+// was started.
+func StopTrace() error {
+	r := activeTrace.Swap(nil)
+	if r == nil {
+		return nil
+	}
+	return r.write()
+}
+
+type harLog struct {
+	Log struct {
+		Version string     `json:"version"`
+		Creator harCreator `json:"creator"`
+		Entries []harEntry `json:"entries"`
+	} `json:"log"`
+}
+
+type harCreator struct {
+	Name    string `json:"name"`
+	Version string `json:"version"`
+}
+
+type harEntry struct {
+	StartedDateTime string      `json:"startedDateTime"`
+	Time            float64     `json:"time"`
+	Request         harRequest  `json:"request"`
+	Response        harResponse `json:"response"`
+	Cache           struct{}    `json:"cache"`
+	Timings         harTimings  `json:"timings"`
+	Comment         string      `json:"comment,omitempty"`
+}
+
+type harRequest struct {
+	Method      string         `json:"method"`
+	URL         string         `json:"url"`
+	HTTPVersion string         `json:"httpVersion"`
+	Headers     []harNameValue `json:"headers"`
+	QueryString []harNameValue `json:"queryString"`
+	Cookies     []harNameValue `json:"cookies"`
+	HeadersSize int            `json:"headersSize"`
+	BodySize    int            `json:"bodySize"`
+	PostData    *harPostData   `json:"postData,omitempty"`
+}
+
+type harResponse struct {
+	Status      int            `json:"status"`
+	StatusText  string         `json:"statusText"`
+	HTTPVersion string         `json:"httpVersion"`
+	Headers     []harNameValue `json:"headers"`
+	Cookies     []harNameValue `json:"cookies"`
+	Content     harContent     `json:"content"`
+	RedirectURL string         `json:"redirectURL"`
+	HeadersSize int            `json:"headersSize"`
+	BodySize    int            `json:"bodySize"`
+}
+
+type harNameValue struct {
+	Name  string `json:"name"`
+	Value string `json:"value"`
+}
+
+type harPostData struct {
+	MimeType string `json:"mimeType"`
+	Text     string `json:"text"`
+}
+
+type harContent struct {
+	Size     int    `json:"size"`
+	MimeType string `json:"mimeType"`
+	Text     string `json:"text,omitempty"`
+	Comment  string `json:"comment,omitempty"`
+}
+
+type harTimings struct {
+	Send    float64 `json:"send"`
+	Wait    float64 `json:"wait"`
+	Receive float64 `json:"receive"`
+}
+
+// do executes the request and records it. The bodies are buffered, so that they can be
+// recorded and still be read by the caller.
+func (r *traceRecorder) do(client *http.Client, req *http.Request) (*http.Response, error) {
+	var reqBody []byte
+	if req.Body != nil && req.Body != http.NoBody {
+		body, err := io.ReadAll(req.Body)
+		req.Body.Close()
+		if err != nil {
+			return nil, err
+		}
+		reqBody = body
+		req.Body = io.NopCloser(bytes.NewReader(body))
+	}
+
+	start := time.Now()
+	resp, err := client.Do(req)
+	wait := time.Since(start)
+
+	entry := harEntry{
+		StartedDateTime: start.Format(time.RFC3339Nano),
+		Request:         traceRequest(req, reqBody),
+		Response:        harResponse{HTTPVersion: "HTTP/1.1", Headers: []harNameValue{}, Cookies: []harNameValue{}, HeadersSize: -1, BodySize: -1},
+	}
+	if err != nil {
+		entry.Time = milliseconds(wait)
+		entry.Timings = harTimings{Wait: milliseconds(wait)}
+		entry.Comment = fmt.Sprintf("request failed: %v", err)
+		r.add(entry)
+		return resp, err
+	}
+
+	respBody, err := io.ReadAll(resp.Body)
+	resp.Body.Close()
+	receive := time.Since(start) - wait
+	resp.Body = io.NopCloser(bytes.NewReader(respBody))
+
+	entry.Time = milliseconds(wait + receive)
+	entry.Timings = harTimings{Wait: milliseconds(wait), Receive: milliseconds(receive)}
+	entry.Response = traceResponse(req, resp, respBody)
+	if err != nil {
+		entry.Comment = fmt.Sprintf("failed to read response body: %v", err)
+	}
+	r.add(entry)
+	return resp, err
+}
+
+func (r *traceRecorder) add(entry harEntry) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	r.entries = append(r.entries, entry)
+}
+
+func (r *traceRecorder) write() error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	var har harLog
+	har.Log.Version = "1.2"
+	har.Log.Creator = harCreator{Name: "flashpipe", Version: r.version}
+	har.Log.Entries = r.entries
+	if har.Log.Entries == nil {
+		har.Log.Entries = []harEntry{}
+	}
+	content, err := json.MarshalIndent(har, "", "  ")
+	if err != nil {
+		return fmt.Errorf("failed to encode trace file: %w", err)
+	}
+	if err := os.WriteFile(r.path, content, 0600); err != nil {
+		return fmt.Errorf("failed to write trace file %s: %w", r.path, err)
+	}
+	return nil
+}
+
+func traceRequest(req *http.Request, body []byte) harRequest {
+	traced := harRequest{
+		Method:      req.Method,
+		URL:         req.URL.String(),
+		HTTPVersion: "HTTP/1.1",
+		Headers:     traceHeaders(req.Header),
+		QueryString: []harNameValue{},
+		Cookies:     []harNameValue{},
+		HeadersSize: -1,
+		BodySize:    len(body),
+	}
+	for name, values := range req.URL.Query() {
+		for _, value := range values {
+			traced.QueryString = append(traced.QueryString, harNameValue{Name: name, Value: value})
+		}
+	}
+	if len(body) > 0 {
+		mimeType := req.Header.Get("Content-Type")
+		text, _ := traceBody(body, mimeType, req.URL.Path)
+		traced.PostData = &harPostData{MimeType: mimeType, Text: text}
+	}
+	return traced
+}
+
+func traceResponse(req *http.Request, resp *http.Response, body []byte) harResponse {
+	mimeType := resp.Header.Get("Content-Type")
+	text, comment := traceBody(body, mimeType, req.URL.Path)
+	return harResponse{
+		Status:      resp.StatusCode,
+		StatusText:  http.StatusText(resp.StatusCode),
+		HTTPVersion: resp.Proto,
+		Headers:     traceHeaders(resp.Header),
+		Cookies:     []harNameValue{},
+		Content:     harContent{Size: len(body), MimeType: mimeType, Text: text, Comment: comment},
+		RedirectURL: resp.Header.Get("Location"),
+		HeadersSize: -1,
+		BodySize:    len(body),
+	}
+}
+
+// traceHeaders returns the headers with the values of credentials, cookies and CSRF tokens redacted
+func traceHeaders(header http.Header) []harNameValue {
+	headers := []harNameValue{}
+	for name, values := range header {
+		for _, value := range values {
+			if sensitiveHeaders[strings.ToLower(name)] {
+				value = redacted
+			}
+			headers = append(headers, harNameValue{Name: name, Value: value})
+		}
+	}
+	return headers
+}
+
+// traceBody returns the body as it is written to the trace file with secrets redacted, and a
+// comment when the body is not or only partly recorded
+func traceBody(body []byte, mimeType string, urlPath string) (string, string) {
+	if len(body) == 0 {
+		return "", ""
+	}
+	if !isTextContent(mimeType) {
+		return "", "binary content not recorded"
+	}
+
+	// The key of a parameter that is updated is only part of the URL
+	parameterKey := urlParameterKey(urlPath)
+	text := ""
+	var content interface{}
+	if json.Unmarshal(body, &content) == nil {
+		if redactedContent, err := json.Marshal(redactJSON(content, parameterKey)); err == nil {
+			text = string(redactedContent)
+		}
+	}
+	if text == "" {
+		text = redactText(string(body), parameterKey)
+	}
+
+	// The full body is redacted before it is truncated, so that artifact content and secrets
+	// are also removed from large bodies
+	if len(text) > maxTracedBodySize {
+		return text[:maxTracedBodySize], fmt.Sprintf("truncated to %d bytes", maxTracedBodySize)
+	}
+	return text, ""
+}
+
+// urlParameterKey returns the key of the configuration parameter or the Id of the partner
+// directory parameter addressed by a URL, or an empty string
+func urlParameterKey(urlPath string) string {
+	if match := configurationKey.FindStringSubmatch(urlPath); match != nil {
+		return match[1]
+	}
+	if match := partnerDirectoryKey.FindStringSubmatch(urlPath); match != nil {
+		return match[1]
+	}
+	return ""
+}
+
+// redactText redacts text that is not valid JSON as a whole, e.g. the parts of a $batch request.
+// JSON lines are redacted like JSON bodies, using the parameter key of the preceding request line
+// of the part.
+func redactText(text string, parameterKey string) string {
+	lines := strings.Split(text, "\n")
+	key := parameterKey
+	for i, line := range lines {
+		trimmed := strings.TrimSpace(line)
+		if strings.HasPrefix(trimmed, "--") {
+			key = parameterKey
+			continue
+		}
+		var content interface{}
+		if strings.HasPrefix(trimmed, "{") && json.Unmarshal([]byte(trimmed), &content) == nil {
+			if redactedContent, err := json.Marshal(redactJSON(content, key)); err == nil {
+				lines[i] = strings.Replace(line, trimmed, string(redactedContent), 1)
+				continue
+			}
+		}
+		if lineKey := urlParameterKey(trimmed); lineKey != "" {
+			key = lineKey
+		}
+		lines[i] = sensitiveJSONField.ReplaceAllString(line, `$1"`+redacted+`"`)
+	}
+	return strings.Join(lines, "\n")
+}
+
+// redactJSON replaces the values of fields with a sensitive name, the values of configuration
+// and partner directory parameters with a sensitive key, and the content of artifacts
+func redactJSON(value interface{}, parameterKey string) interface{} {
+	switch v := value.(type) {
+	case map[string]interface{}:
+		key := parameterKey
+		if k, ok := v["ParameterKey"].(string); ok {
+			key = k
+		}
+		// Partner directory parameters are identified by Pid and Id
+		if _, ok := v["Pid"]; ok {
+			if id, ok := v["Id"].(string); ok {
+				key = id
+			}
+		}
+		for name, fieldValue := range v {
+			// Artifact content is a zip file that may contain parameter values, e.g. in parameters.prop
+			if name == "ArtifactContent" {
+				v[name] = "[artifact content not recorded]"
+				continue
+			}
+			if sensitiveName.MatchString(name) || ((name == "ParameterValue" || name == "Value") && sensitiveName.MatchString(key)) {
+				v[name] = redacted
+				continue
+			}
+			v[name] = redactJSON(fieldValue, parameterKey)
+		}
+		return v
+	case []interface{}:
+		for i := range v {
+			v[i] = redactJSON(v[i], parameterKey)
+		}
+		return v
+	default:
+		return value
+	}
+}
+
+func isTextContent(mimeType string) bool {
+	mimeType = strings.ToLower(mimeType)
+	for _, text := range []string{"json", "xml", "text/", "multipart/mixed"} {
+		if strings.Contains(mimeType, text) {
+			return true
+		}
+	}
+	return false
+}
+
+func milliseconds(d time.Duration) float64 {
+	return float64(d) / float64(time.Millisecond)
+}
@@ -0,0 +1,134 @@
+package httpclnt
+
+import (
+	"encoding/json"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+func TestTrace(t *testing.T) {
+	svr := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Content-Type", "application/json")
+		w.Header().Set("x-csrf-token", "csrf-secret-token")
+		w.Write([]byte(`{"d":{"results":[{"ParameterKey":"DB_Password","ParameterValue":"param-secret"},{"ParameterKey":"Host","ParameterValue":"db.example.com"}]}}`))
+	}))
+	defer svr.Close()
+
+	traceFile := filepath.Join(t.TempDir(), "trace.har")
+	StartTrace(traceFile, "1.0.0")
+	host, port := GetHostPort(svr.URL)
+	exe := New("", "", "", "", "user", "basic-password", host, "http", port, false)
+
+	body := `{"ParameterValue":"new-secret","DataType":"xsd:string"}`
+	resp, err := exe.ExecRequestWithCookies(http.MethodPut, "/api/v1/IntegrationDesigntimeArtifacts(Id='Flow',Version='active')/$links/Configurations('API_Token')",
+		strings.NewReader(body), map[string]string{"Content-Type": "application/json"}, nil)
+	if err != nil {
+		t.Fatalf("request failed: %v", err)
+	}
+	respBody, _ := io.ReadAll(resp.Body)
+	if !strings.Contains(string(respBody), "param-secret") {
+		t.Fatalf("response body not readable after tracing, got %s", respBody)
+	}
+	if err := StopTrace(); err != nil {
+		t.Fatalf("StopTrace failed: %v", err)
+	}
+
+	content, err := os.ReadFile(traceFile)
+	if err != nil {
+		t.Fatalf("trace file not written: %v", err)
+	}
+	for _, secret := range []string{"basic-password", "dXNlcjpiYXNpYy1wYXNzd29yZA==", "csrf-secret-token", "param-secret", "new-secret"} {
+		if strings.Contains(string(content), secret) {
+			t.Errorf("trace file contains secret %q", secret)
+		}
+	}
+
+	var har harLog
+	if err := json.Unmarshal(content, &har); err != nil {
+		t.Fatalf("trace file is not valid JSON: %v", err)
+	}
+	if len(har.Log.Entries) != 1 {
+		t.Fatalf("expected 1 entry, got %d", len(har.Log.Entries))
+	}
+	entry := har.Log.Entries[0]
+	if entry.Request.Method != http.MethodPut || entry.Response.Status != http.StatusOK {
+		t.Errorf("unexpected request %s or status %d", entry.Request.Method, entry.Response.Status)
+	}
+	if !strings.Contains(entry.Response.Content.Text, "db.example.com") {
+		t.Errorf("expected values of other parameters to be kept, got %s", entry.Response.Content.Text)
+	}
+
+	// Without an active trace nothing is recorded
+	if err := StopTrace(); err != nil {
+		t.Errorf("StopTrace without trace failed: %v", err)
+	}
+}
+
+func TestTraceBody(t *testing.T) {
+	text, comment := traceBody([]byte("PK\x03\x04"), "application/zip", "/api/v1/")
+	if text != "" || comment != "binary content not recorded" {
+		t.Errorf("expected binary content to be skipped, got %q, %q", text, comment)
+	}
+
+	batch := "--batch\r\nContent-Type: application/json\r\n\r\n{\"Value\":\"x\",\"clientSecret\":\"batch-secret\"}\r\n--batch--"
+	text, _ = traceBody([]byte(batch), "multipart/mixed; boundary=batch", "/api/v1/$batch")
+	if strings.Contains(text, "batch-secret") || !strings.Contains(text, `"clientSecret":"[REDACTED]"`) {
+		t.Errorf("expected secret in batch body to be redacted, got %s", text)
+	}
+
+	// Partner directory values are keyed by the Id of the parameter, in the body or the URL
+	text, _ = traceBody([]byte(`{"d":{"results":[{"Pid":"SAP","Id":"Api_Password","Value":"pd-secret"},{"Pid":"SAP","Id":"Endpoint","Value":"https://example.com"}]}}`),
+		"application/json", "/api/v1/StringParameters")
+	if strings.Contains(text, "pd-secret") || !strings.Contains(text, "https://example.com") {
+		t.Errorf("expected only the value of the sensitive partner directory parameter to be redacted, got %s", text)
+	}
+	text, _ = traceBody([]byte(`{"Pid":"SAP","Id":"ClientSecret","Value":"pd-post-secret"}`), "application/json", "/api/v1/StringParameters")
+	if strings.Contains(text, "pd-post-secret") {
+		t.Errorf("expected value of created partner directory parameter to be redacted, got %s", text)
+	}
+	text, _ = traceBody([]byte(`{"Value":"pd-put-secret"}`), "application/json", "/api/v1/StringParameters(Pid='SAP',Id='Token')")
+	if strings.Contains(text, "pd-put-secret") {
+		t.Errorf("expected value of updated partner directory parameter to be redacted, got %s", text)
+	}
+
+	pdBatch := "--batch\r\nContent-Type: multipart/mixed; boundary=changeset\r\n\r\n" +
+		"--changeset\r\nContent-Type: application/http\r\n\r\n" +
+		"PUT /api/v1/StringParameters(Pid='SAP',Id='Db_Password') HTTP/1.1\r\nContent-Type: application/json\r\n\r\n" +
+		"{\"Value\":\"batch-put-secret\"}\r\n" +
+		"--changeset\r\nContent-Type: application/http\r\n\r\n" +
+		"PUT /api/v1/StringParameters(Pid='SAP',Id='Endpoint') HTTP/1.1\r\nContent-Type: application/json\r\n\r\n" +
+		"{\"Value\":\"https://example.com\"}\r\n" +
+		"--changeset\r\nContent-Type: application/http\r\n\r\n" +
+		"POST /api/v1/StringParameters HTTP/1.1\r\nContent-Type: application/json\r\n\r\n" +
+		"{\"Id\":\"PrivateKey\",\"Pid\":\"SAP\",\"Value\":\"batch-post-secret\"}\r\n" +
+		"--changeset--\r\n--batch--"
+	text, _ = traceBody([]byte(pdBatch), "multipart/mixed; boundary=batch", "/api/v1/$batch")
+	if strings.Contains(text, "batch-put-secret") || strings.Contains(text, "batch-post-secret") || !strings.Contains(text, "https://example.com") {
+		t.Errorf("expected sensitive partner directory values in batch body to be redacted, got %s", text)
+	}
+	if !strings.Contains(text, "\r\n--changeset--\r\n") {
+		t.Errorf("expected line endings of batch body to be kept, got %q", text)
+	}
+
+	text, _ = traceBody([]byte(`{"Name":"Flow","ArtifactContent":"UEsDBA=="}`), "application/json", "/api/v1/IntegrationDesigntimeArtifacts")
+	if strings.Contains(text, "UEsDBA==") {
+		t.Errorf("expected artifact content to be omitted, got %s", text)
+	}
+
+	// Large bodies are redacted before they are truncated
+	content := strings.Repeat("UEsDBA==", maxTracedBodySize/4)
+	text, comment = traceBody([]byte(`{"Name":"Flow","ArtifactContent":"`+content+`"}`), "application/json", "/api/v1/IntegrationDesigntimeArtifacts")
+	if strings.Contains(text, "UEsDBA==") || comment != "" {
+		t.Errorf("expected artifact content of large body to be omitted, got %d bytes, %q", len(text), comment)
+	}
+	padding := strings.Repeat("x", maxTracedBodySize)
+	text, comment = traceBody([]byte(`{"ClientSecret":"large-secret","Description":"`+padding+`"}`), "application/json", "/api/v1/")
+	if strings.Contains(text, "large-secret") || len(text) != maxTracedBodySize || comment == "" {
+		t.Errorf("expected large body to be redacted and truncated, got %d bytes, %q", len(text), comment)
+	}
+}
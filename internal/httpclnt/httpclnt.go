@@ -8,6 +8,7 @@ import (
 	"time"
 
 	"github.com/rs/zerolog/log"
+	"golang.org/x/oauth2"
 	"golang.org/x/oauth2/clientcredentials"
 )
 
@@ -20,6 +21,7 @@ type HTTPExecuter struct {
 	httpClient    *http.Client
 	AuthType      string
 	showLogs      bool
+	ctx           context.Context
 }
 
 // New returns an initialised HTTPExecuter instance.
@@ -46,14 +48,15 @@ func New(oauthHost string, oauthPath string, clientId string, clientSecret strin
 			TokenURL:     tokenURL,
 		}
 
-		ctx := context.Background()
+		// The token is requested through the same transport as the calls to the tenant
+		ctx := context.WithValue(context.Background(), oauth2.HTTPClient, &http.Client{Transport: transport()})
 		e.httpClient = conf.Client(ctx)
 		e.AuthType = "OAUTH"
 	} else {
 		if showLogs {
 			log.Debug().Msg("Initialising HTTP client with Basic Authentication")
 		}
-		e.httpClient = &http.Client{Timeout: 30 * time.Second}
+		e.httpClient = &http.Client{Timeout: 30 * time.Second, Transport: transport()}
 		e.basicUserId = userId
 		e.basicPassword = password
 		e.AuthType = "BASIC"
@@ -61,6 +64,14 @@ func New(oauthHost string, oauthPath string, clientId string, clientSecret strin
 	return e
 }
 
+// WithContext returns a shallow copy of the executer whose requests are bound to ctx,
+// so that in-flight requests are aborted when ctx is cancelled.
+func (e *HTTPExecuter) WithContext(ctx context.Context) *HTTPExecuter {
+	e2 := *e
+	e2.ctx = ctx
+	return &e2
+}
+
 func (e *HTTPExecuter) ExecRequestWithCookies(method string, path string, body io.Reader, headers map[string]string, cookies []*http.Cookie) (resp *http.Response, err error) {
 
 	url := fmt.Sprintf("%v://%v:%d%v", e.scheme, e.host, e.port, path)
@@ -68,8 +79,13 @@ func (e *HTTPExecuter) ExecRequestWithCookies(method string, path string, body i
 		log.Debug().Msgf("Executing HTTP request: %v %v", method, url)
 	}
 
+	ctx := e.ctx
+	if ctx == nil {
+		ctx = context.Background()
+	}
+
 	// Create new HTTP request
-	req, err := http.NewRequest(method, url, body)
+	req, err := http.NewRequestWithContext(ctx, method, url, body)
 	if err != nil {
 		return
 	}
@@ -91,7 +107,17 @@ func (e *HTTPExecuter) ExecRequestWithCookies(method string, path string, body i
 		}
 	}
 
+	// Wait for the rate limit of --max-rps
+	if limiter := activeLimiter.Load(); limiter != nil {
+		if err = limiter.wait(ctx); err != nil {
+			return
+		}
+	}
+
 	// Execute HTTP request
+	if trace := activeTrace.Load(); trace != nil {
+		return trace.do(e.httpClient, req)
+	}
 	return e.httpClient.Do(req)
 }
 
@@ -1,12 +1,15 @@
 package httpclnt
 
 import (
+	"context"
 	"encoding/base64"
+	"errors"
 	"fmt"
 	"net/http"
 	"net/http/httptest"
 	"os"
 	"testing"
+	"time"
 )
 
 func TestMockOauth(t *testing.T) {
@@ -185,3 +188,20 @@ func TestBasicAuth(t *testing.T) {
 		t.Fatalf("HTTP call failed with response code - %v", resp.StatusCode)
 	}
 }
+
+func TestWithContext_AbortsRequest(t *testing.T) {
+	svr := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		<-r.Context().Done()
+	}))
+	defer svr.Close()
+
+	host, port := GetHostPort(svr.URL)
+	ctx, cancel := context.WithTimeout(context.Background(), 50*time.Millisecond)
+	defer cancel()
+	exe := New("", "", "", "", "user", "password", host, "http", port, false).WithContext(ctx)
+
+	_, err := exe.ExecGetRequest("/slow", nil)
+	if !errors.Is(err, context.DeadlineExceeded) {
+		t.Fatalf("expected request to be aborted by the context, got %v", err)
+	}
+}
@@ -0,0 +1,69 @@
+package httpclnt
+
+import (
+	"context"
+	"fmt"
+	"sync"
+	"sync/atomic"
+	"time"
+)
+
+// activeLimiter limits the requests of all HTTPExecuters, set by SetRateLimit. When it is nil,
+// requests are not limited.
+var activeLimiter atomic.Pointer[rateLimiter]
+
+// rateLimiter is a token bucket shared by all goroutines. Tokens are reserved in order, so that
+// waiting requests are executed one after the other at the configured rate.
+type rateLimiter struct {
+	mu     sync.Mutex
+	rate   float64 // tokens per second
+	burst  float64
+	tokens float64
+	last   time.Time
+}
+
+// SetRateLimit limits the requests to the tenant of all HTTPExecuters to maxRPS requests per
+// second, spread evenly. 0 removes the limit.
+func SetRateLimit(maxRPS float64) error {
+	if maxRPS < 0 {
+		return fmt.Errorf("invalid value for --max-rps = %v: must not be negative", maxRPS)
+	}
+	if maxRPS == 0 {
+		activeLimiter.Store(nil)
+		return nil
+	}
+	activeLimiter.Store(newRateLimiter(maxRPS, 1))
+	return nil
+}
+
+func newRateLimiter(rate float64, burst float64) *rateLimiter {
+	return &rateLimiter{rate: rate, burst: burst, tokens: burst, last: time.Now()}
+}
+
+// wait blocks until a token is available or ctx is done
+func (l *rateLimiter) wait(ctx context.Context) error {
+	l.mu.Lock()
+	now := time.Now()
+	l.tokens = min(l.burst, l.tokens+now.Sub(l.last).Seconds()*l.rate)
+	l.last = now
+	// The token is reserved, a negative balance is the wait of the queued requests
+	l.tokens--
+	delay := time.Duration(-l.tokens / l.rate * float64(time.Second))
+	l.mu.Unlock()
+
+	if delay <= 0 {
+		return nil
+	}
+	timer := time.NewTimer(delay)
+	defer timer.Stop()
+	select {
+	case <-timer.C:
+		return nil
+	case <-ctx.Done():
+		// Return the reserved token to the requests waiting behind this one
+		l.mu.Lock()
+		l.tokens++
+		l.mu.Unlock()
+		return ctx.Err()
+	}
+}
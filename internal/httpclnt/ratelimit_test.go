@@ -0,0 +1,65 @@
+package httpclnt
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"sync"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestSetRateLimit(t *testing.T) {
+	defer SetRateLimit(0)
+
+	var requests atomic.Int32
+	svr := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		requests.Add(1)
+	}))
+	defer svr.Close()
+	host, port := GetHostPort(svr.URL)
+
+	require.NoError(t, SetRateLimit(20))
+
+	// The limit is shared by all executers and goroutines: the first request is executed
+	// immediately, the others every 50ms
+	start := time.Now()
+	var wg sync.WaitGroup
+	for i := 0; i < 5; i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			exe := New("", "", "", "", "user", "password", host, "http", port, false)
+			resp, err := exe.ExecGetRequest("/api/v1/IntegrationPackages", nil)
+			if assert.NoError(t, err) {
+				resp.Body.Close()
+			}
+		}()
+	}
+	wg.Wait()
+	assert.Equal(t, int32(5), requests.Load())
+	assert.GreaterOrEqual(t, time.Since(start), 190*time.Millisecond)
+
+	assert.ErrorContains(t, SetRateLimit(-1), "must not be negative")
+}
+
+func TestRateLimiter_Cancel(t *testing.T) {
+	limiter := newRateLimiter(1, 1)
+	require.NoError(t, limiter.wait(context.Background()))
+
+	// The next token is only available after a second
+	ctx, cancel := context.WithTimeout(context.Background(), 20*time.Millisecond)
+	defer cancel()
+	start := time.Now()
+	assert.ErrorIs(t, limiter.wait(ctx), context.DeadlineExceeded)
+	assert.Less(t, time.Since(start), 500*time.Millisecond)
+
+	// The token of the cancelled request is returned
+	limiter.mu.Lock()
+	assert.InDelta(t, 0, limiter.tokens, 0.1)
+	limiter.mu.Unlock()
+}
@@ -0,0 +1,74 @@
+package httpclnt
+
+import (
+	"crypto/tls"
+	"crypto/x509"
+	"fmt"
+	"net/http"
+	"net/url"
+	"os"
+	"sync/atomic"
+)
+
+// activeTransport is the transport of the clients to the tenant configured by ConfigureTransport.
+// When it is nil, http.DefaultTransport is used.
+var activeTransport atomic.Pointer[http.Transport]
+
+// TransportOptions configures how the tenant is reached, e.g. behind a corporate proxy
+type TransportOptions struct {
+	Proxy      string // proxy URL, HTTPS_PROXY/HTTP_PROXY/NO_PROXY are used when empty
+	CACertFile string // PEM file with CA certificates trusted in addition to the system ones
+	Insecure   bool   // skip the verification of TLS certificates
+}
+
+// ConfigureTransport sets the transport used by all HTTPExecuters created afterwards, including
+// the requests of the OAuth token. Without options, the default transport is used.
+func ConfigureTransport(opts TransportOptions) error {
+	if opts.Proxy == "" && opts.CACertFile == "" && !opts.Insecure {
+		activeTransport.Store(nil)
+		return nil
+	}
+
+	transport := http.DefaultTransport.(*http.Transport).Clone()
+	if opts.Proxy != "" {
+		proxyURL, err := url.Parse(opts.Proxy)
+		if err != nil || proxyURL.Host == "" {
+			return fmt.Errorf("invalid proxy URL %q: expected e.g. http://proxy.example.com:8080", opts.Proxy)
+		}
+		switch proxyURL.Scheme {
+		case "http", "https", "socks5":
+		default:
+			return fmt.Errorf("invalid proxy URL %q: scheme must be http, https or socks5", opts.Proxy)
+		}
+		transport.Proxy = http.ProxyURL(proxyURL)
+	}
+
+	tlsConfig := &tls.Config{MinVersion: tls.VersionTLS12}
+	if opts.CACertFile != "" {
+		pem, err := os.ReadFile(opts.CACertFile)
+		if err != nil {
+			return fmt.Errorf("failed to read CA certificate file: %w", err)
+		}
+		pool, err := x509.SystemCertPool()
+		if err != nil {
+			pool = x509.NewCertPool()
+		}
+		if !pool.AppendCertsFromPEM(pem) {
+			return fmt.Errorf("no PEM certificates found in CA certificate file %s", opts.CACertFile)
+		}
+		tlsConfig.RootCAs = pool
+	}
+	tlsConfig.InsecureSkipVerify = opts.Insecure
+	transport.TLSClientConfig = tlsConfig
+
+	activeTransport.Store(transport)
+	return nil
+}
+
+// transport returns the configured transport of the clients to the tenant
+func transport() http.RoundTripper {
+	if t := activeTransport.Load(); t != nil {
+		return t
+	}
+	return http.DefaultTransport
+}
@@ -0,0 +1,76 @@
+package httpclnt
+
+import (
+	"encoding/pem"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"strconv"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestConfigureTransport_TLS(t *testing.T) {
+	defer ConfigureTransport(TransportOptions{})
+
+	svr := httptest.NewTLSServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Write([]byte(`{"d":{"results":[]}}`))
+	}))
+	defer svr.Close()
+	hostPort := strings.Split(strings.TrimPrefix(svr.URL, "https://"), ":")
+	port, _ := strconv.Atoi(hostPort[1])
+	get := func() error {
+		exe := New("", "", "", "", "user", "password", hostPort[0], "https", port, false)
+		resp, err := exe.ExecGetRequest("/api/v1/IntegrationPackages", nil)
+		if err == nil {
+			resp.Body.Close()
+		}
+		return err
+	}
+
+	// The self-signed certificate of the server is not trusted by default
+	require.NoError(t, ConfigureTransport(TransportOptions{}))
+	assert.ErrorContains(t, get(), "certificate")
+
+	caFile := filepath.Join(t.TempDir(), "ca.pem")
+	require.NoError(t, os.WriteFile(caFile, pem.EncodeToMemory(&pem.Block{Type: "CERTIFICATE", Bytes: svr.Certificate().Raw}), 0644))
+	require.NoError(t, ConfigureTransport(TransportOptions{CACertFile: caFile}))
+	assert.NoError(t, get())
+
+	require.NoError(t, ConfigureTransport(TransportOptions{Insecure: true}))
+	assert.NoError(t, get())
+}
+
+func TestConfigureTransport_Proxy(t *testing.T) {
+	defer ConfigureTransport(TransportOptions{})
+
+	var proxiedURL string
+	proxy := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		proxiedURL = r.URL.String()
+		w.Write([]byte(`{}`))
+	}))
+	defer proxy.Close()
+
+	require.NoError(t, ConfigureTransport(TransportOptions{Proxy: proxy.URL}))
+	exe := New("", "", "", "", "user", "password", "tenant.example.com", "http", 80, false)
+	resp, err := exe.ExecGetRequest("/api/v1/IntegrationPackages", nil)
+	require.NoError(t, err)
+	resp.Body.Close()
+	assert.Equal(t, "http://tenant.example.com:80/api/v1/IntegrationPackages", proxiedURL)
+}
+
+func TestConfigureTransport_Invalid(t *testing.T) {
+	defer ConfigureTransport(TransportOptions{})
+
+	assert.ErrorContains(t, ConfigureTransport(TransportOptions{Proxy: "proxy.example.com:8080"}), "invalid proxy URL")
+	assert.ErrorContains(t, ConfigureTransport(TransportOptions{Proxy: "ftp://proxy.example.com"}), "scheme must be http, https or socks5")
+	assert.ErrorContains(t, ConfigureTransport(TransportOptions{CACertFile: filepath.Join(t.TempDir(), "missing.pem")}), "failed to read CA certificate file")
+
+	caFile := filepath.Join(t.TempDir(), "ca.pem")
+	require.NoError(t, os.WriteFile(caFile, []byte("not a certificate"), 0644))
+	assert.ErrorContains(t, ConfigureTransport(TransportOptions{CACertFile: caFile}), "no PEM certificates found")
+}
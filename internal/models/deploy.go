@@ -2,38 +2,43 @@ package models
 
 // OrchestratorConfig represents orchestrator-specific settings
 type OrchestratorConfig struct {
-	PackagesDir      string `yaml:"packagesDir"`
-	DeployConfig     string `yaml:"deployConfig"`
-	DeploymentPrefix string `yaml:"deploymentPrefix,omitempty"`
-	PackageFilter    string `yaml:"packageFilter,omitempty"`
-	ArtifactFilter   string `yaml:"artifactFilter,omitempty"`
-	ConfigPattern    string `yaml:"configPattern,omitempty"`
-	MergeConfigs     bool   `yaml:"mergeConfigs,omitempty"`
-	KeepTemp         bool   `yaml:"keepTemp,omitempty"`
-	Mode             string `yaml:"mode,omitempty"` // "update-and-deploy", "update-only", "deploy-only"
+	PackagesDir      string `yaml:"packagesDir" json:"packagesDir"`
+	DeployConfig     string `yaml:"deployConfig" json:"deployConfig"`
+	DeploymentPrefix string `yaml:"deploymentPrefix,omitempty" json:"deploymentPrefix,omitempty"`
+	PackageFilter    string `yaml:"packageFilter,omitempty" json:"packageFilter,omitempty"`
+	ArtifactFilter   string `yaml:"artifactFilter,omitempty" json:"artifactFilter,omitempty"`
+	ConfigPattern    string `yaml:"configPattern,omitempty" json:"configPattern,omitempty"`
+	MergeConfigs     bool   `yaml:"mergeConfigs,omitempty" json:"mergeConfigs,omitempty"`
+	KeepTemp         bool   `yaml:"keepTemp,omitempty" json:"keepTemp,omitempty"`
+	Mode             string `yaml:"mode,omitempty" json:"mode,omitempty"` // "update-and-deploy", "update-only", "deploy-only"
 	// Deployment settings
-	DeployRetries       int `yaml:"deployRetries,omitempty"`
-	DeployDelaySeconds  int `yaml:"deployDelaySeconds,omitempty"`
-	ParallelDeployments int `yaml:"parallelDeployments,omitempty"`
+	DeployRetries       int `yaml:"deployRetries,omitempty" json:"deployRetries,omitempty"`
+	DeployDelaySeconds  int `yaml:"deployDelaySeconds,omitempty" json:"deployDelaySeconds,omitempty"`
+	ParallelDeployments int `yaml:"parallelDeployments,omitempty" json:"parallelDeployments,omitempty"`
 }
 
 // DeployConfig represents the complete deployment configuration
 type DeployConfig struct {
-	DeploymentPrefix string              `yaml:"deploymentPrefix"`
-	Packages         []Package           `yaml:"packages"`
-	Orchestrator     *OrchestratorConfig `yaml:"orchestrator,omitempty"`
+	// Include lists config files whose packages are merged into this config, see deploy.ConfigLoader
+	Include          []string            `yaml:"include,omitempty" json:"include,omitempty"`
+	DeploymentPrefix string              `yaml:"deploymentPrefix" json:"deploymentPrefix"`
+	Packages         []Package           `yaml:"packages" json:"packages"`
+	Orchestrator     *OrchestratorConfig `yaml:"orchestrator,omitempty" json:"orchestrator,omitempty"`
 }
 
 // Package represents a SAP CPI package
 type Package struct {
-	ID          string     `yaml:"integrationSuiteId"`
-	PackageDir  string     `yaml:"packageDir,omitempty"`
-	DisplayName string     `yaml:"displayName,omitempty"`
-	Description string     `yaml:"description,omitempty"`
-	ShortText   string     `yaml:"short_text,omitempty"`
-	Sync        bool       `yaml:"sync"`
-	Deploy      bool       `yaml:"deploy"`
-	Artifacts   []Artifact `yaml:"artifacts"`
+	ID          string     `yaml:"integrationSuiteId" json:"integrationSuiteId"`
+	PackageDir  string     `yaml:"packageDir,omitempty" json:"packageDir,omitempty"`
+	DisplayName string     `yaml:"displayName,omitempty" json:"displayName,omitempty"`
+	Description string     `yaml:"description,omitempty" json:"description,omitempty"`
+	ShortText   string     `yaml:"short_text,omitempty" json:"short_text,omitempty"`
+	Version     string     `yaml:"version,omitempty" json:"version,omitempty"`
+	Vendor      string     `yaml:"vendor,omitempty" json:"vendor,omitempty"`
+	Sync        bool       `yaml:"sync" json:"sync"`
+	Deploy      bool       `yaml:"deploy" json:"deploy"`
+	DeployOrder int        `yaml:"deployOrder,omitempty" json:"deployOrder,omitempty"` // packages with a lower order deploy first
+	Artifacts   []Artifact `yaml:"artifacts" json:"artifacts"`
 }
 
 func (p *Package) UnmarshalYAML(unmarshal func(interface{}) error) error {
@@ -54,13 +59,18 @@ func (p *Package) UnmarshalYAML(unmarshal func(interface{}) error) error {
 
 // Artifact represents a SAP CPI artifact (Integration Flow, Script Collection, etc.)
 type Artifact struct {
-	Id              string                 `yaml:"artifactId"`
-	ArtifactDir     string                 `yaml:"artifactDir"`
-	DisplayName     string                 `yaml:"displayName"`
-	Type            string                 `yaml:"type"`
-	Sync            bool                   `yaml:"sync"`
-	Deploy          bool                   `yaml:"deploy"`
-	ConfigOverrides map[string]interface{} `yaml:"configOverrides"`
+	Id                  string                 `yaml:"artifactId" json:"artifactId"`
+	ArtifactDir         string                 `yaml:"artifactDir" json:"artifactDir"`
+	DisplayName         string                 `yaml:"displayName" json:"displayName"`
+	Type                string                 `yaml:"type" json:"type"`
+	Sync                bool                   `yaml:"sync" json:"sync"`
+	Deploy              bool                   `yaml:"deploy" json:"deploy"`
+	ConfigOverrides     map[string]interface{} `yaml:"configOverrides" json:"configOverrides"`
+	ConfigOverridesFile string                 `yaml:"configOverridesFile,omitempty" json:"configOverridesFile,omitempty"` // YAML overrides file relative to the config file, inline overrides take precedence
+	ValueMappingFile    string                 `yaml:"valueMappingFile,omitempty" json:"valueMappingFile,omitempty"`       // YAML value mapping replacements relative to the config file, ValueMapping artifacts only
+	Order               int                    `yaml:"order,omitempty" json:"order,omitempty"`                             // update sequence within the package
+	DeployOrder         int                    `yaml:"deployOrder,omitempty" json:"deployOrder,omitempty"`                 // artifacts with a lower order deploy first within the package
+	SkipPrefix          bool                   `yaml:"skipPrefix,omitempty" json:"skipPrefix,omitempty"`                   // keep the artifact ID without the deployment prefix, e.g. for shared artifacts
 }
 
 func (a *Artifact) UnmarshalYAML(unmarshal func(interface{}) error) error {
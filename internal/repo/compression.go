@@ -0,0 +1,106 @@
+package repo
+
+import (
+	"bytes"
+	"compress/gzip"
+	"compress/zlib"
+	"encoding/base64"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"strings"
+
+	"github.com/engswee/flashpipe/internal/api"
+)
+
+const (
+	// decompressedKeyPrefix marks metadata entries of binary files that are stored decompressed,
+	// holding the SHA-256 of the decompressed content as written by the snapshot
+	decompressedKeyPrefix = "decompressed:"
+	// compressedDirName holds the original compressed bytes of decompressed binary files, so
+	// that unchanged files are deployed with exactly the value of the tenant
+	compressedDirName = ".compressed"
+)
+
+// isCompressedContentType reports whether the content type is one that --decompress handles
+func isCompressedContentType(contentType string) bool {
+	ext, _ := parseContentType(contentType)
+	ext = strings.ToLower(ext)
+	return ext == "gz" || ext == "zlib"
+}
+
+// isCompressedData reports whether data starts with the header of the compression format
+func isCompressedData(contentType string, data []byte) bool {
+	ext, _ := parseContentType(contentType)
+	switch strings.ToLower(ext) {
+	case "gz":
+		return len(data) >= 2 && data[0] == 0x1f && data[1] == 0x8b
+	case "zlib":
+		return len(data) >= 2 && data[0]&0x0f == 8 && (uint16(data[0])<<8|uint16(data[1]))%31 == 0
+	}
+	return false
+}
+
+func decompressData(contentType string, data []byte) ([]byte, error) {
+	var reader io.ReadCloser
+	var err error
+	ext, _ := parseContentType(contentType)
+	if strings.ToLower(ext) == "gz" {
+		reader, err = gzip.NewReader(bytes.NewReader(data))
+	} else {
+		reader, err = zlib.NewReader(bytes.NewReader(data))
+	}
+	if err != nil {
+		return nil, err
+	}
+	defer reader.Close()
+	return io.ReadAll(reader)
+}
+
+func compressData(contentType string, data []byte) ([]byte, error) {
+	var buf bytes.Buffer
+	var writer io.WriteCloser
+	ext, _ := parseContentType(contentType)
+	if strings.ToLower(ext) == "gz" {
+		writer = gzip.NewWriter(&buf)
+	} else {
+		writer = zlib.NewWriter(&buf)
+	}
+	if _, err := writer.Write(data); err != nil {
+		return nil, err
+	}
+	if err := writer.Close(); err != nil {
+		return nil, err
+	}
+	return buf.Bytes(), nil
+}
+
+// decompressValue returns the original compressed bytes and the decompressed bytes of a parameter
+func decompressValue(param api.BinaryParameter) ([]byte, []byte, error) {
+	original, err := base64.StdEncoding.DecodeString(param.Value)
+	if err != nil {
+		return nil, nil, fmt.Errorf("failed to decode base64: %w", err)
+	}
+	decompressed, err := decompressData(param.ContentType, original)
+	if err != nil {
+		return nil, nil, err
+	}
+	return original, decompressed, nil
+}
+
+// compressedValue returns the bytes to deploy for a file stored decompressed. When the file is
+// unchanged since the snapshot, the original compressed bytes are returned, otherwise the file
+// is compressed again.
+func compressedValue(binaryDir, filename, contentType string, data []byte, snapshotChecksum string) ([]byte, error) {
+	if api.BinaryChecksum(data) == snapshotChecksum {
+		original, err := os.ReadFile(filepath.Join(binaryDir, compressedDirName, filename))
+		if err == nil {
+			return original, nil
+		}
+		if !os.IsNotExist(err) {
+			return nil, fmt.Errorf("failed to read compressed original of %s: %w", filename, err)
+		}
+	}
+	return compressData(contentType, data)
+}
@@ -10,6 +10,7 @@ import (
 	"strings"
 
 	"github.com/engswee/flashpipe/internal/api"
+	"github.com/engswee/flashpipe/internal/str"
 	"github.com/rs/zerolog/log"
 )
 
@@ -17,7 +18,13 @@ const (
 	stringPropertiesFile = "String.properties"
 	binaryDirName        = "Binary"
 	metadataFileName     = "_metadata.json"
+	auditFileName        = "_audit.json"
 	defaultBinaryExt     = "bin"
+	// idKeyPrefix marks metadata entries holding the parameter ID of a binary file whose name is
+	// not the ID with an added extension, e.g. cert.pem or an escaped ID like a%2Fb.xml
+	idKeyPrefix = "id:"
+	// escapedIDChars are percent-encoded in the file names of binary parameters
+	escapedIDChars = `/\%:*?"<>|`
 )
 
 // supportedContentTypes defines the valid content types that SAP CPI uses
@@ -37,6 +44,13 @@ var supportedContentTypes = map[string]bool{
 // PartnerDirectory handles Partner Directory file operations
 type PartnerDirectory struct {
 	ResourcesPath string
+	// IncludeAudit writes and reads the read-only audit fields (CreatedBy, CreatedTime, etc.) in a sidecar file per PID
+	IncludeAudit bool
+	// Decompress writes gz and zlib binary parameters decompressed, so that they can be reviewed and
+	// diffed, and compresses gz and zlib files that are not compressed when they are read
+	Decompress bool
+	// extraContentTypes extends supportedContentTypes for this repository
+	extraContentTypes map[string]bool
 }
 
 // NewPartnerDirectory creates a new Partner Directory repository
@@ -46,6 +60,28 @@ func NewPartnerDirectory(resourcesPath string) *PartnerDirectory {
 	}
 }
 
+// AddContentTypes registers additional content types (e.g. pem, p12) that are
+// merged into the supported content types used for file extensions. They only
+// control the file names, IsAcceptedContentType is not extended.
+func (pd *PartnerDirectory) AddContentTypes(contentTypes []string) {
+	if pd.extraContentTypes == nil {
+		pd.extraContentTypes = make(map[string]bool)
+	}
+	for _, contentType := range contentTypes {
+		contentType = strings.ToLower(strings.TrimPrefix(strings.TrimSpace(contentType), "."))
+		if contentType != "" {
+			pd.extraContentTypes[contentType] = true
+		}
+	}
+}
+
+// IsAcceptedContentType reports whether SAP CPI accepts the content type for binary parameters.
+// Content types registered with AddContentTypes are not included.
+func IsAcceptedContentType(contentType string) bool {
+	ext, _ := parseContentType(contentType)
+	return supportedContentTypes[strings.ToLower(ext)]
+}
+
 // GetLocalPIDs returns all PIDs that have local directories
 func (pd *PartnerDirectory) GetLocalPIDs() ([]string, error) {
 	entries, err := os.ReadDir(pd.ResourcesPath)
@@ -67,42 +103,85 @@ func (pd *PartnerDirectory) GetLocalPIDs() ([]string, error) {
 	return pids, nil
 }
 
-// WriteStringParameters writes string parameters to a properties file
-func (pd *PartnerDirectory) WriteStringParameters(pid string, params []api.StringParameter, replace bool) error {
+// WriteStringParameters writes string parameters to a properties file and returns the number of
+// parameters written. Without replace, existing values are kept and not counted.
+func (pd *PartnerDirectory) WriteStringParameters(pid string, params []api.StringParameter, replace bool) (int, error) {
 	pidDir := filepath.Join(pd.ResourcesPath, pid)
 	if err := os.MkdirAll(pidDir, 0755); err != nil {
-		return fmt.Errorf("failed to create PID directory: %w", err)
+		return 0, fmt.Errorf("failed to create PID directory: %w", err)
 	}
 
 	propertiesFile := filepath.Join(pidDir, stringPropertiesFile)
 
+	written := len(params)
 	if replace || !fileExists(propertiesFile) {
 		if err := writePropertiesFile(propertiesFile, params); err != nil {
-			return err
+			return 0, err
 		}
 		log.Debug().Msgf("Created/Updated %s for PID %s", stringPropertiesFile, pid)
 	} else {
 		addedCount, err := mergePropertiesFile(propertiesFile, params)
 		if err != nil {
-			return err
+			return 0, err
 		}
+		written = addedCount
 		log.Debug().Msgf("Merged %d new values into %s for PID %s", addedCount, stringPropertiesFile, pid)
 	}
 
+	if pd.IncludeAudit {
+		entries := make(map[string]parameterAudit)
+		for _, param := range params {
+			entries[param.ID] = parameterAudit{
+				CreatedBy:        param.CreatedBy,
+				LastModifiedBy:   param.LastModifiedBy,
+				CreatedTime:      param.CreatedTime,
+				LastModifiedTime: param.LastModifiedTime,
+			}
+		}
+		if err := updateAuditFile(pidDir, func(audit *auditFile) {
+			if replace {
+				audit.String = nil
+			}
+			audit.String = mergeAuditEntries(audit.String, entries, replace)
+		}); err != nil {
+			return 0, fmt.Errorf("failed to update audit file: %w", err)
+		}
+	}
+
+	return written, nil
+}
+
+// ResetBinaryAudit removes the audit entries of all binary parameters of a PID. Binary parameters
+// are written one by one, so a full snapshot resets the entries first to drop those of parameters
+// that no longer exist, while an incremental snapshot keeps them.
+func (pd *PartnerDirectory) ResetBinaryAudit(pid string) error {
+	pidDir := filepath.Join(pd.ResourcesPath, pid)
+	if !pd.IncludeAudit || !fileExists(filepath.Join(pidDir, auditFileName)) {
+		return nil
+	}
+	if err := updateAuditFile(pidDir, func(audit *auditFile) {
+		audit.Binary = nil
+	}); err != nil {
+		return fmt.Errorf("failed to update audit file: %w", err)
+	}
 	return nil
 }
 
-// WriteBinaryParameters writes binary parameters to files
-func (pd *PartnerDirectory) WriteBinaryParameters(pid string, params []api.BinaryParameter, replace bool) error {
+// WriteBinaryParameters writes binary parameters to files and returns the number of parameters
+// written. Without replace, existing files are kept and not counted. Audit entries are merged
+// with the existing ones, see ResetBinaryAudit.
+func (pd *PartnerDirectory) WriteBinaryParameters(pid string, params []api.BinaryParameter, replace bool) (int, error) {
 	pidDir := filepath.Join(pd.ResourcesPath, pid)
 	binaryDir := filepath.Join(pidDir, binaryDirName)
 
 	if err := os.MkdirAll(binaryDir, 0755); err != nil {
-		return fmt.Errorf("failed to create binary directory: %w", err)
+		return 0, fmt.Errorf("failed to create binary directory: %w", err)
 	}
 
+	written := 0
 	for _, param := range params {
-		filePath := filepath.Join(binaryDir, param.ID)
+		ext := pd.getFileExtension(param.ContentType)
+		filePath := filepath.Join(binaryDir, binaryFileName(param.ID, ext))
 
 		// Check if file exists
 		exists := fileExists(filePath)
@@ -113,16 +192,60 @@ func (pd *PartnerDirectory) WriteBinaryParameters(pid string, params []api.Binar
 			continue
 		}
 
-		if err := saveBinaryParameterToFile(binaryDir, param); err != nil {
-			return fmt.Errorf("failed to save binary parameter %s: %w", param.ID, err)
+		// The original compressed bytes are kept next to the decompressed file, so that
+		// deploying an unchanged file restores exactly the value of the tenant
+		fileParam := param
+		decompressedChecksum := ""
+		compressedPath := filepath.Join(binaryDir, compressedDirName, binaryFileName(param.ID, ext))
+		if pd.Decompress && isCompressedContentType(param.ContentType) {
+			original, decompressed, err := decompressValue(param)
+			if err != nil {
+				log.Warn().Msgf("Keeping binary parameter %s/%s compressed, failed to decompress: %v", pid, param.ID, err)
+			} else {
+				if err := os.MkdirAll(filepath.Dir(compressedPath), 0755); err != nil {
+					return 0, fmt.Errorf("failed to create directory for compressed originals: %w", err)
+				}
+				if err := os.WriteFile(compressedPath, original, 0644); err != nil {
+					return 0, fmt.Errorf("failed to save compressed original of %s: %w", param.ID, err)
+				}
+				fileParam.Value = base64.StdEncoding.EncodeToString(decompressed)
+				decompressedChecksum = api.BinaryChecksum(decompressed)
+			}
+		}
+		if decompressedChecksum == "" {
+			if err := os.Remove(compressedPath); err != nil && !os.IsNotExist(err) {
+				return 0, fmt.Errorf("failed to remove compressed original of %s: %w", param.ID, err)
+			}
+		}
+
+		if err := saveBinaryParameterToFile(binaryDir, fileParam, ext); err != nil {
+			return 0, fmt.Errorf("failed to save binary parameter %s: %w", param.ID, err)
 		}
 
-		if err := updateMetadataFile(binaryDir, param.ID, param.ContentType); err != nil {
-			return fmt.Errorf("failed to update metadata: %w", err)
+		if err := updateMetadataFile(binaryDir, param.ID, param.ContentType, ext, decompressedChecksum); err != nil {
+			return 0, fmt.Errorf("failed to update metadata: %w", err)
 		}
+		written++
 	}
 
-	return nil
+	if pd.IncludeAudit {
+		entries := make(map[string]parameterAudit)
+		for _, param := range params {
+			entries[param.ID] = parameterAudit{
+				CreatedBy:        param.CreatedBy,
+				LastModifiedBy:   param.LastModifiedBy,
+				CreatedTime:      param.CreatedTime,
+				LastModifiedTime: param.LastModifiedTime,
+			}
+		}
+		if err := updateAuditFile(pidDir, func(audit *auditFile) {
+			audit.Binary = mergeAuditEntries(audit.Binary, entries, replace)
+		}); err != nil {
+			return 0, fmt.Errorf("failed to update audit file: %w", err)
+		}
+	}
+
+	return written, nil
 }
 
 // ReadStringParameters reads string parameters from a properties file
@@ -133,7 +256,24 @@ func (pd *PartnerDirectory) ReadStringParameters(pid string) ([]api.StringParame
 		return []api.StringParameter{}, nil
 	}
 
-	return readPropertiesFile(propertiesFile, pid)
+	params, err := readPropertiesFile(propertiesFile, pid)
+	if err != nil || !pd.IncludeAudit {
+		return params, err
+	}
+
+	audit, err := readAuditFile(filepath.Join(pd.ResourcesPath, pid))
+	if err != nil {
+		return nil, err
+	}
+	for i := range params {
+		if entry, ok := audit.String[params[i].ID]; ok {
+			params[i].CreatedBy = entry.CreatedBy
+			params[i].LastModifiedBy = entry.LastModifiedBy
+			params[i].CreatedTime = entry.CreatedTime
+			params[i].LastModifiedTime = entry.LastModifiedTime
+		}
+	}
+	return params, nil
 }
 
 // ReadBinaryParameters reads binary parameters from files
@@ -173,8 +313,7 @@ func (pd *PartnerDirectory) ReadBinaryParameters(pid string) ([]api.BinaryParame
 
 		filePath := filepath.Join(binaryDir, entry.Name())
 
-		// Use filename without extension as ID
-		paramID := removeFileExtension(entry.Name())
+		paramID := binaryParameterID(entry.Name(), metadata)
 
 		// Check for duplicates (same ID, different extension)
 		if seenParams[paramID] {
@@ -189,9 +328,6 @@ func (pd *PartnerDirectory) ReadBinaryParameters(pid string) ([]api.BinaryParame
 			continue
 		}
 
-		// Encode to base64
-		encoded := base64.StdEncoding.EncodeToString(data)
-
 		// Get full content type from metadata (includes encoding if present)
 		contentType := metadata[entry.Name()]
 		if contentType == "" {
@@ -203,6 +339,23 @@ func (pd *PartnerDirectory) ReadBinaryParameters(pid string) ([]api.BinaryParame
 			contentType = ext
 		}
 
+		// Files written decompressed by the snapshot are always compressed again, as their
+		// content type on the tenant is gz or zlib
+		if snapshotChecksum, ok := metadata[decompressedKeyPrefix+entry.Name()]; ok {
+			data, err = compressedValue(binaryDir, entry.Name(), contentType, data, snapshotChecksum)
+			if err != nil {
+				return nil, fmt.Errorf("failed to compress binary parameter %s/%s: %w", pid, paramID, err)
+			}
+		} else if pd.Decompress && isCompressedContentType(contentType) && !isCompressedData(contentType, data) {
+			data, err = compressData(contentType, data)
+			if err != nil {
+				return nil, fmt.Errorf("failed to compress binary parameter %s/%s: %w", pid, paramID, err)
+			}
+		}
+
+		// Encode to base64
+		encoded := base64.StdEncoding.EncodeToString(data)
+
 		log.Debug().Msgf("Loaded binary parameter %s/%s (%s, %d bytes)", pid, paramID, contentType, len(data))
 
 		params = append(params, api.BinaryParameter{
@@ -213,20 +366,61 @@ func (pd *PartnerDirectory) ReadBinaryParameters(pid string) ([]api.BinaryParame
 		})
 	}
 
+	if pd.IncludeAudit {
+		audit, err := readAuditFile(filepath.Join(pd.ResourcesPath, pid))
+		if err != nil {
+			return nil, err
+		}
+		for i := range params {
+			if entry, ok := audit.Binary[params[i].ID]; ok {
+				params[i].CreatedBy = entry.CreatedBy
+				params[i].LastModifiedBy = entry.LastModifiedBy
+				params[i].CreatedTime = entry.CreatedTime
+				params[i].LastModifiedTime = entry.LastModifiedTime
+			}
+		}
+	}
+
 	return params, nil
 }
 
 // Helper functions
 
+// propertiesComments holds the comment lines of a properties file, so that they are kept when the
+// file is written again
+type propertiesComments struct {
+	header  []string            // comment block at the top of the file, followed by a blank line
+	byKey   map[string][]string // comment lines above a key
+	trailer []string            // comment lines after the last key
+}
+
 func writePropertiesFile(filePath string, params []api.StringParameter) error {
+	// Keep the comments of the existing file, comments of keys that are no longer written are dropped
+	comments := &propertiesComments{}
+	if fileExists(filePath) {
+		var err error
+		if _, comments, err = parsePropertiesFile(filePath, ""); err != nil {
+			return err
+		}
+	}
+
 	// Sort by ID for consistent output
 	sort.Slice(params, func(i, j int) bool {
 		return params[i].ID < params[j].ID
 	})
 
 	var content strings.Builder
+	if len(comments.header) > 0 {
+		content.WriteString(strings.Join(comments.header, "\n") + "\n\n")
+	}
 	for _, param := range params {
-		content.WriteString(fmt.Sprintf("%s=%s\n", param.ID, escapePropertyValue(param.Value)))
+		for _, comment := range comments.byKey[param.ID] {
+			content.WriteString(comment + "\n")
+		}
+		content.WriteString(fmt.Sprintf("%s=%s\n", escapePropertyKey(param.ID), escapePropertyValue(param.Value)))
+	}
+	for _, comment := range comments.trailer {
+		content.WriteString(comment + "\n")
 	}
 
 	if err := os.WriteFile(filePath, []byte(content.String()), 0644); err != nil {
@@ -237,85 +431,107 @@ func writePropertiesFile(filePath string, params []api.StringParameter) error {
 }
 
 func mergePropertiesFile(filePath string, newParams []api.StringParameter) (int, error) {
-	// Read existing properties
-	existing := make(map[string]string)
+	// Read existing properties, a later duplicate key overrides an earlier one
+	var params []api.StringParameter
+	index := make(map[string]int)
 	if fileExists(filePath) {
-		data, err := os.ReadFile(filePath)
+		existing, err := readPropertiesFile(filePath, "")
 		if err != nil {
 			return 0, fmt.Errorf("failed to read existing properties: %w", err)
 		}
-
-		lines := strings.Split(string(data), "\n")
-		for _, line := range lines {
-			line = strings.TrimSpace(line)
-			if line == "" || strings.HasPrefix(line, "#") {
+		for _, param := range existing {
+			if i, exists := index[param.ID]; exists {
+				params[i] = param
 				continue
 			}
-
-			parts := strings.SplitN(line, "=", 2)
-			if len(parts) == 2 {
-				existing[parts[0]] = parts[1]
-			}
+			index[param.ID] = len(params)
+			params = append(params, param)
 		}
 	}
 
 	// Add new parameters
 	addedCount := 0
 	for _, param := range newParams {
-		if _, exists := existing[param.ID]; !exists {
-			existing[param.ID] = escapePropertyValue(param.Value)
+		if _, exists := index[param.ID]; !exists {
+			index[param.ID] = len(params)
+			params = append(params, param)
 			addedCount++
 		}
 	}
 
 	// Write back sorted
-	keys := make([]string, 0, len(existing))
-	for k := range existing {
-		keys = append(keys, k)
-	}
-	sort.Strings(keys)
-
-	var content strings.Builder
-	for _, key := range keys {
-		content.WriteString(fmt.Sprintf("%s=%s\n", key, existing[key]))
-	}
-
-	if err := os.WriteFile(filePath, []byte(content.String()), 0644); err != nil {
-		return 0, fmt.Errorf("failed to write properties file: %w", err)
+	if err := writePropertiesFile(filePath, params); err != nil {
+		return 0, err
 	}
 
 	return addedCount, nil
 }
 
 func readPropertiesFile(filePath string, pid string) ([]api.StringParameter, error) {
+	params, _, err := parsePropertiesFile(filePath, pid)
+	return params, err
+}
+
+// parsePropertiesFile reads the parameters and the comments of a properties file. A comment block
+// belongs to the key below it, except for a block at the top of the file that is followed by a
+// blank line, which is the header of the file.
+func parsePropertiesFile(filePath string, pid string) ([]api.StringParameter, *propertiesComments, error) {
 	data, err := os.ReadFile(filePath)
 	if err != nil {
-		return nil, fmt.Errorf("failed to read properties file: %w", err)
+		return nil, nil, fmt.Errorf("failed to read properties file: %w", err)
 	}
 
 	var params []api.StringParameter
-	lines := strings.Split(string(data), "\n")
+	comments := &propertiesComments{byKey: make(map[string][]string)}
+	var pending []string
+	lines := strings.Split(str.TrimBOM(string(data)), "\n")
 
 	for _, line := range lines {
 		line = strings.TrimSpace(line)
-		if line == "" || strings.HasPrefix(line, "#") {
-			continue
-		}
-
-		parts := strings.SplitN(line, "=", 2)
-		if len(parts) == 2 {
+		switch {
+		case line == "":
+			if len(params) == 0 && comments.header == nil && len(pending) > 0 {
+				comments.header = pending
+				pending = nil
+			}
+		case strings.HasPrefix(line, "#") || strings.HasPrefix(line, "!"):
+			pending = append(pending, line)
+		default:
+			key, value, ok := splitPropertyLine(line)
+			if !ok {
+				continue
+			}
+			id := unescapePropertyValue(key)
+			if len(pending) > 0 {
+				comments.byKey[id] = append(comments.byKey[id], pending...)
+				pending = nil
+			}
 			params = append(params, api.StringParameter{
 				Pid:   pid,
-				ID:    parts[0],
-				Value: unescapePropertyValue(parts[1]),
+				ID:    id,
+				Value: unescapePropertyValue(value),
 			})
 		}
 	}
+	comments.trailer = pending
 
-	return params, nil
+	return params, comments, nil
+}
+
+// splitPropertyLine splits a line of a properties file at the first '=' that is not escaped
+func splitPropertyLine(line string) (string, string, bool) {
+	for i := 0; i < len(line); i++ {
+		switch line[i] {
+		case '\\':
+			i++
+		case '=':
+			return line[:i], line[i+1:], true
+		}
+	}
+	return "", "", false
 }
 
-func saveBinaryParameterToFile(binaryDir string, param api.BinaryParameter) error {
+func saveBinaryParameterToFile(binaryDir string, param api.BinaryParameter, ext string) error {
 	// Decode base64
 	data, err := base64.StdEncoding.DecodeString(param.Value)
 	if err != nil {
@@ -324,15 +540,9 @@ func saveBinaryParameterToFile(binaryDir string, param api.BinaryParameter) erro
 
 	// Determine file extension from content type
 	log.Debug().Msgf("Processing binary parameter %s with contentType: %s", param.ID, param.ContentType)
-	ext := getFileExtension(param.ContentType)
 	log.Debug().Msgf("Determined file extension: %s", ext)
 
-	// Create filename: {ParamId}.{ext}
-	filename := param.ID
-	if ext != "" && !strings.HasSuffix(strings.ToLower(filename), "."+ext) {
-		filename = fmt.Sprintf("%s.%s", param.ID, ext)
-	}
-
+	filename := binaryFileName(param.ID, ext)
 	filePath := filepath.Join(binaryDir, filename)
 
 	if err := os.WriteFile(filePath, data, 0644); err != nil {
@@ -343,14 +553,66 @@ func saveBinaryParameterToFile(binaryDir string, param api.BinaryParameter) erro
 	return nil
 }
 
-func updateMetadataFile(binaryDir string, paramID string, contentType string) error {
-	// Only store in metadata if contentType has encoding/parameters (contains semicolon)
-	if !strings.Contains(contentType, ";") {
-		return nil
+// binaryFileName returns the name of the file of a binary parameter: {ParamId}.{ext} with the ID
+// escaped by escapeParameterID. An ID that already ends with the extension, e.g. cert.pem, is
+// used without adding it.
+func binaryFileName(paramID string, ext string) string {
+	filename := escapeParameterID(paramID)
+	if ext != "" && !strings.HasSuffix(strings.ToLower(filename), "."+strings.ToLower(ext)) {
+		filename = fmt.Sprintf("%s.%s", filename, ext)
+	}
+	// The metadata file must not be overwritten by a parameter named _metadata
+	if strings.EqualFold(filename, metadataFileName) {
+		filename = "%5F" + filename[1:]
 	}
+	return filename
+}
+
+// escapeParameterID maps a parameter ID to a file name in the Binary directory. Path separators,
+// characters not allowed in file names on Windows, control characters and '%' are percent-encoded,
+// as is a leading dot, so that IDs like .. or ../config cannot point outside the directory.
+func escapeParameterID(paramID string) string {
+	var b strings.Builder
+	for i := 0; i < len(paramID); i++ {
+		c := paramID[i]
+		if c < 0x20 || c == 0x7f || strings.IndexByte(escapedIDChars, c) >= 0 || (i == 0 && c == '.') {
+			fmt.Fprintf(&b, "%%%02X", c)
+		} else {
+			b.WriteByte(c)
+		}
+	}
+	return b.String()
+}
+
+// recordsParameterID reports whether the ID of a binary parameter must be recorded in the
+// metadata, as it cannot be derived from the file name by removing the extension
+func recordsParameterID(paramID string, filename string) bool {
+	return removeFileExtension(filename) != paramID
+}
 
+// binaryParameterID returns the ID of the binary parameter of a file. The extension added by
+// binaryFileName is removed, unless the metadata records the original ID.
+func binaryParameterID(filename string, metadata map[string]string) string {
+	if paramID, ok := metadata[idKeyPrefix+filename]; ok {
+		return paramID
+	}
+	return removeFileExtension(filename)
+}
+
+func updateMetadataFile(binaryDir string, paramID string, contentType string, ext string, decompressedChecksum string) error {
 	metadataPath := filepath.Join(binaryDir, metadataFileName)
 
+	filename := binaryFileName(paramID, ext)
+
+	// Only store in metadata if contentType has encoding/parameters (contains semicolon), the
+	// file is decompressed or the ID cannot be derived from the file name. An existing file is
+	// still updated to drop stale entries.
+	hasEncoding := strings.Contains(contentType, ";")
+	recordID := recordsParameterID(paramID, filename)
+	if !hasEncoding && decompressedChecksum == "" && !recordID && !fileExists(metadataPath) {
+		return nil
+	}
+
 	metadata := make(map[string]string)
 	if fileExists(metadataPath) {
 		data, err := os.ReadFile(metadataPath)
@@ -362,15 +624,20 @@ func updateMetadataFile(binaryDir string, paramID string, contentType string) er
 		}
 	}
 
-	// Determine filename
-	ext := getFileExtension(contentType)
-	filename := paramID
-	if ext != "" && !strings.HasSuffix(strings.ToLower(filename), "."+ext) {
-		filename = fmt.Sprintf("%s.%s", paramID, ext)
-	}
-
 	// Store full content type (with encoding)
-	metadata[filename] = contentType
+	if hasEncoding {
+		metadata[filename] = contentType
+	}
+	if decompressedChecksum != "" {
+		metadata[decompressedKeyPrefix+filename] = decompressedChecksum
+	} else {
+		delete(metadata, decompressedKeyPrefix+filename)
+	}
+	if recordID {
+		metadata[idKeyPrefix+filename] = paramID
+	} else {
+		delete(metadata, idKeyPrefix+filename)
+	}
 
 	data, err := json.MarshalIndent(metadata, "", "  ")
 	if err != nil {
@@ -384,6 +651,67 @@ func updateMetadataFile(binaryDir string, paramID string, contentType string) er
 	return nil
 }
 
+// parameterAudit holds the read-only audit fields of a parameter
+type parameterAudit struct {
+	CreatedBy        string `json:"createdBy,omitempty"`
+	LastModifiedBy   string `json:"lastModifiedBy,omitempty"`
+	CreatedTime      string `json:"createdTime,omitempty"`
+	LastModifiedTime string `json:"lastModifiedTime,omitempty"`
+}
+
+// auditFile is the content of the audit sidecar file, keyed by parameter ID
+type auditFile struct {
+	String map[string]parameterAudit `json:"string,omitempty"`
+	Binary map[string]parameterAudit `json:"binary,omitempty"`
+}
+
+func readAuditFile(pidDir string) (*auditFile, error) {
+	audit := &auditFile{}
+	auditPath := filepath.Join(pidDir, auditFileName)
+	if !fileExists(auditPath) {
+		return audit, nil
+	}
+	data, err := os.ReadFile(auditPath)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read audit file: %w", err)
+	}
+	if err := json.Unmarshal(data, audit); err != nil {
+		return nil, fmt.Errorf("failed to parse audit file: %w", err)
+	}
+	return audit, nil
+}
+
+func updateAuditFile(pidDir string, update func(audit *auditFile)) error {
+	audit, err := readAuditFile(pidDir)
+	if err != nil {
+		return err
+	}
+	update(audit)
+
+	data, err := json.MarshalIndent(audit, "", "  ")
+	if err != nil {
+		return fmt.Errorf("failed to marshal audit file: %w", err)
+	}
+	if err := os.WriteFile(filepath.Join(pidDir, auditFileName), data, 0644); err != nil {
+		return fmt.Errorf("failed to write audit file: %w", err)
+	}
+	return nil
+}
+
+// mergeAuditEntries adds entries to existing, overwriting existing entries only when replace is set
+func mergeAuditEntries(existing, entries map[string]parameterAudit, replace bool) map[string]parameterAudit {
+	if existing == nil {
+		existing = make(map[string]parameterAudit)
+	}
+	for id, entry := range entries {
+		if _, ok := existing[id]; ok && !replace {
+			continue
+		}
+		existing[id] = entry
+	}
+	return existing
+}
+
 func parseContentType(contentType string) (string, string) {
 	// SAP CPI returns simple types like "xml", "json", "txt"
 	// But may also include encoding like "xml; encoding=UTF-8"
@@ -418,17 +746,50 @@ func escapePropertyValue(value string) string {
 	return value
 }
 
+// escapePropertyKey escapes a parameter ID like escapePropertyValue, and additionally the
+// separators '=' and ':' and a leading '#' or '!', which would start a comment line
+func escapePropertyKey(key string) string {
+	key = escapePropertyValue(key)
+	key = strings.ReplaceAll(key, "=", "\\=")
+	key = strings.ReplaceAll(key, ":", "\\:")
+	if strings.HasPrefix(key, "#") || strings.HasPrefix(key, "!") {
+		key = "\\" + key
+	}
+	return key
+}
+
+// unescapePropertyValue reverses escapePropertyValue and escapePropertyKey. Unknown escape
+// sequences are kept as is, e.g. in hand-written Windows paths.
 func unescapePropertyValue(value string) string {
-	value = strings.ReplaceAll(value, "\\n", "\n")
-	value = strings.ReplaceAll(value, "\\r", "\r")
-	value = strings.ReplaceAll(value, "\\\\", "\\")
-	return value
+	if !strings.Contains(value, "\\") {
+		return value
+	}
+	var b strings.Builder
+	for i := 0; i < len(value); i++ {
+		if value[i] != '\\' || i == len(value)-1 {
+			b.WriteByte(value[i])
+			continue
+		}
+		switch next := value[i+1]; next {
+		case 'n':
+			b.WriteByte('\n')
+		case 'r':
+			b.WriteByte('\r')
+		case '\\', '=', ':', '#', '!':
+			b.WriteByte(next)
+		default:
+			b.WriteByte('\\')
+			b.WriteByte(next)
+		}
+		i++
+	}
+	return b.String()
 }
 
-func getFileExtension(contentType string) string {
+func (pd *PartnerDirectory) getFileExtension(contentType string) string {
 	ext, _ := parseContentType(contentType)
 	// Use the extension if it's in our supported list or if it's reasonable
-	if isValidContentType(ext) {
+	if pd.isValidContentType(ext) {
 		return ext
 	}
 	// If not in supported list but looks valid (alphanumeric, 2-5 chars), still use it
@@ -456,8 +817,11 @@ func removeFileExtension(filename string) string {
 	return filename
 }
 
-func isValidContentType(ext string) bool {
-	return supportedContentTypes[strings.ToLower(ext)]
+// isValidContentType checks the built-in supported content types and the ones registered with
+// AddContentTypes
+func (pd *PartnerDirectory) isValidContentType(ext string) bool {
+	ext = strings.ToLower(ext)
+	return supportedContentTypes[ext] || pd.extraContentTypes[ext]
 }
 
 func fileExists(path string) bool {
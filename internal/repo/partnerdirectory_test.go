@@ -1,10 +1,13 @@
 package repo
 
 import (
+	"bytes"
+	"compress/gzip"
 	"encoding/base64"
 	"encoding/json"
 	"os"
 	"path/filepath"
+	"strings"
 	"testing"
 
 	"github.com/engswee/flashpipe/internal/api"
@@ -142,7 +145,7 @@ func TestGetFileExtension_SupportedTypes(t *testing.T) {
 
 	for _, tt := range tests {
 		t.Run(tt.name, func(t *testing.T) {
-			ext := getFileExtension(tt.contentType)
+			ext := NewPartnerDirectory("").getFileExtension(tt.contentType)
 			assert.Equal(t, tt.wantExt, ext)
 		})
 	}
@@ -178,7 +181,7 @@ func TestGetFileExtension_UnsupportedTypes(t *testing.T) {
 
 	for _, tt := range tests {
 		t.Run(tt.name, func(t *testing.T) {
-			ext := getFileExtension(tt.contentType)
+			ext := NewPartnerDirectory("").getFileExtension(tt.contentType)
 			assert.Equal(t, tt.wantExt, ext)
 		})
 	}
@@ -198,7 +201,7 @@ func TestGetFileExtension_CustomValidTypes(t *testing.T) {
 
 	for _, tt := range tests {
 		t.Run(tt.name, func(t *testing.T) {
-			ext := getFileExtension(tt.contentType)
+			ext := NewPartnerDirectory("").getFileExtension(tt.contentType)
 			assert.Equal(t, tt.wantExt, ext)
 		})
 	}
@@ -317,7 +320,7 @@ func TestWriteAndReadStringParameters(t *testing.T) {
 	}
 
 	// Write parameters
-	err = pd.WriteStringParameters(pid, params, true)
+	_, err = pd.WriteStringParameters(pid, params, true)
 	require.NoError(t, err)
 
 	// Read parameters back
@@ -333,6 +336,27 @@ func TestWriteAndReadStringParameters(t *testing.T) {
 	}
 }
 
+func TestReadStringParameters_WithBOM(t *testing.T) {
+	tempDir, err := os.MkdirTemp("", "pd-test-*")
+	require.NoError(t, err)
+	defer os.RemoveAll(tempDir)
+
+	pid := "TestPID"
+	pidDir := filepath.Join(tempDir, pid)
+	require.NoError(t, os.MkdirAll(pidDir, 0755))
+	content := "\uFEFFparam1=value1\r\nparam2=value2\r\n"
+	require.NoError(t, os.WriteFile(filepath.Join(pidDir, "String.properties"), []byte(content), 0644))
+
+	pd := NewPartnerDirectory(tempDir)
+	readParams, err := pd.ReadStringParameters(pid)
+	require.NoError(t, err)
+
+	require.Len(t, readParams, 2)
+	assert.Equal(t, "param1", readParams[0].ID)
+	assert.Equal(t, "value1", readParams[0].Value)
+	assert.Equal(t, "param2", readParams[1].ID)
+}
+
 func TestWriteStringParameters_MergeMode(t *testing.T) {
 	tempDir, err := os.MkdirTemp("", "pd-test-*")
 	require.NoError(t, err)
@@ -346,7 +370,7 @@ func TestWriteStringParameters_MergeMode(t *testing.T) {
 		{Pid: pid, ID: "param1", Value: "value1"},
 		{Pid: pid, ID: "param2", Value: "value2"},
 	}
-	err = pd.WriteStringParameters(pid, initial, true)
+	_, err = pd.WriteStringParameters(pid, initial, true)
 	require.NoError(t, err)
 
 	// Merge new parameters (replace=false)
@@ -354,8 +378,9 @@ func TestWriteStringParameters_MergeMode(t *testing.T) {
 		{Pid: pid, ID: "param3", Value: "value3"},
 		{Pid: pid, ID: "param1", Value: "updated_value1"}, // Should be ignored
 	}
-	err = pd.WriteStringParameters(pid, additional, false)
+	added, err := pd.WriteStringParameters(pid, additional, false)
 	require.NoError(t, err)
+	assert.Equal(t, 1, added)
 
 	// Read back
 	readParams, err := pd.ReadStringParameters(pid)
@@ -391,7 +416,7 @@ func TestWriteAndReadBinaryParameters(t *testing.T) {
 	}
 
 	// Write parameters
-	err = pd.WriteBinaryParameters(pid, params, true)
+	_, err = pd.WriteBinaryParameters(pid, params, true)
 	require.NoError(t, err)
 
 	// Verify files exist
@@ -434,7 +459,7 @@ func TestBinaryParameterWithEncoding(t *testing.T) {
 	}
 
 	// Write parameter
-	err = pd.WriteBinaryParameters(pid, params, true)
+	_, err = pd.WriteBinaryParameters(pid, params, true)
 	require.NoError(t, err)
 
 	// Verify metadata file was created
@@ -477,7 +502,7 @@ func TestBinaryParameterWithoutEncoding_NoMetadata(t *testing.T) {
 	}
 
 	// Write parameter
-	err = pd.WriteBinaryParameters(pid, params, true)
+	_, err = pd.WriteBinaryParameters(pid, params, true)
 	require.NoError(t, err)
 
 	// Verify metadata file was NOT created (since no encoding)
@@ -598,7 +623,7 @@ func TestWriteStringParameters_Sorted(t *testing.T) {
 		{Pid: pid, ID: "mmm", Value: "middle"},
 	}
 
-	err = pd.WriteStringParameters(pid, params, true)
+	_, err = pd.WriteStringParameters(pid, params, true)
 	require.NoError(t, err)
 
 	// Read file content
@@ -701,8 +726,404 @@ func TestIsValidContentType(t *testing.T) {
 
 	for _, tt := range tests {
 		t.Run(tt.ext, func(t *testing.T) {
-			result := isValidContentType(tt.ext)
+			result := NewPartnerDirectory("").isValidContentType(tt.ext)
 			assert.Equal(t, tt.valid, result)
 		})
 	}
 }
+
+func TestAddContentTypes_RoundTrip(t *testing.T) {
+	tempDir, err := os.MkdirTemp("", "pd-test-*")
+	require.NoError(t, err)
+	defer os.RemoveAll(tempDir)
+
+	pd := NewPartnerDirectory(tempDir)
+	pid := "TestPID"
+
+	// Longer than 5 characters, so falls back to bin unless registered
+	assert.Equal(t, defaultBinaryExt, pd.getFileExtension("pkcs12"))
+	assert.False(t, pd.isValidContentType("pkcs12"))
+
+	pd.AddContentTypes([]string{" PKCS12", ".pem", ""})
+	assert.Equal(t, "pkcs12", pd.getFileExtension("pkcs12"))
+	assert.True(t, pd.isValidContentType("pkcs12"))
+	assert.True(t, pd.isValidContentType("PEM"))
+	assert.True(t, pd.isValidContentType("xml"))
+	// Registered content types are not accepted by SAP CPI because of the registration
+	assert.False(t, IsAcceptedContentType("pkcs12"))
+	assert.True(t, IsAcceptedContentType("xml; encoding=UTF-8"))
+
+	encoded := base64.StdEncoding.EncodeToString([]byte{0x30, 0x82, 0x01, 0x0a})
+	params := []api.BinaryParameter{
+		{Pid: pid, ID: "keystore", Value: encoded, ContentType: "pkcs12"},
+		{Pid: pid, ID: "cert", Value: encoded, ContentType: "pem"},
+	}
+
+	written, err := pd.WriteBinaryParameters(pid, params, true)
+	require.NoError(t, err)
+	assert.Equal(t, 2, written)
+	assert.True(t, fileExists(filepath.Join(tempDir, pid, "Binary", "keystore.pkcs12")))
+	assert.True(t, fileExists(filepath.Join(tempDir, pid, "Binary", "cert.pem")))
+
+	readParams, err := pd.ReadBinaryParameters(pid)
+	require.NoError(t, err)
+	require.Len(t, readParams, 2)
+
+	paramMap := make(map[string]api.BinaryParameter)
+	for _, p := range readParams {
+		paramMap[p.ID] = p
+	}
+	assert.Equal(t, "pkcs12", paramMap["keystore"].ContentType)
+	assert.Equal(t, "pem", paramMap["cert"].ContentType)
+	assert.Equal(t, encoded, paramMap["keystore"].Value)
+}
+
+func TestBinaryParameterDecompress(t *testing.T) {
+	tempDir, err := os.MkdirTemp("", "pd-test-*")
+	require.NoError(t, err)
+	defer os.RemoveAll(tempDir)
+
+	pd := NewPartnerDirectory(tempDir)
+	pd.Decompress = true
+	pid := "TestPID"
+
+	var buf bytes.Buffer
+	writer, err := gzip.NewWriterLevel(&buf, gzip.BestCompression)
+	require.NoError(t, err)
+	_, err = writer.Write([]byte("<root>test</root>"))
+	require.NoError(t, err)
+	require.NoError(t, writer.Close())
+	original := buf.Bytes()
+
+	params := []api.BinaryParameter{
+		{Pid: pid, ID: "mapping", Value: base64.StdEncoding.EncodeToString(original), ContentType: "gz"},
+	}
+	_, err = pd.WriteBinaryParameters(pid, params, true)
+	require.NoError(t, err)
+
+	binaryDir := filepath.Join(tempDir, pid, "Binary")
+	content, err := os.ReadFile(filepath.Join(binaryDir, "mapping.gz"))
+	require.NoError(t, err)
+	assert.Equal(t, "<root>test</root>", string(content))
+	assert.FileExists(t, filepath.Join(binaryDir, compressedDirName, "mapping.gz"))
+
+	// Unchanged files are deployed with exactly the original bytes
+	readParams, err := pd.ReadBinaryParameters(pid)
+	require.NoError(t, err)
+	require.Len(t, readParams, 1)
+	assert.Equal(t, base64.StdEncoding.EncodeToString(original), readParams[0].Value)
+
+	// Edited files are compressed again
+	require.NoError(t, os.WriteFile(filepath.Join(binaryDir, "mapping.gz"), []byte("<root>edited</root>"), 0644))
+	readParams, err = pd.ReadBinaryParameters(pid)
+	require.NoError(t, err)
+	require.Len(t, readParams, 1)
+	decoded, err := base64.StdEncoding.DecodeString(readParams[0].Value)
+	require.NoError(t, err)
+	decompressed, err := decompressData("gz", decoded)
+	require.NoError(t, err)
+	assert.Equal(t, "<root>edited</root>", string(decompressed))
+
+	// Rewriting without decompression drops the original bytes
+	pd.Decompress = false
+	_, err = pd.WriteBinaryParameters(pid, params, true)
+	require.NoError(t, err)
+	assert.NoFileExists(t, filepath.Join(binaryDir, compressedDirName, "mapping.gz"))
+	readParams, err = pd.ReadBinaryParameters(pid)
+	require.NoError(t, err)
+	require.Len(t, readParams, 1)
+	assert.Equal(t, base64.StdEncoding.EncodeToString(original), readParams[0].Value)
+}
+
+func TestAuditFieldsRoundTrip(t *testing.T) {
+	tempDir, err := os.MkdirTemp("", "pd-test-*")
+	require.NoError(t, err)
+	defer os.RemoveAll(tempDir)
+
+	pd := NewPartnerDirectory(tempDir)
+	pd.IncludeAudit = true
+	pid := "TestPID"
+
+	stringParams := []api.StringParameter{
+		{Pid: pid, ID: "Param1", Value: "value1", CreatedBy: "jdoe", LastModifiedBy: "asmith", CreatedTime: "t1", LastModifiedTime: "t2"},
+	}
+	binaryParams := []api.BinaryParameter{
+		{Pid: pid, ID: "config", Value: base64.StdEncoding.EncodeToString([]byte("<root/>")), ContentType: "xml", CreatedBy: "jdoe"},
+	}
+	_, err = pd.WriteStringParameters(pid, stringParams, true)
+	require.NoError(t, err)
+	_, err = pd.WriteBinaryParameters(pid, binaryParams, true)
+	require.NoError(t, err)
+	assert.True(t, fileExists(filepath.Join(tempDir, pid, auditFileName)))
+
+	readString, err := pd.ReadStringParameters(pid)
+	require.NoError(t, err)
+	require.Len(t, readString, 1)
+	assert.Equal(t, stringParams[0], readString[0])
+
+	readBinary, err := pd.ReadBinaryParameters(pid)
+	require.NoError(t, err)
+	require.Len(t, readBinary, 1)
+	assert.Equal(t, "jdoe", readBinary[0].CreatedBy)
+
+	// Audit fields are not loaded unless requested
+	pd.IncludeAudit = false
+	readString, err = pd.ReadStringParameters(pid)
+	require.NoError(t, err)
+	require.Len(t, readString, 1)
+	assert.Empty(t, readString[0].CreatedBy)
+}
+
+func TestResetBinaryAudit(t *testing.T) {
+	tempDir := t.TempDir()
+	pd := NewPartnerDirectory(tempDir)
+	pd.IncludeAudit = true
+	pid := "TestPID"
+	encoded := base64.StdEncoding.EncodeToString([]byte("<root/>"))
+
+	// Nothing to reset before the first snapshot
+	require.NoError(t, pd.ResetBinaryAudit(pid))
+	assert.NoFileExists(t, filepath.Join(tempDir, pid, auditFileName))
+
+	_, err := pd.WriteStringParameters(pid, []api.StringParameter{{Pid: pid, ID: "Param1", Value: "value1", CreatedBy: "jdoe"}}, true)
+	require.NoError(t, err)
+	_, err = pd.WriteBinaryParameters(pid, []api.BinaryParameter{{Pid: pid, ID: "old", Value: encoded, ContentType: "xml", CreatedBy: "jdoe"}}, true)
+	require.NoError(t, err)
+
+	// A full snapshot drops the audit entries of binary parameters that no longer exist
+	require.NoError(t, pd.ResetBinaryAudit(pid))
+	_, err = pd.WriteBinaryParameters(pid, []api.BinaryParameter{{Pid: pid, ID: "new", Value: encoded, ContentType: "xml", CreatedBy: "asmith"}}, true)
+	require.NoError(t, err)
+
+	audit, err := readAuditFile(filepath.Join(tempDir, pid))
+	require.NoError(t, err)
+	assert.Equal(t, map[string]parameterAudit{"new": {CreatedBy: "asmith"}}, audit.Binary)
+	assert.Equal(t, "jdoe", audit.String["Param1"].CreatedBy)
+}
+
+func TestBinaryParameters_IDWithDots(t *testing.T) {
+	tempDir := t.TempDir()
+	pd := NewPartnerDirectory(tempDir)
+	pd.AddContentTypes([]string{"pem"})
+	pid := "TestPID"
+	encoded := base64.StdEncoding.EncodeToString([]byte("content"))
+
+	params := []api.BinaryParameter{
+		{Pid: pid, ID: "cert.pem", Value: encoded, ContentType: "pem"},
+		{Pid: pid, ID: "mapping.v2", Value: encoded, ContentType: "xml"},
+		{Pid: pid, ID: "config", Value: encoded, ContentType: "xml"},
+	}
+	written, err := pd.WriteBinaryParameters(pid, params, true)
+	require.NoError(t, err)
+	assert.Equal(t, 3, written)
+
+	binaryDir := filepath.Join(tempDir, pid, "Binary")
+	for _, filename := range []string{"cert.pem", "mapping.v2.xml", "config.xml"} {
+		assert.FileExists(t, filepath.Join(binaryDir, filename))
+	}
+
+	readParams, err := pd.ReadBinaryParameters(pid)
+	require.NoError(t, err)
+	ids := make(map[string]string)
+	for _, p := range readParams {
+		ids[p.ID] = p.ContentType
+	}
+	assert.Equal(t, map[string]string{"cert.pem": "pem", "mapping.v2": "xml", "config": "xml"}, ids)
+
+	// Without replace, the existing files are found by their file names
+	written, err = pd.WriteBinaryParameters(pid, params, false)
+	require.NoError(t, err)
+	assert.Equal(t, 0, written)
+}
+
+func TestBinaryParameters_IDWithDotsReplacedByIDWithoutExtension(t *testing.T) {
+	tempDir := t.TempDir()
+	pd := NewPartnerDirectory(tempDir)
+	pid := "TestPID"
+	encoded := base64.StdEncoding.EncodeToString([]byte("content"))
+
+	_, err := pd.WriteBinaryParameters(pid, []api.BinaryParameter{{Pid: pid, ID: "config.xml", Value: encoded, ContentType: "xml"}}, true)
+	require.NoError(t, err)
+
+	// A parameter without the extension in its ID written to the same file drops the recorded ID
+	_, err = pd.WriteBinaryParameters(pid, []api.BinaryParameter{{Pid: pid, ID: "config", Value: encoded, ContentType: "xml"}}, true)
+	require.NoError(t, err)
+
+	readParams, err := pd.ReadBinaryParameters(pid)
+	require.NoError(t, err)
+	require.Len(t, readParams, 1)
+	assert.Equal(t, "config", readParams[0].ID)
+}
+
+func TestBinaryParameterID(t *testing.T) {
+	metadata := map[string]string{idKeyPrefix + "cert.pem": "cert.pem"}
+	assert.Equal(t, "cert.pem", binaryParameterID("cert.pem", metadata))
+	assert.Equal(t, "key", binaryParameterID("key.pem", metadata))
+	assert.Equal(t, "mapping.v2", binaryParameterID("mapping.v2.xml", metadata))
+	assert.Equal(t, "config", binaryParameterID("config.xml", nil))
+}
+
+func TestBinaryParameters_IDWithPathCharacters(t *testing.T) {
+	tempDir := t.TempDir()
+	resourcesPath := filepath.Join(tempDir, "partner-directory")
+	pd := NewPartnerDirectory(resourcesPath)
+	pid := "TestPID"
+	encoded := base64.StdEncoding.EncodeToString([]byte("content"))
+
+	ids := []string{"../../escaped", "..", "mappings/order", `mappings\invoice`, "100%:done", "_metadata", ".hidden"}
+	var params []api.BinaryParameter
+	for _, id := range ids {
+		params = append(params, api.BinaryParameter{Pid: pid, ID: id, Value: encoded, ContentType: "json"})
+	}
+	written, err := pd.WriteBinaryParameters(pid, params, true)
+	require.NoError(t, err)
+	assert.Equal(t, len(ids), written)
+
+	// All files are written directly to the Binary directory
+	binaryDir := filepath.Join(resourcesPath, pid, "Binary")
+	entries, err := os.ReadDir(binaryDir)
+	require.NoError(t, err)
+	var filenames []string
+	for _, entry := range entries {
+		assert.False(t, entry.IsDir(), entry.Name())
+		filenames = append(filenames, entry.Name())
+	}
+	assert.ElementsMatch(t, []string{"%2E.%2F..%2Fescaped.json", "%2E..json", "mappings%2Forder.json", "mappings%5Cinvoice.json",
+		"100%25%3Adone.json", "%5Fmetadata.json", "%2Ehidden.json", "_metadata.json"}, filenames)
+	assert.NoFileExists(t, filepath.Join(tempDir, "escaped.json"))
+
+	readParams, err := pd.ReadBinaryParameters(pid)
+	require.NoError(t, err)
+	var readIDs []string
+	for _, p := range readParams {
+		readIDs = append(readIDs, p.ID)
+		assert.Equal(t, encoded, p.Value)
+	}
+	assert.ElementsMatch(t, ids, readIDs)
+}
+
+func TestEscapeParameterID(t *testing.T) {
+	tests := []struct {
+		id   string
+		want string
+	}{
+		{"config", "config"},
+		{"mapping.v2", "mapping.v2"},
+		{"..", "%2E."},
+		{"../config", "%2E.%2Fconfig"},
+		{"a/b", "a%2Fb"},
+		{`a\b`, "a%5Cb"},
+		{"a%2Fb", "a%252Fb"},
+		{"line\nbreak", "line%0Abreak"},
+	}
+	for _, tt := range tests {
+		assert.Equal(t, tt.want, escapeParameterID(tt.id), tt.id)
+	}
+}
+
+func TestStringParameters_SpecialCharacterKeys(t *testing.T) {
+	tempDir := t.TempDir()
+	pd := NewPartnerDirectory(tempDir)
+	pid := "TestPID"
+
+	params := []api.StringParameter{
+		{Pid: pid, ID: "key=with=equals", Value: "a=b"},
+		{Pid: pid, ID: "host:port", Value: "localhost:8080"},
+		{Pid: pid, ID: "#notAComment", Value: "hash"},
+		{Pid: pid, ID: "!notAComment", Value: "bang"},
+		{Pid: pid, ID: `back\slash`, Value: `C:\temp\new`},
+		{Pid: pid, ID: "plain", Value: `literal \n`},
+	}
+	_, err := pd.WriteStringParameters(pid, params, true)
+	require.NoError(t, err)
+
+	content, err := os.ReadFile(filepath.Join(tempDir, pid, "String.properties"))
+	require.NoError(t, err)
+	assert.Contains(t, string(content), "key\\=with\\=equals=a=b\n")
+	assert.Contains(t, string(content), "host\\:port=localhost:8080\n")
+	assert.Contains(t, string(content), "\\#notAComment=hash\n")
+	assert.Contains(t, string(content), "\\!notAComment=bang\n")
+
+	readParams, err := pd.ReadStringParameters(pid)
+	require.NoError(t, err)
+	assert.ElementsMatch(t, params, readParams)
+
+	// Merging keeps the existing keys and does not duplicate them
+	added, err := pd.WriteStringParameters(pid, []api.StringParameter{
+		{Pid: pid, ID: "key=with=equals", Value: "changed"},
+		{Pid: pid, ID: "new:key", Value: "new"},
+	}, false)
+	require.NoError(t, err)
+	assert.Equal(t, 1, added)
+
+	readParams, err = pd.ReadStringParameters(pid)
+	require.NoError(t, err)
+	assert.ElementsMatch(t, append(params, api.StringParameter{Pid: pid, ID: "new:key", Value: "new"}), readParams)
+}
+
+func TestReadPropertiesFile_Comments(t *testing.T) {
+	filePath := filepath.Join(t.TempDir(), "String.properties")
+	err := os.WriteFile(filePath, []byte("# comment\n! comment\nkey=value\nwindows=C:\\temp\n"), 0644)
+	require.NoError(t, err)
+
+	params, err := readPropertiesFile(filePath, "PID")
+	require.NoError(t, err)
+	assert.Equal(t, []api.StringParameter{
+		{Pid: "PID", ID: "key", Value: "value"},
+		{Pid: "PID", ID: "windows", Value: `C:\temp`},
+	}, params)
+}
+
+func TestStringParameters_PreserveComments(t *testing.T) {
+	tempDir := t.TempDir()
+	pd := NewPartnerDirectory(tempDir)
+	pid := "TestPID"
+	propertiesFile := filepath.Join(tempDir, pid, "String.properties")
+	require.NoError(t, os.MkdirAll(filepath.Dir(propertiesFile), 0755))
+
+	err := os.WriteFile(propertiesFile, []byte(`# Parameters of the order system
+# Owner: integration team
+
+# Rotated every 90 days
+! see the runbook
+API_KEY=old
+
+TIMEOUT=30
+# Endpoint of production
+ENDPOINT=https://old.example.com
+# Removed parameter
+REMOVED=value
+# end of file
+`), 0644)
+	require.NoError(t, err)
+
+	_, err = pd.WriteStringParameters(pid, []api.StringParameter{
+		{Pid: pid, ID: "TIMEOUT", Value: "60"},
+		{Pid: pid, ID: "ENDPOINT", Value: "https://new.example.com"},
+		{Pid: pid, ID: "API_KEY", Value: "new"},
+	}, true)
+	require.NoError(t, err)
+
+	content, err := os.ReadFile(propertiesFile)
+	require.NoError(t, err)
+	assert.Equal(t, `# Parameters of the order system
+# Owner: integration team
+
+# Rotated every 90 days
+! see the runbook
+API_KEY=new
+# Endpoint of production
+ENDPOINT=https://new.example.com
+TIMEOUT=60
+# end of file
+`, string(content))
+
+	// Merging new values keeps the comments as well
+	added, err := pd.WriteStringParameters(pid, []api.StringParameter{{Pid: pid, ID: "NEW", Value: "1"}}, false)
+	require.NoError(t, err)
+	assert.Equal(t, 1, added)
+
+	merged, err := os.ReadFile(propertiesFile)
+	require.NoError(t, err)
+	assert.Equal(t, strings.Replace(string(content), "TIMEOUT=60\n", "NEW=1\nTIMEOUT=60\n", 1), string(merged))
+}
@@ -15,6 +15,14 @@ func ExtractDelimitedValues(input string, delimiter string) []string {
 	}
 }
 
+// utf8BOM is the byte order mark some Windows editors prepend to UTF-8 files
+const utf8BOM = "\uFEFF"
+
+// TrimBOM removes a leading UTF-8 byte order mark from the content
+func TrimBOM(content string) string {
+	return strings.TrimPrefix(content, utf8BOM)
+}
+
 func TrimSlice(input []string) []string {
 	for i, s := range input {
 		input[i] = strings.TrimSpace(s)
@@ -53,3 +61,13 @@ func FilterIDs(id string, includedIds []string, excludedIds []string) bool {
 	}
 	return false
 }
+
+// Mask hides all but the first and last few characters of a value such as a client or user ID,
+// so that it can be recognised in logs without being disclosed. Short values are hidden fully.
+func Mask(value string) string {
+	runes := []rune(value)
+	if len(runes) < 12 {
+		return "****"
+	}
+	return string(runes[:3]) + "****" + string(runes[len(runes)-3:])
+}
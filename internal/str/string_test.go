@@ -26,3 +26,15 @@ func TestExtractDelimitedValues_NoEntry(t *testing.T) {
 
 	assert.Equal(t, 0, len(output), "Expected size = ")
 }
+
+func TestTrimBOM(t *testing.T) {
+	assert.Equal(t, "key=value", TrimBOM("\uFEFFkey=value"), "Expected BOM to be removed")
+	assert.Equal(t, "key=value", TrimBOM("key=value"), "Expected content without BOM to be unchanged")
+	assert.Equal(t, "key=\uFEFF", TrimBOM("key=\uFEFF"), "Expected only a leading BOM to be removed")
+}
+
+func TestMask(t *testing.T) {
+	assert.Equal(t, "sb-****890", Mask("sb-1234567890"), "Expected first and last characters")
+	assert.Equal(t, "****", Mask("user@sap.de"), "Expected short value to be hidden fully")
+	assert.Equal(t, "****", Mask(""), "Expected empty value to be hidden")
+}
@@ -112,9 +112,13 @@ func (s *Synchroniser) ArtifactsToGit(packageId string, workDir string, artifact
 	if err != nil {
 		return err
 	}
+	return s.ListedArtifactsToGit(packageId, artifacts, workDir, artifactsDir, includedIds, excludedIds, draftHandling, dirNamingType, scriptCollectionMap)
+}
 
+// ListedArtifactsToGit is like ArtifactsToGit for the artifacts of the package already retrieved from the tenant
+func (s *Synchroniser) ListedArtifactsToGit(packageId string, artifacts []*api.ArtifactDetails, workDir string, artifactsDir string, includedIds []string, excludedIds []string, draftHandling string, dirNamingType string, scriptCollectionMap []string) error {
 	// Create temp directories in working dir
-	err = os.MkdirAll(workDir+"/download", os.ModePerm)
+	err := os.MkdirAll(workDir+"/download", os.ModePerm)
 	if err != nil {
 		return errors.Wrap(err, 0)
 	}
@@ -150,9 +154,12 @@ func (s *Synchroniser) ArtifactsToGit(packageId string, workDir string, artifact
 
 		// TODO - override directory name using key value pair - to cater for syncing artifact from different environment
 		var directoryName string
-		if dirNamingType == "NAME" {
+		switch dirNamingType {
+		case "NAME":
 			directoryName = artifact.Name
-		} else {
+		case "COMBINED":
+			directoryName = CombinedDirName(artifact.Id, artifact.Name)
+		default:
 			directoryName = artifact.Id
 		}
 		// Unzip artifact contents
@@ -213,6 +220,35 @@ func (s *Synchroniser) ArtifactsToGit(packageId string, workDir string, artifact
 	return nil
 }
 
+// combinedDirSeparator separates the artifact ID from the display name in COMBINED directory names
+const combinedDirSeparator = "__"
+
+// CombinedDirName returns a directory name of the form <id>__<name>. The name is
+// sanitized so that it never contains the separator, keeping the ID recoverable.
+func CombinedDirName(id string, name string) string {
+	var sb strings.Builder
+	for _, r := range name {
+		if (r >= 'a' && r <= 'z') || (r >= 'A' && r <= 'Z') || (r >= '0' && r <= '9') || r == '-' || r == '.' {
+			sb.WriteRune(r)
+		} else {
+			sb.WriteRune('_')
+		}
+	}
+	sanitized := sb.String()
+	for strings.Contains(sanitized, combinedDirSeparator) {
+		sanitized = strings.ReplaceAll(sanitized, combinedDirSeparator, "_")
+	}
+	return id + combinedDirSeparator + strings.Trim(sanitized, "_")
+}
+
+// ArtifactIDFromCombinedDirName extracts the artifact ID from a directory name created by CombinedDirName
+func ArtifactIDFromCombinedDirName(dirName string) string {
+	if idx := strings.LastIndex(dirName, combinedDirSeparator); idx > 0 {
+		return dirName[:idx]
+	}
+	return dirName
+}
+
 func filterArtifacts(artifacts []*api.ArtifactDetails, includedIds []string, excludedIds []string) ([]*api.ArtifactDetails, error) {
 	var output []*api.ArtifactDetails
 
@@ -63,3 +63,23 @@ func TestFilterExcludeInvalidID(t *testing.T) {
 
 	assert.Equal(t, "Artifact DummyIFlow2 in --ids-exclude does not exist", err.Error(), "Incorrect error message")
 }
+
+func TestCombinedDirName(t *testing.T) {
+	tests := []struct {
+		id, name, expected string
+	}{
+		{"Order_Create", "Order Create (S/4)", "Order_Create__Order_Create_S_4"},
+		{"Flow", "  Leading and trailing  ", "Flow__Leading_and_trailing"},
+		{"Flow", "a__b", "Flow__a_b"},
+		{"Flow", "v1.0-final", "Flow__v1.0-final"},
+		{"Flow", "", "Flow__"},
+		{"Flow", "???", "Flow__"},
+		{"My__Flow", "My Flow", "My__Flow__My_Flow"},
+		{"My__Flow", "", "My__Flow__"},
+	}
+	for _, tt := range tests {
+		dirName := CombinedDirName(tt.id, tt.name)
+		assert.Equal(t, tt.expected, dirName)
+		assert.Equal(t, tt.id, ArtifactIDFromCombinedDirName(dirName), "ID should round-trip for %v", dirName)
+	}
+}